@@ -19,10 +19,21 @@ func main() {
 	cliApp := cli.NewCLI()
 	cliApp.SetVersionInfo(Version, BuildTime, GitCommit)
 	cliApp.AddHelpCommand()
+	cliApp.AddJobsCommand()
+	cliApp.AddSelfTestCommand()
+	cliApp.AddGenerateCommand()
+	cliApp.AddEnrichJSONCommand()
+	cliApp.AddDiffCommand()
+	cliApp.AddMergeCommand()
+	cliApp.AddAggregateCommand()
+	cliApp.AddRetryCommand()
+	cliApp.AddSegmentCommand()
+	cliApp.AddRegressCommand()
+	cliApp.AddHistoryCommand()
 
 	// Execute the CLI application
 	if err := cliApp.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, cliApp.FormatError(err))
 		os.Exit(1)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,434 @@
+package aggregate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"csv-h3-tool/internal/csvutil"
+	"csv-h3-tool/internal/extsort"
+)
+
+// Options configures an aggregation of an H3-indexed CSV file
+type Options struct {
+	H3Column  string // H3 index column to group by (default "h3_index")
+	Pivot     string // Column whose values become matrix columns (empty = counts only)
+	Delimiter rune   // Field delimiter (default ',')
+	MaxGroups int    // (cell, value) counts held in memory before spilling to disk (0 = no limit)
+	TempDir   string // Directory for spill files (default: system temp directory)
+	K         int    // k-anonymity: drop cells with fewer than K contributing rows (0 = keep all)
+
+	// Differential privacy: Laplace noise with scale 1/Epsilon is added to every count
+	// and cells whose noisy total is below Threshold are suppressed (Epsilon 0 = exact
+	// counts). Seed makes the noise reproducible and must only be set in tests.
+	Epsilon   float64
+	Threshold float64
+	Seed      int64
+}
+
+// Table holds row counts per H3 cell and, when pivoting, per cell and pivot value.
+// Counts beyond MaxGroups are spilled to compressed temp files and merged into a
+// single sorted spill file; Close removes it.
+type Table struct {
+	H3Column    string
+	Pivot       string
+	Rows        int // Data rows read
+	SkippedRows int // Rows without an H3 index (or pivot value when pivoting)
+	Spills      int // Times the in-memory counts were spilled to disk
+	Suppressed  int // Cells withheld from the last written output by the privacy threshold
+	BelowK      int // Cells withheld from the last written output for having fewer than K rows
+
+	counts map[string]map[string]int // Cell -> pivot value ("" without pivot) -> count
+	groups int                       // (cell, value) pairs in counts
+	values map[string]struct{}       // Distinct pivot values
+	cells  int                       // Distinct cells, known once counting is done
+
+	maxGroups int
+	k         int
+	sorter    *extsort.Sorter
+	merged    string // Spill file with the merged counts, sorted by cell
+	noise     *noise // Differential privacy noise, nil for exact counts
+}
+
+// Count reads an H3-indexed CSV file with a header row and counts rows per cell,
+// and per cell and pivot value when Pivot is set. Memory grows with the number of
+// non-empty (cell, value) pairs, not with the number of rows, and is capped by
+// MaxGroups. Call Close to remove any spill files.
+func Count(path string, opts Options) (table *Table, err error) {
+	if opts.H3Column == "" {
+		opts.H3Column = "h3_index"
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csvutil.NewReader(file, opts.Delimiter)
+	reader.ReuseRecord = true
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+	h3Index := csvutil.FindColumn(headers, opts.H3Column)
+	if h3Index < 0 {
+		return nil, fmt.Errorf("H3 column %q not found in %s", opts.H3Column, path)
+	}
+	pivotIndex := -1
+	if opts.Pivot != "" {
+		if pivotIndex = csvutil.FindColumn(headers, opts.Pivot); pivotIndex < 0 {
+			return nil, fmt.Errorf("pivot column %q not found in %s", opts.Pivot, path)
+		}
+	}
+
+	table = &Table{
+		H3Column:  opts.H3Column,
+		Pivot:     opts.Pivot,
+		counts:    make(map[string]map[string]int),
+		values:    make(map[string]struct{}),
+		maxGroups: opts.MaxGroups,
+		k:         opts.K,
+	}
+	if opts.K < 0 {
+		return nil, fmt.Errorf("k-anonymity k cannot be negative, got %d", opts.K)
+	}
+	if opts.Epsilon != 0 {
+		if table.noise, err = newNoise(opts.Epsilon, opts.Threshold, opts.Seed); err != nil {
+			return nil, err
+		}
+	}
+	if opts.MaxGroups > 0 {
+		if table.sorter, err = extsort.NewSorter(opts.MaxGroups, opts.TempDir); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err != nil {
+			table.Close()
+			table = nil
+		}
+	}()
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s row %d: %w", path, row, err)
+		}
+		table.Rows++
+
+		cell := strings.TrimSpace(csvutil.Field(record, h3Index))
+		if cell == "" {
+			table.SkippedRows++
+			continue
+		}
+		value := ""
+		if pivotIndex >= 0 {
+			if value = strings.TrimSpace(csvutil.Field(record, pivotIndex)); value == "" {
+				table.SkippedRows++
+				continue
+			}
+			table.values[value] = struct{}{}
+		}
+		if err := table.add(cell, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := table.finish(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// add counts one row, spilling the in-memory counts once they reach the group limit
+func (t *Table) add(cell, value string) error {
+	counts := t.counts[cell]
+	if counts == nil {
+		counts = make(map[string]int)
+		t.counts[cell] = counts
+	}
+	if _, seen := counts[value]; !seen {
+		t.groups++
+	}
+	counts[value]++
+
+	if t.maxGroups > 0 && t.groups >= t.maxGroups {
+		t.Spills++
+		return t.spill()
+	}
+	return nil
+}
+
+// spill hands the in-memory counts to the external sorter and clears them
+func (t *Table) spill() error {
+	for cell, counts := range t.counts {
+		for value, count := range counts {
+			if err := t.sorter.Add(cell, []string{cell, value, strconv.Itoa(count)}); err != nil {
+				return err
+			}
+		}
+	}
+	t.counts = make(map[string]map[string]int)
+	t.groups = 0
+	return nil
+}
+
+// finish merges spilled counts, summing the partial counts of each (cell, value)
+// into one spill file sorted by cell, or keeps everything in memory if nothing spilled
+func (t *Table) finish() error {
+	if t.Spills == 0 {
+		t.cells = len(t.counts)
+		if t.sorter != nil {
+			t.sorter.Cleanup()
+		}
+		return nil
+	}
+	if err := t.spill(); err != nil {
+		return err
+	}
+
+	writer, err := extsort.CreateSpill(t.sorter.TempDir())
+	if err != nil {
+		return err
+	}
+	t.merged = writer.Name()
+
+	current := ""
+	counts := make(map[string]int)
+	flush := func() error {
+		for value, count := range counts {
+			if err := writer.Write([]string{current, value, strconv.Itoa(count)}); err != nil {
+				return err
+			}
+		}
+		if len(counts) > 0 {
+			t.cells++
+		}
+		counts = make(map[string]int)
+		return nil
+	}
+	err = t.sorter.Merge(func(row []string) error {
+		if row[0] != current {
+			if err := flush(); err != nil {
+				return err
+			}
+			current = row[0]
+		}
+		count, err := strconv.Atoi(row[2])
+		if err != nil {
+			return fmt.Errorf("corrupt aggregate spill file: %w", err)
+		}
+		counts[row[1]] += count
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Close removes the table's spill files
+func (t *Table) Close() {
+	if t.sorter != nil {
+		t.sorter.Cleanup()
+	}
+	if t.merged != "" {
+		extsort.RemoveSpill(t.merged)
+		t.merged = ""
+	}
+}
+
+// CellCount returns the number of distinct counted cells
+func (t *Table) CellCount() int {
+	return t.cells
+}
+
+// eachCell calls fn with every cell and its counts per pivot value, in cell order
+func (t *Table) eachCell(fn func(cell string, counts map[string]int) error) error {
+	if t.merged == "" {
+		cells := make([]string, 0, len(t.counts))
+		for cell := range t.counts {
+			cells = append(cells, cell)
+		}
+		sort.Strings(cells)
+		for _, cell := range cells {
+			if err := fn(cell, t.counts[cell]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	reader, err := extsort.OpenSpill(t.merged)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	current := ""
+	counts := make(map[string]int)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read aggregate spill file: %w", err)
+		}
+		if row[0] != current && len(counts) > 0 {
+			if err := fn(current, counts); err != nil {
+				return err
+			}
+			counts = make(map[string]int)
+		}
+		current = row[0]
+		count, err := strconv.Atoi(row[2])
+		if err != nil {
+			return fmt.Errorf("corrupt aggregate spill file: %w", err)
+		}
+		counts[row[1]] = count
+	}
+	if len(counts) > 0 {
+		return fn(current, counts)
+	}
+	return nil
+}
+
+// PivotValues returns the distinct pivot values in sorted order (ISO dates and
+// zero-padded time buckets sort chronologically)
+func (t *Table) PivotValues() []string {
+	values := make([]string, 0, len(t.values))
+	for value := range t.values {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// WriteCounts writes one row per cell with its row count
+func (t *Table) WriteCounts(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{t.H3Column, "count"}); err != nil {
+		return fmt.Errorf("failed to write counts: %w", err)
+	}
+	t.Suppressed, t.BelowK = 0, 0
+	err := t.eachCell(func(cell string, counts map[string]int) error {
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+		if total < t.k {
+			t.BelowK++
+			return nil
+		}
+		if t.noise != nil {
+			if total = t.noise.count(cell, "", total); t.noise.suppressed(total) {
+				t.Suppressed++
+				return nil
+			}
+		}
+		if err := writer.Write([]string{cell, strconv.Itoa(total)}); err != nil {
+			return fmt.Errorf("failed to write counts: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WritePivot writes a cells × pivot values matrix of counts for the given pivot values,
+// with zeros for empty combinations. Writing the values in chunks produces several
+// narrower matrices sharing the same cell rows.
+func (t *Table) WritePivot(w io.Writer, values []string) error {
+	writer := csv.NewWriter(w)
+	row := make([]string, len(values)+1)
+	row[0] = t.H3Column
+	copy(row[1:], values)
+	if err := writer.Write(row); err != nil {
+		return fmt.Errorf("failed to write pivot matrix: %w", err)
+	}
+
+	var all []string
+	if t.noise != nil {
+		all = t.PivotValues()
+	}
+	t.Suppressed, t.BelowK = 0, 0
+	err := t.eachCell(func(cell string, counts map[string]int) error {
+		if t.k > 0 {
+			total := 0
+			for _, count := range counts {
+				total += count
+			}
+			if total < t.k {
+				t.BelowK++
+				return nil
+			}
+		}
+		if t.noise != nil {
+			counts = t.noisyCounts(cell, counts, all)
+			if counts == nil {
+				t.Suppressed++
+				return nil
+			}
+		}
+		row[0] = cell
+		for i, value := range values {
+			row[i+1] = strconv.Itoa(counts[value])
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write pivot matrix: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// noisyCounts returns the noisy counts of a cell for every pivot value, including
+// zeros, or nil when the cell's noisy total falls below the suppression threshold.
+// The decision covers all values so every matrix chunk keeps the same cells.
+func (t *Table) noisyCounts(cell string, counts map[string]int, values []string) map[string]int {
+	noisy := make(map[string]int, len(values))
+	total := 0
+	for _, value := range values {
+		noisy[value] = t.noise.count(cell, value, counts[value])
+		total += noisy[value]
+	}
+	if t.noise.suppressed(total) {
+		return nil
+	}
+	return noisy
+}
+
+// Chunks splits values into consecutive chunks of at most size values (size < 1 = one chunk)
+func Chunks(values []string, size int) [][]string {
+	if size < 1 || len(values) <= size {
+		return [][]string{values}
+	}
+	var chunks [][]string
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+		chunks = append(chunks, values[start:end])
+	}
+	return chunks
+}
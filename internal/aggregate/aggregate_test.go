@@ -0,0 +1,266 @@
+package aggregate
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testCSV = `id,h3_index,time_bucket
+1,882a107289fffff,2024-01-02
+2,882a107289fffff,2024-01-01
+3,88195da49bfffff,2024-01-01
+4,,2024-01-01
+5,882a107289fffff,2024-01-01
+6,88195da49bfffff,
+`
+
+func writeInput(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.csv")
+	if err := os.WriteFile(path, []byte(testCSV), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	return path
+}
+
+func TestCount(t *testing.T) {
+	table, err := Count(writeInput(t), Options{})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if table.Rows != 6 || table.SkippedRows != 1 {
+		t.Errorf("Expected 6 rows and 1 skipped, got %d and %d", table.Rows, table.SkippedRows)
+	}
+
+	var output bytes.Buffer
+	if err := table.WriteCounts(&output); err != nil {
+		t.Fatalf("WriteCounts() error = %v", err)
+	}
+	expected := "h3_index,count\n88195da49bfffff,2\n882a107289fffff,3\n"
+	if output.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
+
+func TestCount_Pivot(t *testing.T) {
+	table, err := Count(writeInput(t), Options{Pivot: "time_bucket"})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if table.SkippedRows != 2 {
+		t.Errorf("Expected 2 skipped rows, got %d", table.SkippedRows)
+	}
+	values := table.PivotValues()
+	if !reflect.DeepEqual(values, []string{"2024-01-01", "2024-01-02"}) {
+		t.Fatalf("Unexpected pivot values: %v", values)
+	}
+
+	var output bytes.Buffer
+	if err := table.WritePivot(&output, values); err != nil {
+		t.Fatalf("WritePivot() error = %v", err)
+	}
+	expected := "h3_index,2024-01-01,2024-01-02\n88195da49bfffff,1,0\n882a107289fffff,2,1\n"
+	if output.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, output.String())
+	}
+
+	output.Reset()
+	if err := table.WritePivot(&output, values[1:]); err != nil {
+		t.Fatalf("WritePivot() error = %v", err)
+	}
+	expected = "h3_index,2024-01-02\n88195da49bfffff,0\n882a107289fffff,1\n"
+	if output.String() != expected {
+		t.Errorf("Expected chunk:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
+
+func TestCount_Spilled(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeInput(t)
+	for _, pivot := range []string{"", "time_bucket"} {
+		inMemory, err := Count(input, Options{Pivot: pivot})
+		if err != nil {
+			t.Fatalf("Count() error = %v", err)
+		}
+		spilled, err := Count(input, Options{Pivot: pivot, MaxGroups: 1, TempDir: tempDir})
+		if err != nil {
+			t.Fatalf("Count() with spilling error = %v", err)
+		}
+		if spilled.Spills == 0 {
+			t.Errorf("Expected counts to spill with pivot %q", pivot)
+		}
+		if spilled.CellCount() != inMemory.CellCount() {
+			t.Errorf("Expected %d cells, got %d", inMemory.CellCount(), spilled.CellCount())
+		}
+
+		var expected, got bytes.Buffer
+		if pivot == "" {
+			inMemory.WriteCounts(&expected)
+			spilled.WriteCounts(&got)
+		} else {
+			inMemory.WritePivot(&expected, inMemory.PivotValues())
+			spilled.WritePivot(&got, spilled.PivotValues())
+		}
+		if got.String() != expected.String() {
+			t.Errorf("Spilled output differs:\n%s\nwant:\n%s", got.String(), expected.String())
+		}
+
+		spilled.Close()
+		if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+			t.Errorf("Expected spill files to be removed, found %d", len(entries))
+		}
+	}
+}
+
+func TestCount_Errors(t *testing.T) {
+	input := writeInput(t)
+	if _, err := Count(input, Options{H3Column: "cell"}); err == nil {
+		t.Error("Count() with a missing H3 column should error")
+	}
+	if _, err := Count(input, Options{Pivot: "hour"}); err == nil {
+		t.Error("Count() with a missing pivot column should error")
+	}
+	if _, err := Count(filepath.Join(t.TempDir(), "none.csv"), Options{}); err == nil {
+		t.Error("Count() with a missing file should error")
+	}
+}
+
+func TestChunks(t *testing.T) {
+	values := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		size     int
+		expected [][]string
+	}{
+		{0, [][]string{values}},
+		{5, [][]string{values}},
+		{2, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+	}
+	for _, tt := range tests {
+		if got := Chunks(values, tt.size); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Chunks(%d) = %v, want %v", tt.size, got, tt.expected)
+		}
+	}
+}
+
+func TestNoise_Laplace(t *testing.T) {
+	n, err := newNoise(0.5, 0, 42)
+	if err != nil {
+		t.Fatalf("newNoise() error = %v", err)
+	}
+	// Laplace(0, b) has mean 0 and mean absolute deviation b
+	sum, abs := 0.0, 0.0
+	const draws = 20000
+	for i := 0; i < draws; i++ {
+		x := n.laplace(strconv.Itoa(i), "")
+		sum += x
+		abs += math.Abs(x)
+	}
+	if mean := sum / draws; math.Abs(mean) > 0.1 {
+		t.Errorf("Expected noise mean near 0, got %f", mean)
+	}
+	if mad := abs / draws; math.Abs(mad-2) > 0.1 {
+		t.Errorf("Expected mean absolute noise near 2 (scale 1/0.5), got %f", mad)
+	}
+	if n.laplace("cell", "value") != n.laplace("cell", "value") {
+		t.Error("Expected the same noise for the same count")
+	}
+
+	for _, epsilon := range []float64{-1, math.Inf(1)} {
+		if _, err := newNoise(epsilon, 0, 0); err == nil {
+			t.Errorf("Expected error for epsilon %g", epsilon)
+		}
+	}
+	if _, err := newNoise(1, -1, 0); err == nil {
+		t.Error("Expected error for a negative threshold")
+	}
+}
+
+func TestCount_DifferentialPrivacy(t *testing.T) {
+	table, err := Count(writeInput(t), Options{Epsilon: 1, Threshold: 0, Seed: 7})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	var first, second bytes.Buffer
+	if err := table.WriteCounts(&first); err != nil {
+		t.Fatalf("WriteCounts() error = %v", err)
+	}
+	table.WriteCounts(&second)
+	if first.String() != second.String() {
+		t.Errorf("Expected reproducible noisy counts, got:\n%s\nand:\n%s", first.String(), second.String())
+	}
+
+	// A threshold above any plausible noisy count suppresses every cell
+	table, err = Count(writeInput(t), Options{Pivot: "time_bucket", Epsilon: 1, Threshold: 1000})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	var output bytes.Buffer
+	if err := table.WritePivot(&output, table.PivotValues()); err != nil {
+		t.Fatalf("WritePivot() error = %v", err)
+	}
+	if output.String() != "h3_index,2024-01-01,2024-01-02\n" || table.Suppressed != 2 {
+		t.Errorf("Expected both cells suppressed, got %d suppressed:\n%s", table.Suppressed, output.String())
+	}
+}
+
+func TestCount_DifferentialPrivacyChunks(t *testing.T) {
+	table, err := Count(writeInput(t), Options{Pivot: "time_bucket", Epsilon: 1, Seed: 3})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	var whole, part1, part2 bytes.Buffer
+	values := table.PivotValues()
+	table.WritePivot(&whole, values)
+	table.WritePivot(&part1, values[:1])
+	table.WritePivot(&part2, values[1:])
+
+	// Each chunk holds the same cells and noisy counts as the whole matrix
+	rows := strings.Split(strings.TrimSpace(whole.String()), "\n")
+	rows1 := strings.Split(strings.TrimSpace(part1.String()), "\n")
+	rows2 := strings.Split(strings.TrimSpace(part2.String()), "\n")
+	if len(rows1) != len(rows) || len(rows2) != len(rows) {
+		t.Fatalf("Expected %d rows per chunk, got %d and %d", len(rows), len(rows1), len(rows2))
+	}
+	for i := range rows {
+		fields1 := strings.Split(rows1[i], ",")
+		fields2 := strings.Split(rows2[i], ",")
+		if joined := strings.Join(append(fields1, fields2[1:]...), ","); joined != rows[i] {
+			t.Errorf("Row %d: chunks give %s, whole matrix %s", i, joined, rows[i])
+		}
+	}
+}
+
+func TestCount_KAnonymity(t *testing.T) {
+	table, err := Count(writeInput(t), Options{K: 3})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	var output bytes.Buffer
+	if err := table.WriteCounts(&output); err != nil {
+		t.Fatalf("WriteCounts() error = %v", err)
+	}
+	if output.String() != "h3_index,count\n882a107289fffff,3\n" || table.BelowK != 1 {
+		t.Errorf("Expected the 2-row cell suppressed, got %d suppressed:\n%s", table.BelowK, output.String())
+	}
+
+	// Pivoted cells are kept or dropped by their total over all values
+	table, err = Count(writeInput(t), Options{Pivot: "time_bucket", K: 2})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	output.Reset()
+	table.WritePivot(&output, []string{"2024-01-02"})
+	if output.String() != "h3_index,2024-01-02\n882a107289fffff,1\n" || table.BelowK != 1 {
+		t.Errorf("Expected the 1-row cell suppressed, got %d suppressed:\n%s", table.BelowK, output.String())
+	}
+
+	if _, err := Count(writeInput(t), Options{K: -1}); err == nil {
+		t.Error("Expected error for negative k")
+	}
+}
@@ -0,0 +1,86 @@
+package aggregate
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// DefaultThreshold is the noisy count below which cells are suppressed when adding
+// differential privacy noise
+const DefaultThreshold = 10
+
+// noise adds calibrated Laplace noise to counts for differentially private output.
+// Each row contributes to exactly one (cell, value) count, so the sensitivity is 1 and
+// the noise scale is 1/epsilon. The noise of a count is derived from a secret per-run
+// key, the cell and the value, so it is the same in every pivot matrix chunk.
+type noise struct {
+	scale     float64 // Laplace scale b = 1 / epsilon
+	threshold float64 // Cells with a lower noisy total are suppressed
+	key       uint64
+}
+
+// newNoise creates the noise for a privacy budget epsilon; a non-zero seed makes the
+// noise reproducible (for tests only: published outputs must use a secret key)
+func newNoise(epsilon, threshold float64, seed int64) (*noise, error) {
+	if epsilon <= 0 || math.IsInf(epsilon, 0) || math.IsNaN(epsilon) {
+		return nil, fmt.Errorf("privacy budget epsilon must be a positive number, got %g", epsilon)
+	}
+	if threshold < 0 {
+		return nil, fmt.Errorf("suppression threshold cannot be negative, got %g", threshold)
+	}
+	n := &noise{scale: 1 / epsilon, threshold: threshold, key: uint64(seed)}
+	if seed == 0 {
+		var key [8]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate noise key: %w", err)
+		}
+		n.key = binary.LittleEndian.Uint64(key[:])
+	}
+	return n, nil
+}
+
+// count returns the noisy count of a (cell, value) pair, rounded and clamped at zero
+func (n *noise) count(cell, value string, count int) int {
+	noisy := math.Round(float64(count) + n.laplace(cell, value))
+	if noisy < 0 {
+		return 0
+	}
+	return int(noisy)
+}
+
+// suppressed reports whether a cell with the given noisy total is withheld
+func (n *noise) suppressed(total int) bool {
+	return float64(total) < n.threshold
+}
+
+// laplace draws Laplace(0, scale) noise from the hash of the key, cell and value
+func (n *noise) laplace(cell, value string) float64 {
+	hash := fnv.New64a()
+	var key [8]byte
+	binary.LittleEndian.PutUint64(key[:], n.key)
+	hash.Write(key[:])
+	hash.Write([]byte(cell))
+	hash.Write([]byte{0})
+	hash.Write([]byte(value))
+
+	// Uniform in (-0.5, 0.5) from the top 53 bits of the mixed hash
+	u := float64(splitmix64(hash.Sum64())>>11)/(1<<53) - 0.5
+	if u == -0.5 {
+		u = 0
+	}
+	if u < 0 {
+		return n.scale * math.Log(1+2*u)
+	}
+	return -n.scale * math.Log(1-2*u)
+}
+
+// splitmix64 scrambles a 64-bit value (SplitMix64 finalizer)
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
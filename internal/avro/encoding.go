@@ -0,0 +1,30 @@
+package avro
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// appendLong appends a zig-zag encoded variable-length long
+func appendLong(buf []byte, value int64) []byte {
+	return binary.AppendUvarint(buf, uint64(value<<1)^uint64(value>>63))
+}
+
+// appendString appends a length-prefixed string (also the encoding of bytes)
+func appendString(buf []byte, value string) []byte {
+	buf = appendLong(buf, int64(len(value)))
+	return append(buf, value...)
+}
+
+// appendDouble appends an IEEE 754 double in little-endian byte order
+func appendDouble(buf []byte, value float64) []byte {
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(value))
+}
+
+// appendBoolean appends a boolean as a single byte
+func appendBoolean(buf []byte, value bool) []byte {
+	if value {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
@@ -0,0 +1,232 @@
+// Package avro writes rows to Avro object container files with a flat record schema
+// of primitive, optionally nullable, fields.
+package avro
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Avro primitive types supported for fields
+const (
+	TypeString  = "string"
+	TypeLong    = "long"
+	TypeDouble  = "double"
+	TypeBoolean = "boolean"
+)
+
+// DefaultBlockRecords is the number of records encoded per container block
+const DefaultBlockRecords = 4096
+
+// magic starts every Avro object container file
+var magic = []byte{'O', 'b', 'j', 1}
+
+// Field describes one field of the written records
+type Field struct {
+	Name     string         // Column name, sanitized into a valid Avro name in the schema
+	Type     string         // TypeString, TypeLong, TypeDouble, or TypeBoolean
+	Nullable bool           // Empty values are written as null
+	Hex      bool           // With TypeLong, values are hexadecimal (e.g. H3 indexes)
+	Props    map[string]any // Additional schema attributes, e.g. H3 metadata
+}
+
+// Writer encodes rows of strings as Avro records, one field per column
+type Writer struct {
+	out          *bufio.Writer
+	fields       []Field
+	sync         [16]byte
+	block        []byte
+	records      int // Records in the pending block
+	blockRecords int
+}
+
+// NewWriter writes the container header for records named name with the given
+// fields and returns a writer for the rows
+func NewWriter(w io.Writer, name string, fields []Field) (*Writer, error) {
+	schema, err := Schema(name, fields)
+	if err != nil {
+		return nil, err
+	}
+	writer := &Writer{out: bufio.NewWriter(w), fields: fields, blockRecords: DefaultBlockRecords}
+	if _, err := rand.Read(writer.sync[:]); err != nil {
+		return nil, fmt.Errorf("failed to create sync marker: %w", err)
+	}
+
+	header := append([]byte{}, magic...)
+	header = appendLong(header, 2) // Metadata map with two entries
+	header = appendString(header, "avro.schema")
+	header = appendString(header, string(schema))
+	header = appendString(header, "avro.codec")
+	header = appendString(header, "null")
+	header = appendLong(header, 0)
+	header = append(header, writer.sync[:]...)
+	if _, err := writer.out.Write(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Schema returns the JSON record schema for the fields. Field names that are not
+// valid Avro names are sanitized, keeping the column name in an x-csv-column attribute.
+func Schema(name string, fields []Field) ([]byte, error) {
+	type field map[string]any
+	records := make([]field, len(fields))
+	used := make(map[string]bool, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case TypeString, TypeLong, TypeDouble, TypeBoolean:
+		default:
+			return nil, fmt.Errorf("unsupported Avro type %q for field %s", f.Type, f.Name)
+		}
+		record := field{}
+		for key, value := range f.Props {
+			record[key] = value
+		}
+		fieldName := uniqueName(sanitizeName(f.Name), used)
+		record["name"] = fieldName
+		if fieldName != f.Name {
+			record["x-csv-column"] = f.Name
+		}
+		record["type"] = f.Type
+		if f.Nullable {
+			record["type"] = []string{"null", f.Type}
+			record["default"] = nil
+		}
+		records[i] = record
+	}
+	return json.Marshal(map[string]any{
+		"type":   "record",
+		"name":   sanitizeName(name),
+		"fields": records,
+	})
+}
+
+// sanitizeName turns a column name into a valid Avro name: [A-Za-z_][A-Za-z0-9_]*
+func sanitizeName(name string) string {
+	var sanitized strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			sanitized.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sanitized.WriteByte('_')
+			}
+			sanitized.WriteRune(r)
+		default:
+			sanitized.WriteByte('_')
+		}
+	}
+	if sanitized.Len() == 0 {
+		return "_"
+	}
+	return sanitized.String()
+}
+
+// uniqueName suffixes name with _2, _3, ... until it is not in used, and marks it used
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = name + "_" + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// Write encodes one row; missing trailing values count as empty. Empty values of
+// nullable fields are written as null.
+func (w *Writer) Write(row []string) error {
+	start := len(w.block)
+	for i, f := range w.fields {
+		value := ""
+		if i < len(row) {
+			value = row[i]
+		}
+		block, err := appendValue(w.block, f, value)
+		if err != nil {
+			w.block = w.block[:start]
+			return fmt.Errorf("column %s: %w", f.Name, err)
+		}
+		w.block = block
+	}
+	w.records++
+	if w.records >= w.blockRecords {
+		return w.writeBlock()
+	}
+	return nil
+}
+
+// appendValue encodes a value of a field, preceded by its union branch when nullable
+func appendValue(buf []byte, f Field, value string) ([]byte, error) {
+	trimmed := strings.TrimSpace(value)
+	if f.Nullable {
+		if trimmed == "" {
+			return appendLong(buf, 0), nil
+		}
+		buf = appendLong(buf, 1)
+	}
+
+	switch f.Type {
+	case TypeString:
+		return appendString(buf, value), nil
+	case TypeLong:
+		var number int64
+		var err error
+		if f.Hex {
+			var unsigned uint64
+			unsigned, err = strconv.ParseUint(trimmed, 16, 64)
+			number = int64(unsigned)
+		} else {
+			number, err = strconv.ParseInt(trimmed, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid long %q", value)
+		}
+		return appendLong(buf, number), nil
+	case TypeDouble:
+		number, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid double %q", value)
+		}
+		return appendDouble(buf, number), nil
+	case TypeBoolean:
+		switch {
+		case strings.EqualFold(trimmed, "true"):
+			return appendBoolean(buf, true), nil
+		case strings.EqualFold(trimmed, "false"):
+			return appendBoolean(buf, false), nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", value)
+	}
+	return nil, fmt.Errorf("unsupported Avro type %q", f.Type)
+}
+
+// writeBlock writes the pending records as one container block
+func (w *Writer) writeBlock() error {
+	if w.records == 0 {
+		return nil
+	}
+	header := appendLong(nil, int64(w.records))
+	header = appendLong(header, int64(len(w.block)))
+	for _, part := range [][]byte{header, w.block, w.sync[:]} {
+		if _, err := w.out.Write(part); err != nil {
+			return err
+		}
+	}
+	w.block = w.block[:0]
+	w.records = 0
+	return nil
+}
+
+// Flush writes the pending records and flushes the underlying writer
+func (w *Writer) Flush() error {
+	if err := w.writeBlock(); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
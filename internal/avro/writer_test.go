@@ -0,0 +1,170 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decoder reads back container files written by Writer
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) long() int64 {
+	value, n := binary.Uvarint(d.data[d.pos:])
+	d.pos += n
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+func (d *decoder) string() string {
+	n := int(d.long())
+	d.pos += n
+	return string(d.data[d.pos-n : d.pos])
+}
+
+// value decodes one value of a primitive or ["null", type] union type
+func (d *decoder) value(t any) any {
+	if union, ok := t.([]any); ok {
+		return d.value(union[d.long()])
+	}
+	switch t {
+	case "long":
+		return d.long()
+	case "string":
+		return d.string()
+	case "double":
+		d.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.pos-8:]))
+	case "boolean":
+		d.pos++
+		return d.data[d.pos-1] == 1
+	}
+	return nil
+}
+
+// readContainer decodes the schema and records of a container file
+func readContainer(t *testing.T, data []byte) (map[string]any, []map[string]any) {
+	t.Helper()
+	if !bytes.HasPrefix(data, magic) {
+		t.Fatalf("Missing container magic")
+	}
+	d := &decoder{data: data, pos: len(magic)}
+	metadata := map[string]string{}
+	for n := d.long(); n != 0; n = d.long() {
+		for ; n > 0; n-- {
+			key := d.string()
+			metadata[key] = d.string()
+		}
+	}
+	if metadata["avro.codec"] != "null" {
+		t.Errorf("Expected the null codec, got %q", metadata["avro.codec"])
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(metadata["avro.schema"]), &schema); err != nil {
+		t.Fatalf("Invalid schema: %v", err)
+	}
+	sync := data[d.pos : d.pos+16]
+	d.pos += 16
+
+	var records []map[string]any
+	for d.pos < len(data) {
+		count, size := d.long(), d.long()
+		end := d.pos + int(size)
+		for ; count > 0; count-- {
+			record := map[string]any{}
+			for _, field := range schema["fields"].([]any) {
+				field := field.(map[string]any)
+				record[field["name"].(string)] = d.value(field["type"])
+			}
+			records = append(records, record)
+		}
+		if d.pos != end || !bytes.Equal(data[d.pos:d.pos+16], sync) {
+			t.Fatalf("Block does not end with the sync marker at %d", d.pos)
+		}
+		d.pos += 16
+	}
+	return schema, records
+}
+
+func TestWriter(t *testing.T) {
+	fields := []Field{
+		{Name: "id", Type: TypeLong},
+		{Name: "score", Type: TypeDouble, Nullable: true},
+		{Name: "name", Type: TypeString, Nullable: true},
+		{Name: "flag", Type: TypeBoolean},
+		{Name: "h3_index", Type: TypeLong, Hex: true, Props: map[string]any{"x-h3": map[string]any{"resolution": 8}}},
+	}
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, "points", fields)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	writer.blockRecords = 2 // Exercise several blocks
+	rows := [][]string{
+		{"1", "1.5", "a", "true", "8828308281fffff"},
+		{"-2", "", "", "FALSE", "8828308281fffff"},
+		{"300", " 2 ", "c c", "false", "882a1072d7fffff"},
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := writer.Write([]string{"x", "1", "a", "true", "8828308281fffff"}); err == nil || !strings.Contains(err.Error(), "column id") {
+		t.Errorf("Expected an invalid long error, got %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	schema, records := readContainer(t, output.Bytes())
+	if schema["name"] != "points" || len(schema["fields"].([]any)) != 5 {
+		t.Errorf("Unexpected schema %v", schema)
+	}
+	expected := []map[string]any{
+		{"id": int64(1), "score": 1.5, "name": "a", "flag": true, "h3_index": int64(0x8828308281fffff)},
+		{"id": int64(-2), "score": nil, "name": nil, "flag": false, "h3_index": int64(0x8828308281fffff)},
+		{"id": int64(300), "score": 2.0, "name": "c c", "flag": false, "h3_index": int64(0x882a1072d7fffff)},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected records\n%v\ngot\n%v", expected, records)
+	}
+}
+
+func TestSchemaSanitizesNames(t *testing.T) {
+	schema, err := Schema("my-output", []Field{
+		{Name: "ok_name", Type: TypeString},
+		{Name: "2nd col", Type: TypeString},
+		{Name: "a-b", Type: TypeString},
+		{Name: "a b", Type: TypeString},
+		{Name: "", Type: TypeString},
+	})
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	var document struct {
+		Name   string
+		Fields []map[string]any
+	}
+	json.Unmarshal(schema, &document)
+	var names []string
+	for _, field := range document.Fields {
+		names = append(names, field["name"].(string))
+	}
+	if document.Name != "my_output" || !reflect.DeepEqual(names, []string{"ok_name", "_2nd_col", "a_b", "a_b_2", "_"}) {
+		t.Errorf("Unexpected names %s %v", document.Name, names)
+	}
+	if document.Fields[1]["x-csv-column"] != "2nd col" || document.Fields[0]["x-csv-column"] != nil {
+		t.Errorf("Expected the original name of renamed columns only, got %v", document.Fields)
+	}
+
+	if _, err := Schema("x", []Field{{Name: "a", Type: "int"}}); err == nil {
+		t.Error("Expected an unsupported type error")
+	}
+}
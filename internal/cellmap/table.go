@@ -0,0 +1,228 @@
+package cellmap
+
+import (
+	encodingcsv "encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/uber/h3-go/v4"
+)
+
+// cellColumns are the accepted names of the H3 cell column, in order of preference
+var cellColumns = []string{"h3", "h3_index", "h3_cell", "cell"}
+
+// Table maps H3 cells to attribute values, e.g. admin boundary names from a
+// precomputed polyfill. Cells may be of mixed resolutions (a compacted polyfill);
+// a point matches the finest cell containing it.
+type Table struct {
+	columns     []string
+	values      map[h3.Cell][]string
+	resolutions []int // Resolutions present in the table, finest first
+}
+
+// Load reads a lookup table from a Parquet or CSV file with an H3 cell column
+// (h3, h3_index, h3_cell, or cell) and one or more attribute columns
+func Load(path string) (*Table, error) {
+	var (
+		headers []string
+		rows    [][]string
+		err     error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		headers, rows, err = readParquet(path)
+	case ".csv":
+		headers, rows, err = readCSV(path)
+	default:
+		return nil, fmt.Errorf("unsupported lookup table format %q (expected .parquet or .csv)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lookup table %s: %w", path, err)
+	}
+	return NewTable(headers, rows)
+}
+
+// NewTable builds a lookup table from a header row and data rows
+func NewTable(headers []string, rows [][]string) (*Table, error) {
+	cellIndex := -1
+	for _, name := range cellColumns {
+		for i, header := range headers {
+			if cellIndex < 0 && strings.EqualFold(strings.TrimSpace(header), name) {
+				cellIndex = i
+			}
+		}
+	}
+	if cellIndex < 0 {
+		return nil, fmt.Errorf("lookup table has no H3 cell column (expected one of %s)", strings.Join(cellColumns, ", "))
+	}
+	if len(headers) < 2 {
+		return nil, fmt.Errorf("lookup table has no attribute columns")
+	}
+
+	table := &Table{values: make(map[h3.Cell][]string, len(rows))}
+	for i, header := range headers {
+		if i != cellIndex {
+			table.columns = append(table.columns, strings.TrimSpace(header))
+		}
+	}
+
+	resolutions := make(map[int]bool)
+	for line, row := range rows {
+		if cellIndex >= len(row) {
+			return nil, fmt.Errorf("row %d has no H3 cell", line+1)
+		}
+		cell, err := parseCell(row[cellIndex])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", line+1, err)
+		}
+
+		values := make([]string, 0, len(table.columns))
+		for i := range headers {
+			if i == cellIndex {
+				continue
+			}
+			value := ""
+			if i < len(row) {
+				value = row[i]
+			}
+			values = append(values, value)
+		}
+		table.values[cell] = values
+		resolutions[cell.Resolution()] = true
+	}
+
+	for resolution := range resolutions {
+		table.resolutions = append(table.resolutions, resolution)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(table.resolutions)))
+	return table, nil
+}
+
+// parseCell parses an H3 cell given as a hex string or a decimal integer
+func parseCell(value string) (h3.Cell, error) {
+	value = strings.TrimSpace(value)
+	index, err := strconv.ParseUint(value, 16, 64)
+	if err != nil || !h3.Cell(index).IsValid() {
+		if decimal, decErr := strconv.ParseUint(value, 10, 64); decErr == nil && h3.Cell(decimal).IsValid() {
+			return h3.Cell(decimal), nil
+		}
+		return 0, fmt.Errorf("invalid H3 cell %q", value)
+	}
+	return h3.Cell(index), nil
+}
+
+// Columns returns the names of the attribute columns
+func (t *Table) Columns() []string {
+	return t.columns
+}
+
+// Len returns the number of cells in the table
+func (t *Table) Len() int {
+	return len(t.values)
+}
+
+// Lookup returns the attribute values of the finest cell containing the coordinates
+func (t *Table) Lookup(lat, lng float64) ([]string, bool, error) {
+	latLng := h3.NewLatLng(lat, lng)
+	for _, resolution := range t.resolutions {
+		cell, err := h3.LatLngToCell(latLng, resolution)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to locate H3 cell for (%.6f, %.6f): %w", lat, lng, err)
+		}
+		if values, ok := t.values[cell]; ok {
+			return values, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// readCSV reads a CSV file with a header row
+func readCSV(path string) ([]string, [][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := encodingcsv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+	return records[0], records[1:], nil
+}
+
+// readParquet reads the flat columns of a Parquet file as strings
+func readParquet(path string) ([]string, [][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parquetFile, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, nil, err
+	}
+	var headers []string
+	for _, path := range parquetFile.Schema().Columns() {
+		headers = append(headers, strings.Join(path, "."))
+	}
+
+	var rows [][]string
+	buffer := make([]parquet.Row, 256)
+	for _, rowGroup := range parquetFile.RowGroups() {
+		groupRows := rowGroup.Rows()
+		for {
+			n, err := groupRows.ReadRows(buffer)
+			for _, row := range buffer[:n] {
+				values := make([]string, len(headers))
+				for _, value := range row {
+					if column := value.Column(); column >= 0 && column < len(values) {
+						values[column] = parquetString(value)
+					}
+				}
+				rows = append(rows, values)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				groupRows.Close()
+				return nil, nil, err
+			}
+		}
+		groupRows.Close()
+	}
+	return headers, rows, nil
+}
+
+// parquetString formats a Parquet value as a string; integers are written in decimal,
+// which parseCell accepts for integer-typed cell columns
+func parquetString(value parquet.Value) string {
+	switch value.Kind() {
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return string(value.ByteArray())
+	case parquet.Int64:
+		return strconv.FormatUint(value.Uint64(), 10)
+	default:
+		if value.IsNull() {
+			return ""
+		}
+		return value.String()
+	}
+}
@@ -0,0 +1,103 @@
+package cellmap
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/uber/h3-go/v4"
+)
+
+// cellOf returns the cell containing the coordinates at a resolution
+func cellOf(t *testing.T, lat, lng float64, resolution int) h3.Cell {
+	t.Helper()
+	cell, err := h3.LatLngToCell(h3.NewLatLng(lat, lng), resolution)
+	if err != nil {
+		t.Fatalf("LatLngToCell failed: %v", err)
+	}
+	return cell
+}
+
+func TestTableLookup(t *testing.T) {
+	manhattan := cellOf(t, 40.7831, -73.9712, 7)
+	newYork := cellOf(t, 40.7128, -74.0060, 4)
+	table, err := NewTable([]string{"country", "H3", "region"}, [][]string{
+		{"US", manhattan.String(), "Manhattan"},
+		{"US", newYork.String(), "New York"},
+	})
+	if err != nil {
+		t.Fatalf("NewTable() error = %v", err)
+	}
+
+	if got := table.Columns(); !reflect.DeepEqual(got, []string{"country", "region"}) {
+		t.Errorf("Columns() = %v, want [country region]", got)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		want     []string
+	}{
+		{"finest cell wins", 40.7831, -73.9712, []string{"US", "Manhattan"}},
+		{"coarser cell", 40.6782, -73.9442, []string{"US", "New York"}},
+		{"not covered", 51.5074, -0.1278, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, found, err := table.Lookup(tt.lat, tt.lng)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if found != (tt.want != nil) || !reflect.DeepEqual(values, tt.want) {
+				t.Errorf("Lookup() = %v, %v; want %v", values, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTable_Invalid(t *testing.T) {
+	if _, err := NewTable([]string{"country", "region"}, nil); err == nil {
+		t.Error("NewTable() without a cell column should error")
+	}
+	if _, err := NewTable([]string{"h3", "country"}, [][]string{{"not-a-cell", "US"}}); err == nil {
+		t.Error("NewTable() with an invalid cell should error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cell := cellOf(t, 48.8566, 2.3522, 5)
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "admin_cells.csv")
+	if err := os.WriteFile(csvPath, []byte("h3,country,region\n"+cell.String()+",FR,Ile-de-France\n"), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	// Parquet with an integer cell column
+	type adminCell struct {
+		H3      int64  `parquet:"h3"`
+		Country string `parquet:"country"`
+		Region  string `parquet:"region"`
+	}
+	parquetPath := filepath.Join(dir, "admin_cells.parquet")
+	if err := parquet.WriteFile(parquetPath, []adminCell{{H3: int64(cell), Country: "FR", Region: "Ile-de-France"}}); err != nil {
+		t.Fatalf("Failed to write Parquet: %v", err)
+	}
+
+	for _, path := range []string{csvPath, parquetPath} {
+		table, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) error = %v", filepath.Base(path), err)
+		}
+		values, found, _ := table.Lookup(48.8566, 2.3522)
+		if !found || !reflect.DeepEqual(values, []string{"FR", "Ile-de-France"}) {
+			t.Errorf("Load(%s): Lookup() = %v, %v", filepath.Base(path), values, found)
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "cells.json")); err == nil {
+		t.Error("Load() with an unsupported extension should error")
+	}
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/aggregate"
+)
+
+// AddAggregateCommand adds the aggregate subcommand for per-cell counts of an enriched file
+func (c *CLI) AddAggregateCommand() {
+	var (
+		opts         aggregate.Options
+		delimiter    string
+		chunkColumns int
+		output       string
+		overwrite    bool
+	)
+
+	aggregateCmd := &cobra.Command{
+		Use:   "aggregate input.csv",
+		Short: "Count rows per H3 cell of an enriched CSV, optionally as a cells × values matrix",
+		Long: `Count the rows of an enriched CSV per H3 cell. With --pivot, write a wide matrix
+instead: one row per cell and one column per distinct value of the pivot column (e.g.,
+a time bucket), holding row counts, ready for ML feature engineering. Rows without an
+H3 index or pivot value are skipped.
+
+With many pivot values, --chunk-columns N splits the matrix into several files of at
+most N value columns each (<output>_part001.csv, ...), each starting with the cell column.
+
+Counts are held in memory up to --max-groups (cell, value) pairs and then spilled to
+gzip-compressed files in --temp-dir, which are merged and removed when done.
+
+For publishing aggregates of sensitive location data, --dp-epsilon adds Laplace noise
+calibrated to the privacy budget epsilon to every count (smaller = more private, noisier)
+and suppresses cells whose noisy count is below --dp-threshold. --k-anonymity K drops
+cells with fewer than K contributing rows before any noise is added.
+
+Examples:
+  csv-h3-tool aggregate trips_with_h3.csv -o counts.csv
+  csv-h3-tool aggregate trips_with_h3.csv --pivot time_bucket -o matrix.csv
+  csv-h3-tool aggregate trips_with_h3.csv --pivot hour --chunk-columns 1000 -o matrix.csv
+  csv-h3-tool aggregate trips_with_h3.csv --dp-epsilon 0.5 -o counts.csv`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if opts.Delimiter, err = ParseDelimiter(delimiter); err != nil {
+				return err
+			}
+			if opts.Epsilon == 0 && cmd.Flags().Changed("dp-threshold") {
+				return fmt.Errorf("--dp-threshold requires --dp-epsilon")
+			}
+			if chunkColumns > 0 && (opts.Pivot == "" || output == "") {
+				return fmt.Errorf("--chunk-columns requires --pivot and --output")
+			}
+
+			table, err := aggregate.Count(args[0], opts)
+			if err != nil {
+				return err
+			}
+			defer table.Close()
+
+			if opts.Pivot == "" {
+				out, closeOutput, err := createCommandOutput(cmd, output, overwrite)
+				if err != nil {
+					return err
+				}
+				defer closeOutput()
+				if err := table.WriteCounts(out); err != nil {
+					return err
+				}
+			} else {
+				chunks := aggregate.Chunks(table.PivotValues(), chunkColumns)
+				for i, values := range chunks {
+					path := output
+					if len(chunks) > 1 {
+						path = chunkPath(output, i+1)
+					}
+					out, closeOutput, err := createCommandOutput(cmd, path, overwrite)
+					if err != nil {
+						return err
+					}
+					err = table.WritePivot(out, values)
+					closeOutput()
+					if err != nil {
+						return err
+					}
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Pivot matrix: %d cells × %d %s values in %d file(s)\n",
+					table.CellCount(), len(table.PivotValues()), opts.Pivot, len(chunks))
+			}
+			if opts.K > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Suppressed %d cells with fewer than %d rows (k-anonymity)\n", table.BelowK, opts.K)
+			}
+			if opts.Epsilon != 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Added Laplace noise (epsilon %g): %d cells below %g suppressed\n",
+					opts.Epsilon, table.Suppressed, opts.Threshold)
+			}
+			if table.Spills > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Spilled counts to disk %d time(s) (--max-groups %d)\n", table.Spills, opts.MaxGroups)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Aggregated %d rows into %d cells (%d rows skipped)\n",
+				table.Rows, table.CellCount(), table.SkippedRows)
+			return nil
+		},
+	}
+
+	flags := aggregateCmd.Flags()
+	flags.StringVar(&opts.H3Column, "h3-column", "h3_index", "H3 index column to group by")
+	flags.StringVar(&opts.Pivot, "pivot", "", "Write a cells × values count matrix with one column per distinct value of this column")
+	flags.IntVar(&chunkColumns, "chunk-columns", 0, "Split the pivot matrix into files of at most this many value columns (0 = one file)")
+	flags.IntVar(&opts.MaxGroups, "max-groups", 1000000, "(cell, value) counts held in memory before spilling to disk (0 = no limit)")
+	flags.StringVar(&opts.TempDir, "temp-dir", "", "Directory for temporary spill files (default: system temp directory)")
+	flags.IntVar(&opts.K, "k-anonymity", 0, "Drop cells with fewer than this many contributing rows (0 = keep all)")
+	flags.Float64Var(&opts.Epsilon, "dp-epsilon", 0, "Differential privacy budget: add Laplace noise of scale 1/epsilon to counts (0 = exact counts)")
+	flags.Float64Var(&opts.Threshold, "dp-threshold", aggregate.DefaultThreshold, "With --dp-epsilon, suppress cells whose noisy count is below this")
+	flags.StringVar(&delimiter, "delimiter", ",", "Field delimiter of the input file")
+	flags.StringVarP(&output, "output", "o", "", "Output CSV file path (default: stdout)")
+	flags.BoolVar(&overwrite, "overwrite", false, "Overwrite output files if they already exist")
+
+	c.rootCmd.AddCommand(aggregateCmd)
+}
+
+// chunkPath returns the file name of a pivot matrix chunk, e.g. matrix_part002.csv
+func chunkPath(output string, part int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s_part%03d%s", strings.TrimSuffix(output, ext), part, ext)
+}
@@ -1,14 +1,31 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/extsort"
+	"csv-h3-tool/internal/filehandler"
+	"csv-h3-tool/internal/geocode"
+	"csv-h3-tool/internal/h3"
+	"csv-h3-tool/internal/history"
+	"csv-h3-tool/internal/logging"
+	"csv-h3-tool/internal/notify"
+	"csv-h3-tool/internal/selftest"
 	"csv-h3-tool/internal/service"
+	"csv-h3-tool/internal/stats"
+	"csv-h3-tool/internal/validator"
 )
 
 // CLI represents the command line interface
@@ -27,7 +44,7 @@ func NewCLI() *CLI {
 	}
 	
 	cli.rootCmd = &cobra.Command{
-		Use:   "csv-h3-tool [input-file]",
+		Use:   "csv-h3-tool [input-file...]",
 		Short: "Add H3 geospatial indexes to CSV files with latitude/longitude coordinates",
 		Long: `CSV H3 Tool processes CSV files containing latitude and longitude coordinates
 and adds H3 index values as a new column using Uber's H3 geospatial indexing system.
@@ -54,10 +71,37 @@ CSV FORMAT OPTIONS:
   csv-h3-tool data.csv --delimiter ";"    # Semicolon-separated values
   csv-h3-tool data.csv --delimiter "\t"   # Tab-separated values
 
+ZIP ARCHIVES:
+  csv-h3-tool data.zip                    # The archive's only CSV file
+  csv-h3-tool data.zip::points.csv        # A selected member, streamed without extraction
+
 ADVANCED USAGE:
   csv-h3-tool large_dataset.csv -r 8 -v --overwrite
   csv-h3-tool locations.csv --lat-column "lat_deg" --lng-column "lng_deg" -r 12
 
+DATABASE INPUT:
+  csv-h3-tool --pg-query "SELECT id, lat, lng FROM points" --db-url "$DATABASE_URL" -o points.csv
+  csv-h3-tool --mysql-query "SELECT id, lat, lng FROM points" --db-url "user:pass@tcp(host)/gis" -o points.csv
+  csv-h3-tool --pg-update points --key-column id --h3-column h3   # Backfill an H3 column in place
+
+PROFILES:
+  csv-h3-tool data.csv --profile fleet-eu
+  Profiles in ~/.csvh3/config.yaml (or --profile-file) bundle option defaults
+  keyed by flag name, so teams can standardize runs:
+    profiles:
+      fleet-eu:
+        delimiter: ";"
+        lat-column: breitengrad
+        lng-column: laengengrad
+        resolution: 9
+
+BATCH MODE:
+  csv-h3-tool 'data/*.csv' --parallel-files 4 --workers 8
+  Several files or glob patterns are processed concurrently; each output is
+  written next to its input as <name>_with_h3.csv, or as named by
+  --output-name-template "{{.Stem}}_{{.Resolution}}_h3{{.Ext}}".
+  Add --tui for a live dashboard of per-file progress on interactive terminals.
+
 RESOLUTION LEVELS:
   Use 'csv-h3-tool resolutions' to see all available H3 resolution levels.
   Common choices:
@@ -69,7 +113,7 @@ RESOLUTION LEVELS:
 OUTPUT FORMAT:
   The output CSV will contain all original columns plus a new 'h3_index' column
   with the calculated H3 index values. Invalid coordinates will have empty H3 values.`,
-		Args: cobra.ExactArgs(1),
+		Args: cli.checkArgs,
 		RunE: cli.run,
 	}
 	
@@ -83,7 +127,11 @@ func (c *CLI) setupFlags() {
 	
 	// Output file
 	flags.StringVarP(&c.config.OutputFile, "output", "o", "", 
-		"Output CSV file path (default: input_with_h3.csv)")
+		"Output CSV file path (default: input_with_h3.csv); a .zip path with multiple inputs or --output-dir packages all outputs with a manifest and summary")
+	
+	// Output naming when no output file is given (e.g., batch mode)
+	flags.StringVar(&c.config.OutputNameTemplate, "output-name-template", "", 
+		"Go template for generated output names; fields: .Stem .Ext .Name .Dir .Resolution (default: \"{{.Stem}}_with_h3{{.Ext}}\")")
 	
 	// Column configuration
 	flags.StringVar(&c.config.LatColumn, "lat-column", "latitude", 
@@ -91,6 +139,60 @@ func (c *CLI) setupFlags() {
 	flags.StringVar(&c.config.LngColumn, "lng-column", "longitude", 
 		"Name or index of the longitude column (e.g., 'longitude', 'lng', '1')")
 	
+	// Fallback coordinate columns
+	flags.StringVar(&c.config.LatFallback, "lat-fallback", "", 
+		"Latitude column used when the latitude or longitude column is empty (e.g., 'gps_lat'); requires --lng-fallback, and the values used are written to the latitude/longitude columns")
+	flags.StringVar(&c.config.LngFallback, "lng-fallback", "", 
+		"Longitude column used together with --lat-fallback (e.g., 'gps_lng')")
+	flags.StringVar(&c.config.CoordSources, "coord-sources", "", 
+		"Further fallback coordinate columns tried in order after --lat-fallback/--lng-fallback as 'lat_col:lng_col,...' (e.g., 'gps_lat:gps_lng,wifi_lat:wifi_lng'); the first pair with both values that parse supplies empty coordinates")
+	flags.BoolVar(&c.config.EmitCoordSource, "emit-coord-source", false, 
+		"Add a coord_source column naming the columns that supplied each row's coordinates (e.g., 'gps_lat:gps_lng', or 'geocoded'); empty for invalid rows")
+	
+	// Column matching
+	flags.BoolVar(&c.config.ExplainColumns, "explain-columns", false, 
+		"Print which input column matched each coordinate role and why (exact, case-insensitive, alias, fuzzy, index)")
+	flags.BoolVar(&c.config.NoColumnFallback, "no-column-fallback", false, 
+		"Match coordinate columns only by the given name (or index without headers); never fall back to aliases, fuzzy matches, or indexes")
+	flags.BoolVar(&c.config.FuzzyColumns, "fuzzy-columns", false, 
+		"When a column is not found by name or alias, also match headers ignoring case and punctuation (e.g. 'Lat.'), then a numeric column spec as an index")
+	
+	// Multiple coordinate pairs per row (e.g., trip origin/destination)
+	flags.StringVar(&c.config.CoordPairs, "coord-pairs", "", 
+		"Index several coordinate pairs per row as 'lat_col:lng_col:h3_col,...' (e.g., 'origin_lat:origin_lng:origin_h3,dest_lat:dest_lng:dest_h3'); overrides --lat-column/--lng-column")
+	
+	// Distance and bearing between coordinate pairs
+	flags.BoolVar(&c.config.AddDistanceKm, "add-distance-km", false, 
+		"Add a distance_km column with the great-circle distance from the first to the second --coord-pairs pair")
+	flags.BoolVar(&c.config.AddBearing, "add-bearing", false, 
+		"Add a bearing_deg column with the initial bearing (0-360, clockwise from north) from the first to the second --coord-pairs pair")
+	
+	// Projected coordinate systems
+	flags.BoolVar(&c.config.AddUTM, "add-utm", false, 
+		"Add utm_zone, utm_easting, and utm_northing columns (WGS84 UTM, empty outside 80°S-84°N)")
+	flags.BoolVar(&c.config.AddMGRS, "add-mgrs", false, 
+		"Add an mgrs column with the 1 m Military Grid Reference System string (empty outside 80°S-84°N)")
+	
+	// H3 cell attributes
+	flags.BoolVar(&c.config.AddCentroid, "add-centroid", false, 
+		"Add h3_lat and h3_lng columns with the center of each record's H3 cell, e.g. for plotting aggregated data")
+	flags.BoolVar(&c.config.AddCellMeta, "add-cell-meta", false, 
+		"Add h3_area_km2 (exact area of each record's H3 cell) and h3_edge_length_km (average edge length at the resolution) columns")
+	flags.BoolVar(&c.config.FlagPentagons, "flag-pentagons", false, 
+		"Add an is_pentagon column marking records in one of the 12 pentagonal H3 cells of the resolution")
+	flags.BoolVar(&c.config.AddBaseCell, "add-base-cell", false, 
+		"Add h3_base_cell (0-121) and h3_face (icosahedron face 0-19; several separated by ';' for cells crossing face edges) columns, e.g. for sharding by base cell")
+	flags.BoolVar(&c.config.AddVertexes, "add-vertexes", false, 
+		"Add an h3_vertexes column with the ';'-separated H3 vertex indexes of each record's cell (six, or five for pentagons), shared by neighboring cells")
+	flags.BoolVar(&c.config.AddLocalIJ, "add-local-ij", false, 
+		"Add local_i and local_j columns with experimental H3 local IJ coordinates relative to the --anchor cell, for grid algorithms on the output (empty for cells too far away or beyond a pentagon)")
+	flags.StringVar(&c.config.Anchor, "anchor", "", 
+		"H3 index at the output resolution that --add-local-ij coordinates are relative to")
+	
+	// Directed edges between H3 columns (e.g., origin/destination flows)
+	flags.StringVar(&c.config.AddEdge, "add-edge", "", 
+		"Add the directed H3 edge between two H3 columns as 'origin_h3:dest_h3:edge_col,...'; non-adjacent cells are marked not_neighbors")
+	
 	// H3 resolution
 	flags.IntVarP(&c.config.Resolution, "resolution", "r", int(8), 
 		"H3 resolution level (0-15). Higher = more precise. Default: 8 (street level)")
@@ -106,6 +208,30 @@ func (c *CLI) setupFlags() {
 	flags.StringVar(&delimiterStr, "delimiter", ",", 
 		"CSV delimiter character. Use '\\t' for tab, ';' for semicolon")
 	
+	// Number locale for tolerant coordinate parsing
+	flags.StringVar(&c.config.NumberLocale, "number-locale", "", 
+		"Tolerate thousands separators in coordinates: en (1,234.56), de (1.234,56), fr (1 234,56), ch (1'234.56)")
+	
+	// Missing value markers in coordinates
+	flags.StringVar(&c.config.NullValues, "null-values", "", 
+		"Comma-separated coordinate values treated as empty instead of unparseable, e.g. \"NA,N/A,null,-999\" (case-insensitive); each token is counted in the summary")
+	
+	// Preamble lines before the header
+	flags.IntVar(&c.config.SkipLines, "skip-lines", 0, 
+		"Discard the first N lines of the input before reading the header, even if they are not valid CSV")
+	flags.StringVar(&c.config.SkipComments, "skip-comments", "", 
+		"Discard leading lines starting with this prefix (after --skip-lines) before reading the header, e.g. '#'")
+	flags.IntVar(&c.config.SkipFooter, "skip-footer", 0, 
+		"Discard the last N lines of the input, e.g. a totals line, instead of counting them as invalid records")
+	
+	// Whitespace cleanup of passthrough fields
+	flags.BoolVar(&c.config.TrimFields, "trim-fields", false, 
+		"Trim leading and trailing whitespace from every field (including headers), not just coordinates")
+	flags.BoolVar(&c.config.CollapseWhitespace, "collapse-whitespace", false, 
+		"Also collapse internal runs of whitespace in every field to a single space (implies --trim-fields)")
+	flags.BoolVar(&c.config.NormalizeUnicode, "normalize-unicode", false, 
+		"Normalize every field (including headers) to Unicode NFC, drop zero-width and other invisible characters, and turn NBSPs into spaces")
+	
 	// No-headers flag (handled separately)
 	var noHeaders bool
 	flags.BoolVar(&noHeaders, "no-headers", false, 
@@ -115,10 +241,275 @@ func (c *CLI) setupFlags() {
 	flags.BoolVar(&c.config.Overwrite, "overwrite", false, 
 		"Overwrite output file if it already exists")
 	
+	// Output quoting and line endings
+	flags.StringVar(&c.config.Quote, "quote", "minimal", 
+		"When to quote output fields: always, minimal (only when needed), or never (fails on fields that need quoting)")
+	flags.BoolVar(&c.config.CRLF, "crlf", false, 
+		"Terminate output rows with CRLF (\\r\\n) instead of LF, as required by some legacy loaders")
+	flags.BoolVar(&c.config.EscapeFormulas, "escape-formulas", false, 
+		"Prefix output fields starting with =, +, -, @, tab or carriage return with ' so Excel and Sheets show them as text instead of running them as formulas (numbers such as -74.0 are left as is)")
+	
+	// Write retries
+	flags.IntVar(&c.config.WriteRetries, "write-retries", 0, 
+		"Retry failed output writes this many times (useful on network filesystems); output is written in chunks that are safe to rewrite")
+	flags.DurationVar(&c.config.RetryBackoff, "retry-backoff", 2*time.Second, 
+		"Delay before the first write retry, doubled for each further retry")
+	
 	// Verbose output
 	flags.BoolVarP(&c.config.Verbose, "verbose", "v", false, 
 		"Enable verbose output with processing details and error messages")
 	
+	// Parsed coordinate columns
+	flags.BoolVar(&c.config.EmitParsedCoords, "emit-parsed-coords", false, 
+		"Add latitude_parsed/longitude_parsed columns with the normalized values used for H3 generation")
+	
+	// Expected region check
+	flags.StringVar(&c.config.ExpectBBox, "expect-bbox", "", 
+		"Flag rows outside the expected region 'minLng,minLat,maxLng,maxLat' in an outside_bbox column")
+	
+	// Location sanity check
+	flags.StringVar(&c.config.SanityCheck, "sanity-check", "", 
+		"Flag rows that are not on land, not on water, or outside the given countries using a coarse embedded H3 lookup: land|water|country:US[,CA]")
+	
+	// Outlier detection
+	flags.BoolVar(&c.config.FlagOutliers, "flag-outliers", false, 
+		"Run a second pass marking rows in sparsely populated H3 neighborhoods (likely GPS glitches) in an is_outlier column")
+	flags.IntVar(&c.config.OutlierK, "outlier-k", 1, 
+		"k-ring radius used for outlier neighborhood density")
+	flags.IntVar(&c.config.OutlierMinPoints, "outlier-min-points", 3, 
+		"Rows whose k-ring neighborhood holds fewer points than this are flagged as outliers")
+	
+	// Row filter
+	flags.StringVar(&c.config.Where, "where", "", 
+		"Only process rows matching this expression, e.g. 'speed > 0 && country == \"US\"' (columns by name or $N; + - * / %, == != < <= > >=, && || !)")
+	
+	flags.StringArrayVar(&c.config.Compute, "compute", nil, 
+		"Add an output column computed from the input columns, e.g. 'speed_kmh=speed_ms*3.6' (repeatable; same expressions as --where plus abs, floor, ceil, round, min, max, lower, upper, trim, concat, if)")
+	
+	// Spatial thinning
+	flags.IntVar(&c.config.SamplePerCell, "sample-per-cell", 0, 
+		"Keep at most N rows per H3 cell at the output resolution, chosen by reservoir sampling, to thin data evenly in space (rows are held in memory until the input is read; 0 = all)")
+	
+	// Trajectory checks
+	flags.StringVar(&c.config.IdColumn, "id-column", "", 
+		"Entity id column grouping the points of a trajectory, e.g. a device or vehicle id")
+	flags.StringVar(&c.config.TimeColumn, "time-column", "", 
+		"Timestamp column of trajectory points (RFC 3339, 'YYYY-MM-DD HH:MM:SS', or epoch seconds/milliseconds)")
+	flags.Float64Var(&c.config.MaxSpeedKmh, "max-speed-kmh", 0, 
+		"Flag or drop points implying a speed above this from the entity's previous valid point (requires --id-column and --time-column; input must be in time order per entity; 0 = off)")
+	flags.StringVar(&c.config.SpeedAction, "speed-action", config.SpeedActionFlag, 
+		"What to do with implausible points: flag (implausible_speed column) or drop")
+	flags.BoolVar(&c.config.DetectStays, "detect-stays", false, 
+		"Label points within a stay, a run of an entity's points near its first point for a minimum time, in stay_id and stay_flag columns (requires --id-column and --time-column; rows are held until their run is decided, at most 100000 before the oldest undecided run is labeled moving)")
+	flags.StringVar(&c.config.StayRadius, "stay-radius", "100m", 
+		"Distance from the first point of a stay its points stay within (e.g., 100m or 0.5km)")
+	flags.Float64Var(&c.config.StayMinutes, "stay-minutes", 10, 
+		"Minimum duration of a stay in minutes")
+	
+	// Duplicate removal
+	flags.BoolVar(&c.config.DedupeExact, "dedupe-exact", false, 
+		"Skip rows that exactly duplicate an earlier row")
+	flags.StringVar(&c.config.DedupeKeys, "dedupe-keys", "", 
+		"Skip rows whose values in these comma-separated key columns duplicate an earlier row (e.g., 'id,timestamp')")
+	flags.Float64Var(&c.config.DedupeFPRate, "dedupe-fp-rate", 0.001, 
+		"Bloom filter false-positive rate for dedupe (a false positive drops a unique row)")
+	flags.IntVar(&c.config.DedupeCapacity, "dedupe-capacity", 10000000, 
+		"Expected number of distinct rows used to size the dedupe bloom filter")
+	
+	// Output ordering
+	flags.BoolVar(&c.config.SortByH3, "sort-by-h3", false, 
+		"Write output ordered by H3 index (invalid rows last) for better compression and range-scan locality")
+	flags.IntVar(&c.config.SortChunkSize, "sort-chunk-size", 100000, 
+		"Rows held in memory before spilling a sorted chunk to a temp file when sorting")
+	flags.StringVar(&c.config.TempDir, "temp-dir", "", 
+		"Directory for temporary files: gzip-compressed sort spills and spooled query results (default: system temp directory)")
+	
+	// Partitioned output
+	flags.IntVar(&c.config.PartitionByH3Res, "partition-by-h3-res", -1, 
+		"Write a Hive-style partitioned dataset keyed by the parent H3 cell at this resolution (requires --output-dir)")
+	flags.StringVar(&c.config.OutputDir, "output-dir", "", 
+		"Output directory for partitioned output, e.g. out/h3_r3=<cell>/part-0001.csv (--overwrite first removes the partitions and markers of an earlier run)")
+	flags.BoolVar(&c.config.SparkCompat, "spark-compat", false, 
+		"Write a _manifest.json and Spark-style _SUCCESS markers when partitioned output completes")
+	
+	// Output format
+	flags.StringVar(&c.config.OutputFormat, "output-format", "csv", 
+		"Output format: csv, avro or orc (column types inferred from the output), or duckdb to write --table in the -o DuckDB database (requires a build with -tags duckdb)")
+	flags.StringVar(&c.config.H3Format, "h3-format", "string", 
+		"Type of the H3 index columns in Avro and ORC output: string (hexadecimal) or long")
+	flags.StringVar(&c.config.Table, "table", "", 
+		"Table written with --output-format duckdb (replaced with --overwrite)")
+	
+	// Output schema expectations
+	flags.IntVar(&c.config.ExpectOutputColumns, "expect-output-columns", 0, 
+		"Fail before writing any rows unless the output has exactly this many columns")
+	flags.StringVar(&c.config.ExpectHeaders, "expect-headers", "", 
+		"Fail before writing any rows unless the output header row matches the first row of this CSV file")
+	
+	// Dry-run cost estimate
+	flags.BoolVar(&c.config.Estimate, "estimate", false, 
+		"Sample rows and print the estimated runtime, output size, and distinct cell count without writing output")
+	flags.IntVar(&c.config.EstimateSampleRows, "estimate-sample-rows", 10000, 
+		"Number of rows sampled by --estimate")
+	
+	// Database query input
+	flags.StringVar(&c.config.PGQuery, "pg-query", "", 
+		"Read input rows from this PostgreSQL query instead of a CSV file")
+	flags.StringVar(&c.config.MySQLQuery, "mysql-query", "", 
+		"Read input rows from this MySQL query instead of a CSV file")
+	flags.StringVar(&c.config.DBURL, "db-url", "", 
+		"Database connection string for --pg-query/--mysql-query/--pg-update (default: $DATABASE_URL)")
+	
+	// Database backfill
+	flags.StringVar(&c.config.PGUpdate, "pg-update", "", 
+		"Write H3 indexes back to this PostgreSQL table instead of an output file (reads the whole table unless --pg-query is given)")
+	flags.StringVar(&c.config.KeyColumn, "key-column", "id", 
+		"Column identifying the rows to update with --pg-update")
+	flags.StringVar(&c.config.UpdateH3Column, "h3-column", "h3_index", 
+		"Table column receiving the H3 index with --pg-update")
+	flags.IntVar(&c.config.UpdateBatchSize, "update-batch-size", 1000, 
+		"Number of rows updated per transaction with --pg-update")
+	
+	// Offline reverse geocoding
+	flags.StringVar(&c.config.AdminLookup, "admin-lookup", "", 
+		"Append admin names (e.g. country, region) from this H3 cell lookup table (.parquet or .csv with an h3 column)")
+	
+	// Time zone enrichment
+	flags.BoolVar(&c.config.AddTimezone, "add-timezone", false, 
+		"Append the IANA time zone of each coordinate in a timezone column (coarse embedded H3 index; Etc/GMT zones offshore)")
+	
+	// Country enrichment
+	flags.BoolVar(&c.config.AddCountry, "add-country", false, 
+		"Append the ISO country code of each coordinate in a country column (coarse embedded H3 boundaries)")
+	flags.StringVar(&c.config.OnAmbiguous, "on-ambiguous", c.config.OnAmbiguous, 
+		"Country for points in H3 cells straddling a border: nearest (closest outline) or empty")
+	
+	// Geocoding fallback
+	flags.BoolVar(&c.config.GeocodeMissing, "geocode-missing", false, 
+		"Fill in empty latitude/longitude by geocoding the --address-column before indexing")
+	flags.StringVar(&c.config.Geocoder, "geocoder", "nominatim", 
+		"Geocoding service for --geocode-missing (supported: "+strings.Join(geocode.Supported(), ", ")+")")
+	flags.StringVar(&c.config.GeocoderURL, "geocoder-url", "", 
+		"Base URL of the geocoding service (default: the public service)")
+	flags.StringVar(&c.config.AddressColumn, "address-column", "address", 
+		"Column holding the address geocoded by --geocode-missing")
+	flags.Float64Var(&c.config.GeocodeRate, "geocode-rate", 1, 
+		"Maximum geocoding requests per second (repeated addresses are cached)")
+	
+	// Column encryption
+	flags.StringVar(&c.config.EncryptColumns, "encrypt-columns", "", 
+		"Comma-separated passthrough columns (names or 0-based indexes) encrypted in the output with AES-GCM")
+	flags.StringVar(&c.config.KeyEnv, "key-env", "CSVH3_KEY", 
+		"Environment variable holding the --encrypt-columns key (16, 24, or 32 bytes as hex or base64)")
+	
+	// Output signing
+	flags.BoolVar(&c.config.Sign, "sign", false, 
+		"Write a detached signature of the output file (or of the --spark-compat manifest) to <file>.sig")
+	flags.StringVar(&c.config.SignKey, "key", "", 
+		"PEM private key (RSA, ECDSA, or Ed25519) used by --sign")
+	flags.BoolVar(&c.config.VerifyOutput, "verify-output", false, 
+		"Re-read the output after writing and fail unless the row count matches, the H3 column is present, and a random sample of rows recomputes to the same H3 index")
+	
+	// Redis sink
+	flags.StringVar(&c.config.RedisSink, "redis-sink", "", 
+		"Also write key→H3 index mappings to this Redis server (host:port or redis://[user:password@]host:port[/db])")
+	flags.StringVar(&c.config.KeyTemplate, "key-template", "{{.id}}", 
+		"Template rendering each Redis key from the row's columns, e.g. \"loc:{{.id}}\"")
+	
+	// Coverage check
+	flags.StringVar(&c.config.CoverageCheck, "coverage-check", "", 
+		"Polyfill this GeoJSON region at the output resolution and report the fraction of its cells with at least one data point")
+	flags.StringVar(&c.config.CoverageReport, "coverage-report", "", 
+		"Write the --coverage-check region cells without any data point to this CSV file")
+	
+	// Column statistics
+	flags.BoolVar(&c.config.Stats, "stats", false, 
+		"Add per-column statistics to the summary: min/max/mean of numeric columns and distinct counts of other columns (estimated beyond 100 values)")
+	
+	// Batch locking
+	flags.StringVar(&c.config.LockDir, "lock-dir", "", 
+		"Directory for the lock files that stop concurrent batch runs from processing the same input twice (default: next to each input, e.g. override on NFS)")
+	
+	// Resumable batches
+	flags.StringVar(&c.config.BatchStateFile, "batch-state", "", 
+		"Record the batch inputs processed successfully, with their SHA-256 checksums and settings, in this file; rerunning the batch with the same settings skips unchanged inputs (default: disabled)")
+	flags.BoolVar(&c.config.Force, "force", false, 
+		"Reprocess batch inputs that --batch-state records as already processed (implied by --overwrite)")
+	
+	// Rejected records
+	flags.StringVar(&c.config.ErrorFile, "error-file", "", 
+		"Write invalid records to this CSV file with an error_reason column instead of the output; fix them with the retry command")
+	
+	// Output schema
+	flags.StringVar(&c.config.EmitSchema, "emit-schema", "", 
+		"Write a JSON Schema describing the output columns (names, inferred types, nullability, H3 resolution) to this file, e.g. for automated table creation")
+	flags.StringVar(&c.config.FloatFormat, "float-format", "", 
+		"Re-serialize decimal numbers of the input columns typed as number with this printf float verb (e.g., %.6f) when output types are inferred (--emit-schema or avro/orc output); coordinate columns, integers and text are unchanged")
+	flags.BoolVar(&c.config.PreserveTrailingZeros, "preserve-trailing-zeros", false, 
+		"With --float-format, keep trailing zeros of the source values that the format would drop (e.g., 1.50 stays 1.50 with %g)")
+	
+	// Option profiles
+	flags.StringVar(&c.config.Profile, "profile", "", 
+		"Apply the named profile (e.g. fleet-eu) of option defaults from the user config file; command line flags take precedence")
+	flags.StringVar(&c.config.ProfileFile, "profile-file", "", 
+		"User config file defining profiles (default: ~/.csvh3/config.yaml)")
+	
+	// Audit logging
+	flags.StringVar(&c.config.AuditLog, "audit-log", "", 
+		"Append a JSON record of this invocation (user, time, args, result counts, duration) to this audit log file")
+	
+	// Run history
+	flags.StringVar(&c.config.HistoryFile, "history-file", "", 
+		"Record this run in this history file, listed by the history command (default: ~/.csvh3/history.json)")
+	flags.BoolVar(&c.config.NoHistory, "no-history", false, 
+		"Do not record this run in the run history")
+	
+	// Completion notifications
+	flags.StringVar(&c.config.NotifyWebhook, "notify-webhook", "", 
+		"POST the JSON run summary (as in --audit-log) to this URL when the run finishes or fails, e.g. a Slack or Teams webhook")
+	flags.StringVar(&c.config.NotifyCommand, "notify-command", "", 
+		"Run this shell command when the run finishes or fails, with the JSON run summary on stdin and CSVH3_STATUS set to success or failure")
+	
+	// Processing log
+	flags.StringVar(&c.config.LogFile, "log-file", "", 
+		"Append the processing log to this file instead of stderr")
+	flags.StringVar(&c.config.LogFormat, "log-format", "text", 
+		"Processing log format: text or json (one JSON object per line)")
+	
+	// Input reading
+	flags.BoolVar(&c.config.Mmap, "mmap", false, 
+		"Memory-map the input file and split records without buffered reads; can be faster for very large local files (Linux only)")
+	
+	// Concurrency
+	flags.IntVar(&c.config.Workers, "workers", 1, 
+		"Number of concurrent H3 workers; in batch mode the budget is shared across parallel files")
+	flags.IntVar(&c.config.ParallelFiles, "parallel-files", 1, 
+		"Number of input files processed concurrently in batch mode")
+	flags.BoolVar(&c.config.Unordered, "unordered", false, 
+		"With --workers, write records as soon as they are processed instead of in input order, for maximum throughput; output row order (and which duplicate --dedupe keeps) then varies between runs")
+	flags.BoolVar(&c.config.TUI, "tui", false, 
+		"Show a live dashboard (per-file progress bars, throughput, error counts, memory) in batch mode; plain progress lines are kept when output is not a terminal")
+	flags.DurationVar(&c.config.StatsInterval, "stats-interval", 0, 
+		"In batch mode, log cumulative statistics and the records, errors and files since the previous report at this interval, e.g. 15m (0 = off)")
+	
+	// Invalid row sampling
+	flags.Float64Var(&c.config.MaxInvalidPct, "max-invalid-pct", 0, 
+		"Abort with an error when more than this percentage of processed rows is invalid, checked as rows are processed (0 = no limit)")
+	flags.IntVar(&c.config.MaxShortRows, "max-short-rows", csv.DefaultMaxShortRows, 
+		"Abort when more than N consecutive rows have fewer columns than the header, suggesting the likely delimiter (0 = no limit)")
+	flags.IntVar(&c.config.ConstantCheckRows, "constant-check-rows", validator.DefaultConstantCheckRows, 
+		"Warn when the first N valid rows all have the same latitude or longitude, a sign of a wrong column mapping (0 = off)")
+	flags.StringVar(&c.config.PrecisionCheck, "precision-check", validator.PrecisionWarn, 
+		"Check that coordinates have enough decimal places for the resolution (e.g. 2 decimals are ~1.1 km, too coarse for resolution 12): warn, error, or off")
+	flags.BoolVar(&c.config.Strict, "strict", false, 
+		"Abort instead of warning when a data sanity check fails, e.g. constant coordinates")
+	flags.IntVar(&c.config.ShowInvalid, "show-invalid", 0, 
+		"In verbose mode, print the first N invalid rows verbatim with the offending value highlighted")
+	flags.IntVar(&c.config.WarnLimit, "warn-limit", logging.DefaultWarnLimit, 
+		"In verbose mode, print the first N warnings of each kind and only count the rest (0 = print all)")
+	flags.BoolVar(&c.config.NoColor, "no-color", false, 
+		"Disable colored output (color is only used on terminals; NO_COLOR is also honored)")
+	
 	// Custom flag processing for delimiter and no-headers
 	c.rootCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 		// Handle delimiter conversion
@@ -134,14 +525,99 @@ func (c *CLI) setupFlags() {
 			c.config.HasHeaders = false
 		}
 		
+		// Partitioning writes to a directory rather than a single output file
+		if cmd.Flags().Changed("partition-by-h3-res") && c.config.OutputDir == "" {
+			return fmt.Errorf("--partition-by-h3-res requires --output-dir")
+		}
+		
+		return nil
+	}
+}
+
+// checkArgs requires at least one input file unless rows are read from a database query.
+// The selected profile is applied first, since it may supply the database query.
+func (c *CLI) checkArgs(cmd *cobra.Command, args []string) error {
+	if err := c.applyProfile(cmd); err != nil {
+		return err
+	}
+	if _, query := c.config.QueryInput(); query != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("input files cannot be given with a database query")
+		}
 		return nil
 	}
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
+// applyProfile sets flags from the selected config file profile, leaving
+// flags given on the command line untouched
+func (c *CLI) applyProfile(cmd *cobra.Command) error {
+	if c.config.Profile == "" {
+		return nil
+	}
+	path := c.config.ProfileFile
+	if path == "" {
+		path = config.DefaultProfileFile()
+	}
+	profile, err := config.LoadProfile(path, c.config.Profile)
+	if err != nil {
+		return err
+	}
+	
+	flags := cmd.Flags()
+	for _, name := range profile.Options() {
+		flag := flags.Lookup(name)
+		if flag == nil || name == "profile" || name == "profile-file" {
+			return fmt.Errorf("profile %q: unsupported option %q", c.config.Profile, name)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := flags.Set(name, profile[name]); err != nil {
+			return fmt.Errorf("profile %q: invalid value for %s: %w", c.config.Profile, name, err)
+		}
+	}
+	return nil
 }
 
 // run executes the main command logic
-func (c *CLI) run(cmd *cobra.Command, args []string) error {
+func (c *CLI) run(cmd *cobra.Command, args []string) (err error) {
+	// Record the invocation in the audit log, whatever its outcome
+	audit := logging.NewAuditEntry(os.Args[1:], c.version)
+	recordHistory := !c.config.NoHistory && !c.config.Estimate
+	if c.config.AuditLog != "" || c.config.NotifyWebhook != "" || c.config.NotifyCommand != "" || recordHistory {
+		defer func() {
+			audit.Finish(err)
+			if c.config.AuditLog != "" {
+				if auditErr := logging.AppendAuditEntry(c.config.AuditLog, audit); auditErr != nil && err == nil {
+					err = fmt.Errorf("failed to write audit log: %w", auditErr)
+				}
+			}
+			if recordHistory {
+				c.recordHistory(audit)
+			}
+			c.notify(audit)
+		}()
+	}
+	
+	var inputFiles []string
+	if _, query := c.config.QueryInput(); query == "" {
+		if inputFiles, err = ExpandInputFiles(args); err != nil {
+			return err
+		}
+	}
+	audit.InputFiles = inputFiles
+	if len(inputFiles) > 1 && c.config.Estimate {
+		return fmt.Errorf("--estimate can only be used with a single input file")
+	}
+	if len(inputFiles) > 1 {
+		return c.processBatch(inputFiles, audit)
+	}
+	
 	// Set input file from positional argument
-	c.config.InputFile = args[0]
+	if len(inputFiles) == 1 {
+		c.config.InputFile = inputFiles[0]
+	}
 	
 	// Validate configuration
 	if err := c.config.Validate(); err != nil {
@@ -154,15 +630,84 @@ func (c *CLI) run(cmd *cobra.Command, args []string) error {
 		fmt.Printf("H3 Resolution: %s\n", c.config.GetResolutionDescription())
 	}
 	
+	// Report column matching before processing
+	if c.config.ExplainColumns {
+		if err := c.explainColumns(); err != nil {
+			return err
+		}
+	}
+	
+	// Estimate only, without writing output
+	if c.config.Estimate {
+		return c.estimateFile()
+	}
+	
 	// Process the file using the orchestrator
-	return c.processFile()
+	return c.processFile(audit)
+}
+
+// recordHistory adds the run to the run history. Failing to record it is reported
+// on stderr but does not change the outcome of the run.
+func (c *CLI) recordHistory(audit *logging.AuditEntry) {
+	path := c.config.HistoryFile
+	if path == "" {
+		path = history.DefaultFile()
+	}
+	if _, err := history.Record(path, audit, history.DefaultLimit); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record run history: %v\n", err)
+	}
+}
+
+// notify sends the run summary to the configured webhook and command. Notification
+// failures are reported on stderr but do not change the outcome of the run.
+func (c *CLI) notify(audit *logging.AuditEntry) {
+	if c.config.NotifyWebhook == "" && c.config.NotifyCommand == "" {
+		return
+	}
+	payload, err := notify.NewSummary(audit).Payload()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to encode notification: %v\n", err)
+		return
+	}
+	if c.config.NotifyWebhook != "" {
+		if err := notify.Webhook(c.config.NotifyWebhook, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if c.config.NotifyCommand != "" {
+		if err := notify.Command(c.config.NotifyCommand, audit.Status, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
 }
 
 // Execute runs the CLI application
 func (c *CLI) Execute() error {
+	stop := removeSpillsOnInterrupt()
+	defer stop()
 	return c.rootCmd.Execute()
 }
 
+// removeSpillsOnInterrupt removes temporary sort and aggregate spill files when the
+// process is interrupted or terminated, then exits; the returned function stops watching
+func removeSpillsOnInterrupt() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			extsort.RemoveAllSpills()
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
 // GetConfig returns the current configuration
 func (c *CLI) GetConfig() *config.Config {
 	return c.config
@@ -181,14 +726,24 @@ func (c *CLI) SetVersionInfo(version, buildTime, gitCommit string) {
 // AddHelpCommand adds additional help commands for H3 resolutions and examples
 func (c *CLI) AddHelpCommand() {
 	// H3 resolutions help command
+	var resolutionsJSON bool
 	resolutionsCmd := &cobra.Command{
 		Use:   "resolutions",
 		Short: "Show H3 resolution levels and their descriptions",
-		Long:  "Display all available H3 resolution levels with their approximate edge lengths and use cases",
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: `Display all available H3 resolution levels with their approximate edge lengths and use cases.
+With --json, print machine-readable metadata including the H3 library version, average
+cell area and edge length, and cell counts so downstream systems can record which H3
+version produced the indexes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if resolutionsJSON {
+				return c.printResolutionJSON()
+			}
 			c.printResolutionHelp()
+			return nil
 		},
 	}
+	resolutionsCmd.Flags().BoolVar(&resolutionsJSON, "json", false, 
+		"Print resolution metadata and the H3 library version as JSON")
 	
 	// Examples help command
 	examplesCmd := &cobra.Command{
@@ -204,6 +759,62 @@ func (c *CLI) AddHelpCommand() {
 	c.rootCmd.AddCommand(examplesCmd)
 }
 
+// AddSelfTestCommand adds the selftest subcommand for verifying a deployment
+func (c *CLI) AddSelfTestCommand() {
+	selfTestCmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Run built-in golden H3 cases and environment checks",
+		Long: `Run known coordinates through H3 generation and compare against known indexes at
+several resolutions, then check the environment (temp dir, number locales, memory).
+Exits with an error if any check fails; useful when deploying to a new machine.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := selftest.Run()
+			failed := 0
+			for _, result := range results {
+				status := "PASS"
+				if !result.Passed {
+					status = "FAIL"
+					failed++
+				}
+				fmt.Printf("[%s] %s: %s\n", status, result.Name, result.Detail)
+			}
+			fmt.Printf("\n%d checks, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+			if failed > 0 {
+				return fmt.Errorf("self-test failed: %d of %d checks failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	
+	c.rootCmd.AddCommand(selfTestCmd)
+}
+
+// resolutionLevels describes each H3 resolution level and its typical use
+var resolutionLevels = []struct {
+	level       int
+	description string
+	useCase     string
+	examples    string
+}{
+	{0, "Country level (~1107.71 km)", "Continental/country-wide analysis", "Global logistics, climate zones"},
+	{1, "State level (~418.68 km)", "State/province-wide analysis", "Regional planning, weather patterns"},
+	{2, "Metro level (~158.24 km)", "Metropolitan area analysis", "Urban planning, service areas"},
+	{3, "City level (~59.81 km)", "City-wide analysis", "Municipal services, demographics"},
+	{4, "District level (~22.61 km)", "District/county analysis", "School districts, postal zones"},
+	{5, "Neighborhood level (~8.54 km)", "Neighborhood analysis", "Community planning, local services"},
+	{6, "Block level (~3.23 km)", "City block analysis", "Traffic analysis, retail catchment"},
+	{7, "Building level (~1.22 km)", "Building cluster analysis", "Campus planning, facility management"},
+	{8, "Street level (~461.35 m)", "Street-level analysis (DEFAULT)", "Address geocoding, delivery routes"},
+	{9, "Intersection level (~174.38 m)", "Street intersection analysis", "Traffic lights, crosswalk planning"},
+	{10, "Property level (~65.91 m)", "Property/lot analysis", "Real estate, land parcels"},
+	{11, "Room level (~24.91 m)", "Room-level analysis", "Indoor positioning, floor plans"},
+	{12, "Desk level (~9.42 m)", "Desk/workspace analysis", "Office layouts, seating charts"},
+	{13, "Chair level (~3.56 m)", "Chair/seat analysis", "Precise indoor positioning"},
+	{14, "Book level (~1.35 m)", "Book/object analysis", "Inventory tracking, asset management"},
+	{15, "Page level (~0.51 m)", "Page/fine-detail analysis", "High-precision measurements"},
+}
+
 // printResolutionHelp prints detailed information about H3 resolution levels
 func (c *CLI) printResolutionHelp() {
 	fmt.Println("H3 Resolution Levels and Use Cases")
@@ -214,34 +825,10 @@ func (c *CLI) printResolutionHelp() {
 	fmt.Println("matches your analysis requirements:")
 	fmt.Println()
 	
-	resolutions := []struct {
-		level       int
-		description string
-		useCase     string
-		examples    string
-	}{
-		{0, "Country level (~1107.71 km)", "Continental/country-wide analysis", "Global logistics, climate zones"},
-		{1, "State level (~418.68 km)", "State/province-wide analysis", "Regional planning, weather patterns"},
-		{2, "Metro level (~158.24 km)", "Metropolitan area analysis", "Urban planning, service areas"},
-		{3, "City level (~59.81 km)", "City-wide analysis", "Municipal services, demographics"},
-		{4, "District level (~22.61 km)", "District/county analysis", "School districts, postal zones"},
-		{5, "Neighborhood level (~8.54 km)", "Neighborhood analysis", "Community planning, local services"},
-		{6, "Block level (~3.23 km)", "City block analysis", "Traffic analysis, retail catchment"},
-		{7, "Building level (~1.22 km)", "Building cluster analysis", "Campus planning, facility management"},
-		{8, "Street level (~461.35 m)", "Street-level analysis (DEFAULT)", "Address geocoding, delivery routes"},
-		{9, "Intersection level (~174.38 m)", "Street intersection analysis", "Traffic lights, crosswalk planning"},
-		{10, "Property level (~65.91 m)", "Property/lot analysis", "Real estate, land parcels"},
-		{11, "Room level (~24.91 m)", "Room-level analysis", "Indoor positioning, floor plans"},
-		{12, "Desk level (~9.42 m)", "Desk/workspace analysis", "Office layouts, seating charts"},
-		{13, "Chair level (~3.56 m)", "Chair/seat analysis", "Precise indoor positioning"},
-		{14, "Book level (~1.35 m)", "Book/object analysis", "Inventory tracking, asset management"},
-		{15, "Page level (~0.51 m)", "Page/fine-detail analysis", "High-precision measurements"},
-	}
-	
 	fmt.Printf("%-4s %-32s %-35s %s\n", "Res", "Scale & Edge Length", "Primary Use Case", "Example Applications")
 	fmt.Printf("%-4s %-32s %-35s %s\n", "---", "--------------------------------", "-----------------------------------", "--------------------")
 	
-	for _, res := range resolutions {
+	for _, res := range resolutionLevels {
 		marker := ""
 		if res.level == 8 {
 			marker = " *"
@@ -324,6 +911,42 @@ func ParseDelimiter(delimStr string) (rune, error) {
 	return rune(delimStr[0]), nil
 }
 
+// printResolutionJSON prints H3 library and resolution metadata as JSON
+func (c *CLI) printResolutionJSON() error {
+	metadata, err := h3.Resolutions()
+	if err != nil {
+		return err
+	}
+	
+	type resolutionEntry struct {
+		h3.ResolutionInfo
+		Description string `json:"description"`
+		UseCase     string `json:"use_case"`
+	}
+	output := struct {
+		ToolVersion       string            `json:"tool_version"`
+		H3                h3.LibraryInfo    `json:"h3"`
+		DefaultResolution int               `json:"default_resolution"`
+		Resolutions       []resolutionEntry `json:"resolutions"`
+	}{
+		ToolVersion:       c.version,
+		H3:                h3.Library(),
+		DefaultResolution: int(h3.ResolutionStreet),
+	}
+	for i, info := range metadata {
+		entry := resolutionEntry{ResolutionInfo: info}
+		if i < len(resolutionLevels) {
+			entry.Description = resolutionLevels[i].description
+			entry.UseCase = resolutionLevels[i].useCase
+		}
+		output.Resolutions = append(output.Resolutions, entry)
+	}
+	
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
 // printExamplesHelp prints practical usage examples
 func (c *CLI) printExamplesHelp() {
 	fmt.Println("CSV H3 Tool - Usage Examples")
@@ -440,10 +1063,329 @@ func (c *CLI) printExamplesHelp() {
 	fmt.Println("  csv-h3-tool resolutions")
 }
 
+// ExpandInputFiles expands glob patterns in the positional arguments into a sorted,
+// de-duplicated list of input files. Arguments without glob characters are kept as is.
+func ExpandInputFiles(args []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input pattern %q: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no input files match pattern: %s", arg)
+			}
+			sort.Strings(matches)
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// colorize colors summary text when standard output is a terminal and --no-color is not set
+func (c *CLI) colorize(color logging.Color, text string) string {
+	return logging.Colorize(logging.ColorEnabled(os.Stdout, c.config.NoColor), color, text)
+}
+
+// countLine formats a "label: count" summary line, colored when the count is not zero
+func (c *CLI) countLine(label string, count int, color logging.Color) string {
+	line := fmt.Sprintf("%s: %d", label, count)
+	if count == 0 {
+		return line
+	}
+	return c.colorize(color, line)
+}
+
+// FormatError formats an error for standard error, with the "Error:" label in red on terminals
+func (c *CLI) FormatError(err error) string {
+	label := logging.Colorize(logging.ColorEnabled(os.Stderr, c.config.NoColor), logging.Red, "Error:")
+	return fmt.Sprintf("%s %v", label, err)
+}
+
+// newLogger creates the processing logger: text lines on stderr, or the configured
+// format on stderr or appended to --log-file. The returned function closes the log file.
+func (c *CLI) newLogger() (logging.Logger, func() error, error) {
+	level := logging.DefaultLevel(c.config.Verbose)
+	if c.config.LogFile == "" {
+		sink, err := logging.NewSink(os.Stderr, c.config.LogFormat)
+		if err != nil {
+			return nil, nil, err
+		}
+		if text, ok := sink.(*logging.TextSink); ok {
+			text.SetColor(logging.ColorEnabled(os.Stderr, c.config.NoColor))
+		}
+		return logging.NewSinkLogger(level, sink, c.config.Verbose), func() error { return nil }, nil
+	}
+	sink, err := logging.OpenFileSink(c.config.LogFile, c.config.LogFormat)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logging.NewSinkLogger(level, sink, c.config.Verbose), sink.Close, nil
+}
+
+// processBatch processes several input files concurrently and prints a combined summary
+func (c *CLI) processBatch(inputFiles []string, audit *logging.AuditEntry) error {
+	if c.config.OutputFile != "" && !c.config.IsArchiveOutput() {
+		return fmt.Errorf("--output cannot be used with multiple input files, except for a .zip archive of the outputs")
+	}
+	if c.config.IsArchiveOutput() {
+		if err := filehandler.NewFileHandler().ValidateOutputFile(c.config.OutputFile, c.config.Overwrite); err != nil {
+			return fmt.Errorf("output archive validation failed: %w", err)
+		}
+	}
+	if c.config.EmitSchema != "" {
+		return fmt.Errorf("--emit-schema cannot be used with multiple input files")
+	}
+	if c.config.IsPartitioned() {
+		return fmt.Errorf("--output-dir cannot be used with multiple input files")
+	}
+	
+	batch := service.NewBatchProcessor(c.config, inputFiles)
+	if err := batch.CheckOutputNames(); err != nil {
+		return err
+	}
+	if err := batch.LoadState(); err != nil {
+		return err
+	}
+	logger, closeLog, err := c.newLogger()
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	batch.SetLogger(logger)
+	if c.config.Verbose {
+		fmt.Printf("Configuration: %s\n", c.config.String())
+		fmt.Printf("Batch: %d files, %d in parallel, %d workers per file\n",
+			len(inputFiles), batch.ParallelFiles(), batch.WorkersPerFile())
+	}
+	
+	// Replace the plain progress lines with a live dashboard on interactive terminals
+	var dash *dashboard
+	if c.config.TUI && isTerminal(os.Stdout) {
+		dash = newDashboard(os.Stdout)
+		batch.SetProgressReporter(dashboardInterval, dash.render)
+	}
+	
+	result := batch.Process()
+	if dash != nil {
+		dash.render(batch.Progress())
+		fmt.Println()
+	}
+	audit.SetCounts(result.TotalRecords, result.ValidRecords, result.InvalidRecords)
+	audit.RecordCategories = result.RecordCategories
+	
+	// Display per-file and combined results
+	for _, file := range result.Files {
+		if file.Skipped {
+			fmt.Printf("%s %s: being processed by another instance\n", c.colorize(logging.Yellow, "SKIPPED"), file.InputFile)
+			continue
+		}
+		if file.UpToDate {
+			fmt.Printf("%s %s: already processed, unchanged (use --force to reprocess)\n", c.colorize(logging.Yellow, "SKIPPED"), file.InputFile)
+		} else if file.Err != nil {
+			fmt.Printf("%s  %s: %v\n", c.colorize(logging.Red, "FAILED"), file.InputFile, file.Err)
+		} else {
+			fmt.Printf("%s      %s -> %s (%d records, %d valid, %d invalid)\n", c.colorize(logging.Green, "OK"), file.InputFile,
+				file.Result.OutputFile, file.Result.TotalRecords, file.Result.ValidRecords, file.Result.InvalidRecords)
+		}
+		if file.SHA256 != "" {
+			fmt.Printf("        sha256 %s\n", file.SHA256)
+		}
+	}
+	fmt.Printf("\nBatch summary:\n")
+	fmt.Printf("Files processed: %d\n", len(result.Files)-result.FailedFiles-result.SkippedFiles-result.UpToDateFiles)
+	if result.SkippedFiles > 0 {
+		fmt.Println(c.countLine("Files skipped (locked)", result.SkippedFiles, logging.Yellow))
+	}
+	if result.UpToDateFiles > 0 {
+		fmt.Println(c.countLine("Files skipped (already processed)", result.UpToDateFiles, logging.Yellow))
+	}
+	fmt.Println(c.countLine("Files failed", result.FailedFiles, logging.Red))
+	fmt.Printf("Total records: %d\n", result.TotalRecords)
+	fmt.Printf("Valid records: %d\n", result.ValidRecords)
+	fmt.Printf("Invalid records: %d\n", result.InvalidRecords)
+	fmt.Printf("Processing time: %v\n", result.ProcessingTime)
+	
+	// Package the outputs for hand-off, including those of files processed before
+	if c.config.IsArchiveOutput() {
+		manifest, err := batch.WriteArchive(c.config.OutputFile, result)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Archive written: %s (%d files)\n", c.config.OutputFile, len(manifest.Files))
+	}
+	
+	if result.FailedFiles > 0 {
+		return fmt.Errorf("%d of %d files failed to process", result.FailedFiles, len(result.Files))
+	}
+	return nil
+}
+
+// coverageListLimit is the number of empty cells listed in the summary
+const coverageListLimit = 20
+
+// printCoverage prints the coverage check summary and the first empty cells
+func printCoverage(coverage *h3.Coverage, report string) {
+	fmt.Printf("Region coverage: %d/%d cells (%.1f%%)\n",
+		coverage.Covered(), coverage.Cells(), coverage.Fraction()*100)
+	empty := coverage.EmptyCells()
+	if len(empty) == 0 {
+		return
+	}
+	fmt.Printf("Empty cells:")
+	for i, cell := range empty {
+		if i == coverageListLimit {
+			fmt.Printf(" ... and %d more", len(empty)-coverageListLimit)
+			break
+		}
+		fmt.Printf(" %s", cell)
+	}
+	fmt.Println()
+	if report != "" {
+		fmt.Printf("Empty cells written to: %s\n", report)
+	}
+}
+
+// printNullValues prints how many coordinate fields held each null value token
+func printNullValues(counts []csv.NullCount) {
+	fmt.Printf("Null coordinate values:")
+	for i, count := range counts {
+		if i > 0 {
+			fmt.Printf(",")
+		}
+		fmt.Printf(" %q %d", count.Value, count.Count)
+	}
+	fmt.Println()
+}
+
+// printCoordSources prints how many valid records took their coordinates from each source
+func printCoordSources(sources []service.SourceCount) {
+	fmt.Printf("Coordinate sources:")
+	for i, source := range sources {
+		if i > 0 {
+			fmt.Printf(",")
+		}
+		fmt.Printf(" %s %d", source.Source, source.Records)
+	}
+	fmt.Println()
+}
+
+// printColumnStats prints one line per input column: min/max/mean for numeric columns
+// and the distinct value count for the others
+func printColumnStats(profile *stats.Profile) {
+	fmt.Printf("\nColumn statistics:\n")
+	for _, column := range profile.Columns() {
+		switch {
+		case column.IsNumeric():
+			fmt.Printf("  %s: min %g, max %g, mean %.4g", column.Name, column.Min, column.Max, column.Mean())
+		case column.Count == 0:
+			fmt.Printf("  %s: no values", column.Name)
+		default:
+			distinct, exact := column.Distinct()
+			if exact {
+				fmt.Printf("  %s: %d distinct", column.Name, distinct)
+			} else {
+				fmt.Printf("  %s: ~%d distinct (estimated)", column.Name, distinct)
+			}
+		}
+		if column.Empty > 0 {
+			fmt.Printf(", %d empty", column.Empty)
+		}
+		fmt.Println()
+	}
+}
+
+// explainColumns prints how each coordinate column was matched
+func (c *CLI) explainColumns() error {
+	matches, err := service.NewOrchestrator(c.config).ExplainColumns()
+	if err != nil {
+		return fmt.Errorf("column matching failed: %w", err)
+	}
+
+	fmt.Printf("Column matching:\n")
+	for _, match := range matches {
+		fmt.Printf("  %s\n", match)
+	}
+	return nil
+}
+
+// estimateFile prints a dry-run cost estimate for the input file
+func (c *CLI) estimateFile() error {
+	estimate, err := service.NewOrchestrator(c.config).Estimate()
+	if err != nil {
+		return fmt.Errorf("estimate failed: %w", err)
+	}
+
+	fmt.Printf("Estimate for %s (no output written):\n", c.config.InputFile)
+	fmt.Printf("Input size: %d bytes\n", estimate.FileSize)
+	fmt.Printf("Sampled records: %d (%d valid)\n", estimate.SampledRecords, estimate.SampleValid)
+	if estimate.SampledRecords == 0 {
+		fmt.Printf("No records found; nothing to estimate.\n")
+		return nil
+	}
+	qualifier := "Estimated"
+	if estimate.SampleComplete {
+		qualifier = "Measured (whole file sampled)"
+	}
+	fmt.Printf("Per-record latency: %v\n", estimate.PerRecordLatency)
+	fmt.Printf("%s total records: %d\n", qualifier, estimate.EstimatedRecords)
+	fmt.Printf("%s runtime: %v (single worker)\n", qualifier, estimate.EstimatedRuntime.Round(time.Millisecond))
+	fmt.Printf("%s output size: %d bytes\n", qualifier, estimate.EstimatedOutputBytes)
+	fmt.Printf("%s distinct H3 cells: %d\n", qualifier, estimate.EstimatedDistinctCells)
+	if estimate.Coordinates.Count > 0 {
+		printCoordinates(estimate.Coordinates)
+	}
+	return nil
+}
+
+// printCoordinates prints the bounding box and coordinate histograms of a sample, so
+// the data can be checked against the expected region before processing
+func printCoordinates(coordinates *stats.Coordinates) {
+	fmt.Printf("\nBounding box of valid coordinates: lat [%g, %g], lng [%g, %g]\n",
+		coordinates.MinLat, coordinates.MaxLat, coordinates.MinLng, coordinates.MaxLng)
+	printHistogram("Latitude", coordinates.Latitudes, coordinates.Count)
+	printHistogram("Longitude", coordinates.Longitudes, coordinates.Count)
+}
+
+// histogramWidth is the length of the longest histogram bar
+const histogramWidth = 40
+
+// printHistogram prints the non-empty bins of a histogram as bars scaled to the fullest bin
+func printHistogram(name string, histogram *stats.Histogram, total int) {
+	fullest := 0
+	for _, count := range histogram.Counts {
+		fullest = max(fullest, count)
+	}
+	fmt.Printf("%s histogram:\n", name)
+	for i, count := range histogram.Counts {
+		if count == 0 {
+			continue
+		}
+		low, high := histogram.Bin(i)
+		bar := strings.Repeat("#", max(1, count*histogramWidth/fullest))
+		fmt.Printf("  [%4g, %4g) %-*s %d (%.1f%%)\n", low, high, histogramWidth, bar, count, float64(count)*100/float64(total))
+	}
+}
+
 // processFile processes the CSV file using the orchestrator
-func (c *CLI) processFile() error {
+func (c *CLI) processFile(audit *logging.AuditEntry) error {
 	// Create orchestrator with the configuration
 	orchestrator := service.NewOrchestrator(c.config)
+	logger, closeLog, err := c.newLogger()
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	orchestrator.SetLogger(logger)
 
 	// Validate all components are properly wired
 	if err := orchestrator.ValidateComponents(); err != nil {
@@ -455,17 +1397,129 @@ func (c *CLI) processFile() error {
 	if err != nil {
 		return fmt.Errorf("file processing failed: %w", err)
 	}
+	audit.OutputFile = result.OutputFile
+	audit.SetCounts(result.TotalRecords, result.ValidRecords, result.InvalidRecords)
+	audit.RecordCategories = result.RecordCategories
 
 	// Display results
-	fmt.Printf("Processing completed successfully!\n")
-	fmt.Printf("Output file: %s\n", result.OutputFile)
+	fmt.Println(c.colorize(logging.Green, "Processing completed successfully!"))
+	if c.config.IsUpdate() {
+		fmt.Printf("Updated table: %s (%d rows)\n", c.config.PGUpdate, result.UpdatedRows)
+	} else {
+		fmt.Printf("Output file: %s\n", result.OutputFile)
+	}
 	fmt.Printf("Total records: %d\n", result.TotalRecords)
 	fmt.Printf("Valid records: %d\n", result.ValidRecords)
-	fmt.Printf("Invalid records: %d\n", result.InvalidRecords)
+	fmt.Println(c.countLine("Invalid records", result.InvalidRecords, logging.Yellow))
+	if result.InvalidRecords > 0 {
+		fmt.Printf("  empty coordinates: %d, unparseable: %d, out of range: %d, H3 failures: %d, lookup failures: %d\n",
+			result.EmptyCoordinateRows, result.UnparseableRows, result.OutOfRangeRows, result.H3FailureRows, result.LookupFailureRows)
+	}
+	if result.MalformedRows > 0 {
+		fmt.Println(c.countLine("Malformed rows skipped", result.MalformedRows, logging.Yellow))
+	}
+	if c.config.ErrorFile != "" {
+		fmt.Printf("Rejected records written to %s: %d\n", c.config.ErrorFile, result.RejectedRows)
+	}
+	if c.config.EmitSchema != "" {
+		fmt.Printf("Output schema written to %s\n", c.config.EmitSchema)
+	}
+	if c.config.VerifyOutput {
+		fmt.Printf("Output verified: %d sampled H3 indexes recomputed\n", result.VerifiedRows)
+	}
+	if result.SignatureFile != "" {
+		fmt.Printf("Signature written to %s\n", result.SignatureFile)
+	}
 	fmt.Printf("Processing time: %v\n", result.ProcessingTime)
+	if c.config.IsPartitioned() {
+		fmt.Printf("Partitions written: %d\n", result.Partitions)
+		if c.config.SparkCompat {
+			fmt.Printf("Manifest and _SUCCESS markers written to %s\n", c.config.OutputDir)
+		}
+		if result.ArchiveFile != "" {
+			fmt.Printf("Archive written: %s\n", result.ArchiveFile)
+		}
+	}
+	if c.config.CoordPairs != "" {
+		fmt.Printf("Records with an invalid secondary pair: %d\n", result.InvalidPairRecords)
+	}
+	if c.config.AddEdge != "" {
+		fmt.Printf("Edges between non-neighboring cells: %d\n", result.NonNeighborEdges)
+	}
+	if c.config.RedisSink != "" {
+		fmt.Printf("Redis keys written: %d\n", result.RedisKeys)
+	}
+	if c.config.SkipFooter > 0 {
+		fmt.Printf("Footer rows skipped: %d\n", result.FooterRows)
+	}
+	if len(result.NullValues) > 0 {
+		printNullValues(result.NullValues)
+	}
+	if c.config.LatFallback != "" || c.config.CoordSources != "" {
+		fmt.Printf("Records using fallback coordinates: %d\n", result.FallbackRecords)
+	}
+	if c.config.EmitCoordSource || c.config.LatFallback != "" || c.config.CoordSources != "" {
+		printCoordSources(result.CoordSources)
+	}
+	if c.config.GeocodeMissing {
+		fmt.Printf("Geocoded records: %d\n", result.GeocodedRecords)
+	}
+	if c.config.AdminLookup != "" {
+		fmt.Printf("Records matched in admin lookup: %d\n", result.AdminMatchedRecords)
+	}
+	if c.config.AddCountry {
+		fmt.Printf("Records near a country border: %d\n", result.AmbiguousCountryRecords)
+	}
+	if c.config.ExpectBBox != "" {
+		fmt.Printf("Outside expected bbox: %d\n", result.OutsideBBoxRecords)
+	}
+	if c.config.SanityCheck != "" {
+		fmt.Printf("Failed sanity check: %d\n", result.SanityFailedRecords)
+	}
+	if c.config.Where != "" {
+		fmt.Printf("Records filtered out by --where: %d\n", result.FilteredRecords)
+	}
+	if c.config.SamplePerCell > 0 {
+		fmt.Printf("Records dropped by --sample-per-cell: %d (%d cells sampled)\n", result.SampledOutRecords, result.SampledCells)
+	}
+	if c.config.MaxSpeedKmh > 0 {
+		action := "flagged"
+		if c.config.SpeedAction == config.SpeedActionDrop {
+			action = "dropped"
+		}
+		fmt.Printf("Implausible speed records %s: %d\n", action, result.ImplausibleSpeedRecords)
+		if result.UntimedRecords > 0 {
+			fmt.Printf("Records without an id or timestamp: %d\n", result.UntimedRecords)
+		}
+	}
+	if c.config.DetectStays {
+		fmt.Printf("Stays detected: %d\n", result.Stays)
+	}
+	if c.config.AddLocalIJ && result.NoLocalIJRecords > 0 {
+		fmt.Println(c.countLine("Cells without local IJ coordinates", result.NoLocalIJRecords, logging.Yellow))
+	}
+	if len(c.config.Compute) > 0 && result.ComputeErrors > 0 {
+		fmt.Println(c.countLine("Computed values left empty", result.ComputeErrors, logging.Yellow))
+	}
+	if c.config.DedupeExact || c.config.DedupeKeys != "" {
+		fmt.Printf("Duplicate records dropped: %d\n", result.DuplicateRecords)
+	}
+	if c.config.FlagOutliers {
+		fmt.Printf("Outlier records: %d\n", result.OutlierRecords)
+	}
+	if result.Coverage != nil {
+		printCoverage(result.Coverage, c.config.CoverageReport)
+	}
+	if result.ColumnStats != nil {
+		printColumnStats(result.ColumnStats)
+	}
 
+	for _, warning := range result.DataWarnings {
+		fmt.Printf("\n%s %s\n", c.colorize(logging.Yellow, "Warning:"), warning)
+	}
 	if result.InvalidRecords > 0 {
-		fmt.Printf("\nWarning: %d records were skipped due to invalid coordinates.\n", result.InvalidRecords)
+		fmt.Printf("\n%s %d records were skipped due to invalid coordinates.\n",
+			c.colorize(logging.Yellow, "Warning:"), result.InvalidRecords)
 		fmt.Printf("Use --verbose flag to see detailed error messages.\n")
 	}
 
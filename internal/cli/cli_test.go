@@ -4,10 +4,28 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"csv-h3-tool/internal/service"
 )
 
+// TestMain points HOME at a temporary directory so runs in these tests are not
+// recorded in the user's run history
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "cli-home-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("HOME", home)
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
 func TestNewCLI(t *testing.T) {
 	cli := NewCLI()
 	
@@ -655,4 +673,375 @@ func TestCLI_GetConfig(t *testing.T) {
 	if config != cli.config {
 		t.Error("Expected GetConfig to return the same config instance")
 	}
-}
\ No newline at end of file
+}
+
+func TestExpandInputFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"b.csv", "a.csv", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("latitude,longitude\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	a := filepath.Join(tempDir, "a.csv")
+	b := filepath.Join(tempDir, "b.csv")
+
+	files, err := ExpandInputFiles([]string{filepath.Join(tempDir, "*.csv"), a})
+	if err != nil {
+		t.Fatalf("ExpandInputFiles failed: %v", err)
+	}
+	if len(files) != 2 || files[0] != a || files[1] != b {
+		t.Errorf("Expected [%s %s], got %v", a, b, files)
+	}
+
+	if _, err := ExpandInputFiles([]string{filepath.Join(tempDir, "*.json")}); err == nil {
+		t.Error("Expected error for pattern without matches")
+	}
+}
+
+func TestParseRowCount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		hasError bool
+	}{
+		{"1000", 1000, false},
+		{"1e6", 1000000, false},
+		{"2.5e3", 2500, false},
+		{"0", 0, false},
+		{"1.5", 0, true},
+		{"-10", 0, true},
+		{"many", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			count, err := ParseRowCount(tt.input)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.input)
+				}
+				return
+			}
+			if err != nil || count != tt.expected {
+				t.Errorf("Expected %d, got %d (err: %v)", tt.expected, count, err)
+			}
+		})
+	}
+}
+
+func TestCLI_EnrichJSON(t *testing.T) {
+	cli := NewCLI()
+	cli.AddEnrichJSONCommand()
+
+	var output bytes.Buffer
+	cli.rootCmd.SetIn(strings.NewReader(`[{"id":1,"lat":40.7128,"lng":-74.0060}]`))
+	cli.rootCmd.SetOut(&output)
+	cli.rootCmd.SetArgs([]string{"enrich-json", "-r", "9"})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("enrich-json failed: %v", err)
+	}
+
+	expected := `[{"id":1,"lat":40.7128,"lng":-74.0060,"h3_index":"892a1072893ffff"}]` + "\n"
+	if output.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, output.String())
+	}
+}
+
+func TestCLI_ApplyProfile(t *testing.T) {
+	profileFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := `profiles:
+  fleet-eu:
+    lat-column: breitengrad
+    lng-column: laengengrad
+    resolution: 9
+    overwrite: true
+  broken:
+    no-such-flag: 1
+`
+	if err := os.WriteFile(profileFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write profile file: %v", err)
+	}
+
+	cli := NewCLI()
+	if err := cli.rootCmd.ParseFlags([]string{"--profile", "fleet-eu", "--profile-file", profileFile, "-r", "11"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	if err := cli.applyProfile(cli.rootCmd); err != nil {
+		t.Fatalf("applyProfile failed: %v", err)
+	}
+	if cli.config.LatColumn != "breitengrad" || cli.config.LngColumn != "laengengrad" {
+		t.Errorf("Expected profile columns, got %s/%s", cli.config.LatColumn, cli.config.LngColumn)
+	}
+	if !cli.config.Overwrite {
+		t.Error("Expected Overwrite from profile")
+	}
+	if cli.config.Resolution != 11 {
+		t.Errorf("Expected command line resolution 11 to override the profile, got %d", cli.config.Resolution)
+	}
+
+	cli = NewCLI()
+	if err := cli.rootCmd.ParseFlags([]string{"--profile", "broken", "--profile-file", profileFile}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	if err := cli.applyProfile(cli.rootCmd); err == nil {
+		t.Error("Expected an error for a profile with an unknown option")
+	}
+}
+
+func TestDashboard_Render(t *testing.T) {
+	progress := service.BatchProgress{
+		Files: []service.FileStatus{
+			{InputFile: "/data/a.csv", State: service.FileDone, Size: 100, Offset: 100, Records: 10},
+			{InputFile: "/data/b.csv", State: service.FileRunning, Size: 100, Offset: 50, Records: 5, Invalid: 2},
+		},
+		Completed: 1,
+		Running:   1,
+		Records:   15,
+		Invalid:   2,
+		Elapsed:   3 * time.Second,
+	}
+
+	var output bytes.Buffer
+	dash := newDashboard(&output)
+	dash.render(progress)
+	first := output.String()
+	for _, expected := range []string{"Batch: 1/2 files complete, 1 running", "a.csv", "100%", " 50% running", "Throughput: 5 rec/s", "Invalid rows: 2"} {
+		if !strings.Contains(first, expected) {
+			t.Errorf("Expected dashboard to contain %q, got:\n%s", expected, first)
+		}
+	}
+	if strings.Contains(first, "\033[4A") {
+		t.Error("First frame should not move the cursor up")
+	}
+
+	output.Reset()
+	dash.render(progress)
+	if !strings.HasPrefix(output.String(), "\033[4A") {
+		t.Errorf("Expected the second frame to redraw over the first 4 lines, got %q", output.String())
+	}
+}
+
+func TestVisibleFiles(t *testing.T) {
+	var files []service.FileStatus
+	for i := 0; i < 20; i++ {
+		files = append(files, service.FileStatus{InputFile: fmt.Sprintf("f%d.csv", i), State: service.FileDone})
+	}
+	files[18].State = service.FileRunning
+
+	visible := visibleFiles(files)
+	if len(visible) != dashboardMaxFiles {
+		t.Fatalf("Expected %d visible files, got %d", dashboardMaxFiles, len(visible))
+	}
+	if visible[len(visible)-1].InputFile != "f18.csv" {
+		t.Errorf("Expected the running file to be shown last in input order, got %s", visible[len(visible)-1].InputFile)
+	}
+}
+
+func TestCLI_Diff(t *testing.T) {
+	tempDir := t.TempDir()
+	oldFile := filepath.Join(tempDir, "old.csv")
+	newFile := filepath.Join(tempDir, "new.csv")
+	if err := os.WriteFile(oldFile, []byte("id,h3_index\n1,a\n2,b\n"), 0644); err != nil {
+		t.Fatalf("Failed to write old file: %v", err)
+	}
+	if err := os.WriteFile(newFile, []byte("id,h3_index\n1,a\n2,c\n"), 0644); err != nil {
+		t.Fatalf("Failed to write new file: %v", err)
+	}
+
+	cli := NewCLI()
+	cli.AddDiffCommand()
+
+	var output, summary bytes.Buffer
+	cli.rootCmd.SetOut(&output)
+	cli.rootCmd.SetErr(&summary)
+	cli.rootCmd.SetArgs([]string{"diff", oldFile, newFile, "--key", "id"})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+
+	expected := "id,change,old_h3_index,new_h3_index\n2,changed,b,c\n"
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+	if !strings.Contains(summary.String(), "Compared 2 rows: 1 changed, 0 added, 0 removed") {
+		t.Errorf("Unexpected summary: %s", summary.String())
+	}
+}
+
+func TestCLI_Merge(t *testing.T) {
+	tempDir := t.TempDir()
+	left := filepath.Join(tempDir, "left.csv")
+	right := filepath.Join(tempDir, "right.csv")
+	if err := os.WriteFile(left, []byte("id,h3_index\n1,a\n2,b\n"), 0644); err != nil {
+		t.Fatalf("Failed to write left file: %v", err)
+	}
+	if err := os.WriteFile(right, []byte("h3_index,zone\na,north\n"), 0644); err != nil {
+		t.Fatalf("Failed to write right file: %v", err)
+	}
+
+	cli := NewCLI()
+	cli.AddMergeCommand()
+
+	var output, summary bytes.Buffer
+	cli.rootCmd.SetOut(&output)
+	cli.rootCmd.SetErr(&summary)
+	cli.rootCmd.SetArgs([]string{"merge", left, right, "--how", "inner"})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	expected := "id,h3_index,zone\n1,a,north\n"
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+	if !strings.Contains(summary.String(), "1 matched, 1 unmatched, 1 rows written") {
+		t.Errorf("Unexpected summary: %s", summary.String())
+	}
+}
+
+func TestCLI_Segment(t *testing.T) {
+	tempDir := t.TempDir()
+	input := filepath.Join(tempDir, "gps.csv")
+	content := `device,ts,latitude,longitude
+a,2024-05-01 08:00:00,40.7128,-74.0060
+b,2024-05-01 08:01:00,51.5074,-0.1278
+a,2024-05-01 08:05:00,40.7130,-74.0062
+a,2024-05-01 09:00:00,40.7140,-74.0070
+b,,51.5075,-0.1279
+`
+	if err := os.WriteFile(input, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	output := filepath.Join(tempDir, "trips.csv")
+
+	cli := NewCLI()
+	cli.AddSegmentCommand()
+	var summary bytes.Buffer
+	cli.rootCmd.SetErr(&summary)
+	cli.rootCmd.SetArgs([]string{"segment", input, "--id-column", "device", "--time-column", "ts", "--gap", "10m", "-o", output})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("segment failed: %v", err)
+	}
+
+	written, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(written)), "\n")
+	var trips []string
+	for _, line := range lines {
+		trips = append(trips, line[strings.LastIndex(line, ",")+1:])
+	}
+	if strings.Join(trips, " ") != "trip_id a-1 b-1 a-1 a-2 " {
+		t.Errorf("Unexpected trips %v", trips)
+	}
+	if !strings.Contains(summary.String(), "Segmented 5 rows into 3 trips (1 rows without id or timestamp)") {
+		t.Errorf("Unexpected summary: %s", summary.String())
+	}
+}
+
+func TestCLI_AggregatePivotChunks(t *testing.T) {
+	tempDir := t.TempDir()
+	input := filepath.Join(tempDir, "input.csv")
+	content := "h3_index,bucket\na,1\na,2\nb,3\n"
+	if err := os.WriteFile(input, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	output := filepath.Join(tempDir, "matrix.csv")
+
+	cli := NewCLI()
+	cli.AddAggregateCommand()
+	cli.rootCmd.SetErr(&bytes.Buffer{})
+	cli.rootCmd.SetArgs([]string{"aggregate", input, "--pivot", "bucket", "--chunk-columns", "2", "-o", output})
+	if err := cli.Execute(); err != nil {
+		t.Fatalf("aggregate failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"matrix_part001.csv": "h3_index,1,2\na,1,1\nb,0,0\n",
+		"matrix_part002.csv": "h3_index,3\na,0\nb,1\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Expected chunk %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("Chunk %s: expected %q, got %q", name, want, string(got))
+		}
+	}
+}
+
+func TestCLI_Regress(t *testing.T) {
+	goldenDir := t.TempDir()
+	run := func(args ...string) (string, error) {
+		cli := NewCLI()
+		cli.AddRegressCommand()
+		var output bytes.Buffer
+		cli.rootCmd.SetOut(&output)
+		cli.rootCmd.SetErr(&output)
+		cli.rootCmd.SetArgs(append([]string{"regress", "--golden", goldenDir}, args...))
+		err := cli.Execute()
+		return output.String(), err
+	}
+
+	if _, err := run(); err == nil {
+		t.Error("Expected regress to fail without golden files")
+	}
+	if _, err := run("--update"); err != nil {
+		t.Fatalf("regress --update failed: %v", err)
+	}
+	output, err := run()
+	if err != nil {
+		t.Fatalf("regress failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "0 failed") {
+		t.Errorf("Unexpected summary: %s", output)
+	}
+}
+
+func TestCLI_History(t *testing.T) {
+	dir := t.TempDir()
+	historyPath := filepath.Join(dir, "history.json")
+	input := filepath.Join(dir, "input.csv")
+	os.WriteFile(input, []byte("latitude,longitude\n40.7128,-74.0060\nabc,1\n"), 0644)
+
+	run := func(args ...string) (string, error) {
+		cli := NewCLI()
+		cli.AddHistoryCommand()
+		var output bytes.Buffer
+		cli.rootCmd.SetOut(&output)
+		cli.rootCmd.SetErr(&output)
+		cli.rootCmd.SetArgs(args)
+		err := cli.Execute()
+		return output.String(), err
+	}
+
+	output := filepath.Join(dir, "output.csv")
+	if _, err := run(input, "-o", output, "--history-file", historyPath); err != nil {
+		t.Fatalf("Processing failed: %v", err)
+	}
+	if _, err := run(input, "-o", output, "--history-file", historyPath, "--no-history", "--overwrite"); err != nil {
+		t.Fatalf("Processing failed: %v", err)
+	}
+
+	list, err := run("history", "--history-file", historyPath)
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if !strings.Contains(list, input+" → "+output) || strings.Contains(list, "\n2 ") {
+		t.Errorf("Expected a single recorded run, got:\n%s", list)
+	}
+
+	details, err := run("history", "show", "1", "--history-file", historyPath)
+	if err != nil {
+		t.Fatalf("history show failed: %v", err)
+	}
+	if !strings.Contains(details, "2 total, 1 valid, 1 invalid") || !strings.Contains(details, "Output:    "+output) {
+		t.Errorf("Unexpected run details:\n%s", details)
+	}
+
+	if _, err := run("history", "show", "2", "--history-file", historyPath); err == nil {
+		t.Error("Expected an error for an unknown run")
+	}
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/diff"
+)
+
+// AddDiffCommand adds the diff subcommand for comparing two enriched outputs
+func (c *CLI) AddDiffCommand() {
+	var (
+		key       string
+		h3Column  string
+		delimiter string
+		output    string
+		overwrite bool
+	)
+
+	diffCmd := &cobra.Command{
+		Use:   "diff old.csv new.csv",
+		Short: "Report rows whose H3 index changed between two enriched outputs",
+		Long: `Compare two enriched CSV outputs row by row using one or more key columns and write
+a changes-only CSV with the key columns, the change type (changed, added, or removed),
+and the old and new H3 index. Useful after a resolution change or coordinate correction.
+The old file is held in memory by key; the new file is streamed.
+
+Example:
+  csv-h3-tool diff old_with_h3.csv new_with_h3.csv --key id -o changes.csv`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := diff.Options{H3Column: h3Column}
+			for _, column := range strings.Split(key, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					opts.KeyColumns = append(opts.KeyColumns, column)
+				}
+			}
+			var err error
+			if opts.Delimiter, err = ParseDelimiter(delimiter); err != nil {
+				return err
+			}
+
+			out, closeOutput, err := createCommandOutput(cmd, output, overwrite)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			stats, err := diff.Files(args[0], args[1], out, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Compared %d rows: %d changed, %d added, %d removed\n",
+				stats.Compared, stats.Changed, stats.Added, stats.Removed)
+			return nil
+		},
+	}
+
+	flags := diffCmd.Flags()
+	flags.StringVar(&key, "key", "", "Key column(s) identifying rows in both files, comma-separated")
+	flags.StringVar(&h3Column, "h3-column", "h3_index", "H3 index column to compare")
+	flags.StringVar(&delimiter, "delimiter", ",", "Field delimiter of both files")
+	flags.StringVarP(&output, "output", "o", "", "Changes CSV file path (default: stdout)")
+	flags.BoolVar(&overwrite, "overwrite", false, "Overwrite output file if it already exists")
+	diffCmd.MarkFlagRequired("key")
+
+	c.rootCmd.AddCommand(diffCmd)
+}
+
+// createCommandOutput opens a subcommand's output file, or returns the command's
+// stdout when path is empty. Existing files are only replaced with overwrite.
+func createCommandOutput(cmd *cobra.Command, path string, overwrite bool) (io.Writer, func() error, error) {
+	if path == "" {
+		return cmd.OutOrStdout(), func() error { return nil }, nil
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create output file (use --overwrite to replace it): %w", err)
+	}
+	return file, file.Close, nil
+}
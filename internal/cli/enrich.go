@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/enrich"
+	"csv-h3-tool/internal/h3"
+)
+
+// AddEnrichJSONCommand adds the enrich-json subcommand for single-invocation JSON enrichment
+func (c *CLI) AddEnrichJSONCommand() {
+	opts := enrich.Options{}
+
+	enrichCmd := &cobra.Command{
+		Use:   "enrich-json",
+		Short: "Add H3 indexes to a JSON array of points read from stdin",
+		Long: `Read one JSON array of point objects from stdin and write the same array to stdout
+with an H3 index field added to every object. Coordinates are read from the latitude/lat
+and longitude/lng/lon fields (or --lat-field/--lng-field) as numbers or numeric strings.
+Points that cannot be indexed get a null index and an h3_error field. Nothing else is
+written to stdout, so the command is easy to wrap in serverless functions.
+
+Example:
+  echo '[{"id":1,"lat":40.7128,"lng":-74.0060}]' | csv-h3-tool enrich-json -r 9`,
+		Args: cobra.NoArgs,
+		// Errors go to stderr without usage text to keep wrapper logs clean
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := enrich.JSON(cmd.InOrStdin(), cmd.OutOrStdout(), h3.NewH3Generator(), opts)
+			return err
+		},
+	}
+
+	flags := enrichCmd.Flags()
+	flags.IntVarP(&opts.Resolution, "resolution", "r", int(h3.ResolutionStreet), "H3 resolution level (0-15)")
+	flags.StringVar(&opts.LatField, "lat-field", "", "Latitude field (default: latitude or lat)")
+	flags.StringVar(&opts.LngField, "lng-field", "", "Longitude field (default: longitude, lng, or lon)")
+	flags.StringVar(&opts.H3Field, "h3-field", "h3_index", "Field receiving the H3 index")
+
+	c.rootCmd.AddCommand(enrichCmd)
+}
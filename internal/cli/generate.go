@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/synthetic"
+	"csv-h3-tool/internal/validator"
+)
+
+// AddGenerateCommand adds the generate subcommand for creating synthetic test datasets
+func (c *CLI) AddGenerateCommand() {
+	var (
+		rows        string
+		bbox        string
+		invalidRate float64
+		seed        int64
+		output      string
+		overwrite   bool
+	)
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a synthetic CSV dataset for benchmarks and demos",
+		Long: `Generate a synthetic CSV file with id, latitude, longitude, name, category, timestamp,
+and value columns. Valid coordinates are spread uniformly over --bbox (default: the whole
+globe) and a fraction of rows gets invalid coordinates (out of range, empty, or malformed).
+The same options and --seed always produce the same file.
+
+Example:
+  csv-h3-tool generate --rows 1e6 --bbox "-125,24,-66,50" --invalid-rate 0.05 -o synthetic.csv`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			count, err := ParseRowCount(rows)
+			if err != nil {
+				return err
+			}
+			opts := synthetic.Options{Rows: count, InvalidRate: invalidRate, Seed: seed}
+			if bbox != "" {
+				if opts.BBox, err = validator.ParseBoundingBox(bbox); err != nil {
+					return fmt.Errorf("invalid bounding box: %w", err)
+				}
+			}
+
+			stats, err := synthetic.GenerateFile(output, opts, overwrite)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Generated %s: %d rows (%d with invalid coordinates)\n", output, stats.Rows, stats.InvalidRows)
+			return nil
+		},
+	}
+
+	flags := generateCmd.Flags()
+	flags.StringVar(&rows, "rows", "1000", "Number of data rows; accepts scientific notation (e.g., 1e6)")
+	flags.StringVar(&bbox, "bbox", "", "Region for valid coordinates as 'minLng,minLat,maxLng,maxLat' (default: whole globe)")
+	flags.Float64Var(&invalidRate, "invalid-rate", 0, "Fraction of rows with invalid coordinates (0-1)")
+	flags.Int64Var(&seed, "seed", 1, "Random seed")
+	flags.StringVarP(&output, "output", "o", "", "Output CSV file path")
+	flags.BoolVar(&overwrite, "overwrite", false, "Overwrite output file if it already exists")
+	generateCmd.MarkFlagRequired("output")
+
+	c.rootCmd.AddCommand(generateCmd)
+}
+
+// ParseRowCount parses a non-negative whole row count, accepting scientific notation like 1e6
+func ParseRowCount(value string) (int, error) {
+	count, err := strconv.ParseFloat(value, 64)
+	if err != nil || count < 0 || count != math.Trunc(count) || count > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid row count %q: expected a non-negative whole number (e.g., 1000 or 1e6)", value)
+	}
+	return int(count), nil
+}
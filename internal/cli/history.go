@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/history"
+	"csv-h3-tool/internal/logging"
+)
+
+// AddHistoryCommand adds the history subcommand for listing recent runs
+func (c *CLI) AddHistoryCommand() {
+	var (
+		historyPath string
+		limit       int
+	)
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "List recent runs with their inputs, outputs, counts, and durations",
+		Long: fmt.Sprintf(`List recent processing runs, newest first. Every run is recorded in a small
+history file (default: ~/.csvh3/history.json, or --history-file) unless --no-history
+is given; the last %d runs are kept. Use "history show <id>" for the full
+arguments and record breakdown of a run.`, history.DefaultLimit),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := history.Load(historyFile(historyPath))
+			if err != nil {
+				return err
+			}
+			if limit > 0 && len(runs) > limit {
+				runs = runs[len(runs)-limit:]
+			}
+			printRuns(cmd.OutOrStdout(), runs)
+			return nil
+		},
+	}
+	historyCmd.PersistentFlags().StringVar(&historyPath, "history-file", "", "Path to the history file (default: ~/.csvh3/history.json)")
+	historyCmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of runs to list (0 = all)")
+
+	showCmd := &cobra.Command{
+		Use:   "show <run-id>",
+		Short: "Show the details of a recorded run",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid run ID: %s", args[0])
+			}
+			run, ok, err := history.Find(historyFile(historyPath), id)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("run %d not found in history", id)
+			}
+			printRun(cmd.OutOrStdout(), run)
+			return nil
+		},
+	}
+
+	historyCmd.AddCommand(showCmd)
+	c.rootCmd.AddCommand(historyCmd)
+}
+
+// historyFile returns the given history file path or the default one
+func historyFile(path string) string {
+	if path == "" {
+		return history.DefaultFile()
+	}
+	return path
+}
+
+// printRuns prints runs as a table, newest first
+func printRuns(out io.Writer, runs []history.Run) {
+	if len(runs) == 0 {
+		fmt.Fprintln(out, "No runs recorded")
+		return
+	}
+
+	fmt.Fprintf(out, "%-5s %-19s %-7s %10s %10s %-9s %s\n", "ID", "Started", "Status", "Records", "Valid", "Duration", "Input → Output")
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		files := strings.Join(run.InputFiles, ", ")
+		if run.OutputFile != "" {
+			files += " → " + run.OutputFile
+		}
+		fmt.Fprintf(out, "%-5d %-19s %-7s %10d %10d %-9s %s\n", run.ID, run.StartedAt.Format("2006-01-02 15:04:05"),
+			run.Status, run.TotalRecords, run.ValidRecords, runDuration(run), files)
+	}
+}
+
+// printRun prints the details of a single run
+func printRun(out io.Writer, run history.Run) {
+	fmt.Fprintf(out, "Run %d\n", run.ID)
+	fmt.Fprintf(out, "  Started:   %s\n", run.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(out, "  Duration:  %s\n", runDuration(run))
+	fmt.Fprintf(out, "  Status:    %s\n", run.Status)
+	if run.Error != "" {
+		fmt.Fprintf(out, "  Error:     %s\n", run.Error)
+	}
+	fmt.Fprintf(out, "  User:      %s@%s\n", run.User, run.Hostname)
+	fmt.Fprintf(out, "  Version:   %s\n", run.Version)
+	fmt.Fprintf(out, "  Arguments: %s\n", strings.Join(logging.RedactArgs(run.Args), " "))
+	for _, input := range run.InputFiles {
+		fmt.Fprintf(out, "  Input:     %s\n", input)
+	}
+	if run.OutputFile != "" {
+		fmt.Fprintf(out, "  Output:    %s\n", run.OutputFile)
+	}
+
+	fmt.Fprintf(out, "  Records:   %d total, %d valid, %d invalid\n", run.TotalRecords, run.ValidRecords, run.InvalidRecords)
+	categories := []struct {
+		label string
+		count int
+	}{
+		{"Malformed rows", run.MalformedRows},
+		{"Empty coordinates", run.EmptyCoordinateRows},
+		{"Unparseable coordinates", run.UnparseableRows},
+		{"Out of range", run.OutOfRangeRows},
+		{"H3 failures", run.H3FailureRows},
+		{"Lookup failures", run.LookupFailureRows},
+	}
+	for _, category := range categories {
+		if category.count > 0 {
+			fmt.Fprintf(out, "    %-24s %d\n", category.label+":", category.count)
+		}
+	}
+	if run.BytesRead > 0 || run.BytesWritten > 0 {
+		fmt.Fprintf(out, "  Bytes:     %d read, %d written\n", run.BytesRead, run.BytesWritten)
+	}
+}
+
+// runDuration formats the duration of a run
+func runDuration(run history.Run) string {
+	return (time.Duration(run.DurationMS) * time.Millisecond).String()
+}
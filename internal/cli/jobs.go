@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/jobs"
+)
+
+// AddJobsCommand adds the jobs subcommand for inspecting and managing the persistent job queue
+func (c *CLI) AddJobsCommand() {
+	var queuePath string
+
+	jobsCmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "List, retry, and cancel queued processing jobs",
+		Long: `Manage the persistent job queue. Queued and in-flight jobs are stored on disk
+so they survive restarts; jobs that were running when the worker stopped are re-queued
+when a worker next opens the queue.`,
+	}
+	jobsCmd.PersistentFlags().StringVar(&queuePath, "queue", jobs.DefaultQueueFile, "Path to the job queue file")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all jobs and their status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			queue, err := jobs.Open(queuePath)
+			if err != nil {
+				return err
+			}
+			printJobs(queue.List())
+			return nil
+		},
+	}
+
+	retryCmd := &cobra.Command{
+		Use:   "retry <job-id>",
+		Short: "Re-queue a failed or canceled job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateJob(queuePath, args[0], (*jobs.Queue).Retry, "re-queued")
+		},
+	}
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <job-id>",
+		Short: "Cancel a queued or running job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateJob(queuePath, args[0], (*jobs.Queue).Cancel, "canceled")
+		},
+	}
+
+	jobsCmd.AddCommand(listCmd, retryCmd, cancelCmd)
+	c.rootCmd.AddCommand(jobsCmd)
+}
+
+// updateJob applies a status change to the job identified by idStr
+func updateJob(queuePath, idStr string, update func(*jobs.Queue, int) error, verb string) error {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %s", idStr)
+	}
+
+	queue, err := jobs.Open(queuePath)
+	if err != nil {
+		return err
+	}
+	if err := update(queue, id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Job %d %s\n", id, verb)
+	return nil
+}
+
+// printJobs prints jobs as a table
+func printJobs(list []jobs.Job) {
+	if len(list) == 0 {
+		fmt.Println("No jobs in queue")
+		return
+	}
+
+	fmt.Printf("%-5s %-9s %-8s %-20s %s\n", "ID", "Status", "Attempts", "Updated", "Input File")
+	for _, job := range list {
+		fmt.Printf("%-5d %-9s %-8d %-20s %s\n", job.ID, job.Status, job.Attempts,
+			job.UpdatedAt.Format("2006-01-02 15:04:05"), job.InputFile)
+		if job.Error != "" {
+			fmt.Printf("      error: %s\n", job.Error)
+		}
+	}
+}
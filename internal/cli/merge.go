@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/merge"
+)
+
+// AddMergeCommand adds the merge subcommand for joining two H3-indexed files
+func (c *CLI) AddMergeCommand() {
+	var (
+		opts      merge.Options
+		delimiter string
+		output    string
+		overwrite bool
+	)
+
+	mergeCmd := &cobra.Command{
+		Use:   "merge left.csv right.csv",
+		Short: "Join two H3-indexed CSV files on a shared column",
+		Long: `Join two CSV files with header rows on a shared column (h3_index by default) with a
+streaming hash join: the right file is held in memory by join key and the left file is
+streamed, so files larger than memory can be merged as long as the right file fits.
+Output columns are the left columns followed by the right columns except the join column;
+clashing right column names get a "_right" suffix.
+
+  --how left   keep every left row, with empty right columns when unmatched (default)
+  --how inner  keep only left rows with a matching right row
+
+Example:
+  csv-h3-tool merge trips_with_h3.csv zones_with_h3.csv --on h3_index --how inner -o merged.csv`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if opts.Delimiter, err = ParseDelimiter(delimiter); err != nil {
+				return err
+			}
+
+			out, closeOutput, err := createCommandOutput(cmd, output, overwrite)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			stats, err := merge.Files(args[0], args[1], out, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Merged %d left rows with %d right rows: %d matched, %d unmatched, %d rows written\n",
+				stats.LeftRows, stats.RightRows, stats.MatchedRows, stats.UnmatchedRows, stats.OutputRows)
+			return nil
+		},
+	}
+
+	flags := mergeCmd.Flags()
+	flags.StringVar(&opts.On, "on", "h3_index", "Join column present in both files")
+	flags.StringVar(&opts.How, "how", merge.HowLeft, "Join type: left or inner")
+	flags.StringVar(&delimiter, "delimiter", ",", "Field delimiter of both files and the output")
+	flags.StringVarP(&output, "output", "o", "", "Merged CSV file path (default: stdout)")
+	flags.BoolVar(&overwrite, "overwrite", false, "Overwrite output file if it already exists")
+
+	c.rootCmd.AddCommand(mergeCmd)
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/regress"
+)
+
+// AddRegressCommand adds the regress subcommand for checking a build against golden outputs
+func (c *CLI) AddRegressCommand() {
+	var (
+		goldenDir string
+		update    bool
+	)
+
+	regressCmd := &cobra.Command{
+		Use:   "regress --golden golden_dir/",
+		Short: "Compare the outputs of bundled reference inputs against golden files",
+		Long: `Process the reference inputs bundled with the binary (several resolutions, invalid
+and quoted rows, multi-line fields, headerless input, dedupe and sorting) and compare
+each output byte for byte against the golden file of the same name in the golden
+directory. Exits with an error if any output differs; use it to check a new binary
+before swapping it into a production pipeline.
+
+Create the golden files once with a trusted binary using --update.
+
+Example:
+  csv-h3-tool regress --golden golden/ --update   # with the current binary
+  csv-h3-tool regress --golden golden/            # with the new binary`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := regress.Run(goldenDir, update)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			failed := 0
+			for _, result := range results {
+				status := "PASS"
+				if !result.Passed {
+					status = "FAIL"
+					failed++
+				}
+				fmt.Fprintf(out, "[%s] %s: %s\n", status, result.Name, result.Detail)
+			}
+			fmt.Fprintf(out, "\n%d cases, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+			if failed > 0 {
+				return fmt.Errorf("regression check failed: %d of %d cases differ", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	flags := regressCmd.Flags()
+	flags.StringVar(&goldenDir, "golden", "", "Directory holding the golden output files")
+	flags.BoolVar(&update, "update", false, "Write the current outputs as the golden files instead of comparing")
+	regressCmd.MarkFlagRequired("golden")
+
+	c.rootCmd.AddCommand(regressCmd)
+}
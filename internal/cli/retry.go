@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/retry"
+	"csv-h3-tool/internal/service"
+)
+
+// AddRetryCommand adds the retry subcommand for reprocessing an --error-file
+func (c *CLI) AddRetryCommand() {
+	var (
+		fix        string
+		into       string
+		remaining  string
+		latColumn  string
+		lngColumn  string
+		resolution int
+		overwrite  bool
+	)
+
+	retryCmd := &cobra.Command{
+		Use:   "retry rejects.csv --fix name[,name] --into output.csv",
+		Short: "Fix rejected rows from an --error-file and merge them into the output",
+		Long: fmt.Sprintf(`Apply named fixes to the coordinates of the rows rejected by a run with --error-file,
+reprocess them, and append the recovered rows to the main output of that run. The
+output columns must match, so pass the same column and resolution options as the
+original run. Rows that are still invalid are written to --remaining with their new
+error_reason, ready for another attempt.
+
+Available fixes (applied in the order given): %s
+
+Example:
+  csv-h3-tool input.csv -o output.csv --error-file rejects.csv
+  csv-h3-tool retry rejects.csv --fix swap-latlng --into output.csv`, strings.Join(retry.FixNames(), ", ")),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fixes, err := retry.ParseFixes(fix)
+			if err != nil {
+				return err
+			}
+			if remaining == "" {
+				remaining = strings.TrimSuffix(args[0], filepath.Ext(args[0])) + "_remaining.csv"
+			}
+
+			workDir, err := os.MkdirTemp("", "csv-h3-retry-*")
+			if err != nil {
+				return fmt.Errorf("failed to create temporary directory: %w", err)
+			}
+			defer os.RemoveAll(workDir)
+
+			// Write the fixed rows as a new input
+			fixedPath := filepath.Join(workDir, "fixed.csv")
+			fixedFile, err := os.Create(fixedPath)
+			if err != nil {
+				return err
+			}
+			rows, err := retry.Prepare(args[0], fixedFile, retry.Options{
+				LatColumn:    latColumn,
+				LngColumn:    lngColumn,
+				ReasonColumn: csv.ErrorReasonColumn,
+				Fixes:        fixes,
+			})
+			if closeErr := fixedFile.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+
+			// Reprocess them, diverting rows that are still invalid
+			cfg := config.NewConfig()
+			cfg.InputFile = fixedPath
+			cfg.OutputFile = filepath.Join(workDir, "recovered.csv")
+			cfg.ErrorFile = remaining
+			cfg.LatColumn = latColumn
+			cfg.LngColumn = lngColumn
+			cfg.Resolution = resolution
+			cfg.Overwrite = overwrite
+			result, err := service.NewOrchestrator(cfg).ProcessFile()
+			if err != nil {
+				return err
+			}
+
+			recovered, err := retry.Append(cfg.OutputFile, into)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Retried %d rows: %d recovered into %s, %d still invalid in %s\n",
+				rows, recovered, into, result.RejectedRows, remaining)
+			return nil
+		},
+	}
+
+	flags := retryCmd.Flags()
+	flags.StringVar(&fix, "fix", "", "Fix(es) to apply, comma-separated: "+strings.Join(retry.FixNames(), ", "))
+	flags.StringVar(&into, "into", "", "Main output file of the original run to append the recovered rows to")
+	flags.StringVar(&remaining, "remaining", "", "CSV file for rows that are still invalid (default: <rejects>_remaining.csv)")
+	flags.StringVar(&latColumn, "lat-column", "latitude", "Latitude column name")
+	flags.StringVar(&lngColumn, "lng-column", "longitude", "Longitude column name")
+	flags.IntVarP(&resolution, "resolution", "r", 8, "H3 resolution (0-15), as in the original run")
+	flags.BoolVar(&overwrite, "overwrite", false, "Overwrite the --remaining file if it already exists")
+	retryCmd.MarkFlagRequired("fix")
+	retryCmd.MarkFlagRequired("into")
+
+	c.rootCmd.AddCommand(retryCmd)
+}
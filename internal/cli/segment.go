@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/h3"
+	"csv-h3-tool/internal/service"
+)
+
+// AddSegmentCommand adds the segment subcommand for splitting GPS traces into trips
+func (c *CLI) AddSegmentCommand() {
+	cfg := config.NewConfig()
+
+	segmentCmd := &cobra.Command{
+		Use:   "segment input.csv --id-column device --time-column ts --gap 10m",
+		Short: "Assign trip IDs to GPS traces by time gaps and add H3 indexes",
+		Long: `Split the trace of each entity into trips and add H3 indexes in one pass. A new trip
+starts when an entity's consecutive points are more than --gap apart in time, or when
+time steps backwards. The trip_id column holds "<id>-<n>" for the entity's n-th trip;
+rows without an id or a parseable timestamp get an empty trip_id.
+
+The rows of each entity must be in time order; traces of different entities may be
+interleaved. Timestamps are RFC 3339 or "2006-01-02 15:04:05" (UTC when no zone is
+given), or Unix epoch seconds or milliseconds.
+
+Example:
+  csv-h3-tool segment gps.csv --id-column device --time-column ts --gap 10m -r 9 -o trips.csv`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg.InputFile = args[0]
+			result, err := service.NewOrchestrator(cfg).ProcessFile()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Segmented %d rows into %d trips (%d rows without id or timestamp); %d valid, %d invalid coordinates; output written to %s\n",
+				result.TotalRecords, result.Trips, result.UntimedRecords, result.ValidRecords, result.InvalidRecords, result.OutputFile)
+			return nil
+		},
+	}
+
+	flags := segmentCmd.Flags()
+	flags.StringVar(&cfg.IdColumn, "id-column", "", "Entity id column, e.g. a device or vehicle id")
+	flags.StringVar(&cfg.TimeColumn, "time-column", "", "Timestamp column")
+	flags.DurationVar(&cfg.TripGap, "gap", 10*time.Minute, "Time gap between consecutive points of an entity that starts a new trip")
+	flags.StringVarP(&cfg.OutputFile, "output", "o", "", "Output CSV file path (default: input_with_h3.csv)")
+	flags.StringVar(&cfg.LatColumn, "lat-column", cfg.LatColumn, "Latitude column name")
+	flags.StringVar(&cfg.LngColumn, "lng-column", cfg.LngColumn, "Longitude column name")
+	flags.IntVarP(&cfg.Resolution, "resolution", "r", int(h3.ResolutionStreet), "H3 resolution level (0-15)")
+	flags.BoolVar(&cfg.Overwrite, "overwrite", false, "Overwrite output file if it already exists")
+	segmentCmd.MarkFlagRequired("id-column")
+	segmentCmd.MarkFlagRequired("time-column")
+
+	c.rootCmd.AddCommand(segmentCmd)
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"csv-h3-tool/internal/service"
+)
+
+const (
+	// dashboardInterval is how often the --tui dashboard is redrawn
+	dashboardInterval = 250 * time.Millisecond
+	// dashboardBarWidth is the width of each file's progress bar in characters
+	dashboardBarWidth = 30
+	// dashboardMaxFiles caps the file rows shown; finished files are hidden first
+	dashboardMaxFiles = 15
+)
+
+// dashboard renders a live batch progress dashboard on an ANSI terminal,
+// redrawing in place over its previous frame
+type dashboard struct {
+	out   io.Writer
+	lines int // Lines drawn by the previous frame
+}
+
+// newDashboard creates a dashboard writing to out
+func newDashboard(out io.Writer) *dashboard {
+	return &dashboard{out: out}
+}
+
+// isTerminal reports whether the file is an interactive terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// render draws a frame for the batch progress
+func (d *dashboard) render(progress service.BatchProgress) {
+	var frame strings.Builder
+	if d.lines > 0 {
+		fmt.Fprintf(&frame, "\033[%dA", d.lines) // Move back to the top of the previous frame
+	}
+
+	lines := d.frame(progress)
+	for _, line := range lines {
+		frame.WriteString("\033[2K") // Clear the line
+		frame.WriteString(line)
+		frame.WriteString("\n")
+	}
+	// Clear lines left over from a longer previous frame
+	for i := len(lines); i < d.lines; i++ {
+		frame.WriteString("\033[2K\n")
+	}
+	if d.lines > len(lines) {
+		fmt.Fprintf(&frame, "\033[%dA", d.lines-len(lines))
+	}
+	d.lines = len(lines)
+
+	fmt.Fprint(d.out, frame.String())
+}
+
+// frame returns the dashboard lines for the batch progress
+func (d *dashboard) frame(progress service.BatchProgress) []string {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	throughput := 0.0
+	if seconds := progress.Elapsed.Seconds(); seconds > 0 {
+		throughput = float64(progress.Records) / seconds
+	}
+
+	lines := []string{
+		fmt.Sprintf("Batch: %d/%d files complete, %d running, %d failed   Elapsed: %s",
+			progress.Completed, len(progress.Files), progress.Running, progress.Failed,
+			progress.Elapsed.Truncate(time.Second)),
+	}
+
+	files := visibleFiles(progress.Files)
+	for _, file := range files {
+		lines = append(lines, fileLine(file))
+	}
+	if hidden := len(progress.Files) - len(files); hidden > 0 {
+		lines = append(lines, fmt.Sprintf("  ... %d more files", hidden))
+	}
+
+	lines = append(lines, fmt.Sprintf("Records: %d   Throughput: %.0f rec/s   Invalid rows: %d   Memory: %.1f MiB",
+		progress.Records, throughput, progress.Invalid, float64(memory.Alloc)/(1024*1024)))
+	return lines
+}
+
+// visibleFiles picks up to dashboardMaxFiles files to show, preferring running,
+// failed, and pending files over finished ones and keeping input order
+func visibleFiles(files []service.FileStatus) []service.FileStatus {
+	if len(files) <= dashboardMaxFiles {
+		return files
+	}
+	show := make([]bool, len(files))
+	shown := 0
+	for _, states := range [][]service.FileState{
+		{service.FileRunning, service.FileFailed}, {service.FilePending}, {service.FileDone},
+	} {
+		for i, file := range files {
+			for _, state := range states {
+				if shown < dashboardMaxFiles && !show[i] && file.State == state {
+					show[i] = true
+					shown++
+				}
+			}
+		}
+	}
+
+	visible := make([]service.FileStatus, 0, shown)
+	for i, file := range files {
+		if show[i] {
+			visible = append(visible, file)
+		}
+	}
+	return visible
+}
+
+// fileLine formats one file's progress bar and counters
+func fileLine(file service.FileStatus) string {
+	fraction := file.Fraction()
+	filled := int(fraction * dashboardBarWidth)
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", dashboardBarWidth-filled)
+	return fmt.Sprintf("  [%s] %3.0f%% %-7s %-28s %10d rows %8d invalid",
+		bar, fraction*100, file.State, truncateName(filepath.Base(file.InputFile), 28), file.Records, file.Invalid)
+}
+
+// truncateName shortens a name to at most width characters
+func truncateName(name string, width int) string {
+	runes := []rune(name)
+	if len(runes) <= width {
+		return name
+	}
+	return string(runes[:width-3]) + "..."
+}
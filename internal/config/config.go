@@ -2,9 +2,24 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"text/template"
+	"time"
 	"csv-h3-tool/internal/h3"
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/database"
+	"csv-h3-tool/internal/encrypt"
+	"csv-h3-tool/internal/expr"
+	"csv-h3-tool/internal/geo"
 	"csv-h3-tool/internal/filehandler"
+	"csv-h3-tool/internal/geocode"
+	"csv-h3-tool/internal/logging"
+	"csv-h3-tool/internal/sign"
+	"csv-h3-tool/internal/validator"
 )
 
 // Config holds all configuration options for the CSV H3 tool
@@ -17,6 +32,51 @@ type Config struct {
 	LatColumn string `json:"lat_column"`
 	LngColumn string `json:"lng_column"`
 	
+	// Coordinate columns used when the primary latitude or longitude is empty (empty = none)
+	LatFallback string `json:"lat_fallback"`
+	LngFallback string `json:"lng_fallback"`
+	
+	// Further fallback coordinate columns as "lat:lng,...", tried in order after LatFallback/LngFallback
+	CoordSources string `json:"coord_sources"`
+	
+	// Write a coord_source column naming the columns that supplied each row's coordinates
+	EmitCoordSource bool `json:"emit_coord_source"`
+	
+	// Column matching: report how columns were matched; disable alias fallback, or
+	// extend it with fuzzy and index matching
+	ExplainColumns   bool `json:"explain_columns"`
+	NoColumnFallback bool `json:"no_column_fallback"`
+	FuzzyColumns     bool `json:"fuzzy_columns"`
+	
+	// Multiple coordinate pairs per row as "lat:lng:h3_col,..."; the first pair replaces
+	// LatColumn/LngColumn and names the H3 column
+	CoordPairs string `json:"coord_pairs"`
+	
+	// Directed H3 edges between H3 output columns as "origin_h3:dest_h3:edge_col,..."
+	AddEdge string `json:"add_edge"`
+	
+	// Great-circle distance and initial bearing from the first to the second coordinate pair
+	AddDistanceKm bool `json:"add_distance_km"`
+	AddBearing    bool `json:"add_bearing"`
+	
+	// UTM zone/easting/northing and MGRS grid reference columns for each coordinate
+	AddUTM  bool `json:"add_utm"`
+	AddMGRS bool `json:"add_mgrs"`
+	
+	// Attributes of each record's H3 cell: center coordinates, area and average edge
+	// length, whether it is a pentagon, its base cell and icosahedron faces, and its
+	// vertex indexes
+	AddCentroid   bool `json:"add_centroid"`
+	AddCellMeta   bool `json:"add_cell_meta"`
+	FlagPentagons bool `json:"flag_pentagons"`
+	AddBaseCell   bool `json:"add_base_cell"`
+	AddVertexes   bool `json:"add_vertexes"`
+	
+	// Local IJ coordinates of each record's H3 cell relative to the Anchor cell, which
+	// must be at the output resolution
+	AddLocalIJ bool   `json:"add_local_ij"`
+	Anchor     string `json:"anchor"`
+	
 	// H3 configuration
 	Resolution int `json:"resolution"`
 	
@@ -24,16 +84,293 @@ type Config struct {
 	HasHeaders bool `json:"has_headers"`
 	Delimiter  rune `json:"delimiter"`
 	
+	// Whitespace cleanup of every passthrough field (collapse implies trim)
+	TrimFields         bool `json:"trim_fields"`
+	CollapseWhitespace bool `json:"collapse_whitespace"`
+	
+	// Normalize every passthrough field to NFC without invisible characters (headers
+	// are always normalized for column matching)
+	NormalizeUnicode bool `json:"normalize_unicode"`
+	
+	// Locale used to tolerate thousands separators in coordinates (empty = strict)
+	NumberLocale string `json:"number_locale"`
+	
+	// Comma-separated coordinate values treated as empty, e.g. "NA,N/A,null,-999" (empty = none)
+	NullValues string `json:"null_values"`
+	
+	// Lines discarded at the start of the input, before the header (0 = none)
+	SkipLines int `json:"skip_lines"`
+	
+	// Leading lines starting with this prefix are discarded before the header, e.g. "#"
+	SkipComments string `json:"skip_comments"`
+	
+	// Lines discarded at the end of the input, e.g. a totals line (0 = none)
+	SkipFooter int `json:"skip_footer"`
+	
+	// Template for generated output file names, e.g. "{{.Stem}}_{{.Resolution}}_h3{{.Ext}}"
+	OutputNameTemplate string `json:"output_name_template"`
+	
 	// File handling options
 	Overwrite bool `json:"overwrite"`
 	
+	// Output quoting ("always", "minimal", "never"; empty = minimal) and CRLF line endings
+	Quote string `json:"quote"`
+	CRLF  bool   `json:"crlf"`
+	
+	// Prefix output fields that spreadsheets would run as formulas with a quote
+	EscapeFormulas bool `json:"escape_formulas"`
+	
+	// Retries of failed output writes (e.g., on network filesystems)
+	WriteRetries int           `json:"write_retries"`
+	RetryBackoff time.Duration `json:"retry_backoff"`
+	
 	// Output options
 	Verbose bool `json:"verbose"`
 	
+	// Write latitude_parsed/longitude_parsed columns with the values used for H3 generation
+	EmitParsedCoords bool `json:"emit_parsed_coords"`
+	
+	// Outlier detection based on H3 neighborhood density
+	FlagOutliers     bool `json:"flag_outliers"`
+	OutlierK         int  `json:"outlier_k"`
+	OutlierMinPoints int  `json:"outlier_min_points"`
+	
+	// Expression selecting the rows to process, e.g. speed > 0 && country == "US"
+	// (empty = all rows)
+	Where string `json:"where"`
+	
+	// Output columns computed from the input columns, each name=expression,
+	// e.g. speed_kmh=speed_ms*3.6
+	Compute []string `json:"compute"`
+	
+	// Entity id and timestamp columns of GPS traces, for the trajectory stages
+	IdColumn   string `json:"id_column"`
+	TimeColumn string `json:"time_column"`
+	
+	// Start a new trip_id when an entity's consecutive points are further apart in
+	// time (0 = no trip segmentation)
+	TripGap time.Duration `json:"trip_gap"`
+	
+	// Flag or drop points implying a higher speed from the entity's previous point
+	// (0 = no speed check)
+	MaxSpeedKmh float64 `json:"max_speed_kmh"`
+	SpeedAction string  `json:"speed_action"`
+	
+	// Label points staying within StayRadius (a distance such as "100m") of the first
+	// point of their run for at least StayMinutes
+	DetectStays bool    `json:"detect_stays"`
+	StayRadius  string  `json:"stay_radius"`
+	StayMinutes float64 `json:"stay_minutes"`
+	
+	// Keep at most this many valid rows per H3 cell, chosen by reservoir sampling
+	// (0 = all rows)
+	SamplePerCell int `json:"sample_per_cell"`
+	
+	// Duplicate row removal (bloom filter based)
+	DedupeExact    bool    `json:"dedupe_exact"`
+	DedupeKeys     string  `json:"dedupe_keys"`
+	DedupeFPRate   float64 `json:"dedupe_fp_rate"`
+	DedupeCapacity int     `json:"dedupe_capacity"`
+	
+	// Output ordering (external merge sort with temp spill files)
+	SortByH3      bool   `json:"sort_by_h3"`
+	SortChunkSize int    `json:"sort_chunk_size"`
+	TempDir       string `json:"temp_dir"`
+	
+	// Hive-style partitioned output, enabled when OutputDir is set
+	PartitionByH3Res int    `json:"partition_by_h3_res"`
+	OutputDir        string `json:"output_dir"`
+	
+	// Write a _manifest.json and Spark-style _SUCCESS markers once partitioned output completes
+	SparkCompat bool `json:"spark_compat"`
+	
+	// Memory-map local input files instead of reading them through a buffer (Linux only)
+	Mmap bool `json:"mmap"`
+	
+	// Concurrency: H3 workers shared across files and files processed at once in batch mode
+	Workers       int `json:"workers"`
+	ParallelFiles int `json:"parallel_files"`
+	
+	// Write records as workers finish them instead of in input order (faster with
+	// --workers, but output order and dedupe "first" records vary between runs)
+	Unordered bool `json:"unordered"`
+	
+	// Abort when more than this percentage of processed rows is invalid (0 = no limit)
+	MaxInvalidPct float64 `json:"max_invalid_pct"`
+	
+	// Abort when more than this many consecutive rows have fewer columns than the
+	// header, which usually means a wrong delimiter (0 = no limit)
+	MaxShortRows int `json:"max_short_rows"`
+	
+	// Warn when the first N valid rows all have the same latitude or longitude (0 = off)
+	ConstantCheckRows int `json:"constant_check_rows"`
+	
+	// Check that coordinates have enough decimal places for the resolution: "warn",
+	// "error" to abort, or "off" (empty = off)
+	PrecisionCheck string `json:"precision_check"`
+	
+	// Abort instead of warning when a data sanity check fails
+	Strict bool `json:"strict"`
+	
+	// Number of invalid rows to print verbatim in verbose mode
+	ShowInvalid int `json:"show_invalid"`
+	
+	// Verbose warnings shown per kind before the rest are only counted (0 = show all)
+	WarnLimit int `json:"warn_limit"`
+	
+	// Disable colored terminal output (it is only used on terminals anyway)
+	NoColor bool `json:"no_color"`
+	
+	// Expected region as "minLng,minLat,maxLng,maxLat"; rows outside are flagged
+	ExpectBBox string `json:"expect_bbox"`
+	
+	// Fail-fast output schema expectations (0 / empty = not checked)
+	ExpectOutputColumns int    `json:"expect_output_columns"`
+	ExpectHeaders       string `json:"expect_headers"`
+	
+	// Dry-run cost estimate from a sample of rows (no output is written)
+	Estimate           bool `json:"estimate"`
+	EstimateSampleRows int  `json:"estimate_sample_rows"`
+	
+	// Database query read instead of an input file; DBURL is the connection string,
+	// DATABASE_URL when empty (see DatabaseURL)
+	PGQuery    string `json:"pg_query"`
+	MySQLQuery string `json:"mysql_query"`
+	DBURL      string `json:"db_url"`
+	
+	// Backfill mode: write H3 indexes back to this PostgreSQL table instead of an output file,
+	// matching rows by KeyColumn and setting UpdateH3Column
+	PGUpdate        string `json:"pg_update"`
+	KeyColumn       string `json:"key_column"`
+	UpdateH3Column  string `json:"update_h3_column"`
+	UpdateBatchSize int    `json:"update_batch_size"`
+	
+	// Geocoding fallback filling in empty coordinates from AddressColumn, with at most
+	// GeocodeRate requests per second to the Geocoder service (GeocoderURL empty = public service)
+	GeocodeMissing bool    `json:"geocode_missing"`
+	Geocoder       string  `json:"geocoder"`
+	GeocoderURL    string  `json:"geocoder_url"`
+	AddressColumn  string  `json:"address_column"`
+	GeocodeRate    float64 `json:"geocode_rate"`
+	
+	// H3 cell lookup table (Parquet or CSV) whose attribute columns, e.g. country and
+	// region names, are appended for each point (empty = disabled)
+	AdminLookup string `json:"admin_lookup"`
+	
+	// Append the IANA time zone of each coordinate from the embedded coarse zone index
+	AddTimezone bool `json:"add_timezone"`
+	
+	// Append the ISO country code of each coordinate from the embedded coarse region set;
+	// OnAmbiguous picks the policy for cells straddling a border: "nearest" or "empty"
+	AddCountry  bool   `json:"add_country"`
+	OnAmbiguous string `json:"on_ambiguous"`
+	
+	// GeoJSON region whose H3 cells are checked for data coverage, and an optional
+	// CSV file receiving the region cells without any data point
+	CoverageCheck  string `json:"coverage_check"`
+	CoverageReport string `json:"coverage_report"`
+	
+	// Per-column statistics in the summary (min/max/mean or distinct counts)
+	Stats bool `json:"stats"`
+	
+	// CSV file receiving invalid records (with an error_reason column) instead of the output
+	ErrorFile string `json:"error_file"`
+	
+	// JSON Schema file describing the output columns and their inferred types
+	EmitSchema string `json:"emit_schema"`
+	
+	// Printf float verb re-serializing the decimal numbers of passthrough number columns,
+	// other than the coordinate columns, when the output types are inferred (empty =
+	// values are written as read)
+	FloatFormat           string `json:"float_format"`
+	PreserveTrailingZeros bool   `json:"preserve_trailing_zeros"`
+	
+	// Passthrough columns encrypted in the output (names or 0-based indexes, empty = none)
+	// with the AES key read from the KeyEnv environment variable (hex or base64)
+	EncryptColumns string `json:"encrypt_columns"`
+	KeyEnv         string `json:"key_env"`
+	
+	// Detached signature of the output file (or partition manifest) made with the PEM
+	// private key in SignKey
+	Sign    bool   `json:"sign"`
+	SignKey string `json:"sign_key"`
+	
+	// Re-read the output file after writing to check its row count, H3 column, and a
+	// random sample of recomputed H3 indexes
+	VerifyOutput bool `json:"verify_output"`
+	
+	// Directory for the lock files guarding batch input files (default: next to each input)
+	LockDir string `json:"lock_dir"`
+	
+	// File recording the batch inputs processed successfully, with their checksums, so a
+	// rerun with the same settings skips the unchanged ones (empty = disabled); Force or
+	// Overwrite reprocesses them anyway
+	BatchStateFile string `json:"batch_state_file"`
+	Force          bool   `json:"force"`
+	
+	// Output format: "csv" (default), "duckdb" to write Table in the OutputFile database,
+	// or "avro" / "orc" for an Avro container or ORC file
+	OutputFormat string `json:"output_format"`
+	Table        string `json:"table"`
+	
+	// Type of the H3 index columns in Avro and ORC output: "string" (default) or "long"
+	H3Format string `json:"h3_format"`
+	
+	// Redis server receiving key→H3 index mappings alongside the output (empty = disabled);
+	// KeyTemplate renders each key from the row's columns, e.g. "loc:{{.id}}"
+	RedisSink   string `json:"redis_sink"`
+	KeyTemplate string `json:"key_template"`
+	
+	// Audit log file recording every invocation (empty = disabled)
+	AuditLog string `json:"audit_log"`
+	
+	// Run history file listed by the history command (empty = ~/.csvh3/history.json);
+	// NoHistory disables recording the run
+	HistoryFile string `json:"history_file"`
+	NoHistory   bool   `json:"no_history"`
+	
+	// Completion notifications: the JSON run summary is POSTed to the webhook and/or
+	// piped to the shell command when a run finishes or fails (empty = disabled)
+	NotifyWebhook string `json:"notify_webhook"`
+	NotifyCommand string `json:"notify_command"`
+	
+	// Log file receiving the processing log instead of stderr, and the log format
+	// ("text" or "json" lines; empty = text)
+	LogFile   string `json:"log_file"`
+	LogFormat string `json:"log_format"`
+	
+	// Named option profile and the YAML file defining it (empty = ~/.csvh3/config.yaml)
+	Profile     string `json:"profile"`
+	ProfileFile string `json:"profile_file"`
+	
+	// Live terminal dashboard for batch runs (plain progress lines when not a terminal)
+	TUI bool `json:"tui"`
+	
+	// Interval at which long batch runs log cumulative statistics and the activity
+	// since the previous report (0 = disabled)
+	StatsInterval time.Duration `json:"stats_interval"`
+	
+	// Coarse location sanity check: "land", "water", or "country:US[,CA...]"
+	SanityCheck string `json:"sanity_check"`
+	
 	// Internal file handler
 	fileHandler *filehandler.FileHandler
 }
 
+// Supported output formats
+const (
+	OutputFormatCSV    = "csv"
+	OutputFormatDuckDB = "duckdb"
+	OutputFormatAvro   = "avro"
+	OutputFormatORC    = "orc"
+)
+
+// Supported H3 index formats of Avro and ORC output
+const (
+	H3FormatString = "string"
+	H3FormatLong   = "long"
+)
+
 // NewConfig creates a new configuration with default values
 func NewConfig() *Config {
 	return &Config{
@@ -46,19 +383,47 @@ func NewConfig() *Config {
 		Delimiter:   ',',
 		Overwrite:   false,
 		Verbose:     false,
+		OutlierK:    1,
+		OutlierMinPoints: 3,
+		DedupeFPRate:   0.001,
+		DedupeCapacity: 10000000,
+		SortChunkSize:  100000,
+		PartitionByH3Res: -1,
+		Workers:        1,
+		RetryBackoff:   2 * time.Second,
+		ParallelFiles:  1,
+		EstimateSampleRows: 10000,
+		SpeedAction:        SpeedActionFlag,
+		StayRadius:         "100m",
+		StayMinutes:        10,
+		Geocoder:       "nominatim",
+		AddressColumn:  "address",
+		GeocodeRate:    1,
+		OnAmbiguous:    validator.AmbiguousNearest,
+		WarnLimit:      logging.DefaultWarnLimit,
+		MaxShortRows:   csv.DefaultMaxShortRows,
+		ConstantCheckRows: validator.DefaultConstantCheckRows,
+		PrecisionCheck: validator.PrecisionWarn,
+		KeyEnv:         "CSVH3_KEY",
 		fileHandler: filehandler.NewFileHandler(),
 	}
 }
 
 // Validate validates the configuration and returns any errors
 func (c *Config) Validate() error {
-	// Validate input file
-	if c.InputFile == "" {
-		return fmt.Errorf("input file path is required")
-	}
-	
-	if err := c.validateInputFile(); err != nil {
-		return fmt.Errorf("input file validation failed: %w", err)
+	// Validate input file or database query
+	if _, query := c.QueryInput(); query != "" {
+		if err := c.validateQueryInput(); err != nil {
+			return fmt.Errorf("database input validation failed: %w", err)
+		}
+	} else {
+		if c.InputFile == "" {
+			return fmt.Errorf("input file path is required")
+		}
+		
+		if err := c.validateInputFile(); err != nil {
+			return fmt.Errorf("input file validation failed: %w", err)
+		}
 	}
 	
 	// Validate column names
@@ -66,24 +431,458 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("column validation failed: %w", err)
 	}
 	
+	// Validate coordinate pairs
+	if c.CoordPairs != "" {
+		if _, err := csv.ParseCoordPairs(c.CoordPairs); err != nil {
+			return fmt.Errorf("coordinate pairs validation failed: %w", err)
+		}
+	}
+	
+	// Validate directed edges
+	if c.AddEdge != "" {
+		if _, err := csv.ParseEdgeSpecs(c.AddEdge); err != nil {
+			return fmt.Errorf("edge validation failed: %w", err)
+		}
+	}
+	
+	// Distance and bearing need an origin and a destination pair
+	if c.AddDistanceKm || c.AddBearing {
+		pairs, err := csv.ParseCoordPairs(c.CoordPairs)
+		if c.CoordPairs == "" || err != nil || len(pairs) < 2 {
+			return fmt.Errorf("distance and bearing columns require at least two coordinate pairs")
+		}
+	}
+	
 	// Validate H3 resolution
 	if err := c.validateResolution(); err != nil {
 		return fmt.Errorf("resolution validation failed: %w", err)
 	}
 	
-	// Validate output file
-	if err := c.validateOutputFile(); err != nil {
+	// Validate number locale
+	if c.NumberLocale != "" {
+		if _, ok := csv.GetNumberLocale(c.NumberLocale); !ok {
+			return fmt.Errorf("unsupported number locale %q (supported: %s)",
+				c.NumberLocale, strings.Join(csv.SupportedNumberLocales(), ", "))
+		}
+	}
+	
+	// Validate output quoting
+	if _, err := csv.ParseQuoteMode(c.Quote); err != nil {
+		return fmt.Errorf("quote mode validation failed: %w", err)
+	}
+	
+	// Validate expected bounding box
+	if c.ExpectBBox != "" {
+		if _, err := validator.ParseBoundingBox(c.ExpectBBox); err != nil {
+			return fmt.Errorf("expected bounding box validation failed: %w", err)
+		}
+	}
+	
+	// Validate location sanity check
+	if c.SanityCheck != "" {
+		if _, err := validator.ParseSanityCheck(c.SanityCheck, nil); err != nil {
+			return fmt.Errorf("sanity check validation failed: %w", err)
+		}
+	}
+	
+	// Validate outlier detection settings
+	if c.FlagOutliers {
+		if c.OutlierK < 1 || c.OutlierK > 10 {
+			return fmt.Errorf("outlier k-ring radius %d is out of valid range [1, 10]", c.OutlierK)
+		}
+		if c.OutlierMinPoints < 2 {
+			return fmt.Errorf("outlier minimum points must be at least 2, got %d", c.OutlierMinPoints)
+		}
+	}
+	
+	// Validate dedupe settings
+	if c.DedupeExact && c.DedupeKeys != "" {
+		return fmt.Errorf("dedupe-exact and dedupe-keys cannot be used together")
+	}
+	if c.DedupeExact || c.DedupeKeys != "" {
+		if c.DedupeFPRate <= 0 || c.DedupeFPRate >= 1 {
+			return fmt.Errorf("dedupe false-positive rate %g is out of valid range (0, 1)", c.DedupeFPRate)
+		}
+		if c.DedupeCapacity <= 0 {
+			return fmt.Errorf("dedupe capacity must be positive, got %d", c.DedupeCapacity)
+		}
+	}
+	
+	// Validate sort settings
+	if c.SortByH3 {
+		if c.SortChunkSize <= 0 {
+			return fmt.Errorf("sort chunk size must be positive, got %d", c.SortChunkSize)
+		}
+		if c.TempDir != "" {
+			if err := c.fileHandler.ValidateOutputDirectory(c.TempDir); err != nil {
+				return fmt.Errorf("temp directory validation failed: %w", err)
+			}
+		}
+	}
+	
+	// Validate geocoding fallback
+	if c.GeocodeMissing {
+		if c.AddressColumn == "" {
+			return fmt.Errorf("an address column is required for geocoding")
+		}
+		if _, err := geocode.New(c.Geocoder, c.GeocoderURL, c.GeocodeRate); err != nil {
+			return fmt.Errorf("geocoder validation failed: %w", err)
+		}
+	}
+	
+	// Validate admin lookup table
+	if c.AdminLookup != "" {
+		if err := c.fileHandler.ValidateInputFile(c.AdminLookup); err != nil {
+			return fmt.Errorf("admin lookup table validation failed: %w", err)
+		}
+	}
+	
+	// Validate country enrichment
+	if c.AddCountry {
+		if _, err := validator.NewCountryLookup(c.OnAmbiguous, nil); err != nil {
+			return fmt.Errorf("country lookup validation failed: %w", err)
+		}
+	}
+	
+	// Validate coverage check region
+	if c.CoverageCheck != "" {
+		if err := c.fileHandler.ValidateInputFile(c.CoverageCheck); err != nil {
+			return fmt.Errorf("coverage region validation failed: %w", err)
+		}
+		if c.CoverageReport != "" {
+			if err := c.fileHandler.ValidateOutputFile(c.CoverageReport, c.Overwrite); err != nil {
+				return fmt.Errorf("coverage report validation failed: %w", err)
+			}
+		}
+	} else if c.CoverageReport != "" {
+		return fmt.Errorf("a coverage report requires a coverage check region")
+	}
+	
+	// Validate memory-mapped input
+	if c.Mmap {
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("memory-mapped input is only supported on Linux")
+		}
+		if _, query := c.QueryInput(); query != "" {
+			return fmt.Errorf("memory-mapped input requires an input file, not a query")
+		}
+	}
+	
+	// Validate lock directory
+	if c.LockDir != "" {
+		if info, err := os.Stat(c.LockDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("lock directory does not exist: %s", c.LockDir)
+		}
+	}
+	
+	// Validate error file
+	if c.ErrorFile != "" && (c.ErrorFile == c.InputFile || c.ErrorFile == c.OutputFile) {
+		return fmt.Errorf("error file must differ from the input and output files: %s", c.ErrorFile)
+	}
+	if c.EmitSchema != "" && (c.EmitSchema == c.InputFile || c.EmitSchema == c.OutputFile || c.EmitSchema == c.ErrorFile) {
+		return fmt.Errorf("schema file must differ from the input, output and error files: %s", c.EmitSchema)
+	}
+	
+	// Validate column encryption; backfill mode writes no passthrough columns
+	if c.EncryptColumns != "" {
+		if c.IsUpdate() {
+			return fmt.Errorf("--encrypt-columns cannot be used with --pg-update")
+		}
+		if _, err := c.EncryptionKey(); err != nil {
+			return fmt.Errorf("encryption key validation failed: %w", err)
+		}
+	}
+	
+	// Validate output signing
+	if c.SignKey != "" && !c.Sign {
+		return fmt.Errorf("--key requires --sign")
+	}
+	if c.Sign {
+		if err := c.validateSigning(); err != nil {
+			return fmt.Errorf("signing validation failed: %w", err)
+		}
+	}
+	
+	// Validate computed columns; expressions are compiled against the input headers
+	for _, definition := range c.Compute {
+		if _, _, err := expr.Assignment(definition); err != nil {
+			return err
+		}
+	}
+	
+	// Validate output verification; only single CSV output files are re-read
+	if c.VerifyOutput && (c.IsUpdate() || c.OutputFormat == OutputFormatDuckDB || c.IsTypedOutput() || c.IsPartitioned()) {
+		return fmt.Errorf("--verify-output requires a single CSV output file")
+	}
+	
+	// Validate Redis sink
+	if c.RedisSink != "" {
+		if _, err := c.ParseKeyTemplate(); err != nil {
+			return fmt.Errorf("key template validation failed: %w", err)
+		}
+	}
+	
+	// Validate output schema expectations
+	if c.ExpectOutputColumns < 0 {
+		return fmt.Errorf("expected output column count cannot be negative: %d", c.ExpectOutputColumns)
+	}
+	if c.ExpectHeaders != "" {
+		if err := c.fileHandler.ValidateInputFile(c.ExpectHeaders); err != nil {
+			return fmt.Errorf("expected headers file validation failed: %w", err)
+		}
+	}
+	
+	// Validate write retry settings
+	if c.WriteRetries < 0 {
+		return fmt.Errorf("write retries cannot be negative: %d", c.WriteRetries)
+	}
+	if c.RetryBackoff < 0 {
+		return fmt.Errorf("retry backoff cannot be negative: %v", c.RetryBackoff)
+	}
+	if c.StatsInterval < 0 {
+		return fmt.Errorf("stats interval cannot be negative: %v", c.StatsInterval)
+	}
+	
+	// Validate concurrency settings
+	if c.Workers < 0 {
+		return fmt.Errorf("worker count cannot be negative: %d", c.Workers)
+	}
+	if c.ParallelFiles < 0 {
+		return fmt.Errorf("parallel file count cannot be negative: %d", c.ParallelFiles)
+	}
+	
+	if c.ShowInvalid < 0 {
+		return fmt.Errorf("show-invalid count cannot be negative: %d", c.ShowInvalid)
+	}
+	
+	if c.WarnLimit < 0 {
+		return fmt.Errorf("warn limit cannot be negative: %d", c.WarnLimit)
+	}
+	
+	if c.MaxInvalidPct < 0 || c.MaxInvalidPct > 100 {
+		return fmt.Errorf("maximum invalid percentage must be between 0 and 100: %g", c.MaxInvalidPct)
+	}
+	
+	if c.SkipLines < 0 {
+		return fmt.Errorf("skip lines cannot be negative: %d", c.SkipLines)
+	}
+	
+	if c.NoColumnFallback && c.FuzzyColumns {
+		return fmt.Errorf("--fuzzy-columns cannot be used with --no-column-fallback")
+	}
+	
+	if err := c.validateTrajectory(); err != nil {
+		return err
+	}
+	
+	if c.PreserveTrailingZeros && c.FloatFormat == "" {
+		return fmt.Errorf("--preserve-trailing-zeros requires --float-format")
+	}
+	if c.FloatFormat != "" {
+		if c.EmitSchema == "" && !c.IsTypedOutput() {
+			return fmt.Errorf("--float-format requires inferred output types: use --emit-schema or a typed output format")
+		}
+		if _, err := csv.NewFloatFormatter(c.FloatFormat, c.PreserveTrailingZeros); err != nil {
+			return err
+		}
+	}
+	
+	if c.AddLocalIJ {
+		if c.Anchor == "" {
+			return fmt.Errorf("local IJ coordinates require an anchor cell")
+		}
+		anchor, err := h3.ParseCell(c.Anchor)
+		if err != nil {
+			return fmt.Errorf("invalid anchor: %w", err)
+		}
+		if anchor.Resolution() != c.Resolution {
+			return fmt.Errorf("anchor %s is at resolution %d, but the output resolution is %d", c.Anchor, anchor.Resolution(), c.Resolution)
+		}
+	}
+	
+	if c.SamplePerCell < 0 {
+		return fmt.Errorf("sample per cell cannot be negative: %d", c.SamplePerCell)
+	}
+	
+	if c.SkipFooter < 0 {
+		return fmt.Errorf("skip footer cannot be negative: %d", c.SkipFooter)
+	}
+	
+	if c.MaxShortRows < 0 {
+		return fmt.Errorf("maximum short rows cannot be negative: %d", c.MaxShortRows)
+	}
+	
+	if c.ConstantCheckRows < 0 {
+		return fmt.Errorf("constant check rows cannot be negative: %d", c.ConstantCheckRows)
+	}
+	
+	if _, err := validator.ParsePrecisionMode(c.PrecisionCheck); err != nil {
+		return err
+	}
+	
+	if c.EstimateSampleRows < 0 {
+		return fmt.Errorf("estimate sample rows cannot be negative: %d", c.EstimateSampleRows)
+	}
+	
+	if c.NotifyWebhook != "" {
+		if u, err := url.Parse(c.NotifyWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notify webhook must be an http(s) URL, got %q", c.NotifyWebhook)
+		}
+	}
+	
+	// Validate log format
+	switch c.LogFormat {
+	case "", logging.FormatText, logging.FormatJSON:
+	default:
+		return fmt.Errorf("unsupported log format %q (supported: %s, %s)", c.LogFormat, logging.FormatText, logging.FormatJSON)
+	}
+	
+	// Validate output format
+	switch c.OutputFormat {
+	case "", OutputFormatCSV, OutputFormatDuckDB, OutputFormatAvro, OutputFormatORC:
+	default:
+		return fmt.Errorf("unsupported output format %q (supported: %s, %s, %s, %s)", c.OutputFormat,
+			OutputFormatCSV, OutputFormatDuckDB, OutputFormatAvro, OutputFormatORC)
+	}
+	switch c.H3Format {
+	case "", H3FormatString:
+	case H3FormatLong:
+		if !c.IsTypedOutput() {
+			return fmt.Errorf("--h3-format %s requires --output-format %s or %s", c.H3Format, OutputFormatAvro, OutputFormatORC)
+		}
+	default:
+		return fmt.Errorf("unsupported H3 format %q (supported: %s, %s)", c.H3Format, H3FormatString, H3FormatLong)
+	}
+	if c.IsTypedOutput() && c.IsPartitioned() {
+		return fmt.Errorf("%s output cannot be partitioned", c.OutputFormat)
+	}
+	if c.SparkCompat && !c.IsPartitioned() {
+		return fmt.Errorf("--spark-compat requires partitioned output (--output-dir)")
+	}
+	
+	// Nothing is written when only estimating, and updates go back to the database
+	if c.Estimate || c.IsUpdate() {
+		return nil
+	}
+	
+	if c.OutputFormat == OutputFormatDuckDB {
+		if err := c.validateDuckDBOutput(); err != nil {
+			return fmt.Errorf("DuckDB output validation failed: %w", err)
+		}
+		return nil
+	}
+	
+	// Validate output file or partitioned output directory
+	if c.IsPartitioned() {
+		if err := c.validatePartitioning(); err != nil {
+			return fmt.Errorf("partitioned output validation failed: %w", err)
+		}
+		if c.IsArchiveOutput() {
+			if isWithinDir(c.OutputDir, c.OutputFile) {
+				return fmt.Errorf("output archive %s must not be inside the output directory %s", c.OutputFile, c.OutputDir)
+			}
+			if err := c.fileHandler.ValidateOutputFile(c.OutputFile, c.Overwrite); err != nil {
+				return fmt.Errorf("output archive validation failed: %w", err)
+			}
+		}
+	} else if c.IsArchiveOutput() {
+		return fmt.Errorf("a zip archive output (%s) requires multiple input files or --output-dir", c.OutputFile)
+	} else if err := c.validateOutputFile(); err != nil {
 		return fmt.Errorf("output file validation failed: %w", err)
 	}
 	
 	return nil
 }
 
-// validateInputFile checks if the input file exists and is readable
+// validateInputFile checks if the input file exists and is readable; for zip archive
+// inputs the archive is checked and the member when the input is opened
 func (c *Config) validateInputFile() error {
+	if archive, _, ok := csv.SplitZipPath(c.InputFile); ok {
+		return c.fileHandler.ValidateInputFile(archive)
+	}
 	return c.fileHandler.ValidateInputFile(c.InputFile)
 }
 
+// QueryInput returns the database driver and query when rows are read from a
+// database instead of an input file (empty query otherwise)
+func (c *Config) QueryInput() (driver, query string) {
+	switch {
+	case c.PGQuery != "":
+		return database.DriverPostgres, c.PGQuery
+	case c.MySQLQuery != "":
+		return database.DriverMySQL, c.MySQLQuery
+	case c.PGUpdate != "":
+		// Backfill the whole table unless a query selects the rows
+		return database.DriverPostgres, "SELECT * FROM " + database.QuoteIdentifier(database.DriverPostgres, c.PGUpdate)
+	}
+	return "", ""
+}
+
+// InputHasHeaders reports whether the input starts with a header row; query results
+// always carry column names
+func (c *Config) InputHasHeaders() bool {
+	_, query := c.QueryInput()
+	return c.HasHeaders || query != ""
+}
+
+// DatabaseURL returns the database connection string: DBURL, or the DATABASE_URL
+// environment variable when none was given. The environment is read at use time so
+// the connection string never shows up as a flag default.
+func (c *Config) DatabaseURL() string {
+	if c.DBURL != "" {
+		return c.DBURL
+	}
+	return os.Getenv("DATABASE_URL")
+}
+
+// validateQueryInput validates database query input
+func (c *Config) validateQueryInput() error {
+	if c.PGQuery != "" && c.MySQLQuery != "" {
+		return fmt.Errorf("only one of a PostgreSQL or MySQL query can be given")
+	}
+	if c.InputFile != "" {
+		return fmt.Errorf("an input file and a database query cannot be used together")
+	}
+	if c.DatabaseURL() == "" {
+		return fmt.Errorf("a database URL is required with a database query (--db-url or $DATABASE_URL)")
+	}
+	if c.Estimate {
+		return fmt.Errorf("estimate requires an input file")
+	}
+	if c.IsUpdate() {
+		return c.validateUpdate()
+	}
+	if c.OutputFile == "" && !c.IsPartitioned() {
+		return fmt.Errorf("an output file is required with a database query")
+	}
+	return nil
+}
+
+// IsUpdate reports whether H3 indexes are written back to the database instead of an output file
+func (c *Config) IsUpdate() bool {
+	return c.PGUpdate != ""
+}
+
+// validateUpdate validates the database backfill configuration
+func (c *Config) validateUpdate() error {
+	if c.MySQLQuery != "" {
+		return fmt.Errorf("a PostgreSQL update cannot read from a MySQL query")
+	}
+	if c.KeyColumn == "" {
+		return fmt.Errorf("a key column is required with a database update")
+	}
+	if c.UpdateH3Column == "" {
+		return fmt.Errorf("an H3 column is required with a database update")
+	}
+	if c.UpdateBatchSize < 0 {
+		return fmt.Errorf("update batch size cannot be negative: %d", c.UpdateBatchSize)
+	}
+	if c.OutputFile != "" || c.IsPartitioned() {
+		return fmt.Errorf("a database update cannot also write an output file")
+	}
+	return nil
+}
+
 // validateColumns validates the column configuration
 func (c *Config) validateColumns() error {
 	if c.LatColumn == "" {
@@ -102,6 +901,14 @@ func (c *Config) validateColumns() error {
 		return fmt.Errorf("latitude and longitude columns cannot be the same: %s", c.LatColumn)
 	}
 	
+	// Fallback columns are only used as a pair
+	if (c.LatFallback == "") != (c.LngFallback == "") {
+		return fmt.Errorf("fallback latitude and longitude columns must be given together")
+	}
+	if _, err := c.FallbackSources(); err != nil {
+		return err
+	}
+	
 	return nil
 }
 
@@ -117,13 +924,213 @@ func (c *Config) validateResolution() error {
 func (c *Config) validateOutputFile() error {
 	// If no output file specified, generate default name
 	if c.OutputFile == "" {
-		c.OutputFile = c.fileHandler.GenerateOutputPath(c.InputFile, "_with_h3")
+		outputFile, err := c.fileHandler.GenerateTemplatedOutputPath(c.InputFile, c.OutputNameTemplate, c.Resolution)
+		if err != nil {
+			return err
+		}
+		if c.IsTypedOutput() && c.OutputNameTemplate == "" {
+			outputFile = strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "." + c.OutputFormat
+		}
+		c.OutputFile = outputFile
 	}
 	
 	return c.fileHandler.ValidateOutputFile(c.OutputFile, c.Overwrite)
 }
 
 
+// CoordinateColumns returns the primary latitude and longitude columns and the name of
+// their H3 output column (empty for the default h3_index)
+func (c *Config) CoordinateColumns() (lat, lng, h3Column string) {
+	if c.CoordPairs != "" {
+		if pairs, err := csv.ParseCoordPairs(c.CoordPairs); err == nil {
+			return pairs[0].LatColumn, pairs[0].LngColumn, pairs[0].H3Column
+		}
+	}
+	return c.LatColumn, c.LngColumn, ""
+}
+
+// FallbackSources returns the fallback coordinate columns in priority order: the
+// --lat-fallback/--lng-fallback pair followed by the --coord-sources pairs
+func (c *Config) FallbackSources() ([]csv.CoordPair, error) {
+	var sources []csv.CoordPair
+	if c.LatFallback != "" || c.LngFallback != "" {
+		spec := strings.TrimSpace(c.LatFallback) + ":" + strings.TrimSpace(c.LngFallback)
+		pair, err := csv.ParseCoordSources(spec)
+		if err != nil {
+			return nil, fmt.Errorf("fallback columns: %w", err)
+		}
+		sources = append(sources, pair...)
+	}
+	if c.CoordSources != "" {
+		pairs, err := csv.ParseCoordSources(c.CoordSources)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, pairs...)
+	}
+	return sources, nil
+}
+
+// EncryptionKey returns the column encryption key from the KeyEnv environment variable
+func (c *Config) EncryptionKey() ([]byte, error) {
+	if c.KeyEnv == "" {
+		return nil, fmt.Errorf("a key environment variable is required to encrypt columns")
+	}
+	value, ok := os.LookupEnv(c.KeyEnv)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", c.KeyEnv)
+	}
+	key, err := encrypt.ParseKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.KeyEnv, err)
+	}
+	return key, nil
+}
+
+// validateSigning validates signing the output with a private key
+func (c *Config) validateSigning() error {
+	if c.SignKey == "" {
+		return fmt.Errorf("a private key file (--key) is required")
+	}
+	if c.IsUpdate() || c.OutputFormat == OutputFormatDuckDB {
+		return fmt.Errorf("only output files can be signed")
+	}
+	if c.IsPartitioned() && !c.SparkCompat {
+		return fmt.Errorf("partitioned output is signed through its manifest, which requires --spark-compat")
+	}
+	if _, err := sign.LoadPrivateKey(c.SignKey); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseKeyTemplate parses the Redis key template; keys missing from a row are errors
+func (c *Config) ParseKeyTemplate() (*template.Template, error) {
+	if strings.TrimSpace(c.KeyTemplate) == "" {
+		return nil, fmt.Errorf("a key template is required with a Redis sink")
+	}
+	return template.New("key").Option("missingkey=error").Parse(c.KeyTemplate)
+}
+
+// validateDuckDBOutput validates writing output into a DuckDB table
+func (c *Config) validateDuckDBOutput() error {
+	if c.OutputFile == "" {
+		return fmt.Errorf("an output database file is required")
+	}
+	if c.Table == "" {
+		return fmt.Errorf("a table name is required")
+	}
+	if c.IsPartitioned() {
+		return fmt.Errorf("DuckDB output cannot be partitioned")
+	}
+	if !c.InputHasHeaders() {
+		return fmt.Errorf("DuckDB output requires input with a header row")
+	}
+	return c.fileHandler.ValidateOutputDirectory(filepath.Dir(c.OutputFile))
+}
+
+// IsTypedOutput reports whether output is written in a typed format (Avro or ORC)
+// whose schema is inferred from the output rows
+func (c *Config) IsTypedOutput() bool {
+	return c.OutputFormat == OutputFormatAvro || c.OutputFormat == OutputFormatORC
+}
+
+// Actions for points implying impossible speeds
+const (
+	SpeedActionFlag = "flag" // Mark them in the implausible_speed column
+	SpeedActionDrop = "drop" // Leave them out of the output
+)
+
+// HasTrajectory reports whether a trajectory stage reads the id and time columns
+func (c *Config) HasTrajectory() bool {
+	return c.TripGap > 0 || c.MaxSpeedKmh > 0 || c.DetectStays
+}
+
+// validateTrajectory validates the trajectory stages, which need the rows of each
+// entity in input order
+func (c *Config) validateTrajectory() error {
+	if c.TripGap < 0 {
+		return fmt.Errorf("trip gap cannot be negative: %v", c.TripGap)
+	}
+	if c.MaxSpeedKmh < 0 {
+		return fmt.Errorf("maximum speed cannot be negative: %g", c.MaxSpeedKmh)
+	}
+	if c.MaxSpeedKmh > 0 && c.SpeedAction != SpeedActionFlag && c.SpeedAction != SpeedActionDrop {
+		return fmt.Errorf("invalid speed action %q: expected %s or %s", c.SpeedAction, SpeedActionFlag, SpeedActionDrop)
+	}
+	if c.DetectStays {
+		if _, err := geo.ParseDistance(c.StayRadius); err != nil {
+			return fmt.Errorf("invalid stay radius: %w", err)
+		}
+		if !(c.StayMinutes > 0) {
+			return fmt.Errorf("stay minutes must be positive: %g", c.StayMinutes)
+		}
+	}
+	if !c.HasTrajectory() {
+		return nil
+	}
+	if c.IdColumn == "" || c.TimeColumn == "" {
+		return fmt.Errorf("trajectory processing requires an id column and a time column")
+	}
+	if c.Unordered {
+		return fmt.Errorf("trajectory processing requires records in input order and cannot be used with --unordered")
+	}
+	return nil
+}
+
+// IsArchiveOutput reports whether the output file is a zip archive packaging the
+// outputs of a batch or a partitioned run
+func (c *Config) IsArchiveOutput() bool {
+	return strings.EqualFold(filepath.Ext(c.OutputFile), ".zip")
+}
+
+// IsPartitioned reports whether output is written as a partitioned directory
+func (c *Config) IsPartitioned() bool {
+	return c.OutputDir != ""
+}
+
+// validatePartitioning validates the partitioned output configuration
+func (c *Config) validatePartitioning() error {
+	if c.PartitionByH3Res < 0 {
+		return fmt.Errorf("partition resolution is required with an output directory")
+	}
+	if c.PartitionByH3Res > c.Resolution {
+		return fmt.Errorf("partition resolution %d must not be finer than H3 resolution %d", c.PartitionByH3Res, c.Resolution)
+	}
+	
+	info, err := os.Stat(c.OutputDir)
+	if err == nil && !info.IsDir() {
+		return fmt.Errorf("output path is not a directory: %s", c.OutputDir)
+	}
+	// Files inside the dataset directory would be taken as part of the dataset, or
+	// removed when it is overwritten
+	for _, path := range []string{c.InputFile, c.ErrorFile, c.EmitSchema, c.CoverageReport, c.BatchStateFile,
+		c.AuditLog, c.HistoryFile, c.LogFile, c.ProfileFile} {
+		if path != "" && isWithinDir(c.OutputDir, path) {
+			return fmt.Errorf("%s must not be inside the output directory %s", path, c.OutputDir)
+		}
+	}
+	if err == nil && !c.Overwrite {
+		if entries, _ := os.ReadDir(c.OutputDir); len(entries) > 0 {
+			return fmt.Errorf("output directory is not empty: %s (use --overwrite to overwrite)", c.OutputDir)
+		}
+	}
+	return nil
+}
+
+// isWithinDir reports whether path resolves to a location inside dir
+func isWithinDir(dir, path string) bool {
+	absoluteDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	relative, err := filepath.Rel(absoluteDir, absolutePath)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
 
 // GetResolutionDescription returns a human-readable description of the H3 resolution
 func (c *Config) GetResolutionDescription() string {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 	"csv-h3-tool/internal/h3"
 )
 
@@ -354,6 +355,9 @@ func TestConfig_Validate(t *testing.T) {
 	}
 	defer os.Remove(tempFile.Name())
 	tempFile.Close()
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("CSVH3_TEST_KEY", "000102030405060708090a0b0c0d0e0f")
+	t.Setenv("CSVH3_TEST_SHORT_KEY", "0001020304")
 	
 	tests := []struct {
 		name        string
@@ -394,6 +398,428 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "supported number locale",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.NumberLocale = "de"
+			},
+			expectError: false,
+		},
+		{
+			name: "unsupported number locale",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.NumberLocale = "xx"
+			},
+			expectError: true,
+		},
+		{
+			name: "output dir without partition resolution",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputDir = os.TempDir() + "/csv-h3-partition-test"
+			},
+			expectError: true,
+		},
+		{
+			name: "partition resolution finer than H3 resolution",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.PartitionByH3Res = 9
+				c.OutputDir = os.TempDir() + "/csv-h3-partition-test"
+			},
+			expectError: true,
+		},
+		{
+			name: "negative worker count",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.Workers = -2
+			},
+			expectError: true,
+		},
+		{
+			name: "distance without destination pair",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.CoordPairs = "lat:lng:h3"
+				c.AddDistanceKm = true
+			},
+			expectError: true,
+		},
+		{
+			name: "database query without input file",
+			setupConfig: func(c *Config) {
+				c.PGQuery = "SELECT id, lat, lng FROM points"
+				c.DBURL = "postgres://localhost/gis"
+				c.OutputFile = os.TempDir() + "/csv-h3-query-test.csv"
+				c.Overwrite = true
+			},
+			expectError: false,
+		},
+		{
+			name: "database query without database URL",
+			setupConfig: func(c *Config) {
+				c.MySQLQuery = "SELECT id, lat, lng FROM points"
+				c.OutputFile = os.TempDir() + "/csv-h3-query-test.csv"
+			},
+			expectError: true,
+		},
+		{
+			name: "duckdb output without table",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputFormat = OutputFormatDuckDB
+				c.OutputFile = os.TempDir() + "/csv-h3-test.duckdb"
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported output format",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputFormat = "parquet"
+			},
+			expectError: true,
+		},
+		{
+			name: "avro output with long H3 indexes",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputFormat = OutputFormatAvro
+				c.H3Format = H3FormatLong
+			},
+			expectError: false,
+		},
+		{
+			name: "orc output with long H3 indexes",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputFormat = OutputFormatORC
+				c.H3Format = H3FormatLong
+			},
+			expectError: false,
+		},
+		{
+			name: "long H3 indexes without avro output",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.H3Format = H3FormatLong
+			},
+			expectError: true,
+		},
+		{
+			name: "partitioned avro output",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputFormat = OutputFormatAvro
+				c.OutputDir = os.TempDir() + "/csv-h3-partitions"
+				c.PartitionByH3Res = 5
+			},
+			expectError: true,
+		},
+		{
+			name: "partitioned zip archive inside the output directory",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputDir = os.TempDir() + "/csv-h3-partitions"
+				c.OutputFile = os.TempDir() + "/csv-h3-partitions/out.zip"
+				c.PartitionByH3Res = 5
+			},
+			expectError: true,
+		},
+		{
+			name: "fuzzy columns without column fallback",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.FuzzyColumns = true
+				c.NoColumnFallback = true
+			},
+			expectError: true,
+		},
+		{
+			name: "existing coverage report without overwrite",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.CoverageCheck = tempFile.Name()
+				c.CoverageReport = tempFile.Name()
+			},
+			expectError: true,
+		},
+		{
+			name: "input file inside the output directory",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputDir = filepath.Dir(tempFile.Name())
+				c.PartitionByH3Res = 5
+				c.Overwrite = true
+			},
+			expectError: true,
+		},
+		{
+			name: "spark compat without partitioned output",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.SparkCompat = true
+			},
+			expectError: true,
+		},
+		{
+			name: "encrypted columns with key",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.EncryptColumns = "ssn,email"
+				c.KeyEnv = "CSVH3_TEST_KEY"
+			},
+			expectError: false,
+		},
+		{
+			name: "encrypted columns without key",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.EncryptColumns = "ssn"
+				c.KeyEnv = "CSVH3_TEST_UNSET_KEY"
+			},
+			expectError: true,
+		},
+		{
+			name: "encrypted columns with short key",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.EncryptColumns = "ssn"
+				c.KeyEnv = "CSVH3_TEST_SHORT_KEY"
+			},
+			expectError: true,
+		},
+		{
+			name: "signing without key",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.Sign = true
+			},
+			expectError: true,
+		},
+		{
+			name: "key without signing",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.SignKey = tempFile.Name()
+			},
+			expectError: true,
+		},
+		{
+			name: "signing with a key file that is not PEM",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.Sign = true
+				c.SignKey = tempFile.Name()
+			},
+			expectError: true,
+		},
+		{
+			name: "maximum invalid percentage over 100",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.MaxInvalidPct = 101
+			},
+			expectError: true,
+		},
+		{
+			name: "negative maximum invalid percentage",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.MaxInvalidPct = -1
+			},
+			expectError: true,
+		},
+		{
+			name: "negative skip lines",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.SkipLines = -1
+			},
+			expectError: true,
+		},
+		{
+			name: "trip gap without time column",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.TripGap = time.Minute
+				c.IdColumn = "device"
+			},
+			expectError: true,
+		},
+		{
+			name: "trip gap with unordered output",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.TripGap = time.Minute
+				c.IdColumn = "device"
+				c.TimeColumn = "ts"
+				c.Unordered = true
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid speed action",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.MaxSpeedKmh = 200
+				c.IdColumn = "device"
+				c.TimeColumn = "ts"
+				c.SpeedAction = "remove"
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid stay radius",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.DetectStays = true
+				c.IdColumn = "device"
+				c.TimeColumn = "ts"
+				c.StayRadius = "100ft"
+			},
+			expectError: true,
+		},
+		{
+			name: "anchor at another resolution",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.AddLocalIJ = true
+				c.Anchor = "872a10728ffffff"
+			},
+			expectError: true,
+		},
+		{
+			name: "float format without schema inference",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.FloatFormat = "%.6f"
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid float format",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.EmitSchema = "schema.json"
+				c.FloatFormat = "%d"
+			},
+			expectError: true,
+		},
+		{
+			name: "negative sample per cell",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.SamplePerCell = -1
+			},
+			expectError: true,
+		},
+		{
+			name: "negative skip footer",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.SkipFooter = -1
+			},
+			expectError: true,
+		},
+		{
+			name: "negative maximum short rows",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.MaxShortRows = -1
+			},
+			expectError: true,
+		},
+		{
+			name: "negative constant check rows",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.ConstantCheckRows = -1
+			},
+			expectError: true,
+		},
+		{
+			name: "fallback latitude without longitude",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.LatFallback = "gps_lat"
+			},
+			expectError: true,
+		},
+		{
+			name: "fallback coordinate columns",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.LatFallback = "gps_lat"
+				c.LngFallback = "gps_lng"
+			},
+			expectError: false,
+		},
+		{
+			name: "coordinate source without longitude",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.CoordSources = "gps_lat:gps_lng,wifi_lat"
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown precision check mode",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.PrecisionCheck = "strict"
+			},
+			expectError: true,
+		},
+		{
+			name: "computed column without a name",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.Compute = []string{"speed*3.6"}
+			},
+			expectError: true,
+		},
+		{
+			name: "verify partitioned output",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.OutputDir = t.TempDir()
+				c.PartitionByH3Res = 4
+				c.VerifyOutput = true
+			},
+			expectError: true,
+		},
+		{
+			name: "database update of whole table",
+			setupConfig: func(c *Config) {
+				c.PGUpdate = "points"
+				c.DBURL = "postgres://localhost/gis"
+				c.KeyColumn = "id"
+				c.UpdateH3Column = "h3"
+			},
+			expectError: false,
+		},
+		{
+			name: "database update without key column",
+			setupConfig: func(c *Config) {
+				c.PGUpdate = "points"
+				c.DBURL = "postgres://localhost/gis"
+				c.UpdateH3Column = "h3"
+			},
+			expectError: true,
+		},
+		{
+			name: "database query with input file",
+			setupConfig: func(c *Config) {
+				c.InputFile = tempFile.Name()
+				c.PGQuery = "SELECT id, lat, lng FROM points"
+				c.DBURL = "postgres://localhost/gis"
+			},
+			expectError: true,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -412,6 +838,20 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_DatabaseURL(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://env/gis")
+	config := NewConfig()
+
+	if url := config.DatabaseURL(); url != "postgres://env/gis" {
+		t.Errorf("Expected DATABASE_URL as the default database URL, got %q", url)
+	}
+
+	config.DBURL = "postgres://flag/gis"
+	if url := config.DatabaseURL(); url != "postgres://flag/gis" {
+		t.Errorf("Expected --db-url to take precedence over DATABASE_URL, got %q", url)
+	}
+}
+
 func TestConfig_String(t *testing.T) {
 	config := NewConfig()
 	config.InputFile = "input.csv"
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a named set of option values from the user config file, keyed by
+// long flag name (e.g. "delimiter", "lat-column", "resolution")
+type Profile map[string]string
+
+// profileFile is the YAML representation of the user config file
+type profileFile struct {
+	Profiles map[string]map[string]interface{} `yaml:"profiles"`
+}
+
+// DefaultProfileFile returns the user config file path, ~/.csvh3/config.yaml
+func DefaultProfileFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".csvh3", "config.yaml")
+	}
+	return filepath.Join(home, ".csvh3", "config.yaml")
+}
+
+// LoadProfile reads the named profile from a YAML config file of the form
+//
+//	profiles:
+//	  fleet-eu:
+//	    delimiter: ";"
+//	    resolution: 9
+//
+// List values are joined with commas.
+func LoadProfile(path, name string) (Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file profileFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values, ok := file.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(file.Profiles))
+		for profile := range file.Profiles {
+			names = append(names, profile)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %q not found in %s (available: %s)", name, path, strings.Join(names, ", "))
+	}
+
+	profile := make(Profile, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case nil:
+			profile[key] = ""
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			profile[key] = strings.Join(items, ",")
+		case map[string]interface{}:
+			return nil, fmt.Errorf("profile %q: option %s must be a value or list, not a mapping", name, key)
+		default:
+			profile[key] = fmt.Sprint(v)
+		}
+	}
+	return profile, nil
+}
+
+// Options returns the profile's option names in sorted order
+func (p Profile) Options() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `profiles:
+  fleet-eu:
+    delimiter: ";"
+    lat-column: breitengrad
+    lng-column: laengengrad
+    resolution: 9
+    overwrite: true
+    coord-pairs: [a:b:c, d:e:f]
+  nested:
+    output:
+      path: out.csv
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	profile, err := LoadProfile(path, "fleet-eu")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	expected := Profile{
+		"delimiter":   ";",
+		"lat-column":  "breitengrad",
+		"lng-column":  "laengengrad",
+		"resolution":  "9",
+		"overwrite":   "true",
+		"coord-pairs": "a:b:c,d:e:f",
+	}
+	if !reflect.DeepEqual(profile, expected) {
+		t.Errorf("LoadProfile() = %v, want %v", profile, expected)
+	}
+	if got := strings.Join(profile.Options(), ","); got != "coord-pairs,delimiter,lat-column,lng-column,overwrite,resolution" {
+		t.Errorf("Options() = %s", got)
+	}
+
+	if _, err := LoadProfile(path, "missing"); err == nil || !strings.Contains(err.Error(), "fleet-eu") {
+		t.Errorf("LoadProfile() for a missing profile should list the available ones, got %v", err)
+	}
+	if _, err := LoadProfile(path, "nested"); err == nil {
+		t.Error("LoadProfile() with a mapping value should error")
+	}
+	if _, err := LoadProfile(filepath.Join(t.TempDir(), "none.yaml"), "fleet-eu"); err == nil {
+		t.Error("LoadProfile() with a missing file should error")
+	}
+}
@@ -0,0 +1,157 @@
+package csv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MatchMethod describes how a configured column was matched to an input column
+type MatchMethod string
+
+const (
+	MatchExact           MatchMethod = "exact"            // Header equals the specified name
+	MatchCaseInsensitive MatchMethod = "case-insensitive" // Header equals the specified name ignoring case
+	MatchAlias           MatchMethod = "alias"            // Header is a known alias, e.g. "lat" for latitude
+	MatchFuzzy           MatchMethod = "fuzzy"            // Header equals the name or an alias ignoring case and punctuation
+	MatchIndex           MatchMethod = "index"            // Column given by zero-based index
+)
+
+// Default aliases tried when the specified latitude/longitude column is not found
+var (
+	latitudeAliases  = []string{"lat", "latitude", "y"}
+	longitudeAliases = []string{"lng", "lon", "longitude", "x"}
+)
+
+// columnFallback is how far column matching looks past the specified name
+type columnFallback int
+
+const (
+	fallbackAlias columnFallback = iota // The specified name, then the known aliases
+	fallbackNone                        // Only the specified name
+	fallbackFuzzy                       // Also fuzzy matches and, with headers, column indexes
+)
+
+// columnFallbackFor returns the column fallback configured for a reader
+func columnFallbackFor(config Config) columnFallback {
+	switch {
+	case config.NoColumnFallback:
+		return fallbackNone
+	case config.FuzzyColumns:
+		return fallbackFuzzy
+	}
+	return fallbackAlias
+}
+
+// ColumnMatch records which input column was chosen for a role and why
+type ColumnMatch struct {
+	Role      string      // Column role, e.g. "latitude"
+	Specified string      // Configured column name or index
+	Index     int         // Matched column index
+	Header    string      // Matched header (empty for files without headers)
+	Method    MatchMethod // How the column was matched
+	Matched   string      // Name or alias that matched
+}
+
+// String describes the match, e.g. `latitude: column 0 "Lat" (alias "lat"; "latitude" not found)`
+func (m ColumnMatch) String() string {
+	column := fmt.Sprintf("column %d", m.Index)
+	if m.Header != "" {
+		column += fmt.Sprintf(" %q", m.Header)
+	}
+
+	var reason string
+	switch m.Method {
+	case MatchExact, MatchCaseInsensitive:
+		reason = string(m.Method) + " match"
+	case MatchIndex:
+		reason = "index " + m.Specified
+	default:
+		reason = fmt.Sprintf("%s %q", m.Method, m.Matched)
+		if m.Specified != "" && m.Specified != m.Matched {
+			reason += fmt.Sprintf("; %q not found", m.Specified)
+		}
+	}
+	return fmt.Sprintf("%s: %s (%s)", m.Role, column, reason)
+}
+
+// matchColumn finds the input column for a role. Headers are matched by the specified
+// name (exact, then case-insensitive), then by alias; with fuzzy fallback also by name or
+// alias ignoring punctuation, then by a non-negative integer specification as an index.
+// Files without headers select columns by index. Without fallback only the specified
+// name (or index for files without headers) is accepted.
+func (r *Reader) matchColumn(role, specified string, aliases []string, fallback columnFallback) (ColumnMatch, error) {
+	specified = strings.TrimSpace(NormalizeUnicode(specified))
+	match := ColumnMatch{Role: role, Specified: specified, Index: -1}
+	index, indexErr := strconv.Atoi(specified)
+
+	if !r.hasHeaders || len(r.headers) == 0 {
+		if indexErr != nil || index < 0 {
+			return match, fmt.Errorf("%s column not found: %s", role, specified)
+		}
+		match.Index, match.Method = index, MatchIndex
+		return match, nil
+	}
+
+	find := func(equal func(header, name string) bool, names ...string) bool {
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			for i, header := range r.headers {
+				if equal(strings.TrimSpace(NormalizeUnicode(header)), name) {
+					match.Index, match.Header, match.Matched = i, header, name
+					return true
+				}
+			}
+		}
+		return false
+	}
+	exact := func(header, name string) bool { return header == name }
+	fuzzy := func(header, name string) bool {
+		normalized := normalizeColumnName(header)
+		return normalized != "" && normalized == normalizeColumnName(name)
+	}
+
+	switch {
+	case find(exact, specified):
+		match.Method = MatchExact
+	case find(strings.EqualFold, specified):
+		match.Method = MatchCaseInsensitive
+	case fallback == fallbackNone:
+		return match, fmt.Errorf("%s column not found: %s (column fallback disabled)", role, specified)
+	case find(strings.EqualFold, aliases...):
+		match.Method = MatchAlias
+	case fallback != fallbackFuzzy:
+		return match, fmt.Errorf("%s column not found: %s", role, specified)
+	case find(fuzzy, append([]string{specified}, aliases...)...):
+		match.Method = MatchFuzzy
+	case indexErr == nil && index >= 0 && index < len(r.headers):
+		match.Index, match.Header, match.Method = index, r.headers[index], MatchIndex
+	default:
+		return match, fmt.Errorf("%s column not found: %s", role, specified)
+	}
+	return match, nil
+}
+
+// normalizeColumnName lowercases a column name and drops everything but letters and digits
+func normalizeColumnName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ColumnMatches returns how the latitude and longitude columns, and the fallback
+// columns if configured, were matched
+func (r *Reader) ColumnMatches() []ColumnMatch {
+	matches := []ColumnMatch{r.latMatch, r.lngMatch}
+	for _, source := range r.fallbacks {
+		matches = append(matches, source.lat, source.lng)
+	}
+	return matches
+}
@@ -0,0 +1,100 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchColumn(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   []string
+		specified string
+		fallback  columnFallback
+		expected  int
+		method    MatchMethod
+		wantErr   bool
+	}{
+		{"exact", []string{"id", "latitude"}, "latitude", fallbackAlias, 1, MatchExact, false},
+		{"case-insensitive", []string{"id", "Latitude"}, "latitude", fallbackAlias, 1, MatchCaseInsensitive, false},
+		{"alias", []string{"id", "LAT"}, "latitude", fallbackAlias, 1, MatchAlias, false},
+		{"default rejects fuzzy", []string{"id", "Lat."}, "latitude", fallbackAlias, -1, "", true},
+		{"default rejects index", []string{"a", "b"}, "1", fallbackAlias, -1, "", true},
+		{"fuzzy name", []string{"id", "Latitude (deg)"}, "latitude deg", fallbackFuzzy, 1, MatchFuzzy, false},
+		{"fuzzy alias", []string{"id", "Lat."}, "latitude", fallbackFuzzy, 1, MatchFuzzy, false},
+		{"fuzzy prefers alias", []string{"Lat.", "lat"}, "latitude", fallbackFuzzy, 1, MatchAlias, false},
+		{"index with headers", []string{"a", "b"}, "1", fallbackFuzzy, 1, MatchIndex, false},
+		{"not found", []string{"id", "name"}, "latitude", fallbackFuzzy, -1, "", true},
+		{"no fallback exact", []string{"id", "latitude"}, "latitude", fallbackNone, 1, MatchExact, false},
+		{"no fallback case-insensitive", []string{"id", "LATITUDE"}, "latitude", fallbackNone, 1, MatchCaseInsensitive, false},
+		{"no fallback rejects alias", []string{"id", "lat"}, "latitude", fallbackNone, -1, "", true},
+		{"no fallback rejects index", []string{"a", "b"}, "1", fallbackNone, -1, "", true},
+		{"NBSP in header", []string{"id", "latitude\u00a0"}, "latitude", fallbackNone, 1, MatchExact, false},
+		{"zero-width in header", []string{"id", "\ufefflat\u200bitude"}, "latitude", fallbackNone, 1, MatchExact, false},
+		{"decomposed header", []string{"id", "Lati\u0301tud"}, "Lat\u00edtud", fallbackNone, 1, MatchExact, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &Reader{headers: tt.headers, hasHeaders: true}
+			match, err := reader.matchColumn("latitude", tt.specified, latitudeAliases, tt.fallback)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got match %+v", match)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if match.Index != tt.expected || match.Method != tt.method {
+				t.Errorf("Expected column %d by %s, got column %d by %s", tt.expected, tt.method, match.Index, match.Method)
+			}
+		})
+	}
+}
+
+func TestMatchColumnWithoutHeaders(t *testing.T) {
+	reader := &Reader{}
+	match, err := reader.matchColumn("latitude", "2", latitudeAliases, fallbackNone)
+	if err != nil || match.Index != 2 || match.Method != MatchIndex {
+		t.Errorf("Expected column 2 by index, got %+v (err: %v)", match, err)
+	}
+	if _, err := reader.matchColumn("latitude", "latitude", latitudeAliases, fallbackFuzzy); err == nil {
+		t.Error("Expected error for a column name without headers")
+	}
+}
+
+func TestColumnMatchString(t *testing.T) {
+	match := ColumnMatch{Role: "latitude", Specified: "latitude", Index: 0, Header: "LAT", Method: MatchAlias, Matched: "lat"}
+	expected := `latitude: column 0 "LAT" (alias "lat"; "latitude" not found)`
+	if got := match.String(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	match = ColumnMatch{Role: "longitude", Specified: "1", Index: 1, Method: MatchIndex}
+	if got := match.String(); !strings.Contains(got, "index 1") {
+		t.Errorf("Expected index explanation, got %s", got)
+	}
+}
+
+func TestNormalizeUnicode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"latitude", "latitude"},
+		{"place\u00a0name", "place name"},
+		{"lat\u200b\u200citude\u2060", "latitude"},
+		{"\ufeffid", "id"},
+		{"Cafe\u0301", "Caf\u00e9"},
+		{"co\u00adordinate", "coordinate"},
+		{"\u200eRTL\u200f", "RTL"},
+		{"narrow\u202fspace", "narrow space"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeUnicode(tt.input); got != tt.expected {
+			t.Errorf("NormalizeUnicode(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
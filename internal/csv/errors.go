@@ -0,0 +1,31 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMalformedRow matches (with errors.Is) every error for a row that could not be
+// parsed or lacks the coordinate columns. Such rows are skipped; any other read
+// error except io.EOF is fatal.
+var ErrMalformedRow = errors.New("malformed row")
+
+// RowError is a malformed row at a line (the input byte offset for files, the data
+// row number for other sources)
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("malformed row at line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports RowErrors as ErrMalformedRow
+func (e *RowError) Is(target error) bool {
+	return target == ErrMalformedRow
+}
@@ -0,0 +1,103 @@
+package csv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// floatVerb matches a single printf floating-point verb such as %.6f or %g
+var floatVerb = regexp.MustCompile(`^%[-+# 0]*[0-9]*(\.[0-9]*)?[eEfFgG]$`)
+
+// FloatFormatter rewrites the decimal numbers of passthrough fields with a printf
+// verb, so re-serialized numeric columns have a consistent format. Integers and
+// non-numeric fields are left as they are.
+type FloatFormatter struct {
+	format                string
+	preserveTrailingZeros bool         // Keep decimal places of the source that the format trims as zeros
+	columns               map[int]bool // Columns formatted by Apply; nil formats every column
+}
+
+// NewFloatFormatter creates a formatter for a printf float verb, e.g. "%.6f"
+func NewFloatFormatter(format string, preserveTrailingZeros bool) (*FloatFormatter, error) {
+	if !floatVerb.MatchString(format) {
+		return nil, fmt.Errorf("invalid float format %q: expected a single printf float verb such as %%.6f or %%g", format)
+	}
+	return &FloatFormatter{format: format, preserveTrailingZeros: preserveTrailingZeros}, nil
+}
+
+// SetColumns restricts Apply to the columns at the given indexes
+func (f *FloatFormatter) SetColumns(columns []int) {
+	f.columns = make(map[int]bool, len(columns))
+	for _, column := range columns {
+		f.columns[column] = true
+	}
+}
+
+// Apply formats the decimal numbers of a row in place and returns how many it changed
+func (f *FloatFormatter) Apply(row []string) int {
+	changed := 0
+	for i, field := range row {
+		if f.columns != nil && !f.columns[i] {
+			continue
+		}
+		if formatted := f.Format(field); formatted != field {
+			row[i] = formatted
+			changed++
+		}
+	}
+	return changed
+}
+
+// Format returns a decimal number in the configured format, or the field unchanged when
+// it is empty, an integer, or not a plain decimal number (NaN, Inf and hex floats
+// included), matching the numbers schema inference types as number
+func (f *FloatFormatter) Format(field string) string {
+	value := strings.TrimSpace(field)
+	if value == "" || strings.ContainsAny(value, "nNiIxX_") {
+		return field
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return field
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return field
+	}
+	formatted := fmt.Sprintf(f.format, number)
+	if f.preserveTrailingZeros {
+		formatted = padTrailingZeros(formatted, value, number)
+	}
+	return formatted
+}
+
+// padTrailingZeros appends zeros to a formatted number that has fewer decimal places
+// than its source, as long as it still has the source's exact value, so "1.50" written
+// as "1.5" becomes "1.50" again. Exponent forms and padded widths are left alone.
+func padTrailingZeros(formatted, source string, number float64) string {
+	if strings.ContainsAny(formatted, "eE ") || strings.ContainsAny(source, "eE") {
+		return formatted
+	}
+	if parsed, err := strconv.ParseFloat(formatted, 64); err != nil || parsed != number {
+		return formatted
+	}
+	want := decimalPlaces(source)
+	have := decimalPlaces(formatted)
+	if have >= want {
+		return formatted
+	}
+	if have == 0 && !strings.Contains(formatted, ".") {
+		formatted += "."
+	}
+	return formatted + strings.Repeat("0", want-have)
+}
+
+// decimalPlaces counts the digits after the decimal point of a number
+func decimalPlaces(number string) int {
+	point := strings.IndexByte(number, '.')
+	if point < 0 {
+		return 0
+	}
+	return len(number) - point - 1
+}
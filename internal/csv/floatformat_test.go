@@ -0,0 +1,61 @@
+package csv
+
+import "testing"
+
+func TestFloatFormatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		preserve bool
+		value    string
+		expected string
+	}{
+		{"fixed places", "%.6f", false, "40.7128", "40.712800"},
+		{"rounding", "%.2f", false, "2.345", "2.35"},
+		{"integer unchanged", "%.6f", false, "42", "42"},
+		{"text unchanged", "%.6f", false, "abc", "abc"},
+		{"empty unchanged", "%.6f", false, "", ""},
+		{"NaN unchanged", "%.6f", false, "NaN", "NaN"},
+		{"hex float unchanged", "%.6f", false, "0x1p-2", "0x1p-2"},
+		{"exponent input", "%.3f", false, "1.5e2", "150.000"},
+		{"shortest drops zeros", "%g", false, "1.50", "1.5"},
+		{"zeros preserved", "%g", true, "1.50", "1.50"},
+		{"zeros preserved after whole number", "%g", true, "2.000", "2.000"},
+		{"rounded value not padded", "%.1f", true, "2.345", "2.3"},
+		{"longer format kept", "%.4f", true, "1.50", "1.5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := NewFloatFormatter(tt.format, tt.preserve)
+			if err != nil {
+				t.Fatalf("NewFloatFormatter failed: %v", err)
+			}
+			if got := formatter.Format(tt.value); got != tt.expected {
+				t.Errorf("Format(%q) with %s = %q, expected %q", tt.value, tt.format, got, tt.expected)
+			}
+		})
+	}
+
+	for _, format := range []string{"", "%d", "%s", "%.2f%%", "value %f", "%.f2"} {
+		if _, err := NewFloatFormatter(format, false); err == nil {
+			t.Errorf("Expected error for format %q", format)
+		}
+	}
+}
+
+func TestFloatFormatter_SetColumns(t *testing.T) {
+	formatter, err := NewFloatFormatter("%.2f", false)
+	if err != nil {
+		t.Fatalf("NewFloatFormatter failed: %v", err)
+	}
+	formatter.SetColumns([]int{1})
+
+	row := []string{"40.7128", "1.5", "2.5"}
+	if changed := formatter.Apply(row); changed != 1 {
+		t.Errorf("Expected 1 changed field, got %d", changed)
+	}
+	if row[0] != "40.7128" || row[1] != "1.50" || row[2] != "2.5" {
+		t.Errorf("Expected only column 1 to be formatted, got %v", row)
+	}
+}
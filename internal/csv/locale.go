@@ -0,0 +1,102 @@
+package csv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NumberLocale describes how numbers are written in a particular locale
+type NumberLocale struct {
+	Name     string // Locale identifier used on the command line
+	Grouping string // Characters accepted as thousands separators
+	Decimal  rune   // Decimal separator
+}
+
+// numberLocales lists the supported number locales keyed by name
+var numberLocales = map[string]NumberLocale{
+	"en": {Name: "en", Grouping: ",", Decimal: '.'},             // 1,234.56
+	"de": {Name: "de", Grouping: ".", Decimal: ','},             // 1.234,56
+	"fr": {Name: "fr", Grouping: " \u00a0\u202f", Decimal: ','}, // 1 234,56
+	"ch": {Name: "ch", Grouping: "'\u2019", Decimal: '.'},       // 1'234.56
+}
+
+// SupportedNumberLocales returns the names of all supported number locales
+func SupportedNumberLocales() []string {
+	return []string{"en", "de", "fr", "ch"}
+}
+
+// GetNumberLocale looks up a number locale by name (case-insensitive)
+func GetNumberLocale(name string) (NumberLocale, bool) {
+	locale, ok := numberLocales[strings.ToLower(strings.TrimSpace(name))]
+	return locale, ok
+}
+
+// ParseNumber parses a numeric value, tolerating grouping separators of the given locale.
+// An empty locale name falls back to strict strconv.ParseFloat parsing.
+func ParseNumber(value string, localeName string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if localeName == "" {
+		return strconv.ParseFloat(value, 64)
+	}
+
+	locale, ok := GetNumberLocale(localeName)
+	if !ok {
+		return 0, fmt.Errorf("unsupported number locale: %s", localeName)
+	}
+
+	normalized, err := locale.Normalize(value)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(normalized, 64)
+}
+
+// Normalize strips grouping separators and converts the decimal separator to '.'.
+// Grouping separators are only accepted in the integer part and must delimit
+// groups of exactly three digits.
+func (l NumberLocale) Normalize(value string) (string, error) {
+	integerPart := value
+	fractionPart := ""
+	hasDecimal := false
+	if idx := strings.LastIndex(value, string(l.Decimal)); idx >= 0 {
+		integerPart = value[:idx]
+		fractionPart = value[idx+len(string(l.Decimal)):]
+		hasDecimal = true
+	}
+
+	if strings.ContainsAny(fractionPart, l.Grouping) {
+		return "", fmt.Errorf("grouping separator in fractional part of %q", value)
+	}
+
+	// Split the integer part on grouping separators, keeping empty groups
+	var groups []string
+	var current strings.Builder
+	for _, r := range integerPart {
+		if strings.ContainsRune(l.Grouping, r) {
+			groups = append(groups, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	groups = append(groups, current.String())
+
+	if len(groups) > 1 {
+		leading := strings.TrimLeft(groups[0], "+-")
+		if len(leading) == 0 || len(leading) > 3 {
+			return "", fmt.Errorf("invalid digit grouping in %q", value)
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return "", fmt.Errorf("invalid digit grouping in %q", value)
+			}
+		}
+	}
+
+	normalized := strings.Join(groups, "")
+	if hasDecimal {
+		normalized += "." + fractionPart
+	}
+	return normalized, nil
+}
@@ -0,0 +1,84 @@
+package csv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		locale      string
+		expected    float64
+		expectError bool
+	}{
+		{"strict plain", "40.7128", "", 40.7128, false},
+		{"strict rejects grouping", "1,234.56", "", 0, true},
+		{"en grouping", "1,234.56", "en", 1234.56, false},
+		{"en plain", "-74.0060", "en", -74.006, false},
+		{"en negative grouping", "-1,234", "en", -1234, false},
+		{"en bad grouping", "12,34.5", "en", 0, true},
+		{"de grouping", "1.234,56", "de", 1234.56, false},
+		{"de decimal only", "48,8567", "de", 48.8567, false},
+		{"de rejects dot decimal", "40.7128", "de", 0, true},
+		{"fr space grouping", "1 234,56", "fr", 1234.56, false},
+		{"fr nbsp grouping", "1 234,56", "fr", 1234.56, false},
+		{"ch apostrophe grouping", "1'234.56", "ch", 1234.56, false},
+		{"grouping in fraction", "1.5,2", "en", 0, true},
+		{"empty group", "1,,234", "en", 0, true},
+		{"unsupported locale", "1.0", "xx", 0, true},
+		{"locale is case-insensitive", "2,5", "DE", 2.5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNumber(tt.value, tt.locale)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %f", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.value, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %f, got %f", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReadRecordWithNumberLocale(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test.csv")
+
+	csvContent := "latitude,longitude,name\n\"48,8567\",\"2,3508\",Paris\n"
+	if err := os.WriteFile(testFile, []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	reader, err := NewReader(testFile, Config{
+		LatColumn:    "latitude",
+		LngColumn:    "longitude",
+		HasHeaders:   true,
+		NumberLocale: "de",
+	})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	record, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatalf("ReadRecord failed: %v", err)
+	}
+	if !record.IsValid {
+		t.Fatalf("Expected record to be valid, reason: %s", record.InvalidReason)
+	}
+	if record.Latitude != 48.8567 || record.Longitude != 2.3508 {
+		t.Errorf("Expected (48.8567, 2.3508), got (%f, %f)", record.Latitude, record.Longitude)
+	}
+}
@@ -0,0 +1,223 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// mmapSource reads rows from a memory-mapped CSV file with its own record splitter,
+// avoiding the copies through bufio of the default reader. It follows encoding/csv
+// semantics: comma-separated fields, RFC 4180 quoting, \r\n normalized to \n inside
+// quoted fields, and empty lines skipped.
+type mmapSource struct {
+	data      []byte // File contents, valid until unmap
+	offset    int    // Bytes consumed
+	counted   int    // Bytes scanned for line breaks
+	lines     int    // Line breaks in data[:counted]
+	startLine int    // Line on which the last record started
+	unmap     func() error
+
+	buffer []byte // Unescaped fields of the current record
+	ends   []int  // End offset of each field in buffer
+}
+
+// openMmapSource memory-maps a CSV file as a row source
+func openMmapSource(filename string) (*mmapSource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer file.Close() // The mapping stays valid after the file is closed
+
+	data, unmap, err := mapFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to memory-map %s: %w", filename, err)
+	}
+	return &mmapSource{data: data, unmap: unmap}, nil
+}
+
+// Read splits the next record off the mapped data
+func (s *mmapSource) Read() ([]string, error) {
+	data := s.data
+
+	// Skip empty lines
+	for s.offset < len(data) && (data[s.offset] == '\n' ||
+		data[s.offset] == '\r' && s.offset+1 < len(data) && data[s.offset+1] == '\n') {
+		s.offset += bytes.IndexByte(data[s.offset:], '\n') + 1
+	}
+	if s.offset >= len(data) {
+		return nil, io.EOF
+	}
+
+	start := s.offset
+	s.startLine = s.lineAt(start)
+	pos := start
+	s.buffer, s.ends = s.buffer[:0], s.ends[:0]
+	for {
+		if data[pos] != '"' {
+			// Unquoted field up to the next comma or line end
+			end := pos + indexFieldEnd(data[pos:])
+			field := data[pos:end]
+			if end == len(data) || data[end] == '\n' {
+				field = bytes.TrimSuffix(field, []byte{'\r'})
+			}
+			if i := bytes.IndexByte(field, '"'); i >= 0 {
+				return nil, s.parseError(start, pos+i, csv.ErrBareQuote)
+			}
+			s.buffer = append(s.buffer, field...)
+			s.ends = append(s.ends, len(s.buffer))
+			if end < len(data) && data[end] == ',' {
+				pos = end + 1
+				if pos < len(data) {
+					continue
+				}
+				s.ends = append(s.ends, len(s.buffer)) // Trailing empty field at EOF
+			}
+			s.offset = min(end+1, len(data))
+			break
+		}
+
+		// Quoted field: "" is an escaped quote, line breaks are kept
+		pos++
+		for {
+			i := bytes.IndexByte(data[pos:], '"')
+			if i < 0 {
+				return nil, s.parseError(start, len(data), csv.ErrQuote)
+			}
+			s.buffer = appendNormalized(s.buffer, data[pos:pos+i])
+			pos += i + 1
+			if pos < len(data) && data[pos] == '"' {
+				s.buffer = append(s.buffer, '"')
+				pos++
+				continue
+			}
+			break
+		}
+		s.ends = append(s.ends, len(s.buffer))
+
+		switch {
+		case pos == len(data):
+			s.offset = pos
+		case data[pos] == ',':
+			pos++
+			if pos < len(data) {
+				continue
+			}
+			s.ends = append(s.ends, len(s.buffer))
+			s.offset = pos
+		case data[pos] == '\n':
+			s.offset = pos + 1
+		case data[pos] == '\r' && (pos+1 == len(data) || data[pos+1] == '\n'):
+			s.offset = min(pos+2, len(data))
+		default:
+			return nil, s.parseError(start, pos, csv.ErrQuote)
+		}
+		break
+	}
+
+	// One allocation for all fields of the record
+	str := string(s.buffer)
+	fields := make([]string, len(s.ends))
+	prev := 0
+	for i, end := range s.ends {
+		fields[i] = str[prev:end]
+		prev = end
+	}
+	return fields, nil
+}
+
+// indexFieldEnd returns the index of the first comma or line feed in b, or len(b)
+func indexFieldEnd(b []byte) int {
+	for i, c := range b {
+		if c == ',' || c == '\n' {
+			return i
+		}
+	}
+	return len(b)
+}
+
+// appendNormalized appends b to dst with \r\n line breaks replaced by \n
+func appendNormalized(dst, b []byte) []byte {
+	for {
+		i := bytes.Index(b, []byte("\r\n"))
+		if i < 0 {
+			return append(dst, b...)
+		}
+		dst = append(dst, b[:i]...)
+		dst = append(dst, '\n')
+		b = b[i+2:]
+	}
+}
+
+// parseError reports a malformed record like encoding/csv and skips the rest of its line
+func (s *mmapSource) parseError(start, pos int, err error) error {
+	line := s.lineAt(pos)
+	column := pos - (bytes.LastIndexByte(s.data[:pos], '\n') + 1) + 1
+	if next := bytes.IndexByte(s.data[pos:], '\n'); next >= 0 {
+		s.offset = pos + next + 1
+	} else {
+		s.offset = len(s.data)
+	}
+	return &csv.ParseError{
+		StartLine: s.startLine,
+		Line:      line,
+		Column:    column,
+		Err:       err,
+	}
+}
+
+// lineAt returns the line of a position, counting line breaks incrementally; positions
+// must not decrease between calls
+func (s *mmapSource) lineAt(pos int) int {
+	s.lines += bytes.Count(s.data[s.counted:pos], []byte{'\n'})
+	s.counted = pos
+	return s.lines + 1
+}
+
+// skipComments discards the lines at the current position that start with prefix,
+// ignoring leading whitespace and a byte order mark, and blank lines between them
+func (s *mmapSource) skipComments(prefix string) error {
+	for s.offset < len(s.data) {
+		end := len(s.data)
+		if next := bytes.IndexByte(s.data[s.offset:], '\n'); next >= 0 {
+			end = s.offset + next + 1
+		}
+		line := string(s.data[s.offset:end])
+		if end == len(s.data) && !strings.HasSuffix(line, "\n") {
+			line += "\n" // The last line is complete
+		}
+		if !isCommentLine(line, prefix) {
+			return nil
+		}
+		s.offset = end
+	}
+	return nil
+}
+
+// StartLine returns the line on which the last record read started
+func (s *mmapSource) StartLine() int {
+	return s.startLine
+}
+
+// InputOffset returns the number of bytes of the file consumed so far
+func (s *mmapSource) InputOffset() int64 {
+	return int64(s.offset)
+}
+
+// Sizing returns the batch sizes tuned to the file's record width
+func (s *mmapSource) Sizing() Sizing {
+	return Sizing{RecordBytes: averageLineLength(s.data[:min(len(s.data), sizingSampleBytes)])}
+}
+
+func (s *mmapSource) Close() error {
+	if s.unmap == nil {
+		return nil
+	}
+	err := s.unmap()
+	s.data, s.unmap = nil, nil
+	return err
+}
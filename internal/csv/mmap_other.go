@@ -0,0 +1,13 @@
+//go:build !linux
+
+package csv
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is only implemented on Linux
+func mapFile(file *os.File) ([]byte, func() error, error) {
+	return nil, nil, errors.New("memory-mapped input is only supported on Linux")
+}
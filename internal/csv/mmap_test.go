@@ -0,0 +1,159 @@
+//go:build linux
+
+package csv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readAll reads every row of a source, recording parse errors in place of rows
+func readAll(t *testing.T, source RowSource) []string {
+	t.Helper()
+	var rows []string
+	for {
+		row, err := source.Read()
+		if err == io.EOF {
+			return rows
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, "error: "+parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		rows = append(rows, fmt.Sprintf("%q", row))
+	}
+}
+
+func TestMmapSourceMatchesEncodingCSV(t *testing.T) {
+	inputs := map[string]string{
+		"simple":               "a,b,c\n1,2,3\n",
+		"no final newline":     "a,b\n1,2",
+		"CRLF":                 "a,b\r\n1,2\r\n",
+		"trailing CR at EOF":   "a,b\n1,2\r",
+		"empty lines":          "a,b\n\n1,2\r\n\r\n3,4\n",
+		"empty fields":         ",\n,,x\n1,\n",
+		"trailing comma EOF":   "a,b,",
+		"quoted":               "\"a,b\",\"say \"\"hi\"\"\",\"\"\n",
+		"quoted multiline":     "\"line1\r\nline2\nline3\",x\n",
+		"quoted CRLF end":      "\"a\"\r\n\"b\",\"c\"\r\n",
+		"quoted then comma":    "\"a\",\n",
+		"bare quote":           "a,b\"c\n1,2\n",
+		"extraneous quote":     "\"a\"b,c\n1,2\n",
+		"unterminated quote":   "1,2\n\"abc,d\n3,4\n",
+		"carriage return kept": "a\rb,c\n",
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "input.csv")
+			if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+				t.Fatalf("Failed to write input: %v", err)
+			}
+
+			reader := csv.NewReader(strings.NewReader(input))
+			reader.FieldsPerRecord = -1
+			expected := readAll(t, &fileSource{csvReader: reader})
+
+			source, err := openMmapSource(path)
+			if err != nil {
+				t.Fatalf("openMmapSource failed: %v", err)
+			}
+			defer source.Close()
+			if got := readAll(t, source); !reflect.DeepEqual(got, expected) {
+				t.Errorf("Input %q:\nexpected %v\ngot      %v", input, expected, got)
+			}
+		})
+	}
+}
+
+func TestReaderMmap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.csv")
+	content := "id,latitude,longitude\n1,40.7128,-74.0060\n2,\"51.5\",x\n3,34.05\"2,-118.24\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	reader, err := NewReader(path, Config{LatColumn: "latitude", LngColumn: "longitude", HasHeaders: true, Mmap: true})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	record, err := reader.ReadRecord()
+	if err != nil || !record.IsValid || record.Latitude != 40.7128 {
+		t.Fatalf("Expected a valid first record, got %+v (%v)", record, err)
+	}
+	if reader.InputOffset() != int64(len("id,latitude,longitude\n1,40.7128,-74.0060\n")) {
+		t.Errorf("Unexpected input offset %d", reader.InputOffset())
+	}
+	if record, err = reader.ReadRecord(); err != nil || record.IsValid || record.OriginalData[1] != "51.5" {
+		t.Errorf("Expected an invalid second record, got %+v (%v)", record, err)
+	}
+	if _, err = reader.ReadRecord(); !errors.Is(err, ErrMalformedRow) {
+		t.Errorf("Expected a malformed row, got %v", err)
+	}
+	if _, err = reader.ReadRecord(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.csv")
+	os.WriteFile(empty, nil, 0644)
+	source, err := openMmapSource(empty)
+	if err != nil {
+		t.Fatalf("Expected empty files to open, got %v", err)
+	}
+	if _, err := source.Read(); err != io.EOF {
+		t.Errorf("Expected io.EOF for an empty file, got %v", err)
+	}
+	source.Close()
+}
+
+// BenchmarkReader compares reading records through the default buffered reader and
+// the memory-mapped reader
+func BenchmarkReader(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.csv")
+	var content strings.Builder
+	content.WriteString("id,latitude,longitude,name,notes\n")
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&content, "%d,%.6f,%.6f,\"Place %d\",some free text about the place\n",
+			i, float64(i%180)-89.5, float64(i%360)-179.5, i)
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		b.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, mmap := range []bool{false, true} {
+		name := "default"
+		if mmap {
+			name = "mmap"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(content.Len()))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				reader, err := NewReader(path, Config{LatColumn: "latitude", LngColumn: "longitude", HasHeaders: true, Mmap: mmap})
+				if err != nil {
+					b.Fatalf("NewReader failed: %v", err)
+				}
+				for {
+					if _, err := reader.ReadRecord(); err == io.EOF {
+						break
+					} else if err != nil {
+						b.Fatalf("ReadRecord failed: %v", err)
+					}
+				}
+				reader.Close()
+			}
+		})
+	}
+}
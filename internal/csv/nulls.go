@@ -0,0 +1,59 @@
+package csv
+
+import "strings"
+
+// NullCount is the number of coordinate fields that held a null value token
+type NullCount struct {
+	Value string
+	Count int
+}
+
+// ParseNullValues splits a comma-separated list of null value tokens, e.g. "NA,N/A,null,-999"
+func ParseNullValues(spec string) []string {
+	var values []string
+	for _, value := range strings.Split(spec, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// nullValues matches coordinate fields against the configured null value tokens
+// (ignoring case) and counts the matches of each token
+type nullValues struct {
+	counts []NullCount
+	index  map[string]int // Lowercase token to its position in counts
+}
+
+// newNullValues creates a matcher for the given tokens, or returns nil when there are none
+func newNullValues(values []string) *nullValues {
+	if len(values) == 0 {
+		return nil
+	}
+	n := &nullValues{index: make(map[string]int, len(values))}
+	for _, value := range values {
+		key := strings.ToLower(value)
+		if _, ok := n.index[key]; ok {
+			continue
+		}
+		n.index[key] = len(n.counts)
+		n.counts = append(n.counts, NullCount{Value: value})
+	}
+	return n
+}
+
+// contains reports whether the trimmed field is a null value token without counting it
+func (n *nullValues) contains(field string) bool {
+	_, ok := n.index[strings.ToLower(field)]
+	return ok
+}
+
+// match reports whether the trimmed field is a null value token, counting it if so
+func (n *nullValues) match(field string) bool {
+	i, ok := n.index[strings.ToLower(field)]
+	if ok {
+		n.counts[i].Count++
+	}
+	return ok
+}
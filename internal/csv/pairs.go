@@ -0,0 +1,159 @@
+package csv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultH3Column is the name of the output column holding the H3 index
+const DefaultH3Column = "h3_index"
+
+// CoordPair names the latitude and longitude columns of one coordinate pair
+// and the output column receiving its H3 index
+type CoordPair struct {
+	LatColumn string
+	LngColumn string
+	H3Column  string
+}
+
+// ParseCoordPairs parses a comma-separated list of lat:lng:h3 column triples,
+// e.g. "origin_lat:origin_lng:origin_h3,dest_lat:dest_lng:dest_h3"
+func ParseCoordPairs(spec string) ([]CoordPair, error) {
+	var pairs []CoordPair
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid coordinate pair %q: expected lat_column:lng_column:h3_column", part)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+			if fields[i] == "" {
+				return nil, fmt.Errorf("invalid coordinate pair %q: column names cannot be empty", part)
+			}
+		}
+		if strings.EqualFold(fields[0], fields[1]) {
+			return nil, fmt.Errorf("invalid coordinate pair %q: latitude and longitude columns cannot be the same", part)
+		}
+
+		h3Column := strings.ToLower(fields[2])
+		if seen[h3Column] {
+			return nil, fmt.Errorf("duplicate H3 output column %q in coordinate pairs", fields[2])
+		}
+		seen[h3Column] = true
+
+		pairs = append(pairs, CoordPair{LatColumn: fields[0], LngColumn: fields[1], H3Column: fields[2]})
+	}
+
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no coordinate pairs given")
+	}
+	return pairs, nil
+}
+
+// ParseCoordSources parses a comma-separated list of lat:lng column pairs, e.g.
+// "gps_lat:gps_lng,wifi_lat:wifi_lng"; the H3Column of the returned pairs is empty
+func ParseCoordSources(spec string) ([]CoordPair, error) {
+	var sources []CoordPair
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid coordinate source %q: expected lat_column:lng_column", part)
+		}
+		lat, lng := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		if lat == "" || lng == "" {
+			return nil, fmt.Errorf("invalid coordinate source %q: column names cannot be empty", part)
+		}
+		if strings.EqualFold(lat, lng) {
+			return nil, fmt.Errorf("invalid coordinate source %q: latitude and longitude columns cannot be the same", part)
+		}
+		sources = append(sources, CoordPair{LatColumn: lat, LngColumn: lng})
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no coordinate sources given")
+	}
+	return sources, nil
+}
+
+// MatchColumn resolves a column given by header name, or by index, for the given role
+func (r *Reader) MatchColumn(role, column string) (ColumnMatch, error) {
+	return r.matchColumn(role, column, nil, r.columnFallback)
+}
+
+// ParseCoordinates parses the latitude and longitude at the given columns of a row
+func (r *Reader) ParseCoordinates(row []string, latIndex, lngIndex int) (float64, float64, error) {
+	if latIndex >= len(row) || lngIndex >= len(row) {
+		return 0, 0, fmt.Errorf("row has insufficient columns: expected at least %d, got %d",
+			max(latIndex, lngIndex)+1, len(row))
+	}
+
+	latStr := strings.TrimSpace(row[latIndex])
+	lngStr := strings.TrimSpace(row[lngIndex])
+	if latStr == "" || lngStr == "" {
+		return 0, 0, fmt.Errorf("empty coordinates")
+	}
+	if r.nulls != nil && (r.nulls.contains(latStr) || r.nulls.contains(lngStr)) {
+		return 0, 0, fmt.Errorf("null coordinates")
+	}
+
+	lat, err := ParseNumber(latStr, r.numberLocale)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unparseable latitude %q", latStr)
+	}
+	lng, err := ParseNumber(lngStr, r.numberLocale)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unparseable longitude %q", lngStr)
+	}
+	return lat, lng, nil
+}
+
+// EdgeSpec names the origin and destination H3 columns of a directed edge
+// and the output column receiving the edge index
+type EdgeSpec struct {
+	OriginColumn string
+	DestColumn   string
+	EdgeColumn   string
+}
+
+// ParseEdgeSpecs parses a comma-separated list of origin_h3:dest_h3:edge_col triples
+func ParseEdgeSpecs(spec string) ([]EdgeSpec, error) {
+	var edges []EdgeSpec
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid edge %q: expected origin_h3_column:dest_h3_column:edge_column", part)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+			if fields[i] == "" {
+				return nil, fmt.Errorf("invalid edge %q: column names cannot be empty", part)
+			}
+		}
+		if strings.EqualFold(fields[0], fields[1]) {
+			return nil, fmt.Errorf("invalid edge %q: origin and destination columns cannot be the same", part)
+		}
+
+		edges = append(edges, EdgeSpec{OriginColumn: fields[0], DestColumn: fields[1], EdgeColumn: fields[2]})
+	}
+
+	if len(edges) == 0 {
+		return nil, fmt.Errorf("no edges given")
+	}
+	return edges, nil
+}
@@ -0,0 +1,38 @@
+package csv
+
+import (
+	"testing"
+)
+
+func TestParseCoordPairs(t *testing.T) {
+	pairs, err := ParseCoordPairs("origin_lat:origin_lng:origin_h3, dest_lat:dest_lng:dest_h3")
+	if err != nil {
+		t.Fatalf("ParseCoordPairs failed: %v", err)
+	}
+	expected := []CoordPair{
+		{LatColumn: "origin_lat", LngColumn: "origin_lng", H3Column: "origin_h3"},
+		{LatColumn: "dest_lat", LngColumn: "dest_lng", H3Column: "dest_h3"},
+	}
+	if len(pairs) != len(expected) {
+		t.Fatalf("Expected %d pairs, got %d", len(expected), len(pairs))
+	}
+	for i := range expected {
+		if pairs[i] != expected[i] {
+			t.Errorf("Pair %d: expected %+v, got %+v", i, expected[i], pairs[i])
+		}
+	}
+
+	invalid := []string{
+		"",
+		"lat:lng",
+		"lat:lng:h3:extra",
+		"lat::h3",
+		"lat:lat:h3",
+		"a:b:h3,c:d:H3",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseCoordPairs(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
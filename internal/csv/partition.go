@@ -0,0 +1,437 @@
+package csv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultPartition is the Hive convention for rows without a partition value
+const DefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// Spark convention files: a completed dataset directory holds an empty _SUCCESS marker,
+// and readers skip files starting with an underscore
+const (
+	SuccessMarker = "_SUCCESS"
+	ManifestFile  = "_manifest.json"
+)
+
+// partitionFileName is the name of the file holding the rows of a partition
+const partitionFileName = "part-0001.csv"
+
+// maxOpenPartitions bounds the number of partition files kept open at once
+const maxOpenPartitions = 64
+
+// PartitionFunc maps an H3 index to its partition value (e.g., the parent cell)
+type PartitionFunc func(h3Index string) (string, error)
+
+// PartitionWriter writes records into a Hive-style partitioned directory layout:
+// <dir>/<column>=<value>/part-0001.csv
+type PartitionWriter struct {
+	dir         string
+	column      string
+	partitionOf PartitionFunc
+	headers     []string
+	config      Config
+	open        map[string]*partitionFile
+	created     map[string]bool
+	rows        map[string]int64 // Rows written per partition
+	sequence    int
+	committed   bool // Whether Commit wrote the manifest and markers
+}
+
+// partitionFile is an open partition output file
+type partitionFile struct {
+	file      *os.File
+	retry     *RetryWriter // Chunked retrying sink (nil when retries are disabled)
+	csvWriter rowWriter
+	lastUsed  int
+}
+
+// NewPartitionWriter creates a partitioned writer rooted at dir. The partition
+// column name is used as the directory key (e.g., "h3_r3"). With Overwrite, the dataset
+// of a previous run in dir is removed first, so its partitions do not end up in the new
+// dataset; other files in dir are kept.
+func NewPartitionWriter(dir, column string, partitionOf PartitionFunc, inputHeaders []string, config Config) (*PartitionWriter, error) {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		if !config.Overwrite {
+			return nil, fmt.Errorf("output directory %s is not empty (use overwrite option to replace)", dir)
+		}
+		if err := clearDataset(dir, column, entries); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", dir, err)
+	}
+
+	return &PartitionWriter{
+		dir:         dir,
+		column:      column,
+		partitionOf: partitionOf,
+		headers:     OutputHeaders(inputHeaders, config.H3Column, config.ExtraColumns),
+		config:      config,
+		open:        make(map[string]*partitionFile),
+		created:     make(map[string]bool),
+		rows:        make(map[string]int64),
+	}, nil
+}
+
+// clearDataset removes the dataset an earlier run wrote to dir: the partition directories
+// of column and of the column named by an earlier manifest, the manifest and the _SUCCESS
+// markers. Only the part file and marker are removed from a partition directory, and any
+// other entry of dir, e.g. an input file, is left alone. The working directory and file
+// system roots are never cleared.
+func clearDataset(dir, column string, entries []os.DirEntry) error {
+	absolute, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output directory %s: %w", dir, err)
+	}
+	working, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to resolve working directory: %w", err)
+	}
+	if absolute == working || filepath.Dir(absolute) == absolute {
+		return fmt.Errorf("refusing to overwrite output directory %s: use a dedicated dataset directory", dir)
+	}
+
+	columns := map[string]bool{column: true}
+	if data, err := os.ReadFile(filepath.Join(dir, ManifestFile)); err == nil {
+		var manifest PartitionManifest
+		if json.Unmarshal(data, &manifest) == nil && manifest.PartitionColumn != "" {
+			columns[manifest.PartitionColumn] = true
+		}
+	}
+
+	// Remove a marker left by a previous run first, so the dataset is never flagged
+	// complete while it is partly removed
+	for _, name := range []string{SuccessMarker, ManifestFile} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	for _, entry := range entries {
+		key, _, ok := strings.Cut(entry.Name(), "=")
+		if !entry.IsDir() || !ok || !columns[key] {
+			continue
+		}
+		partition := filepath.Join(dir, entry.Name())
+		for _, name := range []string{partitionFileName, SuccessMarker} {
+			if err := os.Remove(filepath.Join(partition, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to clear partition %s: %w", partition, err)
+			}
+		}
+		// A partition directory holding other files is kept
+		os.Remove(partition)
+	}
+	return nil
+}
+
+// WriteRecord writes a record to its partition
+func (w *PartitionWriter) WriteRecord(record *Record) error {
+	row, err := w.FormatRecord(record)
+	if err != nil {
+		return err
+	}
+	return w.WriteRow(row)
+}
+
+// FormatRecord builds the output row for a record without writing it
+func (w *PartitionWriter) FormatRecord(record *Record) ([]string, error) {
+	return FormatOutputRow(record, w.config.ExtraColumns)
+}
+
+// WriteRow writes a formatted output row to the partition derived from its h3_index column
+func (w *PartitionWriter) WriteRow(row []string) error {
+	h3Column := len(row) - 1 - len(w.config.ExtraColumns)
+	if h3Column < 0 {
+		return fmt.Errorf("row has too few columns to locate h3_index: %d", len(row))
+	}
+
+	partition := DefaultPartition
+	if h3Index := row[h3Column]; h3Index != "" {
+		value, err := w.partitionOf(h3Index)
+		if err != nil {
+			return fmt.Errorf("failed to determine partition for %s: %w", h3Index, err)
+		}
+		partition = value
+	}
+
+	pf, err := w.partitionFile(partition)
+	if err != nil {
+		return err
+	}
+	if err := pf.csvWriter.Write(row); err != nil {
+		return fmt.Errorf("failed to write record to partition %s: %w", partition, err)
+	}
+	w.rows[partition]++
+	return nil
+}
+
+// PartitionPath returns the output file path for a partition value
+func (w *PartitionWriter) PartitionPath(partition string) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%s=%s", w.column, partition), partitionFileName)
+}
+
+// Partitions returns the number of partitions written
+func (w *PartitionWriter) Partitions() int {
+	return len(w.created)
+}
+
+// Files returns the paths of the files written, relative to the dataset root in
+// sorted order: the partition files and, once committed, the manifest and markers
+func (w *PartitionWriter) Files() []string {
+	var files []string
+	for partition := range w.created {
+		relative, err := filepath.Rel(w.dir, w.PartitionPath(partition))
+		if err != nil {
+			continue
+		}
+		files = append(files, filepath.ToSlash(relative))
+		if w.committed {
+			files = append(files, filepath.ToSlash(filepath.Join(filepath.Dir(relative), SuccessMarker)))
+		}
+	}
+	if w.committed {
+		files = append(files, ManifestFile, SuccessMarker)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// partitionFile returns an open file for the partition, creating or reopening it as needed
+func (w *PartitionWriter) partitionFile(partition string) (*partitionFile, error) {
+	w.sequence++
+	if pf, ok := w.open[partition]; ok {
+		pf.lastUsed = w.sequence
+		return pf, nil
+	}
+
+	if len(w.open) >= maxOpenPartitions {
+		if err := w.closeLeastRecentlyUsed(); err != nil {
+			return nil, err
+		}
+	}
+
+	path := w.PartitionPath(partition)
+	var file *os.File
+	var err error
+	if w.created[partition] && w.config.WriteRetry.Enabled() {
+		// Retried chunks are written at explicit offsets, which O_APPEND does not allow
+		file, err = os.OpenFile(path, os.O_WRONLY, 0644)
+	} else if w.created[partition] {
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create partition directory: %w", err)
+		}
+		file, err = os.Create(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partition file %s: %w", path, err)
+	}
+
+	pf := &partitionFile{file: file, csvWriter: newRowWriter(file, w.config), lastUsed: w.sequence}
+	if w.config.WriteRetry.Enabled() {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to stat partition file %s: %w", path, err)
+		}
+		pf.retry = NewRetryWriter(file, info.Size(), w.config.WriteRetry)
+		pf.csvWriter = newRowWriter(pf.retry, w.config)
+	}
+	if !w.created[partition] {
+		w.created[partition] = true
+		if w.config.HasHeaders && w.headers != nil {
+			if err := pf.csvWriter.Write(w.headers); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to write headers to %s: %w", path, err)
+			}
+		}
+	}
+	w.open[partition] = pf
+	return pf, nil
+}
+
+// closeLeastRecentlyUsed closes the partition file that was written least recently
+func (w *PartitionWriter) closeLeastRecentlyUsed() error {
+	oldest := ""
+	for partition, pf := range w.open {
+		if oldest == "" || pf.lastUsed < w.open[oldest].lastUsed {
+			oldest = partition
+		}
+	}
+	pf := w.open[oldest]
+	delete(w.open, oldest)
+	return pf.close()
+}
+
+// close flushes and closes a partition file
+func (pf *partitionFile) close() error {
+	if err := pf.flush(); err != nil {
+		pf.file.Close()
+		return fmt.Errorf("error flushing partition file: %w", err)
+	}
+	return pf.file.Close()
+}
+
+// flush flushes buffered rows of a partition file
+func (pf *partitionFile) flush() error {
+	pf.csvWriter.Flush()
+	if err := pf.csvWriter.Error(); err != nil {
+		return err
+	}
+	if pf.retry != nil {
+		return pf.retry.Flush()
+	}
+	return nil
+}
+
+// Flush flushes all open partition files
+func (w *PartitionWriter) Flush() error {
+	for _, pf := range w.open {
+		if err := pf.flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes and closes all open partition files
+func (w *PartitionWriter) Close() error {
+	var firstErr error
+	for partition, pf := range w.open {
+		if err := pf.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(w.open, partition)
+	}
+	return firstErr
+}
+
+// PartitionManifest lists the files of a completed partitioned dataset
+type PartitionManifest struct {
+	Format          string              `json:"format"`
+	PartitionColumn string              `json:"partition_column"`
+	HasHeaders      bool                `json:"has_headers"`
+	Rows            int64               `json:"rows"`
+	Partitions      []ManifestPartition `json:"partitions"`
+}
+
+// ManifestPartition is one partition file of a dataset, its path relative to the dataset root
+type ManifestPartition struct {
+	Value  string `json:"value"`
+	Path   string `json:"path"`
+	Rows   int64  `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"` // Lets a signed manifest vouch for every partition file
+}
+
+// Commit marks the dataset complete for Spark-style readers after every row was flushed:
+// it writes the manifest at the dataset root, then a _SUCCESS marker in each partition
+// directory and finally at the root, so the root marker only appears on a complete dataset.
+// It fails without writing the root marker when the directory holds files other than the
+// dataset's, which readers of the directory would take as part of it.
+func (w *PartitionWriter) Commit() error {
+	if err := w.checkDataset(); err != nil {
+		return err
+	}
+	manifest := PartitionManifest{Format: "csv", PartitionColumn: w.column, HasHeaders: w.config.HasHeaders && w.headers != nil}
+	for partition := range w.created {
+		path := w.PartitionPath(partition)
+		size, checksum, err := checksumFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to checksum partition file %s: %w", path, err)
+		}
+		relative, err := filepath.Rel(w.dir, path)
+		if err != nil {
+			return err
+		}
+		manifest.Partitions = append(manifest.Partitions, ManifestPartition{
+			Value: partition,
+			Path:  filepath.ToSlash(relative),
+			Rows:   w.rows[partition],
+			Bytes:  size,
+			SHA256: checksum,
+		})
+		manifest.Rows += w.rows[partition]
+	}
+	sort.Slice(manifest.Partitions, func(i, j int) bool {
+		return manifest.Partitions[i].Value < manifest.Partitions[j].Value
+	})
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, ManifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	for _, partition := range manifest.Partitions {
+		marker := filepath.Join(w.dir, filepath.Dir(filepath.FromSlash(partition.Path)), SuccessMarker)
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			return fmt.Errorf("failed to write %s marker: %w", SuccessMarker, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, SuccessMarker), nil, 0644); err != nil {
+		return fmt.Errorf("failed to write %s marker: %w", SuccessMarker, err)
+	}
+	w.committed = true
+	return nil
+}
+
+// checkDataset verifies that the dataset directory holds only the partitions written,
+// the manifest, and the _SUCCESS markers
+func (w *PartitionWriter) checkDataset() error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list output directory %s: %w", w.dir, err)
+	}
+	partitions := make(map[string]bool, len(w.created))
+	for partition := range w.created {
+		partitions[filepath.Base(filepath.Dir(w.PartitionPath(partition)))] = true
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case !entry.IsDir() && (name == ManifestFile || name == SuccessMarker):
+			continue
+		case entry.IsDir() && partitions[name]:
+			files, err := os.ReadDir(filepath.Join(w.dir, name))
+			if err != nil {
+				return fmt.Errorf("failed to list partition directory %s: %w", name, err)
+			}
+			for _, file := range files {
+				if file.Name() != partitionFileName && file.Name() != SuccessMarker {
+					return fmt.Errorf("output directory %s holds %s, which is not part of the dataset",
+						w.dir, filepath.Join(name, file.Name()))
+				}
+			}
+			continue
+		}
+		return fmt.Errorf("output directory %s holds %s, which is not part of the dataset", w.dir, name)
+	}
+	return nil
+}
+
+// checksumFile returns the size and hex SHA-256 digest of a file
+func checksumFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	digest := sha256.New()
+	size, err := io.Copy(digest, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(digest.Sum(nil)), nil
+}
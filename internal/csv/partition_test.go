@@ -0,0 +1,277 @@
+package csv
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPartitionWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	config := Config{HasHeaders: true, ExtraColumns: []string{"extra"}}
+
+	// Partition by the first character of the H3 index
+	partitionOf := func(index string) (string, error) {
+		return index[:1], nil
+	}
+
+	writer, err := NewPartitionWriter(dir, "h3_r0", partitionOf, []string{"name"}, config)
+	if err != nil {
+		t.Fatalf("NewPartitionWriter failed: %v", err)
+	}
+
+	records := []*Record{
+		{OriginalData: []string{"a"}, H3Index: "8a", IsValid: true},
+		{OriginalData: []string{"b"}, H3Index: "9b", IsValid: true},
+		{OriginalData: []string{"c"}, H3Index: "8c", IsValid: true},
+		{OriginalData: []string{"d"}, IsValid: false},
+	}
+	for _, record := range records {
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatalf("WriteRecord failed: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if writer.Partitions() != 3 {
+		t.Errorf("Expected 3 partitions, got %d", writer.Partitions())
+	}
+
+	expected := map[string]string{
+		"8":              "name,h3_index,extra\na,8a,\nc,8c,\n",
+		"9":              "name,h3_index,extra\nb,9b,\n",
+		DefaultPartition: "name,h3_index,extra\nd,,\n",
+	}
+	for partition, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, "h3_r0="+partition, "part-0001.csv"))
+		if err != nil {
+			t.Fatalf("Failed to read partition %s: %v", partition, err)
+		}
+		if string(data) != content {
+			t.Errorf("Partition %s: expected %q, got %q", partition, content, string(data))
+		}
+	}
+}
+
+func TestPartitionWriterReopensClosedPartitions(t *testing.T) {
+	t.Run("append", func(t *testing.T) {
+		testPartitionWriterReopen(t, Config{HasHeaders: true})
+	})
+	t.Run("write retries", func(t *testing.T) {
+		testPartitionWriterReopen(t, Config{HasHeaders: true, WriteRetry: RetryPolicy{Retries: 1}})
+	})
+}
+
+func testPartitionWriterReopen(t *testing.T, config Config) {
+	dir := t.TempDir()
+	partitionOf := func(index string) (string, error) {
+		return index, nil
+	}
+
+	writer, err := NewPartitionWriter(dir, "p", partitionOf, []string{"name"}, config)
+	if err != nil {
+		t.Fatalf("NewPartitionWriter failed: %v", err)
+	}
+
+	// Write more partitions than can be held open, then revisit the first one
+	total := maxOpenPartitions + 5
+	for i := 0; i < total; i++ {
+		if err := writer.WriteRow([]string{"first", fmt.Sprint(i)}); err != nil {
+			t.Fatalf("WriteRow failed: %v", err)
+		}
+	}
+	if err := writer.WriteRow([]string{"second", "0"}); err != nil {
+		t.Fatalf("WriteRow failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(writer.PartitionPath("0"))
+	if err != nil {
+		t.Fatalf("Failed to read partition: %v", err)
+	}
+	if got := strings.Count(string(data), "name,h3_index"); got != 1 {
+		t.Errorf("Expected header once, found %d times", got)
+	}
+	if !strings.Contains(string(data), "first,0\nsecond,0\n") {
+		t.Errorf("Expected both rows in reopened partition, got %q", string(data))
+	}
+}
+
+func TestPartitionWriterRequiresEmptyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "existing.csv"), []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	partitionOf := func(index string) (string, error) { return index, nil }
+	if _, err := NewPartitionWriter(dir, "p", partitionOf, nil, Config{}); err == nil {
+		t.Error("Expected error for non-empty output directory")
+	}
+	if _, err := NewPartitionWriter(dir, "p", partitionOf, nil, Config{Overwrite: true}); err != nil {
+		t.Errorf("Expected no error with overwrite, got: %v", err)
+	}
+}
+
+func TestPartitionWriterOverwriteClearsDataset(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "h3_r0=8", "part-0001.csv")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatalf("Failed to create partition: %v", err)
+	}
+	if err := os.WriteFile(stale, []byte("name,h3_index\nold,8a\n"), 0644); err != nil {
+		t.Fatalf("Failed to create partition file: %v", err)
+	}
+	// A partition of an earlier run at another resolution, named by its manifest
+	otherColumn := filepath.Join(dir, "h3_r1=8", "part-0001.csv")
+	os.MkdirAll(filepath.Dir(otherColumn), 0755)
+	os.WriteFile(otherColumn, []byte("name,h3_index\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte(`{"partition_column":"h3_r1"}`), 0644)
+	os.WriteFile(filepath.Join(dir, SuccessMarker), nil, 0644)
+	// Files that are not part of the dataset survive
+	foreign := []string{filepath.Join(dir, "points.csv"), filepath.Join(dir, "notes.txt"), filepath.Join(dir, "h3_r0=8", "keep.txt")}
+	for _, path := range foreign {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+	}
+
+	partitionOf := func(index string) (string, error) { return index[:1], nil }
+	writer, err := NewPartitionWriter(dir, "h3_r0", partitionOf, []string{"name"}, Config{HasHeaders: true, Overwrite: true})
+	if err != nil {
+		t.Fatalf("NewPartitionWriter failed: %v", err)
+	}
+	if err := writer.WriteRecord(&Record{OriginalData: []string{"a"}, H3Index: "9a", IsValid: true}); err != nil {
+		t.Fatalf("WriteRecord failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	for _, path := range []string{stale, filepath.Dir(otherColumn), filepath.Join(dir, ManifestFile), filepath.Join(dir, SuccessMarker)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s of the previous run to be removed, got %v", path, err)
+		}
+	}
+	for _, path := range foreign {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to survive overwriting the dataset: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(writer.PartitionPath("9")); err != nil {
+		t.Errorf("Expected the new partition: %v", err)
+	}
+
+	// The working directory is never cleared
+	working := t.TempDir()
+	os.WriteFile(filepath.Join(working, "input.csv"), []byte("x"), 0644)
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	if err := os.Chdir(working); err != nil {
+		t.Fatalf("Chdir failed: %v", err)
+	}
+	defer os.Chdir(previous)
+	if _, err := NewPartitionWriter(".", "p", partitionOf, nil, Config{Overwrite: true}); err == nil {
+		t.Error("Expected overwriting the working directory to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(working, "input.csv")); err != nil {
+		t.Errorf("Expected the working directory to be left alone: %v", err)
+	}
+}
+
+func TestPartitionWriterCommit(t *testing.T) {
+	dir := t.TempDir()
+	// A marker left by an earlier run is removed until the new dataset is committed
+	if err := os.WriteFile(filepath.Join(dir, SuccessMarker), nil, 0644); err != nil {
+		t.Fatalf("Failed to create marker: %v", err)
+	}
+	partitionOf := func(index string) (string, error) { return index[:1], nil }
+	writer, err := NewPartitionWriter(dir, "h3_r0", partitionOf, []string{"name"}, Config{HasHeaders: true, Overwrite: true})
+	if err != nil {
+		t.Fatalf("NewPartitionWriter failed: %v", err)
+	}
+	defer writer.Close()
+	if _, err := os.Stat(filepath.Join(dir, SuccessMarker)); !os.IsNotExist(err) {
+		t.Errorf("Expected the stale %s marker to be removed", SuccessMarker)
+	}
+
+	for _, record := range []*Record{
+		{OriginalData: []string{"a"}, H3Index: "9a", IsValid: true},
+		{OriginalData: []string{"b"}, H3Index: "8b", IsValid: true},
+		{OriginalData: []string{"c"}, H3Index: "9c", IsValid: true},
+	} {
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatalf("WriteRecord failed: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	for _, marker := range []string{SuccessMarker, "h3_r0=8/" + SuccessMarker, "h3_r0=9/" + SuccessMarker} {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err != nil {
+			t.Errorf("Expected marker %s: %v", marker, err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var manifest PartitionManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Invalid manifest: %v", err)
+	}
+	partition8, partition9 := "name,h3_index\nb,8b\n", "name,h3_index\na,9a\nc,9c\n"
+	expected := PartitionManifest{Format: "csv", PartitionColumn: "h3_r0", HasHeaders: true, Rows: 3, Partitions: []ManifestPartition{
+		{Value: "8", Path: "h3_r0=8/part-0001.csv", Rows: 1, Bytes: int64(len(partition8)), SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(partition8)))},
+		{Value: "9", Path: "h3_r0=9/part-0001.csv", Rows: 2, Bytes: int64(len(partition9)), SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(partition9)))},
+	}}
+	if fmt.Sprint(manifest) != fmt.Sprint(expected) {
+		t.Errorf("Expected manifest %+v, got %+v", expected, manifest)
+	}
+}
+
+func TestPartitionWriterCommitRejectsStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	partitionOf := func(index string) (string, error) { return index[:1], nil }
+	writer, err := NewPartitionWriter(dir, "h3_r0", partitionOf, []string{"name"}, Config{HasHeaders: true})
+	if err != nil {
+		t.Fatalf("NewPartitionWriter failed: %v", err)
+	}
+	defer writer.Close()
+	if err := writer.WriteRecord(&Record{OriginalData: []string{"a"}, H3Index: "9a", IsValid: true}); err != nil {
+		t.Fatalf("WriteRecord failed: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// A partition that appeared meanwhile is not vouched for by the markers
+	stale := filepath.Join(dir, "h3_r0=8")
+	if err := os.MkdirAll(stale, 0755); err != nil {
+		t.Fatalf("Failed to create partition: %v", err)
+	}
+	if err := writer.Commit(); err == nil || !strings.Contains(err.Error(), "h3_r0=8") {
+		t.Errorf("Expected Commit to reject the stale partition, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SuccessMarker)); !os.IsNotExist(err) {
+		t.Errorf("Expected no %s marker over a dataset with stale partitions", SuccessMarker)
+	}
+
+	os.Remove(stale)
+	if err := writer.Commit(); err != nil {
+		t.Errorf("Commit failed: %v", err)
+	}
+}
@@ -2,10 +2,16 @@ package csv
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+
+	"csv-h3-tool/internal/logging"
+	"csv-h3-tool/internal/validator"
 )
 
 // Config holds the configuration for CSV processing
@@ -18,6 +24,31 @@ type Config struct {
 	HasHeaders    bool
 	Overwrite     bool
 	Verbose       bool
+	ShowInvalid   int  // Number of invalid rows to print verbatim in verbose mode
+	WarnLimit     int  // Verbose warnings shown per kind before the rest are only counted (0 = all)
+	Color         bool // Highlight verbose warnings in yellow
+	NumberLocale  string // Locale for tolerant number parsing (empty = strict)
+	H3Column      string   // Name of the H3 index output column (empty = h3_index)
+	ExtraColumns  []string // Additional output columns written after the H3 index
+	Workers       int  // Number of concurrent H3 generation workers (<= 1 means sequential)
+	Unordered     bool // With Workers, hand records over as they complete instead of in input order
+	WriteRetry    RetryPolicy // Retry policy for output writes
+	Quote         QuoteMode   // When to quote output fields (empty = minimal)
+	CRLF          bool        // Terminate output rows with \r\n instead of \n
+	EscapeFormulas bool       // Prefix fields spreadsheets would treat as formulas with a quote
+	BufferSize    int         // Output buffer size in bytes (0 = default), e.g. from Reader.Sizing
+	TrimFields    bool        // Trim surrounding whitespace from every input field
+	CollapseWhitespace bool   // Also collapse internal whitespace runs to a single space (implies TrimFields)
+	NoColumnFallback   bool   // Match columns only by the specified name, without aliases, fuzzy matching, or index
+	FuzzyColumns       bool   // Also match columns ignoring punctuation, then by index, after the aliases
+	NormalizeUnicode   bool   // Apply NormalizeUnicode to every input field, not just for column matching
+	Mmap               bool   // Memory-map the input file instead of reading it through a buffer (Linux only)
+	MaxShortRows       int    // Consecutive rows narrower than the header tolerated before a *StructureError (0 = no limit)
+	FallbackSources    []CoordPair // Coordinate columns tried in order when the primary ones are empty (H3Column unused)
+	NullValues         []string    // Coordinate values treated as empty, e.g. "NA" or "-999" (matched ignoring case)
+	SkipLines          int         // Rows discarded at the start of the input, before the header
+	CommentPrefix      string      // Leading lines starting with this prefix (after SkipLines) are discarded (empty = none)
+	SkipFooter         int         // Rows discarded at the end of the input, e.g. a totals line
 }
 
 // Record represents a single CSV record with coordinate data
@@ -26,10 +57,38 @@ type Record struct {
 	Latitude     float64  // Parsed latitude value
 	Longitude    float64  // Parsed longitude value
 	H3Index      string   // Generated H3 index
-	LineNumber   int      // Original line number for error reporting
+	LineNumber   int      // Input line on which the row starts (the data row number for sources without lines)
+	InputOffset  int64    // Input bytes consumed up to the end of the row (0 when the source cannot report it)
 	IsValid      bool     // Whether record has valid coordinates
+	Parsed       bool     // Whether Latitude/Longitude were parsed (they may still fail validation)
+	InvalidColumn int     // Index of the offending column for invalid records (-1 if unknown)
+	InvalidReason string  // Short description of why the record is invalid
+	InvalidKind  InvalidKind // Category of InvalidReason
+	Extra        map[string]string // Values for additional output columns
+	Geocoded     bool     // Whether the coordinates were filled in by a CoordinateFallback
+	FromFallback bool     // Whether the coordinates were taken from fallback columns
+	CoordSource  string   // Columns that supplied the coordinates, e.g. "gps_lat:gps_lng" (empty when none did)
 }
 
+// GeocodedSource is the CoordSource of records whose coordinates a CoordinateFallback supplied
+const GeocodedSource = "geocoded"
+
+// InvalidKind categorizes why a record is invalid
+type InvalidKind int
+
+const (
+	InvalidNone        InvalidKind = iota // Record is valid
+	InvalidEmpty                          // Empty latitude or longitude (and no fallback match)
+	InvalidUnparseable                    // Coordinate that is not a number
+	InvalidOutOfRange                     // Coordinates rejected by the validator
+	InvalidH3                             // H3 index generation failed
+	InvalidLookup                         // Coordinate lookup for empty coordinates failed, e.g. a geocoder error
+)
+
+// CoordinateFallback supplies coordinates for a row with empty latitude and longitude,
+// e.g. by geocoding an address column; found is false when none are known
+type CoordinateFallback func(row []string) (lat, lng float64, found bool, err error)
+
 // Processor defines the interface for CSV file processing
 type Processor interface {
 	ProcessFile(config Config) error
@@ -38,148 +97,334 @@ type Processor interface {
 
 // Reader handles CSV file reading with column detection
 type Reader struct {
-	file      *os.File
-	csvReader *csv.Reader
+	source    RowSource
+	rows      int // Data rows read so far
 	headers   []string
 	latIndex  int
 	lngIndex  int
 	hasHeaders bool
+	numberLocale string
+	trimFields   bool
+	collapseWhitespace bool
+	columnFallback     columnFallback
+	normalizeUnicode   bool
+	latMatch     ColumnMatch
+	lngMatch     ColumnMatch
+	fallbacks    []coordSource // Fallback coordinate columns in priority order
+	nulls        *nullValues   // Null value tokens treated as empty coordinates (nil when not configured)
+	fallback     CoordinateFallback
+	structure    structureMonitor
+	footer       int        // Rows held back from the end of the input
+	footerRows   int        // Footer rows discarded at the end of the input
+	ahead        []aheadRow // Rows read but not yet returned
+	line         int        // Input line of the last row returned (0 when the source cannot report it)
+	offset       int64      // Input bytes consumed up to the end of the last row returned
 }
 
-// NewReader creates a new CSV reader
-func NewReader(filename string, config Config) (*Reader, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
-	}
-
-	csvReader := csv.NewReader(file)
-	csvReader.FieldsPerRecord = -1 // Allow variable number of fields
+// aheadRow is a row read from the source before it is returned, with its position
+type aheadRow struct {
+	row    []string
+	err    error // Parse error of the row
+	line   int
+	offset int64
+}
 
-	reader := &Reader{
-		file:       file,
-		csvReader:  csvReader,
-		hasHeaders: config.HasHeaders,
-		latIndex:   -1,
-		lngIndex:   -1,
+// NewReader creates a new CSV reader. A filename of the form "archive.zip" or
+// "archive.zip::member.csv" reads a CSV member of a zip archive (Mmap is ignored).
+func NewReader(filename string, config Config) (*Reader, error) {
+	if archive, member, ok := SplitZipPath(filename); ok {
+		source, err := openZipSource(archive, member)
+		if err != nil {
+			return nil, err
+		}
+		return NewSourceReader(source, config)
 	}
-
-	// Read headers if present
-	if config.HasHeaders {
-		headers, err := csvReader.Read()
+	if config.Mmap {
+		source, err := openMmapSource(filename)
 		if err != nil {
-			file.Close()
-			return nil, fmt.Errorf("failed to read headers: %w", err)
+			return nil, err
 		}
-		reader.headers = headers
+		return NewSourceReader(source, config)
 	}
-
-	// Detect column indices
-	if err := reader.detectColumns(config); err != nil {
-		file.Close()
+	source, err := openFileSource(filename)
+	if err != nil {
 		return nil, err
 	}
-
-	return reader, nil
+	return NewSourceReader(source, config)
 }
 
 // detectColumns identifies latitude and longitude column indices
 func (r *Reader) detectColumns(config Config) error {
-	// If we have headers, try to find columns by name
-	if r.hasHeaders && len(r.headers) > 0 {
-		r.latIndex = r.findColumnByName(config.LatColumn, []string{"lat", "latitude", "y"})
-		r.lngIndex = r.findColumnByName(config.LngColumn, []string{"lng", "lon", "longitude", "x"})
-	} else {
-		// Try to parse column specifications as indices
-		if latIdx, err := strconv.Atoi(config.LatColumn); err == nil && latIdx >= 0 {
-			r.latIndex = latIdx
-		}
-		if lngIdx, err := strconv.Atoi(config.LngColumn); err == nil && lngIdx >= 0 {
-			r.lngIndex = lngIdx
-		}
-	}
-
-	// Validate that we found both columns
-	if r.latIndex == -1 {
-		return fmt.Errorf("latitude column not found: %s", config.LatColumn)
+	latMatch, err := r.matchColumn("latitude", config.LatColumn, latitudeAliases, r.columnFallback)
+	if err != nil {
+		return err
 	}
-	if r.lngIndex == -1 {
-		return fmt.Errorf("longitude column not found: %s", config.LngColumn)
+	lngMatch, err := r.matchColumn("longitude", config.LngColumn, longitudeAliases, r.columnFallback)
+	if err != nil {
+		return err
 	}
 
+	r.latMatch, r.lngMatch = latMatch, lngMatch
+	r.latIndex, r.lngIndex = latMatch.Index, lngMatch.Index
+
+	r.fallbacks = nil
+	for i, pair := range config.FallbackSources {
+		role := fmt.Sprintf("fallback %d", i+1)
+		latFallback, err := r.MatchColumn(role+" latitude", pair.LatColumn)
+		if err != nil {
+			return err
+		}
+		lngFallback, err := r.MatchColumn(role+" longitude", pair.LngColumn)
+		if err != nil {
+			return err
+		}
+		r.fallbacks = append(r.fallbacks, coordSource{lat: latFallback, lng: lngFallback})
+	}
 	return nil
 }
 
-// findColumnByName searches for a column by name with fallback options
-func (r *Reader) findColumnByName(specified string, fallbacks []string) int {
-	// First try the specified column name
-	if specified != "" {
-		for i, header := range r.headers {
-			if strings.EqualFold(strings.TrimSpace(header), strings.TrimSpace(specified)) {
-				return i
-			}
+// coordSource is a pair of columns coordinates can be read from
+type coordSource struct {
+	lat, lng ColumnMatch
+}
+
+// label names the source's columns, by header or by index for files without headers
+func (s coordSource) label() string {
+	name := func(match ColumnMatch) string {
+		if match.Header != "" {
+			return match.Header
 		}
+		return strconv.Itoa(match.Index)
 	}
+	return name(s.lat) + ":" + name(s.lng)
+}
 
-	// If not found, try fallback names
-	for _, fallback := range fallbacks {
-		for i, header := range r.headers {
-			if strings.EqualFold(strings.TrimSpace(header), fallback) {
-				return i
-			}
-		}
+// CoordSources returns the labels of the coordinate sources in priority order: the
+// primary latitude and longitude columns followed by the fallback columns
+func (r *Reader) CoordSources() []string {
+	labels := []string{coordSource{lat: r.latMatch, lng: r.lngMatch}.label()}
+	for _, source := range r.fallbacks {
+		labels = append(labels, source.label())
 	}
+	return labels
+}
 
-	return -1
+// CoordinateColumns returns the indexes of the latitude and longitude columns followed
+// by those of the fallback coordinate columns
+func (r *Reader) CoordinateColumns() []int {
+	columns := []int{r.latIndex, r.lngIndex}
+	for _, source := range r.fallbacks {
+		columns = append(columns, source.lat.Index, source.lng.Index)
+	}
+	return columns
 }
 
-// ReadRecord reads the next record from the CSV file
+// ReadRecord reads the next record from the CSV file. It returns io.EOF at the end
+// of the input, a *RowError (matching ErrMalformedRow) for a row that cannot be
+// parsed or lacks the coordinate columns, a *StructureError once too many consecutive
+// rows are narrower than the header, and any other error for a failed read.
 func (r *Reader) ReadRecord() (*Record, error) {
-	row, err := r.csvReader.Read()
+	row, err := r.nextRow(r.footer)
 	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			r.rows++
+			return nil, &RowError{Line: parseErr.StartLine, Err: err}
+		}
+		return nil, err
+	}
+	r.rows++
+	r.normalizeFields(row)
+	if err := r.structure.check(row); err != nil {
 		return nil, err
 	}
 
 	// Validate that we have enough columns
 	if len(row) <= r.latIndex || len(row) <= r.lngIndex {
-		return nil, fmt.Errorf("row has insufficient columns: expected at least %d, got %d", 
-			max(r.latIndex, r.lngIndex)+1, len(row))
+		return nil, &RowError{Line: r.lineNumber(), Err: fmt.Errorf("row has insufficient columns: expected at least %d, got %d", 
+			max(r.latIndex, r.lngIndex)+1, len(row))}
 	}
 
 	record := &Record{
 		OriginalData: make([]string, len(row)),
-		LineNumber:   int(r.csvReader.InputOffset()),
+		LineNumber:   r.lineNumber(),
+		InputOffset:  r.offset,
 		IsValid:      false,
+		InvalidColumn: -1,
 	}
 
 	// Copy original data
 	copy(record.OriginalData, row)
 
 	// Parse coordinates - we'll validate them later in the processing pipeline
-	latStr := strings.TrimSpace(row[r.latIndex])
-	lngStr := strings.TrimSpace(row[r.lngIndex])
+	latStr := r.coordinateField(row, r.latIndex)
+	lngStr := r.coordinateField(row, r.lngIndex)
 
+	// The first fallback source holding both values that parse supplies empty
+	// coordinates; the first source that does not parse explains an invalid record
+	unparseableColumn, unparseableReason := -1, ""
 	if latStr == "" || lngStr == "" {
+		for _, source := range r.fallbacks {
+			latFallback, lngFallback := r.coordinateField(row, source.lat.Index), r.coordinateField(row, source.lng.Index)
+			if latFallback == "" || lngFallback == "" {
+				continue
+			}
+			ok, column, reason := r.fallbackCoordinates(record, source, latFallback, lngFallback)
+			if ok {
+				return record, nil
+			}
+			if unparseableColumn < 0 {
+				unparseableColumn, unparseableReason = column, reason
+			}
+		}
+	}
+	if latStr == "" && lngStr == "" && r.fallback != nil {
+		return r.fillCoordinates(record), nil
+	}
+	if unparseableColumn >= 0 {
+		record.markInvalid(unparseableColumn, InvalidUnparseable, unparseableReason)
+		return record, nil
+	}
+	if latStr == "" {
+		record.markInvalid(r.latIndex, InvalidEmpty, "empty latitude")
+		return record, nil // Return invalid record for empty coordinates
+	}
+	if lngStr == "" {
+		record.markInvalid(r.lngIndex, InvalidEmpty, "empty longitude")
 		return record, nil // Return invalid record for empty coordinates
 	}
 
-	lat, err := strconv.ParseFloat(latStr, 64)
+	lat, err := ParseNumber(latStr, r.numberLocale)
 	if err != nil {
+		record.markInvalid(r.latIndex, InvalidUnparseable, "unparseable latitude")
 		return record, nil // Return invalid record for unparseable coordinates
 	}
 
-	lng, err := strconv.ParseFloat(lngStr, 64)
+	lng, err := ParseNumber(lngStr, r.numberLocale)
 	if err != nil {
+		record.markInvalid(r.lngIndex, InvalidUnparseable, "unparseable longitude")
 		return record, nil // Return invalid record for unparseable coordinates
 	}
 
 	record.Latitude = lat
 	record.Longitude = lng
+	record.Parsed = true
 	record.IsValid = true
+	record.CoordSource = coordSource{lat: r.latMatch, lng: r.lngMatch}.label()
 
 	return record, nil
 }
 
+// SetCoordinateFallback sets the source of coordinates for rows with empty latitude and longitude
+func (r *Reader) SetCoordinateFallback(fallback CoordinateFallback) {
+	r.fallback = fallback
+}
+
+// fillCoordinates fills in a record's missing coordinates from the fallback, writing
+// them to the row's latitude and longitude columns; the record stays invalid if none are found
+func (r *Reader) fillCoordinates(record *Record) *Record {
+	lat, lng, found, err := r.fallback(record.OriginalData)
+	switch {
+	case err != nil:
+		record.markInvalid(r.latIndex, InvalidLookup, "coordinate lookup failed: "+err.Error())
+		return record
+	case !found:
+		record.markInvalid(r.latIndex, InvalidEmpty, "empty coordinates (lookup found no match)")
+		return record
+	}
+
+	record.OriginalData[r.latIndex] = strconv.FormatFloat(lat, 'f', -1, 64)
+	record.OriginalData[r.lngIndex] = strconv.FormatFloat(lng, 'f', -1, 64)
+	record.Latitude = lat
+	record.Longitude = lng
+	record.Parsed = true
+	record.IsValid = true
+	record.Geocoded = true
+	record.CoordSource = GeocodedSource
+	return record
+}
+
+// fallbackCoordinates parses a record's coordinates from a fallback source, writing
+// them to the row's latitude and longitude columns. When they cannot be parsed, the
+// record is left unchanged and the offending column and reason are returned.
+func (r *Reader) fallbackCoordinates(record *Record, source coordSource, latStr, lngStr string) (bool, int, string) {
+	lat, err := ParseNumber(latStr, r.numberLocale)
+	if err != nil {
+		return false, source.lat.Index, "unparseable fallback latitude"
+	}
+	lng, err := ParseNumber(lngStr, r.numberLocale)
+	if err != nil {
+		return false, source.lng.Index, "unparseable fallback longitude"
+	}
+
+	record.OriginalData[r.latIndex] = latStr
+	record.OriginalData[r.lngIndex] = lngStr
+	record.Latitude = lat
+	record.Longitude = lng
+	record.Parsed = true
+	record.IsValid = true
+	record.FromFallback = true
+	record.CoordSource = source.label()
+	return true, -1, ""
+}
+
+// coordinateField returns the trimmed coordinate field at index, or "" when the row is
+// too short or the field is a null value token
+func (r *Reader) coordinateField(row []string, index int) string {
+	if index >= len(row) {
+		return ""
+	}
+	field := strings.TrimSpace(row[index])
+	if field != "" && r.nulls != nil && r.nulls.match(field) {
+		return ""
+	}
+	return field
+}
+
+// NullValueCounts returns how many coordinate fields held each null value token, in
+// configured order (nil when no tokens are configured)
+func (r *Reader) NullValueCounts() []NullCount {
+	if r.nulls == nil {
+		return nil
+	}
+	return append([]NullCount(nil), r.nulls.counts...)
+}
+
+// normalizeFields normalizes Unicode and trims surrounding whitespace from every field
+// in place when enabled, optionally collapsing internal whitespace runs to a single space
+func (r *Reader) normalizeFields(fields []string) {
+	if !r.trimFields && !r.normalizeUnicode {
+		return
+	}
+	for i, field := range fields {
+		if r.normalizeUnicode {
+			field = NormalizeUnicode(field)
+		}
+		if r.collapseWhitespace {
+			field = strings.Join(strings.Fields(field), " ")
+		} else if r.trimFields {
+			field = strings.TrimSpace(field)
+		}
+		fields[i] = field
+	}
+}
+
+// markInvalid flags the record as invalid and remembers the offending column and why
+func (rec *Record) markInvalid(column int, kind InvalidKind, reason string) {
+	rec.IsValid = false
+	rec.InvalidColumn = column
+	rec.InvalidKind = kind
+	rec.InvalidReason = reason
+}
+
+// SetExtra sets the value of an additional output column
+func (rec *Record) SetExtra(column, value string) {
+	if rec.Extra == nil {
+		rec.Extra = make(map[string]string)
+	}
+	rec.Extra[column] = value
+}
+
 // GetHeaders returns the CSV headers if available
 func (r *Reader) GetHeaders() []string {
 	return r.headers
@@ -195,10 +440,127 @@ func (r *Reader) GetLngIndex() int {
 	return r.lngIndex
 }
 
-// Close closes the CSV reader and underlying file
+// InputOffset returns the number of input bytes consumed so far, or 0 when the
+// row source cannot report it
+func (r *Reader) InputOffset() int64 {
+	if source, ok := r.source.(offsetSource); ok {
+		return source.InputOffset()
+	}
+	return 0
+}
+
+// Sizing returns the buffer and batch sizes tuned to the input's record width; the
+// zero Sizing (defaults) for sources that do not measure it
+func (r *Reader) Sizing() Sizing {
+	if source, ok := r.source.(sizedSource); ok {
+		return source.Sizing()
+	}
+	return Sizing{}
+}
+
+// FooterRows returns the number of rows discarded at the end of the input by SkipFooter
+func (r *Reader) FooterRows() int {
+	return r.footerRows
+}
+
+// nextRow returns the next row of the input while holding back keep rows, which are
+// discarded once the source is exhausted. A row that failed to parse is returned with
+// its *csv.ParseError.
+func (r *Reader) nextRow(keep int) ([]string, error) {
+	for len(r.ahead) <= keep {
+		next, err := r.readAhead()
+		if err == io.EOF {
+			r.footerRows += len(r.ahead)
+			r.ahead = r.ahead[:0]
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.ahead = append(r.ahead, next)
+	}
+	next := r.ahead[0]
+	copy(r.ahead, r.ahead[1:])
+	r.ahead = r.ahead[:len(r.ahead)-1]
+	r.line, r.offset = next.line, next.offset
+	return next.row, next.err
+}
+
+// readAhead reads the next row of the source and records its position. It returns
+// an error only when reading fails for another reason than a malformed row.
+func (r *Reader) readAhead() (aheadRow, error) {
+	row, err := r.source.Read()
+	next := aheadRow{row: row, err: err, offset: r.InputOffset()}
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		next.line = parseErr.StartLine
+	case err != nil:
+		return aheadRow{}, err
+	default:
+		if source, ok := r.source.(lineSource); ok {
+			next.line = source.StartLine()
+		}
+	}
+	return next, nil
+}
+
+// skipPreamble discards the first skip rows of the input and then any lines starting
+// with comment. File sources match comments against the raw lines before they are
+// parsed, so comments may hold stray quotes; for other sources, rows whose first field
+// starts with comment are discarded, holding back the first row kept for nextRow. Rows
+// skipped by count may be malformed.
+func (r *Reader) skipPreamble(skip int, comment string) error {
+	for i := 0; i < skip; i++ {
+		if _, err := r.source.Read(); err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				continue
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to skip line %d: %w", i+1, err)
+		}
+	}
+	if comment == "" {
+		return nil
+	}
+	if source, ok := r.source.(commentSource); ok {
+		if err := source.skipComments(comment); err != nil {
+			return fmt.Errorf("failed to skip comment lines: %w", err)
+		}
+		return nil
+	}
+	for {
+		next, err := r.readAhead()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to skip comment lines: %w", err)
+		}
+		if next.err != nil || len(next.row) == 0 || !strings.HasPrefix(strings.TrimLeft(next.row[0], "\ufeff \t"), comment) {
+			r.ahead = append(r.ahead, next)
+			return nil
+		}
+	}
+}
+
+// lineNumber locates the last row read: the input line on which it started for
+// files, so rows with quoted line breaks do not shift later line numbers, and the
+// data row number for other sources
+func (r *Reader) lineNumber() int {
+	if _, ok := r.source.(lineSource); ok {
+		return r.line
+	}
+	return r.rows
+}
+
+// Close closes the CSV reader and underlying row source
 func (r *Reader) Close() error {
-	if r.file != nil {
-		return r.file.Close()
+	if r.source != nil {
+		return r.source.Close()
 	}
 	return nil
 }
@@ -226,8 +588,17 @@ func ValidateColumns(headers []string, config Config) error {
 	return nil
 }
 
+// StreamStats counts the outcome of the rows read by ProcessStream
+type StreamStats struct {
+	Records       int // Records passed to the record handler
+	Valid         int // Records with an H3 index
+	Invalid       int // Records with missing or invalid coordinates or a failed H3 generation
+	MalformedRows int // Rows skipped because they could not be parsed or lack the coordinate columns
+}
+
 // StreamingProcessor implements streaming CSV processing
 type StreamingProcessor struct {
+	stats     StreamStats
 	validator interface {
 		ValidateCoordinates(lat, lng float64) error
 	}
@@ -245,76 +616,338 @@ func NewStreamingProcessor(validator interface{ ValidateCoordinates(lat, lng flo
 	}
 }
 
-// ProcessStream processes CSV records one by one using streaming
+// workerBatchFactor is the number of records per batch when the record width is unknown
+const workerBatchFactor = 256
+
+// streamItem is a record read from the stream together with its evaluation outcome
+type streamItem struct {
+	record  *Record
+	readErr error  // Error returned while reading the row (malformed row)
+	stage   string // Stage that rejected the record: "validation" or "h3"
+	err     error  // Error from the rejecting stage
+}
+
+// sequencedBatch is a batch of consecutive stream items tagged with its position in the input
+type sequencedBatch struct {
+	seq   int
+	items []streamItem
+}
+
+// batchValidator is implemented by validators that check a whole batch of coordinate
+// pairs in one call, reporting the rejected pairs by their index in the batch
+type batchValidator interface {
+	ValidateBatch(lats, lngs []float64) []validator.BatchError
+}
+
+// uncheckedGenerator is implemented by H3 generators that can skip validating
+// coordinates the processor's validator has already checked
+type uncheckedGenerator interface {
+	GenerateUnsafe(lat, lng float64, resolution int) (string, error)
+}
+
+// batchScratch holds the coordinate slices reused across evaluateBatch calls
+type batchScratch struct {
+	lats, lngs []float64
+	items      []int // Batch position of each coordinate pair
+}
+
+// ProcessStream processes CSV records using streaming. Rows are read and evaluated
+// (coordinate validation and H3 generation) in batches of Sizing().BatchRecords() records.
+// When config.Workers is greater than one, batches are read, evaluated and handled in
+// a pipeline, with at most Workers batches in flight. The record handler is always called
+// from a single goroutine and, unless config.Unordered is set, in input order
+// regardless of the number of workers; with Unordered it is called as soon as each
+// record is evaluated. Malformed rows are skipped and counted; other read errors stop
+// processing after the rows read before them are handled, and are returned.
 func (p *StreamingProcessor) ProcessStream(reader *Reader, config Config, recordHandler func(*Record) error) error {
-	recordCount := 0
-	validCount := 0
-	errorCount := 0
+	p.stats = StreamStats{}
+	shownInvalid := 0
+	warnings := logging.NewWarningLimiter(config.WarnLimit)
+	warning := logging.Colorize(config.Color, logging.Yellow, "Warning:") + " "
 
-	for {
-		record, err := reader.ReadRecord()
-		if err != nil {
-			if err.Error() == "EOF" {
-				break // End of file reached
-			}
+	handle := func(item *streamItem) error {
+		if item.readErr != nil {
 			// Handle malformed rows gracefully - log and continue
-			errorCount++
-			if config.Verbose {
-				fmt.Printf("Warning: Skipping malformed row at line %d: %v\n", recordCount+1, err)
-			}
-			continue
-		}
-
-		recordCount++
-
-		// Process valid records
-		if record.IsValid {
-			// Validate coordinates using the validator
-			if p.validator != nil {
-				if err := p.validator.ValidateCoordinates(record.Latitude, record.Longitude); err != nil {
-					record.IsValid = false
-					errorCount++
-					if config.Verbose {
-						fmt.Printf("Warning: Invalid coordinates at line %d: %v\n", record.LineNumber, err)
-					}
-				}
+			p.stats.MalformedRows++
+			if config.Verbose && warnings.Allow("malformed row") {
+				fmt.Printf(warning+"Skipping %v\n", item.readErr)
 			}
+			return nil
+		}
 
-			// Generate H3 index for valid coordinates
-			if record.IsValid && p.h3Generator != nil {
-				h3Index, err := p.h3Generator.Generate(record.Latitude, record.Longitude, config.Resolution)
-				if err != nil {
-					record.IsValid = false
-					errorCount++
-					if config.Verbose {
-						fmt.Printf("Warning: H3 generation failed at line %d: %v\n", record.LineNumber, err)
-					}
-				} else {
-					record.H3Index = h3Index
-					validCount++
-				}
+		record := item.record
+		p.stats.Records++
+
+		switch {
+		case item.stage == "validation":
+			p.stats.Invalid++
+			if config.Verbose && warnings.Allow("invalid coordinates") {
+				fmt.Printf(warning+"Invalid coordinates at line %d: %v\n", record.LineNumber, item.err)
 			}
-		} else {
-			errorCount++
-			if config.Verbose {
-				fmt.Printf("Warning: Skipping invalid record at line %d\n", record.LineNumber)
+		case item.stage == "h3":
+			p.stats.Invalid++
+			if config.Verbose && warnings.Allow("H3 generation failed") {
+				fmt.Printf(warning+"H3 generation failed at line %d: %v\n", record.LineNumber, item.err)
 			}
+		case !record.IsValid:
+			p.stats.Invalid++
+			if config.Verbose && warnings.Allow("invalid record") {
+				fmt.Printf(warning+"Skipping invalid record at line %d\n", record.LineNumber)
+			}
+		case record.H3Index != "":
+			p.stats.Valid++
+		}
+
+		// Show the offending row verbatim for the first few invalid records
+		if !record.IsValid && config.Verbose && shownInvalid < config.ShowInvalid {
+			shownInvalid++
+			fmt.Printf("Invalid row at line %d (%s):\n  %s\n", record.LineNumber, record.InvalidReason,
+				FormatInvalidRow(record.OriginalData, record.InvalidColumn))
 		}
 
 		// Call the record handler
 		if err := recordHandler(record); err != nil {
 			return fmt.Errorf("record handler failed at line %d: %w", record.LineNumber, err)
 		}
+		return nil
+	}
+
+	var err error
+	if config.Workers > 1 {
+		err = p.processParallel(reader, config, handle)
+	} else {
+		err = p.processSequential(reader, config, handle)
+	}
+	if err != nil {
+		return err
 	}
 
 	if config.Verbose {
-		fmt.Printf("Processing complete: %d total records, %d valid, %d errors\n", 
-			recordCount, validCount, errorCount)
+		fmt.Printf("Processing complete: %d total records, %d valid, %d invalid, %d malformed rows skipped\n", 
+			p.stats.Records, p.stats.Valid, p.stats.Invalid, p.stats.MalformedRows)
+		for _, kind := range warnings.Suppressed() {
+			fmt.Printf(warning+"%d more %q warnings suppressed (%d in total)\n", kind.Suppressed, kind.Kind, kind.Total)
+		}
+	}
+
+	return nil
+}
+
+// readBatch reads up to size rows as stream items, appending them to items[:0]. It
+// returns the items read along with io.EOF at the end of the input or any read error
+// other than a malformed row.
+func readBatch(reader *Reader, items []streamItem, size int) ([]streamItem, error) {
+	items = items[:0]
+	for len(items) < size {
+		record, err := reader.ReadRecord()
+		if err != nil && !errors.Is(err, ErrMalformedRow) {
+			return items, err
+		}
+		items = append(items, streamItem{record: record, readErr: err})
+	}
+	return items, nil
+}
+
+// processSequential reads, evaluates and handles one batch at a time
+func (p *StreamingProcessor) processSequential(reader *Reader, config Config, handle func(*streamItem) error) error {
+	size := reader.Sizing().BatchRecords()
+	items := make([]streamItem, 0, size)
+	var scratch batchScratch
+	for {
+		var readErr error
+		items, readErr = readBatch(reader, items, size)
+		p.evaluateBatch(items, reader, config, &scratch)
+		for i := range items {
+			if err := handle(&items[i]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return nil // End of file reached
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read input after %d records: %w", p.stats.Records, readErr)
+		}
+	}
+}
+
+// processParallel runs a reader goroutine, config.Workers evaluating goroutines and
+// the handler in the calling goroutine, restoring input order through a reorder
+// buffer unless config.Unordered is set
+func (p *StreamingProcessor) processParallel(reader *Reader, config Config, handle func(*streamItem) error) error {
+	size := reader.Sizing().BatchRecords()
+	jobs := make(chan sequencedBatch, config.Workers)
+	results := make(chan sequencedBatch, config.Workers)
+	slots := make(chan struct{}, config.Workers) // Batches in flight
+	stop := make(chan struct{})                  // Closed when the handler fails
+
+	// Read batches until the end of the input, a fatal read error, or a handler failure
+	var fatalErr error
+	go func() {
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			select {
+			case slots <- struct{}{}:
+			case <-stop:
+				return
+			}
+			items, err := readBatch(reader, make([]streamItem, 0, size), size)
+			if len(items) > 0 {
+				jobs <- sequencedBatch{seq: seq, items: items}
+			} else {
+				<-slots
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				fatalErr = err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < config.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var scratch batchScratch
+			for job := range jobs {
+				p.evaluateBatch(job.items, reader, config, &scratch)
+				results <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	release := func(items []streamItem) error {
+		<-slots
+		for i := range items {
+			if err := handle(&items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	buffer := newReorderBuffer()
+	var handlerErr error
+	for result := range results {
+		if handlerErr != nil {
+			continue // Drain the pipeline after a failure
+		}
+		if config.Unordered {
+			handlerErr = release(result.items)
+		} else {
+			handlerErr = buffer.push(result.seq, result.items, release)
+		}
+		if handlerErr != nil {
+			close(stop)
+		}
 	}
 
+	if handlerErr != nil {
+		return handlerErr
+	}
+	if fatalErr != nil {
+		return fmt.Errorf("failed to read input after %d records: %w", p.stats.Records, fatalErr)
+	}
 	return nil
 }
 
+// Stats returns the row counters of the last ProcessStream call
+func (p *StreamingProcessor) Stats() StreamStats {
+	return p.stats
+}
+
+// evaluateBatch validates the coordinates of the parsed records of a batch and
+// generates their H3 indexes. Validators implementing ValidateBatch check the whole
+// batch in one call. Coordinates are validated exactly once: when the validator has
+// checked them, generators implementing GenerateUnsafe do not check them again. It
+// only mutates the items, so it is safe to call concurrently for different batches
+// with different scratch space.
+func (p *StreamingProcessor) evaluateBatch(items []streamItem, reader *Reader, config Config, scratch *batchScratch) {
+	scratch.lats, scratch.lngs, scratch.items = scratch.lats[:0], scratch.lngs[:0], scratch.items[:0]
+	for i := range items {
+		if record := items[i].record; items[i].readErr == nil && record.IsValid {
+			scratch.lats = append(scratch.lats, record.Latitude)
+			scratch.lngs = append(scratch.lngs, record.Longitude)
+			scratch.items = append(scratch.items, i)
+		}
+	}
+
+	// Validate coordinates using the validator
+	if batch, ok := p.validator.(batchValidator); ok {
+		for _, batchErr := range batch.ValidateBatch(scratch.lats, scratch.lngs) {
+			rejectCoordinates(&items[scratch.items[batchErr.Index]], reader, batchErr.Err)
+		}
+	} else if p.validator != nil {
+		for j, i := range scratch.items {
+			if err := p.validator.ValidateCoordinates(scratch.lats[j], scratch.lngs[j]); err != nil {
+				rejectCoordinates(&items[i], reader, err)
+			}
+		}
+	}
+
+	// Skip the generator's validation only for coordinates the validator has checked
+	unchecked, ok := p.h3Generator.(uncheckedGenerator)
+	validated := ok && p.validator != nil
+
+	// Generate H3 index for valid coordinates
+	if p.h3Generator != nil {
+		for _, i := range scratch.items {
+			item := &items[i]
+			if item.err != nil {
+				continue
+			}
+			var h3Index string
+			var err error
+			if validated {
+				h3Index, err = unchecked.GenerateUnsafe(item.record.Latitude, item.record.Longitude, config.Resolution)
+			} else {
+				h3Index, err = p.h3Generator.Generate(item.record.Latitude, item.record.Longitude, config.Resolution)
+			}
+			if err != nil {
+				item.record.markInvalid(-1, InvalidH3, err.Error())
+				item.stage, item.err = "h3", err
+				continue
+			}
+			item.record.H3Index = h3Index
+		}
+	}
+}
+
+// rejectCoordinates marks a record rejected by the validator invalid, blaming the
+// latitude column when the latitude is out of range and the longitude column otherwise
+func rejectCoordinates(item *streamItem, reader *Reader, err error) {
+	record := item.record
+	column := reader.GetLngIndex()
+	if record.Latitude < -90 || record.Latitude > 90 {
+		column = reader.GetLatIndex()
+	}
+	record.markInvalid(column, InvalidOutOfRange, err.Error())
+	item.stage, item.err = "validation", err
+}
+
+// FormatInvalidRow renders a row as a comma-separated CSV line, as the input is read,
+// highlighting the offending column with >> << markers around its quoted value
+func FormatInvalidRow(row []string, column int) string {
+	fields := make([]string, len(row))
+	for i, value := range row {
+		if strings.ContainsAny(value, ",\"\r\n") {
+			value = `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+		}
+		if i == column {
+			value = ">>" + value + "<<"
+		}
+		fields[i] = value
+	}
+	return strings.Join(fields, ",")
+}
+
 // ProcessFile implements the Processor interface for streaming processing
 func (p *StreamingProcessor) ProcessFile(config Config) error {
 	// Open input file
@@ -345,7 +978,9 @@ func (p *StreamingProcessor) ValidateColumns(headers []string, config Config) er
 // Writer handles CSV file writing with H3 index column
 type Writer struct {
 	file      *os.File
-	csvWriter *csv.Writer
+	written   *countingWriter // Bytes encoded to the output
+	retry     *RetryWriter // Chunked retrying sink (nil when retries are disabled)
+	csvWriter rowWriter
 	headers   []string
 	config    Config
 }
@@ -362,18 +997,20 @@ func NewWriter(filename string, inputHeaders []string, config Config) (*Writer,
 		return nil, fmt.Errorf("failed to create output file %s: %w", filename, err)
 	}
 
-	csvWriter := csv.NewWriter(file)
-
-	// Prepare headers - add H3 index column as the last column
-	var headers []string
-	if inputHeaders != nil {
-		headers = make([]string, len(inputHeaders)+1)
-		copy(headers, inputHeaders)
-		headers[len(inputHeaders)] = "h3_index"
+	var retry *RetryWriter
+	written := &countingWriter{writer: file}
+	if config.WriteRetry.Enabled() {
+		retry = NewRetryWriter(file, 0, config.WriteRetry)
+		written.writer = retry
 	}
+	csvWriter := newRowWriter(written, config)
+
+	headers := OutputHeaders(inputHeaders, config.H3Column, config.ExtraColumns)
 
 	writer := &Writer{
 		file:      file,
+		written:   written,
+		retry:     retry,
 		csvWriter: csvWriter,
 		headers:   headers,
 		config:    config,
@@ -390,27 +1027,98 @@ func NewWriter(filename string, inputHeaders []string, config Config) (*Writer,
 	return writer, nil
 }
 
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// BytesWritten returns the number of bytes flushed to the output so far
+func (w *Writer) BytesWritten() int64 {
+	return w.written.count
+}
+
 // WriteRecord writes a record to the CSV file
 func (w *Writer) WriteRecord(record *Record) error {
+	outputRow, err := w.FormatRecord(record)
+	if err != nil {
+		return err
+	}
+	return w.WriteRow(outputRow)
+}
+
+// FormatRecord builds the output row for a record without writing it
+func (w *Writer) FormatRecord(record *Record) ([]string, error) {
+	return FormatOutputRow(record, w.config.ExtraColumns)
+}
+
+// OutputHeaders returns the output header row: input headers, the H3 index column
+// (h3_index when h3Column is empty), then extra columns.
+// Returns nil when there are no input headers.
+func OutputHeaders(inputHeaders []string, h3Column string, extraColumns []string) []string {
+	if inputHeaders == nil {
+		return nil
+	}
+	if h3Column == "" {
+		h3Column = DefaultH3Column
+	}
+	headers := make([]string, len(inputHeaders)+1, len(inputHeaders)+1+len(extraColumns))
+	copy(headers, inputHeaders)
+	headers[len(inputHeaders)] = h3Column
+	return append(headers, extraColumns...)
+}
+
+// ReadHeaderRow reads the first row of a CSV file, e.g. an expected header row
+func ReadHeaderRow(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	row, err := csv.NewReader(file).Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row from %s: %w", filename, err)
+	}
+	return row, nil
+}
+
+// FormatOutputRow builds the output row for a record: original data, H3 index, then extra columns
+func FormatOutputRow(record *Record, extraColumns []string) ([]string, error) {
 	if record == nil {
-		return fmt.Errorf("record is nil")
+		return nil, fmt.Errorf("record is nil")
 	}
 
-	// Prepare output row - original data plus H3 index
-	outputRow := make([]string, len(record.OriginalData)+1)
+	// Prepare output row - original data plus H3 index and extra columns
+	outputRow := make([]string, len(record.OriginalData)+1, len(record.OriginalData)+1+len(extraColumns))
 	copy(outputRow, record.OriginalData)
 	
-	// Add H3 index as the last column
+	// Add H3 index after the original columns
 	if record.IsValid && record.H3Index != "" {
 		outputRow[len(record.OriginalData)] = record.H3Index
 	} else {
 		outputRow[len(record.OriginalData)] = "" // Empty H3 index for invalid records
 	}
 
-	if err := w.csvWriter.Write(outputRow); err != nil {
-		return fmt.Errorf("failed to write record: %w", err)
+	// Add extra columns in configured order (missing values are left empty)
+	for _, column := range extraColumns {
+		outputRow = append(outputRow, record.Extra[column])
 	}
 
+	return outputRow, nil
+}
+
+// WriteRow writes an already formatted output row to the CSV file
+func (w *Writer) WriteRow(row []string) error {
+	if err := w.csvWriter.Write(row); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
 	return nil
 }
 
@@ -427,14 +1135,19 @@ func (w *Writer) WriteRecords(records []*Record) error {
 // Flush flushes any buffered data to the underlying file
 func (w *Writer) Flush() error {
 	w.csvWriter.Flush()
-	return w.csvWriter.Error()
+	if err := w.csvWriter.Error(); err != nil {
+		return err
+	}
+	if w.retry != nil {
+		return w.retry.Flush()
+	}
+	return nil
 }
 
 // Close closes the CSV writer and underlying file
 func (w *Writer) Close() error {
 	if w.csvWriter != nil {
-		w.csvWriter.Flush()
-		if err := w.csvWriter.Error(); err != nil {
+		if err := w.Flush(); err != nil {
 			w.file.Close()
 			return fmt.Errorf("error flushing CSV writer: %w", err)
 		}
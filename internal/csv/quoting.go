@@ -0,0 +1,149 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// QuoteMode controls when output fields are enclosed in quotes
+type QuoteMode string
+
+const (
+	// QuoteMinimal quotes only fields that need it (encoding/csv behavior)
+	QuoteMinimal QuoteMode = "minimal"
+	// QuoteAlways quotes every field
+	QuoteAlways QuoteMode = "always"
+	// QuoteNever never quotes; fields that would need quoting are an error
+	QuoteNever QuoteMode = "never"
+)
+
+// ParseQuoteMode parses a quote mode name; empty selects QuoteMinimal
+func ParseQuoteMode(name string) (QuoteMode, error) {
+	switch mode := QuoteMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "":
+		return QuoteMinimal, nil
+	case QuoteMinimal, QuoteAlways, QuoteNever:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid quote mode %q: expected always, minimal, or never", name)
+	}
+}
+
+// rowWriter writes CSV rows; it is satisfied by *encoding/csv.Writer
+type rowWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// newRowWriter creates a CSV row writer honoring the configured buffer size, quoting,
+// line endings and formula escaping
+func newRowWriter(w io.Writer, config Config) rowWriter {
+	if config.BufferSize > 0 {
+		// encoding/csv reuses a large enough bufio.Writer instead of adding its own
+		w = bufio.NewWriterSize(w, config.BufferSize)
+	}
+	var writer rowWriter
+	if config.Quote == "" || config.Quote == QuoteMinimal {
+		csvWriter := csv.NewWriter(w)
+		csvWriter.UseCRLF = config.CRLF
+		writer = csvWriter
+	} else {
+		writer = &quotingWriter{w: bufio.NewWriterSize(w, config.BufferSize), mode: config.Quote, crlf: config.CRLF}
+	}
+	if config.EscapeFormulas {
+		writer = &formulaEscapingWriter{rowWriter: writer}
+	}
+	return writer
+}
+
+// formulaEscapingWriter guards against CSV injection: fields that a spreadsheet would
+// evaluate as a formula get a leading single quote, which makes it show them as text
+type formulaEscapingWriter struct {
+	rowWriter
+}
+
+// Write escapes formula fields without modifying record, then writes the row
+func (f *formulaEscapingWriter) Write(record []string) error {
+	escaped, copied := record, false
+	for i, field := range record {
+		if !IsFormula(field) {
+			continue
+		}
+		if !copied {
+			escaped, copied = append([]string(nil), record...), true
+		}
+		escaped[i] = "'" + field
+	}
+	return f.rowWriter.Write(escaped)
+}
+
+// IsFormula reports whether a spreadsheet could interpret field as a formula: it starts
+// with =, +, -, @, a tab or a carriage return and is not a plain number
+func IsFormula(field string) bool {
+	if field == "" || !strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return false
+	}
+	_, err := strconv.ParseFloat(field, 64)
+	return err != nil
+}
+
+// quotingWriter writes CSV rows with every field quoted or no field quoted
+type quotingWriter struct {
+	w    *bufio.Writer
+	mode QuoteMode
+	crlf bool
+	err  error
+}
+
+// Write writes a single row. In QuoteNever mode, a field containing a comma,
+// quote, or line break cannot be represented and is an error; nothing of the
+// row is written then.
+func (q *quotingWriter) Write(record []string) error {
+	if q.err != nil {
+		return q.err
+	}
+	if q.mode == QuoteNever {
+		for _, field := range record {
+			if strings.ContainsAny(field, ",\"\r\n") {
+				return fmt.Errorf("field %q requires quoting, which is disabled", field)
+			}
+		}
+	}
+
+	for i, field := range record {
+		if i > 0 {
+			q.w.WriteByte(',')
+		}
+		if q.mode == QuoteNever {
+			q.w.WriteString(field)
+			continue
+		}
+		q.w.WriteByte('"')
+		q.w.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		q.w.WriteByte('"')
+	}
+
+	if q.crlf {
+		q.w.WriteString("\r\n")
+	} else {
+		q.w.WriteByte('\n')
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying writer
+func (q *quotingWriter) Flush() {
+	if err := q.w.Flush(); err != nil && q.err == nil {
+		q.err = err
+	}
+}
+
+// Error reports any error from a previous Write or Flush
+func (q *quotingWriter) Error() error {
+	return q.err
+}
@@ -0,0 +1,132 @@
+package csv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriterQuoting(t *testing.T) {
+	record := &Record{OriginalData: []string{"40.7128", "New York, NY", `say "hi"`}, H3Index: "882a107289fffff", IsValid: true}
+	plain := &Record{OriginalData: []string{"40.7128", "Boston", "hello"}, H3Index: "882a302a5bfffff", IsValid: true}
+
+	tests := []struct {
+		name     string
+		quote    QuoteMode
+		crlf     bool
+		records  []*Record
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "minimal",
+			quote:    QuoteMinimal,
+			records:  []*Record{record},
+			expected: "lat,name,note,h3_index\n40.7128,\"New York, NY\",\"say \"\"hi\"\"\",882a107289fffff\n",
+		},
+		{
+			name:     "minimal with CRLF",
+			crlf:     true,
+			records:  []*Record{plain},
+			expected: "lat,name,note,h3_index\r\n40.7128,Boston,hello,882a302a5bfffff\r\n",
+		},
+		{
+			name:     "always with CRLF",
+			quote:    QuoteAlways,
+			crlf:     true,
+			records:  []*Record{record},
+			expected: "\"lat\",\"name\",\"note\",\"h3_index\"\r\n\"40.7128\",\"New York, NY\",\"say \"\"hi\"\"\",\"882a107289fffff\"\r\n",
+		},
+		{
+			name:     "never",
+			quote:    QuoteNever,
+			records:  []*Record{plain},
+			expected: "lat,name,note,h3_index\n40.7128,Boston,hello,882a302a5bfffff\n",
+		},
+		{
+			name:     "never with field needing quotes",
+			quote:    QuoteNever,
+			records:  []*Record{plain, record},
+			expected: "lat,name,note,h3_index\n40.7128,Boston,hello,882a302a5bfffff\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFile := filepath.Join(t.TempDir(), "output.csv")
+			writer, err := NewWriter(outputFile, []string{"lat", "name", "note"}, Config{HasHeaders: true, Quote: tt.quote, CRLF: tt.crlf})
+			if err != nil {
+				t.Fatalf("Failed to create writer: %v", err)
+			}
+			for _, r := range tt.records {
+				err = writer.WriteRecord(r)
+				if err != nil {
+					break
+				}
+			}
+			writer.Close()
+			if tt.wantErr && err == nil {
+				t.Error("Expected error writing a field that needs quoting")
+			} else if !tt.wantErr && err != nil {
+				t.Fatalf("WriteRecord failed: %v", err)
+			}
+
+			content, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(content))
+			}
+		})
+	}
+}
+
+func TestParseQuoteMode(t *testing.T) {
+	for input, expected := range map[string]QuoteMode{"": QuoteMinimal, "Always": QuoteAlways, "never": QuoteNever, "minimal": QuoteMinimal} {
+		if mode, err := ParseQuoteMode(input); err != nil || mode != expected {
+			t.Errorf("ParseQuoteMode(%q): expected %s, got %s (err: %v)", input, expected, mode, err)
+		}
+	}
+	if _, err := ParseQuoteMode("sometimes"); err == nil {
+		t.Error("Expected error for unknown quote mode")
+	}
+}
+
+func TestWriterEscapeFormulas(t *testing.T) {
+	row := []string{"-74.0060", "=HYPERLINK(\"http://x\")", "+1-555", "@SUM(A1)", "-cmd", "plain", ""}
+	outputFile := filepath.Join(t.TempDir(), "output.csv")
+	writer, err := NewWriter(outputFile, nil, Config{EscapeFormulas: true, Quote: QuoteAlways})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := writer.WriteRow(row); err != nil {
+		t.Fatalf("WriteRow failed: %v", err)
+	}
+	writer.Close()
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "\"-74.0060\",\"'=HYPERLINK(\"\"http://x\"\")\",\"'+1-555\",\"'@SUM(A1)\",\"'-cmd\",\"plain\",\"\"\n"
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+	if row[1] != "=HYPERLINK(\"http://x\")" {
+		t.Errorf("Expected the written row to be left unchanged, got %q", row[1])
+	}
+}
+
+func TestIsFormula(t *testing.T) {
+	tests := map[string]bool{
+		"=1+1": true, "+A1": true, "-A1": true, "@cmd": true, "\tx": true, "\rx": true,
+		"-74.0060": false, "+1.5": false, "1e5": false, "text": false, "": false, "a=b": false,
+	}
+	for field, expected := range tests {
+		if got := IsFormula(field); got != expected {
+			t.Errorf("IsFormula(%q) = %v, want %v", field, got, expected)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package csv
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -364,4 +368,468 @@ func TestReaderEdgeCases(t *testing.T) {
 			t.Error("Expected error for insufficient columns")
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestReadRecordTrimFields(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.csv")
+	csvContent := " latitude , longitude ,  place name \n 40.7128 ,-74.0060,  New   York\t City  \n"
+	if err := os.WriteFile(testFile, []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		config          Config
+		expectedHeaders []string
+		expectedRow     []string
+	}{
+		{
+			name:            "disabled",
+			config:          Config{},
+			expectedHeaders: []string{" latitude ", " longitude ", "  place name "},
+			expectedRow:     []string{" 40.7128 ", "-74.0060", "  New   York\t City  "},
+		},
+		{
+			name:            "trim",
+			config:          Config{TrimFields: true},
+			expectedHeaders: []string{"latitude", "longitude", "place name"},
+			expectedRow:     []string{"40.7128", "-74.0060", "New   York\t City"},
+		},
+		{
+			name:            "collapse",
+			config:          Config{CollapseWhitespace: true},
+			expectedHeaders: []string{"latitude", "longitude", "place name"},
+			expectedRow:     []string{"40.7128", "-74.0060", "New York City"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LatColumn, tt.config.LngColumn, tt.config.HasHeaders = "latitude", "longitude", true
+			reader, err := NewReader(testFile, tt.config)
+			if err != nil {
+				t.Fatalf("NewReader failed: %v", err)
+			}
+			defer reader.Close()
+
+			for i, expected := range tt.expectedHeaders {
+				if reader.GetHeaders()[i] != expected {
+					t.Errorf("Header %d: expected %q, got %q", i, expected, reader.GetHeaders()[i])
+				}
+			}
+
+			record, err := reader.ReadRecord()
+			if err != nil {
+				t.Fatalf("ReadRecord failed: %v", err)
+			}
+			for i, expected := range tt.expectedRow {
+				if record.OriginalData[i] != expected {
+					t.Errorf("Field %d: expected %q, got %q", i, expected, record.OriginalData[i])
+				}
+			}
+			if !record.IsValid || record.Latitude != 40.7128 {
+				t.Errorf("Expected valid coordinates, got %+v", record)
+			}
+		})
+	}
+}
+
+// sliceSource is a row source over in-memory rows
+type sliceSource struct {
+	rows   [][]string
+	closed bool
+}
+
+func (s *sliceSource) Read() ([]string, error) {
+	if len(s.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+func (s *sliceSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestNewSourceReader(t *testing.T) {
+	source := &sliceSource{rows: [][]string{
+		{"id", "lat", "lng"},
+		{"1", "40.7128", "-74.0060"},
+		{"2", "51.5074", "-0.1278"},
+	}}
+	reader, err := NewSourceReader(source, Config{LatColumn: "latitude", LngColumn: "longitude", HasHeaders: true})
+	if err != nil {
+		t.Fatalf("NewSourceReader failed: %v", err)
+	}
+
+	if reader.GetLatIndex() != 1 || reader.GetLngIndex() != 2 {
+		t.Errorf("Expected lat/lng indexes 1/2, got %d/%d", reader.GetLatIndex(), reader.GetLngIndex())
+	}
+
+	for line := 1; line <= 2; line++ {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord failed: %v", err)
+		}
+		if !record.IsValid || record.LineNumber != line {
+			t.Errorf("Record %d: expected valid record at line %d, got valid=%v line=%d", line, line, record.IsValid, record.LineNumber)
+		}
+	}
+	if _, err := reader.ReadRecord(); err != io.EOF {
+		t.Errorf("Expected io.EOF after last row, got %v", err)
+	}
+	if reader.InputOffset() != 0 {
+		t.Errorf("Expected zero input offset for non-file source, got %d", reader.InputOffset())
+	}
+
+	reader.Close()
+	if !source.closed {
+		t.Error("Expected Close to close the row source")
+	}
+}
+
+func TestReadRecordFallbackColumns(t *testing.T) {
+	source := &sliceSource{rows: [][]string{
+		{"id", "lat", "lng", "gps_lat", "gps_lng", "wifi_lat", "wifi_lng"},
+		{"1", "40.7128", "-74.0060", "1", "2", "3", "4"},
+		{"2", "", "-0.1", "51.5074", "-0.1278", "3", "4"},
+		{"3", "", "", "", "-0.1278", "48.8566", "2.3522"},
+		{"4", "", "", "", "", "", ""},
+		{"5", "", "", "north", "-0.1278", "48.8566", "2.3522"},
+		{"6", "", "", "north", "-0.1278", "", "2.3522"},
+	}}
+	reader, err := NewSourceReader(source, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true,
+		FallbackSources: []CoordPair{{LatColumn: "gps_lat", LngColumn: "gps_lng"}, {LatColumn: "wifi_lat", LngColumn: "wifi_lng"}}})
+	if err != nil {
+		t.Fatalf("NewSourceReader failed: %v", err)
+	}
+	defer reader.Close()
+	if matches := reader.ColumnMatches(); len(matches) != 6 || matches[2].Index != 3 || matches[5].Index != 6 {
+		t.Fatalf("Expected the fallback columns in the column matches, got %v", matches)
+	}
+	if sources := strings.Join(reader.CoordSources(), ","); sources != "lat:lng,gps_lat:gps_lng,wifi_lat:wifi_lng" {
+		t.Errorf("Unexpected coordinate sources %s", sources)
+	}
+
+	// The first source holding both values that parse is used
+	expected := []struct {
+		valid, fromFallback bool
+		lat, lng            float64
+		kind                InvalidKind
+		source              string
+	}{
+		{true, false, 40.7128, -74.0060, InvalidNone, "lat:lng"},
+		{true, true, 51.5074, -0.1278, InvalidNone, "gps_lat:gps_lng"},
+		{true, true, 48.8566, 2.3522, InvalidNone, "wifi_lat:wifi_lng"},
+		{false, false, 0, 0, InvalidEmpty, ""},
+		{true, true, 48.8566, 2.3522, InvalidNone, "wifi_lat:wifi_lng"},
+		{false, false, 0, 0, InvalidUnparseable, ""},
+	}
+	for i, want := range expected {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("Record %d: ReadRecord failed: %v", i+1, err)
+		}
+		if record.IsValid != want.valid || record.FromFallback != want.fromFallback || record.InvalidKind != want.kind ||
+			record.CoordSource != want.source {
+			t.Errorf("Record %d: expected valid=%t fallback=%t kind=%d source=%q, got %t %t %d %q", i+1,
+				want.valid, want.fromFallback, want.kind, want.source, record.IsValid, record.FromFallback, record.InvalidKind, record.CoordSource)
+		}
+		if want.valid && (record.Latitude != want.lat || record.Longitude != want.lng) {
+			t.Errorf("Record %d: expected (%g, %g), got (%g, %g)", i+1, want.lat, want.lng, record.Latitude, record.Longitude)
+		}
+		// Fallback values are written to the primary columns
+		if i == 1 && (record.OriginalData[1] != "51.5074" || record.OriginalData[2] != "-0.1278") {
+			t.Errorf("Record %d: expected the fallback values in the primary columns, got %v", i+1, record.OriginalData)
+		}
+	}
+}
+
+func TestReadRecordSkipPreamble(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preamble.csv")
+	content := "Exported by \"tracker\" v2\n# exported 2024-05-01\n  # source: gps\nlat,lng\n40.7128,-74.0060\n# not a comment after the header\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	reader, err := NewReader(path, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true, SkipLines: 1, CommentPrefix: "#"})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	if headers := reader.GetHeaders(); len(headers) != 2 || headers[0] != "lat" {
+		t.Fatalf("Expected lat,lng headers, got %v", headers)
+	}
+	record, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatalf("ReadRecord failed: %v", err)
+	}
+	if !record.IsValid || record.LineNumber != 5 {
+		t.Errorf("Expected a valid record on line 5, got valid=%v line=%d", record.IsValid, record.LineNumber)
+	}
+	// Comments are only skipped before the header
+	if _, err := reader.ReadRecord(); !errors.Is(err, ErrMalformedRow) {
+		t.Errorf("Expected the comment after the header to be read as a row, got %v", err)
+	}
+
+	// Without headers the first kept row is the first record
+	reader, err = NewSourceReader(&sliceSource{rows: [][]string{{"#", "x"}, {"1", "2"}}},
+		Config{LatColumn: "0", LngColumn: "1", CommentPrefix: "#"})
+	if err != nil {
+		t.Fatalf("NewSourceReader failed: %v", err)
+	}
+	defer reader.Close()
+	if record, err := reader.ReadRecord(); err != nil || record.Latitude != 1 {
+		t.Errorf("Expected the first record to have latitude 1, got %v, %v", record, err)
+	}
+}
+
+func TestReadRecordSkipCommentsRaw(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "comments.csv")
+	content := "# 6\" screen, \"quoted\n\n# lone \" quote\nlat,lng\n40.7128,-74.0060\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	// Comments with stray quotes are skipped as lines, before CSV parsing
+	for _, mmap := range []bool{false, true} {
+		reader, err := NewReader(path, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true, CommentPrefix: "#", Mmap: mmap})
+		if err != nil {
+			t.Fatalf("NewReader (mmap=%v) failed: %v", mmap, err)
+		}
+		if headers := reader.GetHeaders(); len(headers) != 2 || headers[0] != "lat" {
+			t.Errorf("Expected lat,lng headers (mmap=%v), got %v", mmap, headers)
+		}
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord (mmap=%v) failed: %v", mmap, err)
+		}
+		if !record.IsValid || record.LineNumber != 5 || record.InputOffset != int64(len(content)) {
+			t.Errorf("Expected a valid record on line 5 ending the input (mmap=%v), got valid=%v line=%d offset=%d",
+				mmap, record.IsValid, record.LineNumber, record.InputOffset)
+		}
+		reader.Close()
+	}
+}
+
+func TestReadRecordSkipFooter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "footer.csv")
+	content := "lat,lng\n40.7128,-74.0060\n34.0522,-118.2437\nTOTAL,,\n\"generated\" by export\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	reader, err := NewReader(path, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true, SkipFooter: 2})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	var lines []int
+	for {
+		record, err := reader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadRecord failed: %v", err)
+		}
+		if !record.IsValid {
+			t.Errorf("Expected only valid records, got invalid line %d", record.LineNumber)
+		}
+		lines = append(lines, record.LineNumber)
+	}
+	if fmt.Sprint(lines) != "[2 3]" {
+		t.Errorf("Expected records on lines 2 and 3, got %v", lines)
+	}
+	if reader.FooterRows() != 2 {
+		t.Errorf("Expected 2 footer rows, got %d", reader.FooterRows())
+	}
+}
+
+func TestReadRecordNullValues(t *testing.T) {
+	source := &sliceSource{rows: [][]string{
+		{"lat", "lng", "gps_lat", "gps_lng"},
+		{"NA", "-74.0060", "40.7128", "-74.0060"},
+		{"null", "NULL", "", ""},
+		{"-999", "-999", "", ""},
+		{"n/a", "1", "", ""},
+	}}
+	reader, err := NewSourceReader(source, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true,
+		FallbackSources: []CoordPair{{LatColumn: "gps_lat", LngColumn: "gps_lng"}},
+		NullValues:      ParseNullValues("NA, N/A,null,-999,")})
+	if err != nil {
+		t.Fatalf("NewSourceReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	var kinds []InvalidKind
+	for {
+		record, err := reader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadRecord failed: %v", err)
+		}
+		kinds = append(kinds, record.InvalidKind)
+	}
+	// A null latitude falls back like an empty one; the others are empty, not unparseable
+	expected := []InvalidKind{InvalidNone, InvalidEmpty, InvalidEmpty, InvalidEmpty}
+	if fmt.Sprint(kinds) != fmt.Sprint(expected) {
+		t.Errorf("Expected kinds %v, got %v", expected, kinds)
+	}
+	counts := fmt.Sprint(reader.NullValueCounts())
+	if counts != "[{NA 1} {N/A 1} {null 2} {-999 2}]" {
+		t.Errorf("Unexpected null value counts %s", counts)
+	}
+}
+
+func TestReadRecordNormalizeUnicode(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.csv")
+	csvContent := "\u200blatitude\u00a0,longitude,place\u00a0name\n40.7128,-74.0060,Cafe\u0301\u200b\n"
+	if err := os.WriteFile(testFile, []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Headers are always matched after normalization; values only change when enabled
+	for _, normalize := range []bool{false, true} {
+		reader, err := NewReader(testFile, Config{LatColumn: "latitude", LngColumn: "longitude", HasHeaders: true,
+			NoColumnFallback: true, NormalizeUnicode: normalize})
+		if err != nil {
+			t.Fatalf("NewReader failed (normalize %t): %v", normalize, err)
+		}
+		record, err := reader.ReadRecord()
+		reader.Close()
+		if err != nil {
+			t.Fatalf("ReadRecord failed: %v", err)
+		}
+
+		header, value := "place\u00a0name", "Cafe\u0301\u200b"
+		if normalize {
+			header, value = "place name", "Caf\u00e9"
+		}
+		if reader.GetHeaders()[2] != header || record.OriginalData[2] != value {
+			t.Errorf("Normalize %t: expected %q and %q, got %q and %q",
+				normalize, header, value, reader.GetHeaders()[2], record.OriginalData[2])
+		}
+		if record.Latitude != 40.7128 || record.Longitude != -74.0060 {
+			t.Errorf("Normalize %t: expected parsed coordinates, got %f, %f", normalize, record.Latitude, record.Longitude)
+		}
+	}
+}
+
+func TestReadRecordShortRows(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.csv")
+	csvContent := "lat,lng,name\n40.1;-74.0;a\n40.2;-74.1;b\n40.3;-74.2;c\n40.4;-74.3;d\n"
+	if err := os.WriteFile(testFile, []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	reader, err := NewReader(testFile, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true, MaxShortRows: 3})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := reader.ReadRecord(); !errors.Is(err, ErrMalformedRow) {
+			t.Fatalf("Row %d: expected a malformed row error, got %v", i+1, err)
+		}
+	}
+	_, err = reader.ReadRecord()
+	var structureErr *StructureError
+	if !errors.As(err, &structureErr) {
+		t.Fatalf("Expected a StructureError, got %v", err)
+	}
+	if structureErr.Rows != 4 || structureErr.Expected != 3 || structureErr.Got != 1 || structureErr.Delimiter != ';' {
+		t.Errorf("Unexpected StructureError: %+v", structureErr)
+	}
+	if !strings.Contains(err.Error(), "delimiter") {
+		t.Errorf("Expected a delimiter suggestion, got %q", err.Error())
+	}
+}
+
+func TestReadRecordShortRowsReset(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.csv")
+	csvContent := "lat,lng,name\n40.1,-74.0\n40.2,-74.1\n40.3,-74.2,c\n40.4,-74.3\n40.5,-74.4\n"
+	if err := os.WriteFile(testFile, []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	reader, err := NewReader(testFile, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true, MaxShortRows: 2})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	for rows := 0; ; rows++ {
+		_, err := reader.ReadRecord()
+		if err == io.EOF {
+			if rows != 5 {
+				t.Errorf("Expected 5 rows, got %d", rows)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("Row %d: unexpected error: %v", rows+1, err)
+		}
+	}
+}
+
+func TestReadRecordMultiLineFields(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.csv")
+	csvContent := "lat,lng,address\n" +
+		"40.7,-74.0,\"1 Main St\nApt 2\nNew York\"\n" +
+		"34.0,-118.2,\"2 Elm St\r\nLos Angeles\"\n" +
+		"\n" +
+		"41.9,-87.6,bad \"quote\n" +
+		"47.6,-122.3,\"3 Pine St\n\nSeattle\"\n" +
+		"25.8,-80.2,Miami\n"
+	if err := os.WriteFile(testFile, []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, mmap := range []bool{false, true} {
+		t.Run(fmt.Sprintf("mmap=%v", mmap), func(t *testing.T) {
+			reader, err := NewReader(testFile, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true, Mmap: mmap})
+			if err != nil {
+				t.Fatalf("NewReader failed: %v", err)
+			}
+			defer reader.Close()
+
+			var lines, malformed []int
+			var addresses []string
+			for {
+				record, err := reader.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				var rowErr *RowError
+				if errors.As(err, &rowErr) {
+					malformed = append(malformed, rowErr.Line)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				lines = append(lines, record.LineNumber)
+				addresses = append(addresses, record.OriginalData[2])
+			}
+
+			if want := []int{2, 5, 9, 12}; fmt.Sprint(lines) != fmt.Sprint(want) {
+				t.Errorf("Expected records on lines %v, got %v", want, lines)
+			}
+			if want := []int{8}; fmt.Sprint(malformed) != fmt.Sprint(want) {
+				t.Errorf("Expected a malformed row on lines %v, got %v", want, malformed)
+			}
+			if len(addresses) != 4 || addresses[0] != "1 Main St\nApt 2\nNew York" || addresses[1] != "2 Elm St\nLos Angeles" {
+				t.Errorf("Unexpected multi-line fields: %q", addresses)
+			}
+		})
+	}
+}
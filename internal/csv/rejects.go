@@ -0,0 +1,69 @@
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+)
+
+// ErrorReasonColumn is the column appended to rejected rows explaining why they were rejected
+const ErrorReasonColumn = "error_reason"
+
+// RejectWriter writes invalid records verbatim to an error file, followed by the reason
+// they were rejected, so they can be fixed and retried
+type RejectWriter struct {
+	file      *os.File
+	csvWriter *csv.Writer
+	rows      int
+	closed    bool
+}
+
+// NewRejectWriter creates an error file; the header row is the input headers plus
+// ErrorReasonColumn, or omitted when the input has no headers
+func NewRejectWriter(filename string, inputHeaders []string, overwrite bool) (*RejectWriter, error) {
+	if _, err := os.Stat(filename); err == nil && !overwrite {
+		return nil, fmt.Errorf("error file %s already exists (use overwrite option to replace)", filename)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error file %s: %w", filename, err)
+	}
+
+	writer := &RejectWriter{file: file, csvWriter: csv.NewWriter(file)}
+	if inputHeaders != nil {
+		headers := append(append([]string{}, inputHeaders...), ErrorReasonColumn)
+		if err := writer.csvWriter.Write(headers); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write error file headers: %w", err)
+		}
+	}
+	return writer, nil
+}
+
+// WriteRecord writes an invalid record's original data and reason
+func (w *RejectWriter) WriteRecord(record *Record) error {
+	row := make([]string, len(record.OriginalData)+1)
+	copy(row, record.OriginalData)
+	row[len(record.OriginalData)] = record.InvalidReason
+	w.rows++
+	return w.csvWriter.Write(row)
+}
+
+// Rows returns the number of rejected rows written
+func (w *RejectWriter) Rows() int {
+	return w.rows
+}
+
+// Close flushes and closes the error file; closing twice is a no-op
+func (w *RejectWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.csvWriter.Flush()
+	if err := w.csvWriter.Error(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
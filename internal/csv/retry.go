@@ -0,0 +1,90 @@
+package csv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// retryChunkSize is the amount of output buffered before a chunk is written
+const retryChunkSize = 1 << 20 // 1 MiB
+
+// RetryPolicy controls retries of failed output writes
+type RetryPolicy struct {
+	Retries int           // Retries after the first failed attempt (0 disables retrying)
+	Backoff time.Duration // Delay before the first retry, doubled for each further retry
+}
+
+// Enabled reports whether writes should be retried
+func (p RetryPolicy) Enabled() bool {
+	return p.Retries > 0
+}
+
+// RetryWriter buffers output into chunks and writes each chunk at a fixed file offset,
+// retrying failed writes. Every attempt rewrites the whole chunk at the same offset,
+// so a partially written chunk is overwritten rather than duplicated.
+type RetryWriter struct {
+	target io.WriterAt
+	offset int64
+	policy RetryPolicy
+	buffer bytes.Buffer
+	sleep  func(time.Duration)
+}
+
+// NewRetryWriter creates a retrying writer that starts writing at offset
+func NewRetryWriter(target io.WriterAt, offset int64, policy RetryPolicy) *RetryWriter {
+	return &RetryWriter{
+		target: target,
+		offset: offset,
+		policy: policy,
+		sleep:  time.Sleep,
+	}
+}
+
+// Write buffers p, writing a chunk once enough output has accumulated
+func (w *RetryWriter) Write(p []byte) (int, error) {
+	w.buffer.Write(p)
+	if w.buffer.Len() >= retryChunkSize {
+		if err := w.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered chunk, retrying according to the policy. The error of the
+// last attempt is returned only after all retries are exhausted.
+func (w *RetryWriter) Flush() error {
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+
+	chunk := w.buffer.Bytes()
+	backoff := w.policy.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= w.policy.Retries; attempt++ {
+		if attempt > 0 {
+			w.sleep(backoff)
+			backoff *= 2
+		}
+
+		n, err := w.target.WriteAt(chunk, w.offset)
+		if err == nil && n == len(chunk) {
+			w.offset += int64(n)
+			w.buffer.Reset()
+			return nil
+		}
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		lastErr = err
+	}
+
+	return fmt.Errorf("write failed after %d attempts: %w", w.policy.Retries+1, lastErr)
+}
+
+// Offset returns the file offset the next chunk will be written at
+func (w *RetryWriter) Offset() int64 {
+	return w.offset
+}
@@ -0,0 +1,102 @@
+package csv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyTarget fails the first failures WriteAt calls after writing half of the chunk
+type flakyTarget struct {
+	data     []byte
+	failures int
+	calls    int
+}
+
+func (f *flakyTarget) WriteAt(p []byte, off int64) (int, error) {
+	f.calls++
+	n := len(p)
+	if f.failures > 0 {
+		f.failures--
+		n = len(p) / 2
+	}
+	if end := int(off) + n; end > len(f.data) {
+		f.data = append(f.data, make([]byte, end-len(f.data))...)
+	}
+	copy(f.data[off:], p[:n])
+	if n < len(p) {
+		return n, fmt.Errorf("transient network error")
+	}
+	return n, nil
+}
+
+func TestRetryWriter_RetriesTransientErrors(t *testing.T) {
+	target := &flakyTarget{failures: 2}
+	writer := NewRetryWriter(target, 0, RetryPolicy{Retries: 3, Backoff: time.Second})
+
+	var delays []time.Duration
+	writer.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	writer.Write([]byte("first chunk\n"))
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	writer.Write([]byte("second\n"))
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if got := string(target.data); got != "first chunk\nsecond\n" {
+		t.Errorf("Expected partially written chunks to be rewritten, got %q", got)
+	}
+	if len(delays) != 2 || delays[0] != time.Second || delays[1] != 2*time.Second {
+		t.Errorf("Expected exponential backoff [1s 2s], got %v", delays)
+	}
+}
+
+func TestRetryWriter_GivesUpAfterRetries(t *testing.T) {
+	target := &flakyTarget{failures: 10}
+	writer := NewRetryWriter(target, 0, RetryPolicy{Retries: 2})
+	writer.sleep = func(time.Duration) {}
+
+	writer.Write([]byte("data\n"))
+	err := writer.Flush()
+	if err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") || !strings.Contains(err.Error(), "transient network error") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if target.calls != 3 {
+		t.Errorf("Expected 3 write attempts, got %d", target.calls)
+	}
+}
+
+func TestWriterWithRetries(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "output.csv")
+	config := Config{HasHeaders: true, WriteRetry: RetryPolicy{Retries: 2}}
+
+	writer, err := NewWriter(outputFile, []string{"latitude", "longitude"}, config)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	record := &Record{OriginalData: []string{"40.7128", "-74.0060"}, H3Index: "882a100d2ffffff", IsValid: true}
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatalf("WriteRecord failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "latitude,longitude,h3_index\n40.7128,-74.0060,882a100d2ffffff\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(content))
+	}
+}
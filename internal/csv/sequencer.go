@@ -0,0 +1,36 @@
+package csv
+
+// reorderBuffer releases batches that complete out of order in their sequence order
+type reorderBuffer struct {
+	next    int                  // Sequence number of the next batch to release
+	pending map[int][]streamItem // Completed batches waiting for an earlier one
+}
+
+func newReorderBuffer() *reorderBuffer {
+	return &reorderBuffer{pending: make(map[int][]streamItem)}
+}
+
+// push adds a completed batch and calls release for it and every batch following it
+// that is already complete, stopping at the first release error
+func (b *reorderBuffer) push(seq int, items []streamItem, release func([]streamItem) error) error {
+	if seq != b.next {
+		b.pending[seq] = items
+		return nil
+	}
+	for {
+		if err := release(items); err != nil {
+			return err
+		}
+		b.next++
+		var ok bool
+		if items, ok = b.pending[b.next]; !ok {
+			return nil
+		}
+		delete(b.pending, b.next)
+	}
+}
+
+// Len returns the number of batches waiting for an earlier one
+func (b *reorderBuffer) Len() int {
+	return len(b.pending)
+}
@@ -0,0 +1,69 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Read and write buffers and parallel batches are sized from the average record length
+// measured on the start of the input, so narrow telemetry files and wide exports both
+// get buffers holding about the same number of records
+const (
+	sizingSampleBytes = 64 << 10 // Input bytes sampled to measure record width
+	bufferRecords     = 1024     // Records held by the read and write buffers
+	minBufferSize     = 64 << 10
+	maxBufferSize     = 4 << 20
+	batchBytes        = 1 << 20 // Input bytes in flight per worker
+	minBatchRecords   = 64
+	maxBatchRecords   = 4096
+)
+
+// Sizing holds buffer and batch sizes tuned to the average record length of an input
+type Sizing struct {
+	RecordBytes int // Average record length in bytes (0 = unknown)
+}
+
+// BufferSize returns the read/write buffer size, or 0 for the default when the
+// record length is unknown
+func (s Sizing) BufferSize() int {
+	if s.RecordBytes <= 0 {
+		return 0
+	}
+	return clamp(s.RecordBytes*bufferRecords, minBufferSize, maxBufferSize)
+}
+
+// BatchRecords returns the number of records read and evaluated as one batch;
+// workerBatchFactor when the record length is unknown
+func (s Sizing) BatchRecords() int {
+	if s.RecordBytes <= 0 {
+		return workerBatchFactor
+	}
+	return clamp(batchBytes/s.RecordBytes, minBatchRecords, maxBatchRecords)
+}
+
+// sampleSizing measures the average record length on the first bytes of r without
+// consuming them and returns a reader buffered accordingly
+func sampleSizing(r io.Reader) (*bufio.Reader, Sizing) {
+	buffered := bufio.NewReaderSize(r, sizingSampleBytes)
+	sample, _ := buffered.Peek(sizingSampleBytes)
+	sizing := Sizing{RecordBytes: averageLineLength(sample)}
+	if size := sizing.BufferSize(); size > sizingSampleBytes {
+		buffered = bufio.NewReaderSize(buffered, size)
+	}
+	return buffered, sizing
+}
+
+// averageLineLength returns the average length of the complete lines in sample,
+// or 0 if it holds none
+func averageLineLength(sample []byte) int {
+	lines := bytes.Count(sample, []byte{'\n'})
+	if lines == 0 {
+		return 0
+	}
+	return (bytes.LastIndexByte(sample, '\n') + 1) / lines
+}
+
+func clamp(value, low, high int) int {
+	return max(low, min(value, high))
+}
@@ -0,0 +1,77 @@
+package csv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSizing(t *testing.T) {
+	tests := []struct {
+		name         string
+		recordBytes  int
+		bufferSize   int
+		batchRecords int
+	}{
+		{"unknown", 0, 0, workerBatchFactor},
+		{"narrow telemetry", 24, minBufferSize, maxBatchRecords},
+		{"typical", 200, 200 * bufferRecords, maxBatchRecords},
+		{"wide export", 2000, 2000 * bufferRecords, 524},
+		{"very wide", 100000, maxBufferSize, minBatchRecords},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizing := Sizing{RecordBytes: tt.recordBytes}
+			if got := sizing.BufferSize(); got != tt.bufferSize {
+				t.Errorf("BufferSize() = %d, want %d", got, tt.bufferSize)
+			}
+			if got := sizing.BatchRecords(); got != tt.batchRecords {
+				t.Errorf("BatchRecords() = %d, want %d", got, tt.batchRecords)
+			}
+		})
+	}
+}
+
+func TestAverageLineLength(t *testing.T) {
+	if got := averageLineLength([]byte("a,b\n1,2\n3,4\n5,")); got != 4 {
+		t.Errorf("Expected 4 bytes per line ignoring the partial line, got %d", got)
+	}
+	if got := averageLineLength([]byte("no newline")); got != 0 {
+		t.Errorf("Expected 0 without a complete line, got %d", got)
+	}
+}
+
+func TestReaderSizingWideRecords(t *testing.T) {
+	// Rows wider than the sample still read correctly through the enlarged buffer
+	var content strings.Builder
+	wide := strings.Repeat("x", 3000)
+	content.WriteString("latitude,longitude,payload\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&content, "%d.5,-74.0,%s%d\n", i%90, wide, i)
+	}
+	testFile := filepath.Join(t.TempDir(), "wide.csv")
+	if err := os.WriteFile(testFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	reader, err := NewReader(testFile, Config{LatColumn: "latitude", LngColumn: "longitude", HasHeaders: true})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	if sizing := reader.Sizing(); sizing.RecordBytes < 2000 || sizing.BufferSize() <= sizingSampleBytes {
+		t.Errorf("Expected wide records to enlarge the buffer, got %+v", sizing)
+	}
+	for i := 0; i < 100; i++ {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord %d failed: %v", i, err)
+		}
+		if record.OriginalData[2] != wide+fmt.Sprint(i) || record.Latitude != float64(i%90)+0.5 {
+			t.Fatalf("Record %d read incorrectly", i)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// RowSource supplies rows of string fields to a Reader, e.g. a CSV file or a
+// database query. Read returns io.EOF once all rows have been read.
+type RowSource interface {
+	Read() ([]string, error)
+	Close() error
+}
+
+// fileSource reads rows from a CSV file or a zip archive member
+type fileSource struct {
+	file         io.Closer
+	buffered     *bufio.Reader // Input shared with csvReader, which reuses a large enough bufio.Reader
+	csvReader    *csv.Reader
+	sizing       Sizing
+	skipped      int   // Lines discarded by skipComments, which csvReader does not count
+	skippedBytes int64 // Bytes of those lines
+}
+
+// openFileSource opens a CSV file as a row source
+func openFileSource(filename string) (*fileSource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+
+	return newFileSource(file, file), nil
+}
+
+// newFileSource reads CSV rows from r, closing closer with the source
+func newFileSource(r io.Reader, closer io.Closer) *fileSource {
+	buffered, sizing := sampleSizing(r)
+	csvReader := csv.NewReader(buffered)
+	csvReader.FieldsPerRecord = -1 // Allow variable number of fields
+	return &fileSource{file: closer, buffered: buffered, csvReader: csvReader, sizing: sizing}
+}
+
+// Sizing returns the buffer sizes tuned to the file's record width
+func (s *fileSource) Sizing() Sizing {
+	return s.sizing
+}
+
+func (s *fileSource) Read() ([]string, error) {
+	row, err := s.csvReader.Read()
+	var parseErr *csv.ParseError
+	if s.skipped > 0 && errors.As(err, &parseErr) {
+		parseErr.StartLine += s.skipped
+		parseErr.Line += s.skipped
+	}
+	return row, err
+}
+
+// skipComments discards the lines at the current position that start with prefix,
+// ignoring leading whitespace and a byte order mark, and blank lines between them.
+// Lines are matched as read, before CSV parsing, so a comment may hold stray quotes.
+func (s *fileSource) skipComments(prefix string) error {
+	for {
+		head, err := s.buffered.Peek(s.buffered.Size())
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return err
+		}
+		if len(head) == 0 {
+			return nil
+		}
+		if end := strings.IndexByte(string(head), '\n'); end >= 0 {
+			head = head[:end+1]
+		}
+		if !isCommentLine(string(head), prefix) {
+			return nil
+		}
+		line, err := s.buffered.ReadString('\n')
+		s.skipped++
+		s.skippedBytes += int64(len(line))
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isCommentLine reports whether a raw input line starts with the comment prefix or
+// is blank; the line may be cut short of its end
+func isCommentLine(line, prefix string) bool {
+	trimmed := strings.TrimLeft(line, "\ufeff \t")
+	return strings.HasPrefix(trimmed, prefix) || strings.TrimRight(trimmed, "\r\n") == "" && strings.HasSuffix(line, "\n")
+}
+
+// InputOffset returns the number of bytes of the file consumed so far
+func (s *fileSource) InputOffset() int64 {
+	return s.csvReader.InputOffset() + s.skippedBytes
+}
+
+// StartLine returns the line on which the last record read started, counting the
+// line breaks inside quoted fields of earlier records
+func (s *fileSource) StartLine() int {
+	line, _ := s.csvReader.FieldPos(0)
+	return line + s.skipped
+}
+
+func (s *fileSource) Close() error {
+	return s.file.Close()
+}
+
+// offsetSource is implemented by row sources that can report their input byte offset
+type offsetSource interface {
+	InputOffset() int64
+}
+
+// lineSource is implemented by row sources that can report the input line on which
+// the last record read started
+type lineSource interface {
+	StartLine() int
+}
+
+// commentSource is implemented by row sources that can discard comment lines of the
+// raw input before they are parsed as rows
+type commentSource interface {
+	skipComments(prefix string) error
+}
+
+// sizedSource is implemented by row sources that measured their record width
+type sizedSource interface {
+	Sizing() Sizing
+}
+
+// NewSourceReader creates a reader over rows from any row source. With HasHeaders
+// the first row read is taken as the header row. The source is closed on error.
+func NewSourceReader(source RowSource, config Config) (*Reader, error) {
+	reader := &Reader{
+		source:             source,
+		hasHeaders:         config.HasHeaders,
+		latIndex:           -1,
+		lngIndex:           -1,
+		numberLocale:       config.NumberLocale,
+		trimFields:         config.TrimFields || config.CollapseWhitespace,
+		collapseWhitespace: config.CollapseWhitespace,
+		columnFallback:     columnFallbackFor(config),
+		normalizeUnicode:   config.NormalizeUnicode,
+		structure:          structureMonitor{limit: config.MaxShortRows},
+		nulls:              newNullValues(config.NullValues),
+		footer:             max(config.SkipFooter, 0),
+	}
+
+	// Skip preamble lines before the header
+	if err := reader.skipPreamble(config.SkipLines, config.CommentPrefix); err != nil {
+		source.Close()
+		return nil, err
+	}
+
+	// Read headers if present
+	if config.HasHeaders {
+		headers, err := reader.nextRow(0)
+		if err != nil {
+			source.Close()
+			return nil, fmt.Errorf("failed to read headers: %w", err)
+		}
+		reader.normalizeFields(headers)
+		reader.headers = headers
+		reader.structure.expected = len(headers)
+	}
+
+	// Detect column indices
+	if err := reader.detectColumns(config); err != nil {
+		source.Close()
+		return nil, err
+	}
+
+	return reader, nil
+}
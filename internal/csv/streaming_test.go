@@ -1,10 +1,19 @@
 package csv
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"csv-h3-tool/internal/validator"
 )
 
 // Mock validator for testing
@@ -376,4 +385,457 @@ func TestProcessStreamWithNilComponents(t *testing.T) {
 	if processedRecords[0].H3Index != "" {
 		t.Error("Record should not have H3 index with nil generator")
 	}
-}
\ No newline at end of file
+}
+
+func TestFormatInvalidRow(t *testing.T) {
+	tests := []struct {
+		name     string
+		row      []string
+		column   int
+		expected string
+	}{
+		{"highlight latitude", []string{"91.0", "0.0", "Bad"}, 0, ">>91.0<<,0.0,Bad"},
+		{"highlight longitude", []string{"10.0", "abc", "Bad"}, 1, "10.0,>>abc<<,Bad"},
+		{"highlight empty value", []string{"", "0.0"}, 0, ">><<,0.0"},
+		{"unknown column", []string{"10.0", "20.0"}, -1, "10.0,20.0"},
+		{"quoted fields", []string{"91.0", "New York, NY", `say "hi"`}, 1, `91.0,>>"New York, NY"<<,"say ""hi"""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatInvalidRow(tt.row, tt.column); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProcessStreamInvalidColumnTracking(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test.csv")
+
+	csvContent := "name,latitude,longitude\nA,91.0,0.0\nB,10.0,181.0\nC,abc,0.0\nD,10.0,\n"
+	if err := os.WriteFile(testFile, []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := Config{
+		LatColumn:  "latitude",
+		LngColumn:  "longitude",
+		HasHeaders: true,
+		Resolution: 8,
+	}
+
+	reader, err := NewReader(testFile, config)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	processor := NewStreamingProcessor(&mockValidator{}, &mockH3Generator{})
+
+	var columns []int
+	err = processor.ProcessStream(reader, config, func(record *Record) error {
+		if record.IsValid {
+			t.Errorf("Expected record at line %d to be invalid", record.LineNumber)
+		}
+		if record.InvalidReason == "" {
+			t.Errorf("Expected invalid reason for record at line %d", record.LineNumber)
+		}
+		columns = append(columns, record.InvalidColumn)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+
+	expected := []int{1, 2, 1, 2}
+	if len(columns) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(columns))
+	}
+	for i := range expected {
+		if columns[i] != expected[i] {
+			t.Errorf("Record %d: expected invalid column %d, got %d", i, expected[i], columns[i])
+		}
+	}
+}
+
+func TestProcessStreamParallelPreservesOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test.csv")
+
+	// Enough rows to span several worker batches, with every tenth row invalid
+	content := "latitude,longitude,id\n"
+	for i := 0; i < 3000; i++ {
+		lat := float64(i%180) - 89.5
+		if i%10 == 0 {
+			lat = 95
+		}
+		content += fmt.Sprintf("%.1f,%.3f,%d\n", lat, float64(i%360)-179.5, i)
+	}
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := Config{
+		LatColumn:  "latitude",
+		LngColumn:  "longitude",
+		HasHeaders: true,
+		Resolution: 8,
+		Workers:    4,
+	}
+
+	reader, err := NewReader(testFile, config)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	processor := NewStreamingProcessor(&mockValidator{}, &mockH3Generator{})
+
+	next := 0
+	err = processor.ProcessStream(reader, config, func(record *Record) error {
+		if id := record.OriginalData[2]; id != fmt.Sprint(next) {
+			t.Fatalf("Expected record %d, got %s", next, id)
+		}
+		if wantValid := next%10 != 0; record.IsValid != wantValid {
+			t.Errorf("Record %d: expected valid=%v", next, wantValid)
+		}
+		if record.IsValid {
+			expected := fmt.Sprintf("h3_8_%.3f_%.3f", record.Latitude, record.Longitude)
+			if record.H3Index != expected {
+				t.Errorf("Record %d: expected H3 index %s, got %s", next, expected, record.H3Index)
+			}
+		}
+		next++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+	if next != 3000 {
+		t.Errorf("Expected 3000 records, got %d", next)
+	}
+}
+
+// failingSource returns its rows and then a read error instead of io.EOF
+type failingSource struct {
+	rows [][]string
+	err  error
+}
+
+func (s *failingSource) Read() ([]string, error) {
+	if len(s.rows) == 0 {
+		return nil, s.err
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+func (s *failingSource) Close() error { return nil }
+
+func TestProcessStreamErrorClassification(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "malformed.csv")
+	content := "latitude,longitude\n40.7128,-74.0060\n51.5\n34.0522,\"-118.2437\n"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := Config{InputFile: testFile, HasHeaders: true, Resolution: 8}
+	reader, err := NewReader(testFile, config)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	processor := NewStreamingProcessor(&mockValidator{}, &mockH3Generator{})
+	if err := processor.ProcessStream(reader, config, func(*Record) error { return nil }); err != nil {
+		t.Fatalf("ProcessStream should skip malformed rows, got: %v", err)
+	}
+	stats := processor.Stats()
+	if stats.Records != 1 || stats.Valid != 1 || stats.MalformedRows != 2 {
+		t.Errorf("Expected 1 valid record and 2 malformed rows, got %+v", stats)
+	}
+
+	// A read error other than a malformed row is fatal, even when wrapped
+	ioErr := fmt.Errorf("network read: %w", os.ErrDeadlineExceeded)
+	source := &failingSource{rows: [][]string{{"latitude", "longitude"}, {"40.7128", "-74.0060"}}, err: ioErr}
+	reader, err = NewSourceReader(source, config)
+	if err != nil {
+		t.Fatalf("NewSourceReader failed: %v", err)
+	}
+	handled := 0
+	err = processor.ProcessStream(reader, config, func(*Record) error { handled++; return nil })
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Expected the read error to be returned, got: %v", err)
+	}
+	if handled != 1 {
+		t.Errorf("Expected the row before the error to be handled, got %d", handled)
+	}
+
+	// A wrapped io.EOF still ends the stream cleanly
+	source = &failingSource{rows: [][]string{{"latitude", "longitude"}}, err: fmt.Errorf("query done: %w", io.EOF)}
+	reader, err = NewSourceReader(source, config)
+	if err != nil {
+		t.Fatalf("NewSourceReader failed: %v", err)
+	}
+	if err := processor.ProcessStream(reader, config, func(*Record) error { return nil }); err != nil {
+		t.Errorf("Expected wrapped io.EOF to end the stream, got: %v", err)
+	}
+}
+
+// jitterH3Generator takes longer for some records so that workers finish out of order
+type jitterH3Generator struct{}
+
+func (jitterH3Generator) Generate(lat, lng float64, resolution int) (string, error) {
+	if int(lng*10)%7 == 0 {
+		time.Sleep(50 * time.Microsecond)
+	}
+	return fmt.Sprintf("h3_%d_%.3f_%.3f", resolution, lat, lng), nil
+}
+
+func TestProcessStreamOrderIndependentOfWorkers(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("latitude,longitude,id\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&content, "%.1f,%.1f,%d\n", float64(i%180)-89.5, float64(i%3600)/10-179.5, i)
+	}
+	testFile := filepath.Join(t.TempDir(), "test.csv")
+	if err := os.WriteFile(testFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	run := func(workers int, unordered bool) []string {
+		config := Config{LatColumn: "latitude", LngColumn: "longitude", HasHeaders: true, Resolution: 8,
+			Workers: workers, Unordered: unordered}
+		reader, err := NewReader(testFile, config)
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		var rows []string
+		processor := NewStreamingProcessor(&mockValidator{}, jitterH3Generator{})
+		err = processor.ProcessStream(reader, config, func(record *Record) error {
+			rows = append(rows, strings.Join(record.OriginalData, ",")+","+record.H3Index)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ProcessStream failed with %d workers: %v", workers, err)
+		}
+		return rows
+	}
+
+	sequential := run(1, false)
+	for _, workers := range []int{2, 8, 32} {
+		if rows := run(workers, false); !reflect.DeepEqual(rows, sequential) {
+			t.Errorf("Expected identical output with %d workers", workers)
+		}
+	}
+
+	// Unordered output holds the same records, possibly in another order
+	unordered := run(8, true)
+	sort.Strings(unordered)
+	sorted := append([]string(nil), sequential...)
+	sort.Strings(sorted)
+	if !reflect.DeepEqual(unordered, sorted) {
+		t.Errorf("Expected the same %d records unordered, got %d", len(sorted), len(unordered))
+	}
+}
+
+func TestProcessStreamParallelErrors(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("latitude,longitude\n")
+	for i := 0; i < 5000; i++ {
+		content.WriteString("40.7128,-74.0060\n")
+	}
+	testFile := filepath.Join(t.TempDir(), "test.csv")
+	if err := os.WriteFile(testFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	config := Config{LatColumn: "latitude", LngColumn: "longitude", HasHeaders: true, Resolution: 8, Workers: 4}
+
+	// A handler failure stops the pipeline without waiting for the rest of the input
+	for _, unordered := range []bool{false, true} {
+		config.Unordered = unordered
+		reader, err := NewReader(testFile, config)
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		handled := 0
+		err = NewStreamingProcessor(&mockValidator{}, &mockH3Generator{}).ProcessStream(reader, config, func(*Record) error {
+			if handled++; handled == 100 {
+				return fmt.Errorf("handler error")
+			}
+			return nil
+		})
+		reader.Close()
+		if err == nil || handled != 100 {
+			t.Errorf("Unordered %t: expected the handler error after 100 records, got %v after %d", unordered, err, handled)
+		}
+	}
+
+	// Rows read before a fatal read error are still handled, in order
+	config.Unordered = false
+	source := &failingSource{err: os.ErrDeadlineExceeded}
+	source.rows = append(source.rows, []string{"latitude", "longitude"})
+	for i := 0; i < 1500; i++ {
+		source.rows = append(source.rows, []string{fmt.Sprint(i % 90), "0"})
+	}
+	reader, err := NewSourceReader(source, config)
+	if err != nil {
+		t.Fatalf("NewSourceReader failed: %v", err)
+	}
+	handled := 0
+	err = NewStreamingProcessor(&mockValidator{}, &mockH3Generator{}).ProcessStream(reader, config, func(record *Record) error {
+		if record.Latitude != float64(handled%90) {
+			t.Fatalf("Expected record %d in order, got latitude %f", handled, record.Latitude)
+		}
+		handled++
+		return nil
+	})
+	if !errors.Is(err, os.ErrDeadlineExceeded) || handled != 1500 {
+		t.Errorf("Expected all 1500 rows handled before the read error, got %d and %v", handled, err)
+	}
+}
+
+func TestReorderBuffer(t *testing.T) {
+	buffer := newReorderBuffer()
+	var released []int
+	release := func(items []streamItem) error {
+		for _, item := range items {
+			released = append(released, item.record.LineNumber)
+		}
+		return nil
+	}
+	for _, seq := range []int{2, 0, 3, 1, 5, 4} {
+		items := []streamItem{{record: &Record{LineNumber: 2 * seq}}, {record: &Record{LineNumber: 2*seq + 1}}}
+		if err := buffer.push(seq, items, release); err != nil {
+			t.Fatalf("push failed: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(released, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}) || buffer.Len() != 0 {
+		t.Errorf("Expected batches released in order, got %v with %d pending", released, buffer.Len())
+	}
+}
+
+// batchMockValidator rejects longitudes above 100 and counts the calls it receives
+type batchMockValidator struct {
+	mockValidator
+	batches, pairs int
+}
+
+func (m *batchMockValidator) ValidateBatch(lats, lngs []float64) []validator.BatchError {
+	m.batches++
+	m.pairs += len(lats)
+	var errs []validator.BatchError
+	for i := range lats {
+		if lngs[i] > 100 {
+			errs = append(errs, validator.BatchError{Index: i, Err: fmt.Errorf("longitude %.1f rejected", lngs[i])})
+		}
+	}
+	return errs
+}
+
+func TestProcessStreamBatchValidation(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("id,lat,lng\n")
+	for i := 0; i < 1000; i++ {
+		switch {
+		case i%7 == 0:
+			fmt.Fprintf(&content, "%d,bad,0\n", i) // Unparseable: not passed to the validator
+		case i%5 == 0:
+			fmt.Fprintf(&content, "%d,10,150\n", i)
+		default:
+			fmt.Fprintf(&content, "%d,10,20\n", i)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "batch.csv")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, workers := range []int{1, 4} {
+		config := Config{InputFile: path, LatColumn: "lat", LngColumn: "lng", HasHeaders: true, Resolution: 8, Workers: workers}
+		reader, err := NewReader(path, config)
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		batches := &batchMockValidator{}
+		line := 0
+		err = NewStreamingProcessor(batches, &mockH3Generator{}).ProcessStream(reader, config, func(record *Record) error {
+			i := line
+			line++
+			switch {
+			case record.OriginalData[0] != strconv.Itoa(i):
+				return fmt.Errorf("record %d out of order: got id %s", i, record.OriginalData[0])
+			case i%7 == 0 && (record.IsValid || record.InvalidKind != InvalidUnparseable):
+				return fmt.Errorf("record %d: expected a parse error, got %+v", i, record)
+			case i%7 != 0 && i%5 == 0 && (record.IsValid || record.InvalidKind != InvalidOutOfRange || record.InvalidColumn != 2):
+				return fmt.Errorf("record %d: expected a rejected longitude, got %+v", i, record)
+			case i%7 != 0 && i%5 != 0 && record.H3Index == "":
+				return fmt.Errorf("record %d: expected an H3 index, got %+v", i, record)
+			}
+			return nil
+		})
+		reader.Close()
+		if err != nil {
+			t.Fatalf("Workers %d: %v", workers, err)
+		}
+		if line != 1000 || batches.pairs != 1000-143 || batches.batches == 0 || batches.batches >= batches.pairs {
+			t.Errorf("Workers %d: %d records, %d pairs validated in %d batches", workers, line, batches.pairs, batches.batches)
+		}
+	}
+}
+
+// uncheckedMockGenerator counts the indexes generated with and without re-validation
+type uncheckedMockGenerator struct {
+	mockH3Generator
+	checked, unchecked int
+}
+
+func (m *uncheckedMockGenerator) Generate(lat, lng float64, resolution int) (string, error) {
+	m.checked++
+	return m.mockH3Generator.Generate(lat, lng, resolution)
+}
+
+func (m *uncheckedMockGenerator) GenerateUnsafe(lat, lng float64, resolution int) (string, error) {
+	m.unchecked++
+	return m.mockH3Generator.Generate(lat, lng, resolution)
+}
+
+func TestProcessStreamValidatesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "once.csv")
+	if err := os.WriteFile(path, []byte("lat,lng\n10,20\n95,1\n-33.9,151.2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, withValidator := range []bool{true, false} {
+		config := Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true, Resolution: 8}
+		reader, err := NewReader(path, config)
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		generator := &uncheckedMockGenerator{}
+		processor := NewStreamingProcessor(nil, generator)
+		if withValidator {
+			processor = NewStreamingProcessor(&mockValidator{}, generator)
+		}
+		err = processor.ProcessStream(reader, config, func(*Record) error { return nil })
+		reader.Close()
+		if err != nil {
+			t.Fatalf("ProcessStream failed: %v", err)
+		}
+
+		// Validated coordinates skip the generator's checks; without a validator the
+		// generator is the only one to validate them
+		switch {
+		case withValidator && (generator.unchecked != 2 || generator.checked != 0):
+			t.Errorf("Expected 2 unchecked generations, got %d unchecked and %d checked", generator.unchecked, generator.checked)
+		case !withValidator && (generator.checked != 3 || generator.unchecked != 0):
+			t.Errorf("Expected 3 checked generations, got %d checked and %d unchecked", generator.checked, generator.unchecked)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package csv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxShortRows is the default number of consecutive rows narrower than the
+// header row tolerated before the input is rejected as structurally wrong
+const DefaultMaxShortRows = 1000
+
+// delimiterCandidates are suggested when short rows contain them
+var delimiterCandidates = []rune{';', '\t', '|', ':'}
+
+// StructureError reports input whose rows keep having fewer columns than the header
+// row, which usually means it was read with the wrong delimiter
+type StructureError struct {
+	Rows      int  // Consecutive short rows seen
+	Expected  int  // Header row width
+	Got       int  // Width of the last short row
+	Delimiter rune // Likely delimiter found in the short rows (0 if none)
+}
+
+func (e *StructureError) Error() string {
+	message := fmt.Sprintf("%d consecutive rows have fewer columns than the header (%d instead of %d)", e.Rows, e.Got, e.Expected)
+	if e.Delimiter != 0 {
+		return fmt.Sprintf("%s; the input looks %q-delimited, so the delimiter is probably wrong", message, e.Delimiter)
+	}
+	return message + "; the delimiter or the header row is probably wrong"
+}
+
+// structureMonitor watches the width of the rows read, so that a file read with the
+// wrong delimiter fails fast instead of producing millions of rows without H3 indexes
+type structureMonitor struct {
+	expected int // Header row width, or the first data row's without headers (0 = not known yet)
+	limit    int // Consecutive short rows tolerated (0 = disabled)
+	short    int // Current run of short rows
+}
+
+// check records the width of a row and fails once more than limit consecutive rows
+// were narrower than expected
+func (m *structureMonitor) check(row []string) error {
+	if m.limit <= 0 {
+		return nil
+	}
+	if m.expected == 0 {
+		m.expected = len(row)
+		return nil
+	}
+	if len(row) >= m.expected {
+		m.short = 0
+		return nil
+	}
+	m.short++
+	if m.short <= m.limit {
+		return nil
+	}
+	return &StructureError{Rows: m.short, Expected: m.expected, Got: len(row), Delimiter: likelyDelimiter(row)}
+}
+
+// likelyDelimiter returns the candidate delimiter occurring most often in a row, or 0
+func likelyDelimiter(row []string) rune {
+	text := strings.Join(row, "")
+	best, bestCount := rune(0), 0
+	for _, candidate := range delimiterCandidates {
+		if count := strings.Count(text, string(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,36 @@
+package csv
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeUnicode returns s in Unicode normalization form C with invisible format
+// characters (zero-width spaces and joiners, byte order marks, soft hyphens, bidi
+// marks) removed and other space characters such as NBSP replaced by plain spaces.
+// Text copied from spreadsheets often carries these, and they defeat name matching.
+func NormalizeUnicode(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.Cf, r):
+			return -1
+		case r != ' ' && unicode.Is(unicode.Zs, r):
+			return ' '
+		}
+		return r
+	}, norm.NFC.String(s))
+}
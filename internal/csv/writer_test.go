@@ -372,4 +372,44 @@ func TestWriterFileCreationError(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when creating file in non-existent directory")
 	}
-}
\ No newline at end of file
+}
+
+func TestWriterExtraColumns(t *testing.T) {
+	tempDir := t.TempDir()
+	outputFile := filepath.Join(tempDir, "output.csv")
+
+	config := Config{
+		HasHeaders:   true,
+		Overwrite:    true,
+		ExtraColumns: []string{"first_extra", "second_extra"},
+	}
+
+	writer, err := NewWriter(outputFile, []string{"latitude", "longitude"}, config)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	record := &Record{
+		OriginalData: []string{"40.7128", "-74.0060"},
+		H3Index:      "882a100d2ffffff",
+		IsValid:      true,
+	}
+	record.SetExtra("second_extra", "b")
+
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatalf("WriteRecord failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	expected := "latitude,longitude,h3_index,first_extra,second_extra\n40.7128,-74.0060,882a100d2ffffff,,b\n"
+	if string(content) != expected {
+		t.Errorf("Expected output:\n%s\nGot:\n%s", expected, string(content))
+	}
+}
@@ -0,0 +1,112 @@
+package csv
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ZipMemberSeparator separates a zip archive from the member to read, as in
+// "data.zip::points.csv"
+const ZipMemberSeparator = "::"
+
+// SplitZipPath splits an input path naming a zip archive, optionally followed by
+// ZipMemberSeparator and a member name; ok is false for other paths
+func SplitZipPath(input string) (archive, member string, ok bool) {
+	if i := strings.Index(strings.ToLower(input), ".zip"+ZipMemberSeparator); i >= 0 {
+		return input[:i+len(".zip")], input[i+len(".zip"+ZipMemberSeparator):], true
+	}
+	if strings.EqualFold(filepath.Ext(input), ".zip") {
+		return input, "", true
+	}
+	return "", "", false
+}
+
+// InputSize returns the size in bytes of an input file, or the uncompressed size of
+// the selected member for zip archive inputs, matching Reader.InputOffset
+func InputSize(input string) (int64, error) {
+	archive, member, ok := SplitZipPath(input)
+	if !ok {
+		info, err := os.Stat(input)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	}
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open zip archive %s: %w", archive, err)
+	}
+	defer reader.Close()
+	file, err := selectZipMember(archive, reader.File, member)
+	if err != nil {
+		return 0, err
+	}
+	return int64(file.UncompressedSize64), nil
+}
+
+// openZipSource reads rows from a CSV member of a zip archive, decompressing it as it
+// is read without extracting it to disk
+func openZipSource(archive, member string) (*fileSource, error) {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive %s: %w", archive, err)
+	}
+	file, err := selectZipMember(archive, reader.File, member)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	contents, err := file.Open()
+	if err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("failed to open %s in zip archive %s: %w", file.Name, archive, err)
+	}
+	return newFileSource(contents, zipMember{contents, reader}), nil
+}
+
+// zipMember closes an archive member together with its archive
+type zipMember struct {
+	contents io.Closer
+	archive  io.Closer
+}
+
+func (m zipMember) Close() error {
+	return errors.Join(m.contents.Close(), m.archive.Close())
+}
+
+// selectZipMember finds the named member of an archive, or without a name its only
+// CSV file. Errors list the CSV members to choose from.
+func selectZipMember(archive string, files []*zip.File, member string) (*zip.File, error) {
+	var candidates []*zip.File
+	for _, file := range files {
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		if member != "" && file.Name == member {
+			return file, nil
+		}
+		if strings.EqualFold(path.Ext(file.Name), ".csv") {
+			candidates = append(candidates, file)
+		}
+	}
+	names := make([]string, len(candidates))
+	for i, file := range candidates {
+		names[i] = file.Name
+	}
+	switch {
+	case member != "":
+		return nil, fmt.Errorf("zip archive %s has no member %q; CSV members: %s", archive, member, strings.Join(names, ", "))
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("zip archive %s contains no CSV files", archive)
+	}
+	return nil, fmt.Errorf("zip archive %s contains %d CSV files; select one as %s%s<member>: %s",
+		archive, len(candidates), archive, ZipMemberSeparator, strings.Join(names, ", "))
+}
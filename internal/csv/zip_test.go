@@ -0,0 +1,101 @@
+package csv
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeZip creates a zip archive with the given members in order
+func writeZip(t *testing.T, path string, members ...string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	for i := 0; i+1 < len(members); i += 2 {
+		w, err := archive.Create(members[i])
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", members[i], err)
+		}
+		io.WriteString(w, members[i+1])
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+}
+
+func TestSplitZipPath(t *testing.T) {
+	tests := []struct {
+		input, archive, member string
+		ok                     bool
+	}{
+		{"data.zip", "data.zip", "", true},
+		{"dir/DATA.ZIP", "dir/DATA.ZIP", "", true},
+		{"data.zip::points.csv", "data.zip", "points.csv", true},
+		{"data.zip::sub/points.csv", "data.zip", "sub/points.csv", true},
+		{"data.csv", "", "", false},
+		{"data.zipped.csv", "", "", false},
+	}
+	for _, test := range tests {
+		archive, member, ok := SplitZipPath(test.input)
+		if archive != test.archive || member != test.member || ok != test.ok {
+			t.Errorf("SplitZipPath(%q) = %q, %q, %v; expected %q, %q, %v",
+				test.input, archive, member, ok, test.archive, test.member, test.ok)
+		}
+	}
+}
+
+func TestNewReaderZip(t *testing.T) {
+	dir := t.TempDir()
+	single := filepath.Join(dir, "single.zip")
+	writeZip(t, single, "readme.txt", "notes", "points.csv", "lat,lng\n40.7128,-74.0060\n")
+	multi := filepath.Join(dir, "multi.zip")
+	writeZip(t, multi, "a.csv", "lat,lng\n1,2\n", "sub/b.csv", "lat,lng\n3,4\n5,6\n")
+
+	readAll := func(input string) []*Record {
+		t.Helper()
+		reader, err := NewReader(input, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true})
+		if err != nil {
+			t.Fatalf("NewReader(%s) failed: %v", input, err)
+		}
+		defer reader.Close()
+		var records []*Record
+		for {
+			record, err := reader.ReadRecord()
+			if err == io.EOF {
+				return records
+			}
+			if err != nil {
+				t.Fatalf("ReadRecord failed: %v", err)
+			}
+			records = append(records, record)
+		}
+	}
+
+	// The only CSV member is selected without a name
+	records := readAll(single)
+	if len(records) != 1 || records[0].Latitude != 40.7128 || records[0].LineNumber != 2 {
+		t.Errorf("Expected one record on line 2, got %+v", records)
+	}
+	records = readAll(multi + "::sub/b.csv")
+	if len(records) != 2 || records[1].Latitude != 5 {
+		t.Errorf("Expected two records from sub/b.csv, got %+v", records)
+	}
+	if size, err := InputSize(multi + "::sub/b.csv"); err != nil || size != int64(len("lat,lng\n3,4\n5,6\n")) {
+		t.Errorf("Expected the uncompressed member size, got %d, %v", size, err)
+	}
+
+	// Ambiguous and missing members list the CSV members
+	for _, input := range []string{multi, multi + "::c.csv"} {
+		_, err := NewReader(input, Config{LatColumn: "lat", LngColumn: "lng", HasHeaders: true})
+		if err == nil || !strings.Contains(err.Error(), "a.csv, sub/b.csv") {
+			t.Errorf("Expected an error listing the members for %s, got %v", input, err)
+		}
+	}
+}
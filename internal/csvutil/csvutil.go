@@ -0,0 +1,44 @@
+// Package csvutil holds the CSV helpers shared by the subcommands that read
+// enriched outputs, such as diff, merge, and aggregate
+package csvutil
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// NewReader creates a CSV reader tolerating rows of differing lengths, splitting
+// fields on delimiter (',' when 0)
+func NewReader(r io.Reader, delimiter rune) *csv.Reader {
+	reader := csv.NewReader(r)
+	if delimiter != 0 {
+		reader.Comma = delimiter
+	}
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// FindColumn returns the index of a header, matching exactly first and then
+// case-insensitively, or -1 if absent
+func FindColumn(headers []string, name string) int {
+	for i, header := range headers {
+		if strings.TrimSpace(header) == name {
+			return i
+		}
+	}
+	for i, header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Field returns a row value, or "" for a short row
+func Field(record []string, index int) string {
+	if index < len(record) {
+		return record[index]
+	}
+	return ""
+}
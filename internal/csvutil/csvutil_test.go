@@ -0,0 +1,38 @@
+package csvutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindColumn(t *testing.T) {
+	headers := []string{"ID", " id ", "H3_Index"}
+	tests := []struct {
+		name     string
+		expected int
+	}{
+		{"id", 1},
+		{"ID", 0},
+		{"h3_index", 2},
+		{"missing", -1},
+	}
+	for _, tt := range tests {
+		if got := FindColumn(headers, tt.name); got != tt.expected {
+			t.Errorf("FindColumn(%q) = %d, want %d", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	reader := NewReader(strings.NewReader("a;b\nc\n"), ';')
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(records) != 2 || len(records[0]) != 2 || len(records[1]) != 1 {
+		t.Fatalf("Expected rows of differing lengths, got %v", records)
+	}
+	if Field(records[1], 0) != "c" || Field(records[1], 1) != "" {
+		t.Errorf("Expected an empty field past the end of a short row, got %v", records[1])
+	}
+}
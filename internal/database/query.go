@@ -0,0 +1,98 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+
+	_ "github.com/go-sql-driver/mysql" // Registers the "mysql" driver
+	_ "github.com/lib/pq"              // Registers the "postgres" driver
+)
+
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// QuerySource streams the rows of a SQL query as string fields. The first row read
+// holds the column names, like a CSV header row; NULL values are read as empty strings.
+type QuerySource struct {
+	db          *sql.DB
+	rows        *sql.Rows
+	columns     []string
+	values      []sql.NullString
+	headersRead bool
+}
+
+// Query connects to the database and runs the query
+func Query(driver, dsn, query string) (*QuerySource, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", driver, err)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run query: %w", err)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		db.Close()
+		return nil, fmt.Errorf("failed to read query columns: %w", err)
+	}
+
+	return &QuerySource{
+		db:      db,
+		rows:    rows,
+		columns: columns,
+		values:  make([]sql.NullString, len(columns)),
+	}, nil
+}
+
+// Columns returns the column names of the query result
+func (s *QuerySource) Columns() []string {
+	return s.columns
+}
+
+// Read returns the column names on the first call, then one result row per call,
+// and io.EOF after the last row
+func (s *QuerySource) Read() ([]string, error) {
+	if !s.headersRead {
+		s.headersRead = true
+		return append([]string(nil), s.columns...), nil
+	}
+
+	if !s.rows.Next() {
+		if err := s.rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read query results: %w", err)
+		}
+		return nil, io.EOF
+	}
+
+	dest := make([]any, len(s.values))
+	for i := range s.values {
+		dest[i] = &s.values[i]
+	}
+	if err := s.rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to scan query row: %w", err)
+	}
+
+	row := make([]string, len(s.values))
+	for i, value := range s.values {
+		row[i] = value.String
+	}
+	return row, nil
+}
+
+// Close closes the result set and the database connection
+func (s *QuerySource) Close() error {
+	rowsErr := s.rows.Close()
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return rowsErr
+}
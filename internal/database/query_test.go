@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// fakeDriver serves a fixed result set for any query; the DSN "fail" makes queries fail
+type fakeDriver struct{}
+
+type fakeConn struct{ dsn string }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+type fakeTx struct{ conn *fakeConn }
+
+// fakeExec records a statement executed through the fake driver
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeRows struct{ next int }
+
+var (
+	fakeColumns = []string{"id", "lat", "lng"}
+	fakeData    = [][]driver.Value{
+		{int64(1), 40.7128, -74.006},
+		{int64(2), nil, "51.5"},
+	}
+	// fakeExecs holds the statements executed and committed, in order
+	fakeExecs []fakeExec
+)
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) { return &fakeConn{dsn: dsn}, nil }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if c.dsn == "fail" {
+		return nil, fmt.Errorf("relation does not exist")
+	}
+	return &fakeStmt{conn: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{conn: c}, nil }
+
+func (tx *fakeTx) Commit() error   { return nil }
+func (tx *fakeTx) Rollback() error { return nil }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fakeExecs = append(fakeExecs, fakeExec{query: s.query, args: args})
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+func (r *fakeRows) Columns() []string { return fakeColumns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(fakeData) {
+		return io.EOF
+	}
+	copy(dest, fakeData[r.next])
+	r.next++
+	return nil
+}
+
+func TestQuerySource(t *testing.T) {
+	source, err := Query("fakedb", "ok", "SELECT id, lat, lng FROM points")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	defer source.Close()
+
+	want := [][]string{
+		{"id", "lat", "lng"},
+		{"1", "40.7128", "-74.006"},
+		{"2", "", "51.5"},
+	}
+	for i, wantRow := range want {
+		row, err := source.Read()
+		if err != nil {
+			t.Fatalf("Read() row %d error = %v", i, err)
+		}
+		if !reflect.DeepEqual(row, wantRow) {
+			t.Errorf("Read() row %d = %v, want %v", i, row, wantRow)
+		}
+	}
+	if _, err := source.Read(); err != io.EOF {
+		t.Errorf("Read() after last row error = %v, want io.EOF", err)
+	}
+}
+
+func TestQuery_Errors(t *testing.T) {
+	if _, err := Query("fakedb", "fail", "SELECT * FROM missing"); err == nil {
+		t.Error("Query() with failing query should error")
+	}
+	if _, err := Query("nodriver", "", "SELECT 1"); err == nil {
+		t.Error("Query() with unknown driver should error")
+	}
+}
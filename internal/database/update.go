@@ -0,0 +1,120 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// DefaultUpdateBatchSize is the number of updates applied per transaction by default
+const DefaultUpdateBatchSize = 1000
+
+// Updater writes values back to a table column by key. Updates are queued and
+// applied in batches, one transaction per batch.
+type Updater struct {
+	db        *sql.DB
+	statement string
+	batchSize int
+	pending   [][2]string // Queued key/value pairs
+	updated   int64
+}
+
+// NewUpdater connects to the database and prepares to set valueColumn of the
+// rows of table whose keyColumn matches each key
+func NewUpdater(driver, dsn, table, keyColumn, valueColumn string, batchSize int) (*Updater, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultUpdateBatchSize
+	}
+	placeholders := [2]string{"?", "?"}
+	if driver == DriverPostgres {
+		placeholders = [2]string{"$1", "$2"}
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", driver, err)
+	}
+
+	return &Updater{
+		db: db,
+		statement: fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s",
+			QuoteIdentifier(driver, table), QuoteIdentifier(driver, valueColumn), placeholders[0],
+			QuoteIdentifier(driver, keyColumn), placeholders[1]),
+		batchSize: batchSize,
+	}, nil
+}
+
+// Update queues setting the value of the row with the given key, applying the
+// queued batch once it is full
+func (u *Updater) Update(key, value string) error {
+	u.pending = append(u.pending, [2]string{key, value})
+	if len(u.pending) >= u.batchSize {
+		return u.Flush()
+	}
+	return nil
+}
+
+// Flush applies the queued updates in a single transaction
+func (u *Updater) Flush() error {
+	if len(u.pending) == 0 {
+		return nil
+	}
+
+	tx, err := u.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin update transaction: %w", err)
+	}
+	stmt, err := tx.Prepare(u.statement)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to prepare update: %w", err)
+	}
+	defer stmt.Close()
+
+	var updated int64
+	for _, update := range u.pending {
+		result, err := stmt.Exec(update[1], update[0])
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to update key %q: %w", update[0], err)
+		}
+		if rows, err := result.RowsAffected(); err == nil {
+			updated += rows
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit updates: %w", err)
+	}
+
+	u.updated += updated
+	u.pending = u.pending[:0]
+	return nil
+}
+
+// Updated returns the number of rows updated so far
+func (u *Updater) Updated() int64 {
+	return u.updated
+}
+
+// Close applies any queued updates and closes the database connection
+func (u *Updater) Close() error {
+	flushErr := u.Flush()
+	if err := u.db.Close(); err != nil && flushErr == nil {
+		return err
+	}
+	return flushErr
+}
+
+// QuoteIdentifier quotes a possibly schema-qualified identifier, e.g. public.points,
+// for the given driver
+func QuoteIdentifier(driver, name string) string {
+	quote := `"`
+	if driver == DriverMySQL {
+		quote = "`"
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
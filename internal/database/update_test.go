@@ -0,0 +1,54 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestUpdater(t *testing.T) {
+	fakeExecs = nil
+	updater, err := NewUpdater("fakedb", "ok", "public.points", "id", "h3", 2)
+	if err != nil {
+		t.Fatalf("NewUpdater() error = %v", err)
+	}
+
+	for _, key := range []string{"1", "2", "3"} {
+		if err := updater.Update(key, "882a1072b5fffff"); err != nil {
+			t.Fatalf("Update(%s) error = %v", key, err)
+		}
+	}
+	if len(fakeExecs) != 2 {
+		t.Errorf("Expected a full batch of 2 updates applied before Close, got %d", len(fakeExecs))
+	}
+	if err := updater.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(fakeExecs) != 3 || updater.Updated() != 3 {
+		t.Fatalf("Expected 3 updates, got %d executed and %d updated", len(fakeExecs), updater.Updated())
+	}
+	wantQuery := `UPDATE "public"."points" SET "h3" = ? WHERE "id" = ?`
+	if fakeExecs[0].query != wantQuery {
+		t.Errorf("Update statement = %q, want %q", fakeExecs[0].query, wantQuery)
+	}
+	if fakeExecs[2].args[0] != "882a1072b5fffff" || fakeExecs[2].args[1] != "3" {
+		t.Errorf("Update args = %v, want [882a1072b5fffff 3]", fakeExecs[2].args)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		driver string
+		name   string
+		want   string
+	}{
+		{DriverPostgres, "points", `"points"`},
+		{DriverPostgres, "public.points", `"public"."points"`},
+		{DriverPostgres, `odd"name`, `"odd""name"`},
+		{DriverMySQL, "gis.points", "`gis`.`points`"},
+	}
+	for _, tt := range tests {
+		if got := QuoteIdentifier(tt.driver, tt.name); got != tt.want {
+			t.Errorf("QuoteIdentifier(%s, %q) = %s, want %s", tt.driver, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package dedupe
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// BloomFilter is a fixed-size probabilistic set membership structure.
+// It never reports false negatives; false positives occur at roughly the
+// configured rate once the expected number of items has been added.
+type BloomFilter struct {
+	bits      []uint64
+	numBits   uint64
+	numHashes uint64
+}
+
+// NewBloomFilter creates a bloom filter sized for the expected number of items
+// and target false-positive rate
+func NewBloomFilter(expectedItems int, falsePositiveRate float64) (*BloomFilter, error) {
+	if expectedItems <= 0 {
+		return nil, fmt.Errorf("expected items must be positive, got %d", expectedItems)
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("false-positive rate must be between 0 and 1, got %g", falsePositiveRate)
+	}
+
+	// Optimal sizing: m = -n*ln(p)/ln(2)^2, k = m/n*ln(2)
+	n := float64(expectedItems)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/n*math.Ln2))
+
+	numBits := uint64(m)
+	return &BloomFilter{
+		bits:      make([]uint64, (numBits+63)/64),
+		numBits:   numBits,
+		numHashes: uint64(k),
+	}, nil
+}
+
+// hashes returns the two base hashes used for double hashing
+func (b *BloomFilter) hashes(data []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(data)
+	h1 := mix64(h.Sum64())
+	h2 := mix64(h1) | 1 // Ensure the step is odd so probes cover the table
+	return h1, h2
+}
+
+// mix64 is the splitmix64 finalizer, used to decorrelate the two base hashes
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// TestAndAdd adds the item and reports whether it was (probably) already present
+func (b *BloomFilter) TestAndAdd(data []byte) bool {
+	h1, h2 := b.hashes(data)
+	present := true
+	for i := uint64(0); i < b.numHashes; i++ {
+		bit := (h1 + i*h2) % b.numBits
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			present = false
+			b.bits[word] |= mask
+		}
+	}
+	return present
+}
+
+// Test reports whether the item is (probably) present without adding it
+func (b *BloomFilter) Test(data []byte) bool {
+	h1, h2 := b.hashes(data)
+	for i := uint64(0); i < b.numHashes; i++ {
+		bit := (h1 + i*h2) % b.numBits
+		if b.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SizeBytes returns the memory used by the filter's bit array
+func (b *BloomFilter) SizeBytes() int {
+	return len(b.bits) * 8
+}
+
+// NumHashes returns the number of hash functions used
+func (b *BloomFilter) NumHashes() int {
+	return int(b.numHashes)
+}
@@ -0,0 +1,103 @@
+package dedupe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keySeparator separates fields when building a dedupe key; NUL is not expected in CSV text fields
+const keySeparator = "\x00"
+
+// Deduplicator detects duplicate rows using a bloom filter over a row key
+type Deduplicator struct {
+	filter  *BloomFilter
+	columns []int // Key column indices; nil means the full row is used
+	dropped int
+}
+
+// NewExactDeduplicator creates a deduplicator keyed on the full row
+func NewExactDeduplicator(expectedItems int, falsePositiveRate float64) (*Deduplicator, error) {
+	filter, err := NewBloomFilter(expectedItems, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Deduplicator{filter: filter}, nil
+}
+
+// NewKeyDeduplicator creates a deduplicator keyed on the given columns.
+// Columns are matched against headers by name (case-insensitive) or given as 0-based indices.
+func NewKeyDeduplicator(keys []string, headers []string, expectedItems int, falsePositiveRate float64) (*Deduplicator, error) {
+	columns, err := ResolveColumns(keys, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := NewBloomFilter(expectedItems, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Deduplicator{filter: filter, columns: columns}, nil
+}
+
+// ResolveColumns maps column names or indices to column indices
+func ResolveColumns(keys []string, headers []string) ([]int, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one key column is required")
+	}
+
+	columns := make([]int, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		index := -1
+		for i, header := range headers {
+			if strings.EqualFold(strings.TrimSpace(header), key) {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			if parsed, err := strconv.Atoi(key); err == nil && parsed >= 0 {
+				index = parsed
+			}
+		}
+		if index == -1 {
+			return nil, fmt.Errorf("key column not found: %s", key)
+		}
+		columns = append(columns, index)
+	}
+	return columns, nil
+}
+
+// IsDuplicate reports whether the row has (probably) been seen before and records it.
+// Duplicates are counted in Dropped.
+func (d *Deduplicator) IsDuplicate(row []string) bool {
+	var key string
+	if d.columns == nil {
+		key = strings.Join(row, keySeparator)
+	} else {
+		values := make([]string, len(d.columns))
+		for i, column := range d.columns {
+			if column < len(row) {
+				values[i] = row[column]
+			}
+		}
+		key = strings.Join(values, keySeparator)
+	}
+
+	if d.filter.TestAndAdd([]byte(key)) {
+		d.dropped++
+		return true
+	}
+	return false
+}
+
+// Dropped returns the number of duplicate rows detected so far
+func (d *Deduplicator) Dropped() int {
+	return d.dropped
+}
+
+// FilterSizeBytes returns the memory used by the underlying bloom filter
+func (d *Deduplicator) FilterSizeBytes() int {
+	return d.filter.SizeBytes()
+}
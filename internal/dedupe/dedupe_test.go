@@ -0,0 +1,124 @@
+package dedupe
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewBloomFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       int
+		rate        float64
+		expectError bool
+	}{
+		{"valid", 1000, 0.01, false},
+		{"zero items", 0, 0.01, true},
+		{"zero rate", 1000, 0, true},
+		{"rate of one", 1000, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBloomFilter(tt.items, tt.rate)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestBloomFilter_FalsePositiveRate(t *testing.T) {
+	const items = 10000
+	filter, err := NewBloomFilter(items, 0.01)
+	if err != nil {
+		t.Fatalf("NewBloomFilter failed: %v", err)
+	}
+
+	for i := 0; i < items; i++ {
+		filter.TestAndAdd([]byte(fmt.Sprintf("item-%d", i)))
+	}
+
+	// All added items must be reported as present
+	for i := 0; i < items; i++ {
+		if !filter.Test([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Fatalf("False negative for item-%d", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < items; i++ {
+		if filter.Test([]byte(fmt.Sprintf("other-%d", i))) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / items; rate > 0.03 {
+		t.Errorf("False-positive rate %.4f is far above target 0.01", rate)
+	}
+}
+
+func TestDeduplicator_Exact(t *testing.T) {
+	d, err := NewExactDeduplicator(100, 0.001)
+	if err != nil {
+		t.Fatalf("NewExactDeduplicator failed: %v", err)
+	}
+
+	rows := [][]string{
+		{"1", "40.7128", "-74.0060"},
+		{"2", "40.7128", "-74.0060"},
+		{"1", "40.7128", "-74.0060"},
+		{"1,40.7128", "-74.0060"}, // Same text, different fields
+	}
+	expected := []bool{false, false, true, false}
+
+	for i, row := range rows {
+		if got := d.IsDuplicate(row); got != expected[i] {
+			t.Errorf("Row %d: expected duplicate=%v, got %v", i, expected[i], got)
+		}
+	}
+	if d.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped row, got %d", d.Dropped())
+	}
+}
+
+func TestDeduplicator_Keys(t *testing.T) {
+	headers := []string{"id", "timestamp", "latitude", "longitude"}
+	d, err := NewKeyDeduplicator([]string{"ID", " timestamp"}, headers, 100, 0.001)
+	if err != nil {
+		t.Fatalf("NewKeyDeduplicator failed: %v", err)
+	}
+
+	rows := [][]string{
+		{"1", "t1", "40.0", "-74.0"},
+		{"1", "t2", "40.0", "-74.0"},
+		{"1", "t1", "41.0", "-75.0"}, // Same keys, different coordinates
+	}
+	expected := []bool{false, false, true}
+
+	for i, row := range rows {
+		if got := d.IsDuplicate(row); got != expected[i] {
+			t.Errorf("Row %d: expected duplicate=%v, got %v", i, expected[i], got)
+		}
+	}
+
+	if _, err := NewKeyDeduplicator([]string{"missing"}, headers, 100, 0.001); err == nil {
+		t.Error("Expected error for missing key column")
+	}
+}
+
+func TestResolveColumns(t *testing.T) {
+	columns, err := ResolveColumns([]string{"0", "2"}, nil)
+	if err != nil {
+		t.Fatalf("ResolveColumns failed: %v", err)
+	}
+	if len(columns) != 2 || columns[0] != 0 || columns[1] != 2 {
+		t.Errorf("Expected [0 2], got %v", columns)
+	}
+
+	if _, err := ResolveColumns(nil, nil); err == nil {
+		t.Error("Expected error for empty key list")
+	}
+}
@@ -0,0 +1,191 @@
+package diff
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"csv-h3-tool/internal/csvutil"
+)
+
+// Change types written to the change column
+const (
+	Changed = "changed" // Key in both files with a different H3 index
+	Added   = "added"   // Key only in the new file
+	Removed = "removed" // Key only in the old file
+)
+
+// Options configures an output comparison
+type Options struct {
+	KeyColumns []string // Columns identifying a row in both files
+	H3Column   string   // H3 index column compared (default "h3_index")
+	Delimiter  rune     // Field delimiter of both files (default ',')
+}
+
+// Stats summarizes an output comparison
+type Stats struct {
+	Compared int // Keys present in both files
+	Changed  int
+	Added    int
+	Removed  int
+}
+
+// oldRow is the H3 index of a key in the old file
+type oldRow struct {
+	key     []string
+	h3Index string
+	seen    bool
+}
+
+// Files compares two enriched CSV outputs by key and writes a changes-only CSV with the
+// key columns, the change type, and the old and new H3 index of every changed, added, or
+// removed row. The old file is held in memory by key; the new file is streamed.
+func Files(oldPath, newPath string, w io.Writer, opts Options) (*Stats, error) {
+	if len(opts.KeyColumns) == 0 {
+		return nil, fmt.Errorf("at least one key column is required")
+	}
+	if opts.H3Column == "" {
+		opts.H3Column = "h3_index"
+	}
+
+	oldRows, order, err := readOld(oldPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	newFile, err := os.Open(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open new file: %w", err)
+	}
+	defer newFile.Close()
+	reader := csvutil.NewReader(newFile, opts.Delimiter)
+	keyIndexes, h3Index, err := readHeader(reader, newPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(w)
+	header := append(append([]string{}, opts.KeyColumns...), "change", "old_"+opts.H3Column, "new_"+opts.H3Column)
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write changes: %w", err)
+	}
+
+	stats := &Stats{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s row %d: %w", newPath, row, err)
+		}
+
+		key := rowKey(record, keyIndexes)
+		id := strings.Join(key, "\x00")
+		value := csvutil.Field(record, h3Index)
+
+		var change []string
+		if old, ok := oldRows[id]; !ok {
+			stats.Added++
+			change = append(key, Added, "", value)
+		} else {
+			if old.seen {
+				return nil, fmt.Errorf("%s row %d: duplicate key %s", newPath, row, strings.Join(key, ","))
+			}
+			old.seen = true
+			stats.Compared++
+			if old.h3Index != value {
+				stats.Changed++
+				change = append(key, Changed, old.h3Index, value)
+			}
+		}
+		if change != nil {
+			if err := writer.Write(change); err != nil {
+				return nil, fmt.Errorf("failed to write changes: %w", err)
+			}
+		}
+	}
+
+	// Keys missing from the new file, in old file order
+	for _, id := range order {
+		if old := oldRows[id]; !old.seen {
+			stats.Removed++
+			if err := writer.Write(append(append([]string{}, old.key...), Removed, old.h3Index, "")); err != nil {
+				return nil, fmt.Errorf("failed to write changes: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write changes: %w", err)
+	}
+	return stats, nil
+}
+
+// readOld loads the key -> H3 index map of the old file, with keys in file order
+func readOld(path string, opts Options) (map[string]*oldRow, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open old file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csvutil.NewReader(file, opts.Delimiter)
+	keyIndexes, h3Index, err := readHeader(reader, path, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make(map[string]*oldRow)
+	var order []string
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s row %d: %w", path, row, err)
+		}
+
+		key := rowKey(record, keyIndexes)
+		id := strings.Join(key, "\x00")
+		if _, exists := rows[id]; exists {
+			return nil, nil, fmt.Errorf("%s row %d: duplicate key %s", path, row, strings.Join(key, ","))
+		}
+		rows[id] = &oldRow{key: key, h3Index: csvutil.Field(record, h3Index)}
+		order = append(order, id)
+	}
+	return rows, order, nil
+}
+
+// readHeader reads the header row and locates the key and H3 columns
+func readHeader(reader *csv.Reader, path string, opts Options) ([]int, int, error) {
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+
+	keyIndexes := make([]int, len(opts.KeyColumns))
+	for i, column := range opts.KeyColumns {
+		if keyIndexes[i] = csvutil.FindColumn(headers, column); keyIndexes[i] < 0 {
+			return nil, 0, fmt.Errorf("key column %q not found in %s", column, path)
+		}
+	}
+	h3Index := csvutil.FindColumn(headers, opts.H3Column)
+	if h3Index < 0 {
+		return nil, 0, fmt.Errorf("H3 column %q not found in %s", opts.H3Column, path)
+	}
+	return keyIndexes, h3Index, nil
+}
+
+// rowKey returns the key column values of a row
+func rowKey(record []string, indexes []int) []string {
+	key := make([]string, len(indexes))
+	for i, index := range indexes {
+		key[i] = csvutil.Field(record, index)
+	}
+	return key
+}
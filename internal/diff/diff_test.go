@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestFiles(t *testing.T) {
+	oldFile := writeFile(t, "old.csv", `id,latitude,longitude,h3_index
+1,40.7128,-74.0060,882a107289fffff
+2,51.5074,-0.1278,88195da49bfffff
+3,48.8566,2.3522,881fb46625fffff
+`)
+	newFile := writeFile(t, "new.csv", `id,latitude,longitude,h3_index
+3,48.8566,2.3522,881fb46625fffff
+1,40.7130,-74.0060,882a107281fffff
+4,35.6762,139.6503,882f5a3e3dfffff
+`)
+
+	var output bytes.Buffer
+	stats, err := Files(oldFile, newFile, &output, Options{KeyColumns: []string{"id"}})
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+
+	expected := `id,change,old_h3_index,new_h3_index
+1,changed,882a107289fffff,882a107281fffff
+4,added,,882f5a3e3dfffff
+2,removed,88195da49bfffff,
+`
+	if output.String() != expected {
+		t.Errorf("Expected changes:\n%s\ngot:\n%s", expected, output.String())
+	}
+	if *stats != (Stats{Compared: 2, Changed: 1, Added: 1, Removed: 1}) {
+		t.Errorf("Unexpected stats: %+v", *stats)
+	}
+}
+
+func TestFiles_CompositeKeyAndDelimiter(t *testing.T) {
+	oldFile := writeFile(t, "old.csv", "vehicle;ts;H3\nA;1;a\nA;2;b\n")
+	newFile := writeFile(t, "new.csv", "vehicle;ts;H3\nA;1;a\nA;2;c\n")
+
+	var output bytes.Buffer
+	_, err := Files(oldFile, newFile, &output, Options{
+		KeyColumns: []string{"vehicle", "ts"},
+		H3Column:   "h3",
+		Delimiter:  ';',
+	})
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	if !strings.Contains(output.String(), "A,2,changed,b,c") {
+		t.Errorf("Expected composite key change, got:\n%s", output.String())
+	}
+}
+
+func TestFiles_Errors(t *testing.T) {
+	good := writeFile(t, "good.csv", "id,h3_index\n1,a\n")
+	duplicate := writeFile(t, "dup.csv", "id,h3_index\n1,a\n1,b\n")
+	noH3 := writeFile(t, "noh3.csv", "id,lat\n1,2\n")
+
+	tests := []struct {
+		name     string
+		old, new string
+		opts     Options
+	}{
+		{"no key", good, good, Options{}},
+		{"missing key column", good, good, Options{KeyColumns: []string{"row"}}},
+		{"missing H3 column", good, noH3, Options{KeyColumns: []string{"id"}}},
+		{"duplicate old key", duplicate, good, Options{KeyColumns: []string{"id"}}},
+		{"duplicate new key", good, duplicate, Options{KeyColumns: []string{"id"}}},
+		{"missing file", good, filepath.Join(t.TempDir(), "none.csv"), Options{KeyColumns: []string{"id"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Files(tt.old, tt.new, &bytes.Buffer{}, tt.opts); err == nil {
+				t.Error("Files() should error")
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build duckdb
+
+package duckdb
+
+import (
+	_ "github.com/marcboeker/go-duckdb" // Registers the "duckdb" driver
+)
+
+func init() {
+	available = true
+}
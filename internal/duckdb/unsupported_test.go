@@ -0,0 +1,17 @@
+//go:build !duckdb
+
+package duckdb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateUnsupported(t *testing.T) {
+	if Available() {
+		t.Fatal("Available() should be false without the duckdb build tag")
+	}
+	if _, err := Create(filepath.Join(t.TempDir(), "points.duckdb"), "points", []string{"id"}, false); err == nil {
+		t.Error("Create() should error without DuckDB support")
+	}
+}
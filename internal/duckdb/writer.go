@@ -0,0 +1,139 @@
+package duckdb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// driverName is the database/sql driver registered by go-duckdb
+const driverName = "duckdb"
+
+// available is set when the binary is built with DuckDB support (-tags duckdb)
+var available bool
+
+// DefaultBatchSize is the number of rows inserted per transaction
+const DefaultBatchSize = 10000
+
+// Available reports whether this binary was built with DuckDB support
+func Available() bool {
+	return available
+}
+
+// Writer inserts rows into a DuckDB table. Every column is VARCHAR, so values are
+// stored exactly as they would appear in CSV output; missing trailing values are NULL.
+type Writer struct {
+	db        *sql.DB
+	tx        *sql.Tx
+	stmt      *sql.Stmt
+	insert    string
+	columns   int
+	batchSize int
+	batched   int // Rows inserted in the open transaction
+	rows      int64
+}
+
+// Create opens (or creates) the DuckDB database file and creates the table with the
+// given columns. An existing table is an error unless overwrite is set.
+func Create(path, table string, columns []string, overwrite bool) (*Writer, error) {
+	if !available {
+		return nil, fmt.Errorf("DuckDB output is not supported by this build (rebuild with -tags duckdb)")
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("DuckDB output requires column names")
+	}
+
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DuckDB database %s: %w", path, err)
+	}
+
+	definitions := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		definitions[i] = quoteIdentifier(column) + " VARCHAR"
+		placeholders[i] = "?"
+	}
+	create := "CREATE TABLE "
+	if overwrite {
+		create = "CREATE OR REPLACE TABLE "
+	}
+	if _, err := db.Exec(create + quoteIdentifier(table) + " (" + strings.Join(definitions, ", ") + ")"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create table %s: %w", table, err)
+	}
+
+	return &Writer{
+		db:        db,
+		insert:    "INSERT INTO " + quoteIdentifier(table) + " VALUES (" + strings.Join(placeholders, ", ") + ")",
+		columns:   len(columns),
+		batchSize: DefaultBatchSize,
+	}, nil
+}
+
+// WriteRow inserts one row, committing the open transaction once the batch is full
+func (w *Writer) WriteRow(row []string) error {
+	if len(row) > w.columns {
+		return fmt.Errorf("row has %d values but the table has %d columns", len(row), w.columns)
+	}
+	if w.tx == nil {
+		tx, err := w.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		stmt, err := tx.Prepare(w.insert)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to prepare insert: %w", err)
+		}
+		w.tx, w.stmt = tx, stmt
+	}
+
+	values := make([]any, w.columns)
+	for i, value := range row {
+		values[i] = value
+	}
+	if _, err := w.stmt.Exec(values...); err != nil {
+		return fmt.Errorf("failed to insert row: %w", err)
+	}
+	w.rows++
+	w.batched++
+
+	if w.batched >= w.batchSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush commits the rows inserted so far
+func (w *Writer) Flush() error {
+	if w.tx == nil {
+		return nil
+	}
+	w.stmt.Close()
+	err := w.tx.Commit()
+	w.tx, w.stmt, w.batched = nil, nil, 0
+	if err != nil {
+		return fmt.Errorf("failed to commit rows: %w", err)
+	}
+	return nil
+}
+
+// Rows returns the number of rows inserted
+func (w *Writer) Rows() int64 {
+	return w.rows
+}
+
+// Close commits any pending rows and closes the database
+func (w *Writer) Close() error {
+	flushErr := w.Flush()
+	if err := w.db.Close(); err != nil && flushErr == nil {
+		return err
+	}
+	return flushErr
+}
+
+// quoteIdentifier quotes a table or column name
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,62 @@
+//go:build duckdb
+
+package duckdb
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "points.duckdb")
+	writer, err := Create(path, "points", []string{"id", "latitude", "h3_index"}, false)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	writer.batchSize = 2
+
+	rows := [][]string{
+		{"1", "40.7128", "882a100d25fffff"},
+		{"2", "invalid", ""},
+		{"3"},
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow(%v) error = %v", row, err)
+		}
+	}
+	if err := writer.WriteRow([]string{"4", "0", "x", "extra"}); err == nil {
+		t.Error("WriteRow() with too many values should error")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if writer.Rows() != 3 {
+		t.Errorf("Rows() = %d, want 3", writer.Rows())
+	}
+
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	var count, nulls int
+	if err := db.QueryRow(`SELECT count(*), count(*) FILTER (WHERE h3_index IS NULL) FROM points`).Scan(&count, &nulls); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if count != 3 || nulls != 1 {
+		t.Errorf("Expected 3 rows with 1 NULL h3_index, got %d rows and %d NULLs", count, nulls)
+	}
+	db.Close()
+
+	// Existing tables are only replaced with overwrite
+	if _, err := Create(path, "points", []string{"id"}, false); err == nil {
+		t.Error("Create() over an existing table should error without overwrite")
+	}
+	replaced, err := Create(path, "points", []string{"id"}, true)
+	if err != nil {
+		t.Fatalf("Create() with overwrite error = %v", err)
+	}
+	replaced.Close()
+}
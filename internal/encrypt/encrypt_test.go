@@ -0,0 +1,67 @@
+package encrypt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		encoded string
+		size    int
+	}{
+		{"000102030405060708090a0b0c0d0e0f", 16},
+		{"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=", 32},
+		{" 000102030405060708090a0b0c0d0e0f1011121314151617\n", 24},
+	}
+	for _, tt := range tests {
+		key, err := ParseKey(tt.encoded)
+		if err != nil || len(key) != tt.size {
+			t.Errorf("ParseKey(%q) = %d bytes, %v; expected %d bytes", tt.encoded, len(key), err, tt.size)
+		}
+	}
+	for _, encoded := range []string{"", "0001", "not a key!"} {
+		if _, err := ParseKey(encoded); err == nil {
+			t.Errorf("Expected ParseKey(%q) to fail", encoded)
+		}
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	key, _ := ParseKey("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	cipher, err := NewCipher(key)
+	if err != nil {
+		t.Fatalf("NewCipher failed: %v", err)
+	}
+	for _, value := range []string{"123-45-6789", "a@example.com", "", "ünïcødé, \"quoted\""} {
+		encrypted, err := cipher.Encrypt(value)
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		if !strings.HasPrefix(encrypted, Prefix) || strings.Contains(encrypted, value) && value != "" {
+			t.Errorf("Unexpected encrypted value %q", encrypted)
+		}
+		again, _ := cipher.Encrypt(value)
+		if again == encrypted {
+			t.Errorf("Expected a fresh nonce per value")
+		}
+		decrypted, err := cipher.Decrypt(encrypted)
+		if err != nil || decrypted != value {
+			t.Errorf("Decrypt = %q, %v; expected %q", decrypted, err, value)
+		}
+	}
+
+	encrypted, _ := cipher.Encrypt("secret")
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	if _, err := cipher.Decrypt(tampered); err == nil {
+		t.Error("Expected tampered value to fail authentication")
+	}
+	otherKey, _ := ParseKey("ffffffffffffffffffffffffffffffff")
+	other, _ := NewCipher(otherKey)
+	if _, err := other.Decrypt(encrypted); err == nil {
+		t.Error("Expected decryption with another key to fail")
+	}
+	if _, err := cipher.Decrypt("plain"); err == nil {
+		t.Error("Expected unprefixed value to fail")
+	}
+}
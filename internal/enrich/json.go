@@ -0,0 +1,190 @@
+package enrich
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"csv-h3-tool/internal/h3"
+)
+
+// Default field names tried when no latitude/longitude field is given
+var (
+	latitudeFields  = []string{"latitude", "lat"}
+	longitudeFields = []string{"longitude", "lng", "lon"}
+)
+
+// Options controls JSON enrichment
+type Options struct {
+	LatField   string // Latitude field (empty = "latitude" or "lat")
+	LngField   string // Longitude field (empty = "longitude", "lng", or "lon")
+	H3Field    string // Field receiving the H3 index (empty = h3_index)
+	ErrorField string // Field receiving the reason a point could not be indexed (empty = h3_error)
+	Resolution int
+}
+
+// Stats summarizes an enrichment run
+type Stats struct {
+	Points  int
+	Valid   int
+	Invalid int
+}
+
+// JSON reads a JSON array of point objects from r and writes the array to w with
+// each object's H3 index added. Points are streamed one at a time and keep their
+// original field order; points that cannot be indexed get a null index and an error field.
+func JSON(r io.Reader, w io.Writer, generator h3.Generator, opts Options) (Stats, error) {
+	if opts.H3Field == "" {
+		opts.H3Field = "h3_index"
+	}
+	if opts.ErrorField == "" {
+		opts.ErrorField = "h3_error"
+	}
+	if err := generator.ValidateResolution(h3.H3Resolution(opts.Resolution)); err != nil {
+		return Stats{}, err
+	}
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return Stats{}, fmt.Errorf("input must be a JSON array of points")
+	}
+
+	out := bufio.NewWriter(w)
+	out.WriteByte('[')
+	stats := Stats{}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return stats, fmt.Errorf("invalid JSON at point %d: %w", stats.Points, err)
+		}
+
+		enriched, valid, err := enrichPoint(raw, generator, opts)
+		if err != nil {
+			return stats, fmt.Errorf("point %d: %w", stats.Points, err)
+		}
+		if stats.Points > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(enriched)
+
+		stats.Points++
+		if valid {
+			stats.Valid++
+		} else {
+			stats.Invalid++
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return stats, fmt.Errorf("invalid JSON after point %d: %w", stats.Points, err)
+	}
+
+	out.WriteString("]\n")
+	return stats, out.Flush()
+}
+
+// enrichPoint returns the point object with the H3 index (or error) fields appended
+func enrichPoint(raw json.RawMessage, generator h3.Generator, opts Options) ([]byte, bool, error) {
+	var fields map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil || fields == nil {
+		return nil, false, fmt.Errorf("expected a JSON object")
+	}
+
+	index, reason := pointIndex(fields, generator, opts)
+	added := map[string]any{opts.H3Field: nil}
+	if reason != "" {
+		added[opts.ErrorField] = reason
+	} else {
+		added[opts.H3Field] = index
+	}
+
+	// Fields already present are replaced, at the cost of the original field order
+	replace := false
+	for name := range added {
+		_, exists := fields[name]
+		replace = replace || exists
+	}
+	if replace {
+		for name, value := range added {
+			fields[name] = value
+		}
+		enriched, err := json.Marshal(fields)
+		return enriched, reason == "", err
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return nil, false, err
+	}
+	enriched := bytes.TrimSuffix(compact.Bytes(), []byte("}"))
+	for _, name := range []string{opts.H3Field, opts.ErrorField} {
+		value, ok := added[name]
+		if !ok {
+			continue
+		}
+		encoded, err := json.Marshal(map[string]any{name: value})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(enriched) > 1 {
+			enriched = append(enriched, ',')
+		}
+		enriched = append(enriched, encoded[1:len(encoded)-1]...)
+	}
+	return append(enriched, '}'), reason == "", nil
+}
+
+// pointIndex computes the H3 index of a point, or the reason it cannot be indexed
+func pointIndex(fields map[string]any, generator h3.Generator, opts Options) (string, string) {
+	lat, err := coordinate(fields, "latitude", opts.LatField, latitudeFields)
+	if err != nil {
+		return "", err.Error()
+	}
+	lng, err := coordinate(fields, "longitude", opts.LngField, longitudeFields)
+	if err != nil {
+		return "", err.Error()
+	}
+
+	index, err := generator.Generate(lat, lng, h3.H3Resolution(opts.Resolution))
+	if err != nil {
+		return "", err.Error()
+	}
+	return index, ""
+}
+
+// coordinate reads a numeric (or numeric string) coordinate from the named field,
+// or from the first default field present
+func coordinate(fields map[string]any, role, field string, defaults []string) (float64, error) {
+	names := defaults
+	if field != "" {
+		names = []string{field}
+	}
+
+	for _, name := range names {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case json.Number:
+			return v.Float64()
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return 0, fmt.Errorf("unparseable %s %q", role, v)
+			}
+			return parsed, nil
+		case nil:
+			return 0, fmt.Errorf("empty %s", role)
+		default:
+			return 0, fmt.Errorf("%s field %q is not a number", role, name)
+		}
+	}
+	return 0, fmt.Errorf("missing %s field (tried %s)", role, strings.Join(names, ", "))
+}
@@ -0,0 +1,63 @@
+package enrich
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"csv-h3-tool/internal/h3"
+)
+
+func TestJSON(t *testing.T) {
+	input := `[
+  {"id": 1, "lat": 40.7128, "lng": -74.0060},
+  {"id": 2, "latitude": "51.5074", "longitude": "-0.1278"},
+  {"id": 3, "lat": 91, "lng": 0},
+  {"id": 4, "name": "no coordinates"},
+  {}
+]`
+	var output bytes.Buffer
+	stats, err := JSON(strings.NewReader(input), &output, h3.NewH3Generator(), Options{Resolution: 8})
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	if stats.Points != 5 || stats.Valid != 2 || stats.Invalid != 3 {
+		t.Errorf("Expected 5 points (2 valid, 3 invalid), got %+v", stats)
+	}
+
+	got := output.String()
+	for _, want := range []string{
+		`{"id":1,"lat":40.7128,"lng":-74.0060,"h3_index":"882a107289fffff"}`,
+		`{"id":2,"latitude":"51.5074","longitude":"-0.1278","h3_index":"88195da49bfffff"}`,
+		`{"id":4,"name":"no coordinates","h3_index":null,"h3_error":"missing latitude field (tried latitude, lat)"}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %s, got %s", want, got)
+		}
+	}
+	if !strings.HasPrefix(got, "[{") || !strings.HasSuffix(got, "}]\n") {
+		t.Errorf("Expected a JSON array, got %s", got)
+	}
+}
+
+func TestJSON_ExistingField(t *testing.T) {
+	var output bytes.Buffer
+	_, err := JSON(strings.NewReader(`[{"lat":0,"lng":0,"cell":"stale"}]`), &output, h3.NewH3Generator(),
+		Options{H3Field: "cell", Resolution: 0})
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if strings.Contains(output.String(), "stale") || !strings.Contains(output.String(), `"cell":"8075fffffffffff"`) {
+		t.Errorf("Expected existing cell field to be replaced, got %s", output.String())
+	}
+}
+
+func TestJSON_InvalidInput(t *testing.T) {
+	for _, input := range []string{`{"lat":0}`, `[1, 2]`, `[{"lat":0,"lng":0}`} {
+		var output bytes.Buffer
+		if _, err := JSON(strings.NewReader(input), &output, h3.NewH3Generator(), Options{Resolution: 8}); err == nil {
+			t.Errorf("JSON(%s) should error", input)
+		}
+	}
+}
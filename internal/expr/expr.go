@@ -0,0 +1,355 @@
+// Package expr compiles small expressions over the columns of a CSV row, such as
+// speed > 0 && country == "US", and evaluates them row by row.
+//
+// Expressions support number, string ('...' or "..."), and true/false literals;
+// column references by header name (`...` quotes names that are not identifiers) or
+// by 0-based index as $N; arithmetic + - * / %; comparisons == != < <= > >=; and
+// logic && || ! with parentheses; and the functions abs, floor, ceil, round(x[, digits]),
+// min, max, lower, upper, trim, concat, and if(cond, then, else). Column values are
+// compared numerically when both sides are numbers and as strings otherwise; string
+// literals always compare as strings, so zip == "02134" does not match 2134, and NaN
+// is neither equal to nor ordered with any value.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Program is a compiled expression
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses an expression, resolving column names against the header row
+// (exact match first, then case-insensitive); headers may be nil for headerless
+// input, where columns are referenced as $N
+func Compile(source string, headers []string) (*Program, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, headers: headers}
+	root, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", next.text, next.pos)
+	}
+	return &Program{source: source, root: root}, nil
+}
+
+// String returns the source of the expression
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval evaluates the expression on a row; missing columns are empty
+func (p *Program) Eval(row []string) (Value, error) {
+	return p.root.eval(row)
+}
+
+// Match evaluates the expression on a row as a condition
+func (p *Program) Match(row []string) (bool, error) {
+	value, err := p.root.eval(row)
+	if err != nil {
+		return false, err
+	}
+	return value.boolean()
+}
+
+// node is a node of the expression tree
+type node interface {
+	eval(row []string) (Value, error)
+}
+
+type literal struct{ value Value }
+
+func (n literal) eval([]string) (Value, error) { return n.value, nil }
+
+type column struct{ index int }
+
+func (n column) eval(row []string) (Value, error) {
+	if n.index < len(row) {
+		return Value{Kind: KindString, Str: row[n.index]}, nil
+	}
+	return Value{Kind: KindString}, nil
+}
+
+type unary struct {
+	op string
+	x  node
+}
+
+func (n unary) eval(row []string) (Value, error) {
+	x, err := n.x.eval(row)
+	if err != nil {
+		return Value{}, err
+	}
+	if n.op == "!" {
+		b, err := x.boolean()
+		return Value{Kind: KindBool, Bool: !b}, err
+	}
+	number, ok := x.number()
+	if !ok {
+		return Value{}, fmt.Errorf("cannot negate %q", x.String())
+	}
+	return Value{Kind: KindNumber, Num: -number}, nil
+}
+
+type binary struct {
+	op   string
+	l, r node
+}
+
+func (n binary) eval(row []string) (Value, error) {
+	l, err := n.l.eval(row)
+	if err != nil {
+		return Value{}, err
+	}
+
+	// Logical operators short-circuit
+	if n.op == "&&" || n.op == "||" {
+		lb, err := l.boolean()
+		if err != nil {
+			return Value{}, err
+		}
+		if lb == (n.op == "||") {
+			return Value{Kind: KindBool, Bool: lb}, nil
+		}
+		r, err := n.r.eval(row)
+		if err != nil {
+			return Value{}, err
+		}
+		rb, err := r.boolean()
+		return Value{Kind: KindBool, Bool: rb}, err
+	}
+
+	r, err := n.r.eval(row)
+	if err != nil {
+		return Value{}, err
+	}
+	switch n.op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return Value{Kind: KindBool, Bool: compareOp(n.op, l, r)}, nil
+	}
+
+	ln, lok := l.number()
+	rn, rok := r.number()
+	if !lok || !rok {
+		return Value{}, fmt.Errorf("%q %s %q needs numbers", l.String(), n.op, r.String())
+	}
+	var result float64
+	switch n.op {
+	case "+":
+		result = ln + rn
+	case "-":
+		result = ln - rn
+	case "*":
+		result = ln * rn
+	case "/", "%":
+		if rn == 0 {
+			return Value{}, fmt.Errorf("division by zero")
+		}
+		if n.op == "/" {
+			result = ln / rn
+		} else {
+			result = math.Mod(ln, rn)
+		}
+	}
+	return Value{Kind: KindNumber, Num: result}, nil
+}
+
+// compareOp applies a comparison operator; a number and a non-number are unequal and unordered
+func compareOp(op string, l, r Value) bool {
+	if l.Kind == KindBool || r.Kind == KindBool {
+		lb, lerr := l.boolean()
+		rb, rerr := r.boolean()
+		equal := lerr == nil && rerr == nil && lb == rb
+		return op == "==" && equal || op == "!=" && !equal
+	}
+	order, ok := compare(l, r)
+	if !ok {
+		return op == "!="
+	}
+	switch op {
+	case "==":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	}
+	return order >= 0
+}
+
+// precedence of the binary operators; higher binds tighter
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// parser is a precedence-climbing parser over the tokens of an expression
+type parser struct {
+	tokens  []token
+	pos     int
+	headers []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parse parses binary operators binding tighter than minPrecedence
+func (p *parser) parse(minPrecedence int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokenOperator || !ok || prec <= minPrecedence {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parse(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: t.text, l: left, r: right}
+	}
+}
+
+// parseUnary parses prefix operators and operands
+func (p *parser) parseUnary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		number, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return literal{Value{Kind: KindNumber, Num: number}}, nil
+	case tokenString:
+		return literal{Value{Kind: KindString, Str: t.text, Quoted: true}}, nil
+	case tokenIndex:
+		index, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column index $%s at offset %d", t.text, t.pos)
+		}
+		return column{index}, nil
+	case tokenIdent:
+		if t.text == "true" || t.text == "false" {
+			return literal{Value{Kind: KindBool, Bool: t.text == "true"}}, nil
+		}
+		if next := p.peek(); next.kind == tokenOperator && next.text == "(" {
+			return p.call(t)
+		}
+		return p.column(t)
+	case tokenColumn:
+		return p.column(t)
+	case tokenOperator:
+		switch t.text {
+		case "!", "-":
+			x, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return unary{op: t.text, x: x}, nil
+		case "(":
+			x, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.next(); closing.text != ")" {
+				return nil, fmt.Errorf("expected ) at offset %d", closing.pos)
+			}
+			return x, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+// call parses the arguments of a function call
+func (p *parser) call(name token) (node, error) {
+	fn, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at offset %d", name.text, name.pos)
+	}
+	p.next() // (
+	var args []node
+	if next := p.peek(); next.kind == tokenOperator && next.text == ")" {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			separator := p.next()
+			if separator.text == ")" {
+				break
+			}
+			if separator.text != "," {
+				return nil, fmt.Errorf("expected , or ) at offset %d", separator.pos)
+			}
+		}
+	}
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("wrong number of arguments to %s at offset %d: %d", name.text, name.pos, len(args))
+	}
+	return call{name: strings.ToLower(name.text), fn: fn, args: args}, nil
+}
+
+// Assignment splits a computed column definition name=expression into the column
+// name and the expression source
+func Assignment(definition string) (name, source string, err error) {
+	name, source, found := strings.Cut(definition, "=")
+	name, source = strings.TrimSpace(name), strings.TrimSpace(source)
+	if !found || name == "" || source == "" {
+		return "", "", fmt.Errorf("computed column %q must be name=expression", definition)
+	}
+	if strings.ContainsAny(name, "!<>") {
+		return "", "", fmt.Errorf("computed column %q must be name=expression", definition)
+	}
+	return name, source, nil
+}
+
+// column resolves a column name against the headers
+func (p *parser) column(t token) (node, error) {
+	for i, header := range p.headers {
+		if header == t.text {
+			return column{i}, nil
+		}
+	}
+	for i, header := range p.headers {
+		if strings.EqualFold(strings.TrimSpace(header), t.text) {
+			return column{i}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown column %q at offset %d", t.text, t.pos)
+}
@@ -0,0 +1,158 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	headers := []string{"id", "speed", "country", "active", "max speed", "zip", "größe", "ratio"}
+	row := []string{"7", "12.5", "US", "TRUE", "30", "2134", "3", "NaN"}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`speed > 0 && country == "US"`, true},
+		{`speed > 20 || country == 'DE'`, false},
+		{`!(speed > 20)`, true},
+		{`speed * 2 == 25`, true},
+		{`speed + 1 > 13 && speed - 1 < 12`, true},
+		{`id % 2 == 1`, true},
+		{`-speed < 0`, true},
+		{`1 + 2 * 3 == 7`, true},
+		{`(1 + 2) * 3 == 9`, true},
+		{`active`, true},
+		{`active == true`, true},
+		{`COUNTRY == "US"`, true},
+		{"`max speed` >= speed", true},
+		{`$2 == "US" && $0 == 7`, true},
+		{`country > "UZ"`, false}, // String comparison
+		{`country == 0`, false},   // A string is never equal to a number
+		{`country != 0`, true},
+		{`speed == "12.50"`, false}, // String literals compare as strings
+		{`speed == 12.50`, true},    // Both sides numeric
+		{`zip == "02134"`, false},
+		{`zip == "2134" && zip == 2134`, true},
+		{`größe > 1`, true},
+		{`ratio == ratio`, false}, // NaN is unordered
+		{`ratio != 0 && !(ratio < 1) && !(ratio >= 1)`, true},
+		{`$9 == ""`, true},         // Missing columns are empty
+		{`1e1 == 10 && .5 == 0.5`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			program, err := Compile(tt.expr, headers)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			matched, err := program.Match(row)
+			if err != nil {
+				t.Fatalf("Match failed: %v", err)
+			}
+			if matched != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, matched)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	headers := []string{"speed", "country"}
+	tests := map[string]string{
+		`speed >`:        "unexpected end",
+		`speed > 0)`:     "unexpected \")\"",
+		`(speed > 0`:     "expected )",
+		`altitude > 0`:   "unknown column \"altitude\"",
+		`country == "US`: "unterminated",
+		`speed # 2`:      "unexpected character",
+		`$ > 1`:          "column index",
+	}
+	for source, message := range tests {
+		if _, err := Compile(source, headers); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Compile(%q): expected error containing %q, got %v", source, message, err)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	headers := []string{"speed", "country"}
+	tests := map[string]string{
+		`speed * 2 > 1`: "needs numbers",
+		`1 / 0`:         "division by zero",
+		`country`:       "not a boolean",
+		`!speed`:        "not a boolean",
+	}
+	for source, message := range tests {
+		program, err := Compile(source, headers)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", source, err)
+		}
+		if _, err := program.Match([]string{"", "US"}); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Match(%q): expected error containing %q, got %v", source, message, err)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	headers := []string{"speed_ms", "name", "count"}
+	row := []string{"10", " Main St ", ""}
+
+	tests := map[string]string{
+		`speed_ms * 3.6`:         "36",
+		`round(speed_ms / 3, 2)`: "3.33",
+		`round(2.5)`:             "3",
+		`abs(-speed_ms) + floor(1.7) + ceil(0.2)`: "12",
+		`min(speed_ms, 3, 7)`:                     "3",
+		`max(speed_ms, 3, 7)`:                     "10",
+		`upper(trim(name))`:                       "MAIN ST",
+		`lower("AbC")`:                            "abc",
+		`concat(trim(name), "-", speed_ms)`:       "Main St-10",
+		`if(count == "", 0, count * 2)`:           "0",
+		`speed_ms > 5`:                            "true",
+		`name`:                                    " Main St ",
+	}
+	for source, expected := range tests {
+		program, err := Compile(source, headers)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", source, err)
+			continue
+		}
+		value, err := program.Eval(row)
+		if err != nil {
+			t.Errorf("Eval(%q) failed: %v", source, err)
+			continue
+		}
+		if value.String() != expected {
+			t.Errorf("Eval(%q) = %q, want %q", source, value.String(), expected)
+		}
+	}
+
+	for source, message := range map[string]string{
+		`nope(1)`:    "unknown function",
+		`round()`:    "wrong number of arguments",
+		`if(1, 2)`:   "wrong number of arguments",
+		`round(1 2)`: "expected , or )",
+	} {
+		if _, err := Compile(source, headers); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Compile(%q): expected error containing %q, got %v", source, message, err)
+		}
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	name, source, err := Assignment(" speed_kmh = speed_ms*3.6 ")
+	if err != nil || name != "speed_kmh" || source != "speed_ms*3.6" {
+		t.Errorf("Assignment = %q, %q, %v", name, source, err)
+	}
+	name, source, err = Assignment("fast=speed == 10")
+	if err != nil || name != "fast" || source != "speed == 10" {
+		t.Errorf("Assignment = %q, %q, %v", name, source, err)
+	}
+	for _, definition := range []string{"speed*3.6", "=1", "x=", "a!=b"} {
+		if _, _, err := Assignment(definition); err == nil {
+			t.Errorf("Assignment(%q): expected an error", definition)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// function is a builtin function taking between minArgs and maxArgs (-1 = any) arguments
+type function struct {
+	minArgs, maxArgs int
+	call             func(args []Value) (Value, error)
+}
+
+// functions are the builtins available to expressions. if(cond, a, b) is handled by
+// the call node so that only the selected branch is evaluated.
+var functions = map[string]function{
+	"abs":   numeric(math.Abs),
+	"floor": numeric(math.Floor),
+	"ceil":  numeric(math.Ceil),
+	"round": {1, 2, func(args []Value) (Value, error) {
+		numbers, err := numbers(args)
+		if err != nil {
+			return Value{}, err
+		}
+		scale := 1.0
+		if len(numbers) == 2 {
+			scale = math.Pow(10, math.Trunc(numbers[1]))
+		}
+		return Value{Kind: KindNumber, Num: math.Round(numbers[0]*scale) / scale}, nil
+	}},
+	"min": {1, -1, func(args []Value) (Value, error) {
+		numbers, err := numbers(args)
+		if err != nil {
+			return Value{}, err
+		}
+		sort.Float64s(numbers)
+		return Value{Kind: KindNumber, Num: numbers[0]}, nil
+	}},
+	"max": {1, -1, func(args []Value) (Value, error) {
+		numbers, err := numbers(args)
+		if err != nil {
+			return Value{}, err
+		}
+		sort.Float64s(numbers)
+		return Value{Kind: KindNumber, Num: numbers[len(numbers)-1]}, nil
+	}},
+	"lower": text(strings.ToLower),
+	"upper": text(strings.ToUpper),
+	"trim":  text(strings.TrimSpace),
+	"concat": {1, -1, func(args []Value) (Value, error) {
+		var joined strings.Builder
+		for _, arg := range args {
+			joined.WriteString(arg.String())
+		}
+		return Value{Kind: KindString, Str: joined.String()}, nil
+	}},
+	"if": {3, 3, nil},
+}
+
+// numeric wraps a function of one number
+func numeric(fn func(float64) float64) function {
+	return function{1, 1, func(args []Value) (Value, error) {
+		numbers, err := numbers(args)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Kind: KindNumber, Num: fn(numbers[0])}, nil
+	}}
+}
+
+// text wraps a function of one string
+func text(fn func(string) string) function {
+	return function{1, 1, func(args []Value) (Value, error) {
+		return Value{Kind: KindString, Str: fn(args[0].String())}, nil
+	}}
+}
+
+// numbers converts all arguments to numbers
+func numbers(args []Value) ([]float64, error) {
+	numbers := make([]float64, len(args))
+	for i, arg := range args {
+		number, ok := arg.number()
+		if !ok {
+			return nil, fmt.Errorf("%q is not a number", arg.String())
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+type call struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n call) eval(row []string) (Value, error) {
+	if n.name == "if" {
+		condition, err := n.args[0].eval(row)
+		if err != nil {
+			return Value{}, err
+		}
+		b, err := condition.boolean()
+		if err != nil {
+			return Value{}, err
+		}
+		if b {
+			return n.args[1].eval(row)
+		}
+		return n.args[2].eval(row)
+	}
+
+	args := make([]Value, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(row)
+		if err != nil {
+			return Value{}, err
+		}
+		args[i] = value
+	}
+	value, err := n.fn.call(args)
+	if err != nil {
+		return Value{}, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return value, nil
+}
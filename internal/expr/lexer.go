@@ -0,0 +1,120 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind classifies the tokens of an expression
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenIndex  // $N column reference
+	tokenColumn // `...` quoted column name
+	tokenOperator
+)
+
+// token is one lexical element of an expression
+type token struct {
+	kind tokenKind
+	text string // Operator, identifier, number, or unquoted string
+	pos  int    // Byte offset in the source, for error messages
+}
+
+// operators are the recognized operators, two-character ones first
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", ","}
+
+// tokenize splits an expression into tokens
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(source); {
+		c, size := utf8.DecodeRuneInString(source[pos:])
+		switch {
+		case unicode.IsSpace(c):
+			pos += size
+		case c >= '0' && c <= '9' || c == '.' && pos+1 < len(source) && source[pos+1] >= '0' && source[pos+1] <= '9':
+			end := pos
+			for end < len(source) && (source[end] >= '0' && source[end] <= '9' || source[end] == '.' ||
+				source[end] == 'e' || source[end] == 'E' ||
+				(source[end] == '+' || source[end] == '-') && (source[end-1] == 'e' || source[end-1] == 'E')) {
+				end++
+			}
+			tokens = append(tokens, token{tokenNumber, source[pos:end], pos})
+			pos = end
+		case c == '"' || c == '\'' || c == '`':
+			text, end, err := readQuoted(source, pos)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokenString
+			if c == '`' {
+				kind = tokenColumn
+			}
+			tokens = append(tokens, token{kind, text, pos})
+			pos = end
+		case c == '$':
+			end := pos + 1
+			for end < len(source) && source[end] >= '0' && source[end] <= '9' {
+				end++
+			}
+			if end == pos+1 {
+				return nil, fmt.Errorf("expected a column index after $ at offset %d", pos)
+			}
+			tokens = append(tokens, token{tokenIndex, source[pos+1 : end], pos})
+			pos = end
+		case c == '_' || unicode.IsLetter(c):
+			end := pos
+			for end < len(source) {
+				r, width := utf8.DecodeRuneInString(source[end:])
+				if r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += width
+			}
+			tokens = append(tokens, token{tokenIdent, source[pos:end], pos})
+			pos = end
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[pos:], op) {
+					tokens = append(tokens, token{tokenOperator, op, pos})
+					pos += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, pos)
+			}
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(source)}), nil
+}
+
+// readQuoted reads a quoted string or identifier starting at pos; the quote character
+// is escaped by doubling it or with a backslash
+func readQuoted(source string, pos int) (string, int, error) {
+	quote := source[pos]
+	var text strings.Builder
+	for i := pos + 1; i < len(source); i++ {
+		switch {
+		case source[i] == '\\' && i+1 < len(source):
+			i++
+			text.WriteByte(source[i])
+		case source[i] == quote && i+1 < len(source) && source[i+1] == quote:
+			i++
+			text.WriteByte(quote)
+		case source[i] == quote:
+			return text.String(), i + 1, nil
+		default:
+			text.WriteByte(source[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c quote at offset %d", quote, pos)
+}
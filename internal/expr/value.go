@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a value
+type Kind int
+
+const (
+	KindString Kind = iota // Column values and string literals
+	KindNumber
+	KindBool
+)
+
+// Value is the result of evaluating an expression. Column values are strings and are
+// converted to numbers or booleans where an operator needs them.
+type Value struct {
+	Kind   Kind
+	Str    string
+	Num    float64
+	Bool   bool
+	Quoted bool // A string literal, compared as a string even when it looks like a number
+}
+
+// String formats the value for output
+func (v Value) String() string {
+	switch v.Kind {
+	case KindNumber:
+		return strconv.FormatFloat(v.Num, 'f', -1, 64)
+	case KindBool:
+		return strconv.FormatBool(v.Bool)
+	}
+	return v.Str
+}
+
+// number converts the value to a number
+func (v Value) number() (float64, bool) {
+	switch v.Kind {
+	case KindNumber:
+		return v.Num, true
+	case KindString:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v.Str), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// boolean converts the value to a boolean; strings must be true or false (any case), 1 or 0
+func (v Value) boolean() (bool, error) {
+	switch v.Kind {
+	case KindBool:
+		return v.Bool, nil
+	case KindString:
+		if b, err := strconv.ParseBool(strings.ToLower(strings.TrimSpace(v.Str))); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("%q is not a boolean", v.String())
+}
+
+// compare orders two values: as strings when either is a string literal, numerically
+// when both are numbers, and as strings when neither is a number. It returns ok = false
+// when only one side is a number or either is NaN, since such values are neither
+// equal nor ordered.
+func compare(l, r Value) (order int, ok bool) {
+	if l.Quoted || r.Quoted {
+		return strings.Compare(l.String(), r.String()), true
+	}
+	ln, lNumeric := l.number()
+	rn, rNumeric := r.number()
+	switch {
+	case lNumeric && rNumeric:
+		if math.IsNaN(ln) || math.IsNaN(rn) {
+			return 0, false
+		}
+		switch {
+		case ln < rn:
+			return -1, true
+		case ln > rn:
+			return 1, true
+		}
+		return 0, true
+	case l.Kind == KindNumber || r.Kind == KindNumber:
+		return 0, false
+	}
+	return strings.Compare(l.Str, r.Str), true
+}
@@ -0,0 +1,220 @@
+package extsort
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Sorter performs a stable external merge sort of CSV rows by a string key.
+// Rows are buffered in memory up to the chunk size and then spilled to sorted,
+// gzip-compressed temporary files, which are merged when Merge is called.
+type Sorter struct {
+	chunkSize  int
+	tempDir    string
+	buffer     []entry
+	spillFiles []string
+	sequence   int
+}
+
+// entry is a single keyed row; seq preserves input order for equal keys
+type entry struct {
+	key string
+	seq int
+	row []string
+}
+
+// NewSorter creates a sorter that keeps at most chunkSize rows in memory and
+// spills to tempDir (the system temp directory when empty)
+func NewSorter(chunkSize int, tempDir string) (*Sorter, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("sort chunk size must be positive, got %d", chunkSize)
+	}
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	return &Sorter{
+		chunkSize: chunkSize,
+		tempDir:   tempDir,
+		buffer:    make([]entry, 0, chunkSize),
+	}, nil
+}
+
+// Add buffers a row under the given key, spilling to disk when the buffer is full
+func (s *Sorter) Add(key string, row []string) error {
+	stored := make([]string, len(row))
+	copy(stored, row)
+	s.buffer = append(s.buffer, entry{key: key, seq: s.sequence, row: stored})
+	s.sequence++
+
+	if len(s.buffer) >= s.chunkSize {
+		return s.spill()
+	}
+	return nil
+}
+
+// TempDir returns the directory spill files are written to
+func (s *Sorter) TempDir() string {
+	return s.tempDir
+}
+
+// SpillCount returns the number of temporary files written so far
+func (s *Sorter) SpillCount() int {
+	return len(s.spillFiles)
+}
+
+// sortBuffer sorts the in-memory buffer by key, preserving input order for ties
+func (s *Sorter) sortBuffer() {
+	sort.SliceStable(s.buffer, func(i, j int) bool {
+		return s.buffer[i].key < s.buffer[j].key
+	})
+}
+
+// spill writes the sorted buffer to a temporary file
+func (s *Sorter) spill() error {
+	if len(s.buffer) == 0 {
+		return nil
+	}
+	s.sortBuffer()
+
+	writer, err := CreateSpill(s.tempDir)
+	if err != nil {
+		return err
+	}
+	s.spillFiles = append(s.spillFiles, writer.Name())
+
+	for _, e := range s.buffer {
+		record := make([]string, 0, len(e.row)+1)
+		record = append(record, e.key)
+		record = append(record, e.row...)
+		if err := writer.Write(record); err != nil {
+			writer.Close()
+			return spillError(s.tempDir, "write", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	s.buffer = s.buffer[:0]
+	return nil
+}
+
+// Merge emits all rows in key order and removes any temporary files
+func (s *Sorter) Merge(emit func(row []string) error) error {
+	defer s.Cleanup()
+
+	// Everything fit in memory - no merge needed
+	if len(s.spillFiles) == 0 {
+		s.sortBuffer()
+		for _, e := range s.buffer {
+			if err := emit(e.row); err != nil {
+				return err
+			}
+		}
+		s.buffer = s.buffer[:0]
+		return nil
+	}
+
+	if err := s.spill(); err != nil {
+		return err
+	}
+
+	// Open one reader per spill file and seed the merge heap
+	sources := make([]*mergeSource, 0, len(s.spillFiles))
+	defer func() {
+		for _, source := range sources {
+			source.reader.Close()
+		}
+	}()
+
+	h := &mergeHeap{}
+	for i, path := range s.spillFiles {
+		reader, err := OpenSpill(path)
+		if err != nil {
+			return err
+		}
+		source := &mergeSource{reader: reader, index: i}
+		sources = append(sources, source)
+
+		ok, err := source.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Push(h, source)
+		}
+	}
+
+	for h.Len() > 0 {
+		source := (*h)[0]
+		if err := emit(source.row); err != nil {
+			return err
+		}
+
+		ok, err := source.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+
+	return nil
+}
+
+// Cleanup removes temporary spill files and discards buffered rows
+func (s *Sorter) Cleanup() {
+	for _, path := range s.spillFiles {
+		RemoveSpill(path)
+	}
+	s.spillFiles = nil
+	s.buffer = s.buffer[:0]
+}
+
+// mergeSource is a sorted spill file being merged
+type mergeSource struct {
+	reader *spillReader
+	index  int
+	key    string
+	row    []string
+}
+
+// next advances the source to its next row, returning false at end of file
+func (m *mergeSource) next() (bool, error) {
+	record, err := m.reader.Read()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read sort spill file: %w", err)
+	}
+	m.key = record[0]
+	m.row = record[1:]
+	return true, nil
+}
+
+// mergeHeap orders merge sources by current key, then by spill order for stability
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	return h[i].index < h[j].index
+}
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
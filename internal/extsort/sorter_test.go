@@ -0,0 +1,104 @@
+package extsort
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func collect(t *testing.T, s *Sorter) []string {
+	t.Helper()
+	var out []string
+	err := s.Merge(func(row []string) error {
+		out = append(out, strings.Join(row, "|"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	return out
+}
+
+func TestNewSorter(t *testing.T) {
+	if _, err := NewSorter(0, ""); err == nil {
+		t.Error("Expected error for zero chunk size")
+	}
+	if _, err := NewSorter(10, ""); err != nil {
+		t.Errorf("Expected no error but got: %v", err)
+	}
+}
+
+func TestSorter_InMemoryAndSpilled(t *testing.T) {
+	keys := []string{"c", "a", "b", "a", "d", "c", "b", "a"}
+	expected := []string{"a|1", "a|3", "a|7", "b|2", "b|6", "c|0", "c|5", "d|4"}
+
+	for _, chunkSize := range []int{100, 3, 1} {
+		t.Run(fmt.Sprintf("chunk size %d", chunkSize), func(t *testing.T) {
+			tempDir := t.TempDir()
+			s, err := NewSorter(chunkSize, tempDir)
+			if err != nil {
+				t.Fatalf("NewSorter failed: %v", err)
+			}
+
+			for i, key := range keys {
+				if err := s.Add(key, []string{key, fmt.Sprint(i)}); err != nil {
+					t.Fatalf("Add failed: %v", err)
+				}
+			}
+
+			if chunkSize < len(keys) && s.SpillCount() == 0 {
+				t.Error("Expected rows to be spilled to disk")
+			}
+
+			got := collect(t, s)
+			if strings.Join(got, ",") != strings.Join(expected, ",") {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+
+			// Spill files must be removed after merging
+			entries, err := os.ReadDir(tempDir)
+			if err != nil {
+				t.Fatalf("ReadDir failed: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("Expected temp directory to be empty, found %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestSorter_PreservesQuotedFields(t *testing.T) {
+	s, err := NewSorter(1, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSorter failed: %v", err)
+	}
+
+	s.Add("b", []string{"has,comma", "has \"quote\""})
+	s.Add("a", []string{"multi\nline", ""})
+
+	got := collect(t, s)
+	expected := []string{"multi\nline|", "has,comma|has \"quote\""}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestSpill_CompressedAndRemovedOnInterrupt(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := NewSorter(1, tempDir)
+	if err != nil {
+		t.Fatalf("NewSorter failed: %v", err)
+	}
+	s.Add("a", []string{"a", "1"})
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil || len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), ".csv.gz") {
+		t.Fatalf("Expected one compressed spill file, got %v (%v)", entries, err)
+	}
+
+	RemoveAllSpills()
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected spill files to be removed, found %d", len(entries))
+	}
+}
@@ -0,0 +1,123 @@
+package extsort
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// spillFiles tracks every spill file that has not been removed yet, so they can
+// be cleaned up when the process is interrupted
+var spillFiles = struct {
+	sync.Mutex
+	paths map[string]struct{}
+}{paths: make(map[string]struct{})}
+
+// SpillWriter writes CSV rows to a gzip-compressed temporary spill file
+type SpillWriter struct {
+	*csv.Writer
+	file *os.File
+	gzip *gzip.Writer
+	dir  string
+}
+
+// CreateSpill creates a compressed spill file in dir (the system temp directory when
+// empty). The file is tracked until RemoveSpill or RemoveAllSpills removes it.
+func CreateSpill(dir string) (*SpillWriter, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	file, err := os.CreateTemp(dir, "csv-h3-spill-*.csv.gz")
+	if err != nil {
+		return nil, spillError(dir, "create", err)
+	}
+	spillFiles.Lock()
+	spillFiles.paths[file.Name()] = struct{}{}
+	spillFiles.Unlock()
+
+	// Favour speed: spill files are short-lived and written on the hot path
+	compressed, _ := gzip.NewWriterLevel(file, gzip.BestSpeed)
+	return &SpillWriter{Writer: csv.NewWriter(compressed), file: file, gzip: compressed, dir: dir}, nil
+}
+
+// Name returns the path of the spill file
+func (w *SpillWriter) Name() string {
+	return w.file.Name()
+}
+
+// Close flushes the rows, finishes the compressed stream, and closes the file
+func (w *SpillWriter) Close() error {
+	w.Flush()
+	err := w.Error()
+	if gzipErr := w.gzip.Close(); err == nil {
+		err = gzipErr
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return spillError(w.dir, "write", err)
+	}
+	return nil
+}
+
+// spillReader reads CSV rows back from a compressed spill file
+type spillReader struct {
+	*csv.Reader
+	file *os.File
+	gzip *gzip.Reader
+}
+
+// OpenSpill opens a spill file written by a SpillWriter
+func OpenSpill(path string) (*spillReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read spill file %s: %w", path, err)
+	}
+	reader := csv.NewReader(compressed)
+	reader.FieldsPerRecord = -1
+	return &spillReader{Reader: reader, file: file, gzip: compressed}, nil
+}
+
+// Close closes the spill file
+func (r *spillReader) Close() error {
+	r.gzip.Close()
+	return r.file.Close()
+}
+
+// RemoveSpill removes a spill file and stops tracking it
+func RemoveSpill(path string) {
+	os.Remove(path)
+	spillFiles.Lock()
+	delete(spillFiles.paths, path)
+	spillFiles.Unlock()
+}
+
+// RemoveAllSpills removes every spill file still on disk; it is called when the
+// process is interrupted before sorters and aggregations clean up after themselves
+func RemoveAllSpills() {
+	spillFiles.Lock()
+	defer spillFiles.Unlock()
+	for path := range spillFiles.paths {
+		os.Remove(path)
+		delete(spillFiles.paths, path)
+	}
+}
+
+// spillError describes a spill file failure, pointing at --temp-dir when the
+// temp directory ran out of space
+func spillError(dir, action string, err error) error {
+	if errors.Is(err, syscall.ENOSPC) {
+		return fmt.Errorf("temp directory %s is out of space; free space or choose a larger one with --temp-dir: %w", dir, err)
+	}
+	return fmt.Errorf("failed to %s spill file in %s: %w", action, dir, err)
+}
@@ -5,8 +5,23 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
+
+	"csv-h3-tool/internal/csv"
 )
 
+// DefaultOutputNameTemplate reproduces the default "<name>_with_h3<ext>" output naming
+const DefaultOutputNameTemplate = "{{.Stem}}_with_h3{{.Ext}}"
+
+// OutputNameData holds the values available to output name templates
+type OutputNameData struct {
+	Stem       string // Input file name without extension (e.g., "data")
+	Ext        string // Input file extension including the dot (e.g., ".csv")
+	Name       string // Input file name (e.g., "data.csv")
+	Dir        string // Directory containing the input file
+	Resolution int    // H3 resolution
+}
+
 // FileHandler provides file path handling and validation functionality
 type FileHandler struct{}
 
@@ -134,6 +149,58 @@ func (fh *FileHandler) GenerateOutputPath(inputFile string, suffix string) strin
 	return filepath.Join(dir, fmt.Sprintf("%s%s%s", base, suffix, ext))
 }
 
+// ParseOutputNameTemplate parses an output name template such as
+// "{{.Stem}}_{{.Resolution}}_h3{{.Ext}}". An empty template selects DefaultOutputNameTemplate.
+func ParseOutputNameTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultOutputNameTemplate
+	}
+	tmpl, err := template.New("output-name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output name template %q: %w", text, err)
+	}
+	return tmpl, nil
+}
+
+// GenerateTemplatedOutputPath renders the output path for inputFile from a name template.
+// Relative names are placed in the input file's directory. Zip archive inputs are named
+// after the selected member, or the archive as a CSV file when no member is selected.
+func (fh *FileHandler) GenerateTemplatedOutputPath(inputFile, nameTemplate string, resolution int) (string, error) {
+	tmpl, err := ParseOutputNameTemplate(nameTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := OutputNameData{Stem: "output", Ext: ".csv", Name: "output.csv", Dir: ".", Resolution: resolution}
+	if archive, member, ok := csv.SplitZipPath(inputFile); ok {
+		name := strings.TrimSuffix(filepath.Base(archive), filepath.Ext(archive)) + ".csv"
+		if member != "" {
+			name = filepath.Base(member)
+		}
+		inputFile = filepath.Join(filepath.Dir(archive), name)
+	}
+	if inputFile != "" {
+		cleanInput := filepath.Clean(inputFile)
+		data.Name = filepath.Base(cleanInput)
+		data.Ext = filepath.Ext(cleanInput)
+		data.Stem = strings.TrimSuffix(data.Name, data.Ext)
+		data.Dir = filepath.Dir(cleanInput)
+	}
+
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("failed to render output name template: %w", err)
+	}
+	if strings.TrimSpace(name.String()) == "" {
+		return "", fmt.Errorf("output name template %q renders an empty file name", nameTemplate)
+	}
+
+	if filepath.IsAbs(name.String()) {
+		return filepath.Clean(name.String()), nil
+	}
+	return filepath.Join(data.Dir, name.String()), nil
+}
+
 // EnsureCSVExtension ensures the file has a .csv extension
 func (fh *FileHandler) EnsureCSVExtension(path string) string {
 	if path == "" {
@@ -610,4 +610,120 @@ func TestFileHandler_SanitizeFilename(t *testing.T) {
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
-}
\ No newline at end of file
+}
+
+func TestFileHandler_GenerateTemplatedOutputPath(t *testing.T) {
+	fh := NewFileHandler()
+	
+	tests := []struct {
+		name        string
+		inputFile   string
+		template    string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:      "default template",
+			inputFile: filepath.Join("path", "to", "data.csv"),
+			template:  "",
+			expected:  filepath.Join("path", "to", "data_with_h3.csv"),
+		},
+		{
+			name:      "resolution in name",
+			inputFile: "data.csv",
+			template:  "{{.Stem}}_{{.Resolution}}_h3{{.Ext}}",
+			expected:  "data_9_h3.csv",
+		},
+		{
+			name:      "subdirectory relative to input",
+			inputFile: filepath.Join("in", "data.txt"),
+			template:  "out/{{.Name}}",
+			expected:  filepath.Join("in", "out", "data.txt"),
+		},
+		{
+			name:      "empty input file",
+			inputFile: "",
+			template:  "",
+			expected:  "output_with_h3.csv",
+		},
+		{
+			name:        "unknown field",
+			inputFile:   "data.csv",
+			template:    "{{.Missing}}.csv",
+			expectError: true,
+		},
+		{
+			name:        "empty rendered name",
+			inputFile:   "data.csv",
+			template:    "{{if false}}x{{end}}",
+			expectError: true,
+		},
+		{
+			name:        "malformed template",
+			inputFile:   "data.csv",
+			template:    "{{.Stem",
+			expectError: true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := fh.GenerateTemplatedOutputPath(tt.inputFile, tt.template, 9)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got %s", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFileHandler_LockPath(t *testing.T) {
+	fh := NewFileHandler()
+
+	path, err := fh.LockPath(filepath.Join("data", "input.csv"), "")
+	if err != nil || path != filepath.Join("data", ".input.csv.lock") {
+		t.Errorf("Expected lock file next to the input, got %s (%v)", path, err)
+	}
+
+	lockDir := t.TempDir()
+	first, _ := fh.LockPath(filepath.Join("a", "input.csv"), lockDir)
+	second, _ := fh.LockPath(filepath.Join("b", "input.csv"), lockDir)
+	if filepath.Dir(first) != lockDir || !strings.HasPrefix(filepath.Base(first), "input.csv-") {
+		t.Errorf("Expected lock file named after the input in %s, got %s", lockDir, first)
+	}
+	if first == second {
+		t.Errorf("Expected distinct lock files for inputs in different directories, got %s", first)
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".input.csv.lock")
+
+	lock, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock() error = %v", err)
+	}
+	if _, err := TryLock(path); err != ErrLocked {
+		t.Errorf("Expected ErrLocked while the lock is held, got %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed, got %v", err)
+	}
+
+	lock, err = TryLock(path)
+	if err != nil {
+		t.Fatalf("Expected the lock to be free after Unlock, got %v", err)
+	}
+	lock.Unlock()
+}
@@ -0,0 +1,85 @@
+package filehandler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrLocked is returned by TryLock when another process holds the lock
+var ErrLocked = errors.New("locked by another process")
+
+// FileLock is an exclusive advisory lock on a lock file, released when the process
+// exits even if it crashes
+type FileLock struct {
+	file *os.File
+	path string
+}
+
+// LockPath returns the lock file guarding an input file: a hidden ".<name>.lock" next
+// to it, or a file named after the input's absolute path in lockDir when set (for
+// network file systems where locks next to the input are unreliable)
+func (fh *FileHandler) LockPath(inputFile, lockDir string) (string, error) {
+	if lockDir == "" {
+		return filepath.Join(filepath.Dir(inputFile), "."+filepath.Base(inputFile)+".lock"), nil
+	}
+	absolute, err := filepath.Abs(inputFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", inputFile, err)
+	}
+	sum := sha1.Sum([]byte(absolute))
+	return filepath.Join(lockDir, filepath.Base(inputFile)+"-"+hex.EncodeToString(sum[:4])+".lock"), nil
+}
+
+// TryLock acquires the lock file at path without blocking, creating it if needed.
+// It returns ErrLocked if another process holds the lock.
+func TryLock(path string) (*FileLock, error) {
+	for {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", path, err)
+		}
+		if err := lockFile(file); err != nil {
+			file.Close()
+			if errors.Is(err, ErrLocked) {
+				return nil, ErrLocked
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		// The holder removes the lock file on release; retry if this handle locked a
+		// file that was removed meanwhile, since a new lock file may already exist
+		opened, statErr := file.Stat()
+		current, err := os.Stat(path)
+		if statErr == nil && err == nil && os.SameFile(opened, current) {
+			return &FileLock{file: file, path: path}, nil
+		}
+		unlockFile(file)
+		file.Close()
+	}
+}
+
+// Lock acquires the lock file at path like TryLock, waiting up to timeout for another
+// process to release it. It returns ErrLocked if the lock is still held after timeout.
+func Lock(path string, timeout time.Duration) (*FileLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, err := TryLock(path)
+		if err != ErrLocked || time.Now().After(deadline) {
+			return lock, err
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Unlock removes the lock file and releases the lock. Windows cannot remove a file
+// that is still open, so there the lock file is left behind and reused.
+func (l *FileLock) Unlock() error {
+	os.Remove(l.path)
+	unlockFile(l.file)
+	return l.file.Close()
+}
@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadiusKm is the mean Earth radius used for great-circle calculations
+const EarthRadiusKm = 6371.0088
+
+// DistanceKm returns the great-circle (haversine) distance in kilometers between two points
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dPhi := phi2 - phi1
+	dLambda := radians(lng2 - lng1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// InitialBearing returns the initial great-circle bearing from the first point to the second,
+// in degrees clockwise from north in the range [0, 360)
+func InitialBearing(lat1, lng1, lat2, lng2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dLambda := radians(lng2 - lng1)
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	bearing := math.Mod(degrees(math.Atan2(y, x))+360, 360)
+	if bearing >= 360 {
+		bearing = 0
+	}
+	return bearing
+}
+
+// distanceUnits are the suffixes accepted by ParseDistance with their length in meters
+var distanceUnits = []struct {
+	suffix string
+	meters float64
+}{
+	{"km", 1000},
+	{"m", 1},
+}
+
+// ParseDistance parses a positive distance such as "100m" or "1.5km" into meters; a
+// bare number is taken as meters
+func ParseDistance(value string) (float64, error) {
+	text := strings.ToLower(strings.TrimSpace(value))
+	scale := 1.0
+	for _, unit := range distanceUnits {
+		if strings.HasSuffix(text, unit.suffix) {
+			text, scale = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix)), unit.meters
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || !(number > 0) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("invalid distance %q: expected a positive length such as 100m or 1.5km", value)
+	}
+	return number * scale, nil
+}
+
+// RingContains reports whether a point falls inside a ring of [lng, lat] vertices,
+// using the even-odd (ray casting) rule; the ring need not be closed
+func RingContains(ring [][2]float64, lat, lng float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,89 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		expected               float64
+	}{
+		{"same point", 40.7128, -74.0060, 40.7128, -74.0060, 0},
+		{"New York to London", 40.7128, -74.0060, 51.5074, -0.1278, 5570.2},
+		{"one degree of longitude at the equator", 0, 0, 0, 1, 111.195},
+		{"antipodes", 0, 0, 0, 180, math.Pi * EarthRadiusKm},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.195},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+			if math.Abs(got-tt.expected) > 0.5 {
+				t.Errorf("Expected %.3f km, got %.3f km", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInitialBearing(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		expected               float64
+	}{
+		{"north", 0, 0, 1, 0, 0},
+		{"east", 0, 0, 0, 1, 90},
+		{"south", 1, 0, 0, 0, 180},
+		{"west", 0, 1, 0, 0, 270},
+		{"New York to London", 40.7128, -74.0060, 51.5074, -0.1278, 51.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InitialBearing(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+			if math.Abs(got-tt.expected) > 0.1 {
+				t.Errorf("Expected %.1f degrees, got %.3f degrees", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRingContains(t *testing.T) {
+	square := [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	notch := [][2]float64{{0, 0}, {10, 0}, {10, 10}, {5, 5}, {0, 10}, {0, 0}}
+	tests := []struct {
+		name     string
+		ring     [][2]float64
+		lat, lng float64
+		expected bool
+	}{
+		{"inside", square, 5, 5, true},
+		{"outside", square, 5, 15, false},
+		{"above", square, 15, 5, false},
+		{"in the notch", notch, 8, 5, false},
+		{"beside the notch", notch, 8, 1, true},
+		{"empty ring", nil, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := RingContains(tt.ring, tt.lat, tt.lng); got != tt.expected {
+			t.Errorf("%s: RingContains(%g, %g) = %t, expected %t", tt.name, tt.lat, tt.lng, got, tt.expected)
+		}
+	}
+}
+
+func TestParseDistance(t *testing.T) {
+	for value, expected := range map[string]float64{"100m": 100, "1.5km": 1500, "250": 250, " 2 KM ": 2000} {
+		got, err := ParseDistance(value)
+		if err != nil || got != expected {
+			t.Errorf("ParseDistance(%q) = %g, %v; expected %g", value, got, err, expected)
+		}
+	}
+	for _, value := range []string{"", "m", "-5m", "0km", "100ft", "NaNm"} {
+		if _, err := ParseDistance(value); err == nil {
+			t.Errorf("Expected ParseDistance(%q) to fail", value)
+		}
+	}
+}
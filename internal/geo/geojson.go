@@ -0,0 +1,110 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Polygon is a polygon as rings of [lng, lat] vertices; the first ring is the outer
+// boundary and any further rings are holes
+type Polygon [][][2]float64
+
+// geoJSON holds the members of any GeoJSON object needed to find polygons
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Features    []geoJSON       `json:"features"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []geoJSON       `json:"geometries"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ReadPolygons reads every Polygon and MultiPolygon from a GeoJSON file containing a
+// FeatureCollection, Feature, GeometryCollection, or bare geometry. Other geometry
+// types are ignored; a file without any polygon is an error.
+func ReadPolygons(path string) ([]Polygon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON file: %w", err)
+	}
+	polygons, err := ParsePolygons(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return polygons, nil
+}
+
+// ParsePolygons parses every Polygon and MultiPolygon from GeoJSON data
+func ParsePolygons(data []byte) ([]Polygon, error) {
+	var object geoJSON
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	var polygons []Polygon
+	if err := collectPolygons(&object, &polygons); err != nil {
+		return nil, err
+	}
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("GeoJSON contains no Polygon or MultiPolygon geometry")
+	}
+	return polygons, nil
+}
+
+// collectPolygons appends the polygons of a GeoJSON object and its children
+func collectPolygons(object *geoJSON, polygons *[]Polygon) error {
+	switch object.Type {
+	case "FeatureCollection":
+		for i := range object.Features {
+			if err := collectPolygons(&object.Features[i], polygons); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if object.Geometry != nil {
+			return collectPolygons(object.Geometry, polygons)
+		}
+	case "GeometryCollection":
+		for i := range object.Geometries {
+			if err := collectPolygons(&object.Geometries[i], polygons); err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var polygon Polygon
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		if err := checkPolygon(polygon); err != nil {
+			return err
+		}
+		*polygons = append(*polygons, polygon)
+	case "MultiPolygon":
+		var multi []Polygon
+		if err := json.Unmarshal(object.Coordinates, &multi); err != nil {
+			return fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+		for _, polygon := range multi {
+			if err := checkPolygon(polygon); err != nil {
+				return err
+			}
+		}
+		*polygons = append(*polygons, multi...)
+	case "":
+		return fmt.Errorf("GeoJSON object has no type")
+	}
+	return nil
+}
+
+// checkPolygon rejects polygons without an outer ring or with degenerate rings
+func checkPolygon(polygon Polygon) error {
+	if len(polygon) == 0 {
+		return fmt.Errorf("polygon has no rings")
+	}
+	for i, ring := range polygon {
+		if len(ring) < 4 {
+			return fmt.Errorf("polygon ring %d has %d positions; GeoJSON rings need at least 4", i, len(ring))
+		}
+	}
+	return nil
+}
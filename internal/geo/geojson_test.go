@@ -0,0 +1,58 @@
+package geo
+
+import "testing"
+
+func TestParsePolygons(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		polygons int
+		rings    int // Rings of the first polygon
+	}{
+		{"polygon", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`, 1, 1},
+		{"polygon with hole and altitude", `{"type":"Polygon","coordinates":[
+			[[0,0,5],[4,0,5],[4,4,5],[0,0,5]],[[1,1],[2,1],[2,2],[1,1]]]}`, 1, 2},
+		{"feature collection", `{"type":"FeatureCollection","features":[
+			{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}},
+			{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[0,0]}},
+			{"type":"Feature","properties":{},"geometry":{"type":"MultiPolygon","coordinates":[
+				[[[0,0],[1,0],[1,1],[0,0]]],[[[5,5],[6,5],[6,6],[5,5]]]]}}]}`, 3, 1},
+		{"geometry collection", `{"type":"GeometryCollection","geometries":[
+			{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}]}`, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygons, err := ParsePolygons([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParsePolygons() error = %v", err)
+			}
+			if len(polygons) != tt.polygons {
+				t.Fatalf("Expected %d polygons, got %d", tt.polygons, len(polygons))
+			}
+			if len(polygons[0]) != tt.rings {
+				t.Errorf("Expected %d rings, got %d", tt.rings, len(polygons[0]))
+			}
+		})
+	}
+}
+
+func TestParsePolygons_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"invalid JSON", `{`},
+		{"no type", `{"coordinates":[]}`},
+		{"no polygons", `{"type":"Point","coordinates":[0,0]}`},
+		{"short ring", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`},
+		{"no rings", `{"type":"Polygon","coordinates":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePolygons([]byte(tt.data)); err == nil {
+				t.Error("ParsePolygons() should error")
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// WGS84 ellipsoid and UTM projection parameters
+const (
+	wgs84A         = 6378137.0
+	wgs84F         = 1 / 298.257223563
+	utmScale       = 0.9996
+	utmFalseEast   = 500000.0
+	utmFalseNorth  = 10000000.0 // Added to northings in the southern hemisphere
+	utmMinLatitude = -80.0
+	utmMaxLatitude = 84.0
+)
+
+// utmBands are the latitude band letters from 80°S northwards in 8° steps (X spans 12°)
+const utmBands = "CDEFGHJKLMNPQRSTUVWX"
+
+// UTM is a position in the Universal Transverse Mercator system
+type UTM struct {
+	Zone     int     // Longitude zone, 1-60
+	Band     byte    // Latitude band letter, C-X
+	Easting  float64 // Meters
+	Northing float64 // Meters
+}
+
+// ZoneDesignator returns the zone number and band letter, e.g. "18T"
+func (u UTM) ZoneDesignator() string {
+	return fmt.Sprintf("%d%c", u.Zone, u.Band)
+}
+
+// ToUTM projects WGS84 coordinates to UTM. Latitudes outside [-80, 84] are covered by
+// the polar stereographic system instead and return an error.
+func ToUTM(lat, lng float64) (UTM, error) {
+	if lat < utmMinLatitude || lat > utmMaxLatitude {
+		return UTM{}, fmt.Errorf("latitude %.6f is outside the UTM range [%g, %g]", lat, utmMinLatitude, utmMaxLatitude)
+	}
+	if lng < -180 || lng > 180 {
+		return UTM{}, fmt.Errorf("longitude %.6f is outside the valid range [-180, 180]", lng)
+	}
+
+	zone := utmZone(lat, lng)
+	band := int((lat - utmMinLatitude) / 8)
+	if band >= len(utmBands) {
+		band = len(utmBands) - 1
+	}
+
+	e2 := wgs84F * (2 - wgs84F)
+	e4, e6 := e2*e2, e2*e2*e2
+	ep2 := e2 / (1 - e2)
+
+	phi := radians(lat)
+	dLambda := radians(lng - float64((zone-1)*6-180+3))
+	sinPhi, cosPhi, tanPhi := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+
+	n := wgs84A / math.Sqrt(1-e2*sinPhi*sinPhi)
+	t := tanPhi * tanPhi
+	c := ep2 * cosPhi * cosPhi
+	a := cosPhi * dLambda
+	m := wgs84A * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+
+	easting := utmScale*n*(a+(1-t+c)*math.Pow(a, 3)/6+
+		(5-18*t+t*t+72*c-58*ep2)*math.Pow(a, 5)/120) + utmFalseEast
+	northing := utmScale * (m + n*tanPhi*(a*a/2+
+		(5-t+9*c+4*c*c)*math.Pow(a, 4)/24+
+		(61-58*t+t*t+600*c-330*ep2)*math.Pow(a, 6)/720))
+	if lat < 0 {
+		northing += utmFalseNorth
+	}
+
+	return UTM{Zone: zone, Band: utmBands[band], Easting: easting, Northing: northing}, nil
+}
+
+// utmZone returns the UTM zone for the coordinates, including the Norway and Svalbard exceptions
+func utmZone(lat, lng float64) int {
+	if lat >= 56 && lat < 64 && lng >= 3 && lng < 12 {
+		return 32
+	}
+	if lat >= 72 {
+		switch {
+		case lng >= 0 && lng < 9:
+			return 31
+		case lng >= 9 && lng < 21:
+			return 33
+		case lng >= 21 && lng < 33:
+			return 35
+		case lng >= 33 && lng < 42:
+			return 37
+		}
+	}
+	zone := int((lng+180)/6) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	return zone
+}
+
+// MGRS 100 km square letters: column sets cycle every three zones, rows every two
+const (
+	mgrsColumnSets = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	mgrsRows       = "ABCDEFGHJKLMNPQRSTUV"
+)
+
+// ToMGRS returns the Military Grid Reference System string for WGS84 coordinates,
+// e.g. "18TWL8395907350", with digits per axis between 1 (10 km) and 5 (1 m)
+func ToMGRS(lat, lng float64, digits int) (string, error) {
+	if digits < 1 || digits > 5 {
+		return "", fmt.Errorf("MGRS precision %d is out of valid range [1, 5]", digits)
+	}
+	utm, err := ToUTM(lat, lng)
+	if err != nil {
+		return "", err
+	}
+
+	column := int(utm.Easting / 100000)
+	set := (utm.Zone - 1) % 3
+	columnLetter := mgrsColumnSets[set*8+column-1]
+
+	row := int(math.Floor(utm.Northing/100000)) % len(mgrsRows)
+	if utm.Zone%2 == 0 {
+		row = (row + 5) % len(mgrsRows)
+	}
+	rowLetter := mgrsRows[row]
+
+	divisor := math.Pow(10, float64(5-digits))
+	easting := int(math.Mod(utm.Easting, 100000) / divisor)
+	northing := int(math.Mod(utm.Northing, 100000) / divisor)
+	return fmt.Sprintf("%s%c%c%0*d%0*d", utm.ZoneDesignator(), columnLetter, rowLetter,
+		digits, easting, digits, northing), nil
+}
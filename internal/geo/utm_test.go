@@ -0,0 +1,74 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToUTM(t *testing.T) {
+	tests := []struct {
+		name              string
+		lat, lng          float64
+		zone              string
+		easting, northing float64
+	}{
+		{"origin", 0, 0, "31N", 166021.443, 0},
+		{"New York", 40.7128, -74.0060, "18T", 583959.372, 4507350.998},
+		{"Sydney", -33.8688, 151.2093, "56H", 334368.634, 6250948.345},
+		{"Norway exception", 60, 5, "32V", 276979.926, 6658157.203},
+		{"Svalbard exception", 78, 15, "33X", 500000, 8658369.587},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToUTM(tt.lat, tt.lng)
+			if err != nil {
+				t.Fatalf("ToUTM() error = %v", err)
+			}
+			if got.ZoneDesignator() != tt.zone {
+				t.Errorf("Expected zone %s, got %s", tt.zone, got.ZoneDesignator())
+			}
+			if math.Abs(got.Easting-tt.easting) > 0.01 || math.Abs(got.Northing-tt.northing) > 0.01 {
+				t.Errorf("Expected %.3f, %.3f, got %.3f, %.3f", tt.easting, tt.northing, got.Easting, got.Northing)
+			}
+		})
+	}
+}
+
+func TestToUTM_OutOfRange(t *testing.T) {
+	for _, lat := range []float64{-85, 85} {
+		if _, err := ToUTM(lat, 0); err == nil {
+			t.Errorf("ToUTM(%v, 0) should error outside the UTM latitude range", lat)
+		}
+	}
+}
+
+func TestToMGRS(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		digits   int
+		expected string
+	}{
+		{"origin", 0, 0, 5, "31NAA6602100000"},
+		{"New York 1 m", 40.7128, -74.0060, 5, "18TWL8395907350"},
+		{"New York 1 km", 40.7128, -74.0060, 2, "18TWL8307"},
+		{"Sydney", -33.8688, 151.2093, 5, "56HLH3436850948"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToMGRS(tt.lat, tt.lng, tt.digits)
+			if err != nil {
+				t.Fatalf("ToMGRS() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := ToMGRS(0, 0, 6); err == nil {
+		t.Error("ToMGRS() with precision 6 should error")
+	}
+}
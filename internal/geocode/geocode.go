@@ -0,0 +1,120 @@
+package geocode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Geocoder resolves a free-form address to coordinates; found is false when the
+// address is unknown
+type Geocoder interface {
+	Geocode(address string) (lat, lng float64, found bool, err error)
+}
+
+// factories create the supported geocoders from a service URL (empty = the service default)
+var factories = map[string]func(url string) Geocoder{
+	"nominatim": func(url string) Geocoder { return NewNominatim(url) },
+}
+
+// Supported returns the names of the supported geocoders
+func Supported() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the named geocoder with caching and a limit of perSecond requests
+func New(name, url string, perSecond float64) (Geocoder, error) {
+	factory, ok := factories[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported geocoder %q (supported: %s)", name, strings.Join(Supported(), ", "))
+	}
+	if perSecond <= 0 {
+		return nil, fmt.Errorf("geocoder rate must be positive, got %g", perSecond)
+	}
+	// Cache outermost so repeated addresses don't count against the rate limit
+	return NewCache(NewRateLimited(factory(url), perSecond)), nil
+}
+
+// result is a cached geocoding outcome
+type result struct {
+	lat, lng float64
+	found    bool
+}
+
+// Cache remembers the outcome for each address, including addresses not found.
+// Failed lookups are not cached.
+type Cache struct {
+	geocoder Geocoder
+	mu       sync.Mutex
+	results  map[string]result
+	hits     int
+}
+
+// NewCache wraps a geocoder with an in-memory cache
+func NewCache(geocoder Geocoder) *Cache {
+	return &Cache{geocoder: geocoder, results: make(map[string]result)}
+}
+
+func (c *Cache) Geocode(address string) (float64, float64, bool, error) {
+	key := strings.ToLower(strings.Join(strings.Fields(address), " "))
+
+	c.mu.Lock()
+	cached, ok := c.results[key]
+	if ok {
+		c.hits++
+	}
+	c.mu.Unlock()
+	if ok {
+		return cached.lat, cached.lng, cached.found, nil
+	}
+
+	lat, lng, found, err := c.geocoder.Geocode(address)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	c.mu.Lock()
+	c.results[key] = result{lat: lat, lng: lng, found: found}
+	c.mu.Unlock()
+	return lat, lng, found, nil
+}
+
+// Hits returns the number of lookups answered from the cache
+func (c *Cache) Hits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// RateLimited spaces out requests to a geocoder
+type RateLimited struct {
+	geocoder Geocoder
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time // Earliest time of the next request
+}
+
+// NewRateLimited wraps a geocoder allowing at most perSecond requests per second
+func NewRateLimited(geocoder Geocoder, perSecond float64) *RateLimited {
+	return &RateLimited{geocoder: geocoder, interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (r *RateLimited) Geocode(address string) (float64, float64, bool, error) {
+	r.mu.Lock()
+	now := time.Now()
+	wait := r.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	r.next = now.Add(wait + r.interval)
+	r.mu.Unlock()
+
+	time.Sleep(wait)
+	return r.geocoder.Geocode(address)
+}
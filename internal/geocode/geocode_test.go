@@ -0,0 +1,101 @@
+package geocode
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingGeocoder returns fixed coordinates for "known" addresses and counts calls
+type countingGeocoder struct {
+	calls int
+	times []time.Time
+}
+
+func (g *countingGeocoder) Geocode(address string) (float64, float64, bool, error) {
+	g.calls++
+	g.times = append(g.times, time.Now())
+	if address == "fail" {
+		return 0, 0, false, fmt.Errorf("service unavailable")
+	}
+	return 40.7128, -74.006, address != "nowhere", nil
+}
+
+func TestCache(t *testing.T) {
+	inner := &countingGeocoder{}
+	cache := NewCache(inner)
+
+	for _, address := range []string{"1 Main St", "1  main st", "nowhere", "nowhere"} {
+		if _, _, _, err := cache.Geocode(address); err != nil {
+			t.Fatalf("Geocode(%q) error = %v", address, err)
+		}
+	}
+	if inner.calls != 2 || cache.Hits() != 2 {
+		t.Errorf("Expected 2 lookups and 2 cache hits, got %d and %d", inner.calls, cache.Hits())
+	}
+
+	// Failures are retried rather than cached
+	cache.Geocode("fail")
+	cache.Geocode("fail")
+	if inner.calls != 4 {
+		t.Errorf("Expected failed lookups to be retried, got %d calls", inner.calls)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	inner := &countingGeocoder{}
+	limited := NewRateLimited(inner, 50) // 20ms apart
+
+	for i := 0; i < 3; i++ {
+		limited.Geocode(fmt.Sprintf("address %d", i))
+	}
+	for i := 1; i < len(inner.times); i++ {
+		if gap := inner.times[i].Sub(inner.times[i-1]); gap < 15*time.Millisecond {
+			t.Errorf("Request %d came %v after the previous one, expected about 20ms", i, gap)
+		}
+	}
+}
+
+func TestNominatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.Header.Get("User-Agent") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Query().Get("q") {
+		case "Empire State Building":
+			fmt.Fprint(w, `[{"lat":"40.7484","lon":"-73.9857","display_name":"Empire State Building"}]`)
+		case "error":
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	geocoder := NewNominatim(server.URL + "/")
+	lat, lng, found, err := geocoder.Geocode("Empire State Building")
+	if err != nil || !found || lat != 40.7484 || lng != -73.9857 {
+		t.Errorf("Geocode() = %v, %v, %v, %v; want 40.7484, -73.9857, true, nil", lat, lng, found, err)
+	}
+	if _, _, found, err := geocoder.Geocode("nowhere"); found || err != nil {
+		t.Errorf("Geocode(nowhere) = found %v, err %v; want not found without error", found, err)
+	}
+	if _, _, _, err := geocoder.Geocode("error"); err == nil {
+		t.Error("Geocode() should error on a failed request")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("nominatim", "", 1); err != nil {
+		t.Errorf("New(nominatim) error = %v", err)
+	}
+	if _, err := New("unknown", "", 1); err == nil {
+		t.Error("New() with an unknown geocoder should error")
+	}
+	if _, err := New("nominatim", "", 0); err == nil {
+		t.Error("New() with a zero rate should error")
+	}
+}
@@ -0,0 +1,68 @@
+package geocode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultNominatimURL is the public OpenStreetMap Nominatim service
+const DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+// Nominatim geocodes addresses with a Nominatim search API
+type Nominatim struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewNominatim creates a Nominatim geocoder for the service at baseURL (empty = public service)
+func NewNominatim(baseURL string) *Nominatim {
+	if baseURL == "" {
+		baseURL = DefaultNominatimURL
+	}
+	return &Nominatim{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (n *Nominatim) Geocode(address string) (float64, float64, bool, error) {
+	query := url.Values{"q": {address}, "format": {"jsonv2"}, "limit": {"1"}}
+	request, err := http.NewRequest(http.MethodGet, n.baseURL+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	// Nominatim's usage policy requires an identifying user agent
+	request.Header.Set("User-Agent", "csv-h3-tool")
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("geocoding request failed: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, 0, false, fmt.Errorf("geocoding request failed: %s", response.Status)
+	}
+
+	var places []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&places); err != nil {
+		return 0, 0, false, fmt.Errorf("invalid geocoding response: %w", err)
+	}
+	if len(places) == 0 {
+		return 0, 0, false, nil
+	}
+
+	lat, latErr := strconv.ParseFloat(places[0].Lat, 64)
+	lng, lngErr := strconv.ParseFloat(places[0].Lon, 64)
+	if latErr != nil || lngErr != nil {
+		return 0, 0, false, fmt.Errorf("invalid coordinates in geocoding response: %q, %q", places[0].Lat, places[0].Lon)
+	}
+	return lat, lng, true, nil
+}
@@ -0,0 +1,153 @@
+package h3
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/uber/h3-go/v4"
+)
+
+// ParseCell converts an H3 index string into a validated cell
+func ParseCell(index string) (h3.Cell, error) {
+	cell := h3.Cell(h3.IndexFromString(index))
+	if !cell.IsValid() {
+		return 0, fmt.Errorf("invalid H3 index: %q", index)
+	}
+	return cell, nil
+}
+
+// CellCentroid returns the latitude and longitude of the center of a cell
+func CellCentroid(index string) (lat, lng float64, err error) {
+	cell, err := ParseCell(index)
+	if err != nil {
+		return 0, 0, err
+	}
+	center, err := cell.LatLng()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get center of %s: %w", index, err)
+	}
+	return center.Lat, center.Lng, nil
+}
+
+// CellAreaKm2 returns the exact area of a cell in square kilometers
+func CellAreaKm2(index string) (float64, error) {
+	cell, err := ParseCell(index)
+	if err != nil {
+		return 0, err
+	}
+	area, err := h3.CellAreaKm2(cell)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get area of %s: %w", index, err)
+	}
+	return area, nil
+}
+
+// IsPentagon reports whether a cell is one of the 12 pentagons of its resolution
+func IsPentagon(index string) (bool, error) {
+	cell, err := ParseCell(index)
+	if err != nil {
+		return false, err
+	}
+	return cell.IsPentagon(), nil
+}
+
+// BaseCell returns the base cell number (0-121) of a cell and the icosahedron faces
+// (0-19) it intersects, in ascending order; most cells lie on a single face
+func BaseCell(index string) (baseCell int, faces []int, err error) {
+	cell, err := ParseCell(index)
+	if err != nil {
+		return 0, nil, err
+	}
+	all, err := cell.IcosahedronFaces()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get faces of %s: %w", index, err)
+	}
+	for _, face := range all {
+		if face >= 0 { // Unused slots are -1
+			faces = append(faces, face)
+		}
+	}
+	sort.Ints(faces)
+	return cell.BaseCellNumber(), faces, nil
+}
+
+// LocalIJ returns the local IJ coordinates of a cell relative to an anchor cell of the
+// same resolution. It fails for cells too far from the anchor or beyond a pentagon.
+func LocalIJ(anchor, index string) (i, j int, err error) {
+	origin, err := ParseCell(anchor)
+	if err != nil {
+		return 0, 0, err
+	}
+	cell, err := ParseCell(index)
+	if err != nil {
+		return 0, 0, err
+	}
+	coord, err := h3.CellToLocalIJ(origin, cell)
+	if err != nil {
+		return 0, 0, fmt.Errorf("no local IJ coordinates of %s relative to %s: %w", index, anchor, err)
+	}
+	return coord.I, coord.J, nil
+}
+
+// VertexIndexes returns the H3 vertex indexes of a cell: six for hexagons, five for
+// pentagons. Neighboring cells share the vertexes of their common edge.
+func VertexIndexes(index string) ([]string, error) {
+	cell, err := ParseCell(index)
+	if err != nil {
+		return nil, err
+	}
+	vertexes, err := h3.CellToVertexes(cell)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vertexes of %s: %w", index, err)
+	}
+	indexes := make([]string, 0, len(vertexes))
+	for _, vertex := range vertexes {
+		if vertex != 0 { // Pentagons leave the sixth vertex unset
+			indexes = append(indexes, h3.IndexToString(uint64(vertex)))
+		}
+	}
+	return indexes, nil
+}
+
+// ParentIndex returns the H3 index of the cell's ancestor at the given resolution
+func ParentIndex(index string, resolution int) (string, error) {
+	cell, err := ParseCell(index)
+	if err != nil {
+		return "", err
+	}
+	parent, err := cell.Parent(resolution)
+	if err != nil {
+		return "", fmt.Errorf("failed to get parent of %s at resolution %d: %w", index, resolution, err)
+	}
+	return parent.String(), nil
+}
+
+// DirectedEdgeIndex returns the H3 index of the directed edge from origin to destination.
+// ok is false when the cells are not neighbors (including when they are the same cell).
+func DirectedEdgeIndex(origin, destination string) (index string, ok bool, err error) {
+	from, err := ParseCell(origin)
+	if err != nil {
+		return "", false, err
+	}
+	to, err := ParseCell(destination)
+	if err != nil {
+		return "", false, err
+	}
+	if from.Resolution() != to.Resolution() {
+		return "", false, fmt.Errorf("cells %s and %s have different resolutions", origin, destination)
+	}
+
+	neighbors, err := from.IsNeighbor(to)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check whether %s and %s are neighbors: %w", origin, destination, err)
+	}
+	if !neighbors {
+		return "", false, nil
+	}
+
+	edge, err := from.DirectedEdge(to)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get directed edge from %s to %s: %w", origin, destination, err)
+	}
+	return h3.IndexToString(uint64(edge)), true, nil
+}
@@ -0,0 +1,199 @@
+package h3
+
+import (
+	"testing"
+
+	"github.com/uber/h3-go/v4"
+)
+
+func TestDirectedEdgeIndex(t *testing.T) {
+	origin, err := h3.LatLngToCell(h3.NewLatLng(40.7128, -74.0060), 8)
+	if err != nil {
+		t.Fatalf("LatLngToCell failed: %v", err)
+	}
+	neighbors, err := origin.GridDisk(1)
+	if err != nil {
+		t.Fatalf("GridDisk failed: %v", err)
+	}
+	var destination h3.Cell
+	for _, cell := range neighbors {
+		if cell != origin {
+			destination = cell
+			break
+		}
+	}
+
+	index, ok, err := DirectedEdgeIndex(origin.String(), destination.String())
+	if err != nil || !ok {
+		t.Fatalf("Expected an edge between neighbors, got ok=%v err=%v", ok, err)
+	}
+	edge := h3.DirectedEdge(h3.IndexFromString(index))
+	if !edge.IsValid() {
+		t.Fatalf("Expected a valid directed edge, got %s", index)
+	}
+	if from, _ := edge.Origin(); from != origin {
+		t.Errorf("Expected edge origin %s, got %s", origin, from)
+	}
+	if to, _ := edge.Destination(); to != destination {
+		t.Errorf("Expected edge destination %s, got %s", destination, to)
+	}
+
+	// Same cell and distant cells are not neighbors
+	if _, ok, err := DirectedEdgeIndex(origin.String(), origin.String()); ok || err != nil {
+		t.Errorf("Expected same cell not to be neighbors, got ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := DirectedEdgeIndex("882a107289fffff", "88195da49bfffff"); ok || err != nil {
+		t.Errorf("Expected distant cells not to be neighbors, got ok=%v err=%v", ok, err)
+	}
+
+	// Mixed resolutions and invalid indexes are errors
+	parent, _ := origin.Parent(7)
+	if _, _, err := DirectedEdgeIndex(origin.String(), parent.String()); err == nil {
+		t.Error("Expected error for cells at different resolutions")
+	}
+	if _, _, err := DirectedEdgeIndex("invalid", origin.String()); err == nil {
+		t.Error("Expected error for invalid H3 index")
+	}
+}
+
+func TestCellCentroid(t *testing.T) {
+	cell, err := h3.LatLngToCell(h3.NewLatLng(40.7128, -74.0060), 8)
+	if err != nil {
+		t.Fatalf("LatLngToCell failed: %v", err)
+	}
+	lat, lng, err := CellCentroid(cell.String())
+	if err != nil {
+		t.Fatalf("CellCentroid failed: %v", err)
+	}
+	if center, _ := h3.LatLngToCell(h3.NewLatLng(lat, lng), 8); center != cell {
+		t.Errorf("Expected the centroid (%g, %g) to lie in %s, got %s", lat, lng, cell, center)
+	}
+	if _, _, err := CellCentroid("invalid"); err == nil {
+		t.Error("Expected error for invalid H3 index")
+	}
+}
+
+func TestCellAreaKm2(t *testing.T) {
+	cell, err := h3.LatLngToCell(h3.NewLatLng(40.7128, -74.0060), 8)
+	if err != nil {
+		t.Fatalf("LatLngToCell failed: %v", err)
+	}
+	area, err := CellAreaKm2(cell.String())
+	if err != nil {
+		t.Fatalf("CellAreaKm2 failed: %v", err)
+	}
+	// Resolution 8 hexagons average 0.737 km²
+	if area < 0.5 || area > 1 {
+		t.Errorf("Expected an area near 0.737 km², got %g", area)
+	}
+	if _, err := CellAreaKm2("invalid"); err == nil {
+		t.Error("Expected error for invalid H3 index")
+	}
+}
+
+func TestIsPentagon(t *testing.T) {
+	pentagons, err := h3.Pentagons(8)
+	if err != nil {
+		t.Fatalf("Pentagons failed: %v", err)
+	}
+	if ok, err := IsPentagon(pentagons[0].String()); !ok || err != nil {
+		t.Errorf("Expected %s to be a pentagon, got %v, %v", pentagons[0], ok, err)
+	}
+	if ok, err := IsPentagon("882a107289fffff"); ok || err != nil {
+		t.Errorf("Expected a hexagon, got %v, %v", ok, err)
+	}
+	if _, err := IsPentagon("invalid"); err == nil {
+		t.Error("Expected error for invalid H3 index")
+	}
+}
+
+func TestBaseCell(t *testing.T) {
+	baseCell, faces, err := BaseCell("882a107289fffff")
+	if err != nil || baseCell != 21 || len(faces) != 1 || faces[0] != 2 {
+		t.Errorf("Expected base cell 21 on face 2, got %d on %v (%v)", baseCell, faces, err)
+	}
+	// Pentagons sit on icosahedron vertices, where five faces meet
+	baseCell, faces, err = BaseCell("8808000001fffff")
+	if err != nil || baseCell != 4 || len(faces) != 5 {
+		t.Errorf("Expected base cell 4 on five faces, got %d on %v (%v)", baseCell, faces, err)
+	}
+	if _, _, err := BaseCell("invalid"); err == nil {
+		t.Error("Expected error for invalid H3 index")
+	}
+}
+
+func TestLocalIJ(t *testing.T) {
+	anchor := h3.Cell(h3.IndexFromString("882a107289fffff"))
+	neighbors, err := anchor.GridDisk(1)
+	if err != nil {
+		t.Fatalf("GridDisk failed: %v", err)
+	}
+	originI, originJ, err := LocalIJ(anchor.String(), anchor.String())
+	if err != nil {
+		t.Fatalf("LocalIJ failed for the anchor itself: %v", err)
+	}
+	for _, cell := range neighbors {
+		if cell == anchor {
+			continue
+		}
+		i, j, err := LocalIJ(anchor.String(), cell.String())
+		if err != nil {
+			t.Fatalf("LocalIJ failed for neighbor %s: %v", cell, err)
+		}
+		di, dj := i-originI, j-originJ
+		if di < -1 || di > 1 || dj < -1 || dj > 1 || (di == 0 && dj == 0) {
+			t.Errorf("Neighbor %s: expected a unit IJ step from the anchor, got (%d, %d)", cell, di, dj)
+		}
+	}
+
+	// Distant cells have no local coordinates
+	if _, _, err := LocalIJ(anchor.String(), "88195da49bfffff"); err == nil {
+		t.Error("Expected error for a cell far from the anchor")
+	}
+	if _, _, err := LocalIJ("invalid", anchor.String()); err == nil {
+		t.Error("Expected error for invalid anchor")
+	}
+}
+
+func TestVertexIndexes(t *testing.T) {
+	cell := h3.Cell(h3.IndexFromString("882a107289fffff"))
+	vertexes, err := VertexIndexes(cell.String())
+	if err != nil || len(vertexes) != 6 {
+		t.Fatalf("Expected 6 vertexes of a hexagon, got %v (%v)", vertexes, err)
+	}
+	for _, vertex := range vertexes {
+		if !h3.IsValidVertex(h3.Cell(h3.IndexFromString(vertex))) {
+			t.Errorf("Expected a valid vertex index, got %s", vertex)
+		}
+	}
+
+	// Neighbors share the two vertexes of their common edge
+	neighbors, _ := cell.GridDisk(1)
+	for _, neighbor := range neighbors {
+		if neighbor == cell {
+			continue
+		}
+		other, err := VertexIndexes(neighbor.String())
+		if err != nil {
+			t.Fatalf("VertexIndexes failed for %s: %v", neighbor, err)
+		}
+		shared := 0
+		for _, a := range vertexes {
+			for _, b := range other {
+				if a == b {
+					shared++
+				}
+			}
+		}
+		if shared != 2 {
+			t.Errorf("Expected %s to share 2 vertexes with %s, got %d", neighbor, cell, shared)
+		}
+	}
+
+	if vertexes, err := VertexIndexes("8808000001fffff"); err != nil || len(vertexes) != 5 {
+		t.Errorf("Expected 5 vertexes of a pentagon, got %v (%v)", vertexes, err)
+	}
+	if _, err := VertexIndexes("invalid"); err == nil {
+		t.Error("Expected error for invalid H3 index")
+	}
+}
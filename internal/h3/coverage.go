@@ -0,0 +1,87 @@
+package h3
+
+import (
+	"fmt"
+	"sort"
+
+	"csv-h3-tool/internal/geo"
+	"github.com/uber/h3-go/v4"
+)
+
+// Coverage tracks which H3 cells of an expected region received at least one point.
+// Memory is bounded by the number of cells in the region.
+type Coverage struct {
+	covered map[string]bool // Region cell -> whether a point fell in it
+	count   int             // Number of covered cells
+}
+
+// NewCoverage polyfills the polygons at the given resolution; a cell belongs to the
+// region when its center lies inside a polygon (and outside its holes)
+func NewCoverage(polygons []geo.Polygon, resolution int) (*Coverage, error) {
+	if resolution < 0 || resolution > 15 {
+		return nil, fmt.Errorf("resolution %d is out of valid range [0, 15]", resolution)
+	}
+
+	coverage := &Coverage{covered: make(map[string]bool)}
+	for i, polygon := range polygons {
+		geoPolygon := h3.GeoPolygon{GeoLoop: geoLoop(polygon[0])}
+		for _, hole := range polygon[1:] {
+			geoPolygon.Holes = append(geoPolygon.Holes, geoLoop(hole))
+		}
+		cells, err := h3.PolygonToCells(geoPolygon, resolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to polyfill polygon %d: %w", i, err)
+		}
+		for _, cell := range cells {
+			coverage.covered[cell.String()] = false
+		}
+	}
+	if len(coverage.covered) == 0 {
+		return nil, fmt.Errorf("region contains no H3 cells at resolution %d; use a finer resolution", resolution)
+	}
+	return coverage, nil
+}
+
+// geoLoop converts a ring of [lng, lat] vertices to an H3 loop
+func geoLoop(ring [][2]float64) h3.GeoLoop {
+	loop := make(h3.GeoLoop, len(ring))
+	for i, vertex := range ring {
+		loop[i] = h3.NewLatLng(vertex[1], vertex[0])
+	}
+	return loop
+}
+
+// Add records a point in the given cell; cells outside the region are ignored
+func (c *Coverage) Add(index string) {
+	if covered, inRegion := c.covered[index]; inRegion && !covered {
+		c.covered[index] = true
+		c.count++
+	}
+}
+
+// Cells returns the number of cells in the region
+func (c *Coverage) Cells() int {
+	return len(c.covered)
+}
+
+// Covered returns the number of region cells with at least one point
+func (c *Coverage) Covered() int {
+	return c.count
+}
+
+// Fraction returns the fraction of region cells with at least one point
+func (c *Coverage) Fraction() float64 {
+	return float64(c.count) / float64(len(c.covered))
+}
+
+// EmptyCells returns the region cells without any point, in sorted order
+func (c *Coverage) EmptyCells() []string {
+	empty := make([]string, 0, len(c.covered)-c.count)
+	for cell, covered := range c.covered {
+		if !covered {
+			empty = append(empty, cell)
+		}
+	}
+	sort.Strings(empty)
+	return empty
+}
@@ -0,0 +1,58 @@
+package h3
+
+import (
+	"testing"
+
+	"csv-h3-tool/internal/geo"
+)
+
+func TestCoverage(t *testing.T) {
+	// A small square around lower Manhattan
+	square := geo.Polygon{{{-74.03, 40.69}, {-73.98, 40.69}, {-73.98, 40.73}, {-74.03, 40.73}, {-74.03, 40.69}}}
+	coverage, err := NewCoverage([]geo.Polygon{square}, 7)
+	if err != nil {
+		t.Fatalf("NewCoverage() error = %v", err)
+	}
+	if coverage.Cells() == 0 {
+		t.Fatal("Expected the region to contain cells")
+	}
+
+	generator := NewH3Generator()
+	inside, err := generator.Generate(40.7128, -74.0060, 7)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	outside, err := generator.Generate(51.5074, -0.1278, 7)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	coverage.Add(inside)
+	coverage.Add(inside)
+	coverage.Add(outside)
+
+	if coverage.Covered() != 1 {
+		t.Errorf("Expected 1 covered cell, got %d", coverage.Covered())
+	}
+	empty := coverage.EmptyCells()
+	if len(empty) != coverage.Cells()-1 {
+		t.Errorf("Expected %d empty cells, got %d", coverage.Cells()-1, len(empty))
+	}
+	for _, cell := range empty {
+		if cell == inside {
+			t.Errorf("Covered cell %s listed as empty", cell)
+		}
+	}
+	if want := 1 / float64(coverage.Cells()); coverage.Fraction() != want {
+		t.Errorf("Expected fraction %v, got %v", want, coverage.Fraction())
+	}
+}
+
+func TestNewCoverage_Errors(t *testing.T) {
+	tiny := geo.Polygon{{{0, 0}, {0.0001, 0}, {0.0001, 0.0001}, {0, 0}}}
+	if _, err := NewCoverage([]geo.Polygon{tiny}, 0); err == nil {
+		t.Error("NewCoverage() for a region without cells should error")
+	}
+	if _, err := NewCoverage([]geo.Polygon{tiny}, 16); err == nil {
+		t.Error("NewCoverage() with an invalid resolution should error")
+	}
+}
@@ -0,0 +1,73 @@
+package h3
+
+import (
+	"fmt"
+)
+
+// DensityCounter counts points per H3 cell to detect spatially isolated points.
+// Memory is bounded by the number of distinct occupied cells rather than the
+// number of records.
+type DensityCounter struct {
+	k      int
+	counts map[string]int
+	// neighborhoods caches k-ring totals once counting is finished
+	neighborhoods map[string]int
+}
+
+// NewDensityCounter creates an empty density counter using k-rings of radius k
+func NewDensityCounter(k int) *DensityCounter {
+	return &DensityCounter{
+		k:             k,
+		counts:        make(map[string]int),
+		neighborhoods: make(map[string]int),
+	}
+}
+
+// Add records one point in the given cell
+func (d *DensityCounter) Add(index string) {
+	d.counts[index]++
+}
+
+// Count returns the number of points recorded in the given cell
+func (d *DensityCounter) Count(index string) int {
+	return d.counts[index]
+}
+
+// Cells returns the number of distinct occupied cells
+func (d *DensityCounter) Cells() int {
+	return len(d.counts)
+}
+
+// NeighborhoodCount returns the number of points in the k-ring around the cell (including the cell itself).
+// Results are cached, so all points should be added before neighborhoods are queried.
+func (d *DensityCounter) NeighborhoodCount(index string) (int, error) {
+	if total, ok := d.neighborhoods[index]; ok {
+		return total, nil
+	}
+
+	cell, err := ParseCell(index)
+	if err != nil {
+		return 0, err
+	}
+
+	disk, err := cell.GridDisk(d.k)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute k-ring for %s: %w", index, err)
+	}
+
+	total := 0
+	for _, neighbor := range disk {
+		total += d.counts[neighbor.String()]
+	}
+	d.neighborhoods[index] = total
+	return total, nil
+}
+
+// IsOutlier reports whether the cell's k-ring neighborhood holds fewer than minPoints points
+func (d *DensityCounter) IsOutlier(index string, minPoints int) (bool, error) {
+	total, err := d.NeighborhoodCount(index)
+	if err != nil {
+		return false, err
+	}
+	return total < minPoints, nil
+}
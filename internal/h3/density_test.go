@@ -0,0 +1,66 @@
+package h3
+
+import (
+	"testing"
+)
+
+func TestDensityCounter(t *testing.T) {
+	generator := NewH3Generator()
+
+	// Cluster of points in Manhattan plus one isolated point in the Atlantic
+	cluster := [][2]float64{
+		{40.7128, -74.0060},
+		{40.7130, -74.0062},
+		{40.7126, -74.0058},
+		{40.7129, -74.0061},
+	}
+	isolated := [2]float64{35.0, -50.0}
+
+	density := NewDensityCounter(1)
+	var clusterCell string
+	for _, point := range cluster {
+		cell, err := generator.Generate(point[0], point[1], ResolutionStreet)
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		density.Add(cell)
+		clusterCell = cell
+	}
+	isolatedCell, err := generator.Generate(isolated[0], isolated[1], ResolutionStreet)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	density.Add(isolatedCell)
+
+	if density.Count(isolatedCell) != 1 {
+		t.Errorf("Expected 1 point in isolated cell, got %d", density.Count(isolatedCell))
+	}
+
+	total, err := density.NeighborhoodCount(clusterCell)
+	if err != nil {
+		t.Fatalf("NeighborhoodCount failed: %v", err)
+	}
+	if total != len(cluster) {
+		t.Errorf("Expected %d points in cluster neighborhood, got %d", len(cluster), total)
+	}
+
+	outlier, err := density.IsOutlier(clusterCell, 3)
+	if err != nil {
+		t.Fatalf("IsOutlier failed: %v", err)
+	}
+	if outlier {
+		t.Error("Cluster cell should not be an outlier")
+	}
+
+	outlier, err = density.IsOutlier(isolatedCell, 3)
+	if err != nil {
+		t.Fatalf("IsOutlier failed: %v", err)
+	}
+	if !outlier {
+		t.Error("Isolated cell should be an outlier")
+	}
+
+	if _, err := density.IsOutlier("not-a-cell", 3); err == nil {
+		t.Error("Expected error for invalid H3 index")
+	}
+}
@@ -75,6 +75,12 @@ func (g *H3Generator) Generate(lat, lng float64, resolution H3Resolution) (strin
 		return "", fmt.Errorf("coordinate validation failed: %w", err)
 	}
 
+	return g.GenerateUnsafe(lat, lng, resolution)
+}
+
+// GenerateUnsafe creates an H3 index like Generate without validating the coordinates,
+// for callers that have already validated them
+func (g *H3Generator) GenerateUnsafe(lat, lng float64, resolution H3Resolution) (string, error) {
 	// Validate resolution
 	if err := g.ValidateResolution(resolution); err != nil {
 		return "", fmt.Errorf("resolution validation failed: %w", err)
@@ -1,6 +1,7 @@
 package h3
 
 import (
+	"strings"
 	"testing"
 	"csv-h3-tool/internal/validator"
 )
@@ -539,6 +540,60 @@ func TestH3GeneratorConsistency(t *testing.T) {
 	}
 }
 
+// TestH3GeneratorGenerateUnsafe tests that GenerateUnsafe matches Generate without
+// validating coordinates
+func TestH3GeneratorGenerateUnsafe(t *testing.T) {
+	generator := NewH3Generator()
+
+	expected, err := generator.Generate(37.7749, -122.4194, ResolutionStreet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	index, err := generator.GenerateUnsafe(37.7749, -122.4194, ResolutionStreet)
+	if err != nil || index != expected {
+		t.Errorf("expected %s, got %s (%v)", expected, index, err)
+	}
+
+	if _, err := generator.Generate(95, 0, ResolutionStreet); err == nil {
+		t.Error("expected Generate to reject an out of range latitude")
+	}
+	if _, err := generator.GenerateUnsafe(95, 0, ResolutionStreet); err != nil && strings.Contains(err.Error(), "coordinate validation") {
+		t.Errorf("expected GenerateUnsafe to skip coordinate validation, got %v", err)
+	}
+	if _, err := generator.GenerateUnsafe(37.7749, -122.4194, H3Resolution(16)); err == nil {
+		t.Error("expected GenerateUnsafe to reject an invalid resolution")
+	}
+}
+
+// BenchmarkGenerateValidated compares indexing coordinates that were already validated
+// (as the streaming processor does) with Generate, which validates them a second time,
+// and with GenerateUnsafe, which does not
+func BenchmarkGenerateValidated(b *testing.B) {
+	generator := NewH3Generator()
+	coordinateValidator := validator.NewCoordinateValidator()
+	lats, lngs := make([]float64, 1024), make([]float64, 1024)
+	for i := range lats {
+		lats[i], lngs[i] = float64(i%180)-89.5, float64(i%360)-179.5
+	}
+
+	b.Run("Generate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			j := i % len(lats)
+			if coordinateValidator.ValidateCoordinates(lats[j], lngs[j]) == nil {
+				generator.Generate(lats[j], lngs[j], ResolutionStreet)
+			}
+		}
+	})
+	b.Run("GenerateUnsafe", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			j := i % len(lats)
+			if coordinateValidator.ValidateCoordinates(lats[j], lngs[j]) == nil {
+				generator.GenerateUnsafe(lats[j], lngs[j], ResolutionStreet)
+			}
+		}
+	})
+}
+
 // TestH3GeneratorResolutionLevels tests different resolution levels produce different indexes
 func TestH3GeneratorResolutionLevels(t *testing.T) {
 	generator := NewH3Generator()
@@ -0,0 +1,93 @@
+package h3
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/uber/h3-go/v4"
+)
+
+// BindingModule is the Go module providing the H3 binding
+const BindingModule = "github.com/uber/h3-go/v4"
+
+// coreVersion is the H3 C library version bundled with the h3-go release in go.mod.
+// Update it together with the h3-go dependency.
+const coreVersion = "4.2.1"
+
+// LibraryInfo identifies the H3 implementation that produces indexes
+type LibraryInfo struct {
+	Binding        string `json:"binding"`
+	BindingVersion string `json:"binding_version"`
+	CoreVersion    string `json:"core_version"`
+}
+
+// ResolutionInfo holds H3 metadata for one resolution level
+type ResolutionInfo struct {
+	Resolution           int     `json:"resolution"`
+	AvgHexagonAreaKm2    float64 `json:"avg_hexagon_area_km2"`
+	AvgHexagonEdgeLength float64 `json:"avg_hexagon_edge_length_km"`
+	NumCells             int     `json:"num_cells"`
+	NumPentagons         int     `json:"num_pentagons"`
+}
+
+// Library returns the H3 binding and core library versions in use
+func Library() LibraryInfo {
+	info := LibraryInfo{Binding: BindingModule, BindingVersion: "unknown", CoreVersion: coreVersion}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range build.Deps {
+			if dep.Path == BindingModule {
+				info.BindingVersion = dep.Version
+				if dep.Replace != nil {
+					info.BindingVersion = dep.Replace.Version
+				}
+				break
+			}
+		}
+	}
+	return info
+}
+
+// AverageEdgeLengthMeters returns the average hexagon edge length at a resolution in meters
+func AverageEdgeLengthMeters(resolution int) (float64, error) {
+	edge, err := h3.HexagonEdgeLengthAvgM(resolution)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get average edge length for resolution %d: %w", resolution, err)
+	}
+	return edge, nil
+}
+
+// AverageEdgeLengthKm returns the average hexagon edge length at a resolution in kilometers
+func AverageEdgeLengthKm(resolution int) (float64, error) {
+	edge, err := h3.HexagonEdgeLengthAvgKm(resolution)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get average edge length for resolution %d: %w", resolution, err)
+	}
+	return edge, nil
+}
+
+// Resolutions returns cell area, edge length, and cell count metadata for all resolutions
+func Resolutions() ([]ResolutionInfo, error) {
+	infos := make([]ResolutionInfo, 0, 16)
+	for res := 0; res <= 15; res++ {
+		area, err := h3.HexagonAreaAvgKm2(res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get average area for resolution %d: %w", res, err)
+		}
+		edge, err := h3.HexagonEdgeLengthAvgKm(res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get average edge length for resolution %d: %w", res, err)
+		}
+		pentagons, err := h3.Pentagons(res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pentagons for resolution %d: %w", res, err)
+		}
+		infos = append(infos, ResolutionInfo{
+			Resolution:           res,
+			AvgHexagonAreaKm2:    area,
+			AvgHexagonEdgeLength: edge,
+			NumCells:             h3.NumCells(res),
+			NumPentagons:         len(pentagons),
+		})
+	}
+	return infos, nil
+}
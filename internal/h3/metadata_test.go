@@ -0,0 +1,35 @@
+package h3
+
+import "testing"
+
+func TestResolutions(t *testing.T) {
+	infos, err := Resolutions()
+	if err != nil {
+		t.Fatalf("Resolutions failed: %v", err)
+	}
+	if len(infos) != 16 {
+		t.Fatalf("Expected 16 resolutions, got %d", len(infos))
+	}
+
+	if infos[0].NumCells != 122 {
+		t.Errorf("Expected 122 cells at resolution 0, got %d", infos[0].NumCells)
+	}
+	for i, info := range infos {
+		if info.Resolution != i {
+			t.Errorf("Expected resolution %d, got %d", i, info.Resolution)
+		}
+		if info.NumPentagons != 12 {
+			t.Errorf("Resolution %d: expected 12 pentagons, got %d", i, info.NumPentagons)
+		}
+		if i > 0 && (info.AvgHexagonAreaKm2 >= infos[i-1].AvgHexagonAreaKm2 || info.NumCells <= infos[i-1].NumCells) {
+			t.Errorf("Resolution %d: expected finer cells than resolution %d", i, i-1)
+		}
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	info := Library()
+	if info.Binding != BindingModule || info.CoreVersion == "" || info.BindingVersion == "" {
+		t.Errorf("Unexpected library info: %+v", info)
+	}
+}
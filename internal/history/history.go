@@ -0,0 +1,148 @@
+// Package history keeps a small local record of recent runs so analysts can look up
+// what was processed, with which arguments, and with what result.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"csv-h3-tool/internal/filehandler"
+	"csv-h3-tool/internal/logging"
+)
+
+// DefaultLimit is the number of runs kept in the history file
+const DefaultLimit = 100
+
+// lockTimeout bounds how long Record waits for another process recording a run
+const lockTimeout = 10 * time.Second
+
+// Run is a single recorded invocation
+type Run struct {
+	ID int `json:"id"`
+	logging.AuditEntry
+}
+
+// historyData is the on-disk representation of the history
+type historyData struct {
+	NextID int   `json:"next_id"`
+	Runs   []Run `json:"runs"`
+}
+
+// DefaultFile returns the history file path, ~/.csvh3/history.json
+func DefaultFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".csvh3", "history.json")
+	}
+	return filepath.Join(home, ".csvh3", "history.json")
+}
+
+// Load returns the recorded runs at path, oldest first. A missing file is an empty history.
+func Load(path string) ([]Run, error) {
+	data, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	return data.Runs, nil
+}
+
+// Find returns the run with the given ID
+func Find(path string, id int) (Run, bool, error) {
+	runs, err := Load(path)
+	if err != nil {
+		return Run{}, false, err
+	}
+	for _, run := range runs {
+		if run.ID == id {
+			return run, true, nil
+		}
+	}
+	return Run{}, false, nil
+}
+
+// Record appends the entry to the history at path, keeping only the most recent
+// limit runs (DefaultLimit if limit <= 0), and returns the ID assigned to it. The
+// values of secret flags are redacted from the recorded arguments, and the history
+// is locked while it is updated so concurrent runs do not drop each other's entries.
+func Record(path string, entry *logging.AuditEntry, limit int) (int, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create run history directory: %w", err)
+	}
+	lock, err := filehandler.Lock(path+".lock", lockTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock run history %s: %w", path, err)
+	}
+	defer lock.Unlock()
+
+	data, err := load(path)
+	if err != nil {
+		return 0, err
+	}
+
+	run := Run{ID: data.NextID, AuditEntry: *entry}
+	run.Args = logging.RedactArgs(entry.Args)
+	id := run.ID
+	data.NextID++
+	data.Runs = append(data.Runs, run)
+	if len(data.Runs) > limit {
+		data.Runs = data.Runs[len(data.Runs)-limit:]
+	}
+
+	if err := save(path, data); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// load reads the history file, returning an empty history if it does not exist
+func load(path string) (historyData, error) {
+	data := historyData{NextID: 1}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return data, fmt.Errorf("failed to read run history %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return data, fmt.Errorf("failed to parse run history %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// save atomically writes the history to disk, creating its directory if needed
+func save(path string, data historyData) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run history: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create run history directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".history-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace run history %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package history
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"csv-h3-tool/internal/logging"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.json")
+
+	runs, err := Load(path)
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("Expected an empty history, got %v, %v", runs, err)
+	}
+
+	for i := 0; i < 4; i++ {
+		entry := logging.NewAuditEntry([]string{"input.csv"}, "test")
+		entry.SetCounts(i, i, 0)
+		if i == 3 {
+			entry.Finish(errors.New("boom"))
+		} else {
+			entry.Finish(nil)
+		}
+		id, err := Record(path, entry, 3)
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if id != i+1 {
+			t.Errorf("Expected ID %d, got %d", i+1, id)
+		}
+	}
+
+	runs, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(runs) != 3 || runs[0].ID != 2 || runs[2].ID != 4 {
+		t.Fatalf("Expected runs 2-4, got %+v", runs)
+	}
+
+	run, ok, err := Find(path, 4)
+	if err != nil || !ok {
+		t.Fatalf("Expected to find run 4: %v", err)
+	}
+	if run.Status != "failure" || run.Error != "boom" || run.TotalRecords != 3 {
+		t.Errorf("Unexpected run: %+v", run)
+	}
+	if _, ok, _ := Find(path, 1); ok {
+		t.Error("Expected run 1 to have been dropped")
+	}
+}
+
+func TestRecord_RedactsArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	entry := &logging.AuditEntry{Args: []string{"input.csv", "--db-url=postgres://user:secret@db/gis", "--key", "hunter2"}}
+	entry.Finish(nil)
+
+	if _, err := Record(path, entry, 0); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	runs, err := Load(path)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("Expected one run, got %v, %v", runs, err)
+	}
+	args := strings.Join(runs[0].Args, " ")
+	if strings.Contains(args, "secret") || strings.Contains(args, "hunter2") {
+		t.Errorf("Expected secret flag values to be redacted, got %q", args)
+	}
+}
+
+func TestRecord_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry := logging.NewAuditEntry([]string{"input.csv"}, "test")
+			entry.Finish(nil)
+			if _, err := Record(path, entry, 0); err != nil {
+				t.Errorf("Record failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	runs, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(runs) != 8 {
+		t.Errorf("Expected every concurrent run to be recorded, got %d", len(runs))
+	}
+}
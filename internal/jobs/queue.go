@@ -0,0 +1,291 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"csv-h3-tool/internal/filehandler"
+)
+
+// DefaultQueueFile is the queue file used when no path is given
+const DefaultQueueFile = ".csv-h3-jobs.json"
+
+// lockTimeout bounds how long a queue change waits for another process changing the queue
+const lockTimeout = 10 * time.Second
+
+// Status is the lifecycle state of a job
+type Status string
+
+const (
+	StatusQueued   Status = "queued"
+	StatusRunning  Status = "running"
+	StatusDone     Status = "done"
+	StatusFailed   Status = "failed"
+	StatusCanceled Status = "canceled"
+)
+
+// Job is a single input file queued for processing
+type Job struct {
+	ID        int       `json:"id"`
+	InputFile string    `json:"input_file"`
+	Status    Status    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Queue is a persistent job queue stored in a single JSON file. Every change is
+// written atomically (temp file + rename) so queued and in-flight jobs survive restarts,
+// and is made under a lock on the queue file against the latest queue on disk, so
+// processes sharing the queue do not overwrite each other's changes.
+type Queue struct {
+	path   string
+	mu     sync.Mutex
+	data   queueData
+	worker *filehandler.FileLock // Held by the worker for as long as the queue is open
+}
+
+// queueData is the on-disk representation of the queue
+type queueData struct {
+	NextID int    `json:"next_id"`
+	Jobs   []*Job `json:"jobs"`
+}
+
+// Open loads the queue at path, creating an empty queue if the file does not exist.
+// Jobs are left as they are on disk; see OpenWorker.
+func Open(path string) (*Queue, error) {
+	q := &Queue{path: path}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// OpenWorker opens the queue at path for the worker processing its jobs. Only one
+// worker can have a queue open: the worker holds an exclusive lock on the queue until
+// Close. Holding it, jobs still marked running were left by a worker that stopped
+// and are re-queued.
+func OpenWorker(path string) (*Queue, error) {
+	worker, err := filehandler.TryLock(path + ".worker.lock")
+	if err == filehandler.ErrLocked {
+		return nil, fmt.Errorf("job queue %s is in use by another worker", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{path: path, worker: worker}
+	err = q.update(func() error {
+		for _, job := range q.data.Jobs {
+			if job.Status == StatusRunning {
+				job.Status = StatusQueued
+				job.UpdatedAt = time.Now()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		worker.Unlock()
+		return nil, err
+	}
+	return q, nil
+}
+
+// Close releases the worker lock of a queue opened with OpenWorker
+func (q *Queue) Close() error {
+	if q.worker == nil {
+		return nil
+	}
+	err := q.worker.Unlock()
+	q.worker = nil
+	return err
+}
+
+// Enqueue adds a new queued job for inputFile
+func (q *Queue) Enqueue(inputFile string) (job Job, err error) {
+	err = q.update(func() error {
+		now := time.Now()
+		added := &Job{ID: q.data.NextID, InputFile: inputFile, Status: StatusQueued, CreatedAt: now, UpdatedAt: now}
+		q.data.NextID++
+		q.data.Jobs = append(q.data.Jobs, added)
+		job = *added
+		return nil
+	})
+	return job, err
+}
+
+// Next claims the oldest queued job and marks it running. ok is false when no job is queued.
+func (q *Queue) Next() (job Job, ok bool, err error) {
+	err = q.update(func() error {
+		for _, j := range q.data.Jobs {
+			if j.Status == StatusQueued {
+				j.Status = StatusRunning
+				j.Attempts++
+				j.Error = ""
+				j.UpdatedAt = time.Now()
+				job, ok = *j, true
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Job{}, false, err
+	}
+	return job, ok, nil
+}
+
+// Complete marks a running job as done
+func (q *Queue) Complete(id int) error {
+	return q.transition(id, StatusDone, "", StatusRunning)
+}
+
+// Fail marks a running job as failed with the given error
+func (q *Queue) Fail(id int, jobErr error) error {
+	message := ""
+	if jobErr != nil {
+		message = jobErr.Error()
+	}
+	return q.transition(id, StatusFailed, message, StatusRunning)
+}
+
+// Cancel cancels a queued or running job
+func (q *Queue) Cancel(id int) error {
+	return q.transition(id, StatusCanceled, "", StatusQueued, StatusRunning)
+}
+
+// Retry re-queues a failed or canceled job
+func (q *Queue) Retry(id int) error {
+	return q.transition(id, StatusQueued, "", StatusFailed, StatusCanceled)
+}
+
+// Get returns the job with the given ID
+func (q *Queue) Get(id int) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job := q.find(id); job != nil {
+		return *job, true
+	}
+	return Job{}, false
+}
+
+// List returns a snapshot of all jobs in creation order
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]Job, len(q.data.Jobs))
+	for i, job := range q.data.Jobs {
+		jobs[i] = *job
+	}
+	return jobs
+}
+
+// transition moves a job to a new status if its current status is one of from
+func (q *Queue) transition(id int, to Status, message string, from ...Status) error {
+	return q.update(func() error {
+		job := q.find(id)
+		if job == nil {
+			return fmt.Errorf("job %d not found", id)
+		}
+
+		allowed := false
+		for _, status := range from {
+			if job.Status == status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("job %d is %s and cannot be marked %s", id, job.Status, to)
+		}
+
+		job.Status = to
+		job.Error = message
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// update applies a change to the latest queue on disk under the queue file lock and
+// saves it; nothing is saved if change fails
+func (q *Queue) update(change func() error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	lock, err := filehandler.Lock(q.path+".lock", lockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock job queue %s: %w", q.path, err)
+	}
+	defer lock.Unlock()
+
+	if err := q.load(); err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	return q.save()
+}
+
+// load reads the queue from disk, or starts an empty queue if the file does not exist
+func (q *Queue) load() error {
+	q.data = queueData{NextID: 1}
+	content, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read job queue %s: %w", q.path, err)
+	}
+	if err := json.Unmarshal(content, &q.data); err != nil {
+		return fmt.Errorf("failed to parse job queue %s: %w", q.path, err)
+	}
+	return nil
+}
+
+// find returns the job with the given ID or nil
+func (q *Queue) find(id int) *Job {
+	for _, job := range q.data.Jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// save atomically writes the queue to disk
+func (q *Queue) save() error {
+	content, err := json.MarshalIndent(q.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode job queue: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".jobs-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write job queue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write job queue: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync job queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write job queue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return fmt.Errorf("failed to replace job queue %s: %w", q.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueue_Lifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	q, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	for _, name := range []string{"a.csv", "b.csv"} {
+		if _, err := q.Enqueue(name); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	job, ok, err := q.Next()
+	if err != nil || !ok {
+		t.Fatalf("Next failed: ok=%v err=%v", ok, err)
+	}
+	if job.InputFile != "a.csv" || job.Status != StatusRunning || job.Attempts != 1 {
+		t.Errorf("Unexpected claimed job: %+v", job)
+	}
+
+	if err := q.Fail(job.ID, fmt.Errorf("disk full")); err != nil {
+		t.Fatalf("Fail failed: %v", err)
+	}
+	if err := q.Complete(job.ID); err == nil {
+		t.Error("Expected error completing a failed job")
+	}
+	if err := q.Retry(job.ID); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if err := q.Cancel(2); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if err := q.Cancel(42); err == nil {
+		t.Error("Expected error canceling unknown job")
+	}
+
+	job, ok, _ = q.Next()
+	if !ok || job.ID != 1 || job.Attempts != 2 || job.Error != "" {
+		t.Errorf("Expected retried job 1 on second attempt, got %+v", job)
+	}
+	if err := q.Complete(job.ID); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if _, ok, _ := q.Next(); ok {
+		t.Error("Expected no queued jobs")
+	}
+}
+
+func TestQueue_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	q, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	q.Enqueue("a.csv")
+	q.Enqueue("b.csv")
+	if _, _, err := q.Next(); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+
+	// Opening the queue to inspect it leaves the in-flight job alone
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	if job, _ := reopened.Get(1); job.Status != StatusRunning {
+		t.Errorf("Expected job 1 to stay running when the queue is opened, got %s", job.Status)
+	}
+
+	// A worker starting up after a crash with job 1 in flight re-queues it
+	worker, err := OpenWorker(path)
+	if err != nil {
+		t.Fatalf("OpenWorker failed: %v", err)
+	}
+	defer worker.Close()
+	jobs := worker.List()
+	if len(jobs) != 2 {
+		t.Fatalf("Expected 2 jobs after restart, got %d", len(jobs))
+	}
+	for _, job := range jobs {
+		if job.Status != StatusQueued {
+			t.Errorf("Expected job %d to be queued after restart, got %s", job.ID, job.Status)
+		}
+	}
+	if _, err := OpenWorker(path); err == nil {
+		t.Error("Expected a second worker to be refused while the first has the queue open")
+	}
+
+	job, _ := worker.Enqueue("c.csv")
+	if job.ID != 3 {
+		t.Errorf("Expected new job ID 3, got %d", job.ID)
+	}
+}
+
+func TestQueue_SharedBetweenProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	worker, err := OpenWorker(path)
+	if err != nil {
+		t.Fatalf("OpenWorker failed: %v", err)
+	}
+	defer worker.Close()
+	worker.Enqueue("a.csv")
+	worker.Enqueue("b.csv")
+
+	// A job canceled by another process is not claimed or overwritten by the worker
+	other, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := other.Cancel(1); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	job, ok, err := worker.Next()
+	if err != nil || !ok || job.ID != 2 {
+		t.Fatalf("Expected the worker to claim job 2, got %+v, %v, %v", job, ok, err)
+	}
+	if canceled, _ := worker.Get(1); canceled.Status != StatusCanceled {
+		t.Errorf("Expected job 1 to stay canceled, got %s", canceled.Status)
+	}
+}
@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces the values of secret-bearing flags in recorded arguments
+const RedactedValue = "[REDACTED]"
+
+// secretFlags are the flags whose values can carry credentials: connection and webhook
+// URLs, and signing keys
+var secretFlags = map[string]bool{
+	"--db-url":         true,
+	"--redis-sink":     true,
+	"--key":            true,
+	"--notify-webhook": true,
+	"--geocoder-url":   true,
+}
+
+// RedactArgs returns a copy of command line arguments with the values of secret-bearing
+// flags replaced, in both the "--flag value" and "--flag=value" forms
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i < len(redacted); i++ {
+		arg := redacted[i]
+		if arg == "--" {
+			break
+		}
+		if name, _, ok := strings.Cut(arg, "="); ok && secretFlags[name] {
+			redacted[i] = name + "=" + RedactedValue
+		} else if secretFlags[arg] && i+1 < len(redacted) {
+			i++
+			redacted[i] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+// AuditEntry is a structured record of a single tool invocation
+type AuditEntry struct {
+	StartedAt      time.Time `json:"started_at"`
+	User           string    `json:"user"`
+	Hostname       string    `json:"hostname"`
+	PID            int       `json:"pid"`
+	Version        string    `json:"version"`
+	Args           []string  `json:"args"`
+	InputFiles     []string  `json:"input_files,omitempty"`
+	OutputFile     string    `json:"output_file,omitempty"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	TotalRecords   int       `json:"total_records"`
+	ValidRecords   int       `json:"valid_records"`
+	InvalidRecords int       `json:"invalid_records"`
+	DurationMS     int64     `json:"duration_ms"`
+	RecordCategories
+}
+
+// RecordCategories breaks the records of a run down by why they were skipped or
+// invalid, together with the bytes read and written
+type RecordCategories struct {
+	MalformedRows       int   `json:"malformed_rows"`        // Rows that could not be parsed or lack the coordinate columns
+	EmptyCoordinateRows int   `json:"empty_coordinate_rows"` // Records with an empty latitude or longitude
+	UnparseableRows     int   `json:"unparseable_rows"`      // Records with a coordinate that is not a number
+	OutOfRangeRows      int   `json:"out_of_range_rows"`     // Records with coordinates outside the valid range
+	H3FailureRows       int   `json:"h3_failure_rows"`       // Records whose H3 index could not be generated
+	LookupFailureRows   int   `json:"lookup_failure_rows"`   // Records whose coordinate lookup (geocoding) failed
+	BytesRead           int64 `json:"bytes_read"`            // Input bytes read (files only)
+	BytesWritten        int64 `json:"bytes_written"`         // Output bytes written (single CSV output only)
+}
+
+// Add adds the counts of another run, e.g. to combine the files of a batch
+func (c *RecordCategories) Add(other RecordCategories) {
+	c.MalformedRows += other.MalformedRows
+	c.EmptyCoordinateRows += other.EmptyCoordinateRows
+	c.UnparseableRows += other.UnparseableRows
+	c.OutOfRangeRows += other.OutOfRangeRows
+	c.H3FailureRows += other.H3FailureRows
+	c.LookupFailureRows += other.LookupFailureRows
+	c.BytesRead += other.BytesRead
+	c.BytesWritten += other.BytesWritten
+}
+
+// NewAuditEntry starts an audit entry for an invocation with the given arguments,
+// redacting the values of secret-bearing flags
+func NewAuditEntry(args []string, version string) *AuditEntry {
+	entry := &AuditEntry{
+		StartedAt: time.Now(),
+		User:      currentUser(),
+		PID:       os.Getpid(),
+		Version:   version,
+		Args:      RedactArgs(args),
+	}
+	entry.Hostname, _ = os.Hostname()
+	return entry
+}
+
+// SetCounts records the record counts of the run
+func (e *AuditEntry) SetCounts(total, valid, invalid int) {
+	e.TotalRecords = total
+	e.ValidRecords = valid
+	e.InvalidRecords = invalid
+}
+
+// Finish records the outcome and duration of the run
+func (e *AuditEntry) Finish(err error) {
+	e.DurationMS = time.Since(e.StartedAt).Milliseconds()
+	e.Status = "success"
+	e.Error = ""
+	if err != nil {
+		e.Status = "failure"
+		e.Error = err.Error()
+	}
+}
+
+// AppendAuditEntry appends the entry as a single JSON line to the audit log at path
+func AppendAuditEntry(path string, entry *AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+
+	// A single write keeps lines from concurrent invocations intact
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append to audit log %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// currentUser returns the name of the user running the tool
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
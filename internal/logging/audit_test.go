@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAppendAuditEntry tests that audit entries are appended as JSON lines
+func TestAppendAuditEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	first := NewAuditEntry([]string{"data.csv", "-r", "9"}, "1.0.0")
+	first.SetCounts(10, 8, 2)
+	first.RecordCategories = RecordCategories{MalformedRows: 1, OutOfRangeRows: 2, BytesRead: 512}
+	first.Finish(nil)
+
+	second := NewAuditEntry([]string{"missing.csv"}, "1.0.0")
+	second.Finish(fmt.Errorf("input file does not exist"))
+
+	for _, entry := range []*AuditEntry{first, second} {
+		if err := AppendAuditEntry(path, entry); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+	if entries[0].Status != "success" || entries[0].TotalRecords != 10 || entries[0].InvalidRecords != 2 {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[0].MalformedRows != 1 || entries[0].OutOfRangeRows != 2 || entries[0].BytesRead != 512 {
+		t.Errorf("Expected record categories in first entry: %+v", entries[0].RecordCategories)
+	}
+	if len(entries[0].Args) != 3 || entries[0].PID == 0 {
+		t.Errorf("Expected invocation details in first entry: %+v", entries[0])
+	}
+	if entries[1].Status != "failure" || entries[1].Error == "" {
+		t.Errorf("Expected failure with error in second entry: %+v", entries[1])
+	}
+}
+
+// TestRedactArgs tests that secret-bearing flag values are not recorded
+func TestRedactArgs(t *testing.T) {
+	args := []string{"data.csv", "--db-url", "postgres://user:secret@db/points", "-r", "9",
+		"--redis-sink=redis://:pw@cache:6379", "--key", "hunter2", "--notify-webhook", "https://hooks.example/T0/B0/xyz",
+		"--", "--key", "literal"}
+	expected := []string{"data.csv", "--db-url", RedactedValue, "-r", "9",
+		"--redis-sink=" + RedactedValue, "--key", RedactedValue, "--notify-webhook", RedactedValue,
+		"--", "--key", "literal"}
+
+	entry := NewAuditEntry(args, "1.0.0")
+	if fmt.Sprint(entry.Args) != fmt.Sprint(expected) {
+		t.Errorf("Expected redacted args %v, got %v", expected, entry.Args)
+	}
+	if args[2] != "postgres://user:secret@db/points" {
+		t.Errorf("Expected the original arguments to be left unchanged, got %v", args)
+	}
+}
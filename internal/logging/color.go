@@ -0,0 +1,42 @@
+package logging
+
+import "os"
+
+// Color is an ANSI terminal color
+type Color string
+
+const (
+	Green  Color = "\033[32m"
+	Yellow Color = "\033[33m"
+	Red    Color = "\033[31m"
+	reset        = "\033[0m"
+)
+
+// ColorEnabled reports whether output to file should be colorized: only on terminals,
+// and never when disabled (--no-color), NO_COLOR is set, or TERM is "dumb"
+func ColorEnabled(file *os.File, disabled bool) bool {
+	if disabled || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Colorize wraps s in the color when enabled
+func Colorize(enabled bool, color Color, s string) string {
+	if !enabled {
+		return s
+	}
+	return string(color) + s + reset
+}
+
+// levelColor returns the color highlighting a log level, or "" for none
+func levelColor(level LogLevel) Color {
+	switch level {
+	case LogLevelWarn:
+		return Yellow
+	case LogLevelError, LogLevelFatal:
+		return Red
+	}
+	return ""
+}
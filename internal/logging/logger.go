@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"csv-h3-tool/internal/errors"
@@ -38,83 +39,96 @@ func (l LogLevel) String() string {
 	}
 }
 
-// Logger provides structured logging functionality
-type Logger struct {
+// Logger is the logging interface used by the processing pipeline. StandardLogger
+// implements it; library users can inject their own implementation instead.
+type Logger interface {
+	Debug(message string, args ...interface{})
+	Info(message string, args ...interface{})
+	Warn(message string, args ...interface{})
+	Error(message string, args ...interface{})
+	LogError(err error)
+	Enabled(level LogLevel) bool
+}
+
+// StandardLogger provides structured logging functionality, writing entries at or
+// above its level to a sink
+type StandardLogger struct {
 	level      LogLevel
-	output     io.Writer
+	sink       Sink
 	prefix     string
 	verbose    bool
+	mu         *sync.Mutex // Guards the counters
 	errorCount int
 	warnCount  int
 }
 
-// NewLogger creates a new logger instance
-func NewLogger(level LogLevel, output io.Writer, verbose bool) *Logger {
+// NewLogger creates a new logger instance writing text lines to output (stderr when nil)
+func NewLogger(level LogLevel, output io.Writer, verbose bool) *StandardLogger {
 	if output == nil {
 		output = os.Stderr
 	}
-	
-	return &Logger{
+	return NewSinkLogger(level, NewTextSink(output), verbose)
+}
+
+// NewSinkLogger creates a logger writing to the given sink
+func NewSinkLogger(level LogLevel, sink Sink, verbose bool) *StandardLogger {
+	return &StandardLogger{
 		level:   level,
-		output:  output,
+		sink:    sink,
 		verbose: verbose,
+		mu:      &sync.Mutex{},
 	}
 }
 
 // NewDefaultLogger creates a logger with default settings
-func NewDefaultLogger(verbose bool) *Logger {
-	level := LogLevelInfo
+func NewDefaultLogger(verbose bool) *StandardLogger {
+	return NewLogger(DefaultLevel(verbose), os.Stderr, verbose)
+}
+
+// DefaultLevel returns the default minimum level: debug when verbose, info otherwise
+func DefaultLevel(verbose bool) LogLevel {
 	if verbose {
-		level = LogLevelDebug
+		return LogLevelDebug
 	}
-	return NewLogger(level, os.Stderr, verbose)
+	return LogLevelInfo
 }
 
 // SetLevel sets the minimum logging level
-func (l *Logger) SetLevel(level LogLevel) {
+func (l *StandardLogger) SetLevel(level LogLevel) {
 	l.level = level
 }
 
 // SetPrefix sets a prefix for all log messages
-func (l *Logger) SetPrefix(prefix string) {
+func (l *StandardLogger) SetPrefix(prefix string) {
 	l.prefix = prefix
 }
 
-// shouldLog checks if a message at the given level should be logged
-func (l *Logger) shouldLog(level LogLevel) bool {
+// Enabled reports whether messages at the given level are logged
+func (l *StandardLogger) Enabled(level LogLevel) bool {
 	return level >= l.level
 }
 
-// formatMessage formats a log message with timestamp and level
-func (l *Logger) formatMessage(level LogLevel, message string) string {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	prefix := ""
-	if l.prefix != "" {
-		prefix = fmt.Sprintf("[%s] ", l.prefix)
-	}
-	return fmt.Sprintf("%s %s%s: %s", timestamp, prefix, level.String(), message)
-}
-
 // log writes a message at the specified level
-func (l *Logger) log(level LogLevel, message string) {
-	if !l.shouldLog(level) {
+func (l *StandardLogger) log(level LogLevel, message string) {
+	if !l.Enabled(level) {
 		return
 	}
 	
-	formatted := l.formatMessage(level, message)
-	fmt.Fprintln(l.output, formatted)
+	l.sink.Write(Entry{Time: time.Now(), Level: level, Prefix: l.prefix, Message: message})
 	
 	// Update counters
+	l.mu.Lock()
 	switch level {
 	case LogLevelError, LogLevelFatal:
 		l.errorCount++
 	case LogLevelWarn:
 		l.warnCount++
 	}
+	l.mu.Unlock()
 }
 
 // Debug logs a debug message
-func (l *Logger) Debug(message string, args ...interface{}) {
+func (l *StandardLogger) Debug(message string, args ...interface{}) {
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
@@ -122,7 +136,7 @@ func (l *Logger) Debug(message string, args ...interface{}) {
 }
 
 // Info logs an info message
-func (l *Logger) Info(message string, args ...interface{}) {
+func (l *StandardLogger) Info(message string, args ...interface{}) {
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
@@ -130,7 +144,7 @@ func (l *Logger) Info(message string, args ...interface{}) {
 }
 
 // Warn logs a warning message
-func (l *Logger) Warn(message string, args ...interface{}) {
+func (l *StandardLogger) Warn(message string, args ...interface{}) {
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
@@ -138,7 +152,7 @@ func (l *Logger) Warn(message string, args ...interface{}) {
 }
 
 // Error logs an error message
-func (l *Logger) Error(message string, args ...interface{}) {
+func (l *StandardLogger) Error(message string, args ...interface{}) {
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
@@ -146,7 +160,7 @@ func (l *Logger) Error(message string, args ...interface{}) {
 }
 
 // Fatal logs a fatal message and exits
-func (l *Logger) Fatal(message string, args ...interface{}) {
+func (l *StandardLogger) Fatal(message string, args ...interface{}) {
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
@@ -155,7 +169,7 @@ func (l *Logger) Fatal(message string, args ...interface{}) {
 }
 
 // LogError logs an error with detailed context
-func (l *Logger) LogError(err error) {
+func (l *StandardLogger) LogError(err error) {
 	if err == nil {
 		return
 	}
@@ -187,26 +201,41 @@ func (l *Logger) LogError(err error) {
 }
 
 // LogSkippedRecord logs information about a skipped record
-func (l *Logger) LogSkippedRecord(line int, reason string, details ...string) {
+func (l *StandardLogger) LogSkippedRecord(line int, reason string, details ...string) {
+	logSkippedRecord(l, l.verbose, line, reason, details)
+}
+
+// logSkippedRecord logs a skipped record to any logger, with details when verbose
+func logSkippedRecord(l Logger, verbose bool, line int, reason string, details []string) {
 	message := fmt.Sprintf("Skipping record at line %d: %s", line, reason)
-	if len(details) > 0 && l.verbose {
+	if len(details) > 0 && verbose {
 		message += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
 	}
 	l.Warn(message)
 }
 
 // LogProcessingProgress logs processing progress
-func (l *Logger) LogProcessingProgress(processed, total int, stage string) {
+func (l *StandardLogger) LogProcessingProgress(processed, total int, stage string) {
 	if !l.verbose {
 		return
 	}
-	
+	logProgress(l, processed, total, stage)
+}
+
+// logProgress logs processing progress to any logger
+func logProgress(l Logger, processed, total int, stage string) {
 	percentage := float64(processed) / float64(total) * 100
 	l.Info("Processing progress: %d/%d (%.1f%%) - %s", processed, total, percentage, stage)
 }
 
 // LogProcessingSummary logs a summary of processing results
-func (l *Logger) LogProcessingSummary(total, valid, invalid int, duration time.Duration) {
+func (l *StandardLogger) LogProcessingSummary(total, valid, invalid int, duration time.Duration) {
+	LogProcessingSummary(l, total, valid, invalid, duration)
+}
+
+// LogProcessingSummary logs a summary of processing results to any logger, with the
+// error and warning counts when the logger keeps them
+func LogProcessingSummary(l Logger, total, valid, invalid int, duration time.Duration) {
 	l.Info("Processing completed in %v", duration)
 	l.Info("Total records: %d", total)
 	l.Info("Valid records: %d", valid)
@@ -214,37 +243,48 @@ func (l *Logger) LogProcessingSummary(total, valid, invalid int, duration time.D
 		l.Warn("Invalid records: %d", invalid)
 	}
 	
-	if l.errorCount > 0 || l.warnCount > 0 {
-		l.Info("Summary: %d errors, %d warnings", l.errorCount, l.warnCount)
+	if counter, ok := l.(interface {
+		GetErrorCount() int
+		GetWarnCount() int
+	}); ok && (counter.GetErrorCount() > 0 || counter.GetWarnCount() > 0) {
+		l.Info("Summary: %d errors, %d warnings", counter.GetErrorCount(), counter.GetWarnCount())
 	}
 }
 
 // GetErrorCount returns the number of errors logged
-func (l *Logger) GetErrorCount() int {
+func (l *StandardLogger) GetErrorCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.errorCount
 }
 
 // GetWarnCount returns the number of warnings logged
-func (l *Logger) GetWarnCount() int {
+func (l *StandardLogger) GetWarnCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.warnCount
 }
 
 // Reset resets the error and warning counters
-func (l *Logger) Reset() {
+func (l *StandardLogger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.errorCount = 0
 	l.warnCount = 0
 }
 
 // WithPrefix creates a new logger with the specified prefix
-func (l *Logger) WithPrefix(prefix string) *Logger {
+func (l *StandardLogger) WithPrefix(prefix string) *StandardLogger {
 	newLogger := *l
 	newLogger.prefix = prefix
+	newLogger.mu = &sync.Mutex{}
 	return &newLogger
 }
 
 // ProcessingLogger provides specialized logging for CSV processing
 type ProcessingLogger struct {
-	*Logger
+	Logger
+	warnings     *WarningLimiter // Limits repeated per-record warnings (nil = no limit)
 	fileName     string
 	totalRecords int
 	processed    int
@@ -253,7 +293,7 @@ type ProcessingLogger struct {
 }
 
 // NewProcessingLogger creates a logger specialized for processing operations
-func NewProcessingLogger(logger *Logger, fileName string, totalRecords int) *ProcessingLogger {
+func NewProcessingLogger(logger Logger, fileName string, totalRecords int) *ProcessingLogger {
 	return &ProcessingLogger{
 		Logger:         logger,
 		fileName:       fileName,
@@ -264,14 +304,21 @@ func NewProcessingLogger(logger *Logger, fileName string, totalRecords int) *Pro
 	}
 }
 
+// SetWarnLimit shows at most limit per-record warnings of each kind and summarizes
+// the rest on Complete (limit < 1 = no limit)
+func (pl *ProcessingLogger) SetWarnLimit(limit int) {
+	pl.warnings = NewWarningLimiter(limit)
+}
+
 // LogRecordProcessed logs that a record has been processed
 func (pl *ProcessingLogger) LogRecordProcessed(line int, valid bool, h3Index string) {
 	pl.processed++
 	
-	if pl.verbose {
+	verbose := pl.Enabled(LogLevelDebug)
+	if verbose {
 		if valid {
 			pl.Debug("Line %d: Generated H3 index %s", line, h3Index)
-		} else {
+		} else if pl.warnings.Allow("skipped invalid record") {
 			pl.Debug("Line %d: Skipped invalid record", line)
 		}
 	}
@@ -279,36 +326,58 @@ func (pl *ProcessingLogger) LogRecordProcessed(line int, valid bool, h3Index str
 	// Report progress periodically
 	now := time.Now()
 	if now.Sub(pl.lastReported) >= pl.reportInterval {
-		pl.LogProcessingProgress(pl.processed, pl.totalRecords, "processing records")
+		if verbose {
+			logProgress(pl, pl.processed, pl.totalRecords, "processing records")
+		}
 		pl.lastReported = now
 	}
 }
 
+// LogSkippedRecord logs information about a skipped record
+func (pl *ProcessingLogger) LogSkippedRecord(line int, reason string, details ...string) {
+	if !pl.warnings.Allow("skipped record: " + reason) {
+		return
+	}
+	logSkippedRecord(pl, pl.Enabled(LogLevelDebug), line, reason, details)
+}
+
 // LogCoordinateError logs a coordinate validation error with context
 func (pl *ProcessingLogger) LogCoordinateError(line int, lat, lng float64, field, reason string) {
+	if !pl.warnings.Allow("coordinate error: " + reason) {
+		return
+	}
 	err := errors.NewCoordinateError(lat, lng, line, field, reason)
 	pl.LogError(err)
 }
 
 // LogH3Error logs an H3 generation error with context
 func (pl *ProcessingLogger) LogH3Error(line int, lat, lng float64, resolution int, reason string, cause error) {
+	if !pl.warnings.Allow("H3 error: " + reason) {
+		return
+	}
 	err := errors.NewH3Error(lat, lng, resolution, line, reason, cause)
 	pl.LogError(err)
 }
 
 // LogCSVError logs a CSV parsing error with context
 func (pl *ProcessingLogger) LogCSVError(line, column int, field, value, reason string, cause error) {
+	if !pl.warnings.Allow("CSV error: " + reason) {
+		return
+	}
 	err := errors.NewCSVError(pl.fileName, line, column, field, value, reason, cause)
 	pl.LogError(err)
 }
 
 // Complete logs completion of processing
 func (pl *ProcessingLogger) Complete(duration time.Duration, validCount, invalidCount int) {
-	pl.LogProcessingSummary(pl.processed, validCount, invalidCount, duration)
+	for _, kind := range pl.warnings.Suppressed() {
+		pl.Warn("%d more %q messages suppressed (%d in total)", kind.Suppressed, kind.Kind, kind.Total)
+	}
+	LogProcessingSummary(pl.Logger, pl.processed, validCount, invalidCount, duration)
 }
 
 // Global logger instance
-var defaultLogger *Logger
+var defaultLogger *StandardLogger
 
 // InitDefaultLogger initializes the global logger
 func InitDefaultLogger(verbose bool) {
@@ -316,7 +385,7 @@ func InitDefaultLogger(verbose bool) {
 }
 
 // GetDefaultLogger returns the global logger instance
-func GetDefaultLogger() *Logger {
+func GetDefaultLogger() *StandardLogger {
 	if defaultLogger == nil {
 		defaultLogger = NewDefaultLogger(false)
 	}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Log formats supported by the built-in sinks
+const (
+	FormatText = "text" // "2006-01-02 15:04:05 [prefix] LEVEL: message" lines
+	FormatJSON = "json" // One JSON object per line
+)
+
+// Entry is a single log message
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Prefix  string
+	Message string
+}
+
+// Sink receives the entries of a StandardLogger
+type Sink interface {
+	Write(entry Entry) error
+}
+
+// NewSink creates a text or JSON lines sink writing to w
+func NewSink(w io.Writer, format string) (Sink, error) {
+	switch format {
+	case "", FormatText:
+		return NewTextSink(w), nil
+	case FormatJSON:
+		return NewJSONSink(w), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (expected %s or %s)", format, FormatText, FormatJSON)
+}
+
+// TextSink writes entries as human-readable lines
+type TextSink struct {
+	mu    sync.Mutex
+	w     io.Writer
+	color bool // Highlight warning and error levels
+}
+
+// NewTextSink creates a sink writing text lines to w, e.g. os.Stderr
+func NewTextSink(w io.Writer) *TextSink {
+	return &TextSink{w: w}
+}
+
+// SetColor highlights warning levels in yellow and error levels in red when enabled
+func (s *TextSink) SetColor(enabled bool) {
+	s.color = enabled
+}
+
+func (s *TextSink) Write(entry Entry) error {
+	prefix := ""
+	if entry.Prefix != "" {
+		prefix = fmt.Sprintf("[%s] ", entry.Prefix)
+	}
+	level := entry.Level.String()
+	if color := levelColor(entry.Level); color != "" {
+		level = Colorize(s.color, color, level)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.w, "%s %s%s: %s\n", entry.Time.Format("2006-01-02 15:04:05"), prefix, level, entry.Message)
+	return err
+}
+
+// JSONSink writes entries as JSON lines for log collectors
+type JSONSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONSink creates a sink writing one JSON object per entry to w
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{encoder: json.NewEncoder(w)}
+}
+
+// jsonEntry is the JSON lines representation of an entry
+type jsonEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Prefix  string `json:"prefix,omitempty"`
+	Message string `json:"message"`
+}
+
+func (s *JSONSink) Write(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(jsonEntry{
+		Time:    entry.Time.Format(time.RFC3339Nano),
+		Level:   entry.Level.String(),
+		Prefix:  entry.Prefix,
+		Message: entry.Message,
+	})
+}
+
+// FileSink appends entries to a log file in text or JSON lines format
+type FileSink struct {
+	Sink
+	file *os.File
+}
+
+// OpenFileSink opens (or creates) a log file for appending
+func OpenFileSink(path, format string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file %s: %w", path, err)
+	}
+	sink, err := NewSink(file, format)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileSink{Sink: sink, file: file}, nil
+}
+
+// Close closes the log file
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestJSONSink tests that entries are written as JSON lines
+func TestJSONSink(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSinkLogger(LogLevelInfo, NewJSONSink(&buf), false)
+	logger.SetPrefix("trips.csv")
+
+	logger.Debug("hidden")
+	logger.Info("Processed %d records", 42)
+	logger.Warn("warn message")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines, got %d: %q", len(lines), buf.String())
+	}
+	var entry jsonEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", lines[0], err)
+	}
+	if entry.Level != "INFO" || entry.Message != "Processed 42 records" || entry.Prefix != "trips.csv" || entry.Time == "" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if logger.GetWarnCount() != 1 {
+		t.Errorf("Expected 1 warning counted, got %d", logger.GetWarnCount())
+	}
+}
+
+// TestOpenFileSink tests appending to a log file and rejecting unknown formats
+func TestOpenFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+	for _, message := range []string{"first run", "second run"} {
+		sink, err := OpenFileSink(path, FormatText)
+		if err != nil {
+			t.Fatalf("OpenFileSink failed: %v", err)
+		}
+		NewSinkLogger(LogLevelInfo, sink, false).Info(message)
+		if err := sink.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "INFO: first run") || !strings.HasSuffix(lines[1], "INFO: second run") {
+		t.Errorf("Expected both runs appended, got %q", content)
+	}
+
+	if _, err := OpenFileSink(path, "xml"); err == nil {
+		t.Error("Expected an error for an unknown log format")
+	}
+}
+
+// TestTextSink_Color tests that warning and error levels are highlighted when enabled
+func TestTextSink_Color(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewTextSink(&buf)
+	logger := NewSinkLogger(LogLevelInfo, sink, false)
+
+	logger.Warn("plain")
+	sink.SetColor(true)
+	logger.Info("info")
+	logger.Warn("warning")
+	logger.Error("error")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if strings.Contains(lines[0], "\033[") || strings.Contains(lines[1], "\033[") {
+		t.Errorf("Expected no color for a disabled sink or the info level: %q", lines[:2])
+	}
+	if !strings.Contains(lines[2], string(Yellow)+"WARN"+reset) || !strings.Contains(lines[3], string(Red)+"ERROR"+reset) {
+		t.Errorf("Expected yellow WARN and red ERROR, got %q", lines[2:])
+	}
+}
+
+// TestColorEnabled tests that color is disabled off terminals and by NO_COLOR
+func TestColorEnabled(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer file.Close()
+	if ColorEnabled(file, false) {
+		t.Error("Expected no color for a regular file")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(os.Stdout, false) {
+		t.Error("Expected NO_COLOR to disable color")
+	}
+	if Colorize(false, Red, "x") != "x" || Colorize(true, Red, "x") != string(Red)+"x"+reset {
+		t.Error("Unexpected Colorize output")
+	}
+}
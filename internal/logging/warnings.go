@@ -0,0 +1,54 @@
+package logging
+
+// DefaultWarnLimit is the number of warnings of each kind shown in verbose mode
+// before the rest are only counted
+const DefaultWarnLimit = 10
+
+// WarningLimiter deduplicates repeated warnings: it lets through the first limit
+// warnings of each kind and counts the rest for a closing summary. A nil limiter
+// lets everything through. It is not safe for concurrent use.
+type WarningLimiter struct {
+	limit  int
+	counts map[string]int
+	kinds  []string // Kinds in order of first occurrence
+}
+
+// SuppressedWarnings is the number of warnings of one kind that were not shown
+type SuppressedWarnings struct {
+	Kind       string
+	Suppressed int
+	Total      int
+}
+
+// NewWarningLimiter creates a limiter showing at most limit warnings per kind
+// (limit < 1 = no limit)
+func NewWarningLimiter(limit int) *WarningLimiter {
+	return &WarningLimiter{limit: limit, counts: make(map[string]int)}
+}
+
+// Allow counts a warning of the given kind and reports whether it should be shown
+func (w *WarningLimiter) Allow(kind string) bool {
+	if w == nil {
+		return true
+	}
+	if _, seen := w.counts[kind]; !seen {
+		w.kinds = append(w.kinds, kind)
+	}
+	w.counts[kind]++
+	return w.limit < 1 || w.counts[kind] <= w.limit
+}
+
+// Suppressed returns the kinds with warnings that were not shown, in order of
+// their first occurrence
+func (w *WarningLimiter) Suppressed() []SuppressedWarnings {
+	if w == nil || w.limit < 1 {
+		return nil
+	}
+	var suppressed []SuppressedWarnings
+	for _, kind := range w.kinds {
+		if total := w.counts[kind]; total > w.limit {
+			suppressed = append(suppressed, SuppressedWarnings{Kind: kind, Suppressed: total - w.limit, Total: total})
+		}
+	}
+	return suppressed
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestWarningLimiter tests that only the first warnings of each kind are allowed
+func TestWarningLimiter(t *testing.T) {
+	limiter := NewWarningLimiter(2)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if limiter.Allow("empty latitude") {
+			allowed++
+		}
+	}
+	if !limiter.Allow("bad quote") || allowed != 2 {
+		t.Errorf("Expected 2 empty latitude warnings and the first bad quote allowed, got %d", allowed)
+	}
+
+	expected := []SuppressedWarnings{{Kind: "empty latitude", Suppressed: 3, Total: 5}}
+	if got := limiter.Suppressed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Suppressed() = %+v, want %+v", got, expected)
+	}
+
+	var unlimited *WarningLimiter
+	if !unlimited.Allow("anything") || unlimited.Suppressed() != nil {
+		t.Error("A nil limiter should allow everything")
+	}
+	if unlimited = NewWarningLimiter(0); !unlimited.Allow("x") || !unlimited.Allow("x") {
+		t.Error("A zero limit should allow everything")
+	}
+}
+
+// TestProcessingLogger_WarnLimit tests that repeated skipped records are summarized
+func TestProcessingLogger_WarnLimit(t *testing.T) {
+	var buf bytes.Buffer
+	processLogger := NewProcessingLogger(NewLogger(LogLevelInfo, &buf, false), "dirty.csv", 0)
+	processLogger.SetWarnLimit(3)
+
+	for line := 1; line <= 100; line++ {
+		processLogger.LogSkippedRecord(line, "empty or malformed coordinates")
+	}
+	processLogger.Complete(0, 0, 100)
+
+	output := buf.String()
+	if count := strings.Count(output, "Skipping record"); count != 3 {
+		t.Errorf("Expected 3 skipped record warnings, got %d", count)
+	}
+	if !strings.Contains(output, `97 more "skipped record: empty or malformed coordinates" messages suppressed (100 in total)`) {
+		t.Errorf("Expected a suppression summary, got:\n%s", output)
+	}
+}
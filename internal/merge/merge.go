@@ -0,0 +1,190 @@
+package merge
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"csv-h3-tool/internal/csvutil"
+)
+
+// Join types
+const (
+	HowLeft  = "left"  // Keep every left row, with empty right columns when unmatched
+	HowInner = "inner" // Keep only left rows with at least one matching right row
+)
+
+// Options configures a merge
+type Options struct {
+	On        string // Join column present in both files (default "h3_index")
+	How       string // HowLeft (default) or HowInner
+	Delimiter rune   // Field delimiter of both files (default ',')
+}
+
+// Stats summarizes a merge
+type Stats struct {
+	LeftRows      int // Rows read from the left file
+	RightRows     int // Rows read from the right file
+	MatchedRows   int // Left rows with at least one matching right row
+	UnmatchedRows int // Left rows without a matching right row
+	OutputRows    int // Rows written
+}
+
+// Files performs a hash join of two CSV files with header rows on a shared column and
+// writes the result as CSV. The right file is loaded into memory by join key and the
+// left file is streamed, so pass the smaller file on the right. Output columns are the
+// left columns followed by the right columns except the join column; right columns whose
+// name is already taken get a "_right" suffix. Empty join keys never match.
+func Files(leftPath, rightPath string, w io.Writer, opts Options) (*Stats, error) {
+	if opts.On == "" {
+		opts.On = "h3_index"
+	}
+	switch opts.How {
+	case "":
+		opts.How = HowLeft
+	case HowLeft, HowInner:
+	default:
+		return nil, fmt.Errorf("invalid join type %q: expected %s or %s", opts.How, HowLeft, HowInner)
+	}
+
+	stats := &Stats{}
+	rightHeaders, rightRows, err := readRight(rightPath, opts, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	leftFile, err := os.Open(leftPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open left file: %w", err)
+	}
+	defer leftFile.Close()
+	reader := csvutil.NewReader(leftFile, opts.Delimiter)
+	leftHeaders, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %s: %w", leftPath, err)
+	}
+	leftOn := csvutil.FindColumn(leftHeaders, opts.On)
+	if leftOn < 0 {
+		return nil, fmt.Errorf("join column %q not found in %s", opts.On, leftPath)
+	}
+	rightOn := csvutil.FindColumn(rightHeaders, opts.On)
+
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+	if err := writer.Write(outputHeaders(leftHeaders, rightHeaders, rightOn)); err != nil {
+		return nil, fmt.Errorf("failed to write merged output: %w", err)
+	}
+
+	emptyRight := make([]string, len(rightHeaders)-1)
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s row %d: %w", leftPath, row, err)
+		}
+		stats.LeftRows++
+		left := pad(record, len(leftHeaders))
+
+		matches := rightRows[strings.TrimSpace(left[leftOn])]
+		if len(matches) == 0 {
+			stats.UnmatchedRows++
+			if opts.How == HowInner {
+				continue
+			}
+			matches = [][]string{emptyRight}
+		} else {
+			stats.MatchedRows++
+		}
+
+		for _, right := range matches {
+			if err := writer.Write(append(append(make([]string, 0, len(left)+len(right)), left...), right...)); err != nil {
+				return nil, fmt.Errorf("failed to write merged output: %w", err)
+			}
+			stats.OutputRows++
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write merged output: %w", err)
+	}
+	return stats, nil
+}
+
+// readRight loads the right file into memory, keyed by join column with the join column
+// itself removed from every row
+func readRight(path string, opts Options, stats *Stats) ([]string, map[string][][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open right file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csvutil.NewReader(file, opts.Delimiter)
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+	on := csvutil.FindColumn(headers, opts.On)
+	if on < 0 {
+		return nil, nil, fmt.Errorf("join column %q not found in %s", opts.On, path)
+	}
+
+	rows := make(map[string][][]string)
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s row %d: %w", path, row, err)
+		}
+		stats.RightRows++
+
+		record = pad(record, len(headers))
+		key := strings.TrimSpace(record[on])
+		if key == "" {
+			continue
+		}
+		values := make([]string, 0, len(headers)-1)
+		values = append(append(values, record[:on]...), record[on+1:]...)
+		rows[key] = append(rows[key], values)
+	}
+	return headers, rows, nil
+}
+
+// outputHeaders returns the merged header row, suffixing right columns that clash
+func outputHeaders(left, right []string, rightOn int) []string {
+	taken := make(map[string]bool, len(left)+len(right))
+	headers := make([]string, 0, len(left)+len(right)-1)
+	for _, header := range left {
+		taken[header] = true
+		headers = append(headers, header)
+	}
+	for i, header := range right {
+		if i == rightOn {
+			continue
+		}
+		name := header
+		for taken[name] {
+			name += "_right"
+		}
+		taken[name] = true
+		headers = append(headers, name)
+	}
+	return headers
+}
+
+// pad fits a row to the header length, extending short rows with empty values
+func pad(record []string, length int) []string {
+	for len(record) < length {
+		record = append(record, "")
+	}
+	return record[:length]
+}
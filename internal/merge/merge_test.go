@@ -0,0 +1,86 @@
+package merge
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestFiles(t *testing.T) {
+	left := writeFile(t, "left.csv", `id,name,h3_index
+1,a,882a107289fffff
+2,b,88195da49bfffff
+3,c,
+`)
+	right := writeFile(t, "right.csv", `h3_index,population,name
+882a107289fffff,100,NYC
+882a107289fffff,150,Manhattan
+881fb46625fffff,200,Paris
+`)
+
+	tests := []struct {
+		how      string
+		expected string
+		stats    Stats
+	}{
+		{HowLeft, `id,name,h3_index,population,name_right
+1,a,882a107289fffff,100,NYC
+1,a,882a107289fffff,150,Manhattan
+2,b,88195da49bfffff,,
+3,c,,,
+`, Stats{LeftRows: 3, RightRows: 3, MatchedRows: 1, UnmatchedRows: 2, OutputRows: 4}},
+		{HowInner, `id,name,h3_index,population,name_right
+1,a,882a107289fffff,100,NYC
+1,a,882a107289fffff,150,Manhattan
+`, Stats{LeftRows: 3, RightRows: 3, MatchedRows: 1, UnmatchedRows: 2, OutputRows: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.how, func(t *testing.T) {
+			var output bytes.Buffer
+			stats, err := Files(left, right, &output, Options{How: tt.how})
+			if err != nil {
+				t.Fatalf("Files() error = %v", err)
+			}
+			if output.String() != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, output.String())
+			}
+			if *stats != tt.stats {
+				t.Errorf("Expected stats %+v, got %+v", tt.stats, *stats)
+			}
+		})
+	}
+}
+
+func TestFiles_Errors(t *testing.T) {
+	good := writeFile(t, "good.csv", "h3_index,v\nx,1\n")
+	noKey := writeFile(t, "nokey.csv", "cell,v\nx,1\n")
+
+	tests := []struct {
+		name        string
+		left, right string
+		opts        Options
+	}{
+		{"invalid join type", good, good, Options{How: "outer"}},
+		{"missing left column", noKey, good, Options{}},
+		{"missing right column", good, noKey, Options{}},
+		{"missing file", good, filepath.Join(t.TempDir(), "none.csv"), Options{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Files(tt.left, tt.right, &bytes.Buffer{}, tt.opts); err == nil {
+				t.Error("Files() should error")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Package notify sends the JSON summary of a run to a webhook or a local command
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"csv-h3-tool/internal/logging"
+)
+
+// Timeout bounds each notification so a slow endpoint cannot hang the tool
+const Timeout = 30 * time.Second
+
+// Summary is the notification payload of a run. Text is a one-line description of the
+// run, the field Slack and Teams incoming webhooks display; the other fields are for
+// consumers that parse the payload. Args are redacted of secret flag values.
+type Summary struct {
+	Text           string   `json:"text"`
+	Status         string   `json:"status"`
+	Error          string   `json:"error,omitempty"`
+	Hostname       string   `json:"hostname"`
+	Version        string   `json:"version"`
+	Args           []string `json:"args"`
+	InputFiles     []string `json:"input_files,omitempty"`
+	OutputFile     string   `json:"output_file,omitempty"`
+	TotalRecords   int      `json:"total_records"`
+	ValidRecords   int      `json:"valid_records"`
+	InvalidRecords int      `json:"invalid_records"`
+	DurationMS     int64    `json:"duration_ms"`
+}
+
+// NewSummary builds the notification summary of a finished run
+func NewSummary(audit *logging.AuditEntry) Summary {
+	summary := Summary{
+		Status:         audit.Status,
+		Error:          audit.Error,
+		Hostname:       audit.Hostname,
+		Version:        audit.Version,
+		Args:           logging.RedactArgs(audit.Args),
+		InputFiles:     audit.InputFiles,
+		OutputFile:     audit.OutputFile,
+		TotalRecords:   audit.TotalRecords,
+		ValidRecords:   audit.ValidRecords,
+		InvalidRecords: audit.InvalidRecords,
+		DurationMS:     audit.DurationMS,
+	}
+
+	outcome := "succeeded"
+	if audit.Status != "success" {
+		outcome = "failed"
+	}
+	text := fmt.Sprintf("csv-h3-tool run %s on %s: %d records (%d valid, %d invalid) in %s",
+		outcome, audit.Hostname, audit.TotalRecords, audit.ValidRecords, audit.InvalidRecords,
+		(time.Duration(audit.DurationMS) * time.Millisecond).String())
+	if audit.OutputFile != "" {
+		text += ", output " + audit.OutputFile
+	}
+	if audit.Error != "" {
+		text += ": " + audit.Error
+	}
+	summary.Text = text
+	return summary
+}
+
+// Payload encodes the summary as the JSON notification payload
+func (s Summary) Payload() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// Webhook POSTs the JSON payload to url, e.g. a Slack or Teams incoming webhook.
+// Responses other than 2xx are errors.
+func Webhook(url string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Command runs a shell command with the JSON payload on standard input and the run
+// status ("success" or "failure") in the CSVH3_STATUS environment variable
+func Command(command string, status string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "CSVH3_STATUS="+status)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"csv-h3-tool/internal/logging"
+)
+
+func TestWebhook(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	}))
+	defer server.Close()
+
+	if err := Webhook(server.URL, []byte(`{"status":"success"}`)); err != nil {
+		t.Fatalf("Webhook() error = %v", err)
+	}
+	if received != `{"status":"success"}` {
+		t.Errorf("Expected the payload to be posted, got %q", received)
+	}
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := Webhook(server.URL, []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("Expected a 403 error with the response body, got %v", err)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "summary.json")
+	if err := Command(`cat > "`+output+`"; echo "$CSVH3_STATUS" >> "`+output+`"`, "failure", []byte(`{"status":"failure"}`)); err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read command output: %v", err)
+	}
+	if string(content) != "{\"status\":\"failure\"}failure\n" {
+		t.Errorf("Expected the payload and status, got %q", content)
+	}
+
+	if err := Command("echo oops >&2; exit 3", "success", nil); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("Expected a failing command error with its output, got %v", err)
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	audit := logging.NewAuditEntry([]string{"in.csv", "--notify-webhook", "https://hooks.example/T0/B0/xyz", "-o", "out.csv"}, "1.2.0")
+	audit.Hostname = "worker-1"
+	audit.OutputFile = "out.csv"
+	audit.TotalRecords, audit.ValidRecords, audit.InvalidRecords = 10, 8, 2
+	audit.Finish(errors.New("disk full"))
+
+	payload, err := NewSummary(audit).Payload()
+	if err != nil {
+		t.Fatalf("Payload() error = %v", err)
+	}
+	if strings.Contains(string(payload), "hooks.example") {
+		t.Errorf("Expected the webhook URL to be redacted, got %s", payload)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	text, _ := decoded["text"].(string)
+	if !strings.Contains(text, "failed on worker-1") || !strings.Contains(text, "10 records (8 valid, 2 invalid)") ||
+		!strings.Contains(text, "out.csv") || !strings.Contains(text, "disk full") {
+		t.Errorf("Unexpected summary text %q", text)
+	}
+	if decoded["status"] != "failure" || decoded["valid_records"] != float64(8) {
+		t.Errorf("Expected the run status and counts in the payload, got %s", payload)
+	}
+	if _, ok := decoded["user"]; ok {
+		t.Errorf("Expected only summary fields in the payload, got %s", payload)
+	}
+}
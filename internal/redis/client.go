@@ -0,0 +1,151 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPipelineSize is the number of commands sent before their replies are read
+const DefaultPipelineSize = 1000
+
+// Client is a minimal Redis client that pipelines SET commands
+type Client struct {
+	conn         net.Conn
+	reader       *bufio.Reader
+	writer       *bufio.Writer
+	pending      int // Commands sent without their replies read
+	pipelineSize int
+}
+
+// Dial connects to a Redis server given as host:port or as a
+// redis://[user:password@]host:port[/db] URL
+func Dial(addr string) (*Client, error) {
+	host, password, username, db := addr, "", "", 0
+	if strings.HasPrefix(addr, "redis://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis URL %q: %w", addr, err)
+		}
+		host = u.Host
+		if u.User != nil {
+			username = u.User.Username()
+			password, _ = u.User.Password()
+		}
+		if path := strings.Trim(u.Path, "/"); path != "" {
+			if db, err = strconv.Atoi(path); err != nil {
+				return nil, fmt.Errorf("invalid Redis database %q in %s", path, addr)
+			}
+		}
+	}
+	if !strings.Contains(host, ":") {
+		host += ":6379"
+	}
+
+	conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", host, err)
+	}
+	client := &Client{
+		conn:         conn,
+		reader:       bufio.NewReader(conn),
+		writer:       bufio.NewWriter(conn),
+		pipelineSize: DefaultPipelineSize,
+	}
+
+	if password != "" {
+		args := []string{"AUTH", password}
+		if username != "" {
+			args = []string{"AUTH", username, password}
+		}
+		if err := client.do(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis authentication failed: %w", err)
+		}
+	}
+	if db != 0 {
+		if err := client.do("SELECT", strconv.Itoa(db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select Redis database %d: %w", db, err)
+		}
+	}
+	return client, nil
+}
+
+// Set queues setting key to value; queued commands are sent in pipelined batches
+func (c *Client) Set(key, value string) error {
+	c.send("SET", key, value)
+	if c.pending >= c.pipelineSize {
+		return c.Flush()
+	}
+	return nil
+}
+
+// Flush sends the queued commands and checks their replies
+func (c *Client) Flush() error {
+	if err := c.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write to Redis: %w", err)
+	}
+
+	var firstErr error
+	for ; c.pending > 0; c.pending-- {
+		if err := c.readReply(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close sends any queued commands and closes the connection
+func (c *Client) Close() error {
+	flushErr := c.Flush()
+	if err := c.conn.Close(); err != nil && flushErr == nil {
+		return err
+	}
+	return flushErr
+}
+
+// do sends a single command and waits for its reply
+func (c *Client) do(args ...string) error {
+	c.send(args...)
+	return c.Flush()
+}
+
+// send buffers a command in the Redis serialization protocol; write errors surface on Flush
+func (c *Client) send(args ...string) {
+	fmt.Fprintf(c.writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	c.pending++
+}
+
+// readReply reads one simple reply, returning server errors as errors
+func (c *Client) readReply() error {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read Redis reply: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	switch {
+	case strings.HasPrefix(line, "-"):
+		return fmt.Errorf("redis error: %s", line[1:])
+	case strings.HasPrefix(line, "$"):
+		// Bulk string reply: skip its payload
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("malformed Redis reply %q", line)
+		}
+		if length >= 0 {
+			if _, err := c.reader.Discard(length + 2); err != nil {
+				return fmt.Errorf("failed to read Redis reply: %w", err)
+			}
+		}
+	}
+	return nil
+}
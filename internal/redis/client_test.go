@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer accepts one connection, records the commands it receives, and
+// replies +OK (or an error for keys starting with "bad")
+type fakeServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	commands [][]string
+	done     chan struct{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	s := &fakeServer{listener: listener, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, count)
+		for i := range args {
+			reader.ReadString('\n') // Length line
+			arg, _ := reader.ReadString('\n')
+			args[i] = strings.TrimRight(arg, "\r\n")
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		s.mu.Unlock()
+
+		if len(args) > 1 && strings.HasPrefix(args[1], "bad") {
+			conn.Write([]byte("-ERR bad key\r\n"))
+		} else {
+			conn.Write([]byte("+OK\r\n"))
+		}
+	}
+}
+
+func TestClientSet(t *testing.T) {
+	server := newFakeServer(t)
+	client, err := Dial("redis://:secret@" + server.listener.Addr().String() + "/2")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	client.pipelineSize = 2
+
+	for _, key := range []string{"loc:1", "loc:2", "loc:3"} {
+		if err := client.Set(key, "882a1072b5fffff"); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	<-server.done
+
+	want := [][]string{
+		{"AUTH", "secret"},
+		{"SELECT", "2"},
+		{"SET", "loc:1", "882a1072b5fffff"},
+		{"SET", "loc:2", "882a1072b5fffff"},
+		{"SET", "loc:3", "882a1072b5fffff"},
+	}
+	if !reflect.DeepEqual(server.commands, want) {
+		t.Errorf("commands = %v, want %v", server.commands, want)
+	}
+}
+
+func TestClientSetError(t *testing.T) {
+	server := newFakeServer(t)
+	client, err := Dial(server.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	client.Set("bad:1", "x")
+	if err := client.Close(); err == nil || !strings.Contains(err.Error(), "bad key") {
+		t.Errorf("Close() error = %v, want server error", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Package regress runs bundled reference inputs through the processing pipeline and
+// compares the outputs byte for byte against golden files written by a trusted build.
+package regress
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/logging"
+	"csv-h3-tool/internal/service"
+)
+
+// inputs holds the reference input files compiled into the binary
+//
+//go:embed inputs/*.csv
+var inputs embed.FS
+
+// Case is a reference input processed with a fixed configuration
+type Case struct {
+	Name      string
+	Input     string               // File name in inputs/
+	configure func(*config.Config) // Adjusts the default configuration
+}
+
+// Cases are the bundled regression cases, covering resolutions, invalid and quoted
+// input, headerless files, deduplication, and sorting
+var Cases = []Case{
+	{"points_r8", "points.csv", func(cfg *config.Config) {}},
+	{"points_r0", "points.csv", func(cfg *config.Config) { cfg.Resolution = 0 }},
+	{"points_r15", "points.csv", func(cfg *config.Config) { cfg.Resolution = 15 }},
+	{"invalid_rows", "invalid.csv", func(cfg *config.Config) {
+		cfg.LatColumn, cfg.LngColumn = "lat", "lng"
+		cfg.TrimFields = true
+	}},
+	{"multiline_fields", "multiline.csv", func(cfg *config.Config) {
+		cfg.LatColumn, cfg.LngColumn = "lat", "lng"
+	}},
+	{"headerless", "headerless.csv", func(cfg *config.Config) {
+		cfg.HasHeaders = false
+		cfg.LatColumn, cfg.LngColumn = "0", "1"
+	}},
+	{"dedupe_sorted", "duplicates.csv", func(cfg *config.Config) {
+		cfg.DedupeExact = true
+		cfg.SortByH3 = true
+	}},
+}
+
+// Result is the outcome of one regression case
+type Result struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// GoldenFile returns the path of a case's golden output in goldenDir
+func GoldenFile(goldenDir string, c Case) string {
+	return filepath.Join(goldenDir, c.Name+".csv")
+}
+
+// Run processes every case and compares its output to the golden file in goldenDir.
+// With update, the outputs are written as the new golden files instead.
+func Run(goldenDir string, update bool) ([]Result, error) {
+	workDir, err := os.MkdirTemp("", "csv-h3-regress-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+	if update {
+		if err := os.MkdirAll(goldenDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create golden directory: %w", err)
+		}
+	}
+
+	results := make([]Result, 0, len(Cases))
+	for _, c := range Cases {
+		output, err := process(c, workDir)
+		if err != nil {
+			results = append(results, Result{Name: c.Name, Detail: err.Error()})
+			continue
+		}
+
+		golden := GoldenFile(goldenDir, c)
+		if update {
+			if err := os.WriteFile(golden, output, 0644); err != nil {
+				return results, fmt.Errorf("failed to write golden file: %w", err)
+			}
+			results = append(results, Result{Name: c.Name, Passed: true, Detail: "golden file updated"})
+			continue
+		}
+		expected, err := os.ReadFile(golden)
+		if os.IsNotExist(err) {
+			results = append(results, Result{Name: c.Name, Detail: "no golden file " + golden + " (create it with --update)"})
+			continue
+		}
+		if err != nil {
+			return results, fmt.Errorf("failed to read golden file: %w", err)
+		}
+		if line, differs := firstDifference(expected, output); differs {
+			results = append(results, Result{Name: c.Name, Detail: fmt.Sprintf("output differs from the golden file at line %d", line)})
+			continue
+		}
+		results = append(results, Result{Name: c.Name, Passed: true, Detail: fmt.Sprintf("%d bytes identical", len(output))})
+	}
+	return results, nil
+}
+
+// process runs one case and returns its output
+func process(c Case, workDir string) ([]byte, error) {
+	data, err := inputs.ReadFile("inputs/" + c.Input)
+	if err != nil {
+		return nil, err
+	}
+	inputFile := filepath.Join(workDir, c.Name+"_input.csv")
+	if err := os.WriteFile(inputFile, data, 0644); err != nil {
+		return nil, err
+	}
+
+	cfg := config.NewConfig()
+	c.configure(cfg)
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(workDir, c.Name+".csv")
+	cfg.Overwrite = true
+	orchestrator := service.NewOrchestrator(cfg)
+	orchestrator.SetLogger(logging.NewLogger(logging.LogLevelFatal, io.Discard, false))
+	if _, err := orchestrator.ProcessFile(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(cfg.OutputFile)
+}
+
+// firstDifference returns the 1-based line at which two outputs first differ
+func firstDifference(expected, actual []byte) (int, bool) {
+	if bytes.Equal(expected, actual) {
+		return 0, false
+	}
+	line := 1
+	for i := 0; i < len(expected) && i < len(actual) && expected[i] == actual[i]; i++ {
+		if expected[i] == '\n' {
+			line++
+		}
+	}
+	return line, true
+}
@@ -0,0 +1,69 @@
+package regress
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	goldenDir := filepath.Join(t.TempDir(), "golden")
+
+	// Without golden files every case fails
+	results, err := Run(goldenDir, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, result := range results {
+		if result.Passed || !strings.Contains(result.Detail, "--update") {
+			t.Errorf("%s: expected a missing golden file, got %+v", result.Name, result)
+		}
+	}
+
+	if _, err := Run(goldenDir, true); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	results, err = Run(goldenDir, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != len(Cases) {
+		t.Fatalf("Expected %d results, got %d", len(Cases), len(results))
+	}
+	for _, result := range results {
+		if !result.Passed {
+			t.Errorf("%s: expected identical output, got %s", result.Name, result.Detail)
+		}
+	}
+
+	// A changed H3 index is reported at its line
+	golden := GoldenFile(goldenDir, Cases[0])
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	lines[2] = strings.Replace(lines[2], "88195da49bfffff", "88195da49dfffff", 1)
+	os.WriteFile(golden, []byte(strings.Join(lines, "\n")), 0644)
+	results, err = Run(goldenDir, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if results[0].Passed || results[0].Detail != "output differs from the golden file at line 3" {
+		t.Errorf("Expected a difference at line 3, got %+v", results[0])
+	}
+}
+
+func TestPointsGolden(t *testing.T) {
+	// The r8 points output matches the selftest golden indexes
+	output, err := process(Cases[0], t.TempDir())
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	for _, index := range []string{"882a107289fffff", "88195da49bfffff", "88754e6499fffff", "88f2939521fffff"} {
+		if !strings.Contains(string(output), ","+index+"\n") {
+			t.Errorf("Expected H3 index %s in output:\n%s", index, output)
+		}
+	}
+}
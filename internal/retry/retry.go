@@ -0,0 +1,215 @@
+package retry
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Fix rewrites the latitude and longitude of a rejected row
+type Fix func(lat, lng string) (string, string)
+
+// Fixes are the named transformations accepted by --fix
+var Fixes = map[string]Fix{
+	// Coordinates written in the wrong column order
+	"swap-latlng": func(lat, lng string) (string, string) {
+		return lng, lat
+	},
+	// Decimal commas from European locales, e.g. "48,8566"
+	"decimal-comma": func(lat, lng string) (string, string) {
+		return strings.Replace(lat, ",", ".", 1), strings.Replace(lng, ",", ".", 1)
+	},
+	// Western hemisphere longitudes that lost their sign
+	"negate-lng": func(lat, lng string) (string, string) {
+		lng = strings.TrimSpace(lng)
+		if strings.HasPrefix(lng, "-") {
+			return lat, lng[1:]
+		}
+		return lat, "-" + lng
+	},
+}
+
+// FixNames returns the names of the available fixes in sorted order
+func FixNames() []string {
+	names := make([]string, 0, len(Fixes))
+	for name := range Fixes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFixes resolves a comma-separated list of fix names, applied in order
+func ParseFixes(spec string) ([]Fix, error) {
+	var fixes []Fix
+	for _, name := range strings.Split(spec, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		fix, ok := Fixes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown fix %q (available: %s)", name, strings.Join(FixNames(), ", "))
+		}
+		fixes = append(fixes, fix)
+	}
+	if len(fixes) == 0 {
+		return nil, fmt.Errorf("at least one fix is required (available: %s)", strings.Join(FixNames(), ", "))
+	}
+	return fixes, nil
+}
+
+// Options configures how a rejects file is prepared for reprocessing
+type Options struct {
+	LatColumn    string // Latitude column name (matched case-insensitively)
+	LngColumn    string // Longitude column name (matched case-insensitively)
+	ReasonColumn string // Trailing column holding the rejection reason, dropped from the output
+	Fixes        []Fix
+}
+
+// Prepare reads a rejects file with a header row, drops its reason column, applies the
+// fixes to every row and writes the result as CSV with the original input columns.
+// It returns the number of rows written.
+func Prepare(rejectsPath string, w io.Writer, opts Options) (int, error) {
+	file, err := os.Open(rejectsPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open rejects file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return 0, fmt.Errorf("rejects file %s is empty", rejectsPath)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read rejects file headers: %w", err)
+	}
+	if len(headers) == 0 || headers[len(headers)-1] != opts.ReasonColumn {
+		return 0, fmt.Errorf("rejects file %s has no trailing %s column", rejectsPath, opts.ReasonColumn)
+	}
+	headers = headers[:len(headers)-1]
+
+	latIndex, err := columnIndex(headers, opts.LatColumn)
+	if err != nil {
+		return 0, err
+	}
+	lngIndex, err := columnIndex(headers, opts.LngColumn)
+	if err != nil {
+		return 0, err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(headers); err != nil {
+		return 0, err
+	}
+	rows := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("failed to read rejects file: %w", err)
+		}
+		if len(row) > len(headers) {
+			row = row[:len(headers)]
+		}
+		if len(row) > latIndex && len(row) > lngIndex {
+			lat, lng := row[latIndex], row[lngIndex]
+			for _, fix := range opts.Fixes {
+				lat, lng = fix(lat, lng)
+			}
+			row[latIndex], row[lngIndex] = lat, lng
+		}
+		if err := writer.Write(row); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	writer.Flush()
+	return rows, writer.Error()
+}
+
+// Append copies the data rows of the CSV file src to the end of dst after checking
+// that both files have the same header row. It returns the number of rows appended.
+func Append(src, dst string) (int, error) {
+	srcHeaders, srcRows, err := openRows(src)
+	if err != nil {
+		return 0, err
+	}
+	defer srcRows.Close()
+	dstHeaders, dstRows, err := openRows(dst)
+	if err != nil {
+		return 0, err
+	}
+	dstRows.Close()
+	if strings.Join(srcHeaders, "\x00") != strings.Join(dstHeaders, "\x00") {
+		return 0, fmt.Errorf("columns of %s do not match the recovered rows: got %s, want %s",
+			dst, strings.Join(dstHeaders, ","), strings.Join(srcHeaders, ","))
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s for appending: %w", dst, err)
+	}
+	defer out.Close()
+
+	reader := csv.NewReader(srcRows)
+	reader.FieldsPerRecord = -1
+	reader.Read() // Header row, already checked
+	writer := csv.NewWriter(out)
+	rows := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("failed to read recovered rows: %w", err)
+		}
+		if err := writer.Write(row); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return rows, err
+	}
+	return rows, out.Close()
+}
+
+// openRows opens a CSV file and reads its header row, leaving the file positioned at
+// the start
+func openRows(path string) ([]string, *os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	headers, err := reader.Read()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to read headers of %s: %w", path, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return headers, file, nil
+}
+
+// columnIndex finds a column by case-insensitive name
+func columnIndex(headers []string, name string) (int, error) {
+	for i, header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header), strings.TrimSpace(name)) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("column %q not found in rejects file", name)
+}
@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestParseFixes(t *testing.T) {
+	fixes, err := ParseFixes("decimal-comma, swap-latlng")
+	if err != nil {
+		t.Fatalf("ParseFixes failed: %v", err)
+	}
+	lat, lng := "2,35", "48,85"
+	for _, fix := range fixes {
+		lat, lng = fix(lat, lng)
+	}
+	if lat != "48.85" || lng != "2.35" {
+		t.Errorf("Expected 48.85, 2.35, got %s, %s", lat, lng)
+	}
+
+	if _, err := ParseFixes("swap-latlng,unknown"); err == nil {
+		t.Error("Expected error for unknown fix")
+	}
+	if _, err := ParseFixes(""); err == nil {
+		t.Error("Expected error for no fixes")
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	rejects := writeFile(t, "rejects.csv", `id,Latitude,Longitude,error_reason
+1,-74.0060,40.7128,latitude out of range
+2,abc,1,unparseable latitude
+`)
+	fixes, _ := ParseFixes("swap-latlng")
+
+	var out bytes.Buffer
+	rows, err := Prepare(rejects, &out, Options{LatColumn: "latitude", LngColumn: "longitude", ReasonColumn: "error_reason", Fixes: fixes})
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if rows != 2 {
+		t.Errorf("Expected 2 rows, got %d", rows)
+	}
+	expected := "id,Latitude,Longitude\n1,40.7128,-74.0060\n2,1,abc\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	if _, err := Prepare(writeFile(t, "plain.csv", "id,latitude,longitude\n"), &out, Options{ReasonColumn: "error_reason"}); err == nil {
+		t.Error("Expected error for a file without a reason column")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	recovered := writeFile(t, "recovered.csv", "id,latitude,longitude,h3_index\n1,40.7128,-74.0060,882a107289fffff\n")
+	output := writeFile(t, "output.csv", "id,latitude,longitude,h3_index\n0,51.5,-0.12,88195da49bfffff\n")
+
+	rows, err := Append(recovered, output)
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("Expected 1 appended row, got %d", rows)
+	}
+	data, _ := os.ReadFile(output)
+	expected := "id,latitude,longitude,h3_index\n0,51.5,-0.12,88195da49bfffff\n1,40.7128,-74.0060,882a107289fffff\n"
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	mismatched := writeFile(t, "other.csv", "id,h3_index\n")
+	if _, err := Append(recovered, mismatched); err == nil {
+		t.Error("Expected error for mismatched columns")
+	}
+}
@@ -0,0 +1,230 @@
+// Package schema infers the column types of output rows and describes them as a
+// JSON Schema document that downstream loaders can use to create tables.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Column types, named after the JSON Schema types
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// H3 index kinds
+const (
+	KindCell         = "cell"
+	KindDirectedEdge = "directed_edge"
+)
+
+// h3Pattern matches the 15-digit hexadecimal form of H3 cells and edges
+const h3Pattern = "^[0-9a-f]{15}$"
+
+// H3Info describes a column holding H3 indexes
+type H3Info struct {
+	Resolution int    `json:"resolution"`
+	Kind       string `json:"kind"` // KindCell or KindDirectedEdge
+}
+
+// Column is the inferred description of one output column
+type Column struct {
+	Name      string
+	Type      string  // TypeString, TypeInteger, TypeNumber, or TypeBoolean
+	Nullable  bool    // Whether some rows leave the column empty
+	H3        *H3Info // Set for H3 index columns
+	Encrypted bool    // Values are encrypted strings (--encrypt-columns)
+}
+
+// columnState tracks which types every non-empty value of a column fits so far
+type columnState struct {
+	values                     int
+	empty                      bool
+	integer, number, isBoolean bool
+	h3                         *H3Info
+	encrypted                  bool
+}
+
+// Inferrer infers column types from the rows added to it, in memory bounded by the
+// number of columns
+type Inferrer struct {
+	names   []string
+	columns []columnState
+	rows    int
+}
+
+// NewInferrer creates an inferrer for the named columns
+func NewInferrer(names []string) *Inferrer {
+	inferrer := &Inferrer{names: names, columns: make([]columnState, len(names))}
+	for i := range inferrer.columns {
+		inferrer.columns[i] = columnState{integer: true, number: true, isBoolean: true}
+	}
+	return inferrer
+}
+
+// SetH3 marks the named column as holding H3 indexes; unknown names are ignored
+func (s *Inferrer) SetH3(name string, info H3Info) {
+	for i, column := range s.names {
+		if column == name {
+			s.columns[i].h3 = &info
+		}
+	}
+}
+
+// SetEncrypted marks the named column as holding encrypted values, which are strings
+// whatever the plaintext looks like; unknown names are ignored
+func (s *Inferrer) SetEncrypted(name string) {
+	for i, column := range s.names {
+		if column == name {
+			s.columns[i].encrypted = true
+		}
+	}
+}
+
+// Add adds one row; values beyond the known columns are ignored and missing
+// values count as empty
+func (s *Inferrer) Add(row []string) {
+	s.rows++
+	for i := range s.columns {
+		value := ""
+		if i < len(row) {
+			value = strings.TrimSpace(row[i])
+		}
+		s.columns[i].add(value)
+	}
+}
+
+// add narrows the candidate types of a column to those the value fits
+func (c *columnState) add(value string) {
+	if value == "" {
+		c.empty = true
+		return
+	}
+	c.values++
+	if c.integer {
+		_, err := strconv.ParseInt(value, 10, 64)
+		c.integer = err == nil
+	}
+	if c.number && !c.integer {
+		_, err := strconv.ParseFloat(value, 64)
+		c.number = err == nil && !strings.ContainsAny(value, "nNiI") // Reject NaN and Inf
+	}
+	if c.isBoolean {
+		c.isBoolean = strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+	}
+}
+
+// Rows returns the number of rows added
+func (s *Inferrer) Rows() int {
+	return s.rows
+}
+
+// Columns returns the inferred columns in column order. Columns without any value
+// are nullable strings; H3 and encrypted columns are always strings.
+func (s *Inferrer) Columns() []Column {
+	columns := make([]Column, len(s.names))
+	for i, state := range s.columns {
+		column := Column{Name: s.names[i], Type: TypeString, Nullable: state.empty || state.values == 0,
+			H3: state.h3, Encrypted: state.encrypted}
+		switch {
+		case state.h3 != nil || state.encrypted || state.values == 0:
+		case state.isBoolean:
+			column.Type = TypeBoolean
+		case state.integer:
+			column.Type = TypeInteger
+		case state.number:
+			column.Type = TypeNumber
+		}
+		columns[i] = column
+	}
+	return columns
+}
+
+// property is the JSON Schema of one column
+type property struct {
+	Type      any     `json:"type"`
+	Pattern   string  `json:"pattern,omitempty"`
+	H3        *H3Info `json:"x-h3,omitempty"`
+	Encrypted bool    `json:"x-encrypted,omitempty"`
+}
+
+// JSONSchema renders the inferred columns as a JSON Schema (draft 2020-12) document
+// describing one row as an object. Properties are listed in column order, which is
+// also given by x-column-order since JSON objects are unordered.
+func (s *Inferrer) JSONSchema(title string) ([]byte, error) {
+	columns := s.Columns()
+	var properties bytes.Buffer
+	order := make([]string, len(columns))
+	required := []string{}
+	properties.WriteByte('{')
+	for i, column := range columns {
+		prop := property{Type: column.Type, H3: column.H3, Encrypted: column.Encrypted}
+		if column.Nullable {
+			prop.Type = []string{column.Type, "null"}
+		} else {
+			required = append(required, column.Name)
+		}
+		if column.H3 != nil {
+			prop.Pattern = h3Pattern
+		}
+		name, err := json.Marshal(column.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(prop)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			properties.WriteByte(',')
+		}
+		properties.Write(name)
+		properties.WriteByte(':')
+		properties.Write(value)
+		order[i] = column.Name
+	}
+	properties.WriteByte('}')
+
+	document, err := json.Marshal(struct {
+		Schema      string          `json:"$schema"`
+		Title       string          `json:"title"`
+		Type        string          `json:"type"`
+		Properties  json.RawMessage `json:"properties"`
+		Required    []string        `json:"required"`
+		ColumnOrder []string        `json:"x-column-order"`
+		Rows        int             `json:"x-rows"`
+	}{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		Title:       title,
+		Type:        "object",
+		Properties:  properties.Bytes(),
+		Required:    required,
+		ColumnOrder: order,
+		Rows:        s.rows,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, document, "", "  "); err != nil {
+		return nil, err
+	}
+	indented.WriteByte('\n')
+	return indented.Bytes(), nil
+}
+
+// WriteFile writes the JSON Schema document to path
+func (s *Inferrer) WriteFile(path, title string) error {
+	document, err := s.JSONSchema(title)
+	if err != nil {
+		return fmt.Errorf("failed to render schema: %w", err)
+	}
+	return os.WriteFile(path, document, 0644)
+}
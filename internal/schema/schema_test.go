@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInferrerColumns(t *testing.T) {
+	inferrer := NewInferrer([]string{"id", "score", "flag", "name", "empty", "h3_index", "mixed"})
+	inferrer.SetH3("h3_index", H3Info{Resolution: 9, Kind: KindCell})
+	inferrer.Add([]string{"1", "1.5", "true", "a", "", "8928308280fffff", "1"})
+	inferrer.Add([]string{"-2", "2", "FALSE", "", "", "", "x"})
+	inferrer.Add([]string{"3", "1e3", "false", "c"}) // Short row: missing values are empty
+
+	expected := []Column{
+		{Name: "id", Type: TypeInteger},
+		{Name: "score", Type: TypeNumber},
+		{Name: "flag", Type: TypeBoolean},
+		{Name: "name", Type: TypeString, Nullable: true},
+		{Name: "empty", Type: TypeString, Nullable: true},
+		{Name: "h3_index", Type: TypeString, Nullable: true, H3: &H3Info{Resolution: 9, Kind: KindCell}},
+		{Name: "mixed", Type: TypeString, Nullable: true},
+	}
+	if columns := inferrer.Columns(); !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected columns\n%+v\ngot\n%+v", expected, columns)
+	}
+	if inferrer.Rows() != 3 {
+		t.Errorf("Expected 3 rows, got %d", inferrer.Rows())
+	}
+}
+
+func TestInferrerRejectsNonFiniteNumbers(t *testing.T) {
+	inferrer := NewInferrer([]string{"value"})
+	inferrer.Add([]string{"1.5"})
+	inferrer.Add([]string{"NaN"})
+	if columns := inferrer.Columns(); columns[0].Type != TypeString {
+		t.Errorf("Expected NaN to make the column a string, got %s", columns[0].Type)
+	}
+}
+
+func TestInferrerEncryptedColumns(t *testing.T) {
+	inferrer := NewInferrer([]string{"id", "ssn"})
+	inferrer.SetEncrypted("ssn")
+	inferrer.Add([]string{"1", "123456789"})
+	inferrer.Add([]string{"2", ""})
+
+	expected := []Column{
+		{Name: "id", Type: TypeInteger},
+		{Name: "ssn", Type: TypeString, Nullable: true, Encrypted: true},
+	}
+	if columns := inferrer.Columns(); !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected columns\n%+v\ngot\n%+v", expected, columns)
+	}
+	document, err := inferrer.JSONSchema("encrypted")
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	if !bytes.Contains(document, []byte(`"x-encrypted": true`)) {
+		t.Errorf("Expected the encrypted column to be marked:\n%s", document)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	inferrer := NewInferrer([]string{"zeta", "alpha", "edge"})
+	inferrer.SetH3("edge", H3Info{Resolution: 8, Kind: KindDirectedEdge})
+	inferrer.Add([]string{"1", "", "1188a5430ffffff"})
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := inferrer.WriteFile(path, "output.csv"); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+
+	var document struct {
+		Schema     string                     `json:"$schema"`
+		Title      string                     `json:"title"`
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+		Order      []string                   `json:"x-column-order"`
+		Rows       int                        `json:"x-rows"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v\n%s", err, content)
+	}
+	if document.Title != "output.csv" || document.Type != "object" || document.Rows != 1 || document.Schema == "" {
+		t.Errorf("Unexpected schema header: %+v", document)
+	}
+	if !reflect.DeepEqual(document.Order, []string{"zeta", "alpha", "edge"}) {
+		t.Errorf("Expected columns in input order, got %v", document.Order)
+	}
+	if !reflect.DeepEqual(document.Required, []string{"zeta", "edge"}) {
+		t.Errorf("Expected non-nullable columns to be required, got %v", document.Required)
+	}
+	if got := compact(t, document.Properties["alpha"]); got != `{"type":["string","null"]}` {
+		t.Errorf("Unexpected property for an empty column: %s", got)
+	}
+	expected := `{"type":"string","pattern":"^[0-9a-f]{15}$","x-h3":{"resolution":8,"kind":"directed_edge"}}`
+	if got := compact(t, document.Properties["edge"]); got != expected {
+		t.Errorf("Expected edge property %s, got %s", expected, got)
+	}
+}
+
+func compact(t *testing.T, raw json.RawMessage) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, raw); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", raw, err)
+	}
+	return buffer.String()
+}
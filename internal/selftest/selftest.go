@@ -0,0 +1,163 @@
+package selftest
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/h3"
+)
+
+// memoryProbeSize is the size of the buffer allocated by the memory check
+const memoryProbeSize = 64 << 20 // 64 MiB
+
+// goldenCase is a known coordinate and its expected H3 index at a resolution
+type goldenCase struct {
+	name       string
+	lat, lng   float64
+	resolution int
+	expected   string
+}
+
+// goldenCases are known coordinates with their expected H3 indexes
+var goldenCases = []goldenCase{
+	{"San Francisco r9 (H3 docs)", 37.775938728915946, -122.41795063018799, 9, "8928308280fffff"},
+	{"New York r0", 40.7128, -74.0060, 0, "802bfffffffffff"},
+	{"New York r5", 40.7128, -74.0060, 5, "852a1073fffffff"},
+	{"New York r8", 40.7128, -74.0060, 8, "882a107289fffff"},
+	{"New York r15", 40.7128, -74.0060, 15, "8f2a10728906185"},
+	{"London r8", 51.5074, -0.1278, 8, "88195da49bfffff"},
+	{"Sydney r9", -33.8688, 151.2093, 9, "89be0e35cbbffff"},
+	{"Null Island r8", 0, 0, 8, "88754e6499fffff"},
+	{"Near north pole r5", 89.9, 0, 5, "85032623fffffff"},
+	{"Near south pole and antimeridian r8", -89.9, 179.9, 8, "88f2939521fffff"},
+}
+
+// Result is the outcome of a single self-test check
+type Result struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// check is a named self-test returning a detail message or an error
+type check struct {
+	name string
+	run  func() (string, error)
+}
+
+// Run executes all golden cases and environment checks
+func Run() []Result {
+	var checks []check
+
+	generator := h3.NewH3Generator()
+	for _, gc := range goldenCases {
+		gc := gc
+		checks = append(checks, check{"golden: " + gc.name, func() (string, error) {
+			index, err := generator.Generate(gc.lat, gc.lng, h3.H3Resolution(gc.resolution))
+			if err != nil {
+				return "", err
+			}
+			if index != gc.expected {
+				return "", fmt.Errorf("expected %s, got %s", gc.expected, index)
+			}
+			return index, nil
+		}})
+	}
+
+	checks = append(checks,
+		check{"environment: temp dir writable", checkTempDir},
+		check{"environment: number locales", checkNumberLocales},
+		check{"environment: memory", checkMemory},
+	)
+
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		detail, err := c.run()
+		if err != nil {
+			results = append(results, Result{Name: c.name, Passed: false, Detail: err.Error()})
+			continue
+		}
+		results = append(results, Result{Name: c.name, Passed: true, Detail: detail})
+	}
+	return results
+}
+
+// Passed reports whether all results passed
+func Passed(results []Result) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTempDir verifies a file can be created, written, and removed in the temp directory
+func checkTempDir() (string, error) {
+	dir := os.TempDir()
+	file, err := os.CreateTemp(dir, "csv-h3-selftest-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot create file in %s: %w", dir, err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString("latitude,longitude\n"); err != nil {
+		file.Close()
+		return "", fmt.Errorf("cannot write to %s: %w", file.Name(), err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("cannot close %s: %w", file.Name(), err)
+	}
+	return dir, nil
+}
+
+// checkNumberLocales verifies locale-aware coordinate parsing and reports the process locale
+func checkNumberLocales() (string, error) {
+	samples := map[string]string{
+		"en": "1,234.5",
+		"de": "1.234,5",
+		"fr": "1 234,5",
+		"ch": "1'234.5",
+	}
+	for _, name := range csv.SupportedNumberLocales() {
+		value, err := csv.ParseNumber(samples[name], name)
+		if err != nil {
+			return "", fmt.Errorf("locale %s: %w", name, err)
+		}
+		if value != 1234.5 {
+			return "", fmt.Errorf("locale %s: parsed %q as %g, expected 1234.5", name, samples[name], value)
+		}
+	}
+
+	locale := os.Getenv("LC_ALL")
+	if locale == "" {
+		locale = os.Getenv("LANG")
+	}
+	if locale == "" {
+		locale = "unset"
+	}
+	return fmt.Sprintf("LANG/LC_ALL=%s (coordinate parsing is locale-independent)", locale), nil
+}
+
+// checkMemory verifies a working buffer can be allocated and reports runtime memory
+func checkMemory() (detail string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to allocate %d MiB: %v", memoryProbeSize>>20, r)
+		}
+	}()
+
+	buffer := make([]byte, memoryProbeSize)
+	for i := 0; i < len(buffer); i += 4096 {
+		buffer[i] = 1 // Touch every page so the memory is actually committed
+	}
+	buffer = nil
+	runtime.GC()
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return fmt.Sprintf("allocated %d MiB probe; runtime using %d MiB, %d CPUs",
+		memoryProbeSize>>20, stats.Sys>>20, runtime.NumCPU()), nil
+}
@@ -0,0 +1,24 @@
+package selftest
+
+import "testing"
+
+func TestRun(t *testing.T) {
+	results := Run()
+	if len(results) != len(goldenCases)+3 {
+		t.Errorf("Expected %d results, got %d", len(goldenCases)+3, len(results))
+	}
+	for _, result := range results {
+		if !result.Passed {
+			t.Errorf("%s failed: %s", result.Name, result.Detail)
+		}
+	}
+	if !Passed(results) {
+		t.Error("Expected all checks to pass")
+	}
+}
+
+func TestPassed(t *testing.T) {
+	if Passed([]Result{{Name: "a", Passed: true}, {Name: "b", Passed: false}}) {
+		t.Error("Expected Passed to be false when a check fails")
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"csv-h3-tool/internal/filehandler"
+)
+
+// Names of the entries describing a zip archive output
+const (
+	ArchiveManifestFile = "manifest.json"
+	ArchiveSummaryFile  = "summary.json"
+)
+
+// ArchiveManifest lists the output files packaged in a zip archive
+type ArchiveManifest struct {
+	Created time.Time      `json:"created"`
+	Files   []ArchiveEntry `json:"files"`
+}
+
+// ArchiveEntry is one output file of an archive
+type ArchiveEntry struct {
+	Name   string `json:"name"`             // Path inside the archive
+	Source string `json:"source,omitempty"` // Input file the output was produced from, in batches
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// BatchArchiveSummary is the summary.json of a batch archive
+type BatchArchiveSummary struct {
+	Files          int    `json:"files"`
+	Packaged       int    `json:"packaged"`
+	FailedFiles    int    `json:"failed_files"`
+	SkippedFiles   int    `json:"skipped_files"`
+	UpToDateFiles  int    `json:"up_to_date_files"`
+	TotalRecords   int    `json:"total_records"`
+	ValidRecords   int    `json:"valid_records"`
+	InvalidRecords int    `json:"invalid_records"`
+	ProcessingTime string `json:"processing_time"`
+}
+
+// PartitionArchiveSummary is the summary.json of a partitioned output archive
+type PartitionArchiveSummary struct {
+	InputFile      string `json:"input_file"`
+	Resolution     int    `json:"resolution"`
+	Partitions     int    `json:"partitions"`
+	TotalRecords   int    `json:"total_records"`
+	ValidRecords   int    `json:"valid_records"`
+	InvalidRecords int    `json:"invalid_records"`
+	ProcessingTime string `json:"processing_time"`
+}
+
+// archiveWriter streams output files into a zip archive one entry at a time. The
+// archive is written to a temporary file and moved into place by close.
+type archiveWriter struct {
+	path     string
+	file     *os.File
+	zip      *zip.Writer
+	manifest ArchiveManifest
+}
+
+// createArchive starts a zip archive to be written to path
+func createArchive(path string) (*archiveWriter, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), ".archive-*.zip.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive %s: %w", path, err)
+	}
+	return &archiveWriter{
+		path:     path,
+		file:     file,
+		zip:      zip.NewWriter(file),
+		manifest: ArchiveManifest{Created: time.Now().UTC(), Files: []ArchiveEntry{}},
+	}, nil
+}
+
+// addFile copies the file at path into the archive as name, recording its size and
+// checksum in the manifest
+func (a *archiveWriter) addFile(name, path, source string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for the archive: %w", path, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s for the archive: %w", path, err)
+	}
+
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()}
+	entry, err := a.zip.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to the archive: %w", name, err)
+	}
+	digest := sha256.New()
+	written, err := io.Copy(io.MultiWriter(entry, digest), file)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to the archive: %w", name, err)
+	}
+	a.manifest.Files = append(a.manifest.Files, ArchiveEntry{
+		Name:   name,
+		Source: source,
+		Bytes:  written,
+		SHA256: hex.EncodeToString(digest.Sum(nil)),
+	})
+	return nil
+}
+
+// addJSON writes value as an indented JSON entry
+func (a *archiveWriter) addJSON(name string, value any) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	entry, err := a.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.manifest.Created})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to the archive: %w", name, err)
+	}
+	if _, err := entry.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to add %s to the archive: %w", name, err)
+	}
+	return nil
+}
+
+// close writes the manifest and summary entries and moves the archive into place
+func (a *archiveWriter) close(summary any) error {
+	if err := a.addJSON(ArchiveManifestFile, a.manifest); err != nil {
+		return err
+	}
+	if err := a.addJSON(ArchiveSummaryFile, summary); err != nil {
+		return err
+	}
+	if err := a.zip.Close(); err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", a.path, err)
+	}
+	if err := a.file.Close(); err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", a.path, err)
+	}
+	if err := os.Rename(a.file.Name(), a.path); err != nil {
+		return fmt.Errorf("failed to move archive into place at %s: %w", a.path, err)
+	}
+	return nil
+}
+
+// abort discards a partially written archive
+func (a *archiveWriter) abort() {
+	a.file.Close()
+	os.Remove(a.file.Name())
+}
+
+// WriteArchive packages the output files of the batch's processed and up-to-date
+// files into a zip archive at path, with a manifest and a summary of the batch
+func (b *BatchProcessor) WriteArchive(path string, result *BatchResult) (*ArchiveManifest, error) {
+	fileHandler := filehandler.NewFileHandler()
+	archive, err := createArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string)
+	for _, file := range result.Files {
+		var outputFile string
+		switch {
+		case file.UpToDate:
+			if outputFile, err = fileHandler.GenerateTemplatedOutputPath(file.InputFile, b.config.OutputNameTemplate, b.config.Resolution); err != nil {
+				archive.abort()
+				return nil, err
+			}
+		case file.Result != nil:
+			outputFile = file.Result.OutputFile
+		default:
+			continue // Failed or locked
+		}
+
+		name := filepath.Base(outputFile)
+		if other, exists := names[name]; exists {
+			archive.abort()
+			return nil, fmt.Errorf("outputs of %s and %s would both be packaged as %s", other, file.InputFile, name)
+		}
+		names[name] = file.InputFile
+		if err := archive.addFile(name, outputFile, file.InputFile); err != nil {
+			archive.abort()
+			return nil, err
+		}
+	}
+
+	summary := BatchArchiveSummary{
+		Files:          len(result.Files),
+		Packaged:       len(archive.manifest.Files),
+		FailedFiles:    result.FailedFiles,
+		SkippedFiles:   result.SkippedFiles,
+		UpToDateFiles:  result.UpToDateFiles,
+		TotalRecords:   result.TotalRecords,
+		ValidRecords:   result.ValidRecords,
+		InvalidRecords: result.InvalidRecords,
+		ProcessingTime: result.ProcessingTime.String(),
+	}
+	if err := archive.close(summary); err != nil {
+		archive.abort()
+		return nil, err
+	}
+	return &archive.manifest, nil
+}
+
+// writePartitionArchive packages the files written to the partitioned output directory,
+// and the signature of its manifest, into the --output zip archive, named by their path
+// relative to the directory. Other files in the directory are left out.
+func (o *Orchestrator) writePartitionArchive(result *ProcessResult) error {
+	archive, err := createArchive(o.config.OutputFile)
+	if err != nil {
+		return err
+	}
+	files := result.PartitionFiles
+	if result.SignatureFile != "" {
+		if name, err := filepath.Rel(o.config.OutputDir, result.SignatureFile); err == nil && !strings.HasPrefix(name, "..") {
+			files = append(files, filepath.ToSlash(name))
+		}
+	}
+	for _, name := range files {
+		if err := archive.addFile(name, filepath.Join(o.config.OutputDir, filepath.FromSlash(name)), ""); err != nil {
+			archive.abort()
+			return err
+		}
+	}
+
+	summary := PartitionArchiveSummary{
+		InputFile:      o.config.InputFile,
+		Resolution:     o.config.Resolution,
+		Partitions:     result.Partitions,
+		TotalRecords:   result.TotalRecords,
+		ValidRecords:   result.ValidRecords,
+		InvalidRecords: result.InvalidRecords,
+		ProcessingTime: result.ProcessingTime.String(),
+	}
+	if err := archive.close(summary); err != nil {
+		archive.abort()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"io"
+
+	"csv-h3-tool/internal/avro"
+	"csv-h3-tool/internal/schema"
+)
+
+// newAvroEncoder writes an Avro container file
+func newAvroEncoder(out io.Writer, name string, columns []schema.Column, h3Long bool) (rowEncoder, error) {
+	return avro.NewWriter(out, name, avroFields(columns, h3Long))
+}
+
+// avroFields maps inferred column types to Avro fields, with H3 columns stored as
+// strings or as longs
+func avroFields(columns []schema.Column, h3Long bool) []avro.Field {
+	types := map[string]string{
+		schema.TypeString:  avro.TypeString,
+		schema.TypeInteger: avro.TypeLong,
+		schema.TypeNumber:  avro.TypeDouble,
+		schema.TypeBoolean: avro.TypeBoolean,
+	}
+	fields := make([]avro.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = avro.Field{Name: column.Name, Type: types[column.Type], Nullable: column.Nullable}
+		if column.H3 != nil {
+			fields[i].Props = map[string]any{"x-h3": column.H3}
+			if h3Long {
+				fields[i].Type, fields[i].Hex = avro.TypeLong, true
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/filehandler"
+	"csv-h3-tool/internal/logging"
+)
+
+// BatchFileResult holds the outcome of processing one file in a batch
+type BatchFileResult struct {
+	InputFile string
+	Result    *ProcessResult // nil when processing failed or the file was skipped
+	Err       error
+	Skipped   bool   // Locked by another instance processing the same file
+	UpToDate  bool   // Processed by an earlier run and unchanged since; not processed again
+	SHA256    string // Checksum of the input file, empty if it could not be read
+}
+
+// BatchResult contains the combined results of processing several files
+type BatchResult struct {
+	Files          []BatchFileResult // In input order
+	TotalRecords   int
+	ValidRecords   int
+	InvalidRecords int
+	logging.RecordCategories
+	FailedFiles    int
+	SkippedFiles   int // Files locked by another instance
+	UpToDateFiles  int // Files already processed by an earlier run
+	ProcessingTime time.Duration
+}
+
+// BatchProcessor processes several input files concurrently. At most ParallelFiles
+// files are processed at once and the Workers budget is divided between them.
+type BatchProcessor struct {
+	config         *config.Config
+	inputFiles     []string
+	reportInterval time.Duration
+	reporter       func(BatchProgress) // Called every reportInterval while processing
+	logger         logging.Logger      // Shared by the files' orchestrators when set
+	state          *batchState         // Per-file status of earlier runs, nil if disabled
+	configHash     string              // Fingerprint of the settings recorded in the state
+	files          []*FileProgress     // Per-file progress, in input order
+	started        time.Time
+
+	records   atomic.Int64 // Records processed across all files
+	completed atomic.Int64 // Files finished (successfully or not)
+	running   atomic.Int64 // Files currently being processed
+}
+
+// FileState is the processing state of a file in a batch
+type FileState int32
+
+const (
+	FilePending FileState = iota
+	FileRunning
+	FileDone
+	FileFailed
+	FileSkipped // Locked by another instance
+)
+
+// String returns the state name
+func (s FileState) String() string {
+	switch s {
+	case FileRunning:
+		return "running"
+	case FileDone:
+		return "done"
+	case FileFailed:
+		return "failed"
+	case FileSkipped:
+		return "skipped"
+	default:
+		return "pending"
+	}
+}
+
+// FileProgress tracks the live progress of one file in a batch
+type FileProgress struct {
+	inputFile string
+	size      int64 // Input size in bytes, 0 if unknown
+
+	state   atomic.Int32
+	records atomic.Int64
+	invalid atomic.Int64
+	offset  atomic.Int64 // Input bytes consumed
+}
+
+// record counts a processed record ending at the given input byte offset
+func (p *FileProgress) record(offset int64, valid bool) {
+	p.records.Add(1)
+	if !valid {
+		p.invalid.Add(1)
+	}
+	// Records may complete out of order with several workers; keep the furthest offset
+	for {
+		current := p.offset.Load()
+		if offset <= current || p.offset.CompareAndSwap(current, offset) {
+			return
+		}
+	}
+}
+
+// FileStatus is a snapshot of one file's progress
+type FileStatus struct {
+	InputFile string
+	State     FileState
+	Size      int64 // Input size in bytes, 0 if unknown
+	Offset    int64 // Input bytes consumed
+	Records   int64
+	Invalid   int64
+}
+
+// Fraction returns the completed fraction of the file in [0, 1]
+func (s FileStatus) Fraction() float64 {
+	switch {
+	case s.State == FileDone || s.State == FileFailed || s.State == FileSkipped:
+		return 1
+	case s.Size <= 0:
+		return 0
+	case s.Offset >= s.Size:
+		return 1
+	default:
+		return float64(s.Offset) / float64(s.Size)
+	}
+}
+
+// BatchProgress is a snapshot of a running batch
+type BatchProgress struct {
+	Files     []FileStatus // In input order
+	Completed int
+	Running   int
+	Failed    int
+	Records   int64
+	Invalid   int64
+	Elapsed   time.Duration
+}
+
+// NewBatchProcessor creates a batch processor. The configuration is used as a
+// template for every file; InputFile and OutputFile are set per file.
+func NewBatchProcessor(cfg *config.Config, inputFiles []string) *BatchProcessor {
+	b := &BatchProcessor{
+		config:         cfg,
+		inputFiles:     inputFiles,
+		reportInterval: 2 * time.Second, // Report progress every 2 seconds
+		files:          make([]*FileProgress, len(inputFiles)),
+	}
+	b.reporter = b.printProgress
+	for i, inputFile := range inputFiles {
+		b.files[i] = &FileProgress{inputFile: inputFile}
+		if size, err := csv.InputSize(inputFile); err == nil {
+			b.files[i].size = size
+		}
+	}
+	return b
+}
+
+// SetProgressReporter replaces the plain progress lines with a custom reporter
+// called every interval while the batch is processed
+func (b *BatchProcessor) SetProgressReporter(interval time.Duration, reporter func(BatchProgress)) {
+	b.reportInterval = interval
+	b.reporter = reporter
+}
+
+// SetLogger sets the logger used while processing every file of the batch, each file's
+// messages prefixed with its name when the logger is a StandardLogger
+func (b *BatchProcessor) SetLogger(logger logging.Logger) {
+	b.logger = logger
+}
+
+// Progress returns a snapshot of the batch progress
+func (b *BatchProcessor) Progress() BatchProgress {
+	progress := BatchProgress{
+		Files:     make([]FileStatus, len(b.files)),
+		Completed: int(b.completed.Load()),
+		Running:   int(b.running.Load()),
+		Records:   b.records.Load(),
+	}
+	if !b.started.IsZero() {
+		progress.Elapsed = time.Since(b.started)
+	}
+	for i, file := range b.files {
+		status := FileStatus{
+			InputFile: file.inputFile,
+			State:     FileState(file.state.Load()),
+			Size:      file.size,
+			Offset:    file.offset.Load(),
+			Records:   file.records.Load(),
+			Invalid:   file.invalid.Load(),
+		}
+		if status.State == FileFailed {
+			progress.Failed++
+		}
+		progress.Invalid += status.Invalid
+		progress.Files[i] = status
+	}
+	return progress
+}
+
+// ParallelFiles returns the number of files processed concurrently
+func (b *BatchProcessor) ParallelFiles() int {
+	parallel := b.config.ParallelFiles
+	if parallel < 1 {
+		parallel = 1
+	}
+	if parallel > len(b.inputFiles) && len(b.inputFiles) > 0 {
+		parallel = len(b.inputFiles)
+	}
+	return parallel
+}
+
+// WorkersPerFile returns each file's share of the H3 worker budget
+func (b *BatchProcessor) WorkersPerFile() int {
+	workers := b.config.Workers / b.ParallelFiles()
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// CheckOutputNames renders the output name of every input file and reports an error
+// if the template is invalid or two inputs would be written to the same output file
+func (b *BatchProcessor) CheckOutputNames() error {
+	fileHandler := filehandler.NewFileHandler()
+	outputs := make(map[string]string, len(b.inputFiles))
+	for _, inputFile := range b.inputFiles {
+		outputFile, err := fileHandler.GenerateTemplatedOutputPath(inputFile, b.config.OutputNameTemplate, b.config.Resolution)
+		if err != nil {
+			return err
+		}
+		if other, exists := outputs[outputFile]; exists {
+			return fmt.Errorf("input files %s and %s would both be written to %s", other, inputFile, outputFile)
+		}
+		outputs[outputFile] = inputFile
+	}
+	return nil
+}
+
+// LoadState loads the batch state file so that inputs processed successfully by an
+// earlier run with the same settings, and unchanged since, are skipped (unless Force or
+// Overwrite is set). Files processed by this run are recorded in it. Does nothing if no
+// state file is configured.
+func (b *BatchProcessor) LoadState() error {
+	if b.config.BatchStateFile == "" {
+		return nil
+	}
+	configHash, err := configFingerprint(b.config)
+	if err != nil {
+		return err
+	}
+	state, err := loadBatchState(b.config.BatchStateFile)
+	if err != nil {
+		return err
+	}
+	b.state, b.configHash = state, configHash
+	return nil
+}
+
+// Process processes all input files and returns the combined result.
+// A failing file does not stop the other files; check FailedFiles.
+func (b *BatchProcessor) Process() *BatchResult {
+	startTime := time.Now()
+	b.started = startTime
+	result := &BatchResult{Files: make([]BatchFileResult, len(b.inputFiles))}
+
+	done := make(chan struct{})
+	var progress sync.WaitGroup
+	progress.Add(1)
+	go func() {
+		defer progress.Done()
+		b.reportProgress(done)
+	}()
+	if b.config.StatsInterval > 0 {
+		progress.Add(1)
+		go func() {
+			defer progress.Done()
+			b.reportStats(done)
+		}()
+	}
+
+	// Schedule files, bounded by the number of parallel files
+	slots := make(chan struct{}, b.ParallelFiles())
+	var wg sync.WaitGroup
+	for i, inputFile := range b.inputFiles {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, inputFile string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			lock, err := b.lock(inputFile)
+			if err == filehandler.ErrLocked {
+				b.files[i].state.Store(int32(FileSkipped))
+				b.completed.Add(1)
+				result.Files[i] = BatchFileResult{InputFile: inputFile, Skipped: true}
+				return
+			}
+
+			b.running.Add(1)
+			b.files[i].state.Store(int32(FileRunning))
+			fileResult := BatchFileResult{InputFile: inputFile}
+			if err == nil {
+				err = b.processOnce(b.files[i], &fileResult)
+				lock.Unlock()
+			}
+			fileResult.Err = err
+			if err != nil {
+				b.files[i].state.Store(int32(FileFailed))
+			} else {
+				b.files[i].state.Store(int32(FileDone))
+			}
+			b.running.Add(-1)
+			b.completed.Add(1)
+
+			result.Files[i] = fileResult
+		}(i, inputFile)
+	}
+	wg.Wait()
+
+	close(done)
+	progress.Wait()
+
+	// Combine per-file results
+	for _, file := range result.Files {
+		if file.Skipped {
+			result.SkippedFiles++
+			continue
+		}
+		if file.UpToDate {
+			result.UpToDateFiles++
+			continue
+		}
+		if file.Err != nil {
+			result.FailedFiles++
+			continue
+		}
+		result.TotalRecords += file.Result.TotalRecords
+		result.ValidRecords += file.Result.ValidRecords
+		result.InvalidRecords += file.Result.InvalidRecords
+		result.RecordCategories.Add(file.Result.RecordCategories)
+	}
+	result.ProcessingTime = time.Since(startTime)
+
+	return result
+}
+
+// lock takes the advisory lock guarding an input file so that concurrent instances
+// processing the same directory never process a file twice; it returns
+// filehandler.ErrLocked if another instance holds it
+func (b *BatchProcessor) lock(inputFile string) (*filehandler.FileLock, error) {
+	path, err := filehandler.NewFileHandler().LockPath(inputFile, b.config.LockDir)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := filehandler.TryLock(path)
+	if err != nil && err != filehandler.ErrLocked && b.config.LockDir == "" {
+		return nil, fmt.Errorf("%w (use --lock-dir to keep lock files elsewhere)", err)
+	}
+	return lock, err
+}
+
+// processOnce checksums a file of the batch and processes it unless the batch state
+// shows it was already processed with the same content, recording it when done
+func (b *BatchProcessor) processOnce(progress *FileProgress, result *BatchFileResult) error {
+	checksum, err := fileChecksum(progress.inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", progress.inputFile, err)
+	}
+	result.SHA256 = checksum
+
+	var outputFile string
+	if b.state != nil {
+		outputFile, err = filehandler.NewFileHandler().GenerateTemplatedOutputPath(progress.inputFile, b.config.OutputNameTemplate, b.config.Resolution)
+		if err != nil {
+			return err
+		}
+		_, done := b.state.processed(progress.inputFile, checksum, b.configHash, outputFile)
+		if done && !b.config.Force && !b.config.Overwrite {
+			result.UpToDate = true
+			return nil
+		}
+	}
+
+	if result.Result, err = b.processFile(progress); err != nil {
+		return err
+	}
+	if b.state != nil {
+		if err := b.state.complete(progress.inputFile, checksum, b.configHash, outputFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processFile processes a single file of the batch with its own copy of the configuration
+func (b *BatchProcessor) processFile(progress *FileProgress) (*ProcessResult, error) {
+	fileConfig := *b.config
+	fileConfig.InputFile = progress.inputFile
+	fileConfig.OutputFile = "" // Generated from the input file name
+	fileConfig.Workers = b.WorkersPerFile()
+
+	orchestrator := NewOrchestrator(&fileConfig)
+	orchestrator.SetRecordCounter(&b.records)
+	orchestrator.SetFileProgress(progress)
+	if standard, ok := b.logger.(*logging.StandardLogger); ok {
+		orchestrator.SetLogger(standard.WithPrefix(filepath.Base(progress.inputFile)))
+	} else if b.logger != nil {
+		orchestrator.SetLogger(b.logger)
+	}
+	if err := orchestrator.ValidateComponents(); err != nil {
+		return nil, err
+	}
+	return orchestrator.ProcessFile()
+}
+
+// reportProgress periodically reports progress until done is closed
+func (b *BatchProcessor) reportProgress(done <-chan struct{}) {
+	ticker := time.NewTicker(b.reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			b.reporter(b.Progress())
+		}
+	}
+}
+
+// statsWindow holds the batch totals at the previous statistics report, so that each
+// report covers the activity since the one before it
+type statsWindow struct {
+	start     time.Time
+	records   int64
+	invalid   int64
+	completed int
+}
+
+// reportStats logs statistics every StatsInterval until done is closed, and a final
+// report for the last window
+func (b *BatchProcessor) reportStats(done <-chan struct{}) {
+	logger := b.logger
+	if logger == nil {
+		logger = logging.NewDefaultLogger(b.config.Verbose)
+	}
+	window := statsWindow{start: b.started}
+	ticker := time.NewTicker(b.config.StatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			b.logStats(logger, &window)
+			return
+		case <-ticker.C:
+			b.logStats(logger, &window)
+		}
+	}
+}
+
+// logStats logs the batch totals and the activity in the window ending now, then
+// starts a new window
+func (b *BatchProcessor) logStats(logger logging.Logger, window *statsWindow) {
+	progress := b.Progress()
+	now := time.Now()
+	elapsed := now.Sub(window.start)
+	records := progress.Records - window.records
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(records) / elapsed.Seconds()
+	}
+	logger.Info("Stats: last %s: %d records (%.0f/s), %d invalid, %d files completed; total: %d records, %d invalid, %d/%d files completed, %d failed",
+		elapsed.Round(time.Millisecond), records, rate, progress.Invalid-window.invalid, progress.Completed-window.completed,
+		progress.Records, progress.Invalid, progress.Completed, len(progress.Files), progress.Failed)
+	*window = statsWindow{start: now, records: progress.Records, invalid: progress.Invalid, completed: progress.Completed}
+}
+
+// printProgress prints aggregated progress as a plain line (the default reporter)
+func (b *BatchProcessor) printProgress(progress BatchProgress) {
+	fmt.Printf("Progress: %d/%d files complete, %d in progress, %d records processed\n",
+		progress.Completed, len(progress.Files), progress.Running, progress.Records)
+}
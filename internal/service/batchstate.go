@@ -0,0 +1,194 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/filehandler"
+)
+
+// batchStateLockTimeout bounds how long recording a file waits for another instance
+// recording one in the same batch state
+const batchStateLockTimeout = 10 * time.Second
+
+// batchFileState records a successfully processed batch input
+type batchFileState struct {
+	SHA256      string    `json:"sha256"`
+	ConfigHash  string    `json:"config_hash"` // Fingerprint of the settings the output was produced with
+	OutputFile  string    `json:"output_file"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// runOnlySettings are the configuration keys that do not affect the output of a file,
+// left out of the configuration fingerprint
+var runOnlySettings = []string{
+	"input_file", "output_file", "overwrite", "verbose", "no_color", "show_invalid", "warn_limit",
+	"workers", "parallel_files", "mmap", "temp_dir", "lock_dir", "batch_state_file", "force",
+	"audit_log", "history_file", "no_history", "notify_webhook", "notify_command",
+	"log_file", "log_format", "tui", "stats_interval", "profile", "profile_file",
+}
+
+// configFingerprint returns a hash of the settings that shape a batch file's output
+// (resolution, columns, output options, ...), so that outputs produced with other
+// settings are not taken as up to date
+func configFingerprint(cfg *config.Config) (string, error) {
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(content, &settings); err != nil {
+		return "", fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	for _, key := range runOnlySettings {
+		delete(settings, key)
+	}
+	// Map keys are encoded in sorted order, so equal settings give equal hashes
+	content, err = json.Marshal(settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	digest := sha256.Sum256(content)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// batchState is the persistent per-file status of batch runs, keyed by absolute input
+// path, so that rerunning a batch skips the inputs that were already processed.
+// Every change is written atomically (temp file + rename). Instances sharing the state
+// re-read it before each check and change, so a file one instance completed is seen
+// by the others and their records do not overwrite each other.
+type batchState struct {
+	path  string
+	mu    sync.Mutex
+	Files map[string]batchFileState `json:"files"`
+}
+
+// loadBatchState loads the batch state at path, or an empty state if it does not exist
+func loadBatchState(path string) (*batchState, error) {
+	state := &batchState{path: path}
+	if err := state.reload(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// reload replaces the files with those recorded on disk
+func (s *batchState) reload() error {
+	s.Files = make(map[string]batchFileState)
+	content, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read batch state %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(content, s); err != nil {
+		return fmt.Errorf("failed to parse batch state %s: %w", s.path, err)
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]batchFileState)
+	}
+	return nil
+}
+
+// processed returns the recorded state of inputFile if it was processed with the same
+// content and settings into outputFile and that output still exists. The state is
+// re-read first, so a file completed by another instance since the batch started is
+// seen as processed; call it holding the file's batch lock.
+func (s *batchState) processed(inputFile, checksum, configHash, outputFile string) (batchFileState, bool) {
+	key, err := filepath.Abs(inputFile)
+	if err != nil {
+		return batchFileState{}, false
+	}
+
+	s.mu.Lock()
+	err = s.reload()
+	file, ok := s.Files[key]
+	s.mu.Unlock()
+	if err != nil {
+		return batchFileState{}, false
+	}
+	if !ok || file.SHA256 != checksum || file.ConfigHash != configHash || file.OutputFile != outputFile {
+		return batchFileState{}, false
+	}
+	if _, err := os.Stat(outputFile); err != nil {
+		return batchFileState{}, false
+	}
+	return file, true
+}
+
+// complete records inputFile as successfully processed into outputFile, adding it to
+// the latest state on disk under the state's lock file
+func (s *batchState) complete(inputFile, checksum, configHash, outputFile string) error {
+	key, err := filepath.Abs(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", inputFile, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lock, err := filehandler.Lock(s.path+".lock", batchStateLockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock batch state %s: %w", s.path, err)
+	}
+	defer lock.Unlock()
+	if err := s.reload(); err != nil {
+		return err
+	}
+	s.Files[key] = batchFileState{SHA256: checksum, ConfigHash: configHash, OutputFile: outputFile, CompletedAt: time.Now()}
+	return s.save()
+}
+
+// save atomically writes the state to disk
+func (s *batchState) save() error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode batch state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".batch-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write batch state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write batch state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write batch state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace batch state %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// fileChecksum returns the hex SHA-256 digest of a file, or of the whole archive for a
+// zip archive member
+func fileChecksum(path string) (string, error) {
+	if archive, _, ok := csv.SplitZipPath(path); ok {
+		path = archive
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
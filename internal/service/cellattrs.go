@@ -0,0 +1,110 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/h3"
+)
+
+// cellAttributeColumns returns the output columns describing each record's H3 cell
+func (o *Orchestrator) cellAttributeColumns() []string {
+	var columns []string
+	if o.config.AddCentroid {
+		columns = append(columns, "h3_lat", "h3_lng")
+	}
+	if o.config.AddCellMeta {
+		columns = append(columns, "h3_area_km2", "h3_edge_length_km")
+	}
+	if o.config.FlagPentagons {
+		columns = append(columns, "is_pentagon")
+	}
+	if o.config.AddBaseCell {
+		columns = append(columns, "h3_base_cell", "h3_face")
+	}
+	if o.config.AddVertexes {
+		columns = append(columns, "h3_vertexes")
+	}
+	if o.config.AddLocalIJ {
+		columns = append(columns, "local_i", "local_j")
+	}
+	return columns
+}
+
+// setCellAttributes computes the attributes of a record's H3 cell, leaving the columns
+// empty for records without a cell. Returns false when a valid record's cell has no
+// local IJ coordinates relative to the anchor.
+func (o *Orchestrator) setCellAttributes(record *csv.Record) bool {
+	var lat, lng, area, edge, pentagon, baseCell, face, vertexes, localI, localJ string
+	located := true
+	if record.IsValid && record.H3Index != "" {
+		if o.config.AddCentroid {
+			if centerLat, centerLng, err := h3.CellCentroid(record.H3Index); err == nil {
+				lat = strconv.FormatFloat(centerLat, 'f', 6, 64)
+				lng = strconv.FormatFloat(centerLng, 'f', 6, 64)
+			}
+		}
+		if o.config.AddCellMeta {
+			if km2, err := h3.CellAreaKm2(record.H3Index); err == nil {
+				area = strconv.FormatFloat(km2, 'f', -1, 64)
+			}
+			if km, err := h3.AverageEdgeLengthKm(o.config.Resolution); err == nil {
+				edge = strconv.FormatFloat(km, 'f', -1, 64)
+			}
+		}
+		if o.config.FlagPentagons {
+			if isPentagon, err := h3.IsPentagon(record.H3Index); err == nil {
+				pentagon = strconv.FormatBool(isPentagon)
+			}
+		}
+		if o.config.AddBaseCell {
+			if number, faces, err := h3.BaseCell(record.H3Index); err == nil {
+				baseCell = strconv.Itoa(number)
+				names := make([]string, len(faces))
+				for i, f := range faces {
+					names[i] = strconv.Itoa(f)
+				}
+				face = strings.Join(names, ";")
+			}
+		}
+		if o.config.AddVertexes {
+			if indexes, err := h3.VertexIndexes(record.H3Index); err == nil {
+				vertexes = strings.Join(indexes, ";")
+			}
+		}
+		if o.config.AddLocalIJ {
+			i, j, err := h3.LocalIJ(o.config.Anchor, record.H3Index)
+			if err != nil {
+				located = false
+				o.logger.Debug("Line %d: %v", record.LineNumber, err)
+			} else {
+				localI, localJ = strconv.Itoa(i), strconv.Itoa(j)
+			}
+		}
+	}
+
+	if o.config.AddCentroid {
+		record.SetExtra("h3_lat", lat)
+		record.SetExtra("h3_lng", lng)
+	}
+	if o.config.AddCellMeta {
+		record.SetExtra("h3_area_km2", area)
+		record.SetExtra("h3_edge_length_km", edge)
+	}
+	if o.config.FlagPentagons {
+		record.SetExtra("is_pentagon", pentagon)
+	}
+	if o.config.AddBaseCell {
+		record.SetExtra("h3_base_cell", baseCell)
+		record.SetExtra("h3_face", face)
+	}
+	if o.config.AddVertexes {
+		record.SetExtra("h3_vertexes", vertexes)
+	}
+	if o.config.AddLocalIJ {
+		record.SetExtra("local_i", localI)
+		record.SetExtra("local_j", localJ)
+	}
+	return located
+}
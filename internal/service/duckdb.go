@@ -0,0 +1,36 @@
+package service
+
+import (
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/duckdb"
+	"csv-h3-tool/internal/errors"
+)
+
+// duckdbWriter is a record sink inserting output rows into a DuckDB table
+type duckdbWriter struct {
+	*duckdb.Writer
+	extraColumns []string
+}
+
+// newDuckDBWriter creates the configured table in the output DuckDB database
+func (o *Orchestrator) newDuckDBWriter(headers []string, extraColumns []string) (*duckdbWriter, error) {
+	_, _, h3Column := o.config.CoordinateColumns()
+	columns := csv.OutputHeaders(headers, h3Column, extraColumns)
+	writer, err := duckdb.Create(o.config.OutputFile, o.config.Table, columns, o.config.Overwrite)
+	if err != nil {
+		return nil, errors.NewFileError(o.config.OutputFile, "create", err)
+	}
+	return &duckdbWriter{Writer: writer, extraColumns: extraColumns}, nil
+}
+
+func (w *duckdbWriter) WriteRecord(record *csv.Record) error {
+	row, err := w.FormatRecord(record)
+	if err != nil {
+		return err
+	}
+	return w.WriteRow(row)
+}
+
+func (w *duckdbWriter) FormatRecord(record *csv.Record) ([]string, error) {
+	return csv.FormatOutputRow(record, w.extraColumns)
+}
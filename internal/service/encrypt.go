@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/dedupe"
+	"csv-h3-tool/internal/encrypt"
+	"csv-h3-tool/internal/errors"
+)
+
+// encryptWriter wraps the output sink and encrypts the configured passthrough columns
+// of every formatted row. Records themselves keep their plaintext, so coordinates and
+// dedupe keys are computed as usual; the Redis sink wraps this writer and renders its
+// keys from the records, also for rows buffered for sorting.
+type encryptWriter struct {
+	recordWriter
+	cipher  *encrypt.Cipher
+	columns []int
+}
+
+// newEncryptWriter resolves the encrypted columns against the input headers and wraps
+// the output sink
+func (o *Orchestrator) newEncryptWriter(output recordWriter, headers []string) (*encryptWriter, error) {
+	columns, err := o.encryptedColumns(headers)
+	if err != nil {
+		return nil, err
+	}
+	key, err := o.config.EncryptionKey()
+	if err != nil {
+		return nil, errors.NewConfigError("key_env", o.config.KeyEnv, "invalid encryption key", err)
+	}
+	cipher, err := encrypt.NewCipher(key)
+	if err != nil {
+		return nil, errors.NewConfigError("key_env", o.config.KeyEnv, "invalid encryption key", err)
+	}
+	return &encryptWriter{recordWriter: output, cipher: cipher, columns: columns}, nil
+}
+
+// encryptedColumns resolves --encrypt-columns to input column indexes
+func (o *Orchestrator) encryptedColumns(headers []string) ([]int, error) {
+	columns, err := dedupe.ResolveColumns(strings.Split(o.config.EncryptColumns, ","), headers)
+	if err != nil {
+		return nil, errors.NewConfigError("encrypt_columns", o.config.EncryptColumns, "invalid encrypted columns", err)
+	}
+	return columns, nil
+}
+
+func (w *encryptWriter) WriteRecord(record *csv.Record) error {
+	row, err := w.FormatRecord(record)
+	if err != nil {
+		return err
+	}
+	return w.recordWriter.WriteRow(row)
+}
+
+// FormatRecord formats the record with its encrypted columns
+func (w *encryptWriter) FormatRecord(record *csv.Record) ([]string, error) {
+	protected, err := w.protect(record)
+	if err != nil {
+		return nil, err
+	}
+	return w.recordWriter.FormatRecord(protected)
+}
+
+// protect returns a copy of the record with its encrypted columns encrypted; empty
+// values stay empty
+func (w *encryptWriter) protect(record *csv.Record) (*csv.Record, error) {
+	protected := *record
+	protected.OriginalData = append([]string(nil), record.OriginalData...)
+	for _, column := range w.columns {
+		if column >= len(protected.OriginalData) || protected.OriginalData[column] == "" {
+			continue
+		}
+		encrypted, err := w.cipher.Encrypt(protected.OriginalData[column])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt column %d: %w", column, err)
+		}
+		protected.OriginalData[column] = encrypted
+	}
+	return &protected, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	encodingcsv "encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/errors"
+	"csv-h3-tool/internal/stats"
+)
+
+// EstimateResult is a dry-run projection of the cost of processing a full file
+type EstimateResult struct {
+	FileSize       int64
+	SampledRecords int
+	SampleValid    int
+	// SampleComplete is true when the sample covered the whole file and the figures are exact
+	SampleComplete bool
+
+	PerRecordLatency       time.Duration
+	EstimatedRecords       int64
+	EstimatedRuntime       time.Duration
+	EstimatedOutputBytes   int64
+	EstimatedDistinctCells int64
+
+	// Coordinates summarizes the valid coordinates of the sample
+	Coordinates *stats.Coordinates
+}
+
+// errSampleComplete stops the sampling stream once enough records have been read
+var errSampleComplete = fmt.Errorf("sample complete")
+
+// byteCounter counts bytes written to it
+type byteCounter int64
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+	*b += byteCounter(len(p))
+	return len(p), nil
+}
+
+// Estimate samples the first rows of the input, measures per-record latency, and
+// extrapolates the runtime, output size, and distinct cell count for the full file.
+// No output is written.
+func (o *Orchestrator) Estimate() (*EstimateResult, error) {
+	if err := o.config.Validate(); err != nil {
+		return nil, errors.NewConfigError("", "", "configuration validation failed", err)
+	}
+
+	size, err := csv.InputSize(o.config.InputFile)
+	if err != nil {
+		return nil, errors.NewFileError(o.config.InputFile, "stat", err)
+	}
+
+	reader, err := csv.NewReader(o.config.InputFile, o.readerConfig())
+	if err != nil {
+		return nil, errors.NewFileError(o.config.InputFile, "open", err)
+	}
+	defer reader.Close()
+
+	sampleRows := o.config.EstimateSampleRows
+	if sampleRows <= 0 {
+		sampleRows = 10000
+	}
+
+	// Measure output row sizes with placeholder values for the flag columns
+	extraColumns := o.extraColumns()
+	pairs, err := o.secondaryPairs(reader)
+	if err != nil {
+		return nil, errors.NewConfigError("coord_pairs", o.config.CoordPairs, "invalid coordinate pairs", err)
+	}
+	edges, err := o.edgeSpecs()
+	if err != nil {
+		return nil, errors.NewConfigError("add_edge", o.config.AddEdge, "invalid edges", err)
+	}
+	_, _, h3Column := o.config.CoordinateColumns()
+	var outputBytes byteCounter
+	output := encodingcsv.NewWriter(&outputBytes)
+	if headers := csv.OutputHeaders(reader.GetHeaders(), h3Column, extraColumns); headers != nil {
+		output.Write(headers)
+	}
+	output.Flush()
+	headerBytes := int64(outputBytes)
+
+	result := &EstimateResult{FileSize: size, Coordinates: stats.NewCoordinates(0)}
+	dataStart := reader.InputOffset()
+	dataEnd := dataStart
+	cellCounts := make(map[string]int)
+	stopped := false
+
+	streamProcessor := csv.NewStreamingProcessor(o.validator, &h3GeneratorAdapter{
+		generator: o.h3Generator,
+	})
+
+	// Process the sample sequentially; records carry the input offset at which they end
+	start := time.Now()
+	err = streamProcessor.ProcessStream(reader, csv.Config{
+		Resolution: o.config.Resolution,
+	}, func(record *csv.Record) error {
+		result.SampledRecords++
+		if record.IsValid {
+			result.SampleValid++
+			cellCounts[record.H3Index]++
+			result.Coordinates.Add(record.Latitude, record.Longitude)
+		}
+		o.setPairIndexes(reader, pairs, record)
+		if _, err := o.setEdges(edges, record); err != nil {
+			return err
+		}
+		if o.config.AddDistanceKm || o.config.AddBearing {
+			o.setDistanceBearing(reader, pairs, record)
+		}
+		if o.config.EmitParsedCoords && record.Parsed {
+			record.SetExtra("latitude_parsed", strconv.FormatFloat(record.Latitude, 'f', -1, 64))
+			record.SetExtra("longitude_parsed", strconv.FormatFloat(record.Longitude, 'f', -1, 64))
+		}
+		for _, column := range extraColumns {
+			if _, set := record.Extra[column]; !set {
+				record.SetExtra(column, "false")
+			}
+		}
+		row, err := csv.FormatOutputRow(record, extraColumns)
+		if err != nil {
+			return err
+		}
+		output.Write(row)
+
+		dataEnd = record.InputOffset
+		if result.SampledRecords >= sampleRows {
+			stopped = true
+			return errSampleComplete
+		}
+		return nil
+	})
+	elapsed := time.Since(start)
+	if err != nil && !stopped {
+		return nil, errors.NewProcessingError("estimate", 0, "failed to sample input", err)
+	}
+	output.Flush()
+
+	if result.SampledRecords == 0 {
+		return result, nil
+	}
+
+	result.PerRecordLatency = elapsed / time.Duration(result.SampledRecords)
+	sampleBytes := dataEnd - dataStart
+	dataBytes := result.FileSize - dataStart
+	result.SampleComplete = !stopped || dataEnd >= result.FileSize
+
+	if result.SampleComplete || sampleBytes <= 0 {
+		result.EstimatedRecords = int64(result.SampledRecords)
+		result.EstimatedDistinctCells = int64(len(cellCounts))
+	} else {
+		scale := float64(dataBytes) / float64(sampleBytes)
+		result.EstimatedRecords = int64(float64(result.SampledRecords) * scale)
+		validRecords := int64(float64(result.SampleValid) * scale)
+		result.EstimatedDistinctCells = estimateDistinct(cellCounts, validRecords)
+	}
+
+	rowBytes := float64(int64(outputBytes)-headerBytes) / float64(result.SampledRecords)
+	result.EstimatedOutputBytes = headerBytes + int64(rowBytes*float64(result.EstimatedRecords))
+	result.EstimatedRuntime = result.PerRecordLatency * time.Duration(result.EstimatedRecords)
+
+	return result, nil
+}
+
+// estimateDistinct extrapolates the number of distinct cells from sample frequencies
+// using the bias-corrected Chao1 estimator, capped at the projected number of valid records
+func estimateDistinct(counts map[string]int, maxCells int64) int64 {
+	var singletons, doubletons float64
+	for _, count := range counts {
+		switch count {
+		case 1:
+			singletons++
+		case 2:
+			doubletons++
+		}
+	}
+
+	estimate := int64(float64(len(counts)) + singletons*(singletons-1)/(2*(doubletons+1)))
+	if estimate > maxCells {
+		estimate = maxCells
+	}
+	if estimate < int64(len(counts)) {
+		estimate = int64(len(counts))
+	}
+	return estimate
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"csv-h3-tool/internal/config"
+)
+
+// TestOrchestrator_Estimate tests that a sampled estimate extrapolates to the full file without writing output
+func TestOrchestrator_Estimate(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+
+	var content strings.Builder
+	content.WriteString("latitude,longitude,name\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&content, "%.4f,%.4f,p%04d\n", 40.0+float64(i%10)*0.01, -74.0, i)
+	}
+	if err := os.WriteFile(inputFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+	cfg.Estimate = true
+	cfg.EstimateSampleRows = 100
+
+	estimate, err := NewOrchestrator(cfg).Estimate()
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	if estimate.SampledRecords != 100 || estimate.SampleComplete {
+		t.Errorf("Expected a partial sample of 100 records, got %d (complete: %v)", estimate.SampledRecords, estimate.SampleComplete)
+	}
+	if estimate.EstimatedRecords < 950 || estimate.EstimatedRecords > 1050 {
+		t.Errorf("Expected about 1000 estimated records, got %d", estimate.EstimatedRecords)
+	}
+	if estimate.EstimatedDistinctCells != 10 {
+		t.Errorf("Expected 10 distinct cells, got %d", estimate.EstimatedDistinctCells)
+	}
+	if c := estimate.Coordinates; c.Count != 100 || c.MinLat != 40.0 || c.MaxLat != 40.09 || c.MinLng != -74.0 || c.MaxLng != -74.0 {
+		t.Errorf("Unexpected sample coordinates: %d points, lat [%g, %g], lng [%g, %g]", c.Count, c.MinLat, c.MaxLat, c.MinLng, c.MaxLng)
+	}
+	if _, err := os.Stat(cfg.OutputFile); err == nil {
+		t.Error("Estimate should not write output")
+	}
+
+	// Compare the projected output size to a real run
+	cfg.Estimate = false
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	info, err := os.Stat(result.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to stat output: %v", err)
+	}
+	if diff := estimate.EstimatedOutputBytes - info.Size(); diff < -info.Size()/20 || diff > info.Size()/20 {
+		t.Errorf("Estimated output size %d is not within 5%% of actual %d", estimate.EstimatedOutputBytes, info.Size())
+	}
+}
+
+// TestOrchestrator_EstimateWholeFile tests that small files are measured exactly
+func TestOrchestrator_EstimateWholeFile(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "input.csv")
+	testCSV := "latitude,longitude\n40.7128,-74.0060\n51.5074,-0.1278\ninvalid,0\n"
+	if err := os.WriteFile(inputFile, []byte(testCSV), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.Estimate = true
+
+	estimate, err := NewOrchestrator(cfg).Estimate()
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	if !estimate.SampleComplete || estimate.EstimatedRecords != 3 || estimate.SampleValid != 2 {
+		t.Errorf("Expected exact figures for 3 records (2 valid), got %+v", estimate)
+	}
+	if estimate.EstimatedDistinctCells != 2 {
+		t.Errorf("Expected 2 distinct cells, got %d", estimate.EstimatedDistinctCells)
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/scritchley/orc"
+
+	"csv-h3-tool/internal/schema"
+)
+
+// orcStripeBytes bounds the encoded column data buffered before a stripe is written,
+// keeping memory flat on large outputs (the library default is 200 MiB)
+const orcStripeBytes = 32 << 20
+
+// orcEncoder writes an ORC file; every column is nullable and empty values are null
+type orcEncoder struct {
+	writer  *orc.Writer
+	columns []schema.Column
+	h3Long  bool
+	values  []any
+}
+
+// newORCEncoder writes an ORC file with a struct schema of the inferred columns. The
+// H3 metadata of the index columns is kept in the "csvh3.h3" user metadata item.
+func newORCEncoder(out io.Writer, name string, columns []schema.Column, h3Long bool) (rowEncoder, error) {
+	fields := []orc.TypeDescriptionTransformFunc{orc.SetCategory(orc.CategoryStruct)}
+	h3Columns := map[string]*schema.H3Info{}
+	for _, column := range columns {
+		fields = append(fields, orc.AddField(column.Name, orc.SetCategory(orcCategory(column, h3Long))))
+		if column.H3 != nil {
+			h3Columns[column.Name] = column.H3
+		}
+	}
+	description, err := orc.NewTypeDescription(fields...)
+	if err != nil {
+		return nil, fmt.Errorf("ORC schema: %w", err)
+	}
+	h3Metadata, err := json.Marshal(h3Columns)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := orc.NewWriter(out,
+		orc.SetSchema(description),
+		orc.SetStripeTargetSize(orcStripeBytes),
+		orc.AddUserMetadata("csvh3.h3", h3Metadata))
+	if err != nil {
+		return nil, err
+	}
+	return &orcEncoder{writer: writer, columns: columns, h3Long: h3Long, values: make([]any, len(columns))}, nil
+}
+
+// orcCategory maps an inferred column type to an ORC type, with H3 columns stored as
+// strings or as bigints
+func orcCategory(column schema.Column, h3Long bool) orc.Category {
+	if column.H3 != nil && h3Long {
+		return orc.CategoryLong
+	}
+	switch column.Type {
+	case schema.TypeInteger:
+		return orc.CategoryLong
+	case schema.TypeNumber:
+		return orc.CategoryDouble
+	case schema.TypeBoolean:
+		return orc.CategoryBoolean
+	}
+	return orc.CategoryString
+}
+
+// Write converts one row to the column types; missing trailing values are null
+func (e *orcEncoder) Write(row []string) error {
+	for i, column := range e.columns {
+		value := ""
+		if i < len(row) {
+			value = row[i]
+		}
+		converted, err := e.convert(column, value)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		e.values[i] = converted
+	}
+	return e.writer.Write(e.values...)
+}
+
+// convert parses a value of a column, returning nil for empty values
+func (e *orcEncoder) convert(column schema.Column, value string) (any, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil, nil
+	}
+	switch orcCategory(column, e.h3Long) {
+	case orc.CategoryLong:
+		if column.H3 != nil {
+			index, err := strconv.ParseUint(trimmed, 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid H3 index %q", value)
+			}
+			return int64(index), nil
+		}
+		number, err := strconv.ParseInt(trimmed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bigint %q", value)
+		}
+		return number, nil
+	case orc.CategoryDouble:
+		number, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid double %q", value)
+		}
+		return number, nil
+	case orc.CategoryBoolean:
+		switch {
+		case strings.EqualFold(trimmed, "true"):
+			return true, nil
+		case strings.EqualFold(trimmed, "false"):
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", value)
+	}
+	return value, nil
+}
+
+// Flush writes the last stripe and the file footer
+func (e *orcEncoder) Flush() error {
+	return e.writer.Close()
+}
@@ -1,15 +1,36 @@
 package service
 
 import (
+	"bufio"
+	stderrors "errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"csv-h3-tool/internal/cellmap"
 	"csv-h3-tool/internal/config"
 	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/database"
+	"csv-h3-tool/internal/dedupe"
 	"csv-h3-tool/internal/errors"
+	"csv-h3-tool/internal/expr"
+	"csv-h3-tool/internal/extsort"
+	"csv-h3-tool/internal/filehandler"
+	"csv-h3-tool/internal/geocode"
+	"csv-h3-tool/internal/geo"
 	"csv-h3-tool/internal/h3"
 	"csv-h3-tool/internal/logging"
+	"csv-h3-tool/internal/schema"
+	"csv-h3-tool/internal/sign"
+	"csv-h3-tool/internal/stats"
+	"csv-h3-tool/internal/timezone"
+	"csv-h3-tool/internal/trajectory"
 	"csv-h3-tool/internal/validator"
 )
 
@@ -19,7 +40,17 @@ type Orchestrator struct {
 	h3Generator h3.Generator
 	processor   csv.Processor
 	config      *config.Config
-	logger      *logging.Logger
+	logger      logging.Logger
+	// recordCounter, when set, is incremented for every processed record (live batch progress)
+	recordCounter *atomic.Int64
+	// fileProgress, when set, tracks this file's live progress in a batch
+	fileProgress *FileProgress
+	// adminTable maps H3 cells to admin names when an admin lookup is configured (loaded on first use)
+	adminTable *cellmap.Table
+	// geocoder fills in missing coordinates when geocoding is enabled (created on first use)
+	geocoder geocode.Geocoder
+	// querySpool is the temporary file holding the input query results while a file is processed
+	querySpool string
 }
 
 // h3GeneratorAdapter adapts the h3.Generator interface to work with csv.StreamingProcessor
@@ -31,6 +62,16 @@ func (a *h3GeneratorAdapter) Generate(lat, lng float64, resolution int) (string,
 	return a.generator.Generate(lat, lng, h3.H3Resolution(resolution))
 }
 
+// GenerateUnsafe skips coordinate validation when the generator supports it
+func (a *h3GeneratorAdapter) GenerateUnsafe(lat, lng float64, resolution int) (string, error) {
+	if unchecked, ok := a.generator.(interface {
+		GenerateUnsafe(lat, lng float64, resolution h3.H3Resolution) (string, error)
+	}); ok {
+		return unchecked.GenerateUnsafe(lat, lng, h3.H3Resolution(resolution))
+	}
+	return a.Generate(lat, lng, resolution)
+}
+
 // NewOrchestrator creates a new orchestrator with all required components
 func NewOrchestrator(cfg *config.Config) *Orchestrator {
 	validator := validator.NewCoordinateValidator()
@@ -55,17 +96,92 @@ type ProcessResult struct {
 	TotalRecords   int
 	ValidRecords   int
 	InvalidRecords int
+	OutsideBBoxRecords int // Valid records outside the expected bounding box
+	OutlierRecords     int // Valid records in sparsely populated H3 neighborhoods
+	SanityFailedRecords int // Valid records failing the location sanity check
+	InvalidPairRecords int // Records with an invalid secondary coordinate pair
+	NonNeighborEdges   int // Edges whose origin and destination cells are not neighbors
+	DuplicateRecords   int // Records dropped as duplicates (included in TotalRecords)
+	FilteredRecords    int // Records dropped by the --where filter (included in TotalRecords)
+	ComputeErrors      int // Computed column values left empty because their expression failed
+	Partitions         int // Number of partitions written in partitioned output mode
+	PartitionFiles     []string // Files of the partitioned output, relative to the output directory
+	SignatureFile      string // Detached signature written by --sign
+	VerifiedRows       int    // Output rows whose H3 index --verify-output recomputed
+	UpdatedRows        int64 // Database rows updated in backfill mode
+	RedisKeys          int64 // Keys written to the Redis sink
+	GeocodedRecords    int   // Records whose missing coordinates were geocoded
+	FallbackRecords    int   // Records whose coordinates came from the fallback columns
+	CoordSources       []SourceCount // Valid records by the columns that supplied their coordinates, in priority order
+	NullValues         []csv.NullCount // Coordinate fields holding each null value token
+	AdminMatchedRecords int  // Valid records found in the admin lookup table
+	AmbiguousCountryRecords int // Valid records in H3 cells straddling a country border
+	Coverage           *h3.Coverage // Region cell coverage when a coverage check is configured
+	ColumnStats        *stats.Profile // Input column statistics when requested
+	RejectedRows       int            // Invalid records written to the error file instead of the output
+	DataWarnings       []string       // Findings of the data checks, e.g. constant coordinates
+	FooterRows         int            // Rows discarded at the end of the input by --skip-footer
+	ArchiveFile        string         // Zip archive packaging the partitioned output, if requested
+	SampledOutRecords  int            // Valid records dropped by --sample-per-cell (included in TotalRecords)
+	SampledCells       int            // H3 cells sampled by --sample-per-cell
+	Trips              int            // Trips assigned by trip segmentation
+	UntimedRecords     int            // Records without an entity id or a parseable timestamp, skipped by the trajectory stages
+	ImplausibleSpeedRecords int       // Valid records implying more than --max-speed-kmh from the entity's previous point
+	Stays              int            // Stays detected by --detect-stays
+	NoLocalIJRecords   int            // Valid records whose cell has no local IJ coordinates relative to --anchor
+	logging.RecordCategories             // Skipped and invalid records by category, bytes read and written
 	ProcessingTime time.Duration
 	OutputFile     string
 }
 
+// SourceCount is the number of valid records whose coordinates came from one source
+type SourceCount struct {
+	Source  string // Coordinate columns, e.g. "gps_lat:gps_lng", or csv.GeocodedSource
+	Records int
+}
+
+// countSource counts a valid record under the source of its coordinates
+func (r *ProcessResult) countSource(source string) {
+	for i := range r.CoordSources {
+		if r.CoordSources[i].Source == source {
+			r.CoordSources[i].Records++
+			return
+		}
+	}
+	r.CoordSources = append(r.CoordSources, SourceCount{Source: source, Records: 1})
+}
+
+// countInvalid counts an invalid record in its category
+func (r *ProcessResult) countInvalid(kind csv.InvalidKind) {
+	switch kind {
+	case csv.InvalidEmpty:
+		r.EmptyCoordinateRows++
+	case csv.InvalidUnparseable:
+		r.UnparseableRows++
+	case csv.InvalidOutOfRange:
+		r.OutOfRangeRows++
+	case csv.InvalidH3:
+		r.H3FailureRows++
+	case csv.InvalidLookup:
+		r.LookupFailureRows++
+	}
+}
+
 // ProcessFile orchestrates the complete CSV processing workflow
 func (o *Orchestrator) ProcessFile() (*ProcessResult, error) {
 	startTime := time.Now()
 
 	o.logger.Info("Starting CSV processing")
-	o.logger.Info("Input file: %s", o.config.InputFile)
-	o.logger.Info("Output file: %s", o.config.OutputFile)
+	if driver, query := o.config.QueryInput(); query != "" {
+		o.logger.Info("Input query (%s): %s", driver, query)
+	} else {
+		o.logger.Info("Input file: %s", o.config.InputFile)
+	}
+	if o.config.IsUpdate() {
+		o.logger.Info("Updating table: %s (%s by %s)", o.config.PGUpdate, o.config.UpdateH3Column, o.config.KeyColumn)
+	} else {
+		o.logger.Info("Output file: %s", o.config.OutputFile)
+	}
 	o.logger.Info("H3 Resolution: %d (%s)", o.config.Resolution, o.config.GetResolutionDescription())
 
 	// Validate configuration
@@ -75,6 +191,15 @@ func (o *Orchestrator) ProcessFile() (*ProcessResult, error) {
 		return nil, configErr
 	}
 
+	// Run the input query once; every pass over the input reads its spooled results
+	if driver, query := o.config.QueryInput(); query != "" {
+		if err := o.spoolQuery(driver, query); err != nil {
+			o.logger.LogError(err)
+			return nil, err
+		}
+		defer o.removeQuerySpool()
+	}
+
 	// Pre-validate CSV structure
 	if err := o.validateCSVStructure(); err != nil {
 		csvErr := errors.NewCSVError(o.config.InputFile, 0, 0, "", "", "CSV structure validation failed", err)
@@ -92,9 +217,20 @@ func (o *Orchestrator) ProcessFile() (*ProcessResult, error) {
 
 	result.ProcessingTime = time.Since(startTime)
 	result.OutputFile = o.config.OutputFile
+	if o.config.IsPartitioned() {
+		result.OutputFile = o.config.OutputDir
+		if o.config.IsArchiveOutput() {
+			if err := o.writePartitionArchive(result); err != nil {
+				fileErr := errors.NewFileError(o.config.OutputFile, "write", err)
+				o.logger.LogError(fileErr)
+				return nil, fileErr
+			}
+			result.ArchiveFile = o.config.OutputFile
+		}
+	}
 
 	// Log processing summary
-	o.logger.LogProcessingSummary(result.TotalRecords, result.ValidRecords, result.InvalidRecords, result.ProcessingTime)
+	logging.LogProcessingSummary(o.logger, result.TotalRecords, result.ValidRecords, result.InvalidRecords, result.ProcessingTime)
 
 	return result, nil
 }
@@ -102,74 +238,811 @@ func (o *Orchestrator) ProcessFile() (*ProcessResult, error) {
 // validateCSVStructure performs pre-processing validation of the CSV file
 func (o *Orchestrator) validateCSVStructure() error {
 	// Open the file to read headers
-	reader, err := csv.NewReader(o.config.InputFile, csv.Config{
-		InputFile:  o.config.InputFile,
-		LatColumn:  o.config.LatColumn,
-		LngColumn:  o.config.LngColumn,
-		HasHeaders: o.config.HasHeaders,
-	})
+	reader, err := o.openReader()
 	if err != nil {
-		return errors.NewFileError(o.config.InputFile, "open", err)
+		return err
 	}
 	defer reader.Close()
 
 	// Validate column configuration
 	headers := reader.GetHeaders()
-	if err := o.processor.ValidateColumns(headers, csv.Config{
-		LatColumn:  o.config.LatColumn,
-		LngColumn:  o.config.LngColumn,
-		HasHeaders: o.config.HasHeaders,
-	}); err != nil {
+	readerConfig := o.readerConfig()
+	if err := o.processor.ValidateColumns(headers, readerConfig); err != nil {
 		return errors.NewValidationError("columns", "", 0, "column validation failed", err)
 	}
+	if _, err := o.secondaryPairs(reader); err != nil {
+		return errors.NewValidationError("coord_pairs", o.config.CoordPairs, 0, "coordinate pair validation failed", err)
+	}
+	if _, err := o.edgeSpecs(); err != nil {
+		return errors.NewValidationError("add_edge", o.config.AddEdge, 0, "edge validation failed", err)
+	}
+	if _, err := o.traceColumns(reader); err != nil {
+		return errors.NewValidationError("trajectory", o.config.IdColumn+","+o.config.TimeColumn, 0, "trajectory column validation failed", err)
+	}
+
+	// Check the output schema before any rows are written
+	if err := o.validateOutputSchema(reader); err != nil {
+		return err
+	}
 
 	o.logger.Info("CSV structure validated successfully")
-	if o.config.HasHeaders {
+	if o.config.InputHasHeaders() {
 		o.logger.Debug("Headers: %v", headers)
-		o.logger.Debug("Latitude column: %s (index %d)", o.config.LatColumn, reader.GetLatIndex())
-		o.logger.Debug("Longitude column: %s (index %d)", o.config.LngColumn, reader.GetLngIndex())
+		o.logger.Debug("Latitude column: %s (index %d)", readerConfig.LatColumn, reader.GetLatIndex())
+		o.logger.Debug("Longitude column: %s (index %d)", readerConfig.LngColumn, reader.GetLngIndex())
+	}
+
+	return nil
+}
+
+// readerConfig returns the CSV configuration used to read the input file
+func (o *Orchestrator) readerConfig() csv.Config {
+	lat, lng, _ := o.config.CoordinateColumns()
+	fallbacks, _ := o.config.FallbackSources() // Checked by Config.Validate
+	return csv.Config{
+		InputFile:    o.config.InputFile,
+		LatColumn:    lat,
+		LngColumn:    lng,
+		HasHeaders:   o.config.InputHasHeaders(),
+		NumberLocale: o.config.NumberLocale,
+		TrimFields:   o.config.TrimFields,
+		NoColumnFallback: o.config.NoColumnFallback,
+		FuzzyColumns:     o.config.FuzzyColumns,
+		CollapseWhitespace: o.config.CollapseWhitespace,
+		NormalizeUnicode:   o.config.NormalizeUnicode,
+		Mmap:               o.config.Mmap,
+		MaxShortRows:       o.config.MaxShortRows,
+		FallbackSources:    fallbacks,
+		NullValues:         csv.ParseNullValues(o.config.NullValues),
+		SkipLines:          o.config.SkipLines,
+		CommentPrefix:      o.config.SkipComments,
+		SkipFooter:         o.config.SkipFooter,
+	}
+}
+
+// openReader opens the input file, or runs the database query when one is configured
+func (o *Orchestrator) openReader() (*csv.Reader, error) {
+	reader, err := o.openInput()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.config.GeocodeMissing {
+		if err := o.setGeocoding(reader); err != nil {
+			reader.Close()
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+// openInput opens the input file or database query without geocoding missing coordinates.
+// Query results spooled by ProcessFile are read from the spool.
+func (o *Orchestrator) openInput() (*csv.Reader, error) {
+	var reader *csv.Reader
+	if o.querySpool != "" {
+		source, err := openSpoolSource(o.querySpool)
+		if err != nil {
+			return nil, errors.NewFileError(o.querySpool, "open", err)
+		}
+		reader, err = csv.NewSourceReader(source, o.readerConfig())
+		if err != nil {
+			return nil, errors.NewProcessingError("query", 0, "failed to read query results", err)
+		}
+	} else if driver, query := o.config.QueryInput(); query == "" {
+		var err error
+		reader, err = csv.NewReader(o.config.InputFile, o.readerConfig())
+		if err != nil {
+			return nil, errors.NewFileError(o.config.InputFile, "open", err)
+		}
+	} else {
+		source, err := database.Query(driver, o.config.DatabaseURL(), query)
+		if err != nil {
+			return nil, errors.NewProcessingError("query", 0, "failed to query input database", err)
+		}
+		reader, err = csv.NewSourceReader(source, o.readerConfig())
+		if err != nil {
+			return nil, errors.NewProcessingError("query", 0, "failed to read query results", err)
+		}
+	}
+	return reader, nil
+}
+
+// setGeocoding fills in missing coordinates by geocoding the address column. The geocoder
+// and its cache are shared by every pass over the input.
+func (o *Orchestrator) setGeocoding(reader *csv.Reader) error {
+	match, err := reader.MatchColumn("address", o.config.AddressColumn)
+	if err != nil {
+		return errors.NewConfigError("address_column", o.config.AddressColumn, "address column not found", err)
+	}
+	if o.geocoder == nil {
+		o.geocoder, err = geocode.New(o.config.Geocoder, o.config.GeocoderURL, o.config.GeocodeRate)
+		if err != nil {
+			return errors.NewConfigError("geocoder", o.config.Geocoder, "invalid geocoder", err)
+		}
+	}
+
+	reader.SetCoordinateFallback(func(row []string) (float64, float64, bool, error) {
+		if match.Index >= len(row) || strings.TrimSpace(row[match.Index]) == "" {
+			return 0, 0, false, nil
+		}
+		return o.geocoder.Geocode(row[match.Index])
+	})
+	return nil
+}
+
+// coordPair is a secondary coordinate pair resolved against the input columns
+type coordPair struct {
+	csv.CoordPair
+	latIndex int
+	lngIndex int
+	matches  []csv.ColumnMatch
+}
+
+// secondaryPairs resolves the coordinate pairs after the first (primary) one
+func (o *Orchestrator) secondaryPairs(reader *csv.Reader) ([]coordPair, error) {
+	if o.config.CoordPairs == "" {
+		return nil, nil
+	}
+	pairs, err := csv.ParseCoordPairs(o.config.CoordPairs)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]coordPair, 0, len(pairs)-1)
+	for _, pair := range pairs[1:] {
+		latMatch, err := reader.MatchColumn(pair.H3Column+" latitude", pair.LatColumn)
+		if err != nil {
+			return nil, err
+		}
+		lngMatch, err := reader.MatchColumn(pair.H3Column+" longitude", pair.LngColumn)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, coordPair{CoordPair: pair, latIndex: latMatch.Index, lngIndex: lngMatch.Index,
+			matches: []csv.ColumnMatch{latMatch, lngMatch}})
+	}
+	return resolved, nil
+}
+
+// ExplainColumns reports which input column was matched for each coordinate role and why
+func (o *Orchestrator) ExplainColumns() ([]csv.ColumnMatch, error) {
+	reader, err := o.openReader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	matches := reader.ColumnMatches()
+	pairs, err := o.secondaryPairs(reader)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		matches = append(matches, pair.matches...)
+	}
+	return matches, nil
+}
+
+// setPairIndexes computes the H3 index of each secondary coordinate pair of a record,
+// leaving the column empty for invalid coordinates. Returns the number of invalid pairs.
+func (o *Orchestrator) setPairIndexes(reader *csv.Reader, pairs []coordPair, record *csv.Record) int {
+	invalid := 0
+	for _, pair := range pairs {
+		index, err := o.pairIndex(reader, pair, record.OriginalData)
+		if err != nil {
+			invalid++
+			o.logger.Debug("Line %d: no %s: %v", record.LineNumber, pair.H3Column, err)
+		}
+		record.SetExtra(pair.H3Column, index)
+	}
+	return invalid
+}
+
+// pairIndex parses, validates, and indexes one secondary coordinate pair of a row
+func (o *Orchestrator) pairIndex(reader *csv.Reader, pair coordPair, row []string) (string, error) {
+	lat, lng, err := reader.ParseCoordinates(row, pair.latIndex, pair.lngIndex)
+	if err != nil {
+		return "", err
+	}
+	if err := o.validator.ValidateCoordinates(lat, lng); err != nil {
+		return "", err
+	}
+	return (&h3GeneratorAdapter{generator: o.h3Generator}).GenerateUnsafe(lat, lng, o.config.Resolution)
+}
+
+// setDistanceBearing computes the great-circle distance and initial bearing from the primary
+// coordinates to the first secondary pair, leaving the columns empty when either is invalid
+func (o *Orchestrator) setDistanceBearing(reader *csv.Reader, pairs []coordPair, record *csv.Record) {
+	distance, bearing := "", ""
+	if record.IsValid && len(pairs) > 0 {
+		lat, lng, err := reader.ParseCoordinates(record.OriginalData, pairs[0].latIndex, pairs[0].lngIndex)
+		if err == nil {
+			err = o.validator.ValidateCoordinates(lat, lng)
+		}
+		if err == nil {
+			distance = strconv.FormatFloat(geo.DistanceKm(record.Latitude, record.Longitude, lat, lng), 'f', 3, 64)
+			bearing = strconv.FormatFloat(geo.InitialBearing(record.Latitude, record.Longitude, lat, lng), 'f', 1, 64)
+		}
+	}
+
+	if o.config.AddDistanceKm {
+		record.SetExtra("distance_km", distance)
+	}
+	if o.config.AddBearing {
+		record.SetExtra("bearing_deg", bearing)
+	}
+}
+
+// setProjections computes the UTM and MGRS coordinates of the primary coordinates,
+// leaving the columns empty for invalid records and polar latitudes outside UTM
+func (o *Orchestrator) setProjections(record *csv.Record) {
+	var zone, easting, northing, mgrs string
+	if record.IsValid {
+		if utm, err := geo.ToUTM(record.Latitude, record.Longitude); err == nil {
+			zone = utm.ZoneDesignator()
+			easting = strconv.FormatFloat(utm.Easting, 'f', 2, 64)
+			northing = strconv.FormatFloat(utm.Northing, 'f', 2, 64)
+		}
+		if grid, err := geo.ToMGRS(record.Latitude, record.Longitude, mgrsDigits); err == nil {
+			mgrs = grid
+		}
+	}
+
+	if o.config.AddUTM {
+		record.SetExtra("utm_zone", zone)
+		record.SetExtra("utm_easting", easting)
+		record.SetExtra("utm_northing", northing)
+	}
+	if o.config.AddMGRS {
+		record.SetExtra("mgrs", mgrs)
+	}
+}
+
+// mgrsDigits is the MGRS precision written to the mgrs column (5 digits per axis = 1 m)
+const mgrsDigits = 5
+
+// notNeighborsValue is written to an edge column when both cells are known but not adjacent
+const notNeighborsValue = "not_neighbors"
+
+// edgeSpecs returns the configured directed edges, checking that they refer to H3 output columns
+func (o *Orchestrator) edgeSpecs() ([]csv.EdgeSpec, error) {
+	if o.config.AddEdge == "" {
+		return nil, nil
+	}
+	edges, err := csv.ParseEdgeSpecs(o.config.AddEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	h3Columns := o.h3Columns()
+	known := func(column string) bool {
+		for _, h3Column := range h3Columns {
+			if strings.EqualFold(h3Column, column) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, edge := range edges {
+		for _, column := range []string{edge.OriginColumn, edge.DestColumn} {
+			if !known(column) {
+				return nil, fmt.Errorf("edge %s refers to unknown H3 column %q (available: %s)",
+					edge.EdgeColumn, column, strings.Join(h3Columns, ", "))
+			}
+		}
+	}
+	return edges, nil
+}
+
+// h3Columns returns the names of all H3 index output columns: the primary column
+// followed by the secondary coordinate pair columns
+func (o *Orchestrator) h3Columns() []string {
+	_, _, primary := o.config.CoordinateColumns()
+	if primary == "" {
+		primary = csv.DefaultH3Column
+	}
+	columns := []string{primary}
+	if o.config.CoordPairs != "" {
+		if pairs, err := csv.ParseCoordPairs(o.config.CoordPairs); err == nil {
+			for _, pair := range pairs[1:] {
+				columns = append(columns, pair.H3Column)
+			}
+		}
+	}
+	return columns
+}
+
+// h3Value returns the H3 index a record holds in the named H3 output column
+func (o *Orchestrator) h3Value(record *csv.Record, column string) string {
+	if strings.EqualFold(column, o.h3Columns()[0]) {
+		if record.IsValid {
+			return record.H3Index
+		}
+		return ""
+	}
+	for name, value := range record.Extra {
+		if strings.EqualFold(name, column) {
+			return value
+		}
+	}
+	return ""
+}
+
+// setEdges computes the directed edge columns of a record. The edge column is left empty when
+// either cell is missing and holds not_neighbors when the cells are not adjacent.
+// Returns the number of edges between non-neighboring cells.
+func (o *Orchestrator) setEdges(edges []csv.EdgeSpec, record *csv.Record) (int, error) {
+	notNeighbors := 0
+	for _, edge := range edges {
+		origin := o.h3Value(record, edge.OriginColumn)
+		destination := o.h3Value(record, edge.DestColumn)
+		if origin == "" || destination == "" {
+			record.SetExtra(edge.EdgeColumn, "")
+			continue
+		}
+
+		index, ok, err := h3.DirectedEdgeIndex(origin, destination)
+		if err != nil {
+			return notNeighbors, err
+		}
+		if !ok {
+			notNeighbors++
+			index = notNeighborsValue
+		}
+		record.SetExtra(edge.EdgeColumn, index)
+	}
+	return notNeighbors, nil
+}
+
+// extraColumns returns the additional output columns written after the H3 index, in order
+func (o *Orchestrator) extraColumns() []string {
+	var columns []string
+	if o.config.CoordPairs != "" {
+		if pairs, err := csv.ParseCoordPairs(o.config.CoordPairs); err == nil {
+			for _, pair := range pairs[1:] {
+				columns = append(columns, pair.H3Column)
+			}
+		}
+	}
+	if o.config.AddEdge != "" {
+		if edges, err := csv.ParseEdgeSpecs(o.config.AddEdge); err == nil {
+			for _, edge := range edges {
+				columns = append(columns, edge.EdgeColumn)
+			}
+		}
+	}
+	if o.config.AddDistanceKm {
+		columns = append(columns, "distance_km")
+	}
+	if o.config.AddBearing {
+		columns = append(columns, "bearing_deg")
+	}
+	if o.config.AddUTM {
+		columns = append(columns, "utm_zone", "utm_easting", "utm_northing")
+	}
+	if o.config.AddMGRS {
+		columns = append(columns, "mgrs")
+	}
+	columns = append(columns, o.cellAttributeColumns()...)
+	if o.config.EmitParsedCoords {
+		columns = append(columns, "latitude_parsed", "longitude_parsed")
+	}
+	if o.config.EmitCoordSource {
+		columns = append(columns, "coord_source")
+	}
+	if o.config.ExpectBBox != "" {
+		columns = append(columns, "outside_bbox")
+	}
+	if o.config.SanityCheck != "" {
+		columns = append(columns, "failed_sanity_check")
+	}
+	if o.config.FlagOutliers {
+		columns = append(columns, "is_outlier")
+	}
+	if o.config.TripGap > 0 {
+		columns = append(columns, "trip_id")
+	}
+	if o.config.MaxSpeedKmh > 0 && o.config.SpeedAction == config.SpeedActionFlag {
+		columns = append(columns, "implausible_speed")
+	}
+	if o.config.DetectStays {
+		columns = append(columns, "stay_id", "stay_flag")
+	}
+	if o.config.AdminLookup != "" {
+		if table, err := o.adminLookup(); err == nil {
+			columns = append(columns, table.Columns()...)
+		}
+	}
+	if o.config.AddTimezone {
+		columns = append(columns, "timezone")
+	}
+	if o.config.AddCountry {
+		columns = append(columns, "country")
+	}
+	for _, definition := range o.config.Compute {
+		if name, _, err := expr.Assignment(definition); err == nil {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// computedColumn is an output column computed by a --compute expression
+type computedColumn struct {
+	name    string
+	program *expr.Program
+}
+
+// computedColumns compiles the --compute expressions against the input headers. The
+// computed column names must not repeat an input or other output column.
+func (o *Orchestrator) computedColumns(headers []string, extraColumns []string) ([]computedColumn, error) {
+	_, _, h3Column := o.config.CoordinateColumns()
+	if h3Column == "" {
+		h3Column = csv.DefaultH3Column
+	}
+	occurrences := map[string]int{strings.ToLower(h3Column): 1}
+	for _, column := range append(append([]string{}, headers...), extraColumns...) {
+		occurrences[strings.ToLower(column)]++
+	}
+
+	columns := make([]computedColumn, 0, len(o.config.Compute))
+	for _, definition := range o.config.Compute {
+		name, source, err := expr.Assignment(definition)
+		if err != nil {
+			return nil, err
+		}
+		if occurrences[strings.ToLower(name)] > 1 {
+			return nil, fmt.Errorf("computed column %s repeats an existing column", name)
+		}
+		program, err := expr.Compile(source, headers)
+		if err != nil {
+			return nil, fmt.Errorf("computed column %s: %w", name, err)
+		}
+		columns = append(columns, computedColumn{name: name, program: program})
+	}
+	return columns, nil
+}
+
+// adminLookup loads the admin lookup table once
+func (o *Orchestrator) adminLookup() (*cellmap.Table, error) {
+	if o.adminTable == nil {
+		table, err := cellmap.Load(o.config.AdminLookup)
+		if err != nil {
+			return nil, err
+		}
+		o.logger.Debug("Loaded admin lookup table with %d cells", table.Len())
+		o.adminTable = table
+	}
+	return o.adminTable, nil
+}
+
+// validateOutputSchema checks the output columns against the expected column count
+// and expected header row, if configured
+func (o *Orchestrator) validateOutputSchema(reader *csv.Reader) error {
+	if o.config.ExpectOutputColumns == 0 && o.config.ExpectHeaders == "" {
+		return nil
+	}
+
+	extraColumns := o.extraColumns()
+	_, _, h3Column := o.config.CoordinateColumns()
+	outputHeaders := csv.OutputHeaders(reader.GetHeaders(), h3Column, extraColumns)
+
+	if o.config.ExpectHeaders != "" {
+		if !o.config.InputHasHeaders() {
+			return errors.NewValidationError("output_schema", o.config.ExpectHeaders, 0,
+				"expected headers require an input file with a header row", nil)
+		}
+		expected, err := csv.ReadHeaderRow(o.config.ExpectHeaders)
+		if err != nil {
+			return errors.NewFileError(o.config.ExpectHeaders, "read", err)
+		}
+		if err := compareHeaders(expected, outputHeaders); err != nil {
+			return errors.NewValidationError("output_schema", strings.Join(outputHeaders, ","), 0,
+				"output headers do not match expected headers", err)
+		}
+	}
+
+	if expected := o.config.ExpectOutputColumns; expected > 0 {
+		count := len(outputHeaders)
+		if !o.config.InputHasHeaders() {
+			// Without headers the column count comes from the first data row
+			record, err := reader.ReadRecord()
+			if err != nil {
+				return errors.NewValidationError("output_schema", "", 0,
+					"cannot determine output column count from the first row", err)
+			}
+			count = len(record.OriginalData) + 1 + len(extraColumns)
+		}
+		if count != expected {
+			return errors.NewValidationError("output_schema", strconv.Itoa(count), 0,
+				fmt.Sprintf("output would have %d columns, expected %d", count, expected), nil)
+		}
 	}
 
+	o.logger.Debug("Output schema validated: %v", outputHeaders)
+	return nil
+}
+
+// compareHeaders reports the first difference between expected and actual header rows
+func compareHeaders(expected, actual []string) error {
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if strings.TrimSpace(expected[i]) != strings.TrimSpace(actual[i]) {
+			return fmt.Errorf("column %d: expected %q, got %q", i+1, expected[i], actual[i])
+		}
+	}
+	if len(expected) != len(actual) {
+		return fmt.Errorf("expected %d columns %v, got %d columns %v", len(expected), expected, len(actual), actual)
+	}
 	return nil
 }
 
 // processWithProgress processes the CSV file with progress reporting
 func (o *Orchestrator) processWithProgress() (*ProcessResult, error) {
 	// Get file info for validation
-	_, err := os.Stat(o.config.InputFile)
-	if err != nil {
-		return nil, errors.NewFileError(o.config.InputFile, "stat", err)
+	if _, query := o.config.QueryInput(); query == "" {
+		if _, err := csv.InputSize(o.config.InputFile); err != nil {
+			return nil, errors.NewFileError(o.config.InputFile, "stat", err)
+		}
 	}
 
-	// Open input file
-	reader, err := csv.NewReader(o.config.InputFile, csv.Config{
-		InputFile:  o.config.InputFile,
-		LatColumn:  o.config.LatColumn,
-		LngColumn:  o.config.LngColumn,
-		HasHeaders: o.config.HasHeaders,
-	})
+	// Open input file or database query
+	reader, err := o.openReader()
 	if err != nil {
-		return nil, errors.NewFileError(o.config.InputFile, "open", err)
+		return nil, err
 	}
 	defer reader.Close()
 
+	// Compile the record filter
+	var where *expr.Program
+	if o.config.Where != "" {
+		where, err = expr.Compile(o.config.Where, reader.GetHeaders())
+		if err != nil {
+			return nil, errors.NewConfigError("where", o.config.Where, "invalid filter expression", err)
+		}
+	}
+
+	// Determine additional output columns
+	extraColumns := o.extraColumns()
+	computed, err := o.computedColumns(reader.GetHeaders(), extraColumns)
+	if err != nil {
+		return nil, errors.NewConfigError("compute", strings.Join(o.config.Compute, "; "), "invalid computed column", err)
+	}
+	pairs, err := o.secondaryPairs(reader)
+	if err != nil {
+		return nil, errors.NewConfigError("coord_pairs", o.config.CoordPairs, "invalid coordinate pairs", err)
+	}
+	edges, err := o.edgeSpecs()
+	if err != nil {
+		return nil, errors.NewConfigError("add_edge", o.config.AddEdge, "invalid edges", err)
+	}
+	cellAttributes := o.cellAttributeColumns()
+	trace, err := o.traceColumns(reader)
+	if err != nil {
+		return nil, errors.NewConfigError("trajectory", o.config.IdColumn+","+o.config.TimeColumn, "invalid trajectory columns", err)
+	}
+	var segmenter *trajectory.Segmenter
+	if o.config.TripGap > 0 {
+		segmenter = trajectory.NewSegmenter(o.config.TripGap)
+	}
+	var speedFilter *trajectory.SpeedFilter
+	if o.config.MaxSpeedKmh > 0 {
+		speedFilter = trajectory.NewSpeedFilter(o.config.MaxSpeedKmh)
+	}
+	var stayDetector *trajectory.StayDetector
+	if o.config.DetectStays {
+		radius, err := geo.ParseDistance(o.config.StayRadius)
+		if err == nil {
+			stayDetector, err = trajectory.NewStayDetector(radius, time.Duration(o.config.StayMinutes*float64(time.Minute)))
+		}
+		if err != nil {
+			return nil, errors.NewConfigError("stay_radius", o.config.StayRadius, "invalid stay detection", err)
+		}
+	}
+	var bbox *validator.BoundingBox
+	if o.config.ExpectBBox != "" {
+		bbox, err = validator.ParseBoundingBox(o.config.ExpectBBox)
+		if err != nil {
+			return nil, errors.NewConfigError("expect_bbox", o.config.ExpectBBox, "invalid bounding box", err)
+		}
+	}
+	var sanity *validator.SanityCheck
+	if o.config.SanityCheck != "" {
+		sanity, err = validator.ParseSanityCheck(o.config.SanityCheck, nil)
+		if err != nil {
+			return nil, errors.NewConfigError("sanity_check", o.config.SanityCheck, "invalid sanity check", err)
+		}
+	}
+	dataChecks, err := o.dataChecks()
+	if err != nil {
+		return nil, errors.NewConfigError("precision_check", o.config.PrecisionCheck, "invalid precision check", err)
+	}
+	var admin *cellmap.Table
+	if o.config.AdminLookup != "" {
+		admin, err = o.adminLookup()
+		if err != nil {
+			return nil, errors.NewFileError(o.config.AdminLookup, "read", err)
+		}
+	}
+	var zones *timezone.Index
+	if o.config.AddTimezone {
+		zones, err = timezone.DefaultIndex()
+		if err != nil {
+			return nil, errors.NewConfigError("add_timezone", "true", "invalid embedded time zone index", err)
+		}
+	}
+	var countries *validator.CountryLookup
+	if o.config.AddCountry {
+		countries, err = validator.NewCountryLookup(o.config.OnAmbiguous, nil)
+		if err != nil {
+			return nil, errors.NewConfigError("on_ambiguous", o.config.OnAmbiguous, "invalid country lookup", err)
+		}
+	}
+	var coverage *h3.Coverage
+	if o.config.CoverageCheck != "" {
+		polygons, err := geo.ReadPolygons(o.config.CoverageCheck)
+		if err != nil {
+			return nil, errors.NewFileError(o.config.CoverageCheck, "read", err)
+		}
+		coverage, err = h3.NewCoverage(polygons, o.config.Resolution)
+		if err != nil {
+			return nil, errors.NewConfigError("coverage_check", o.config.CoverageCheck, "invalid coverage region", err)
+		}
+		o.logger.Debug("Coverage region contains %d cells at resolution %d", coverage.Cells(), o.config.Resolution)
+	}
+	var profile *stats.Profile
+	var columnTypes *schema.Inferrer
+	var density *h3.DensityCounter
+	if o.config.FlagOutliers {
+		density, err = o.countCellDensity()
+		if err != nil {
+			return nil, errors.NewProcessingError("density_pass", 0, "outlier density pass failed", err)
+		}
+	}
+
 	// Create output writer
-	writer, err := csv.NewWriter(o.config.OutputFile, reader.GetHeaders(), csv.Config{
-		OutputFile: o.config.OutputFile,
-		HasHeaders: o.config.HasHeaders,
-		Overwrite:  o.config.Overwrite,
-	})
+	sizing := reader.Sizing()
+	if sizing.RecordBytes > 0 {
+		o.logger.Debug("Average record length %d bytes: %d KiB buffers, %d records per worker batch",
+			sizing.RecordBytes, sizing.BufferSize()>>10, sizing.BatchRecords())
+	}
+	output, err := o.newRecordWriter(reader.GetHeaders(), extraColumns, sizing)
 	if err != nil {
-		return nil, errors.NewFileError(o.config.OutputFile, "create", err)
+		return nil, err
+	}
+	
+	// Encrypt sensitive passthrough columns of every output row
+	writer := output
+	var encryptor *encryptWriter
+	if o.config.EncryptColumns != "" {
+		encryptor, err = o.newEncryptWriter(output, reader.GetHeaders())
+		if err != nil {
+			output.Close()
+			return nil, err
+		}
+		writer = encryptor
+	}
+	
+	// Mirror key→H3 index mappings to Redis alongside the output
+	if o.config.RedisSink != "" {
+		redisOutput, err := o.newRedisWriter(writer, reader.GetHeaders())
+		if err != nil {
+			output.Close()
+			return nil, err
+		}
+		writer = redisOutput
 	}
 	defer writer.Close()
 
+	// Create duplicate detector if requested
+	deduplicator, err := o.newDeduplicator(reader.GetHeaders())
+	if err != nil {
+		return nil, errors.NewConfigError("dedupe_keys", o.config.DedupeKeys, "invalid dedupe configuration", err)
+	}
+
+	// Divert invalid records to the error file if requested
+	var rejects *csv.RejectWriter
+	if o.config.ErrorFile != "" {
+		rejects, err = csv.NewRejectWriter(o.config.ErrorFile, reader.GetHeaders(), o.config.Overwrite)
+		if err != nil {
+			return nil, errors.NewFileError(o.config.ErrorFile, "create", err)
+		}
+		defer rejects.Close()
+	}
+
+	// Create external sorter if output ordering was requested
+	var sorter *extsort.Sorter
+	if o.config.SortByH3 {
+		sorter, err = extsort.NewSorter(o.config.SortChunkSize, o.config.TempDir)
+		if err != nil {
+			return nil, errors.NewConfigError("sort_chunk_size", strconv.Itoa(o.config.SortChunkSize), "invalid sort configuration", err)
+		}
+		defer sorter.Cleanup()
+	}
+
 	// Create processing logger
 	processLogger := logging.NewProcessingLogger(o.logger, o.config.InputFile, 0)
+	processLogger.SetWarnLimit(o.config.WarnLimit)
 
 	// Process records with progress tracking
 	result := &ProcessResult{}
+	for _, source := range reader.CoordSources() {
+		result.CoordSources = append(result.CoordSources, SourceCount{Source: source})
+	}
+	if o.config.GeocodeMissing {
+		result.CoordSources = append(result.CoordSources, SourceCount{Source: csv.GeocodedSource})
+	}
 	errorCollector := errors.NewErrorCollector(100) // Collect up to 100 errors
+	var sampler *cellSampler
+	if o.config.SamplePerCell > 0 {
+		sampler = newCellSampler(o.config.SamplePerCell, rand.New(rand.NewSource(time.Now().UnixNano())))
+	}
+
+	var floats *csv.FloatFormatter
+	if o.config.FloatFormat != "" {
+		if floats, err = csv.NewFloatFormatter(o.config.FloatFormat, o.config.PreserveTrailingZeros); err != nil {
+			return nil, errors.NewConfigError("float_format", o.config.FloatFormat, "invalid float format", err)
+		}
+		columns, err := o.numberColumns()
+		if err != nil {
+			return nil, errors.NewProcessingError("float_format_pass", 0, "number column pass failed", err)
+		}
+		floats.SetColumns(columns)
+	}
+
+	// writeOutput writes a record to the output, or buffers it for sorting
+	writeOutput := func(record *csv.Record) error {
+		// Re-serialize the decimal numbers of the input columns
+		if floats != nil {
+			floats.Apply(record.OriginalData)
+		}
+		
+		// Infer the output column types from the rows written
+		if o.config.EmitSchema != "" {
+			row, err := csv.FormatOutputRow(record, extraColumns)
+			if err != nil {
+				return errors.NewProcessingError("schema", record.LineNumber, "failed to format record", err)
+			}
+			if columnTypes == nil {
+				columnTypes = o.newSchemaInferrer(reader.GetHeaders(), len(record.OriginalData), extraColumns)
+			}
+			columnTypes.Add(row)
+		}
+
+		// Buffer record for sorting instead of writing it directly
+		if sorter != nil {
+			row, err := writer.FormatRecord(record)
+			if err == nil {
+				err = sorter.Add(sortKey(record), row)
+			}
+			if err != nil {
+				return errors.NewProcessingError("sort", record.LineNumber, "failed to buffer record for sorting", err)
+			}
+			return nil
+		}
+
+		// Write record to output
+		if err := writer.WriteRecord(record); err != nil {
+			writeErr := errors.NewFileError(o.config.OutputFile, "write", err)
+			errorCollector.Add(writeErr)
+			o.logger.LogError(writeErr)
+			return writeErr
+		}
+		return nil
+	}
+	// emit passes a finished record on to the per-cell sample or the output
+	emit := func(record *csv.Record) error {
+		if sampler != nil {
+			sampler.add(record)
+			return nil
+		}
+		return writeOutput(record)
+	}
+	var stays *stayQueue
+	if stayDetector != nil {
+		stays = newStayQueue(stayDetector, trace, emit)
+	}
+	lastLine, footerLine := 0, 0 // Last row processed and last row without numeric coordinates
 	
 	// Create streaming processor with our components
 	streamProcessor := csv.NewStreamingProcessor(o.validator, &h3GeneratorAdapter{
@@ -182,16 +1055,246 @@ func (o *Orchestrator) processWithProgress() (*ProcessResult, error) {
 		OutputFile: o.config.OutputFile,
 		Resolution: o.config.Resolution,
 		Verbose:    o.config.Verbose,
+		ShowInvalid: o.config.ShowInvalid,
+		WarnLimit:  o.config.WarnLimit,
+		Color:      logging.ColorEnabled(os.Stdout, o.config.NoColor),
+		Workers:    o.config.Workers,
+		Unordered:  o.config.Unordered,
 	}, func(record *csv.Record) error {
 		// Update counters
 		result.TotalRecords++
+		if o.recordCounter != nil {
+			o.recordCounter.Add(1)
+		}
+		if o.fileProgress != nil {
+			o.fileProgress.record(record.InputOffset, record.IsValid)
+		}
+		
+		// Drop rows not matching the filter; rows it cannot be evaluated on do not match
+		if where != nil {
+			matched, err := where.Match(record.OriginalData)
+			if err != nil {
+				o.logger.Debug("Line %d: filter not evaluated: %v", record.LineNumber, err)
+			}
+			if !matched {
+				result.FilteredRecords++
+				return nil
+			}
+		}
+		if coverage != nil && record.IsValid {
+			coverage.Add(record.H3Index)
+		}
+		if o.config.Stats {
+			if profile == nil {
+				profile = stats.NewProfile(statsColumns(reader.GetHeaders(), len(record.OriginalData)), stats.DefaultDistinctLimit)
+			}
+			profile.Add(record.OriginalData)
+		}
+		
+		// Drop duplicate rows before they are counted or written
+		if deduplicator != nil && deduplicator.IsDuplicate(record.OriginalData) {
+			result.DuplicateRecords++
+			o.logger.Debug("Line %d: Dropped duplicate record", record.LineNumber)
+			return nil
+		}
+		
+		// Split each entity's trace into trips at time gaps and check the speed between
+		// its consecutive valid points
+		if trace != nil {
+			id, t, timed := trace.point(record.OriginalData)
+			if !timed {
+				result.UntimedRecords++
+			}
+			if segmenter != nil {
+				trip := ""
+				if timed {
+					trip = segmenter.Assign(id, t)
+				}
+				record.SetExtra("trip_id", trip)
+			}
+			if speedFilter != nil {
+				implausible := ""
+				if timed && record.IsValid {
+					speed, plausible := speedFilter.Check(id, t, record.Latitude, record.Longitude)
+					implausible = strconv.FormatBool(!plausible)
+					if !plausible {
+						result.ImplausibleSpeedRecords++
+						o.logger.Debug("Line %d: Implausible speed of %.0f km/h for %s", record.LineNumber, speed, id)
+						if o.config.SpeedAction == config.SpeedActionDrop {
+							return nil
+						}
+					}
+				}
+				if o.config.SpeedAction == config.SpeedActionFlag {
+					record.SetExtra("implausible_speed", implausible)
+				}
+			}
+		}
+		
+		// Evaluate the computed columns; values that cannot be computed are left empty
+		for _, column := range computed {
+			value, err := column.program.Eval(record.OriginalData)
+			if err != nil {
+				result.ComputeErrors++
+				o.logger.Debug("Line %d: %s not computed: %v", record.LineNumber, column.name, err)
+				record.SetExtra(column.name, "")
+				continue
+			}
+			record.SetExtra(column.name, value.String())
+		}
+		
+		// Index the secondary coordinate pairs
+		if len(pairs) > 0 {
+			if o.setPairIndexes(reader, pairs, record) > 0 {
+				result.InvalidPairRecords++
+			}
+		}
+		
+		// Emit distance and bearing between the origin and destination pairs
+		if o.config.AddDistanceKm || o.config.AddBearing {
+			o.setDistanceBearing(reader, pairs, record)
+		}
+		
+		// Emit projected UTM / MGRS coordinates
+		if o.config.AddUTM || o.config.AddMGRS {
+			o.setProjections(record)
+		}
+		
+		// Emit attributes of the record's H3 cell
+		if len(cellAttributes) > 0 {
+			if !o.setCellAttributes(record) {
+				result.NoLocalIJRecords++
+			}
+		}
+		
+		// Emit directed edges between H3 columns
+		if len(edges) > 0 {
+			notNeighbors, err := o.setEdges(edges, record)
+			if err != nil {
+				return errors.NewProcessingError("add_edge", record.LineNumber, "directed edge lookup failed", err)
+			}
+			result.NonNeighborEdges += notNeighbors
+		}
+		
+		// Emit the coordinate values actually used for H3 generation
+		if o.config.EmitParsedCoords && record.Parsed {
+			record.SetExtra("latitude_parsed", strconv.FormatFloat(record.Latitude, 'f', -1, 64))
+			record.SetExtra("longitude_parsed", strconv.FormatFloat(record.Longitude, 'f', -1, 64))
+		}
 		
+		if record.Geocoded {
+			result.GeocodedRecords++
+		}
+		if record.FromFallback {
+			result.FallbackRecords++
+		}
+		
+		// Name the columns the coordinates came from
+		if o.config.EmitCoordSource {
+			source := ""
+			if record.IsValid {
+				source = record.CoordSource
+			}
+			record.SetExtra("coord_source", source)
+		}
+		
+		lastLine = max(lastLine, record.LineNumber)
 		if record.IsValid {
 			result.ValidRecords++
+			result.countSource(record.CoordSource)
 			processLogger.LogRecordProcessed(record.LineNumber, true, record.H3Index)
+			
+			// Flag records outside the expected region
+			if bbox != nil {
+				outside := !bbox.Contains(record.Latitude, record.Longitude)
+				if outside {
+					result.OutsideBBoxRecords++
+					o.logger.Debug("Line %d: coordinates (%.6f, %.6f) outside expected bbox %s",
+						record.LineNumber, record.Latitude, record.Longitude, bbox)
+				}
+				record.SetExtra("outside_bbox", strconv.FormatBool(outside))
+			}
+			
+			// Flag records failing the coarse location sanity check
+			if sanity != nil {
+				passed, err := sanity.Check(record.Latitude, record.Longitude)
+				if err != nil {
+					return errors.NewValidationError("coordinates", "", record.LineNumber, "sanity check failed", err)
+				}
+				if !passed {
+					result.SanityFailedRecords++
+					o.logger.Debug("Line %d: coordinates (%.6f, %.6f) fail sanity check %s",
+						record.LineNumber, record.Latitude, record.Longitude, sanity)
+				}
+				record.SetExtra("failed_sanity_check", strconv.FormatBool(!passed))
+			}
+			
+			// Check the data as a whole, e.g. for a constant column mapped as coordinates
+			if dataChecks != nil {
+				for _, finding := range dataChecks.Observe(record.Latitude, record.Longitude) {
+					if err := o.reportDataFinding(result, finding); err != nil {
+						return err
+					}
+				}
+			}
+			
+			// Flag records in sparsely populated neighborhoods
+			if density != nil {
+				outlier, err := density.IsOutlier(record.H3Index, o.config.OutlierMinPoints)
+				if err != nil {
+					return errors.NewH3Error(record.Latitude, record.Longitude, o.config.Resolution,
+						record.LineNumber, "neighborhood density lookup failed", err)
+				}
+				if outlier {
+					result.OutlierRecords++
+				}
+				record.SetExtra("is_outlier", strconv.FormatBool(outlier))
+			}
+			
+			// Append admin names of the cell containing the point
+			if admin != nil {
+				values, found, err := admin.Lookup(record.Latitude, record.Longitude)
+				if err != nil {
+					return errors.NewH3Error(record.Latitude, record.Longitude, o.config.Resolution,
+						record.LineNumber, "admin lookup failed", err)
+				}
+				if found {
+					result.AdminMatchedRecords++
+					for i, column := range admin.Columns() {
+						record.SetExtra(column, values[i])
+					}
+				}
+			}
+			
+			// Append the time zone of the point
+			if zones != nil {
+				zone, err := zones.Lookup(record.Latitude, record.Longitude)
+				if err != nil {
+					return errors.NewH3Error(record.Latitude, record.Longitude, o.config.Resolution,
+						record.LineNumber, "time zone lookup failed", err)
+				}
+				record.SetExtra("timezone", zone)
+			}
+			
+			// Append the country code of the point
+			if countries != nil {
+				country, ambiguous, err := countries.Lookup(record.Latitude, record.Longitude)
+				if err != nil {
+					return errors.NewH3Error(record.Latitude, record.Longitude, o.config.Resolution,
+						record.LineNumber, "country lookup failed", err)
+				}
+				if ambiguous {
+					result.AmbiguousCountryRecords++
+				}
+				record.SetExtra("country", country)
+			}
 		} else {
 			result.InvalidRecords++
+			result.countInvalid(record.InvalidKind)
 			processLogger.LogRecordProcessed(record.LineNumber, false, "")
+			if record.InvalidKind == csv.InvalidEmpty || record.InvalidKind == csv.InvalidUnparseable || record.InvalidKind == csv.InvalidLookup {
+				footerLine = record.LineNumber
+			}
 			
 			// Log specific error details if available
 			if record.Latitude != 0 || record.Longitude != 0 {
@@ -201,27 +1304,168 @@ func (o *Orchestrator) processWithProgress() (*ProcessResult, error) {
 				processLogger.LogSkippedRecord(record.LineNumber, "empty or malformed coordinates")
 			}
 		}
+		
+		// Fail fast once enough records show the invalid share is over the limit
+		if result.ValidRecords+result.InvalidRecords >= invalidCheckMinRecords {
+			if err := o.checkInvalidRate(result); err != nil {
+				return err
+			}
+		}
 
-		// Write record to output
-		if err := writer.WriteRecord(record); err != nil {
-			writeErr := errors.NewFileError(o.config.OutputFile, "write", err)
-			errorCollector.Add(writeErr)
-			o.logger.LogError(writeErr)
-			return writeErr
+		// Write invalid records to the error file instead of the output
+		if rejects != nil && !record.IsValid {
+			rejected := record
+			if encryptor != nil {
+				protected, err := encryptor.protect(record)
+				if err != nil {
+					return errors.NewProcessingError("encrypt", record.LineNumber, "failed to encrypt record", err)
+				}
+				rejected = protected
+			}
+			if err := rejects.WriteRecord(rejected); err != nil {
+				return errors.NewFileError(o.config.ErrorFile, "write", err)
+			}
+			return nil
 		}
-		
-		return nil
+
+		// Hold the record until its stay is decided; records pass on in input order
+		if stays != nil {
+			return stays.add(record)
+		}
+
+		return emit(record)
 	})
 
 	if err != nil {
 		return nil, errors.NewProcessingError("stream_processing", 0, "stream processing failed", err)
 	}
+	if segmenter != nil {
+		result.Trips = segmenter.Trips()
+	}
+	if stays != nil {
+		if err := stays.finish(); err != nil {
+			return nil, errors.NewProcessingError("stream_processing", 0, "failed to write records held for stay detection", err)
+		}
+		result.Stays = stayDetector.Stays()
+		if stays.released > 0 {
+			o.logger.Warn("%d records were labeled moving before their stay was decided: more than %d records were held for stay detection",
+				stays.released, stays.limit)
+		}
+	}
+	if sampler != nil {
+		for _, record := range sampler.records() {
+			if err := writeOutput(record); err != nil {
+				return nil, errors.NewProcessingError("stream_processing", 0, "failed to write sampled records", err)
+			}
+		}
+		result.SampledOutRecords = sampler.dropped()
+		result.SampledCells = sampler.cells()
+	}
+	if err := o.checkInvalidRate(result); err != nil {
+		return nil, err
+	}
+	if dataChecks != nil {
+		for _, finding := range dataChecks.Finish() {
+			if err := o.reportDataFinding(result, finding); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// A last row without numeric coordinates after only valid rows is likely a totals line
+	if result.InvalidRecords == 1 && result.ValidRecords > 0 && footerLine == lastLine {
+		finding := validator.DataFinding{Check: "footer_row", Message: fmt.Sprintf(
+			"only the last row (line %d) has no numeric coordinates; if it is a totals or summary line, skip it with --skip-footer", footerLine)}
+		if err := o.reportDataFinding(result, finding); err != nil {
+			return nil, err
+		}
+	}
+	result.FooterRows = reader.FooterRows()
+	result.MalformedRows = streamProcessor.Stats().MalformedRows
+	result.NullValues = reader.NullValueCounts()
+	result.BytesRead = reader.InputOffset()
+
+	// Emit sorted records
+	if sorter != nil {
+		o.logger.Debug("Merging sorted output from %d spill files", sorter.SpillCount())
+		if err := sorter.Merge(writer.WriteRow); err != nil {
+			return nil, errors.NewProcessingError("sort", 0, "failed to write sorted output", err)
+		}
+	}
 
 	// Ensure all data is written
 	if err := writer.Flush(); err != nil {
 		return nil, errors.NewFileError(o.config.OutputFile, "flush", err)
 	}
 
+	if rejects != nil {
+		result.RejectedRows = rejects.Rows()
+		if err := rejects.Close(); err != nil {
+			return nil, errors.NewFileError(o.config.ErrorFile, "flush", err)
+		}
+	}
+
+	if o.config.VerifyOutput {
+		expected := result.TotalRecords - result.FilteredRecords - result.DuplicateRecords - result.RejectedRows - result.SampledOutRecords
+		if o.config.SpeedAction == config.SpeedActionDrop {
+			expected -= result.ImplausibleSpeedRecords
+		}
+		verified, err := o.verifyOutput(reader, expected)
+		if err != nil {
+			return nil, errors.NewProcessingError("verify_output", 0, "output verification failed", err)
+		}
+		result.VerifiedRows = verified
+	}
+	if single, ok := output.(*csv.Writer); ok {
+		result.BytesWritten = single.BytesWritten()
+	}
+	if partitioned, ok := output.(*csv.PartitionWriter); ok {
+		result.Partitions = partitioned.Partitions()
+		if o.config.SparkCompat {
+			if err := partitioned.Commit(); err != nil {
+				return nil, errors.NewFileError(o.config.OutputDir, "write", err)
+			}
+		}
+		result.PartitionFiles = partitioned.Files()
+	}
+	if o.config.Sign {
+		signed := o.config.OutputFile
+		if o.config.IsPartitioned() {
+			signed = filepath.Join(o.config.OutputDir, csv.ManifestFile)
+		}
+		signatureFile, err := o.signFile(signed)
+		if err != nil {
+			return nil, errors.NewFileError(signed, "sign", err)
+		}
+		result.SignatureFile = signatureFile
+	}
+	if updater, ok := output.(*updateWriter); ok {
+		result.UpdatedRows = updater.updater.Updated()
+	}
+	if redisOutput, ok := writer.(*redisWriter); ok {
+		result.RedisKeys = redisOutput.keys
+	}
+	result.ColumnStats = profile
+	if o.config.EmitSchema != "" {
+		if columnTypes == nil {
+			columnTypes = o.newSchemaInferrer(reader.GetHeaders(), len(reader.GetHeaders()), extraColumns)
+		}
+		title := o.config.OutputFile
+		if o.config.IsPartitioned() {
+			title = o.config.OutputDir
+		}
+		if err := columnTypes.WriteFile(o.config.EmitSchema, filepath.Base(title)); err != nil {
+			return nil, errors.NewFileError(o.config.EmitSchema, "write", err)
+		}
+	}
+	if coverage != nil {
+		result.Coverage = coverage
+		if o.config.CoverageReport != "" {
+			if err := writeCoverageReport(o.config.CoverageReport, coverage, o.config.Overwrite); err != nil {
+				return nil, errors.NewFileError(o.config.CoverageReport, "write", err)
+			}
+		}
+	}
+
 	// Log completion
 	processLogger.Complete(time.Since(time.Now()), result.ValidRecords, result.InvalidRecords)
 
@@ -238,6 +1482,297 @@ func (o *Orchestrator) processWithProgress() (*ProcessResult, error) {
 	return result, nil
 }
 
+// recordWriter is the output sink records are written to
+type recordWriter interface {
+	WriteRecord(record *csv.Record) error
+	FormatRecord(record *csv.Record) ([]string, error)
+	WriteRow(row []string) error
+	Flush() error
+	Close() error
+}
+
+// newRecordWriter creates the configured output sink: a single CSV file, a partitioned
+// directory, a DuckDB table, an Avro or ORC file, or the source database table in backfill mode. A single
+// CSV file is buffered according to the input sizing.
+func (o *Orchestrator) newRecordWriter(headers []string, extraColumns []string, sizing csv.Sizing) (recordWriter, error) {
+	if o.config.IsUpdate() {
+		return o.newUpdateWriter(headers, extraColumns)
+	}
+	if o.config.OutputFormat == config.OutputFormatDuckDB {
+		return o.newDuckDBWriter(headers, extraColumns)
+	}
+	if o.config.IsTypedOutput() {
+		return o.newTypedWriter(headers, extraColumns)
+	}
+	_, _, h3Column := o.config.CoordinateColumns()
+	quote, err := csv.ParseQuoteMode(o.config.Quote)
+	if err != nil {
+		return nil, errors.NewConfigError("quote", o.config.Quote, "invalid quote mode", err)
+	}
+	writerConfig := csv.Config{
+		OutputFile: o.config.OutputFile,
+		HasHeaders: o.config.InputHasHeaders(),
+		Overwrite:  o.config.Overwrite,
+		H3Column:   h3Column,
+		ExtraColumns: extraColumns,
+		WriteRetry: csv.RetryPolicy{Retries: o.config.WriteRetries, Backoff: o.config.RetryBackoff},
+		Quote:      quote,
+		CRLF:       o.config.CRLF,
+		EscapeFormulas: o.config.EscapeFormulas,
+	}
+
+	if o.config.IsPartitioned() {
+		resolution := o.config.PartitionByH3Res
+		column := fmt.Sprintf("h3_r%d", resolution)
+		writer, err := csv.NewPartitionWriter(o.config.OutputDir, column, func(index string) (string, error) {
+			return h3.ParentIndex(index, resolution)
+		}, headers, writerConfig)
+		if err != nil {
+			return nil, errors.NewFileError(o.config.OutputDir, "create", err)
+		}
+		return writer, nil
+	}
+
+	writerConfig.BufferSize = sizing.BufferSize()
+	writer, err := csv.NewWriter(o.config.OutputFile, headers, writerConfig)
+	if err != nil {
+		return nil, errors.NewFileError(o.config.OutputFile, "create", err)
+	}
+	return writer, nil
+}
+
+// sortKey returns the key used to order output by H3 index; invalid records sort last
+func sortKey(record *csv.Record) string {
+	if record.IsValid && record.H3Index != "" {
+		return record.H3Index
+	}
+	return "~"
+}
+
+// newDeduplicator creates the configured duplicate detector, or nil when dedupe is disabled
+func (o *Orchestrator) newDeduplicator(headers []string) (*dedupe.Deduplicator, error) {
+	var deduplicator *dedupe.Deduplicator
+	var err error
+	switch {
+	case o.config.DedupeExact:
+		deduplicator, err = dedupe.NewExactDeduplicator(o.config.DedupeCapacity, o.config.DedupeFPRate)
+	case o.config.DedupeKeys != "":
+		keys := strings.Split(o.config.DedupeKeys, ",")
+		deduplicator, err = dedupe.NewKeyDeduplicator(keys, headers, o.config.DedupeCapacity, o.config.DedupeFPRate)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	o.logger.Debug("Dedupe bloom filter uses %d bytes", deduplicator.FilterSizeBytes())
+	return deduplicator, nil
+}
+
+// statsColumns returns the input column names profiled by --stats: the headers, or
+// column indexes for files without a header row (sized from the first record)
+func statsColumns(headers []string, width int) []string {
+	if len(headers) > 0 {
+		return headers
+	}
+	columns := make([]string, width)
+	for i := range columns {
+		columns[i] = strconv.Itoa(i)
+	}
+	return columns
+}
+
+// invalidCheckMinRecords is the number of records processed before --max-invalid-pct
+// is enforced during processing, so a few bad rows at the start of a file do not abort
+// it; smaller files are checked once at the end
+const invalidCheckMinRecords = 1000
+
+// checkInvalidRate fails when the share of invalid records exceeds --max-invalid-pct
+func (o *Orchestrator) checkInvalidRate(result *ProcessResult) error {
+	processed := result.ValidRecords + result.InvalidRecords
+	if o.config.MaxInvalidPct <= 0 || processed == 0 {
+		return nil
+	}
+	if float64(result.InvalidRecords)*100 <= o.config.MaxInvalidPct*float64(processed) {
+		return nil
+	}
+	return errors.NewProcessingError("max_invalid_pct", 0, fmt.Sprintf("%d of %d records (%.1f%%) are invalid, over the %g%% limit",
+		result.InvalidRecords, processed, float64(result.InvalidRecords)*100/float64(processed), o.config.MaxInvalidPct), nil)
+}
+
+// dataChecks returns the data sanity checks to run on valid coordinates, or nil
+func (o *Orchestrator) dataChecks() (*validator.DataChecks, error) {
+	var checks []validator.DataCheck
+	if o.config.ConstantCheckRows > 0 {
+		checks = append(checks, validator.NewConstantCoordinates(o.config.ConstantCheckRows))
+	}
+	mode, err := validator.ParsePrecisionMode(o.config.PrecisionCheck)
+	if err != nil {
+		return nil, err
+	}
+	if mode != validator.PrecisionOff {
+		edge, err := h3.AverageEdgeLengthMeters(o.config.Resolution)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, validator.NewCoarsePrecision(validator.DefaultPrecisionCheckRows, o.config.Resolution, edge))
+	}
+	if len(checks) == 0 {
+		return nil, nil
+	}
+	return validator.NewDataChecks(checks...), nil
+}
+
+// reportDataFinding adds a data check finding to the warnings, or fails the run with
+// --strict and for coarse coordinates with --precision-check error
+func (o *Orchestrator) reportDataFinding(result *ProcessResult, finding validator.DataFinding) error {
+	if o.config.Strict || (finding.Check == validator.PrecisionCheckName && o.config.PrecisionCheck == validator.PrecisionError) {
+		return errors.NewProcessingError("data_check", 0, finding.String(), nil)
+	}
+	o.logger.Warn("Data check %s", finding)
+	result.DataWarnings = append(result.DataWarnings, finding.String())
+	return nil
+}
+
+// signFile writes the detached signature of a completed output file
+func (o *Orchestrator) signFile(path string) (string, error) {
+	signer, err := sign.LoadPrivateKey(o.config.SignKey)
+	if err != nil {
+		return "", err
+	}
+	return sign.WriteSignature(signer, path)
+}
+
+// newSchemaInferrer creates the --emit-schema column type inferrer for the output
+// columns, naming the input columns by index for files without a header row
+func (o *Orchestrator) newSchemaInferrer(headers []string, width int, extraColumns []string) *schema.Inferrer {
+	_, _, h3Column := o.config.CoordinateColumns()
+	if h3Column == "" {
+		h3Column = csv.DefaultH3Column
+	}
+	columns := statsColumns(headers, width)
+	inferrer := schema.NewInferrer(csv.OutputHeaders(columns, h3Column, extraColumns))
+	cell := schema.H3Info{Resolution: o.config.Resolution, Kind: schema.KindCell}
+	inferrer.SetH3(h3Column, cell)
+	if pairs, err := csv.ParseCoordPairs(o.config.CoordPairs); o.config.CoordPairs != "" && err == nil {
+		for _, pair := range pairs[1:] {
+			inferrer.SetH3(pair.H3Column, cell)
+		}
+	}
+	if edges, err := csv.ParseEdgeSpecs(o.config.AddEdge); o.config.AddEdge != "" && err == nil {
+		for _, edge := range edges {
+			inferrer.SetH3(edge.EdgeColumn, schema.H3Info{Resolution: o.config.Resolution, Kind: schema.KindDirectedEdge})
+		}
+	}
+	if encrypted, err := o.encryptedColumns(headers); o.config.EncryptColumns != "" && err == nil {
+		for _, column := range encrypted {
+			if column < len(columns) {
+				inferrer.SetEncrypted(columns[column])
+			}
+		}
+	}
+	return inferrer
+}
+
+// writeCoverageReport writes the region cells without any data point, one per row,
+// replacing an existing report only with overwrite
+func writeCoverageReport(path string, coverage *h3.Coverage, overwrite bool) error {
+	if err := filehandler.NewFileHandler().ValidateOutputFile(path, overwrite); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	writer := bufio.NewWriter(file)
+	writer.WriteString("h3_index\n")
+	for _, cell := range coverage.EmptyCells() {
+		writer.WriteString(cell + "\n")
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// countCellDensity performs a first pass over the input counting valid points per H3 cell
+func (o *Orchestrator) countCellDensity() (*h3.DensityCounter, error) {
+	reader, err := o.openReader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	density := h3.NewDensityCounter(o.config.OutlierK)
+	streamProcessor := csv.NewStreamingProcessor(o.validator, &h3GeneratorAdapter{
+		generator: o.h3Generator,
+	})
+	err = streamProcessor.ProcessStream(reader, csv.Config{
+		Resolution: o.config.Resolution,
+		Workers:    o.config.Workers,
+		Unordered:  true, // Counts do not depend on order
+	}, func(record *csv.Record) error {
+		if record.IsValid {
+			density.Add(record.H3Index)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	o.logger.Debug("Density pass found %d occupied cells", density.Cells())
+	return density, nil
+}
+
+// numberColumns reads the input once and returns the input columns --float-format
+// rewrites: those schema inference types as number, except the coordinate and
+// fallback coordinate columns, whose values are verified as read
+func (o *Orchestrator) numberColumns() ([]int, error) {
+	reader, err := o.openInput()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var inferrer *schema.Inferrer
+	for {
+		record, err := reader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if stderrors.Is(err, csv.ErrMalformedRow) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if inferrer == nil {
+			inferrer = schema.NewInferrer(statsColumns(reader.GetHeaders(), len(record.OriginalData)))
+		}
+		inferrer.Add(record.OriginalData)
+	}
+	if inferrer == nil {
+		return []int{}, nil
+	}
+
+	coordinates := make(map[int]bool)
+	for _, column := range reader.CoordinateColumns() {
+		coordinates[column] = true
+	}
+	columns := []int{}
+	for i, column := range inferrer.Columns() {
+		if column.Type == schema.TypeNumber && !coordinates[i] {
+			columns = append(columns, i)
+		}
+	}
+	o.logger.Debug("Float format applies to input columns %v", columns)
+	return columns, nil
+}
+
 // ProgressReporter handles progress reporting for large file processing
 type ProgressReporter struct {
 	fileSize      int64
@@ -311,6 +1846,22 @@ func (o *Orchestrator) GetConfig() *config.Config {
 	return o.config
 }
 
+// SetRecordCounter sets a shared counter incremented for every record processed
+func (o *Orchestrator) SetRecordCounter(counter *atomic.Int64) {
+	o.recordCounter = counter
+}
+
+// SetFileProgress sets the batch progress tracker updated for every record processed
+func (o *Orchestrator) SetFileProgress(progress *FileProgress) {
+	o.fileProgress = progress
+}
+
+// SetLogger replaces the default stderr logger, e.g. with a file or JSON lines sink
+// or a caller's own implementation
+func (o *Orchestrator) SetLogger(logger logging.Logger) {
+	o.logger = logger
+}
+
 // SetConfig updates the configuration
 func (o *Orchestrator) SetConfig(cfg *config.Config) {
 	o.config = cfg
@@ -1,13 +1,36 @@
 package service
 
 import (
+	"archive/zip"
+	"bufio"
+	"crypto/ed25519"
+	"crypto/x509"
+	encodingcsv "encoding/csv"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/scritchley/orc"
 
 	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/encrypt"
+	"csv-h3-tool/internal/filehandler"
+	"csv-h3-tool/internal/h3"
+	"csv-h3-tool/internal/logging"
+	"csv-h3-tool/internal/sign"
+	"csv-h3-tool/internal/trajectory"
 )
 
 // TestOrchestrator_ProcessFile tests the complete workflow integration
@@ -200,6 +223,291 @@ invalid,invalid,Invalid Both
 	}
 }
 
+// processCSV runs the orchestrator over the given CSV content and returns the result and output rows
+func processCSV(t *testing.T, content string, setup func(*config.Config)) (*ProcessResult, [][]string) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+	cfg.Overwrite = true
+	if setup != nil {
+		setup(cfg)
+	}
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+
+	outputFile, err := os.Open(result.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to open output file: %v", err)
+	}
+	defer outputFile.Close()
+
+	reader := encodingcsv.NewReader(outputFile)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	return result, rows
+}
+
+// TestOrchestrator_ExpectBBox tests flagging of records outside the expected region
+func TestOrchestrator_ExpectBBox(t *testing.T) {
+	testCSV := `latitude,longitude,name
+40.7128,-74.0060,New York
+-74.0060,40.7128,Swapped
+51.5074,-0.1278,London
+invalid,invalid,Invalid
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.ExpectBBox = "-125,24,-66,50" // Contiguous United States
+	})
+
+	if result.OutsideBBoxRecords != 2 {
+		t.Errorf("Expected 2 records outside bbox, got %d", result.OutsideBBoxRecords)
+	}
+
+	expectedHeader := []string{"latitude", "longitude", "name", "h3_index", "outside_bbox"}
+	if strings.Join(rows[0], ",") != strings.Join(expectedHeader, ",") {
+		t.Errorf("Expected header %v, got %v", expectedHeader, rows[0])
+	}
+
+	expectedFlags := []string{"false", "true", "true", ""}
+	for i, expected := range expectedFlags {
+		if got := rows[i+1][4]; got != expected {
+			t.Errorf("Row %d: expected outside_bbox %q, got %q", i+1, expected, got)
+		}
+	}
+}
+
+// TestOrchestrator_FlagOutliers tests the neighborhood density outlier pass
+func TestOrchestrator_FlagOutliers(t *testing.T) {
+	testCSV := `latitude,longitude,name
+40.7128,-74.0060,A
+40.7130,-74.0062,B
+40.7126,-74.0058,C
+35.0000,-50.0000,Glitch
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.FlagOutliers = true
+	})
+
+	if result.OutlierRecords != 1 {
+		t.Errorf("Expected 1 outlier record, got %d", result.OutlierRecords)
+	}
+
+	if rows[0][len(rows[0])-1] != "is_outlier" {
+		t.Errorf("Expected last header to be is_outlier, got %v", rows[0])
+	}
+
+	expectedFlags := []string{"false", "false", "false", "true"}
+	for i, expected := range expectedFlags {
+		if got := rows[i+1][4]; got != expected {
+			t.Errorf("Row %d: expected is_outlier %q, got %q", i+1, expected, got)
+		}
+	}
+}
+
+// TestOrchestrator_Dedupe tests exact and key-based duplicate removal
+func TestOrchestrator_Dedupe(t *testing.T) {
+	testCSV := `id,timestamp,latitude,longitude
+1,t1,40.7128,-74.0060
+1,t1,40.7128,-74.0060
+1,t2,40.7128,-74.0060
+2,t1,51.5074,-0.1278
+1,t2,41.0000,-75.0000
+`
+	tests := []struct {
+		name          string
+		setup         func(*config.Config)
+		expectedDrops int
+	}{
+		{"exact", func(cfg *config.Config) { cfg.DedupeExact = true }, 1},
+		{"keys", func(cfg *config.Config) { cfg.DedupeKeys = "id,timestamp" }, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, rows := processCSV(t, testCSV, tt.setup)
+
+			if result.DuplicateRecords != tt.expectedDrops {
+				t.Errorf("Expected %d duplicates, got %d", tt.expectedDrops, result.DuplicateRecords)
+			}
+			if result.TotalRecords != 5 {
+				t.Errorf("Expected 5 total records, got %d", result.TotalRecords)
+			}
+			if len(rows) != 1+5-tt.expectedDrops {
+				t.Errorf("Expected %d output rows, got %d", 1+5-tt.expectedDrops, len(rows))
+			}
+		})
+	}
+}
+
+// TestOrchestrator_SortByH3 tests H3-ordered output using spill files
+func TestOrchestrator_SortByH3(t *testing.T) {
+	testCSV := `latitude,longitude,name
+51.5074,-0.1278,London
+invalid,invalid,Invalid
+40.7128,-74.0060,New York
+34.0522,-118.2437,Los Angeles
+35.6762,139.6503,Tokyo
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.SortByH3 = true
+		cfg.SortChunkSize = 2
+		cfg.TempDir = t.TempDir()
+	})
+
+	if result.TotalRecords != 5 {
+		t.Errorf("Expected 5 total records, got %d", result.TotalRecords)
+	}
+	if len(rows) != 6 {
+		t.Fatalf("Expected 6 output rows, got %d", len(rows))
+	}
+
+	// Valid rows must be in ascending H3 order, followed by the invalid row
+	for i := 2; i < 5; i++ {
+		if rows[i-1][3] > rows[i][3] {
+			t.Errorf("Rows out of order: %s before %s", rows[i-1][3], rows[i][3])
+		}
+	}
+	if rows[5][2] != "Invalid" || rows[5][3] != "" {
+		t.Errorf("Expected invalid row last, got %v", rows[5])
+	}
+}
+
+// TestOrchestrator_PartitionByH3Res tests Hive-style partitioned output
+func TestOrchestrator_PartitionByH3Res(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	testCSV := `latitude,longitude,name
+40.7128,-74.0060,New York
+40.7130,-74.0062,Manhattan
+51.5074,-0.1278,London
+invalid,invalid,Invalid
+`
+	if err := os.WriteFile(inputFile, []byte(testCSV), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputDir = filepath.Join(tempDir, "out")
+	cfg.PartitionByH3Res = 3
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+
+	if result.Partitions != 3 {
+		t.Errorf("Expected 3 partitions (NYC, London, invalid), got %d", result.Partitions)
+	}
+	if result.OutputFile != cfg.OutputDir {
+		t.Errorf("Expected output %s, got %s", cfg.OutputDir, result.OutputFile)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(cfg.OutputDir, "h3_r3=*", "part-0001.csv"))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(matches) != 3 {
+		t.Errorf("Expected 3 partition files, got %v", matches)
+	}
+
+	defaultPartition := filepath.Join(cfg.OutputDir, "h3_r3=__HIVE_DEFAULT_PARTITION__", "part-0001.csv")
+	if _, err := os.Stat(defaultPartition); err != nil {
+		t.Errorf("Expected default partition for invalid rows: %v", err)
+	}
+}
+
+// TestOrchestrator_PartitionArchive tests packaging partitioned output into a zip archive
+func TestOrchestrator_PartitionArchive(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	if err := os.WriteFile(inputFile, []byte("latitude,longitude\n40.7128,-74.0060\n51.5074,-0.1278\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputDir = filepath.Join(tempDir, "out")
+	cfg.OutputFile = filepath.Join(tempDir, "out.zip")
+	cfg.PartitionByH3Res = 3
+	cfg.SparkCompat = true
+
+	orchestrator := NewOrchestrator(cfg)
+	result, err := orchestrator.ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.ArchiveFile != cfg.OutputFile {
+		t.Errorf("Expected archive %s, got %q", cfg.OutputFile, result.ArchiveFile)
+	}
+
+	// Files in the output directory that were not written by the run are left out
+	if err := os.WriteFile(filepath.Join(cfg.OutputDir, "stale.csv"), []byte("a\n"), 0644); err != nil {
+		t.Fatalf("Failed to create stale file: %v", err)
+	}
+	if err := orchestrator.writePartitionArchive(result); err != nil {
+		t.Fatalf("writePartitionArchive failed: %v", err)
+	}
+
+	archive, err := zip.OpenReader(cfg.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer archive.Close()
+	entries := make(map[string]bool)
+	for _, file := range archive.File {
+		entries[file.Name] = true
+	}
+	if entries["stale.csv"] {
+		t.Errorf("Expected stale file to be left out of the archive, got %v", entries)
+	}
+	for _, name := range []string{csv.ManifestFile, ArchiveManifestFile, ArchiveSummaryFile} {
+		if !entries[name] {
+			t.Errorf("Expected archive entry %s, got %v", name, entries)
+		}
+	}
+	parts, _ := filepath.Glob(filepath.Join(cfg.OutputDir, "h3_r3=*", "part-0001.csv"))
+	for _, part := range parts {
+		name, _ := filepath.Rel(cfg.OutputDir, part)
+		if !entries[filepath.ToSlash(name)] {
+			t.Errorf("Expected partition file %s in the archive", name)
+		}
+	}
+
+	// A zip output inside the output directory is rejected
+	cfg = config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputDir = filepath.Join(tempDir, "inside")
+	cfg.OutputFile = filepath.Join(cfg.OutputDir, "out.zip")
+	cfg.PartitionByH3Res = 3
+	if _, err := NewOrchestrator(cfg).ProcessFile(); err == nil {
+		t.Error("Expected a zip output inside the output directory to be rejected")
+	}
+
+	// A zip output without partitioning or a batch is rejected
+	cfg = config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "single.zip")
+	if _, err := NewOrchestrator(cfg).ProcessFile(); err == nil {
+		t.Error("Expected a zip output for a single file to be rejected")
+	}
+}
+
 // TestOrchestrator_ValidateComponents tests component validation
 func TestOrchestrator_ValidateComponents(t *testing.T) {
 	cfg := config.NewConfig()
@@ -328,4 +636,2003 @@ func BenchmarkOrchestrator_ProcessFile(b *testing.B) {
 			b.Fatalf("ProcessFile failed: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// TestBatchProcessor tests concurrent processing of several files with a combined result
+func TestBatchProcessor(t *testing.T) {
+	tempDir := t.TempDir()
+	contents := []string{
+		"latitude,longitude\n40.7128,-74.0060\n34.0522,-118.2437\n",
+		"latitude,longitude\n51.5074,-0.1278\n91.0,0.0\n",
+		"latitude,longitude\n48.8566,2.3522\n",
+	}
+	var inputFiles []string
+	for i, content := range contents {
+		inputFile := filepath.Join(tempDir, fmt.Sprintf("input%d.csv", i))
+		if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test CSV file: %v", err)
+		}
+		inputFiles = append(inputFiles, inputFile)
+	}
+	inputFiles = append(inputFiles, filepath.Join(tempDir, "missing.csv"))
+
+	cfg := config.NewConfig()
+	cfg.ParallelFiles = 2
+	cfg.Workers = 4
+
+	batch := NewBatchProcessor(cfg, inputFiles)
+	if batch.WorkersPerFile() != 2 {
+		t.Errorf("Expected 2 workers per file, got %d", batch.WorkersPerFile())
+	}
+
+	result := batch.Process()
+	if len(result.Files) != len(inputFiles) {
+		t.Fatalf("Expected %d file results, got %d", len(inputFiles), len(result.Files))
+	}
+	if result.FailedFiles != 1 || result.Files[3].Err == nil {
+		t.Errorf("Expected only the missing file to fail, got %d failures", result.FailedFiles)
+	}
+	if result.TotalRecords != 5 || result.ValidRecords != 4 || result.InvalidRecords != 1 {
+		t.Errorf("Expected 5 total, 4 valid, 1 invalid; got %d, %d, %d",
+			result.TotalRecords, result.ValidRecords, result.InvalidRecords)
+	}
+	for i, file := range result.Files[:3] {
+		if file.InputFile != inputFiles[i] {
+			t.Errorf("Expected result %d for %s, got %s", i, inputFiles[i], file.InputFile)
+		}
+		if _, err := os.Stat(file.Result.OutputFile); err != nil {
+			t.Errorf("Expected output file for %s: %v", file.InputFile, err)
+		}
+	}
+	if cfg.InputFile != "" || cfg.Workers != 4 {
+		t.Error("Expected batch processing to leave the template configuration unchanged")
+	}
+
+	progress := batch.Progress()
+	if progress.Completed != 4 || progress.Running != 0 || progress.Failed != 1 {
+		t.Errorf("Expected 4 completed, 0 running, 1 failed; got %d, %d, %d",
+			progress.Completed, progress.Running, progress.Failed)
+	}
+	if progress.Records != 5 || progress.Invalid != 1 {
+		t.Errorf("Expected 5 records and 1 invalid in progress, got %d and %d", progress.Records, progress.Invalid)
+	}
+	if file := progress.Files[1]; file.State != FileDone || file.Records != 2 || file.Invalid != 1 || file.Fraction() != 1 {
+		t.Errorf("Unexpected progress for %s: %+v", file.InputFile, file)
+	}
+	if file := progress.Files[3]; file.State != FileFailed {
+		t.Errorf("Expected %s to be failed, got %s", file.InputFile, file.State)
+	}
+}
+
+// TestBatchProcessor_SkipsLockedFiles tests that files locked by another instance are skipped
+func TestBatchProcessor_SkipsLockedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	var inputFiles []string
+	for i := 0; i < 2; i++ {
+		inputFile := filepath.Join(tempDir, fmt.Sprintf("input%d.csv", i))
+		if err := os.WriteFile(inputFile, []byte("latitude,longitude\n40.7128,-74.0060\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test CSV file: %v", err)
+		}
+		inputFiles = append(inputFiles, inputFile)
+	}
+
+	cfg := config.NewConfig()
+	cfg.LockDir = t.TempDir()
+	lockPath, err := filehandler.NewFileHandler().LockPath(inputFiles[1], cfg.LockDir)
+	if err != nil {
+		t.Fatalf("LockPath failed: %v", err)
+	}
+	lock, err := filehandler.TryLock(lockPath)
+	if err != nil {
+		t.Fatalf("TryLock failed: %v", err)
+	}
+	defer lock.Unlock()
+
+	result := NewBatchProcessor(cfg, inputFiles).Process()
+	if result.SkippedFiles != 1 || !result.Files[1].Skipped || result.Files[1].Result != nil {
+		t.Errorf("Expected the locked file to be skipped, got %+v", result.Files[1])
+	}
+	if result.FailedFiles != 0 || result.TotalRecords != 1 {
+		t.Errorf("Expected the unlocked file to be processed, got %d failed, %d records", result.FailedFiles, result.TotalRecords)
+	}
+	if entries, _ := os.ReadDir(cfg.LockDir); len(entries) != 1 {
+		t.Errorf("Expected only the held lock file to remain, got %d files", len(entries))
+	}
+}
+
+// TestBatchProcessor_WriteArchive tests packaging a batch's outputs with a manifest
+// and summary into one zip archive
+func TestBatchProcessor_WriteArchive(t *testing.T) {
+	tempDir := t.TempDir()
+	var inputFiles []string
+	for i := 0; i < 2; i++ {
+		inputFile := filepath.Join(tempDir, fmt.Sprintf("input%d.csv", i))
+		if err := os.WriteFile(inputFile, []byte("latitude,longitude\n40.7128,-74.0060\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test CSV file: %v", err)
+		}
+		inputFiles = append(inputFiles, inputFile)
+	}
+	inputFiles = append(inputFiles, filepath.Join(tempDir, "missing.csv"))
+
+	cfg := config.NewConfig()
+	cfg.OutputFile = filepath.Join(tempDir, "handoff.zip")
+	batch := NewBatchProcessor(cfg, inputFiles)
+	result := batch.Process()
+	if result.FailedFiles != 1 {
+		t.Fatalf("Expected the missing file to fail, got %d failed", result.FailedFiles)
+	}
+	manifest, err := batch.WriteArchive(cfg.OutputFile, result)
+	if err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if len(manifest.Files) != 2 || manifest.Files[1].Name != "input1_with_h3.csv" || manifest.Files[1].Source != inputFiles[1] {
+		t.Errorf("Expected the two processed outputs in the manifest, got %+v", manifest.Files)
+	}
+
+	archive, err := zip.OpenReader(cfg.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer archive.Close()
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	expected := []string{"input0_with_h3.csv", "input1_with_h3.csv", ArchiveManifestFile, ArchiveSummaryFile}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected archive entries %v, got %v", expected, names)
+	}
+	summaryFile, err := archive.Open(ArchiveSummaryFile)
+	if err != nil {
+		t.Fatalf("Failed to open summary: %v", err)
+	}
+	defer summaryFile.Close()
+	var summary BatchArchiveSummary
+	if err := json.NewDecoder(summaryFile).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if summary.Files != 3 || summary.Packaged != 2 || summary.FailedFiles != 1 || summary.ValidRecords != 2 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+}
+
+// TestBatchProcessor_BatchState tests that a rerun skips the inputs an earlier run
+// processed with the same settings, unless they changed or Force or Overwrite is set
+func TestBatchProcessor_BatchState(t *testing.T) {
+	tempDir := t.TempDir()
+	var inputFiles, outputFiles []string
+	for i := 0; i < 2; i++ {
+		inputFile := filepath.Join(tempDir, fmt.Sprintf("input%d.csv", i))
+		if err := os.WriteFile(inputFile, []byte("latitude,longitude\n40.7128,-74.0060\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test CSV file: %v", err)
+		}
+		inputFiles = append(inputFiles, inputFile)
+		outputFiles = append(outputFiles, strings.TrimSuffix(inputFile, ".csv")+"_with_h3.csv")
+	}
+
+	cfg := config.NewConfig()
+	cfg.BatchStateFile = filepath.Join(tempDir, "state.json")
+	run := func() *BatchResult {
+		batch := NewBatchProcessor(cfg, inputFiles)
+		if err := batch.LoadState(); err != nil {
+			t.Fatalf("LoadState failed: %v", err)
+		}
+		return batch.Process()
+	}
+
+	result := run()
+	if result.UpToDateFiles != 0 || result.TotalRecords != 2 {
+		t.Fatalf("Expected both files to be processed, got %d up to date, %d records", result.UpToDateFiles, result.TotalRecords)
+	}
+	checksum := result.Files[0].SHA256
+	if len(checksum) != 64 || checksum != result.Files[1].SHA256 {
+		t.Errorf("Expected matching SHA-256 checksums, got %q and %q", checksum, result.Files[1].SHA256)
+	}
+
+	// Unchanged inputs are skipped; a changed input is processed again
+	os.WriteFile(inputFiles[1], []byte("latitude,longitude\n48.8566,2.3522\n51.5074,-0.1278\n"), 0644)
+	os.Remove(outputFiles[1])
+	result = run()
+	if !result.Files[0].UpToDate || result.Files[0].Result != nil || result.Files[1].UpToDate {
+		t.Errorf("Expected only the unchanged file to be skipped, got %+v", result.Files)
+	}
+	if result.UpToDateFiles != 1 || result.TotalRecords != 2 || result.FailedFiles != 0 {
+		t.Errorf("Expected 1 up to date file and 2 records, got %d and %d", result.UpToDateFiles, result.TotalRecords)
+	}
+
+	// A missing output is regenerated
+	os.Remove(outputFiles[0])
+	if result = run(); result.UpToDateFiles != 1 || result.Files[0].UpToDate {
+		t.Errorf("Expected the file with a missing output to be processed, got %+v", result.Files)
+	}
+
+	// Outputs produced with other settings are not up to date
+	cfg.Resolution = 9
+	if result = run(); result.UpToDateFiles != 0 {
+		t.Errorf("Expected a resolution change to reprocess every file, got %+v", result.Files)
+	}
+
+	// --overwrite reprocesses every file, recording the new settings
+	cfg.Overwrite = true
+	if result = run(); result.UpToDateFiles != 0 || result.TotalRecords != 3 {
+		t.Errorf("Expected --overwrite to process every file, got %d up to date, %d records", result.UpToDateFiles, result.TotalRecords)
+	}
+	cfg.Overwrite = false
+	if result = run(); result.UpToDateFiles != 2 {
+		t.Errorf("Expected both files to be up to date at the new resolution, got %+v", result.Files)
+	}
+
+	cfg.Force = true
+	if result = run(); result.UpToDateFiles != 0 {
+		t.Errorf("Expected --force to process every file, got %d up to date", result.UpToDateFiles)
+	}
+}
+
+// TestBatchProcessor_BatchStateShared tests that instances sharing a batch state see
+// the files the others completed after they started
+func TestBatchProcessor_BatchStateShared(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFiles := []string{filepath.Join(tempDir, "a.csv"), filepath.Join(tempDir, "b.csv")}
+	for _, inputFile := range inputFiles {
+		if err := os.WriteFile(inputFile, []byte("latitude,longitude\n40.7128,-74.0060\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test CSV file: %v", err)
+		}
+	}
+
+	cfg := config.NewConfig()
+	cfg.BatchStateFile = filepath.Join(tempDir, "state.json")
+	first := NewBatchProcessor(cfg, inputFiles[:1])
+	second := NewBatchProcessor(cfg, inputFiles)
+	for _, batch := range []*BatchProcessor{first, second} {
+		if err := batch.LoadState(); err != nil {
+			t.Fatalf("LoadState failed: %v", err)
+		}
+	}
+
+	// The second instance loaded its state before the first completed a.csv
+	if result := first.Process(); result.TotalRecords != 1 {
+		t.Fatalf("Expected the first instance to process a.csv, got %+v", result.Files)
+	}
+	result := second.Process()
+	if !result.Files[0].UpToDate || result.Files[1].UpToDate || result.FailedFiles != 0 {
+		t.Fatalf("Expected only a.csv to be up to date for the second instance, got %+v", result.Files)
+	}
+
+	// Both completions are recorded
+	state, err := loadBatchState(cfg.BatchStateFile)
+	if err != nil {
+		t.Fatalf("loadBatchState failed: %v", err)
+	}
+	if len(state.Files) != 2 {
+		t.Errorf("Expected both files in the batch state, got %d", len(state.Files))
+	}
+}
+
+// TestBatchProcessor_StatsInterval tests the periodic statistics reports of long batch runs
+func TestBatchProcessor_StatsInterval(t *testing.T) {
+	tempDir := t.TempDir()
+	var inputFiles []string
+	for i, content := range []string{"latitude,longitude\n40.7128,-74.0060\n91.0,0.0\n", "latitude,longitude\n48.8566,2.3522\n"} {
+		inputFile := filepath.Join(tempDir, fmt.Sprintf("input%d.csv", i))
+		if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test CSV file: %v", err)
+		}
+		inputFiles = append(inputFiles, inputFile)
+	}
+
+	cfg := config.NewConfig()
+	cfg.StatsInterval = time.Hour // Only the final report is due
+	logger := &recordingLogger{}
+	batch := NewBatchProcessor(cfg, inputFiles)
+	batch.SetLogger(logger)
+	batch.Process()
+
+	var reports []string
+	for _, message := range logger.messages {
+		if strings.HasPrefix(message, "INFO: Stats: ") {
+			reports = append(reports, message)
+		}
+	}
+	if len(reports) != 1 || !strings.Contains(reports[0], ": 3 records (") ||
+		!strings.Contains(reports[0], "1 invalid, 2 files completed; total: 3 records, 1 invalid, 2/2 files completed, 0 failed") {
+		t.Fatalf("Expected one final report, got %q", reports)
+	}
+
+	// A new window starts after each report
+	window := statsWindow{start: time.Now(), records: 1, invalid: 1, completed: 1}
+	batch.logStats(logger, &window)
+	report := logger.messages[len(logger.messages)-1]
+	if !strings.Contains(report, ": 2 records (") || !strings.Contains(report, "0 invalid, 1 files completed; total: 3 records") {
+		t.Errorf("Expected the report to cover the window only, got %q", report)
+	}
+	if window.records != 3 || window.invalid != 1 || window.completed != 2 {
+		t.Errorf("Expected the window to be rotated, got %+v", window)
+	}
+}
+
+// TestOrchestrator_EmitParsedCoords tests the latitude_parsed/longitude_parsed columns
+func TestOrchestrator_EmitParsedCoords(t *testing.T) {
+	testCSV := `latitude,longitude
+" 40.71280 ",-74.0060
+"1,234.5",0
+91.0,0.0
+invalid,0
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.EmitParsedCoords = true
+		cfg.NumberLocale = "en"
+	})
+
+	expectedHeader := "latitude,longitude,h3_index,latitude_parsed,longitude_parsed"
+	if got := strings.Join(rows[0], ","); got != expectedHeader {
+		t.Errorf("Expected header %s, got %s", expectedHeader, got)
+	}
+
+	expected := [][2]string{{"40.7128", "-74.006"}, {"1234.5", "0"}, {"91", "0"}, {"", ""}}
+	for i, want := range expected {
+		row := rows[i+1]
+		if row[3] != want[0] || row[4] != want[1] {
+			t.Errorf("Row %d: expected parsed coords %v, got [%s %s]", i+1, want, row[3], row[4])
+		}
+	}
+}
+
+// TestOrchestrator_SanityCheck tests flagging of records that fail the location sanity check
+func TestOrchestrator_SanityCheck(t *testing.T) {
+	testCSV := `latitude,longitude,name
+40.7128,-74.0060,New York
+35.0000,-40.0000,Mid Atlantic
+48.8566,2.3522,Paris
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.SanityCheck = "country:US"
+	})
+
+	if result.SanityFailedRecords != 2 {
+		t.Errorf("Expected 2 records failing the sanity check, got %d", result.SanityFailedRecords)
+	}
+
+	expectedFlags := []string{"false", "true", "true"}
+	for i, expected := range expectedFlags {
+		if got := rows[i+1][4]; got != expected {
+			t.Errorf("Row %d: expected failed_sanity_check %q, got %q", i+1, expected, got)
+		}
+	}
+}
+
+// TestOrchestrator_ExpectOutputSchema tests that schema mismatches fail before any rows are written
+func TestOrchestrator_ExpectOutputSchema(t *testing.T) {
+	testCSV := `latitude,longitude,name
+40.7128,-74.0060,New York
+`
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	if err := os.WriteFile(inputFile, []byte(testCSV), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+	headersFile := filepath.Join(tempDir, "expected.csv")
+	if err := os.WriteFile(headersFile, []byte("latitude,longitude,name,h3_index,outside_bbox\n"), 0644); err != nil {
+		t.Fatalf("Failed to create headers file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(*config.Config)
+		wantErr bool
+	}{
+		{"matching column count", func(cfg *config.Config) { cfg.ExpectOutputColumns = 4 }, false},
+		{"wrong column count", func(cfg *config.Config) { cfg.ExpectOutputColumns = 5 }, true},
+		{"column count without headers", func(cfg *config.Config) {
+			cfg.HasHeaders = false
+			cfg.LatColumn, cfg.LngColumn = "0", "1"
+			cfg.ExpectOutputColumns = 4
+		}, false},
+		{"matching headers", func(cfg *config.Config) {
+			cfg.ExpectBBox = "-75,40,-73,41"
+			cfg.ExpectHeaders = headersFile
+		}, false},
+		{"mismatched headers", func(cfg *config.Config) { cfg.ExpectHeaders = headersFile }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.InputFile = inputFile
+			cfg.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+			tt.setup(cfg)
+
+			_, err := NewOrchestrator(cfg).ProcessFile()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error: %v, got: %v", tt.wantErr, err)
+			}
+			if _, statErr := os.Stat(cfg.OutputFile); tt.wantErr && statErr == nil {
+				t.Error("Expected no output file to be written on schema mismatch")
+			}
+		})
+	}
+}
+
+// TestOrchestrator_CoordPairs tests indexing origin and destination coordinates in one pass
+func TestOrchestrator_CoordPairs(t *testing.T) {
+	testCSV := `trip,origin_lat,origin_lng,dest_lat,dest_lng
+1,40.7128,-74.0060,51.5074,-0.1278
+2,40.7128,-74.0060,invalid,-0.1278
+3,999,-74.0060,51.5074,-0.1278
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.CoordPairs = "origin_lat:origin_lng:origin_h3,dest_lat:dest_lng:dest_h3"
+	})
+
+	expectedHeader := "trip,origin_lat,origin_lng,dest_lat,dest_lng,origin_h3,dest_h3"
+	if got := strings.Join(rows[0], ","); got != expectedHeader {
+		t.Errorf("Expected header %s, got %s", expectedHeader, got)
+	}
+	if result.ValidRecords != 2 || result.InvalidRecords != 1 {
+		t.Errorf("Expected 2 valid and 1 invalid primary pairs, got %d and %d", result.ValidRecords, result.InvalidRecords)
+	}
+	if result.InvalidPairRecords != 1 {
+		t.Errorf("Expected 1 record with an invalid secondary pair, got %d", result.InvalidPairRecords)
+	}
+
+	const newYork, london = "882a107289fffff", "88195da49bfffff"
+	expected := [][2]string{{newYork, london}, {newYork, ""}, {"", london}}
+	for i, want := range expected {
+		row := rows[i+1]
+		if row[5] != want[0] || row[6] != want[1] {
+			t.Errorf("Row %d: expected origin/dest %v, got %v", i+1, want, row[5:])
+		}
+	}
+}
+
+// TestOrchestrator_AddEdge tests directed edges between origin and destination H3 columns
+func TestOrchestrator_AddEdge(t *testing.T) {
+	testCSV := `origin_lat,origin_lng,dest_lat,dest_lng
+40.7128,-74.0060,40.7128,-73.9910
+40.7128,-74.0060,51.5074,-0.1278
+40.7128,-74.0060,invalid,-0.1278
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.Resolution = 7
+		cfg.CoordPairs = "origin_lat:origin_lng:origin_h3,dest_lat:dest_lng:dest_h3"
+		cfg.AddEdge = "origin_h3:dest_h3:od_edge"
+	})
+
+	expectedHeader := "origin_lat,origin_lng,dest_lat,dest_lng,origin_h3,dest_h3,od_edge"
+	if got := strings.Join(rows[0], ","); got != expectedHeader {
+		t.Fatalf("Expected header %s, got %s", expectedHeader, got)
+	}
+	if result.NonNeighborEdges != 1 {
+		t.Errorf("Expected 1 edge between non-neighbors, got %d", result.NonNeighborEdges)
+	}
+
+	if edge := rows[1][6]; !strings.HasPrefix(edge, "11") || len(edge) != 16 {
+		t.Errorf("Row 1: expected a directed edge index, got %q", edge)
+	}
+	if edge := rows[2][6]; edge != "not_neighbors" {
+		t.Errorf("Row 2: expected not_neighbors, got %q", edge)
+	}
+	if edge := rows[3][6]; edge != "" {
+		t.Errorf("Row 3: expected empty edge for missing destination, got %q", edge)
+	}
+}
+
+// TestOrchestrator_AddEdgeUnknownColumn tests that edges must refer to H3 output columns
+func TestOrchestrator_AddEdgeUnknownColumn(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "input.csv")
+	if err := os.WriteFile(inputFile, []byte("latitude,longitude\n40.7128,-74.0060\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+	cfg.AddEdge = "h3_index:dest_h3:edge"
+
+	if _, err := NewOrchestrator(cfg).ProcessFile(); err == nil {
+		t.Error("Expected error for edge referring to an unknown H3 column")
+	}
+}
+
+// TestOrchestrator_DistanceBearing tests distance and bearing columns between origin and destination pairs
+func TestOrchestrator_DistanceBearing(t *testing.T) {
+	testCSV := `origin_lat,origin_lng,dest_lat,dest_lng
+0,0,0,1
+40.7128,-74.0060,invalid,-0.1278
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.CoordPairs = "origin_lat:origin_lng:origin_h3,dest_lat:dest_lng:dest_h3"
+		cfg.AddDistanceKm = true
+		cfg.AddBearing = true
+	})
+
+	expectedHeader := "origin_lat,origin_lng,dest_lat,dest_lng,origin_h3,dest_h3,distance_km,bearing_deg"
+	if got := strings.Join(rows[0], ","); got != expectedHeader {
+		t.Fatalf("Expected header %s, got %s", expectedHeader, got)
+	}
+	if got := rows[1][6:]; got[0] != "111.195" || got[1] != "90.0" {
+		t.Errorf("Row 1: expected distance 111.195 and bearing 90.0, got %v", got)
+	}
+	if got := rows[2][6:]; got[0] != "" || got[1] != "" {
+		t.Errorf("Row 2: expected empty distance and bearing for invalid destination, got %v", got)
+	}
+}
+
+// sliceSource is a row source over fixed rows, standing in for a database query
+type sliceSource struct{ rows [][]string }
+
+func (s *sliceSource) Read() ([]string, error) {
+	if len(s.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+func (s *sliceSource) Close() error { return nil }
+
+func TestOrchestrator_QuerySpool(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.PGQuery = "SELECT id, lat, lng FROM points"
+	cfg.LatColumn = "lat"
+	cfg.LngColumn = "lng"
+	cfg.TempDir = t.TempDir()
+	orchestrator := NewOrchestrator(cfg)
+
+	source := &sliceSource{rows: [][]string{
+		{"id", "lat", "lng"},
+		{"a1", "40.7128", "-74.0060"},
+		{"a,\"2\"\n", "51.5074", "-0.1278"},
+	}}
+	path, err := spoolRows(source, cfg.TempDir)
+	if err != nil {
+		t.Fatalf("spoolRows failed: %v", err)
+	}
+	orchestrator.querySpool = path
+
+	// Every pass over the input reads the same rows back from the spool
+	for pass := 1; pass <= 2; pass++ {
+		reader, err := orchestrator.openInput()
+		if err != nil {
+			t.Fatalf("Pass %d: openInput failed: %v", pass, err)
+		}
+		var ids []string
+		for {
+			record, err := reader.ReadRecord()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Pass %d: ReadRecord failed: %v", pass, err)
+			}
+			ids = append(ids, record.OriginalData[0])
+		}
+		reader.Close()
+		if len(ids) != 2 || ids[0] != "a1" || ids[1] != "a,\"2\"\n" {
+			t.Errorf("Pass %d: expected the spooled ids, got %q", pass, ids)
+		}
+	}
+
+	orchestrator.removeQuerySpool()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the spool to be removed, got %v", err)
+	}
+}
+
+// startRedisServer starts a minimal Redis server on loopback recording the arguments of
+// the commands it receives, which are closed once the client disconnects
+func startRedisServer(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sets := make(chan []string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			header, err := reader.ReadString('\n')
+			if err != nil {
+				close(sets)
+				return
+			}
+			var args []string
+			count := 0
+			fmt.Sscanf(header, "*%d", &count)
+			for i := 0; i < count; i++ {
+				reader.ReadString('\n')
+				arg, _ := reader.ReadString('\n')
+				args = append(args, strings.TrimRight(arg, "\r\n"))
+			}
+			sets <- args
+			conn.Write([]byte("+OK\r\n"))
+		}
+	}()
+	return listener.Addr().String(), sets
+}
+
+func TestOrchestrator_RedisSink(t *testing.T) {
+	addr, sets := startRedisServer(t)
+
+	testCSV := `id,latitude,longitude
+a1,40.7128,-74.0060
+a2,invalid,-0.1278
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.RedisSink = addr
+		cfg.KeyTemplate = "loc:{{.id}}"
+	})
+
+	var got [][]string
+	for args := range sets {
+		got = append(got, args)
+	}
+	if len(got) != 1 || got[0][1] != "loc:a1" || got[0][2] != rows[1][3] {
+		t.Errorf("Expected one SET loc:a1 %s, got %v", rows[1][3], got)
+	}
+}
+
+func TestOrchestrator_RedisSinkSortedEncrypted(t *testing.T) {
+	t.Setenv("CSVH3_TEST_KEY", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	addr, sets := startRedisServer(t)
+
+	testCSV := `id,latitude,longitude
+a1,40.7128,-74.0060
+a2,invalid,-0.1278
+a3,51.5074,-0.1278
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.RedisSink = addr
+		cfg.KeyTemplate = "loc:{{.id}}"
+		cfg.EncryptColumns = "id"
+		cfg.KeyEnv = "CSVH3_TEST_KEY"
+		cfg.SortByH3 = true
+		cfg.SortChunkSize = 1
+	})
+
+	// Keys are rendered from the plaintext id, although the sorted output holds ciphertext
+	got := make(map[string]string)
+	for args := range sets {
+		got[args[1]] = args[2]
+	}
+	expected := make(map[string]string)
+	for _, row := range rows[1:] {
+		if len(row) != 4 || !strings.HasPrefix(row[0], "aesgcm:") {
+			t.Errorf("Expected an encrypted id and no extra fields, got %v", row)
+		}
+		switch row[1] {
+		case "40.7128":
+			expected["loc:a1"] = row[3]
+		case "51.5074":
+			expected["loc:a3"] = row[3]
+		}
+	}
+	if len(rows) != 4 || len(got) != 2 || got["loc:a1"] != expected["loc:a1"] || got["loc:a3"] != expected["loc:a3"] {
+		t.Errorf("Expected SETs %v, got %v", expected, got)
+	}
+}
+
+func TestOrchestrator_GeocodeMissing(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("q") {
+		case "350 5th Ave, New York":
+			fmt.Fprint(w, `[{"lat":"40.7484","lon":"-73.9857"}]`)
+		case "Unreachable":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	testCSV := `id,latitude,longitude,address
+1,51.5074,-0.1278,London
+2,,,"350 5th Ave, New York"
+3,,,"350 5th Ave, New York"
+4,,,Atlantis
+5,,,Unreachable
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.GeocodeMissing = true
+		cfg.GeocoderURL = server.URL
+		cfg.GeocodeRate = 1000
+	})
+
+	if result.GeocodedRecords != 2 || result.ValidRecords != 3 {
+		t.Errorf("Expected 2 geocoded and 3 valid records, got %d and %d", result.GeocodedRecords, result.ValidRecords)
+	}
+	if requests != 3 {
+		t.Errorf("Expected repeated addresses to be cached (3 requests), got %d", requests)
+	}
+	if result.EmptyCoordinateRows != 1 || result.LookupFailureRows != 1 {
+		t.Errorf("Expected 1 empty and 1 lookup failure row, got %d and %d", result.EmptyCoordinateRows, result.LookupFailureRows)
+	}
+	if got := rows[2][1:3]; got[0] != "40.7484" || got[1] != "-73.9857" || rows[2][4] == "" {
+		t.Errorf("Row 2: expected geocoded coordinates and an H3 index, got %v", rows[2])
+	}
+	if rows[4][4] != "" {
+		t.Errorf("Row 4: expected empty H3 index for an unknown address, got %v", rows[4])
+	}
+}
+
+func TestOrchestrator_AdminLookup(t *testing.T) {
+	cell, err := h3.NewH3Generator().Generate(40.7128, -74.0060, 5)
+	if err != nil {
+		t.Fatalf("Failed to generate cell: %v", err)
+	}
+	lookupFile := filepath.Join(t.TempDir(), "admin_cells.csv")
+	if err := os.WriteFile(lookupFile, []byte("h3,country,region\n"+cell+",US,New York\n"), 0644); err != nil {
+		t.Fatalf("Failed to write lookup table: %v", err)
+	}
+
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,51.5074,-0.1278
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AdminLookup = lookupFile
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,country,region" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	if got := rows[1][4:]; got[0] != "US" || got[1] != "New York" {
+		t.Errorf("Row 1: expected US, New York, got %v", got)
+	}
+	if got := rows[2][4:]; got[0] != "" || got[1] != "" {
+		t.Errorf("Row 2: expected empty admin names outside the table, got %v", got)
+	}
+	if result.AdminMatchedRecords != 1 {
+		t.Errorf("Expected 1 matched record, got %d", result.AdminMatchedRecords)
+	}
+}
+
+func TestOrchestrator_AddTimezone(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,35.6762,139.6503
+3,30.0,-40.0
+4,invalid,-74.0060
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AddTimezone = true
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,timezone" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	want := []string{"America/New_York", "Asia/Tokyo", "Etc/GMT+3", ""}
+	for i, zone := range want {
+		if got := rows[i+1][4]; got != zone {
+			t.Errorf("Row %d: expected time zone %q, got %q", i+1, zone, got)
+		}
+	}
+}
+
+func TestOrchestrator_AddCountry(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,48.58,7.75
+3,30.0,-40.0
+`
+	for _, tc := range []struct {
+		policy string
+		want   []string
+	}{
+		{"nearest", []string{"US", "FR", ""}},
+		{"empty", []string{"US", "", ""}},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+				cfg.AddCountry = true
+				cfg.OnAmbiguous = tc.policy
+			})
+
+			if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,country" {
+				t.Fatalf("Unexpected header: %s", got)
+			}
+			for i, country := range tc.want {
+				if got := rows[i+1][4]; got != country {
+					t.Errorf("Row %d: expected country %q, got %q", i+1, country, got)
+				}
+			}
+			if result.AmbiguousCountryRecords != 1 {
+				t.Errorf("Expected 1 ambiguous record, got %d", result.AmbiguousCountryRecords)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_AddUTMAndMGRS(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,89.0,0.0
+3,invalid,-74.0060
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AddUTM = true
+		cfg.AddMGRS = true
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,utm_zone,utm_easting,utm_northing,mgrs" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	if got := strings.Join(rows[1][4:], ","); got != "18T,583959.37,4507351.00,18TWL8395907350" {
+		t.Errorf("Row 1: unexpected projections %s", got)
+	}
+	for _, i := range []int{2, 3} {
+		if got := strings.Join(rows[i][4:], ","); got != ",,," {
+			t.Errorf("Row %d: expected empty projections, got %s", i, got)
+		}
+	}
+}
+
+func TestOrchestrator_AddCentroid(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,invalid,-74.0060
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AddCentroid = true
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,h3_lat,h3_lng" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	lat, latErr := strconv.ParseFloat(rows[1][4], 64)
+	lng, lngErr := strconv.ParseFloat(rows[1][5], 64)
+	if latErr != nil || lngErr != nil || lat < 40.70 || lat > 40.72 || lng < -74.02 || lng > -73.99 || lat == 40.7128 {
+		t.Errorf("Row 1: expected the cell center near the point, got %s,%s", rows[1][4], rows[1][5])
+	}
+	if got := strings.Join(rows[2][4:], ","); got != "," {
+		t.Errorf("Row 2: expected empty centroid, got %s", got)
+	}
+}
+
+func TestOrchestrator_AddCellMeta(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,invalid,-74.0060
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AddCellMeta = true
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,h3_area_km2,h3_edge_length_km" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	area, areaErr := strconv.ParseFloat(rows[1][4], 64)
+	edge, edgeErr := strconv.ParseFloat(rows[1][5], 64)
+	if areaErr != nil || edgeErr != nil || area < 0.5 || area > 1 || edge < 0.4 || edge > 0.6 {
+		t.Errorf("Row 1: expected resolution 8 area and edge length, got %s,%s", rows[1][4], rows[1][5])
+	}
+	if got := strings.Join(rows[2][4:], ","); got != "," {
+		t.Errorf("Row 2: expected empty cell metadata, got %s", got)
+	}
+}
+
+func TestOrchestrator_FlagPentagons(t *testing.T) {
+	// The first point lies in the resolution 8 pentagon 8808000001fffff, off Norway
+	testCSV := `id,latitude,longitude
+1,64.7000001,10.5361991
+2,40.7128,-74.0060
+3,invalid,-74.0060
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.FlagPentagons = true
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,is_pentagon" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	for i, expected := range []string{"8808000001fffff,true", "882a107289fffff,false", ","} {
+		if got := strings.Join(rows[i+1][3:], ","); got != expected {
+			t.Errorf("Row %d: expected %s, got %s", i+1, expected, got)
+		}
+	}
+}
+
+func TestOrchestrator_AddBaseCell(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,64.7000001,10.5361991
+3,invalid,-74.0060
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AddBaseCell = true
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,h3_base_cell,h3_face" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	for i, expected := range []string{"21,2", "4,0;1;2;3;4", ","} {
+		if got := strings.Join(rows[i+1][4:], ","); got != expected {
+			t.Errorf("Row %d: expected %s, got %s", i+1, expected, got)
+		}
+	}
+}
+
+func TestOrchestrator_AddLocalIJ(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,51.5074,-0.1278
+3,invalid,-74.0060
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AddLocalIJ = true
+		cfg.Anchor = "882a107289fffff"
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,local_i,local_j" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	// The first point lies in the anchor cell; London is too far away
+	for i, expected := range []string{"-27,343", ",", ","} {
+		if got := strings.Join(rows[i+1][4:], ","); got != expected {
+			t.Errorf("Row %d: expected %s, got %s", i+1, expected, got)
+		}
+	}
+	if result.NoLocalIJRecords != 1 {
+		t.Errorf("Expected 1 record without local IJ coordinates, got %d", result.NoLocalIJRecords)
+	}
+}
+
+func TestOrchestrator_AddVertexes(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,64.7000001,10.5361991
+3,invalid,-74.0060
+`
+	_, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.AddVertexes = true
+	})
+
+	if got := strings.Join(rows[0], ","); got != "id,latitude,longitude,h3_index,h3_vertexes" {
+		t.Fatalf("Unexpected header: %s", got)
+	}
+	// Six vertexes for a hexagon, five for the pentagon, none for the invalid row
+	for i, expected := range []int{6, 5, 0} {
+		got := 0
+		if rows[i+1][4] != "" {
+			got = len(strings.Split(rows[i+1][4], ";"))
+		}
+		if got != expected {
+			t.Errorf("Row %d: expected %d vertexes, got %q", i+1, expected, rows[i+1][4])
+		}
+	}
+}
+
+func TestOrchestrator_FloatFormat(t *testing.T) {
+	testCSV := `id,latitude,longitude,speed,note,alt_lat,alt_lng
+1,40.7128,-74.0060,1.50,2.5,,
+2,,,12,b,51.5074,-0.1278
+`
+	result, rows := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.EmitSchema = filepath.Join(t.TempDir(), "schema.json")
+		cfg.FloatFormat = "%.5f"
+		cfg.LatFallback, cfg.LngFallback = "alt_lat", "alt_lng"
+	})
+
+	// Decimal numbers of number columns are re-serialized; integers, columns of other
+	// types and the coordinate columns are unchanged
+	if result.ValidRecords != 2 {
+		t.Fatalf("Expected 2 valid records, got %d", result.ValidRecords)
+	}
+	expected := []string{"1,40.7128,-74.0060,1.50000,2.5,,", "2,51.5074,-0.1278,12,b,51.5074,-0.1278"}
+	for i, row := range expected {
+		if got := strings.Join(rows[i+1][:7], ","); got != row {
+			t.Errorf("Row %d: expected %s, got %s", i+1, row, got)
+		}
+	}
+}
+
+func TestOrchestrator_CoverageCheck(t *testing.T) {
+	tempDir := t.TempDir()
+	region := filepath.Join(tempDir, "region.geojson")
+	geojson := `{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[
+		[[-74.03,40.69],[-73.98,40.69],[-73.98,40.73],[-74.03,40.73],[-74.03,40.69]]]}}`
+	if err := os.WriteFile(region, []byte(geojson), 0644); err != nil {
+		t.Fatalf("Failed to write region: %v", err)
+	}
+	report := filepath.Join(tempDir, "empty_cells.csv")
+
+	testCSV := `latitude,longitude
+40.7128,-74.0060
+40.7128,-74.0060
+51.5074,-0.1278
+`
+	result, _ := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.Resolution = 7
+		cfg.CoverageCheck = region
+		cfg.CoverageReport = report
+	})
+
+	if result.Coverage == nil {
+		t.Fatal("Expected a coverage result")
+	}
+	if result.Coverage.Covered() != 1 || result.Coverage.Cells() < 2 {
+		t.Errorf("Expected 1 covered cell of several, got %d of %d", result.Coverage.Covered(), result.Coverage.Cells())
+	}
+	content, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("Failed to read coverage report: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if lines[0] != "h3_index" || len(lines)-1 != result.Coverage.Cells()-1 {
+		t.Errorf("Expected a header and %d empty cells, got %d lines", result.Coverage.Cells()-1, len(lines))
+	}
+
+	// An existing report is only replaced with --overwrite
+	if err := writeCoverageReport(report, result.Coverage, false); err == nil {
+		t.Error("Expected an existing coverage report to be kept without overwrite")
+	}
+	if err := writeCoverageReport(report, result.Coverage, true); err != nil {
+		t.Errorf("Expected an existing coverage report to be replaced with overwrite, got %v", err)
+	}
+}
+
+func TestOrchestrator_Stats(t *testing.T) {
+	testCSV := `id,latitude,longitude,city
+1,40.7128,-74.0060,New York
+2,51.5074,-0.1278,London
+3,invalid,-0.1278,
+`
+	result, _ := processCSV(t, testCSV, func(cfg *config.Config) {
+		cfg.Stats = true
+	})
+
+	if result.ColumnStats == nil {
+		t.Fatal("Expected column statistics")
+	}
+	columns := result.ColumnStats.Columns()
+	if len(columns) != 4 {
+		t.Fatalf("Expected 4 columns, got %d", len(columns))
+	}
+	if id := columns[0]; !id.IsNumeric() || id.Min != 1 || id.Max != 3 || id.Mean() != 2 {
+		t.Errorf("Unexpected id statistics: %+v", id)
+	}
+	if columns[1].IsNumeric() {
+		t.Error("Expected latitude with an invalid value to be non-numeric")
+	}
+	city := columns[3]
+	if distinct, exact := city.Distinct(); distinct != 2 || !exact || city.Empty != 1 {
+		t.Errorf("Expected 2 exact distinct cities and 1 empty, got %d (exact %v), %d empty", distinct, exact, city.Empty)
+	}
+}
+
+func TestOrchestrator_RecordCategories(t *testing.T) {
+	testCSV := `id,latitude,longitude
+1,40.7128,-74.0060
+2,,-74.0060
+3,north,-74.0060
+4,95.0,-74.0060
+5
+6,"40.7,-74.0
+`
+	result, _ := processCSV(t, testCSV, nil)
+
+	if result.ValidRecords != 1 || result.InvalidRecords != 3 {
+		t.Errorf("Expected 1 valid and 3 invalid records, got %d and %d", result.ValidRecords, result.InvalidRecords)
+	}
+	if result.EmptyCoordinateRows != 1 || result.UnparseableRows != 1 || result.OutOfRangeRows != 1 || result.H3FailureRows != 0 {
+		t.Errorf("Unexpected invalid record categories: %+v", result.RecordCategories)
+	}
+	if result.MalformedRows != 2 {
+		t.Errorf("Expected 2 malformed rows, got %d", result.MalformedRows)
+	}
+	if result.BytesRead != int64(len(testCSV)) {
+		t.Errorf("Expected %d bytes read, got %d", len(testCSV), result.BytesRead)
+	}
+	if result.BytesWritten == 0 {
+		t.Error("Expected bytes written to be counted")
+	}
+}
+
+// recordingLogger is a custom logging.Logger keeping every message
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) log(level, message string, args ...interface{}) {
+	l.messages = append(l.messages, level+": "+fmt.Sprintf(message, args...))
+}
+func (l *recordingLogger) Debug(message string, args ...interface{}) { l.log("DEBUG", message, args...) }
+func (l *recordingLogger) Info(message string, args ...interface{})  { l.log("INFO", message, args...) }
+func (l *recordingLogger) Warn(message string, args ...interface{})  { l.log("WARN", message, args...) }
+func (l *recordingLogger) Error(message string, args ...interface{}) { l.log("ERROR", message, args...) }
+func (l *recordingLogger) LogError(err error)                        { l.log("ERROR", "%v", err) }
+func (l *recordingLogger) Enabled(level logging.LogLevel) bool       { return true }
+
+func TestOrchestrator_SetLogger(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	if err := os.WriteFile(inputFile, []byte("latitude,longitude\n40.7128,-74.0060\n,\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+
+	logger := &recordingLogger{}
+	orchestrator := NewOrchestrator(cfg)
+	orchestrator.SetLogger(logger)
+	if _, err := orchestrator.ProcessFile(); err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+
+	log := strings.Join(logger.messages, "\n")
+	for _, expected := range []string{"INFO: Starting CSV processing", "DEBUG: Line", "WARN: Invalid records: 1"} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("Expected %q in the injected logger's messages:\n%s", expected, log)
+		}
+	}
+}
+
+func TestOrchestrator_ErrorFile(t *testing.T) {
+	errorFile := filepath.Join(t.TempDir(), "rejects.csv")
+	result, rows := processCSV(t, "id,latitude,longitude\n1,40.7128,-74.0060\n2,abc,1\n3,95,10\n", func(cfg *config.Config) {
+		cfg.ErrorFile = errorFile
+	})
+
+	if result.RejectedRows != 2 || result.InvalidRecords != 2 {
+		t.Errorf("Expected 2 rejected invalid records, got %d rejected, %d invalid", result.RejectedRows, result.InvalidRecords)
+	}
+	if len(rows) != 2 || rows[1][0] != "1" {
+		t.Errorf("Expected only the valid record in the output, got %v", rows)
+	}
+
+	data, err := os.ReadFile(errorFile)
+	if err != nil {
+		t.Fatalf("Failed to read error file: %v", err)
+	}
+	expected := "id,latitude,longitude,error_reason\n2,abc,1,unparseable latitude\n3,95,10,"
+	if !strings.HasPrefix(string(data), expected) {
+		t.Errorf("Unexpected error file contents:\n%s", data)
+	}
+}
+
+func TestOrchestrator_EmitSchema(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	processCSV(t, "id,latitude,longitude,note\n1,40.7128,-74.0060,a\n2,51.5074,-0.1278,\n3,abc,1,c\n", func(cfg *config.Config) {
+		cfg.EmitSchema = schemaFile
+		cfg.Resolution = 7
+		cfg.ExpectBBox = "-10,30,10,60"
+	})
+
+	data, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("Failed to read schema file: %v", err)
+	}
+	var document struct {
+		Properties map[string]struct {
+			Type any `json:"type"`
+			H3   *struct {
+				Resolution int    `json:"resolution"`
+				Kind       string `json:"kind"`
+			} `json:"x-h3"`
+		} `json:"properties"`
+		Order []string `json:"x-column-order"`
+		Rows  int      `json:"x-rows"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Invalid schema: %v", err)
+	}
+	if strings.Join(document.Order, ",") != "id,latitude,longitude,note,h3_index,outside_bbox" || document.Rows != 3 {
+		t.Errorf("Unexpected columns %v for %d rows", document.Order, document.Rows)
+	}
+	// The unparseable latitude makes the column a string; the invalid record has no H3 index
+	types := map[string]string{"id": "integer", "latitude": "string", "longitude": "number",
+		"note": "[string null]", "h3_index": "[string null]", "outside_bbox": "[boolean null]"}
+	for column, expected := range types {
+		if got := fmt.Sprint(document.Properties[column].Type); got != expected {
+			t.Errorf("Column %s: expected type %s, got %s", column, expected, got)
+		}
+	}
+	if h3 := document.Properties["h3_index"].H3; h3 == nil || h3.Resolution != 7 || h3.Kind != "cell" {
+		t.Errorf("Expected H3 metadata for resolution 7, got %+v", h3)
+	}
+}
+
+func TestOrchestrator_AvroOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := "id,latitude,longitude,note\n1,40.7128,-74.0060,a\n2,51.5074,-0.1278,\n"
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.avro")
+	cfg.OutputFormat = config.OutputFormatAvro
+	cfg.H3Format = config.H3FormatLong
+	cfg.TempDir = tempDir
+	cfg.Overwrite = true
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	data, err := os.ReadFile(result.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "Obj\x01") {
+		t.Fatalf("Expected an Avro container file")
+	}
+	for _, field := range []string{`"name":"id","type":"long"`, `"name":"note","type":["null","string"]`, `"name":"h3_index","type":"long"`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("Expected field %s in the schema", field)
+		}
+	}
+	// The spool holding rows until the schema is known is removed
+	if spools, _ := filepath.Glob(filepath.Join(tempDir, "csvh3-avro-*")); len(spools) != 0 {
+		t.Errorf("Expected the spool to be removed, found %v", spools)
+	}
+
+	// An existing output is only replaced with --overwrite
+	cfg.Overwrite = false
+	if _, err := NewOrchestrator(cfg).newTypedWriter([]string{"id"}, nil); err == nil {
+		t.Error("Expected an existing typed output to be kept without overwrite")
+	}
+}
+
+func TestOrchestrator_ORCOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := "id,latitude,longitude,note\n1,40.7128,-74.0060,a\n2,51.5074,-0.1278,\n3,abc,1,c\n"
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.orc")
+	cfg.OutputFormat = config.OutputFormatORC
+	cfg.H3Format = config.H3FormatLong
+	cfg.TempDir = tempDir
+	cfg.Overwrite = true
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	reader, err := orc.Open(result.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to open ORC output: %v", err)
+	}
+	defer reader.Close()
+	if schema := reader.Schema().String(); schema != "struct<id:bigint,latitude:string,longitude:double,note:string,h3_index:bigint>" {
+		t.Errorf("Unexpected ORC schema %s", schema)
+	}
+
+	cursor := reader.Select(reader.Schema().Columns()...)
+	var rows [][]any
+	for cursor.Stripes() {
+		for cursor.Next() {
+			rows = append(rows, cursor.Row())
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		t.Fatalf("Failed to read ORC rows: %v", err)
+	}
+	expected := [][]any{
+		{int64(1), "40.7128", orc.Double(-74.006), "a", int64(0x882a107289fffff)},
+		{int64(2), "51.5074", orc.Double(-0.1278), nil, int64(0x88195da49bfffff)},
+		{int64(3), "abc", orc.Double(1), "c", nil},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows\n%v\ngot\n%v", expected, rows)
+	}
+	if spools, _ := filepath.Glob(filepath.Join(tempDir, "csvh3-orc-*")); len(spools) != 0 {
+		t.Errorf("Expected the spool to be removed, found %v", spools)
+	}
+}
+
+func TestOrchestrator_EncryptColumns(t *testing.T) {
+	t.Setenv("CSVH3_TEST_KEY", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	errorFile := filepath.Join(t.TempDir(), "rejects.csv")
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	_, rows := processCSV(t, "id,latitude,longitude,ssn,email\n1,40.7128,-74.0060,123456789,a@example.com\n2,51.5074,-0.1278,,b@example.com\n3,abc,1,987654321,c@example.com\n", func(cfg *config.Config) {
+		cfg.EncryptColumns = "ssn,4"
+		cfg.KeyEnv = "CSVH3_TEST_KEY"
+		cfg.ErrorFile = errorFile
+		cfg.EmitSchema = schemaFile
+	})
+
+	key, _ := encrypt.ParseKey(os.Getenv("CSVH3_TEST_KEY"))
+	cipher, _ := encrypt.NewCipher(key)
+	decrypt := func(value string) string {
+		plaintext, err := cipher.Decrypt(value)
+		if err != nil {
+			t.Errorf("Failed to decrypt %q: %v", value, err)
+		}
+		return plaintext
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 valid rows, got %v", rows)
+	}
+	if strings.Join(rows[0], ",") != "id,latitude,longitude,ssn,email,h3_index" {
+		t.Errorf("Unexpected header %v", rows[0])
+	}
+	// Coordinates and the H3 index are untouched; empty values stay empty
+	if rows[1][1] != "40.7128" || rows[1][5] == "" || rows[2][3] != "" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+	if decrypt(rows[1][3]) != "123456789" || decrypt(rows[1][4]) != "a@example.com" || decrypt(rows[2][4]) != "b@example.com" {
+		t.Errorf("Encrypted columns do not decrypt to the input values: %v", rows)
+	}
+
+	// Rejected records are protected too
+	rejects, err := os.ReadFile(errorFile)
+	if err != nil {
+		t.Fatalf("Failed to read error file: %v", err)
+	}
+	if strings.Contains(string(rejects), "987654321") || strings.Contains(string(rejects), "c@example.com") {
+		t.Errorf("Expected encrypted columns in the error file:\n%s", rejects)
+	}
+
+	document, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("Failed to read schema file: %v", err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type      any  `json:"type"`
+			Encrypted bool `json:"x-encrypted"`
+		} `json:"properties"`
+	}
+	json.Unmarshal(document, &schema)
+	if ssn := schema.Properties["ssn"]; !ssn.Encrypted || fmt.Sprint(ssn.Type) != "[string null]" {
+		t.Errorf("Expected ssn to be a nullable encrypted string, got %+v", ssn)
+	}
+	if schema.Properties["id"].Encrypted {
+		t.Errorf("Expected only the encrypted columns to be marked")
+	}
+}
+
+func TestOrchestrator_Sign(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	content := "id,latitude,longitude\n1,40.7128,-74.0060\n2,51.5074,-0.1278\n"
+
+	t.Run("output file", func(t *testing.T) {
+		result, _ := processCSV(t, content, func(cfg *config.Config) {
+			cfg.Sign = true
+			cfg.SignKey = keyFile
+		})
+		if result.SignatureFile != result.OutputFile+sign.Extension {
+			t.Fatalf("Unexpected signature file %q", result.SignatureFile)
+		}
+		signature, _ := os.ReadFile(result.SignatureFile)
+		if err := sign.VerifyFile(key.Public(), result.OutputFile, signature); err != nil {
+			t.Errorf("Signature does not verify: %v", err)
+		}
+	})
+
+	t.Run("partition manifest", func(t *testing.T) {
+		tempDir := t.TempDir()
+		inputFile := filepath.Join(tempDir, "input.csv")
+		os.WriteFile(inputFile, []byte(content), 0644)
+		cfg := config.NewConfig()
+		cfg.InputFile = inputFile
+		cfg.OutputDir = filepath.Join(tempDir, "out")
+		cfg.PartitionByH3Res = 3
+		cfg.SparkCompat = true
+		cfg.Sign = true
+		cfg.SignKey = keyFile
+
+		result, err := NewOrchestrator(cfg).ProcessFile()
+		if err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+		manifest := filepath.Join(cfg.OutputDir, csv.ManifestFile)
+		if result.SignatureFile != manifest+sign.Extension {
+			t.Fatalf("Unexpected signature file %q", result.SignatureFile)
+		}
+		signature, _ := os.ReadFile(result.SignatureFile)
+		if err := sign.VerifyFile(key.Public(), manifest, signature); err != nil {
+			t.Errorf("Manifest signature does not verify: %v", err)
+		}
+	})
+}
+
+func TestOrchestrator_MaxInvalidPct(t *testing.T) {
+	run := func(content string, maxInvalidPct float64) error {
+		tempDir := t.TempDir()
+		inputFile := filepath.Join(tempDir, "input.csv")
+		os.WriteFile(inputFile, []byte(content), 0644)
+		cfg := config.NewConfig()
+		cfg.InputFile = inputFile
+		cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+		cfg.MaxInvalidPct = maxInvalidPct
+		_, err := NewOrchestrator(cfg).ProcessFile()
+		return err
+	}
+
+	// 1 of 10 records invalid: checked once the file is done
+	small := "id,latitude,longitude\n"
+	for i := 0; i < 9; i++ {
+		small += fmt.Sprintf("%d,40.7128,-74.0060\n", i)
+	}
+	small += "9,abc,1\n"
+	if err := run(small, 20); err != nil {
+		t.Errorf("Expected 10%% invalid to pass a 20%% limit, got %v", err)
+	}
+	if err := run(small, 5); err == nil || !strings.Contains(err.Error(), "1 of 10 records (10.0%) are invalid") {
+		t.Errorf("Expected 10%% invalid to fail a 5%% limit, got %v", err)
+	}
+	if err := run(small, 0); err != nil {
+		t.Errorf("Expected no limit by default, got %v", err)
+	}
+
+	// A file of invalid records fails as soon as enough records were processed
+	var large strings.Builder
+	large.WriteString("id,latitude,longitude\n")
+	for i := 0; i < 5*invalidCheckMinRecords; i++ {
+		fmt.Fprintf(&large, "%d,abc,1\n", i)
+	}
+	err := run(large.String(), 5)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("%d of %d records", invalidCheckMinRecords, invalidCheckMinRecords)) {
+		t.Errorf("Expected to abort after %d records, got %v", invalidCheckMinRecords, err)
+	}
+}
+
+func TestOrchestrator_ConstantCoordinates(t *testing.T) {
+	run := func(content string, strict bool) (*ProcessResult, error) {
+		tempDir := t.TempDir()
+		inputFile := filepath.Join(tempDir, "input.csv")
+		os.WriteFile(inputFile, []byte(content), 0644)
+		cfg := config.NewConfig()
+		cfg.InputFile = inputFile
+		cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+		cfg.ConstantCheckRows = 5
+		cfg.Strict = strict
+		return NewOrchestrator(cfg).ProcessFile()
+	}
+
+	constant := "id,latitude,longitude\n"
+	for i := 0; i < 8; i++ {
+		constant += fmt.Sprintf("%d,40.7128,-74.0060\n", i)
+	}
+	result, err := run(constant, false)
+	if err != nil {
+		t.Fatalf("Expected a warning only, got %v", err)
+	}
+	if len(result.DataWarnings) != 1 || !strings.Contains(result.DataWarnings[0], "first 5 valid rows") {
+		t.Errorf("Expected a constant coordinates warning, got %v", result.DataWarnings)
+	}
+	if _, err := run(constant, true); err == nil || !strings.Contains(err.Error(), "constant_coordinates") {
+		t.Errorf("Expected --strict to abort, got %v", err)
+	}
+
+	varied := constant + "8,40.8,-74.1\n"
+	varied = strings.Replace(varied, "2,40.7128,-74.0060", "2,40.7,-74.0", 1)
+	result, err = run(varied, true)
+	if err != nil {
+		t.Fatalf("Expected varied coordinates to pass, got %v", err)
+	}
+	if len(result.DataWarnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.DataWarnings)
+	}
+}
+
+func TestOrchestrator_FallbackColumns(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := "id,latitude,longitude,gps_lat,gps_lng\n" +
+		"1,40.7128,-74.0060,,\n" +
+		"2,,,51.5074,-0.1278\n" +
+		"3,,,,\n"
+	os.WriteFile(inputFile, []byte(content), 0644)
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+	cfg.LatFallback = "gps_lat"
+	cfg.LngFallback = "gps_lng"
+	cfg.EmitCoordSource = true
+	cfg.VerifyOutput = true
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.ValidRecords != 2 || result.InvalidRecords != 1 || result.FallbackRecords != 1 {
+		t.Errorf("Expected 2 valid, 1 invalid, 1 fallback record, got %d, %d, %d",
+			result.ValidRecords, result.InvalidRecords, result.FallbackRecords)
+	}
+	expectedSources := []SourceCount{{"latitude:longitude", 1}, {"gps_lat:gps_lng", 1}}
+	if fmt.Sprint(result.CoordSources) != fmt.Sprint(expectedSources) {
+		t.Errorf("Expected coordinate sources %v, got %v", expectedSources, result.CoordSources)
+	}
+	output, _ := os.ReadFile(cfg.OutputFile)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 4 || !strings.HasSuffix(lines[0], ",h3_index,coord_source") ||
+		!strings.HasPrefix(lines[2], "2,51.5074,-0.1278,51.5074,-0.1278,8") || !strings.HasSuffix(lines[2], ",gps_lat:gps_lng") ||
+		!strings.HasSuffix(lines[1], ",latitude:longitude") || !strings.HasSuffix(lines[3], ",,") {
+		t.Errorf("Expected the fallback coordinates and their sources in the output, got:\n%s", output)
+	}
+}
+
+func TestOrchestrator_SamplePerCell(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	var content strings.Builder
+	content.WriteString("id,latitude,longitude\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&content, "%d,40.7128,-74.0060\n", i)
+	}
+	content.WriteString("10,51.5074,-0.1278\n11,invalid,invalid\n")
+	os.WriteFile(inputFile, []byte(content.String()), 0644)
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+	cfg.SamplePerCell = 3
+	cfg.VerifyOutput = true
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.SampledOutRecords != 7 || result.SampledCells != 2 {
+		t.Errorf("Expected 7 records dropped from 2 cells, got %d from %d", result.SampledOutRecords, result.SampledCells)
+	}
+
+	output, err := os.ReadFile(cfg.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")[1:]
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 output rows, got %d: %v", len(lines), lines)
+	}
+	// Kept rows stay in input order, with the other cell and the invalid row last
+	previous := -1
+	for _, line := range lines {
+		id, _ := strconv.Atoi(strings.Split(line, ",")[0])
+		if id <= previous {
+			t.Errorf("Expected rows in input order, got %v", lines)
+			break
+		}
+		previous = id
+	}
+	if !strings.HasPrefix(lines[3], "10,") || !strings.HasPrefix(lines[4], "11,") {
+		t.Errorf("Expected rows 10 and 11 to be kept, got %v", lines)
+	}
+}
+
+func TestOrchestrator_ZipInput(t *testing.T) {
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "data.zip")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	archive := zip.NewWriter(file)
+	for name, content := range map[string]string{
+		"points.csv": "id,latitude,longitude\n1,40.7128,-74.0060\n2,34.0522,-118.2437\n",
+		"other.csv":  "id,latitude,longitude\n",
+	} {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	archive.Close()
+	file.Close()
+
+	cfg := config.NewConfig()
+	cfg.InputFile = archivePath + "::points.csv"
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.ValidRecords != 2 {
+		t.Errorf("Expected 2 valid records, got %d", result.ValidRecords)
+	}
+	// The output is named after the member, next to the archive
+	if expected := filepath.Join(tempDir, "points_with_h3.csv"); result.OutputFile != expected {
+		t.Errorf("Expected output %s, got %s", expected, result.OutputFile)
+	}
+
+	cfg = config.NewConfig()
+	cfg.InputFile = archivePath
+	if _, err := NewOrchestrator(cfg).ProcessFile(); err == nil || !strings.Contains(err.Error(), "points.csv") {
+		t.Errorf("Expected an error listing the archive members, got %v", err)
+	}
+}
+
+func TestOrchestrator_FooterRow(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := "id,latitude,longitude\n1,40.7128,-74.0060\n2,34.0522,-118.2437\nTOTAL,,\n"
+	os.WriteFile(inputFile, []byte(content), 0644)
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if len(result.DataWarnings) != 1 || !strings.Contains(result.DataWarnings[0], "--skip-footer") {
+		t.Errorf("Expected a footer warning, got %v", result.DataWarnings)
+	}
+
+	cfg.SkipFooter = 1
+	cfg.Overwrite = true
+	result, err = NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.InvalidRecords != 0 || result.FooterRows != 1 || len(result.DataWarnings) != 0 {
+		t.Errorf("Expected the footer to be skipped, got %d invalid, %d footer rows, warnings %v",
+			result.InvalidRecords, result.FooterRows, result.DataWarnings)
+	}
+}
+
+func TestOrchestrator_NullValues(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := "id,latitude,longitude\n1,40.7128,-74.0060\n2,NA,NA\n3,-999,-999\n4,abc,1\n"
+	os.WriteFile(inputFile, []byte(content), 0644)
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+	cfg.NullValues = "NA,-999"
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.EmptyCoordinateRows != 2 || result.UnparseableRows != 1 {
+		t.Errorf("Expected 2 empty and 1 unparseable rows, got %d and %d", result.EmptyCoordinateRows, result.UnparseableRows)
+	}
+	if counts := fmt.Sprint(result.NullValues); counts != "[{NA 2} {-999 2}]" {
+		t.Errorf("Unexpected null value counts %s", counts)
+	}
+}
+
+func TestOrchestrator_PrecisionCheck(t *testing.T) {
+	run := func(mode string, resolution int) (*ProcessResult, error) {
+		tempDir := t.TempDir()
+		inputFile := filepath.Join(tempDir, "input.csv")
+		os.WriteFile(inputFile, []byte("id,latitude,longitude\n1,40.71,-74.01\n2,34.05,-118.24\n"), 0644)
+		cfg := config.NewConfig()
+		cfg.InputFile = inputFile
+		cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+		cfg.Resolution = resolution
+		cfg.PrecisionCheck = mode
+		return NewOrchestrator(cfg).ProcessFile()
+	}
+
+	result, err := run("warn", 12)
+	if err != nil {
+		t.Fatalf("Expected a warning only, got %v", err)
+	}
+	if len(result.DataWarnings) != 1 || !strings.Contains(result.DataWarnings[0], "coordinate_precision") {
+		t.Errorf("Expected a coordinate precision warning, got %v", result.DataWarnings)
+	}
+	if _, err := run("error", 12); err == nil || !strings.Contains(err.Error(), "coordinate_precision") {
+		t.Errorf("Expected --precision-check error to abort, got %v", err)
+	}
+	for _, tc := range []struct {
+		mode       string
+		resolution int
+	}{{"off", 12}, {"error", 6}} {
+		result, err := run(tc.mode, tc.resolution)
+		if err != nil {
+			t.Fatalf("%s at resolution %d: unexpected error %v", tc.mode, tc.resolution, err)
+		}
+		if len(result.DataWarnings) != 0 {
+			t.Errorf("%s at resolution %d: expected no warnings, got %v", tc.mode, tc.resolution, result.DataWarnings)
+		}
+	}
+}
+
+func TestOrchestrator_MultiLineFields(t *testing.T) {
+	content := "latitude,longitude,address\n" +
+		"40.7128,-74.0060,\"1 Main St\nApt 2\nNew York\"\n" +
+		"41.9,-87.6,bad \"quote\n" +
+		"34.0522,-118.2437,\"2 Elm St\nLos Angeles\"\n"
+	for _, mmap := range []bool{false, true} {
+		progress := &FileProgress{}
+		result, rows := processCSV(t, content, func(cfg *config.Config) {
+			cfg.Mmap = mmap
+		})
+		if result.TotalRecords != 2 || result.ValidRecords != 2 || result.MalformedRows != 1 {
+			t.Errorf("mmap=%v: expected 2 valid records and 1 malformed row, got %d total, %d valid, %d malformed",
+				mmap, result.TotalRecords, result.ValidRecords, result.MalformedRows)
+		}
+		if len(rows) != 3 || rows[1][2] != "1 Main St\nApt 2\nNew York" || rows[2][2] != "2 Elm St\nLos Angeles" {
+			t.Errorf("mmap=%v: multi-line fields not preserved: %q", mmap, rows)
+		}
+
+		// Progress follows the input offset, not the line numbers
+		inputFile := filepath.Join(t.TempDir(), "input.csv")
+		os.WriteFile(inputFile, []byte(content), 0644)
+		cfg := config.NewConfig()
+		cfg.InputFile = inputFile
+		cfg.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+		cfg.Mmap = mmap
+		orchestrator := NewOrchestrator(cfg)
+		orchestrator.SetFileProgress(progress)
+		if _, err := orchestrator.ProcessFile(); err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+		if offset := progress.offset.Load(); offset != int64(len(content)) {
+			t.Errorf("mmap=%v: expected progress at offset %d, got %d", mmap, len(content), offset)
+		}
+	}
+}
+
+func TestOrchestrator_VerifyOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := "id,latitude,longitude\n1,40.7128,-74.0060\n2,abc,1\n3,34.0522,-118.2437\n3,34.0522,-118.2437\n4,51.5074,-0.1278\n"
+	os.WriteFile(inputFile, []byte(content), 0644)
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+	cfg.DedupeExact = true
+	cfg.VerifyOutput = true
+
+	orchestrator := NewOrchestrator(cfg)
+	result, err := orchestrator.ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.VerifiedRows != 3 {
+		t.Errorf("Expected 3 recomputed H3 indexes, got %d", result.VerifiedRows)
+	}
+
+	verify := func() error {
+		reader, err := csv.NewReader(inputFile, orchestrator.readerConfig())
+		if err != nil {
+			t.Fatalf("Failed to open input: %v", err)
+		}
+		defer reader.Close()
+		_, err = orchestrator.verifyOutput(reader, 4)
+		return err
+	}
+	output, _ := os.ReadFile(cfg.OutputFile)
+
+	// A corrupted H3 index fails the recomputation
+	corrupted := strings.Replace(string(output), "882a1072", "882a1073", 1)
+	os.WriteFile(cfg.OutputFile, []byte(corrupted), 0644)
+	if err := verify(); err == nil || !strings.Contains(err.Error(), "recomputed") {
+		t.Errorf("Expected a recomputation mismatch, got %v", err)
+	}
+
+	// A truncated file fails the row count
+	lines := strings.SplitAfter(string(output), "\n")
+	os.WriteFile(cfg.OutputFile, []byte(strings.Join(lines[:3], "")), 0644)
+	if err := verify(); err == nil || !strings.Contains(err.Error(), "output has 2 rows, expected 4") {
+		t.Errorf("Expected a row count mismatch, got %v", err)
+	}
+
+	// A missing H3 column fails the header check
+	os.WriteFile(cfg.OutputFile, []byte(content), 0644)
+	if err := verify(); err == nil || !strings.Contains(err.Error(), "H3 column") {
+		t.Errorf("Expected a missing H3 column, got %v", err)
+	}
+}
+
+func TestOrchestrator_Where(t *testing.T) {
+	content := "id,latitude,longitude,speed,country\n" +
+		"1,40.7128,-74.0060,10,US\n" +
+		"2,51.5074,-0.1278,5,GB\n" +
+		"3,34.0522,-118.2437,0,US\n" +
+		"4,41.8781,-87.6298,,US\n" +
+		"5,47.6062,-122.3321,7.5,US\n"
+	result, rows := processCSV(t, content, func(cfg *config.Config) {
+		cfg.Where = `speed > 0 && country == "US"`
+		cfg.VerifyOutput = true
+	})
+	if result.TotalRecords != 5 || result.FilteredRecords != 3 || result.ValidRecords != 2 {
+		t.Errorf("Expected 5 records with 3 filtered out and 2 valid, got %d, %d, %d",
+			result.TotalRecords, result.FilteredRecords, result.ValidRecords)
+	}
+	if len(rows) != 3 || rows[1][0] != "1" || rows[2][0] != "5" {
+		t.Errorf("Expected rows 1 and 5, got %v", rows)
+	}
+
+	// Unknown columns are reported before any output is written
+	cfg := config.NewConfig()
+	cfg.InputFile = filepath.Join(t.TempDir(), "input.csv")
+	os.WriteFile(cfg.InputFile, []byte(content), 0644)
+	cfg.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+	cfg.Where = "altitude > 0"
+	if _, err := NewOrchestrator(cfg).ProcessFile(); err == nil || !strings.Contains(err.Error(), `unknown column "altitude"`) {
+		t.Errorf("Expected an unknown column error, got %v", err)
+	}
+	if _, err := os.Stat(cfg.OutputFile); err == nil {
+		t.Error("Expected no output for an invalid filter")
+	}
+}
+
+func TestOrchestrator_Compute(t *testing.T) {
+	content := "id,latitude,longitude,speed_ms\n" +
+		"1,40.7128,-74.0060,10\n" +
+		"2,51.5074,-0.1278,\n" +
+		"3,abc,1,2.5\n"
+	result, rows := processCSV(t, content, func(cfg *config.Config) {
+		cfg.Compute = []string{"speed_kmh=speed_ms*3.6", "label=concat(id, \":\", if(speed_ms == \"\", \"stopped\", \"moving\"))"}
+	})
+	expected := [][]string{
+		{"id", "latitude", "longitude", "speed_ms", "h3_index", "speed_kmh", "label"},
+		{"1", "40.7128", "-74.0060", "10", "882a107289fffff", "36", "1:moving"},
+		{"2", "51.5074", "-0.1278", "", "88195da49bfffff", "", "2:stopped"},
+		{"3", "abc", "1", "2.5", "", "9", "3:moving"},
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, rows)
+	}
+	if result.ComputeErrors != 1 {
+		t.Errorf("Expected 1 compute error, got %d", result.ComputeErrors)
+	}
+
+	// Computed columns may not repeat input or output columns
+	for _, definition := range []string{"speed_ms=1", "h3_index=1"} {
+		cfg := config.NewConfig()
+		cfg.InputFile = filepath.Join(t.TempDir(), "input.csv")
+		os.WriteFile(cfg.InputFile, []byte(content), 0644)
+		cfg.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+		cfg.Compute = []string{definition}
+		if _, err := NewOrchestrator(cfg).ProcessFile(); err == nil || !strings.Contains(err.Error(), "repeats an existing column") {
+			t.Errorf("%s: expected a repeated column error, got %v", definition, err)
+		}
+	}
+}
+
+func TestOrchestrator_MaxSpeed(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := `device,ts,latitude,longitude
+a,2024-05-01 08:00:00,40.7128,-74.0060
+a,2024-05-01 08:01:00,51.5074,-0.1278
+a,2024-05-01 08:10:00,40.7200,-74.0000
+b,,40.7128,-74.0060
+b,2024-05-01 08:00:00,invalid,invalid
+`
+	os.WriteFile(inputFile, []byte(content), 0644)
+
+	for _, action := range []string{config.SpeedActionFlag, config.SpeedActionDrop} {
+		t.Run(action, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.InputFile = inputFile
+			cfg.OutputFile = filepath.Join(tempDir, action+".csv")
+			cfg.IdColumn = "device"
+			cfg.TimeColumn = "ts"
+			cfg.MaxSpeedKmh = 200
+			cfg.SpeedAction = action
+			cfg.VerifyOutput = true
+
+			result, err := NewOrchestrator(cfg).ProcessFile()
+			if err != nil {
+				t.Fatalf("ProcessFile failed: %v", err)
+			}
+			if result.ImplausibleSpeedRecords != 1 || result.UntimedRecords != 1 {
+				t.Errorf("Expected 1 implausible and 1 untimed record, got %d and %d",
+					result.ImplausibleSpeedRecords, result.UntimedRecords)
+			}
+
+			output, err := os.ReadFile(cfg.OutputFile)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+			if action == config.SpeedActionDrop {
+				if len(lines) != 5 || strings.Contains(string(output), "51.5074") || strings.Contains(lines[0], "implausible_speed") {
+					t.Errorf("Expected the implausible row to be dropped, got:\n%s", output)
+				}
+				return
+			}
+			expected := []string{"false", "true", "false", "", ""}
+			if len(lines) != 6 || !strings.HasSuffix(lines[0], ",implausible_speed") {
+				t.Fatalf("Expected an implausible_speed column on 5 rows, got:\n%s", output)
+			}
+			for i, flag := range expected {
+				if !strings.HasSuffix(lines[i+1], ","+flag) {
+					t.Errorf("Row %d: expected implausible_speed %q, got %s", i+1, flag, lines[i+1])
+				}
+			}
+		})
+	}
+}
+
+func TestOrchestrator_DetectStays(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.csv")
+	content := `device,ts,latitude,longitude
+a,2024-05-01 08:00:00,40.7128,-74.0060
+b,2024-05-01 08:00:00,51.5074,-0.1278
+a,2024-05-01 08:06:00,40.7129,-74.0061
+c,2024-05-01 08:07:00,invalid,invalid
+a,2024-05-01 08:12:00,40.7128,-74.0060
+a,2024-05-01 08:20:00,40.7500,-73.9900
+`
+	os.WriteFile(inputFile, []byte(content), 0644)
+	cfg := config.NewConfig()
+	cfg.InputFile = inputFile
+	cfg.OutputFile = filepath.Join(tempDir, "output.csv")
+	cfg.IdColumn = "device"
+	cfg.TimeColumn = "ts"
+	cfg.DetectStays = true
+	cfg.VerifyOutput = true
+
+	result, err := NewOrchestrator(cfg).ProcessFile()
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if result.Stays != 1 {
+		t.Errorf("Expected 1 stay, got %d", result.Stays)
+	}
+
+	output, err := os.ReadFile(cfg.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 7 || !strings.HasSuffix(lines[0], ",stay_id,stay_flag") {
+		t.Fatalf("Expected stay columns on 6 rows, got:\n%s", output)
+	}
+	// Rows held for their stay decision are written in input order
+	expected := []string{"a,a-stay-1,true", "b,,false", "a,a-stay-1,true", "c,,", "a,a-stay-1,true", "a,,false"}
+	for i, row := range expected {
+		fields := strings.Split(lines[i+1], ",")
+		got := strings.Join([]string{fields[0], fields[len(fields)-2], fields[len(fields)-1]}, ",")
+		if got != row {
+			t.Errorf("Row %d: expected %s, got %s", i+1, row, lines[i+1])
+		}
+	}
+}
+
+// TestStayQueue_Bound tests that a run that is never decided does not hold back the
+// records after it beyond the queue limit
+func TestStayQueue_Bound(t *testing.T) {
+	detector, err := trajectory.NewStayDetector(100, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewStayDetector failed: %v", err)
+	}
+	var written []string
+	queue := newStayQueue(detector, &traceColumns{id: 0, time: 1}, func(record *csv.Record) error {
+		written = append(written, record.OriginalData[0]+":"+record.Extra["stay_flag"])
+		return nil
+	})
+	queue.limit = 3
+
+	// Device a reports once and never again; b keeps moving
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	add := func(id string, minutes int, lat float64) {
+		record := &csv.Record{OriginalData: []string{id, start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339)},
+			Latitude: lat, Longitude: -74.0060, IsValid: true}
+		if err := queue.add(record); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+	add("a", 0, 40.7128)
+	for i := 0; i < 4; i++ {
+		add("b", i, 41+float64(i))
+	}
+	if len(written) != 4 || written[0] != "a:false" || written[3] != "b:false" {
+		t.Fatalf("Expected the held run of a to be released at the limit, got %v", written)
+	}
+	if queue.released != 1 {
+		t.Errorf("Expected 1 released record, got %d", queue.released)
+	}
+	if err := queue.finish(); err != nil {
+		t.Fatalf("finish failed: %v", err)
+	}
+	if len(written) != 5 {
+		t.Errorf("Expected every record written after finish, got %v", written)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	encodingcsv "encoding/csv"
+	"io"
+	"os"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/database"
+	"csv-h3-tool/internal/errors"
+)
+
+// spoolQuery runs the input query once and copies its rows to a temporary file, which
+// every later pass over the input reads instead of querying the database again
+func (o *Orchestrator) spoolQuery(driver, query string) error {
+	source, err := database.Query(driver, o.config.DatabaseURL(), query)
+	if err != nil {
+		return errors.NewProcessingError("query", 0, "failed to query input database", err)
+	}
+	defer source.Close()
+
+	path, err := spoolRows(source, o.config.TempDir)
+	if err != nil {
+		return errors.NewProcessingError("query", 0, "failed to spool query results", err)
+	}
+	o.querySpool = path
+	return nil
+}
+
+// removeQuerySpool deletes the spooled query results
+func (o *Orchestrator) removeQuerySpool() {
+	if o.querySpool != "" {
+		os.Remove(o.querySpool)
+		o.querySpool = ""
+	}
+}
+
+// spoolRows writes every row of source to a temporary CSV file in dir (the system
+// temp directory when empty) and returns its path
+func spoolRows(source csv.RowSource, dir string) (string, error) {
+	file, err := os.CreateTemp(dir, "csvh3-query-*.csv")
+	if err != nil {
+		return "", err
+	}
+	writer := encodingcsv.NewWriter(file)
+	for {
+		row, err := source.Read()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = writer.Write(row)
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return "", err
+		}
+	}
+	writer.Flush()
+	err = writer.Error()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// spoolSource reads back the rows written by spoolRows. Unlike a file source it
+// leaves preamble and comment handling to the reader, as for the query itself.
+type spoolSource struct {
+	file   *os.File
+	reader *encodingcsv.Reader
+}
+
+// openSpoolSource opens spooled query results as a row source
+func openSpoolSource(path string) (*spoolSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader := encodingcsv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	return &spoolSource{file: file, reader: reader}, nil
+}
+
+func (s *spoolSource) Read() ([]string, error) {
+	return s.reader.Read()
+}
+
+func (s *spoolSource) Close() error {
+	return s.file.Close()
+}
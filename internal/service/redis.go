@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/errors"
+	"csv-h3-tool/internal/redis"
+)
+
+// redisWriter wraps an output sink, also writing a key→H3 index mapping to Redis
+// for every valid record. Keys are rendered from the record's input fields, before
+// any wrapped sink changes them, e.g. by encrypting columns.
+type redisWriter struct {
+	recordWriter
+	client      *redis.Client
+	keyTemplate *template.Template
+	headers     []string // Template field names (column indexes without headers)
+	keys        int64
+}
+
+// newRedisWriter connects to the configured Redis server and wraps the output sink
+func (o *Orchestrator) newRedisWriter(output recordWriter, headers []string) (*redisWriter, error) {
+	keyTemplate, err := o.config.ParseKeyTemplate()
+	if err != nil {
+		return nil, errors.NewConfigError("key_template", o.config.KeyTemplate, "invalid key template", err)
+	}
+
+	client, err := redis.Dial(o.config.RedisSink)
+	if err != nil {
+		return nil, errors.NewProcessingError("redis", 0, "failed to connect to Redis sink", err)
+	}
+	return &redisWriter{recordWriter: output, client: client, keyTemplate: keyTemplate, headers: headers}, nil
+}
+
+func (w *redisWriter) WriteRecord(record *csv.Record) error {
+	if err := w.recordWriter.WriteRecord(record); err != nil {
+		return err
+	}
+	if !record.IsValid || record.H3Index == "" {
+		return nil
+	}
+	key, err := w.key(record.OriginalData)
+	if err != nil {
+		return err
+	}
+	return w.set(key, record.H3Index)
+}
+
+// FormatRecord formats the record with the wrapped sink and appends its Redis key and
+// H3 index, both empty for an invalid record, so WriteRow can set the mapping of a
+// row formatted earlier, e.g. for sorted output
+func (w *redisWriter) FormatRecord(record *csv.Record) ([]string, error) {
+	row, err := w.recordWriter.FormatRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	if !record.IsValid || record.H3Index == "" {
+		return append(row, "", ""), nil
+	}
+	key, err := w.key(record.OriginalData)
+	if err != nil {
+		return nil, err
+	}
+	return append(row, key, record.H3Index), nil
+}
+
+// WriteRow writes a row built by FormatRecord and sets its mapping
+func (w *redisWriter) WriteRow(row []string) error {
+	if len(row) < 2 {
+		return fmt.Errorf("row has too few columns to hold a Redis key: %d", len(row))
+	}
+	key, h3Index := row[len(row)-2], row[len(row)-1]
+	if err := w.recordWriter.WriteRow(row[:len(row)-2]); err != nil {
+		return err
+	}
+	if h3Index == "" {
+		return nil
+	}
+	return w.set(key, h3Index)
+}
+
+// set queues the mapping of a key to an H3 index
+func (w *redisWriter) set(key, h3Index string) error {
+	w.keys++
+	return w.client.Set(key, h3Index)
+}
+
+// key renders the Redis key of a row's input fields. Template fields are the input
+// headers, or zero-based column indexes for input without headers.
+func (w *redisWriter) key(row []string) (string, error) {
+	fields := make(map[string]string, len(row))
+	for i, value := range row {
+		if w.headers == nil {
+			fields[strconv.Itoa(i)] = value
+		} else if i < len(w.headers) {
+			fields[w.headers[i]] = value
+		}
+	}
+
+	var key strings.Builder
+	if err := w.keyTemplate.Execute(&key, fields); err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+func (w *redisWriter) Flush() error {
+	if err := w.recordWriter.Flush(); err != nil {
+		return err
+	}
+	return w.client.Flush()
+}
+
+func (w *redisWriter) Close() error {
+	outputErr := w.recordWriter.Close()
+	if err := w.client.Close(); err != nil && outputErr == nil {
+		return err
+	}
+	return outputErr
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"math/rand"
+	"sort"
+
+	"csv-h3-tool/internal/csv"
+)
+
+// cellSampler keeps at most a fixed number of valid records per H3 cell by reservoir
+// sampling, thinning the data evenly in space. Invalid records have no cell and are
+// all kept. The kept records are held in memory until the input is read.
+type cellSampler struct {
+	perCell int
+	rng     *rand.Rand
+	seq     int // Records added so far
+	added   int // Valid records added
+	cellMap map[string]*reservoir
+	other   []sampledRecord // Invalid records
+}
+
+// reservoir is the sample of one cell
+type reservoir struct {
+	seen    int
+	records []sampledRecord
+}
+
+// sampledRecord is a kept record with its position in the input
+type sampledRecord struct {
+	seq    int
+	record *csv.Record
+}
+
+// newCellSampler creates a sampler keeping perCell records per cell
+func newCellSampler(perCell int, rng *rand.Rand) *cellSampler {
+	return &cellSampler{perCell: perCell, rng: rng, cellMap: make(map[string]*reservoir)}
+}
+
+// add offers a record to the sample of its cell
+func (s *cellSampler) add(record *csv.Record) {
+	sampled := sampledRecord{seq: s.seq, record: record}
+	s.seq++
+	if !record.IsValid || record.H3Index == "" {
+		s.other = append(s.other, sampled)
+		return
+	}
+	s.added++
+	cell := s.cellMap[record.H3Index]
+	if cell == nil {
+		cell = &reservoir{}
+		s.cellMap[record.H3Index] = cell
+	}
+	cell.seen++
+	if len(cell.records) < s.perCell {
+		cell.records = append(cell.records, sampled)
+	} else if i := s.rng.Intn(cell.seen); i < s.perCell {
+		cell.records[i] = sampled
+	}
+}
+
+// records returns the kept records in the order they were added
+func (s *cellSampler) records() []*csv.Record {
+	kept := append([]sampledRecord(nil), s.other...)
+	for _, cell := range s.cellMap {
+		kept = append(kept, cell.records...)
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].seq < kept[j].seq })
+	records := make([]*csv.Record, len(kept))
+	for i, sampled := range kept {
+		records[i] = sampled.record
+	}
+	return records
+}
+
+// dropped returns the number of valid records not kept
+func (s *cellSampler) dropped() int {
+	kept := 0
+	for _, cell := range s.cellMap {
+		kept += len(cell.records)
+	}
+	return s.added - kept
+}
+
+// cells returns the number of cells sampled
+func (s *cellSampler) cells() int {
+	return len(s.cellMap)
+}
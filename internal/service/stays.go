@@ -0,0 +1,105 @@
+package service
+
+import (
+	"strconv"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/trajectory"
+)
+
+// maxHeldStayRecords bounds the records held for stay detection. A run of an entity
+// that stops reporting is decided only at the end of the input, and holds back every
+// later record of every entity until then; past this many held records the run holding
+// the oldest one is released as moving.
+const maxHeldStayRecords = 100000
+
+// stayQueue holds records until the stay detector has labeled them and passes them on
+// in input order, each as soon as every earlier record is labeled
+type stayQueue struct {
+	detector *trajectory.StayDetector
+	trace    *traceColumns
+	write    func(*csv.Record) error
+	queue    []*csv.Record // Records not yet written, oldest first
+	labeled  []bool        // Whether each queued record is labeled
+	first    int           // Token of the oldest queued record
+	limit    int           // Records held before undecided runs are released
+	released int           // Records labeled moving because their run was released
+}
+
+// newStayQueue creates a queue labeling the points of trace with detector and passing
+// the records on to write
+func newStayQueue(detector *trajectory.StayDetector, trace *traceColumns, write func(*csv.Record) error) *stayQueue {
+	return &stayQueue{detector: detector, trace: trace, write: write, limit: maxHeldStayRecords}
+}
+
+// add queues a record and writes the records labeled so far. Invalid records and rows
+// without an id or timestamp are not points of a trace and leave the stay columns empty.
+func (q *stayQueue) add(record *csv.Record) error {
+	token := q.first + len(q.queue)
+	q.queue = append(q.queue, record)
+	q.labeled = append(q.labeled, false)
+
+	id, t, timed := q.trace.point(record.OriginalData)
+	if !timed || !record.IsValid {
+		record.SetExtra("stay_id", "")
+		record.SetExtra("stay_flag", "")
+		q.labeled[token-q.first] = true
+		return q.flush()
+	}
+	labels, err := q.detector.Add(token, id, t, record.Latitude, record.Longitude)
+	if err != nil {
+		return err
+	}
+	q.apply(labels)
+	if err := q.flush(); err != nil {
+		return err
+	}
+	return q.bound()
+}
+
+// bound releases the undecided run holding the oldest queued record, and writes the
+// records it frees, while more than limit records are queued. The oldest queued record
+// is always a pending point of its entity's run, so each release frees it.
+func (q *stayQueue) bound() error {
+	for len(q.queue) > q.limit {
+		id, _, _ := q.trace.point(q.queue[0].OriginalData)
+		labels := q.detector.Release(id)
+		q.released += len(labels)
+		q.apply(labels)
+		if err := q.flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish labels the records still waiting at the end of the input and writes them
+func (q *stayQueue) finish() error {
+	q.apply(q.detector.Finish())
+	return q.flush()
+}
+
+// apply sets the stay columns of labeled records
+func (q *stayQueue) apply(labels []trajectory.StayLabel) {
+	for _, label := range labels {
+		index := label.Token - q.first
+		q.queue[index].SetExtra("stay_id", label.StayID)
+		q.queue[index].SetExtra("stay_flag", strconv.FormatBool(label.StayID != ""))
+		q.labeled[index] = true
+	}
+}
+
+// flush writes the labeled records at the head of the queue
+func (q *stayQueue) flush() error {
+	written := 0
+	for written < len(q.queue) && q.labeled[written] {
+		if err := q.write(q.queue[written]); err != nil {
+			return err
+		}
+		q.queue[written] = nil
+		written++
+	}
+	q.queue, q.labeled = q.queue[written:], q.labeled[written:]
+	q.first += written
+	return nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"time"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/trajectory"
+)
+
+// traceColumns locates the entity id and timestamp of trajectory points
+type traceColumns struct {
+	id, time int
+}
+
+// traceColumns resolves the --id-column and --time-column of the input, or returns nil
+// when no trajectory stage is configured
+func (o *Orchestrator) traceColumns(reader *csv.Reader) (*traceColumns, error) {
+	if !o.config.HasTrajectory() {
+		return nil, nil
+	}
+	id, err := reader.MatchColumn("id", o.config.IdColumn)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := reader.MatchColumn("time", o.config.TimeColumn)
+	if err != nil {
+		return nil, err
+	}
+	return &traceColumns{id: id.Index, time: timestamp.Index}, nil
+}
+
+// point returns the entity id and time of a row; ok is false when the id is empty or
+// the time is missing or unparseable
+func (c *traceColumns) point(row []string) (id string, t time.Time, ok bool) {
+	if c.id >= len(row) || c.time >= len(row) {
+		return "", time.Time{}, false
+	}
+	id = row[c.id]
+	if id == "" {
+		return "", time.Time{}, false
+	}
+	t, err := trajectory.ParseTime(row[c.time])
+	return id, t, err == nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	encodingcsv "encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"csv-h3-tool/internal/config"
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/errors"
+	"csv-h3-tool/internal/filehandler"
+	"csv-h3-tool/internal/schema"
+)
+
+// rowEncoder writes rows of string values to a typed output file
+type rowEncoder interface {
+	Write(row []string) error
+	// Flush completes the file
+	Flush() error
+}
+
+// newRowEncoder creates the encoder of a typed output format writing to out; name is
+// the output file base name and h3Long stores H3 indexes as integers
+type newRowEncoder func(out io.Writer, name string, columns []schema.Column, h3Long bool) (rowEncoder, error)
+
+// typedWriter is a record sink writing a typed output file (Avro or ORC). The file
+// schema needs the column types up front, so rows are spooled to a temporary CSV file
+// while their types are inferred, and the encoder writes them from the spool on Flush.
+type typedWriter struct {
+	file         *os.File
+	spool        *os.File
+	rows         *encodingcsv.Writer
+	extraColumns []string
+	inferrer     *schema.Inferrer
+	newInferrer  func(width int) *schema.Inferrer // Creates the inferrer from the first row's width
+	newEncoder   newRowEncoder
+	h3Long       bool // Store H3 indexes as integers instead of strings
+}
+
+// newTypedWriter creates the output file and the spool its rows are buffered in
+func (o *Orchestrator) newTypedWriter(headers []string, extraColumns []string) (*typedWriter, error) {
+	newEncoder := newAvroEncoder
+	if o.config.OutputFormat == config.OutputFormatORC {
+		newEncoder = newORCEncoder
+	}
+	if err := filehandler.NewFileHandler().ValidateOutputFile(o.config.OutputFile, o.config.Overwrite); err != nil {
+		return nil, errors.NewFileError(o.config.OutputFile, "create", err)
+	}
+	file, err := os.Create(o.config.OutputFile)
+	if err != nil {
+		return nil, errors.NewFileError(o.config.OutputFile, "create", err)
+	}
+	spool, err := os.CreateTemp(o.config.TempDir, "csvh3-"+o.config.OutputFormat+"-*.csv")
+	if err != nil {
+		file.Close()
+		return nil, errors.NewFileError(o.config.TempDir, "create", err)
+	}
+	return &typedWriter{
+		file:         file,
+		spool:        spool,
+		rows:         encodingcsv.NewWriter(spool),
+		extraColumns: extraColumns,
+		newInferrer: func(width int) *schema.Inferrer {
+			return o.newSchemaInferrer(headers, width, extraColumns)
+		},
+		newEncoder: newEncoder,
+		h3Long:     o.config.H3Format == config.H3FormatLong,
+	}, nil
+}
+
+func (w *typedWriter) WriteRecord(record *csv.Record) error {
+	row, err := w.FormatRecord(record)
+	if err != nil {
+		return err
+	}
+	return w.WriteRow(row)
+}
+
+func (w *typedWriter) FormatRecord(record *csv.Record) ([]string, error) {
+	return csv.FormatOutputRow(record, w.extraColumns)
+}
+
+func (w *typedWriter) WriteRow(row []string) error {
+	if w.inferrer == nil {
+		w.inferrer = w.newInferrer(len(row) - 1 - len(w.extraColumns))
+	}
+	w.inferrer.Add(row)
+	return w.rows.Write(row)
+}
+
+// Flush writes the output file with the inferred schema and every spooled row
+func (w *typedWriter) Flush() error {
+	w.rows.Flush()
+	if err := w.rows.Error(); err != nil {
+		return err
+	}
+	if w.inferrer == nil {
+		w.inferrer = w.newInferrer(0)
+	}
+	if _, err := w.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(w.file.Name()), filepath.Ext(w.file.Name()))
+	encoder, err := w.newEncoder(w.file, name, w.inferrer.Columns(), w.h3Long)
+	if err != nil {
+		return err
+	}
+	rows := encodingcsv.NewReader(w.spool)
+	rows.FieldsPerRecord = -1
+	for line := 1; ; line++ {
+		row, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := encoder.Write(row); err != nil {
+			return fmt.Errorf("output row %d: %w", line, err)
+		}
+	}
+	return encoder.Flush()
+}
+
+// Close closes the output file and removes the spool
+func (w *typedWriter) Close() error {
+	w.spool.Close()
+	os.Remove(w.spool.Name())
+	return w.file.Close()
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/database"
+	"csv-h3-tool/internal/errors"
+)
+
+// updateWriter is a record sink writing H3 indexes back to the source database table.
+// Rows of invalid records are left unchanged.
+type updateWriter struct {
+	updater      *database.Updater
+	keyIndex     int // Key column in input rows
+	h3Index      int // H3 index column in formatted rows
+	extraColumns []string
+}
+
+// newUpdateWriter connects to the database for a backfill of the configured table
+func (o *Orchestrator) newUpdateWriter(headers []string, extraColumns []string) (*updateWriter, error) {
+	keyIndex := findHeader(headers, o.config.KeyColumn)
+	if keyIndex < 0 {
+		return nil, errors.NewConfigError("key_column", o.config.KeyColumn, "key column not found in query results", nil)
+	}
+
+	updater, err := database.NewUpdater(database.DriverPostgres, o.config.DatabaseURL(), o.config.PGUpdate,
+		o.config.KeyColumn, o.config.UpdateH3Column, o.config.UpdateBatchSize)
+	if err != nil {
+		return nil, errors.NewProcessingError("update", 0, "failed to connect to update database", err)
+	}
+	return &updateWriter{updater: updater, keyIndex: keyIndex, h3Index: len(headers), extraColumns: extraColumns}, nil
+}
+
+// findHeader returns the index of the named header, matched exactly or ignoring case, or -1
+func findHeader(headers []string, name string) int {
+	for i, header := range headers {
+		if header == name {
+			return i
+		}
+	}
+	for i, header := range headers {
+		if strings.EqualFold(header, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *updateWriter) WriteRecord(record *csv.Record) error {
+	if !record.IsValid || record.H3Index == "" {
+		return nil
+	}
+	if w.keyIndex >= len(record.OriginalData) {
+		return fmt.Errorf("line %d has no key column", record.LineNumber)
+	}
+	return w.updater.Update(record.OriginalData[w.keyIndex], record.H3Index)
+}
+
+func (w *updateWriter) FormatRecord(record *csv.Record) ([]string, error) {
+	return csv.FormatOutputRow(record, w.extraColumns)
+}
+
+// WriteRow applies a formatted row, e.g. from sorted output
+func (w *updateWriter) WriteRow(row []string) error {
+	if w.keyIndex >= len(row) || w.h3Index >= len(row) || row[w.h3Index] == "" {
+		return nil
+	}
+	return w.updater.Update(row[w.keyIndex], row[w.h3Index])
+}
+
+func (w *updateWriter) Flush() error {
+	return w.updater.Flush()
+}
+
+func (w *updateWriter) Close() error {
+	return w.updater.Close()
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	encodingcsv "encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+
+	"csv-h3-tool/internal/csv"
+	"csv-h3-tool/internal/encrypt"
+)
+
+// verifySampleRows is the number of output rows whose H3 index --verify-output recomputes
+const verifySampleRows = 1000
+
+// verifyOutput re-reads the written output file and checks that it holds the expected
+// number of rows, that its header row includes the H3 column, and that a random sample
+// of rows recomputes to the same H3 index. It returns the number of rows recomputed.
+func (o *Orchestrator) verifyOutput(reader *csv.Reader, expectedRows int) (int, error) {
+	file, err := os.Open(o.config.OutputFile)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	output := encodingcsv.NewReader(file)
+	output.FieldsPerRecord = -1
+
+	// The H3 column follows the input columns, before the extra columns
+	h3Offset := 1 + len(o.extraColumns())
+	_, _, h3Column := o.config.CoordinateColumns()
+	if h3Column == "" {
+		h3Column = csv.DefaultH3Column
+	}
+	if reader.GetHeaders() != nil {
+		headers, err := output.Read()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read the header row: %w", err)
+		}
+		if len(headers) < h3Offset || !strings.EqualFold(headers[len(headers)-h3Offset], h3Column) {
+			return 0, fmt.Errorf("header row does not include the H3 column %q", h3Column)
+		}
+	}
+
+	// Reservoir-sample the rows while counting them
+	var sample [][]string
+	rows := 0
+	for {
+		row, err := output.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read row %d: %w", rows+1, err)
+		}
+		rows++
+		if len(sample) < verifySampleRows {
+			sample = append(sample, row)
+		} else if i := rand.Intn(rows); i < verifySampleRows {
+			sample[i] = row
+		}
+	}
+	if rows != expectedRows {
+		return 0, fmt.Errorf("output has %d rows, expected %d", rows, expectedRows)
+	}
+
+	latIndex, lngIndex := reader.GetLatIndex(), reader.GetLngIndex()
+	generator := &h3GeneratorAdapter{generator: o.h3Generator}
+	recomputed := 0
+	for _, row := range sample {
+		if len(row) < h3Offset || len(row) <= max(latIndex, lngIndex) {
+			return recomputed, fmt.Errorf("row %v is truncated", row)
+		}
+		index := row[len(row)-h3Offset]
+		lat, lng := strings.TrimSpace(row[latIndex]), strings.TrimSpace(row[lngIndex])
+		if index == "" || strings.HasPrefix(lat, encrypt.Prefix) || strings.HasPrefix(lng, encrypt.Prefix) {
+			continue // Invalid records have no index; encrypted coordinates cannot be recomputed
+		}
+		latitude, latErr := csv.ParseNumber(lat, o.config.NumberLocale)
+		longitude, lngErr := csv.ParseNumber(lng, o.config.NumberLocale)
+		if latErr != nil || lngErr != nil {
+			return recomputed, fmt.Errorf("row with H3 index %s has unparseable coordinates (%s, %s)", index, lat, lng)
+		}
+		expected, err := generator.Generate(latitude, longitude, o.config.Resolution)
+		if err != nil {
+			return recomputed, fmt.Errorf("failed to recompute the H3 index of (%s, %s): %w", lat, lng, err)
+		}
+		if expected != index {
+			return recomputed, fmt.Errorf("row at (%s, %s) has H3 index %s, recomputed %s", lat, lng, index, expected)
+		}
+		recomputed++
+	}
+	return recomputed, nil
+}
@@ -0,0 +1,149 @@
+// Package sign produces and checks detached signatures of output files, so downstream
+// consumers can verify that a file was written by a pipeline holding the private key.
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// Extension is appended to a signed file's path to name its signature file
+const Extension = ".sig"
+
+// LoadPrivateKey reads an RSA, ECDSA, or Ed25519 private key from a PEM file in PKCS #8,
+// PKCS #1 (RSA), or SEC 1 (EC) form
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	var key any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T in %s", key, path)
+}
+
+// signatureHash returns the digest signed for a key type: SHA-256 for RSA (PKCS #1 v1.5)
+// and ECDSA (ASN.1), as "openssl dgst -sha256 -sign" does, and SHA-512 for Ed25519,
+// which signs the digest as Ed25519ph
+func signatureHash(key crypto.PublicKey) (crypto.Hash, error) {
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return crypto.SHA256, nil
+	case ed25519.PublicKey:
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported public key type %T", key)
+}
+
+// digestFile hashes a file without reading it into memory
+func digestFile(path string, hashFunc crypto.Hash) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var digest hash.Hash
+	if hashFunc == crypto.SHA512 {
+		digest = sha512.New()
+	} else {
+		digest = sha256.New()
+	}
+	if _, err := io.Copy(digest, file); err != nil {
+		return nil, err
+	}
+	return digest.Sum(nil), nil
+}
+
+// SignFile returns the detached signature of a file
+func SignFile(signer crypto.Signer, path string) ([]byte, error) {
+	hashFunc, err := signatureHash(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestFile(path, hashFunc)
+	if err != nil {
+		return nil, err
+	}
+	var opts crypto.SignerOpts = hashFunc
+	if hashFunc == crypto.SHA512 {
+		opts = &ed25519.Options{Hash: crypto.SHA512}
+	}
+	return signer.Sign(rand.Reader, digest, opts)
+}
+
+// WriteSignature signs a file and writes the signature next to it, returning the
+// signature file path
+func WriteSignature(signer crypto.Signer, path string) (string, error) {
+	signature, err := SignFile(signer, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s: %w", path, err)
+	}
+	signaturePath := path + Extension
+	if err := os.WriteFile(signaturePath, signature, 0644); err != nil {
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+	return signaturePath, nil
+}
+
+// VerifyFile checks a detached signature of a file against a public key
+func VerifyFile(key crypto.PublicKey, path string, signature []byte) error {
+	hashFunc, err := signatureHash(key)
+	if err != nil {
+		return err
+	}
+	digest, err := digestFile(path, hashFunc)
+	if err != nil {
+		return err
+	}
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, hashFunc, digest, signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			err = fmt.Errorf("ECDSA verification failed")
+		}
+	case ed25519.PublicKey:
+		err = ed25519.VerifyWithOptions(key, digest, signature, &ed25519.Options{Hash: crypto.SHA512})
+	}
+	if err != nil {
+		return fmt.Errorf("invalid signature of %s: %w", path, err)
+	}
+	return nil
+}
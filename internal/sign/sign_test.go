@@ -0,0 +1,107 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKey writes a private key as a PEM file of the given block type
+func writeKey(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return path
+}
+
+func pkcs8(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return der
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	keys := map[string]string{
+		"rsa pkcs1":     writeKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		"rsa pkcs8":     writeKey(t, "PRIVATE KEY", pkcs8(t, rsaKey)),
+		"ecdsa sec1":    writeKey(t, "EC PRIVATE KEY", ecDER),
+		"ed25519 pkcs8": writeKey(t, "PRIVATE KEY", pkcs8(t, edKey)),
+	}
+	for name, keyFile := range keys {
+		t.Run(name, func(t *testing.T) {
+			signer, err := LoadPrivateKey(keyFile)
+			if err != nil {
+				t.Fatalf("LoadPrivateKey failed: %v", err)
+			}
+			file := filepath.Join(t.TempDir(), "output.csv")
+			os.WriteFile(file, []byte("id,h3_index\n1,8828308281fffff\n"), 0644)
+
+			signatureFile, err := WriteSignature(signer, file)
+			if err != nil {
+				t.Fatalf("WriteSignature failed: %v", err)
+			}
+			if signatureFile != file+Extension {
+				t.Errorf("Unexpected signature file %s", signatureFile)
+			}
+			signature, _ := os.ReadFile(signatureFile)
+			if err := VerifyFile(signer.Public(), file, signature); err != nil {
+				t.Errorf("VerifyFile failed: %v", err)
+			}
+
+			os.WriteFile(file, []byte("id,h3_index\n1,8828308281ffffe\n"), 0644)
+			if err := VerifyFile(signer.Public(), file, signature); err == nil {
+				t.Error("Expected a modified file to fail verification")
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsOtherKey(t *testing.T) {
+	signer, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	file := filepath.Join(t.TempDir(), "output.csv")
+	os.WriteFile(file, []byte("data"), 0644)
+
+	signature, err := SignFile(signer, file)
+	if err != nil {
+		t.Fatalf("SignFile failed: %v", err)
+	}
+	if err := VerifyFile(crypto.PublicKey(&other.PublicKey), file, signature); err == nil {
+		t.Error("Expected verification with another key to fail")
+	}
+}
+
+func TestLoadPrivateKeyErrors(t *testing.T) {
+	notPEM := filepath.Join(t.TempDir(), "key.pem")
+	os.WriteFile(notPEM, []byte("not a key"), 0600)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+
+	for name, path := range map[string]string{
+		"missing file":  filepath.Join(t.TempDir(), "missing.pem"),
+		"not PEM":       notPEM,
+		"public key":    writeKey(t, "PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)),
+		"corrupt pkcs8": writeKey(t, "PRIVATE KEY", []byte{1, 2, 3}),
+	} {
+		if _, err := LoadPrivateKey(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
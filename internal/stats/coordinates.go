@@ -0,0 +1,64 @@
+package stats
+
+import "math"
+
+// DefaultBinDegrees is the width of the coordinate histogram bins
+const DefaultBinDegrees = 10
+
+// Histogram counts values in fixed-width bins over a range
+type Histogram struct {
+	Min, Width float64
+	Counts     []int
+}
+
+// NewHistogram creates a histogram over [min, max] with bins of the given width
+func NewHistogram(min, max, width float64) *Histogram {
+	return &Histogram{Min: min, Width: width, Counts: make([]int, int(math.Ceil((max-min)/width)))}
+}
+
+// Add counts a value; values outside the range are counted in the nearest bin
+func (h *Histogram) Add(value float64) {
+	bin := int(math.Floor((value - h.Min) / h.Width))
+	bin = max(0, min(bin, len(h.Counts)-1))
+	h.Counts[bin]++
+}
+
+// Bin returns the range [low, high) of a bin
+func (h *Histogram) Bin(i int) (low, high float64) {
+	low = h.Min + float64(i)*h.Width
+	return low, low + h.Width
+}
+
+// Coordinates collects the bounding box and coarse histograms of latitudes and
+// longitudes in constant memory
+type Coordinates struct {
+	Count                 int
+	MinLat, MaxLat        float64 // Valid when Count > 0
+	MinLng, MaxLng        float64
+	Latitudes, Longitudes *Histogram
+}
+
+// NewCoordinates creates an empty summary with bins of binDegrees; binDegrees <= 0
+// selects the default
+func NewCoordinates(binDegrees float64) *Coordinates {
+	if binDegrees <= 0 {
+		binDegrees = DefaultBinDegrees
+	}
+	return &Coordinates{
+		MinLat:     math.Inf(1),
+		MaxLat:     math.Inf(-1),
+		MinLng:     math.Inf(1),
+		MaxLng:     math.Inf(-1),
+		Latitudes:  NewHistogram(-90, 90, binDegrees),
+		Longitudes: NewHistogram(-180, 180, binDegrees),
+	}
+}
+
+// Add adds a point
+func (c *Coordinates) Add(lat, lng float64) {
+	c.Count++
+	c.MinLat, c.MaxLat = math.Min(c.MinLat, lat), math.Max(c.MaxLat, lat)
+	c.MinLng, c.MaxLng = math.Min(c.MinLng, lng), math.Max(c.MaxLng, lng)
+	c.Latitudes.Add(lat)
+	c.Longitudes.Add(lng)
+}
@@ -0,0 +1,121 @@
+package stats
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultDistinctLimit is the number of distinct values counted exactly per column
+// before switching to a HyperLogLog estimate
+const DefaultDistinctLimit = 100
+
+// Profile collects per-column statistics in memory bounded by the number of columns:
+// min/max/mean for numeric columns and distinct counts for string columns, exact up
+// to a limit and sketched beyond it
+type Profile struct {
+	limit   int
+	columns []*Column
+}
+
+// Column holds the statistics of one column
+type Column struct {
+	Name     string
+	Count    int // Non-empty values
+	Empty    int // Empty values
+	Numeric  bool
+	Min, Max float64 // Valid when Numeric
+	sum      float64
+
+	distinct map[string]struct{} // Exact distinct values, nil once the limit is exceeded
+	sketch   *HyperLogLog
+}
+
+// NewProfile creates a profile for the named columns; distinctLimit < 1 selects the default
+func NewProfile(names []string, distinctLimit int) *Profile {
+	if distinctLimit < 1 {
+		distinctLimit = DefaultDistinctLimit
+	}
+	profile := &Profile{limit: distinctLimit, columns: make([]*Column, len(names))}
+	for i, name := range names {
+		profile.columns[i] = &Column{
+			Name:     name,
+			Numeric:  true,
+			Min:      math.Inf(1),
+			Max:      math.Inf(-1),
+			distinct: make(map[string]struct{}),
+		}
+	}
+	return profile
+}
+
+// Add adds one row; values beyond the known columns are ignored
+func (p *Profile) Add(row []string) {
+	for i, column := range p.columns {
+		value := ""
+		if i < len(row) {
+			value = strings.TrimSpace(row[i])
+		}
+		column.add(value, p.limit)
+	}
+}
+
+// Columns returns the column statistics in column order
+func (p *Profile) Columns() []*Column {
+	return p.columns
+}
+
+// add adds one value to the column statistics
+func (c *Column) add(value string, limit int) {
+	if value == "" {
+		c.Empty++
+		return
+	}
+	c.Count++
+
+	if c.Numeric {
+		if number, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) {
+			c.Min = math.Min(c.Min, number)
+			c.Max = math.Max(c.Max, number)
+			c.sum += number
+		} else {
+			c.Numeric = false
+		}
+	}
+
+	if c.distinct != nil {
+		c.distinct[value] = struct{}{}
+		if len(c.distinct) <= limit {
+			return
+		}
+		// Too many values to count exactly: move them into a sketch
+		c.sketch = &HyperLogLog{}
+		for seen := range c.distinct {
+			c.sketch.Add(seen)
+		}
+		c.distinct = nil
+		return
+	}
+	c.sketch.Add(value)
+}
+
+// Mean returns the mean of a numeric column
+func (c *Column) Mean() float64 {
+	if c.Count == 0 {
+		return 0
+	}
+	return c.sum / float64(c.Count)
+}
+
+// Distinct returns the number of distinct non-empty values and whether it is exact
+func (c *Column) Distinct() (count uint64, exact bool) {
+	if c.distinct != nil {
+		return uint64(len(c.distinct)), true
+	}
+	return c.sketch.Estimate(), false
+}
+
+// IsNumeric reports whether the column has values and all of them are numbers
+func (c *Column) IsNumeric() bool {
+	return c.Numeric && c.Count > 0
+}
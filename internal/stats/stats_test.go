@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000} {
+		sketch := &HyperLogLog{}
+		for i := 0; i < n; i++ {
+			sketch.Add(fmt.Sprintf("value-%d", i))
+			sketch.Add(fmt.Sprintf("value-%d", i)) // Duplicates must not count
+		}
+		estimate := float64(sketch.Estimate())
+		if math.Abs(estimate-float64(n)) > math.Max(1, 0.05*float64(n)) {
+			t.Errorf("Estimate for %d distinct values = %.0f, want within 5%%", n, estimate)
+		}
+	}
+}
+
+func TestProfile(t *testing.T) {
+	profile := NewProfile([]string{"id", "category", "value"}, 3)
+	rows := [][]string{
+		{"1", "a", "10.5"},
+		{"2", "b", ""},
+		{"3", "a", "-2"},
+		{"4", "c", "x"},
+		{"5", "d"},
+	}
+	for _, row := range rows {
+		profile.Add(row)
+	}
+
+	columns := profile.Columns()
+	id := columns[0]
+	if !id.IsNumeric() || id.Min != 1 || id.Max != 5 || id.Mean() != 3 {
+		t.Errorf("Unexpected id stats: numeric=%v min=%v max=%v mean=%v", id.IsNumeric(), id.Min, id.Max, id.Mean())
+	}
+	if count, exact := id.Distinct(); count != 5 || exact {
+		t.Errorf("Expected an estimated 5 distinct ids past the limit, got %d (exact=%v)", count, exact)
+	}
+
+	category := columns[1]
+	if category.IsNumeric() {
+		t.Error("Expected category to be non-numeric")
+	}
+	if count, exact := category.Distinct(); count != 4 || exact {
+		t.Errorf("Expected an estimated 4 distinct categories, got %d (exact=%v)", count, exact)
+	}
+
+	value := columns[2]
+	if value.IsNumeric() || value.Count != 3 || value.Empty != 2 {
+		t.Errorf("Expected a non-numeric value column with 3 values and 2 empty, got numeric=%v count=%d empty=%d",
+			value.IsNumeric(), value.Count, value.Empty)
+	}
+	if count, exact := value.Distinct(); count != 3 || !exact {
+		t.Errorf("Expected exactly 3 distinct values, got %d (exact=%v)", count, exact)
+	}
+}
+
+func TestCoordinates(t *testing.T) {
+	coordinates := NewCoordinates(0)
+	points := [][2]float64{{40.7, -74.0}, {34.0, -118.2}, {41.9, -87.6}, {90, 180}}
+	for _, point := range points {
+		coordinates.Add(point[0], point[1])
+	}
+
+	if coordinates.Count != 4 {
+		t.Errorf("Count = %d, want 4", coordinates.Count)
+	}
+	if coordinates.MinLat != 34.0 || coordinates.MaxLat != 90 || coordinates.MinLng != -118.2 || coordinates.MaxLng != 180 {
+		t.Errorf("Unexpected bounding box: lat [%g, %g], lng [%g, %g]",
+			coordinates.MinLat, coordinates.MaxLat, coordinates.MinLng, coordinates.MaxLng)
+	}
+	if len(coordinates.Latitudes.Counts) != 18 || len(coordinates.Longitudes.Counts) != 36 {
+		t.Fatalf("Unexpected bin counts: %d latitude, %d longitude",
+			len(coordinates.Latitudes.Counts), len(coordinates.Longitudes.Counts))
+	}
+	// 30-40: Los Angeles, 40-50: New York and Chicago, 80-90 includes the pole
+	for bin, want := range map[int]int{12: 1, 13: 2, 17: 1} {
+		if got := coordinates.Latitudes.Counts[bin]; got != want {
+			low, high := coordinates.Latitudes.Bin(bin)
+			t.Errorf("Latitude bin [%g, %g) = %d, want %d", low, high, got, want)
+		}
+	}
+	if got := coordinates.Longitudes.Counts[35]; got != 1 {
+		t.Errorf("Last longitude bin = %d, want 1 (180 is clamped into it)", got)
+	}
+}
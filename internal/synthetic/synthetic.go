@@ -0,0 +1,145 @@
+package synthetic
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"csv-h3-tool/internal/validator"
+)
+
+// Headers are the columns of generated datasets
+var Headers = []string{"id", "latitude", "longitude", "name", "category", "timestamp", "value"}
+
+// invalidCoordinates are the kinds of invalid coordinates mixed into generated data, used in turn
+var invalidCoordinates = [][2]string{
+	{"91.0", "0.0"},  // Latitude out of range
+	{"0.0", "181.0"}, // Longitude out of range
+	{"", ""},         // Empty
+	{"abc", "xyz"},   // Malformed
+}
+
+// Options controls the generated dataset
+type Options struct {
+	Rows        int
+	BBox        *validator.BoundingBox // Region for valid coordinates (nil = whole globe)
+	InvalidRate float64                // Fraction of rows with invalid coordinates [0, 1]
+	Seed        int64                  // Random seed; the same options and seed produce the same file
+}
+
+// Stats summarizes a generated dataset
+type Stats struct {
+	Rows        int
+	InvalidRows int
+}
+
+// Validate checks the generation options
+func (o Options) Validate() error {
+	if o.Rows < 0 {
+		return fmt.Errorf("row count cannot be negative: %d", o.Rows)
+	}
+	if o.InvalidRate < 0 || o.InvalidRate > 1 {
+		return fmt.Errorf("invalid rate %g is out of valid range [0, 1]", o.InvalidRate)
+	}
+	return nil
+}
+
+// Generate writes a synthetic CSV dataset with a header row to w.
+// Invalid rows are spread evenly so exactly floor(Rows*InvalidRate) rows are invalid.
+func Generate(w io.Writer, opts Options) (Stats, error) {
+	if err := opts.Validate(); err != nil {
+		return Stats{}, err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Headers); err != nil {
+		return Stats{}, fmt.Errorf("failed to write headers: %w", err)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := Stats{}
+
+	for i := 0; i < opts.Rows; i++ {
+		var lat, lng string
+		if math.Floor(float64(i+1)*opts.InvalidRate) > math.Floor(float64(i)*opts.InvalidRate) {
+			invalid := invalidCoordinates[stats.InvalidRows%len(invalidCoordinates)]
+			lat, lng = invalid[0], invalid[1]
+			stats.InvalidRows++
+		} else {
+			latVal, lngVal := randomPoint(rng, opts.BBox)
+			lat = strconv.FormatFloat(latVal, 'f', 6, 64)
+			lng = strconv.FormatFloat(lngVal, 'f', 6, 64)
+		}
+
+		record := []string{
+			fmt.Sprintf("ID_%08d", i),
+			lat,
+			lng,
+			fmt.Sprintf("Location_%d", i),
+			fmt.Sprintf("Category_%d", i%20),
+			start.Add(time.Duration(rng.Int63n(int64(365 * 24 * time.Hour)))).Format("2006-01-02 15:04:05"),
+			strconv.FormatFloat(rng.Float64()*1000, 'f', 2, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return stats, fmt.Errorf("failed to write record %d: %w", i, err)
+		}
+		stats.Rows++
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return stats, fmt.Errorf("failed to flush output: %w", err)
+	}
+	return stats, nil
+}
+
+// GenerateFile writes a synthetic CSV dataset to a file
+func GenerateFile(filename string, opts Options, overwrite bool) (Stats, error) {
+	if err := opts.Validate(); err != nil {
+		return Stats{}, err
+	}
+	if _, err := os.Stat(filename); err == nil && !overwrite {
+		return Stats{}, fmt.Errorf("output file %s already exists (use overwrite option to replace)", filename)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to create output file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	stats, err := Generate(buffered, opts)
+	if err != nil {
+		return stats, err
+	}
+	if err := buffered.Flush(); err != nil {
+		return stats, fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return stats, file.Close()
+}
+
+// randomPoint returns a uniformly distributed point within the bounding box (or the whole globe)
+func randomPoint(rng *rand.Rand, bbox *validator.BoundingBox) (float64, float64) {
+	if bbox == nil {
+		bbox = &validator.BoundingBox{MinLng: -180, MinLat: -90, MaxLng: 180, MaxLat: 90}
+	}
+
+	width := bbox.MaxLng - bbox.MinLng
+	if width < 0 {
+		width += 360 // Box crossing the antimeridian
+	}
+	lng := bbox.MinLng + rng.Float64()*width
+	if lng > 180 {
+		lng -= 360
+	}
+	lat := bbox.MinLat + rng.Float64()*(bbox.MaxLat-bbox.MinLat)
+	return lat, lng
+}
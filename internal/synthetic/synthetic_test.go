@@ -0,0 +1,88 @@
+package synthetic
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"testing"
+
+	"csv-h3-tool/internal/validator"
+)
+
+func TestGenerate(t *testing.T) {
+	bbox := &validator.BoundingBox{MinLng: -125, MinLat: 24, MaxLng: -66, MaxLat: 50}
+	opts := Options{Rows: 1000, BBox: bbox, InvalidRate: 0.05, Seed: 42}
+
+	var buf bytes.Buffer
+	stats, err := Generate(&buf, opts)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if stats.Rows != 1000 || stats.InvalidRows != 50 {
+		t.Errorf("Expected 1000 rows with 50 invalid, got %+v", stats)
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse generated CSV: %v", err)
+	}
+	if len(rows) != 1001 {
+		t.Fatalf("Expected header and 1000 rows, got %d rows", len(rows))
+	}
+
+	coordinateValidator := validator.NewCoordinateValidator()
+	invalid := 0
+	for _, row := range rows[1:] {
+		lat, latErr := strconv.ParseFloat(row[1], 64)
+		lng, lngErr := strconv.ParseFloat(row[2], 64)
+		if latErr != nil || lngErr != nil || coordinateValidator.ValidateCoordinates(lat, lng) != nil {
+			invalid++
+			continue
+		}
+		if !bbox.Contains(lat, lng) {
+			t.Errorf("Valid point (%f, %f) outside bounding box", lat, lng)
+		}
+	}
+	if invalid != 50 {
+		t.Errorf("Expected 50 rows with invalid coordinates, found %d", invalid)
+	}
+
+	// The same options produce the same data
+	var again bytes.Buffer
+	if _, err := Generate(&again, opts); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("Expected identical output for the same seed")
+	}
+}
+
+func TestGenerateAntimeridianBBox(t *testing.T) {
+	bbox := &validator.BoundingBox{MinLng: 170, MinLat: -20, MaxLng: -170, MaxLat: -10}
+
+	var buf bytes.Buffer
+	if _, err := Generate(&buf, Options{Rows: 200, BBox: bbox}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	rows, _ := csv.NewReader(&buf).ReadAll()
+	for _, row := range rows[1:] {
+		lat, _ := strconv.ParseFloat(row[1], 64)
+		lng, _ := strconv.ParseFloat(row[2], 64)
+		if !bbox.Contains(lat, lng) {
+			t.Errorf("Point (%f, %f) outside antimeridian bounding box", lat, lng)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	invalid := []Options{
+		{Rows: -1},
+		{Rows: 10, InvalidRate: -0.1},
+		{Rows: 10, InvalidRate: 1.5},
+	}
+	for _, opts := range invalid {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", opts)
+		}
+	}
+}
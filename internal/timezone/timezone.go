@@ -0,0 +1,183 @@
+package timezone
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	"csv-h3-tool/internal/geo"
+
+	"github.com/uber/h3-go/v4"
+)
+
+// embeddedZones holds coarse time zone outlines compiled into the binary
+//
+//go:embed zones.json
+var embeddedZones []byte
+
+// Index is a coarse H3-based lookup of IANA time zones. Zone outlines are converted
+// to H3 cells on first use, so most lookups are a single map access; points in cells
+// shared by several zones are resolved against the outlines themselves, and points
+// outside every zone get the nautical Etc/GMT zone for their longitude.
+type Index struct {
+	resolution int
+	zones      []zone
+
+	once  sync.Once
+	cells map[h3.Cell][]int // Cell -> indexes of overlapping zones, in file order
+	err   error
+}
+
+// zone is a named time zone outline
+type zone struct {
+	name  string
+	rings [][][2]float64 // Rings of [lng, lat] vertices
+}
+
+// indexFile is the JSON representation of a time zone index
+type indexFile struct {
+	Resolution int `json:"resolution"`
+	Zones      []struct {
+		Zone  string         `json:"zone"`
+		Rings [][][2]float64 `json:"rings"`
+	} `json:"zones"`
+}
+
+var (
+	defaultIndex     *Index
+	defaultIndexErr  error
+	defaultIndexOnce sync.Once
+)
+
+// DefaultIndex returns the time zone index embedded in the binary
+func DefaultIndex() (*Index, error) {
+	defaultIndexOnce.Do(func() {
+		defaultIndex, defaultIndexErr = LoadIndex(embeddedZones)
+	})
+	return defaultIndex, defaultIndexErr
+}
+
+// LoadIndex parses a time zone index from JSON. Zones are matched in file order,
+// so more specific zones must come before the zones they overlap.
+func LoadIndex(data []byte) (*Index, error) {
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse time zone index: %w", err)
+	}
+	if file.Resolution < 0 || file.Resolution > 15 {
+		return nil, fmt.Errorf("time zone index resolution %d is out of valid range [0, 15]", file.Resolution)
+	}
+
+	zones := make([]zone, 0, len(file.Zones))
+	for _, z := range file.Zones {
+		if z.Zone == "" {
+			return nil, fmt.Errorf("time zone index contains a zone without a name")
+		}
+		for i, ring := range z.Rings {
+			if len(ring) < 3 {
+				return nil, fmt.Errorf("zone %s ring %d has fewer than 3 vertices", z.Zone, i)
+			}
+		}
+		zones = append(zones, zone{name: z.Zone, rings: z.Rings})
+	}
+
+	return &Index{resolution: file.Resolution, zones: zones}, nil
+}
+
+// Zones returns the names of all zones in the index, in match order
+func (x *Index) Zones() []string {
+	names := make([]string, len(x.zones))
+	for i, z := range x.zones {
+		names[i] = z.name
+	}
+	return names
+}
+
+// Lookup returns the IANA time zone for the coordinates
+func (x *Index) Lookup(lat, lng float64) (string, error) {
+	cells, err := x.cellIndex()
+	if err != nil {
+		return "", err
+	}
+
+	cell, err := h3.LatLngToCell(h3.NewLatLng(lat, lng), x.resolution)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate H3 cell for (%.6f, %.6f): %w", lat, lng, err)
+	}
+
+	candidates := cells[cell]
+	switch len(candidates) {
+	case 0:
+		return Nautical(lng), nil
+	case 1:
+		return x.zones[candidates[0]].name, nil
+	}
+
+	// Border cell: use the first zone whose outline contains the point
+	for _, i := range candidates {
+		if x.zones[i].contains(lat, lng) {
+			return x.zones[i].name, nil
+		}
+	}
+	return x.zones[candidates[0]].name, nil
+}
+
+// cellIndex returns the cell -> zones map, computing it on first use
+func (x *Index) cellIndex() (map[h3.Cell][]int, error) {
+	x.once.Do(func() {
+		cells := make(map[h3.Cell][]int)
+		for i, z := range x.zones {
+			seen := make(map[h3.Cell]struct{})
+			for _, ring := range z.rings {
+				loop := make(h3.GeoLoop, len(ring))
+				for j, vertex := range ring {
+					loop[j] = h3.NewLatLng(vertex[1], vertex[0])
+				}
+				covering, err := h3.PolygonToCellsExperimental(h3.GeoPolygon{GeoLoop: loop}, x.resolution, h3.ContainmentOverlapping)
+				if err != nil {
+					x.err = fmt.Errorf("failed to convert zone %s to H3 cells: %w", z.name, err)
+					return
+				}
+				for _, cell := range covering {
+					if _, ok := seen[cell]; !ok {
+						seen[cell] = struct{}{}
+						cells[cell] = append(cells[cell], i)
+					}
+				}
+			}
+		}
+		x.cells = cells
+	})
+	return x.cells, x.err
+}
+
+// contains reports whether the point falls inside any ring of the zone outline
+func (z zone) contains(lat, lng float64) bool {
+	for _, ring := range z.rings {
+		if geo.RingContains(ring, lat, lng) {
+			return true
+		}
+	}
+	return false
+}
+
+// Nautical returns the nautical time zone for a longitude, e.g. "Etc/GMT+5" at -75.
+// Etc/GMT zones use POSIX sign conventions: zones west of Greenwich have positive offsets.
+func Nautical(lng float64) string {
+	offset := int(math.Round(lng / 15))
+	if offset > 12 {
+		offset = 12
+	} else if offset < -12 {
+		offset = -12
+	}
+	switch {
+	case offset > 0:
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	case offset < 0:
+		return fmt.Sprintf("Etc/GMT+%d", -offset)
+	default:
+		return "Etc/GMT"
+	}
+}
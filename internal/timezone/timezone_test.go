@@ -0,0 +1,107 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestDefaultIndex_Lookup(t *testing.T) {
+	index, err := DefaultIndex()
+	if err != nil {
+		t.Fatalf("DefaultIndex() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		want     string
+	}{
+		{"New York", 40.7128, -74.0060, "America/New_York"},
+		{"Chicago", 41.8781, -87.6298, "America/Chicago"},
+		{"Denver", 39.7392, -104.9903, "America/Denver"},
+		{"Phoenix", 33.4484, -112.0740, "America/Phoenix"},
+		{"Los Angeles", 34.0522, -118.2437, "America/Los_Angeles"},
+		{"Anchorage", 61.2181, -149.9003, "America/Anchorage"},
+		{"Honolulu", 21.3069, -157.8583, "Pacific/Honolulu"},
+		{"Toronto", 43.6532, -79.3832, "America/Toronto"},
+		{"Vancouver", 49.2827, -123.1207, "America/Vancouver"},
+		{"Mexico City", 19.4326, -99.1332, "America/Mexico_City"},
+		{"Sao Paulo", -23.5505, -46.6333, "America/Sao_Paulo"},
+		{"London", 51.5074, -0.1278, "Europe/London"},
+		{"Paris", 48.8566, 2.3522, "Europe/Paris"},
+		{"Berlin", 52.5200, 13.4050, "Europe/Berlin"},
+		{"Mumbai", 19.0760, 72.8777, "Asia/Kolkata"},
+		{"Tokyo", 35.6762, 139.6503, "Asia/Tokyo"},
+		{"Perth", -31.9505, 115.8605, "Australia/Perth"},
+		{"Sydney", -33.8688, 151.2093, "Australia/Sydney"},
+		{"Mid-Atlantic", 30.0, -40.0, "Etc/GMT+3"},
+		{"Null Island", 0, 0, "Etc/GMT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := index.Lookup(tt.lat, tt.lng)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Lookup(%v, %v) = %s, want %s", tt.lat, tt.lng, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultIndex_ValidZoneNames(t *testing.T) {
+	index, err := DefaultIndex()
+	if err != nil {
+		t.Fatalf("DefaultIndex() error = %v", err)
+	}
+	for _, name := range index.Zones() {
+		if _, err := time.LoadLocation(name); err != nil {
+			t.Errorf("zone %s is not a valid IANA time zone: %v", name, err)
+		}
+	}
+}
+
+func TestNautical(t *testing.T) {
+	tests := []struct {
+		lng  float64
+		want string
+	}{
+		{0, "Etc/GMT"},
+		{-75, "Etc/GMT+5"},
+		{139.7, "Etc/GMT-9"},
+		{7.4, "Etc/GMT"},
+		{7.6, "Etc/GMT-1"},
+		{180, "Etc/GMT-12"},
+		{-180, "Etc/GMT+12"},
+	}
+	for _, tt := range tests {
+		if got := Nautical(tt.lng); got != tt.want {
+			t.Errorf("Nautical(%v) = %s, want %s", tt.lng, got, tt.want)
+		}
+		if _, err := time.LoadLocation(Nautical(tt.lng)); err != nil {
+			t.Errorf("Nautical(%v) is not a valid zone: %v", tt.lng, err)
+		}
+	}
+}
+
+func TestLoadIndex_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"invalid JSON", `{`},
+		{"bad resolution", `{"resolution": 16, "zones": []}`},
+		{"unnamed zone", `{"resolution": 3, "zones": [{"rings": [[[0,0],[1,0],[1,1]]]}]}`},
+		{"short ring", `{"resolution": 3, "zones": [{"zone": "Etc/UTC", "rings": [[[0,0],[1,0]]]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadIndex([]byte(tt.data)); err == nil {
+				t.Error("LoadIndex() should error")
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package trajectory
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/uber/h3-go/v4"
+)
+
+// StayDetector finds stays: runs of consecutive points of an entity that remain near
+// the run's first point for at least a minimum duration. Nearness is decided on the H3
+// grid, at the coarsest resolution whose cell spacing fits the radius: a point is near
+// when its cell is within the radius in grid steps of the first point's cell.
+//
+// Whether a point is part of a stay is only known once its run lasts long enough or
+// ends, so labels are returned as they are decided rather than per point.
+type StayDetector struct {
+	resolution  int
+	steps       int // Grid steps within the radius
+	minDuration time.Duration
+	entities    map[string]*stayRun
+	stays       int
+}
+
+// stayRun is the current run of nearby points of one entity
+type stayRun struct {
+	anchor  h3.Cell
+	start   time.Time
+	last    time.Time
+	pending []int  // Tokens of the run's points not yet labeled
+	stay    string // Stay ID once the run lasted the minimum duration
+	count   int    // Stays of the entity so far
+}
+
+// StayLabel is the decided label of a point: its stay ID, or "" when it is moving
+type StayLabel struct {
+	Token  int
+	StayID string
+}
+
+// NewStayDetector creates a detector for stays of at least minDuration within
+// radiusMeters of their first point
+func NewStayDetector(radiusMeters float64, minDuration time.Duration) (*StayDetector, error) {
+	if radiusMeters <= 0 {
+		return nil, fmt.Errorf("stay radius must be positive: %g", radiusMeters)
+	}
+	for resolution := 0; resolution <= 15; resolution++ {
+		edge, err := h3.HexagonEdgeLengthAvgM(resolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get average edge length for resolution %d: %w", resolution, err)
+		}
+		// Neighboring cell centers are sqrt(3) edge lengths apart
+		spacing := math.Sqrt(3) * edge
+		if spacing <= radiusMeters || resolution == 15 {
+			return &StayDetector{
+				resolution:  resolution,
+				steps:       int(radiusMeters / spacing),
+				minDuration: minDuration,
+				entities:    make(map[string]*stayRun),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no H3 resolution fits stay radius %gm", radiusMeters)
+}
+
+// Add records the point of an entity identified by token and returns the labels
+// decided by it, which may include earlier points of the entity
+func (d *StayDetector) Add(token int, id string, t time.Time, lat, lng float64) ([]StayLabel, error) {
+	cell, err := h3.LatLngToCell(h3.NewLatLng(lat, lng), d.resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index point at (%g, %g): %w", lat, lng, err)
+	}
+	run := d.entities[id]
+	if run == nil {
+		run = &stayRun{}
+		d.entities[id] = run
+	}
+
+	var labels []StayLabel
+	if run.anchor == 0 || t.Before(run.last) || !d.near(run.anchor, cell) {
+		labels = run.resolve()
+		run.anchor, run.start, run.stay = cell, t, ""
+	}
+	run.last = t
+	run.pending = append(run.pending, token)
+	if run.stay == "" && t.Sub(run.start) >= d.minDuration {
+		run.count++
+		d.stays++
+		run.stay = id + "-stay-" + strconv.Itoa(run.count)
+	}
+	if run.stay != "" {
+		labels = append(labels, run.resolve()...)
+	}
+	return labels, nil
+}
+
+// near reports whether a cell is within the radius of the anchor cell in grid steps.
+// Cells the grid distance cannot be computed for, e.g. across pentagons, are not near.
+func (d *StayDetector) near(anchor, cell h3.Cell) bool {
+	distance, err := anchor.GridDistance(cell)
+	return err == nil && distance <= d.steps
+}
+
+// resolve labels the pending points of a run: in the run's stay, or moving when the
+// run has not become a stay
+func (r *stayRun) resolve() []StayLabel {
+	labels := make([]StayLabel, len(r.pending))
+	for i, token := range r.pending {
+		labels[i] = StayLabel{Token: token, StayID: r.stay}
+	}
+	r.pending = r.pending[:0]
+	return labels
+}
+
+// Release labels the pending points of an entity's current run without waiting for the
+// run to end: moving unless the run already became a stay. Later points of the run are
+// labeled as usual, so points of a run that becomes a stay afterwards stay moving.
+func (d *StayDetector) Release(id string) []StayLabel {
+	if run := d.entities[id]; run != nil {
+		return run.resolve()
+	}
+	return nil
+}
+
+// Finish labels the points still pending at the end of the input, whose runs ended
+// too soon to be stays
+func (d *StayDetector) Finish() []StayLabel {
+	var labels []StayLabel
+	for _, run := range d.entities {
+		labels = append(labels, run.resolve()...)
+	}
+	return labels
+}
+
+// Stays returns the number of stays detected so far across all entities
+func (d *StayDetector) Stays() int {
+	return d.stays
+}
@@ -0,0 +1,142 @@
+// Package trajectory implements stateful stages over GPS traces: rows carry an entity
+// id and a timestamp, and the rows of each entity are expected in time order, though
+// the traces of different entities may be interleaved.
+package trajectory
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"csv-h3-tool/internal/geo"
+)
+
+// timeLayouts are the timestamp formats accepted by ParseTime, in the order tried;
+// fractional seconds are accepted after the seconds of any of them
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// ParseTime parses a timestamp in RFC 3339 or "2006-01-02 15:04:05" form (without a
+// zone taken as UTC), or a Unix epoch in seconds, or milliseconds from 1e12
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty timestamp")
+	}
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(epoch, 0) && !math.IsNaN(epoch) {
+		if math.Abs(epoch) >= 1e12 {
+			return time.UnixMilli(int64(epoch)).UTC(), nil
+		}
+		seconds, fraction := math.Modf(epoch)
+		return time.Unix(int64(seconds), int64(fraction*1e9)).UTC(), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// Segmenter splits the trace of each entity into trips wherever consecutive points are
+// more than a gap apart in time, or time steps backwards
+type Segmenter struct {
+	gap      time.Duration
+	entities map[string]*segmentState
+	trips    int
+}
+
+// segmentState is the current trip of one entity
+type segmentState struct {
+	last time.Time
+	trip int
+}
+
+// NewSegmenter creates a segmenter starting a new trip after gaps longer than gap
+func NewSegmenter(gap time.Duration) *Segmenter {
+	return &Segmenter{gap: gap, entities: make(map[string]*segmentState)}
+}
+
+// Assign returns the trip ID of an entity's point at time t, "<id>-<n>" for the
+// entity's n-th trip (counting from 1)
+func (s *Segmenter) Assign(id string, t time.Time) string {
+	state := s.entities[id]
+	if state == nil {
+		state = &segmentState{}
+		s.entities[id] = state
+	}
+	if state.trip == 0 || t.Sub(state.last) > s.gap || t.Before(state.last) {
+		state.trip++
+		s.trips++
+	}
+	state.last = t
+	return id + "-" + strconv.Itoa(state.trip)
+}
+
+// Trips returns the number of trips started so far across all entities
+func (s *Segmenter) Trips() int {
+	return s.trips
+}
+
+// MaxRejectedPoints is the number of consecutive points of an entity the SpeedFilter
+// rejects before it takes the next point as the new last plausible point, so a glitch
+// that was accepted, e.g. a bad first fix, cannot reject the rest of the track
+const MaxRejectedPoints = 3
+
+// SameTimeToleranceKm is the distance two points of an entity at the same time may be
+// apart and still be plausible, allowing for the jitter of fixes reported together
+const SameTimeToleranceKm = 0.05
+
+// SpeedFilter finds points implying impossible speeds: each point of an entity is
+// compared to the entity's last plausible point, so a single jump is rejected without
+// also rejecting the point that returns to the true track
+type SpeedFilter struct {
+	maxKmh float64
+	last   map[string]tracePoint
+}
+
+// tracePoint is the last plausible point of an entity
+type tracePoint struct {
+	t        time.Time
+	lat, lng float64
+	rejected int // Consecutive points rejected since
+}
+
+// NewSpeedFilter creates a filter rejecting speeds above maxKmh
+func NewSpeedFilter(maxKmh float64) *SpeedFilter {
+	return &SpeedFilter{maxKmh: maxKmh, last: make(map[string]tracePoint)}
+}
+
+// Check returns the speed from the entity's last plausible point to this one and
+// whether it is plausible. The first point of an entity is always plausible; points
+// at the same time as the last one, or earlier, are plausible only within
+// SameTimeToleranceKm of it. After MaxRejectedPoints consecutive rejections the next
+// point is plausible again and becomes the point later ones are compared to.
+func (f *SpeedFilter) Check(id string, t time.Time, lat, lng float64) (speedKmh float64, plausible bool) {
+	last, seen := f.last[id]
+	if seen {
+		distance := geo.DistanceKm(last.lat, last.lng, lat, lng)
+		hours := t.Sub(last.t).Hours()
+		switch {
+		case hours > 0:
+			speedKmh = distance / hours
+		case distance > SameTimeToleranceKm:
+			speedKmh = math.Inf(1)
+		}
+		if speedKmh > f.maxKmh && last.rejected < MaxRejectedPoints {
+			last.rejected++
+			f.last[id] = last
+			return speedKmh, false
+		}
+	}
+	f.last[id] = tracePoint{t: t, lat: lat, lng: lng}
+	return speedKmh, true
+}
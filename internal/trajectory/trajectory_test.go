@@ -0,0 +1,159 @@
+package trajectory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	for _, value := range []string{
+		"2024-05-01T12:30:15Z",
+		"2024-05-01T14:30:15+02:00",
+		"2024-05-01 12:30:15",
+		"2024-05-01T12:30:15",
+		" 1714566615 ",
+		"1714566615000",
+	} {
+		got, err := ParseTime(value)
+		if err != nil {
+			t.Errorf("ParseTime(%q) failed: %v", value, err)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("ParseTime(%q) = %v, expected %v", value, got, expected)
+		}
+	}
+	if got, err := ParseTime("2024-05-01 12:30:15.250"); err != nil || got.Nanosecond() != 250000000 {
+		t.Errorf("Expected fractional seconds to be parsed, got %v, %v", got, err)
+	}
+	for _, value := range []string{"", "yesterday", "2024-13-01 00:00:00"} {
+		if _, err := ParseTime(value); err == nil {
+			t.Errorf("Expected ParseTime(%q) to fail", value)
+		}
+	}
+}
+
+func TestSegmenter(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	segmenter := NewSegmenter(10 * time.Minute)
+	points := []struct {
+		id      string
+		minutes int
+		trip    string
+	}{
+		{"a", 0, "a-1"},
+		{"b", 1, "b-1"},
+		{"a", 5, "a-1"},
+		{"a", 15, "a-1"}, // Exactly the gap apart
+		{"b", 30, "b-2"},
+		{"a", 26, "a-2"},
+		{"a", 20, "a-3"}, // Time steps backwards
+	}
+	for _, point := range points {
+		if trip := segmenter.Assign(point.id, start.Add(time.Duration(point.minutes)*time.Minute)); trip != point.trip {
+			t.Errorf("Point %s at +%dm: expected trip %s, got %s", point.id, point.minutes, point.trip, trip)
+		}
+	}
+	if segmenter.Trips() != 5 {
+		t.Errorf("Expected 5 trips, got %d", segmenter.Trips())
+	}
+}
+
+func TestSpeedFilter(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	filter := NewSpeedFilter(200)
+	points := []struct {
+		id        string
+		minutes   int
+		lat, lng  float64
+		plausible bool
+	}{
+		{"a", 0, 40.7128, -74.0060, true},
+		{"b", 0, 51.5074, -0.1278, true},    // Entities are checked separately
+		{"a", 1, 51.5074, -0.1278, false},   // Across the Atlantic in a minute
+		{"a", 10, 40.7200, -74.0000, true},  // Compared to the last plausible point
+		{"a", 10, 40.7300, -74.0000, false}, // Moved without time passing
+		{"b", 60, 51.6000, -0.1278, true},
+	}
+	for _, point := range points {
+		_, plausible := filter.Check(point.id, start.Add(time.Duration(point.minutes)*time.Minute), point.lat, point.lng)
+		if plausible != point.plausible {
+			t.Errorf("Point %s at +%dm (%g, %g): expected plausible=%v", point.id, point.minutes, point.lat, point.lng, point.plausible)
+		}
+	}
+}
+
+func TestSpeedFilter_Recovery(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	filter := NewSpeedFilter(200)
+
+	// A bad first fix in London followed by the true track in New York: the track is
+	// rejected until MaxRejectedPoints points have been, then followed again
+	if _, plausible := filter.Check("a", start, 51.5074, -0.1278); !plausible {
+		t.Fatal("Expected the first point to be plausible")
+	}
+	for i := 1; i <= MaxRejectedPoints+2; i++ {
+		lat := 40.7128 + float64(i)*0.001
+		_, plausible := filter.Check("a", start.Add(time.Duration(i)*time.Minute), lat, -74.0060)
+		if expected := i > MaxRejectedPoints; plausible != expected {
+			t.Errorf("Point %d: expected plausible=%v", i, expected)
+		}
+	}
+
+	// Fixes at the same time a few meters apart are plausible
+	at := start.Add(time.Hour)
+	if _, plausible := filter.Check("b", at, 40.7128, -74.0060); !plausible {
+		t.Fatal("Expected the first point to be plausible")
+	}
+	if speed, plausible := filter.Check("b", at, 40.71281, -74.00601); !plausible || speed != 0 {
+		t.Errorf("Expected a same-time point within the tolerance to be plausible at 0 km/h, got %g, %v", speed, plausible)
+	}
+}
+
+func TestStayDetector(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	detector, err := NewStayDetector(100, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewStayDetector failed: %v", err)
+	}
+	points := []struct {
+		id       string
+		minutes  int
+		lat, lng float64
+	}{
+		{"a", 0, 40.7128, -74.0060},  // 0: stay starts
+		{"a", 5, 40.7129, -74.0061},  // 1
+		{"b", 5, 51.5074, -0.1278},   // 2: b never stays
+		{"a", 12, 40.7128, -74.0060}, // 3: 12 minutes, the run becomes a stay
+		{"a", 13, 40.7128, -74.0059}, // 4: labeled right away
+		{"a", 20, 40.7300, -74.0000}, // 5: moving away
+		{"a", 22, 40.7500, -73.9900}, // 6
+	}
+	labels := make(map[int]string)
+	for token, point := range points {
+		decided, err := detector.Add(token, point.id, start.Add(time.Duration(point.minutes)*time.Minute), point.lat, point.lng)
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		for _, label := range decided {
+			labels[label.Token] = label.StayID
+		}
+	}
+	if len(labels) != 5 {
+		t.Errorf("Expected 5 points labeled before the end of the input, got %v", labels)
+	}
+	for _, label := range detector.Finish() {
+		labels[label.Token] = label.StayID
+	}
+
+	expected := []string{"a-stay-1", "a-stay-1", "", "a-stay-1", "a-stay-1", "", ""}
+	for token, stay := range expected {
+		if got, ok := labels[token]; !ok || got != stay {
+			t.Errorf("Point %d: expected stay %q, got %q (labeled %v)", token, stay, got, ok)
+		}
+	}
+	if detector.Stays() != 1 {
+		t.Errorf("Expected 1 stay, got %d", detector.Stays())
+	}
+}
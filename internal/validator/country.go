@@ -0,0 +1,107 @@
+package validator
+
+import (
+	"fmt"
+	"math"
+
+	"csv-h3-tool/internal/geo"
+
+	"github.com/uber/h3-go/v4"
+)
+
+// Policies for points in H3 cells that straddle a country border
+const (
+	AmbiguousNearest = "nearest" // Use the country whose outline is closest to the point
+	AmbiguousEmpty   = "empty"   // Leave the country code empty
+)
+
+// CountryLookup resolves coordinates to ISO country codes using the coarse region set
+type CountryLookup struct {
+	set     *RegionSet
+	nearest bool
+}
+
+// NewCountryLookup creates a country lookup with the given border policy ("" = nearest).
+// A nil region set selects the embedded default.
+func NewCountryLookup(onAmbiguous string, set *RegionSet) (*CountryLookup, error) {
+	lookup := &CountryLookup{set: set}
+	switch onAmbiguous {
+	case "", AmbiguousNearest:
+		lookup.nearest = true
+	case AmbiguousEmpty:
+	default:
+		return nil, fmt.Errorf("invalid ambiguity policy %q: expected %s or %s", onAmbiguous, AmbiguousNearest, AmbiguousEmpty)
+	}
+
+	if lookup.set == nil {
+		var err error
+		if lookup.set, err = DefaultRegionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return lookup, nil
+}
+
+// Lookup returns the country code for the coordinates, or "" outside every known country.
+// ambiguous reports whether the point's cell overlaps more than one country.
+func (l *CountryLookup) Lookup(lat, lng float64) (code string, ambiguous bool, err error) {
+	cell, err := h3.LatLngToCell(h3.NewLatLng(lat, lng), l.set.resolution)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to locate H3 cell for (%.6f, %.6f): %w", lat, lng, err)
+	}
+
+	var candidates []string
+	for _, country := range l.set.countries() {
+		cells, err := l.set.regionCells(country)
+		if err != nil {
+			return "", false, err
+		}
+		if _, ok := cells[cell]; ok {
+			candidates = append(candidates, country)
+		}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return "", false, nil
+	case len(candidates) == 1:
+		return candidates[0], false, nil
+	case !l.nearest:
+		return "", true, nil
+	}
+
+	best, bestDistance := "", math.Inf(1)
+	for _, country := range candidates {
+		if distance := l.set.distance(country, lat, lng); distance < bestDistance {
+			best, bestDistance = country, distance
+		}
+	}
+	return best, true, nil
+}
+
+// distance returns the approximate distance in degrees from a point to a region outline,
+// or 0 when the point is inside it
+func (s *RegionSet) distance(region string, lat, lng float64) float64 {
+	scale := math.Cos(lat * math.Pi / 180) // Shrink longitude differences away from the equator
+	best := math.Inf(1)
+	for _, ring := range s.outlines[region] {
+		if geo.RingContains(ring, lat, lng) {
+			return 0
+		}
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			xi, yi := ring[i][0], ring[i][1]
+			xj, yj := ring[j][0], ring[j][1]
+			best = math.Min(best, segmentDistance((lng-xi)*scale, lat-yi, (xj-xi)*scale, yj-yi))
+		}
+	}
+	return best
+}
+
+// segmentDistance returns the distance from point (px, py) to the segment from the origin to (dx, dy)
+func segmentDistance(px, py, dx, dy float64) float64 {
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, (px*dx+py*dy)/length))
+	}
+	return math.Hypot(px-t*dx, py-t*dy)
+}
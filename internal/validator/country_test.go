@@ -0,0 +1,55 @@
+package validator
+
+import "testing"
+
+func TestCountryLookup(t *testing.T) {
+	nearest, err := NewCountryLookup(AmbiguousNearest, nil)
+	if err != nil {
+		t.Fatalf("NewCountryLookup() error = %v", err)
+	}
+	empty, err := NewCountryLookup(AmbiguousEmpty, nil)
+	if err != nil {
+		t.Fatalf("NewCountryLookup() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		lat, lng  float64
+		nearest   string
+		empty     string
+		ambiguous bool
+	}{
+		{"New York", 40.7128, -74.0060, "US", "US", false},
+		{"London", 51.5074, -0.1278, "GB", "GB", false},
+		{"Tokyo", 35.6762, 139.6503, "JP", "JP", false},
+		{"Strasbourg", 48.58, 7.75, "FR", "", true},
+		{"Karlsruhe", 49.0, 8.2, "DE", "", true},
+		{"Atlantic Ocean", 30.0, -40.0, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ambiguous, err := nearest.Lookup(tt.lat, tt.lng)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if code != tt.nearest || ambiguous != tt.ambiguous {
+				t.Errorf("nearest Lookup() = %q, %v, want %q, %v", code, ambiguous, tt.nearest, tt.ambiguous)
+			}
+
+			code, _, err = empty.Lookup(tt.lat, tt.lng)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if code != tt.empty {
+				t.Errorf("empty Lookup() = %q, want %q", code, tt.empty)
+			}
+		})
+	}
+}
+
+func TestNewCountryLookup_InvalidPolicy(t *testing.T) {
+	if _, err := NewCountryLookup("first", nil); err == nil {
+		t.Error("NewCountryLookup() with invalid policy should error")
+	}
+}
@@ -0,0 +1,122 @@
+package validator
+
+import "fmt"
+
+// DefaultConstantCheckRows is the number of valid rows inspected for constant coordinates
+const DefaultConstantCheckRows = 100
+
+// DataCheck inspects the valid coordinates of a run as a whole, catching data that
+// parses fine row by row but is implausible, e.g. because a wrong column was mapped
+type DataCheck interface {
+	// Name identifies the check in warnings and errors
+	Name() string
+	// Observe records the coordinates of a valid row. It returns true once the check
+	// has reached its verdict and needs no further rows.
+	Observe(lat, lng float64) bool
+	// Finding describes the problem found, or returns "" when the data looks plausible
+	Finding() string
+}
+
+// DataFinding is a problem reported by a data check
+type DataFinding struct {
+	Check   string
+	Message string
+}
+
+func (f DataFinding) String() string {
+	return fmt.Sprintf("%s: %s", f.Check, f.Message)
+}
+
+// DataChecks feeds valid coordinates to a set of data checks until each of them has
+// reached its verdict
+type DataChecks struct {
+	pending []DataCheck
+}
+
+// NewDataChecks creates a stage running the given checks
+func NewDataChecks(checks ...DataCheck) *DataChecks {
+	return &DataChecks{pending: checks}
+}
+
+// Observe feeds a valid coordinate to the checks still running and returns the
+// findings of the checks that concluded with it
+func (d *DataChecks) Observe(lat, lng float64) []DataFinding {
+	var findings []DataFinding
+	remaining := d.pending[:0]
+	for _, check := range d.pending {
+		if !check.Observe(lat, lng) {
+			remaining = append(remaining, check)
+			continue
+		}
+		if message := check.Finding(); message != "" {
+			findings = append(findings, DataFinding{Check: check.Name(), Message: message})
+		}
+	}
+	d.pending = remaining
+	return findings
+}
+
+// Finish returns the findings of the checks still running at the end of the input,
+// e.g. for files with fewer valid rows than a check inspects
+func (d *DataChecks) Finish() []DataFinding {
+	var findings []DataFinding
+	for _, check := range d.pending {
+		if message := check.Finding(); message != "" {
+			findings = append(findings, DataFinding{Check: check.Name(), Message: message})
+		}
+	}
+	d.pending = nil
+	return findings
+}
+
+// ConstantCoordinates reports inputs whose first rows all have the same latitude or the
+// same longitude, which usually means a constant column was mapped as that coordinate
+type ConstantCoordinates struct {
+	rows                 int // Rows to inspect
+	seen                 int
+	lat, lng             float64
+	latVaried, lngVaried bool
+}
+
+// NewConstantCoordinates creates a check over the first rows valid rows
+func NewConstantCoordinates(rows int) *ConstantCoordinates {
+	return &ConstantCoordinates{rows: rows}
+}
+
+// Name identifies the check
+func (c *ConstantCoordinates) Name() string {
+	return "constant_coordinates"
+}
+
+// Observe records a coordinate; the verdict is reached after the configured rows or
+// once both latitude and longitude have varied
+func (c *ConstantCoordinates) Observe(lat, lng float64) bool {
+	if c.seen == 0 {
+		c.lat, c.lng = lat, lng
+	}
+	c.latVaried = c.latVaried || lat != c.lat
+	c.lngVaried = c.lngVaried || lng != c.lng
+	if c.latVaried && c.lngVaried {
+		return true
+	}
+	c.seen++
+	return c.seen >= c.rows
+}
+
+// Finding reports the constant coordinate columns once the configured rows all had
+// the same value in them
+func (c *ConstantCoordinates) Finding() string {
+	if c.seen < c.rows {
+		return ""
+	}
+	switch {
+	case !c.latVaried && !c.lngVaried:
+		return fmt.Sprintf("the first %d valid rows all have coordinates (%g, %g); check the latitude and longitude column mapping",
+			c.seen, c.lat, c.lng)
+	case !c.latVaried:
+		return fmt.Sprintf("the first %d valid rows all have latitude %g; check the latitude column mapping", c.seen, c.lat)
+	case !c.lngVaried:
+		return fmt.Sprintf("the first %d valid rows all have longitude %g; check the longitude column mapping", c.seen, c.lng)
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConstantCoordinates(t *testing.T) {
+	checks := NewDataChecks(NewConstantCoordinates(3))
+	if findings := checks.Observe(40.7, -74.0); len(findings) != 0 {
+		t.Fatalf("Unexpected findings after one row: %v", findings)
+	}
+	checks.Observe(40.7, -74.0)
+	findings := checks.Observe(40.7, -74.0)
+	if len(findings) != 1 || findings[0].Check != "constant_coordinates" {
+		t.Fatalf("Expected a constant_coordinates finding, got %v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "(40.7, -74)") {
+		t.Errorf("Expected the coordinates in the message, got %q", findings[0].Message)
+	}
+	if findings := checks.Observe(40.7, -74.0); len(findings) != 0 {
+		t.Errorf("Expected the check to report once, got %v", findings)
+	}
+}
+
+func TestConstantCoordinatesVaried(t *testing.T) {
+	checks := NewDataChecks(NewConstantCoordinates(3))
+	checks.Observe(40.7, -74.0)
+	if findings := checks.Observe(40.8, -74.1); len(findings) != 0 {
+		t.Errorf("Unexpected findings for varied coordinates: %v", findings)
+	}
+	for i := 0; i < 5; i++ {
+		if findings := checks.Observe(40.7, -74.0); len(findings) != 0 {
+			t.Errorf("Unexpected findings after the verdict: %v", findings)
+		}
+	}
+}
+
+func TestConstantCoordinatesSingleAxis(t *testing.T) {
+	tests := []struct {
+		name     string
+		lats     []float64
+		lngs     []float64
+		expected string
+	}{
+		{"constant latitude", []float64{40.7, 40.7, 40.7}, []float64{-74.0, -74.1, -74.2}, "latitude 40.7; check the latitude column"},
+		{"constant longitude", []float64{40.7, 40.8, 40.9}, []float64{-74.0, -74.0, -74.0}, "longitude -74; check the longitude column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := NewDataChecks(NewConstantCoordinates(3))
+			var findings []DataFinding
+			for i := range tt.lats {
+				findings = checks.Observe(tt.lats[i], tt.lngs[i])
+			}
+			if len(findings) != 1 || !strings.Contains(findings[0].Message, tt.expected) {
+				t.Errorf("Expected a finding containing %q, got %v", tt.expected, findings)
+			}
+		})
+	}
+}
+
+func TestCoarsePrecision(t *testing.T) {
+	// Resolution 12 cells have ~9.4 m edges, which need 5 decimal places
+	if required := RequiredDecimals(9.4); required != 5 {
+		t.Fatalf("Expected 5 required decimal places, got %d", required)
+	}
+
+	checks := NewDataChecks(NewCoarsePrecision(3, 12, 9.4))
+	checks.Observe(40.71, -74.01)
+	checks.Observe(40.7, -74)
+	findings := checks.Observe(40.72, -74.02)
+	if len(findings) != 1 || findings[0].Check != PrecisionCheckName {
+		t.Fatalf("Expected a coordinate_precision finding, got %v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "at most 2 decimal places") || !strings.Contains(findings[0].Message, "resolution 12") {
+		t.Errorf("Unexpected message %q", findings[0].Message)
+	}
+
+	precise := NewDataChecks(NewCoarsePrecision(3, 12, 9.4))
+	precise.Observe(40.71, -74.01)
+	if findings := precise.Observe(40.712812, -74.006015); len(findings) != 0 {
+		t.Errorf("Unexpected findings for precise coordinates: %v", findings)
+	}
+	if findings := precise.Finish(); len(findings) != 0 {
+		t.Errorf("Unexpected findings at the end: %v", findings)
+	}
+}
+
+func TestDataChecksFinish(t *testing.T) {
+	checks := NewDataChecks(NewConstantCoordinates(5), NewCoarsePrecision(5, 12, 9.4))
+	checks.Observe(40.7, -74.0)
+	findings := checks.Finish()
+	if len(findings) != 1 || findings[0].Check != PrecisionCheckName {
+		t.Fatalf("Expected only the precision finding for a short input, got %v", findings)
+	}
+	if findings := checks.Finish(); len(findings) != 0 {
+		t.Errorf("Expected the checks to report once, got %v", findings)
+	}
+}
@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Coordinate precision check modes
+const (
+	PrecisionWarn  = "warn"  // Report coordinates coarser than the resolution as a warning
+	PrecisionError = "error" // Abort the run
+	PrecisionOff   = "off"   // Do not check coordinate precision
+)
+
+// DefaultPrecisionCheckRows is the number of valid rows inspected for coarse coordinates
+const DefaultPrecisionCheckRows = 100
+
+// PrecisionCheckName identifies the coordinate precision check in findings
+const PrecisionCheckName = "coordinate_precision"
+
+// metersPerDegree is the length of one degree of latitude (and of longitude at the equator)
+const metersPerDegree = 111320.0
+
+// ParsePrecisionMode validates a precision check mode; empty means off
+func ParsePrecisionMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return PrecisionOff, nil
+	case PrecisionWarn, PrecisionError, PrecisionOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid precision check %q: expected %s, %s or %s", mode, PrecisionWarn, PrecisionError, PrecisionOff)
+}
+
+// RequiredDecimals returns the number of decimal places of a coordinate in degrees
+// needed to resolve distances of the given length
+func RequiredDecimals(meters float64) int {
+	return max(0, int(math.Ceil(math.Log10(metersPerDegree/meters))))
+}
+
+// DecimalPlaces returns the number of decimal places in the shortest representation of v
+func DecimalPlaces(v float64) int {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// CoarsePrecision reports inputs whose coordinates have fewer decimal places than the
+// H3 resolution resolves, so the indexes suggest more precision than the data has
+type CoarsePrecision struct {
+	rows       int // Rows to inspect
+	resolution int
+	edgeMeters float64 // Average cell edge length at the resolution
+	required   int     // Decimal places needed for the resolution
+	seen       int
+	decimals   int // Most decimal places seen
+	precise    bool
+}
+
+// NewCoarsePrecision creates a check over the first rows valid rows against cells of
+// the given resolution and average edge length
+func NewCoarsePrecision(rows, resolution int, edgeMeters float64) *CoarsePrecision {
+	return &CoarsePrecision{
+		rows:       rows,
+		resolution: resolution,
+		edgeMeters: edgeMeters,
+		required:   RequiredDecimals(edgeMeters),
+	}
+}
+
+// Name identifies the check
+func (c *CoarsePrecision) Name() string {
+	return PrecisionCheckName
+}
+
+// Observe records a coordinate; the verdict is reached after the configured rows or
+// at the first coordinate precise enough for the resolution
+func (c *CoarsePrecision) Observe(lat, lng float64) bool {
+	decimals := max(DecimalPlaces(lat), DecimalPlaces(lng))
+	c.decimals = max(c.decimals, decimals)
+	if decimals >= c.required {
+		c.precise = true
+		return true
+	}
+	c.seen++
+	return c.seen >= c.rows
+}
+
+// Finding reports the coarse coordinates once rows were seen without enough decimal places
+func (c *CoarsePrecision) Finding() string {
+	if c.precise || c.seen == 0 {
+		return ""
+	}
+	return fmt.Sprintf("the first %d valid rows have at most %d decimal places (~%s), but resolution %d cells have ~%s edges (%d decimal places needed); the H3 indexes suggest more precision than the coordinates have",
+		c.seen, c.decimals, formatMeters(metersPerDegree/math.Pow10(c.decimals)), c.resolution, formatMeters(c.edgeMeters), c.required)
+}
+
+// formatMeters formats a distance in meters, or in kilometers from 1 km
+func formatMeters(meters float64) string {
+	if meters >= 1000 {
+		return fmt.Sprintf("%.3g km", meters/1000)
+	}
+	return fmt.Sprintf("%.3g m", meters)
+}
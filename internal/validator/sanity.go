@@ -0,0 +1,223 @@
+package validator
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/uber/h3-go/v4"
+)
+
+// embeddedRegions holds coarse land and country outlines compiled into the binary
+//
+//go:embed regions.json
+var embeddedRegions []byte
+
+// LandRegion is the name of the region covering all land masses
+const LandRegion = "land"
+
+// RegionSet is a coarse H3-based lookup of named regions ("land" and ISO country codes).
+// Region outlines are converted to H3 cells on first use; a point is inside a region
+// when its cell overlaps the region outline.
+type RegionSet struct {
+	resolution int
+	outlines   map[string][][][2]float64 // Region name -> rings of [lng, lat] vertices
+
+	mu    sync.Mutex
+	cells map[string]map[h3.Cell]struct{}
+}
+
+// regionFile is the JSON representation of a region set
+type regionFile struct {
+	Resolution int                       `json:"resolution"`
+	Regions    map[string][][][2]float64 `json:"regions"`
+}
+
+var (
+	defaultRegions     *RegionSet
+	defaultRegionsErr  error
+	defaultRegionsOnce sync.Once
+)
+
+// DefaultRegionSet returns the region set embedded in the binary
+func DefaultRegionSet() (*RegionSet, error) {
+	defaultRegionsOnce.Do(func() {
+		defaultRegions, defaultRegionsErr = LoadRegionSet(embeddedRegions)
+	})
+	return defaultRegions, defaultRegionsErr
+}
+
+// LoadRegionSet parses a region set from JSON
+func LoadRegionSet(data []byte) (*RegionSet, error) {
+	var file regionFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse region set: %w", err)
+	}
+	if file.Resolution < 0 || file.Resolution > 15 {
+		return nil, fmt.Errorf("region set resolution %d is out of valid range [0, 15]", file.Resolution)
+	}
+
+	outlines := make(map[string][][][2]float64, len(file.Regions))
+	for name, rings := range file.Regions {
+		for i, ring := range rings {
+			if len(ring) < 3 {
+				return nil, fmt.Errorf("region %s ring %d has fewer than 3 vertices", name, i)
+			}
+		}
+		outlines[strings.ToUpper(name)] = rings
+	}
+
+	return &RegionSet{
+		resolution: file.Resolution,
+		outlines:   outlines,
+		cells:      make(map[string]map[h3.Cell]struct{}),
+	}, nil
+}
+
+// Regions returns the names of all regions in the set
+func (s *RegionSet) Regions() []string {
+	names := make([]string, 0, len(s.outlines))
+	for name := range s.outlines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasRegion reports whether the set contains a region (case-insensitive)
+func (s *RegionSet) HasRegion(name string) bool {
+	_, ok := s.outlines[strings.ToUpper(name)]
+	return ok
+}
+
+// Contains reports whether the coordinates fall inside the named region
+func (s *RegionSet) Contains(region string, lat, lng float64) (bool, error) {
+	cells, err := s.regionCells(strings.ToUpper(region))
+	if err != nil {
+		return false, err
+	}
+
+	cell, err := h3.LatLngToCell(h3.NewLatLng(lat, lng), s.resolution)
+	if err != nil {
+		return false, fmt.Errorf("failed to locate H3 cell for (%.6f, %.6f): %w", lat, lng, err)
+	}
+	_, ok := cells[cell]
+	return ok, nil
+}
+
+// regionCells returns the H3 cells covering a region, computing them on first use
+func (s *RegionSet) regionCells(region string) (map[h3.Cell]struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cells, ok := s.cells[region]; ok {
+		return cells, nil
+	}
+
+	rings, ok := s.outlines[region]
+	if !ok {
+		return nil, fmt.Errorf("unknown region: %s", region)
+	}
+
+	cells := make(map[h3.Cell]struct{})
+	for _, ring := range rings {
+		loop := make(h3.GeoLoop, len(ring))
+		for i, vertex := range ring {
+			loop[i] = h3.NewLatLng(vertex[1], vertex[0])
+		}
+		covering, err := h3.PolygonToCellsExperimental(h3.GeoPolygon{GeoLoop: loop}, s.resolution, h3.ContainmentOverlapping)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert region %s to H3 cells: %w", region, err)
+		}
+		for _, cell := range covering {
+			cells[cell] = struct{}{}
+		}
+	}
+
+	s.cells[region] = cells
+	return cells, nil
+}
+
+// SanityCheck checks that coordinates fall where they are expected to:
+// on land, on water, or within one of a set of countries
+type SanityCheck struct {
+	spec    string
+	water   bool     // Expect points off land
+	regions []string // Regions the point must fall in (any of)
+	set     *RegionSet
+}
+
+// ParseSanityCheck parses a sanity check spec: "land", "water", or "country:US[,CA...]".
+// A nil region set selects the embedded default.
+func ParseSanityCheck(spec string, set *RegionSet) (*SanityCheck, error) {
+	if set == nil {
+		var err error
+		if set, err = DefaultRegionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	check := &SanityCheck{spec: spec, set: set}
+	mode, arg, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch strings.ToLower(mode) {
+	case "land":
+		check.regions = []string{LandRegion}
+	case "water":
+		check.water = true
+		check.regions = []string{LandRegion}
+	case "country":
+		for _, code := range strings.Split(arg, ",") {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if code == "" {
+				continue
+			}
+			if !set.HasRegion(code) || code == strings.ToUpper(LandRegion) {
+				return nil, fmt.Errorf("unknown country %q (available: %s)", code, strings.Join(set.countries(), ", "))
+			}
+			check.regions = append(check.regions, code)
+		}
+		if len(check.regions) == 0 {
+			return nil, fmt.Errorf("country sanity check requires at least one country code, e.g. country:US")
+		}
+	default:
+		return nil, fmt.Errorf("invalid sanity check %q: expected land, water, or country:<codes>", spec)
+	}
+
+	if arg != "" && strings.ToLower(mode) != "country" {
+		return nil, fmt.Errorf("invalid sanity check %q: %s takes no argument", spec, mode)
+	}
+	return check, nil
+}
+
+// Check reports whether the coordinates pass the sanity check
+func (c *SanityCheck) Check(lat, lng float64) (bool, error) {
+	for _, region := range c.regions {
+		inside, err := c.set.Contains(region, lat, lng)
+		if err != nil {
+			return false, err
+		}
+		if inside {
+			return !c.water, nil
+		}
+	}
+	return c.water, nil
+}
+
+// String returns the sanity check spec
+func (c *SanityCheck) String() string {
+	return c.spec
+}
+
+// countries returns the country codes available in the set
+func (s *RegionSet) countries() []string {
+	var codes []string
+	for _, name := range s.Regions() {
+		if name != strings.ToUpper(LandRegion) {
+			codes = append(codes, name)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,70 @@
+package validator
+
+import "testing"
+
+func TestSanityCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		lat, lng float64
+		expected bool
+	}{
+		{"New York on land", "land", 40.7128, -74.0060, true},
+		{"mid Atlantic not on land", "land", 35.0, -40.0, false},
+		{"mid Pacific on water", "water", 0.0, -140.0, true},
+		{"Berlin not on water", "water", 52.52, 13.405, false},
+		{"Sydney on land", "land", -33.8688, 151.2093, true},
+		{"Nairobi on land", "land", -1.2921, 36.8219, true},
+		{"Denver in US", "country:US", 39.7392, -104.9903, true},
+		{"Anchorage in US", "country:us", 61.2181, -149.9003, true},
+		{"Toronto in US or CA", "country:US,CA", 43.6532, -79.3832, true},
+		{"Paris not in US", "country:US", 48.8566, 2.3522, false},
+		{"Paris in FR", "country:FR", 48.8566, 2.3522, true},
+		{"London in GB", "country:GB", 51.5074, -0.1278, true},
+		{"Gulf of Mexico not in MX", "country:MX", 25.0, -90.0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := ParseSanityCheck(tt.spec, nil)
+			if err != nil {
+				t.Fatalf("ParseSanityCheck(%q) failed: %v", tt.spec, err)
+			}
+			ok, err := check.Check(tt.lat, tt.lng)
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			if ok != tt.expected {
+				t.Errorf("Check(%.4f, %.4f) with %q = %v, expected %v", tt.lat, tt.lng, tt.spec, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSanityCheck_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "ocean", "country:", "country:XX", "country:land", "land:US"} {
+		if _, err := ParseSanityCheck(spec, nil); err == nil {
+			t.Errorf("Expected error for sanity check %q", spec)
+		}
+	}
+}
+
+func TestLoadRegionSet(t *testing.T) {
+	set, err := LoadRegionSet([]byte(`{"resolution": 4, "regions": {"box": [[[0,0],[1,0],[1,1],[0,1]]]}}`))
+	if err != nil {
+		t.Fatalf("LoadRegionSet failed: %v", err)
+	}
+	if inside, err := set.Contains("box", 0.5, 0.5); err != nil || !inside {
+		t.Errorf("Expected point inside box, got %v (err %v)", inside, err)
+	}
+	if inside, _ := set.Contains("box", 5, 5); inside {
+		t.Error("Expected point outside box")
+	}
+	if _, err := set.Contains("missing", 0, 0); err == nil {
+		t.Error("Expected error for unknown region")
+	}
+
+	if _, err := LoadRegionSet([]byte(`{"resolution": 4, "regions": {"bad": [[[0,0],[1,0]]]}}`)); err == nil {
+		t.Error("Expected error for degenerate ring")
+	}
+}
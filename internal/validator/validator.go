@@ -29,9 +29,16 @@ func (e FileError) Error() string {
 	return e.Cause.Error()
 }
 
+// BatchError is the validation error of one coordinate pair in a batch
+type BatchError struct {
+	Index int // Position of the rejected pair in the batch
+	Err   error
+}
+
 // Validator defines the interface for coordinate validation
 type Validator interface {
 	ValidateCoordinates(lat, lng float64) error
+	ValidateBatch(lats, lngs []float64) []BatchError
 	ParseCoordinate(value string) (float64, error)
 }
 
@@ -64,6 +71,21 @@ func (v *CoordinateValidator) ValidateCoordinates(lat, lng float64) error {
 	return nil
 }
 
+// ValidateBatch validates the coordinate pairs (lats[i], lngs[i]) of a batch in a single
+// pass, returning the errors of the rejected pairs in index order (nil when all are valid).
+// lats and lngs must have the same length.
+func (v *CoordinateValidator) ValidateBatch(lats, lngs []float64) []BatchError {
+	var errs []BatchError
+	lngs = lngs[:len(lats)]
+	for i, lat := range lats {
+		lng := lngs[i]
+		if lat < -90.0 || lat > 90.0 || lng < -180.0 || lng > 180.0 {
+			errs = append(errs, BatchError{Index: i, Err: v.ValidateCoordinates(lat, lng)})
+		}
+	}
+	return errs
+}
+
 // ParseCoordinate parses a string coordinate value to float64
 func (v *CoordinateValidator) ParseCoordinate(value string) (float64, error) {
 	// Handle empty or whitespace-only values
@@ -134,4 +156,79 @@ func ParseAndValidateCoordinate(value string, field string) (float64, error) {
 	}
 	
 	return coord, nil
-}
\ No newline at end of file
+}
+
+// BoundingBox represents an expected coordinate region
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// ParseBoundingBox parses a "minLng,minLat,maxLng,maxLat" specification.
+// A minLng greater than maxLng describes a box crossing the antimeridian.
+func ParseBoundingBox(spec string) (*BoundingBox, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 4 {
+		return nil, &ValidationError{
+			Field:   "bbox",
+			Value:   spec,
+			Message: fmt.Sprintf("bounding box must have 4 comma-separated values (minLng,minLat,maxLng,maxLat), got %d", len(parts)),
+		}
+	}
+	
+	values := make([]float64, 4)
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, &ValidationError{
+				Field:   "bbox",
+				Value:   spec,
+				Message: fmt.Sprintf("invalid bounding box value %q: %s", part, err.Error()),
+			}
+		}
+		values[i] = value
+	}
+	
+	bbox := &BoundingBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	
+	if err := ValidateLongitude(bbox.MinLng); err != nil {
+		return nil, err
+	}
+	if err := ValidateLongitude(bbox.MaxLng); err != nil {
+		return nil, err
+	}
+	if err := ValidateLatitude(bbox.MinLat); err != nil {
+		return nil, err
+	}
+	if err := ValidateLatitude(bbox.MaxLat); err != nil {
+		return nil, err
+	}
+	if bbox.MinLat > bbox.MaxLat {
+		return nil, &ValidationError{
+			Field:   "bbox",
+			Value:   spec,
+			Message: fmt.Sprintf("bounding box minLat %.6f is greater than maxLat %.6f", bbox.MinLat, bbox.MaxLat),
+		}
+	}
+	
+	return bbox, nil
+}
+
+// Contains reports whether the coordinates fall inside the bounding box (inclusive)
+func (b *BoundingBox) Contains(lat, lng float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLng <= b.MaxLng {
+		return lng >= b.MinLng && lng <= b.MaxLng
+	}
+	// Box crosses the antimeridian
+	return lng >= b.MinLng || lng <= b.MaxLng
+}
+
+// String returns the bounding box in its command line form
+func (b *BoundingBox) String() string {
+	return fmt.Sprintf("%g,%g,%g,%g", b.MinLng, b.MinLat, b.MaxLng, b.MaxLat)
+}
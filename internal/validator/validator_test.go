@@ -103,6 +103,59 @@ func TestCoordinateValidator_ValidateCoordinates(t *testing.T) {
 	}
 }
 
+func TestCoordinateValidator_ValidateBatch(t *testing.T) {
+	validator := NewCoordinateValidator()
+
+	if errs := validator.ValidateBatch([]float64{40.7128, -90, 90}, []float64{-74.0060, 180, -180}); errs != nil {
+		t.Errorf("Expected no errors for a valid batch, got %v", errs)
+	}
+	if errs := validator.ValidateBatch(nil, nil); errs != nil {
+		t.Errorf("Expected no errors for an empty batch, got %v", errs)
+	}
+
+	lats := []float64{40.7128, 91, 0, -45, -90.5}
+	lngs := []float64{-74.0060, 0, 180.1, 10, -181}
+	errs := validator.ValidateBatch(lats, lngs)
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 errors, got %v", errs)
+	}
+	for i, index := range []int{1, 2, 4} {
+		if errs[i].Index != index {
+			t.Errorf("Error %d: expected index %d, got %d", i, index, errs[i].Index)
+		}
+		expected := validator.ValidateCoordinates(lats[index], lngs[index])
+		if errs[i].Err == nil || errs[i].Err.Error() != expected.Error() {
+			t.Errorf("Error %d: expected %v, got %v", i, expected, errs[i].Err)
+		}
+	}
+}
+
+func BenchmarkValidateCoordinates(b *testing.B) {
+	lats, lngs := benchmarkCoordinates()
+	var validator Validator = NewCoordinateValidator()
+	for i := 0; i < b.N; i++ {
+		for j := range lats {
+			validator.ValidateCoordinates(lats[j], lngs[j])
+		}
+	}
+}
+
+func BenchmarkValidateBatch(b *testing.B) {
+	lats, lngs := benchmarkCoordinates()
+	var validator Validator = NewCoordinateValidator()
+	for i := 0; i < b.N; i++ {
+		validator.ValidateBatch(lats, lngs)
+	}
+}
+
+func benchmarkCoordinates() ([]float64, []float64) {
+	lats, lngs := make([]float64, 1024), make([]float64, 1024)
+	for i := range lats {
+		lats[i], lngs[i] = float64(i%180)-89.5, float64(i%360)-179.5
+	}
+	return lats, lngs
+}
+
 func TestCoordinateValidator_ParseCoordinate(t *testing.T) {
 	validator := NewCoordinateValidator()
 
@@ -467,4 +520,61 @@ func TestFileError(t *testing.T) {
 	if err.Error() != expected {
 		t.Errorf("FileError.Error() = %v, want %v", err.Error(), expected)
 	}
-}
\ No newline at end of file
+}
+
+func TestParseBoundingBox(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expectError bool
+	}{
+		{"valid box", "-125,24,-66,50", false},
+		{"valid box with spaces", " -10.5, 35 , 30 , 60 ", false},
+		{"antimeridian box", "170,-50,-170,-30", false},
+		{"too few values", "-125,24,-66", true},
+		{"non-numeric value", "-125,abc,-66,50", true},
+		{"latitude out of range", "-125,-91,-66,50", true},
+		{"longitude out of range", "-181,24,-66,50", true},
+		{"inverted latitudes", "-125,50,-66,24", true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBoundingBox(tt.spec)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestBoundingBox_Contains(t *testing.T) {
+	usa, _ := ParseBoundingBox("-125,24,-66,50")
+	pacific, _ := ParseBoundingBox("170,-50,-170,-30")
+	
+	tests := []struct {
+		name     string
+		bbox     *BoundingBox
+		lat, lng float64
+		expected bool
+	}{
+		{"inside", usa, 40.7128, -74.0060, true},
+		{"on edge", usa, 24, -125, true},
+		{"swapped coordinates", usa, -74.0060, 40.7128, false},
+		{"outside longitude", usa, 40, 0, false},
+		{"antimeridian east side", pacific, -40, 175, true},
+		{"antimeridian west side", pacific, -40, -175, true},
+		{"antimeridian outside", pacific, -40, 0, false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.bbox.Contains(tt.lat, tt.lng); got != tt.expected {
+				t.Errorf("Contains(%f, %f) = %v, want %v", tt.lat, tt.lng, got, tt.expected)
+			}
+		})
+	}
+}
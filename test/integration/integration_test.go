@@ -402,6 +402,8 @@ func (suite *IntegrationTestSuite) verifyOutputFile(t *testing.T, outputFile, te
 func TestCLIIntegration(t *testing.T) {
 	suite := setupTestSuite(t)
 	defer suite.cleanup()
+	// Keep these runs out of the user's run history
+	t.Setenv("HOME", suite.tempDir)
 
 	tests := []struct {
 		name     string
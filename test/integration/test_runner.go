@@ -187,76 +187,6 @@ func (tr *TestRunner) ValidateOutputFile(t *testing.T, outputPath string, expect
 	return nil
 }
 
-// CompareFiles compares two CSV files and returns differences
-func (tr *TestRunner) CompareFiles(file1, file2 string) ([]string, error) {
-	records1, err := tr.readCSVFile(file1)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file1: %w", err)
-	}
-
-	records2, err := tr.readCSVFile(file2)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file2: %w", err)
-	}
-
-	var differences []string
-
-	// Compare record counts
-	if len(records1) != len(records2) {
-		differences = append(differences, 
-			fmt.Sprintf("Record count differs: file1=%d, file2=%d", len(records1), len(records2)))
-	}
-
-	// Compare records
-	maxRecords := len(records1)
-	if len(records2) > maxRecords {
-		maxRecords = len(records2)
-	}
-
-	for i := 0; i < maxRecords; i++ {
-		if i >= len(records1) {
-			differences = append(differences, fmt.Sprintf("Record %d: missing in file1", i))
-			continue
-		}
-		if i >= len(records2) {
-			differences = append(differences, fmt.Sprintf("Record %d: missing in file2", i))
-			continue
-		}
-
-		record1 := records1[i]
-		record2 := records2[i]
-
-		if len(record1) != len(record2) {
-			differences = append(differences, 
-				fmt.Sprintf("Record %d: column count differs: file1=%d, file2=%d", 
-					i, len(record1), len(record2)))
-			continue
-		}
-
-		for j := 0; j < len(record1); j++ {
-			if record1[j] != record2[j] {
-				differences = append(differences, 
-					fmt.Sprintf("Record %d, Column %d: '%s' != '%s'", 
-						i, j, record1[j], record2[j]))
-			}
-		}
-	}
-
-	return differences, nil
-}
-
-// readCSVFile reads a CSV file and returns all records
-func (tr *TestRunner) readCSVFile(filePath string) ([][]string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	return reader.ReadAll()
-}
-
 // CreateLargeTestFile creates a large CSV file for performance testing
 func (tr *TestRunner) CreateLargeTestFile(name string, numRecords int) (string, error) {
 	filePath := filepath.Join(tr.tempDir, name+".csv")
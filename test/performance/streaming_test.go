@@ -11,6 +11,7 @@ import (
 
 	"csv-h3-tool/internal/config"
 	"csv-h3-tool/internal/service"
+	"csv-h3-tool/internal/synthetic"
 )
 
 // StreamingTestResult captures streaming performance metrics
@@ -487,61 +488,10 @@ func TestStreamingErrorHandlingPerformance(t *testing.T) {
 
 // createErrorTestFile creates a test file with a specified error rate
 func createErrorTestFile(t *testing.T, filePath string, numRecords int, errorRate float64) {
-	file, err := os.Create(filePath)
-	if err != nil {
+	opts := synthetic.Options{Rows: numRecords, InvalidRate: errorRate, Seed: 1}
+	if _, err := synthetic.GenerateFile(filePath, opts, true); err != nil {
 		t.Fatalf("Failed to create error test file: %v", err)
 	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header
-	if err := writer.Write([]string{"id", "latitude", "longitude", "name"}); err != nil {
-		t.Fatalf("Failed to write header: %v", err)
-	}
-
-	errorCount := int(float64(numRecords) * errorRate)
-	errorInterval := numRecords / errorCount
-	if errorInterval == 0 {
-		errorInterval = 1
-	}
-
-	for i := 0; i < numRecords; i++ {
-		var lat, lng string
-		
-		if i%errorInterval == 0 && errorCount > 0 {
-			// Generate invalid coordinate
-			switch i % 4 {
-			case 0:
-				lat, lng = "91.0", "0.0"    // Invalid lat
-			case 1:
-				lat, lng = "0.0", "181.0"   // Invalid lng
-			case 2:
-				lat, lng = "", ""           // Empty
-			case 3:
-				lat, lng = "abc", "xyz"     // Malformed
-			}
-			errorCount--
-		} else {
-			// Generate valid coordinate
-			latVal := float64((i%180)-90) + (float64(i%1000)/1000.0)
-			lngVal := float64((i%360)-180) + (float64(i%1000)/1000.0)
-			lat = fmt.Sprintf("%.6f", latVal)
-			lng = fmt.Sprintf("%.6f", lngVal)
-		}
-
-		record := []string{
-			fmt.Sprintf("ID_%06d", i),
-			lat,
-			lng,
-			fmt.Sprintf("Location_%d", i),
-		}
-
-		if err := writer.Write(record); err != nil {
-			t.Fatalf("Failed to write record %d: %v", i, err)
-		}
-	}
 }
 
 // abs returns the absolute value of an integer